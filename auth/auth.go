@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/akildemir/go-tss/common"
+)
+
+// Role is what the caller of the api is allowed to do, each role can do all the lower roles can
+type Role int
+
+const (
+	// RolePublic is needed by the probes, they can be called without any credential
+	RolePublic Role = iota
+	// RoleRead can query the status, the keys and the metrics of the node
+	RoleRead
+	// RoleSign can run the keysigns as well
+	RoleSign
+	// RoleAdmin can run the keygens and the reshares, and retire and delete the keys as well
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RolePublic: "public",
+	RoleRead:   "read",
+	RoleSign:   "sign",
+	RoleAdmin:  "admin",
+}
+
+// String return the name of the role
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("role(%d)", int(r))
+}
+
+// MarshalText encode the role as its name
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decode the role from its name
+func (r *Role) UnmarshalText(text []byte) error {
+	for k, v := range roleNames {
+		if v == string(text) {
+			*r = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown role %s", text)
+}
+
+var (
+	// ErrUnauthenticated is returned if the caller has no valid credential
+	ErrUnauthenticated = errors.New("the caller is not authenticated")
+	// ErrPermissionDenied is returned if the credential of the caller does not have the role the method needs
+	ErrPermissionDenied = errors.New("the caller does not have the role the method needs")
+)
+
+// APIKey is a static key the callers send in the X-API-Key header, or the x-api-key metadata of gRPC
+type APIKey struct {
+	// Name identifies the caller in the logs, the key itself is never logged
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Role Role   `json:"role"`
+	// Tenants are the committees the caller can use, empty is all of them
+	Tenants []string `json:"tenants,omitempty"`
+}
+
+// Client is a caller authenticated by its client certificate
+type Client struct {
+	// CommonName is the common name of the subject of the client certificate
+	CommonName string `json:"common_name"`
+	Role       Role   `json:"role"`
+	// Tenants are the committees the caller can use, empty is all of them
+	Tenants []string `json:"tenants,omitempty"`
+}
+
+// Config is the authentication of the api, the api is open to everyone if it has neither API keys nor clients
+type Config struct {
+	APIKeys []APIKey `json:"api_keys"`
+	Clients []Client `json:"clients"`
+}
+
+// LoadConfig read the authentication config from the json file
+func LoadConfig(filePath string) (Config, error) {
+	var cfg Config
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return cfg, fmt.Errorf("fail to read the auth config: %w", err)
+	}
+	if err := json.Unmarshal(buf, &cfg); err != nil {
+		return cfg, fmt.Errorf("fail to unmarshal the auth config: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate check the API keys and the clients are unique and have a role
+func (cfg Config) Validate() error {
+	keys := make(map[string]bool, len(cfg.APIKeys))
+	for _, el := range cfg.APIKeys {
+		if len(el.Key) < 16 {
+			return fmt.Errorf("the API key %s is shorter than 16 characters", el.Name)
+		}
+		if el.Role <= RolePublic || el.Role > RoleAdmin {
+			return fmt.Errorf("the API key %s has an invalid role %s", el.Name, el.Role)
+		}
+		if keys[el.Key] {
+			return fmt.Errorf("the API key %s is duplicated", el.Name)
+		}
+		keys[el.Key] = true
+		if err := validateTenants(el.Tenants); err != nil {
+			return fmt.Errorf("the API key %s: %w", el.Name, err)
+		}
+	}
+	clients := make(map[string]bool, len(cfg.Clients))
+	for _, el := range cfg.Clients {
+		if len(el.CommonName) == 0 {
+			return errors.New("the client has no common name")
+		}
+		if el.Role <= RolePublic || el.Role > RoleAdmin {
+			return fmt.Errorf("the client %s has an invalid role %s", el.CommonName, el.Role)
+		}
+		if clients[el.CommonName] {
+			return fmt.Errorf("the client %s is duplicated", el.CommonName)
+		}
+		clients[el.CommonName] = true
+		if err := validateTenants(el.Tenants); err != nil {
+			return fmt.Errorf("the client %s: %w", el.CommonName, err)
+		}
+	}
+	return nil
+}
+
+// validateTenants check the tenants of a caller are valid tenant ids
+func validateTenants(tenants []string) error {
+	for _, el := range tenants {
+		if err := common.ValidateTenantID(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Identity is the caller the credential belongs to
+type Identity struct {
+	Name string
+	Role Role
+	// Tenants are the committees the caller can use, empty is all of them
+	Tenants []string
+}
+
+// CanAccess return true if the caller can use the tenant, the empty tenant is common.DefaultTenant
+func (i Identity) CanAccess(tenant string) bool {
+	if len(i.Tenants) == 0 {
+		return true
+	}
+	tenant = common.TenantOrDefault(tenant)
+	for _, el := range i.Tenants {
+		if el == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKey is the API key we hold, we only keep the hash so it is compared in constant time
+type apiKey struct {
+	name    string
+	hash    [sha256.Size]byte
+	role    Role
+	tenants []string
+}
+
+// Authorizer authenticates the callers of the api with their API keys or client certificates, and checks they
+// have the role the method needs
+type Authorizer struct {
+	apiKeys []apiKey
+	clients map[string]Client
+}
+
+// NewAuthorizer create the authorizer of the config
+func NewAuthorizer(cfg Config) (*Authorizer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	a := &Authorizer{
+		clients: make(map[string]Client, len(cfg.Clients)),
+	}
+	for _, el := range cfg.APIKeys {
+		a.apiKeys = append(a.apiKeys, apiKey{
+			name:    el.Name,
+			hash:    sha256.Sum256([]byte(el.Key)),
+			role:    el.Role,
+			tenants: el.Tenants,
+		})
+	}
+	for _, el := range cfg.Clients {
+		a.clients[el.CommonName] = el
+	}
+	return a, nil
+}
+
+// Enabled return true if the callers need to authenticate
+func (a *Authorizer) Enabled() bool {
+	return a != nil && (len(a.apiKeys) > 0 || len(a.clients) > 0)
+}
+
+// Authenticate return the identity of the API key or the verified client certificate, the one with the higher role
+// wins if the caller has both
+func (a *Authorizer) Authenticate(key string, state *tls.ConnectionState) (Identity, error) {
+	var identity Identity
+	found := false
+	if len(key) != 0 {
+		hash := sha256.Sum256([]byte(key))
+		for _, el := range a.apiKeys {
+			// we check all the keys, so the time does not tell which one matches
+			if subtle.ConstantTimeCompare(hash[:], el.hash[:]) == 1 {
+				identity = Identity{Name: el.name, Role: el.role, Tenants: el.tenants}
+				found = true
+			}
+		}
+	}
+	if cert := verifiedCertificate(state); cert != nil {
+		if client, ok := a.clients[cert.Subject.CommonName]; ok && client.Role > identity.Role {
+			identity = Identity{Name: cert.Subject.CommonName, Role: client.Role, Tenants: client.Tenants}
+			found = true
+		}
+	}
+	if !found {
+		return Identity{}, ErrUnauthenticated
+	}
+	return identity, nil
+}
+
+// Authorize check the caller has the role the method needs, the public methods and the api without any
+// credential configured are open to everyone
+func (a *Authorizer) Authorize(required Role, key string, state *tls.ConnectionState) (Identity, error) {
+	if !a.Enabled() || required == RolePublic {
+		return Identity{Role: RoleAdmin}, nil
+	}
+	identity, err := a.Authenticate(key, state)
+	if err != nil {
+		return identity, err
+	}
+	if identity.Role < required {
+		return identity, fmt.Errorf("%s has the role %s, the method needs %s: %w", identity.Name, identity.Role, required, ErrPermissionDenied)
+	}
+	return identity, nil
+}
+
+// verifiedCertificate return the client certificate of the connection if its chain is verified
+func verifiedCertificate(state *tls.ConnectionState) *x509.Certificate {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// TLSConfig is the certificate the api is served with, and the CA the client certificates are verified with
+type TLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	// RequireClientCert refuses the connections without a verified client certificate, otherwise the callers can
+	// authenticate with an API key instead
+	RequireClientCert bool
+}
+
+// Enabled return true if the api is served over TLS
+func (cfg TLSConfig) Enabled() bool {
+	return len(cfg.CertFile) > 0
+}
+
+// Load return the TLS config of the server, the client certificates are verified if we have the client CA
+func (cfg TLSConfig) Load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load the tls certificate: %w", err)
+	}
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(cfg.ClientCAFile) == 0 {
+		if cfg.RequireClientCert {
+			return nil, errors.New("the client certificates are required without the client CA to verify them")
+		}
+		return tlsConf, nil
+	}
+	buf, err := ioutil.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, errors.New("no certificate in the client CA")
+	}
+	tlsConf.ClientCAs = pool
+	tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+	if cfg.RequireClientCert {
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConf, nil
+}
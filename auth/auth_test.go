@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type AuthTestSuite struct{}
+
+var _ = Suite(&AuthTestSuite{})
+
+func testConfig() Config {
+	return Config{
+		APIKeys: []APIKey{
+			{Name: "reader", Key: "reader-key-0123456789", Role: RoleRead},
+			{Name: "signer", Key: "signer-key-0123456789", Role: RoleSign},
+		},
+		Clients: []Client{
+			{CommonName: "operator", Role: RoleAdmin},
+		},
+	}
+}
+
+// connectionState return the tls connection state with the verified client certificate of the common name
+func connectionState(commonName string) *tls.ConnectionState {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+}
+
+func (s *AuthTestSuite) TestRole(c *C) {
+	buf, err := json.Marshal(Client{CommonName: "operator", Role: RoleAdmin})
+	c.Assert(err, IsNil)
+	c.Assert(string(buf), Equals, `{"common_name":"operator","role":"admin"}`)
+	var client Client
+	c.Assert(json.Unmarshal(buf, &client), IsNil)
+	c.Assert(client.Role, Equals, RoleAdmin)
+	c.Assert(json.Unmarshal([]byte(`{"role":"root"}`), &client), ErrorMatches, "unknown role root")
+}
+
+func (s *AuthTestSuite) TestValidate(c *C) {
+	c.Assert(testConfig().Validate(), IsNil)
+	c.Assert(Config{APIKeys: []APIKey{{Name: "short", Key: "short", Role: RoleRead}}}.Validate(), ErrorMatches, ".*shorter than 16 characters")
+	c.Assert(Config{APIKeys: []APIKey{{Name: "public", Key: "public-key-0123456789"}}}.Validate(), ErrorMatches, ".*invalid role public")
+	cfg := testConfig()
+	cfg.APIKeys = append(cfg.APIKeys, APIKey{Name: "again", Key: "reader-key-0123456789", Role: RoleRead})
+	c.Assert(cfg.Validate(), ErrorMatches, "the API key again is duplicated")
+	c.Assert(Config{Clients: []Client{{Role: RoleRead}}}.Validate(), ErrorMatches, "the client has no common name")
+	c.Assert(Config{Clients: []Client{{CommonName: "operator", Role: RoleRead, Tenants: []string{"BTC"}}}}.Validate(), ErrorMatches, "the client operator: invalid tenant id.*")
+}
+
+func (s *AuthTestSuite) TestLoadConfig(c *C) {
+	filePath := filepath.Join(c.MkDir(), "auth.json")
+	buf, err := json.Marshal(testConfig())
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filePath, buf, 0o600), IsNil)
+	cfg, err := LoadConfig(filePath)
+	c.Assert(err, IsNil)
+	c.Assert(cfg, DeepEquals, testConfig())
+	_, err = LoadConfig(filepath.Join(c.MkDir(), "missing.json"))
+	c.Assert(err, NotNil)
+}
+
+func (s *AuthTestSuite) TestAuthorize(c *C) {
+	// the api without any credential is open to everyone
+	var open *Authorizer
+	c.Assert(open.Enabled(), Equals, false)
+	_, err := open.Authorize(RoleAdmin, "", nil)
+	c.Assert(err, IsNil)
+
+	a, err := NewAuthorizer(testConfig())
+	c.Assert(err, IsNil)
+	c.Assert(a.Enabled(), Equals, true)
+	_, err = a.Authorize(RolePublic, "", nil)
+	c.Assert(err, IsNil)
+	_, err = a.Authorize(RoleRead, "", nil)
+	c.Assert(err, Equals, ErrUnauthenticated)
+	_, err = a.Authorize(RoleRead, "wrong-key-0123456789", nil)
+	c.Assert(err, Equals, ErrUnauthenticated)
+
+	identity, err := a.Authorize(RoleRead, "signer-key-0123456789", nil)
+	c.Assert(err, IsNil)
+	c.Assert(identity, DeepEquals, Identity{Name: "signer", Role: RoleSign})
+	_, err = a.Authorize(RoleSign, "reader-key-0123456789", nil)
+	c.Assert(err, ErrorMatches, "reader has the role read, the method needs sign.*")
+	_, err = a.Authorize(RoleAdmin, "signer-key-0123456789", nil)
+	c.Assert(err, ErrorMatches, ".*"+ErrPermissionDenied.Error())
+
+	// the client certificate of the admin wins over the API key
+	identity, err = a.Authorize(RoleAdmin, "reader-key-0123456789", connectionState("operator"))
+	c.Assert(err, IsNil)
+	c.Assert(identity, DeepEquals, Identity{Name: "operator", Role: RoleAdmin})
+	_, err = a.Authorize(RoleRead, "", connectionState("stranger"))
+	c.Assert(err, Equals, ErrUnauthenticated)
+	// the certificates that are not verified are ignored
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "operator"}}
+	_, err = a.Authorize(RoleRead, "", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	c.Assert(err, Equals, ErrUnauthenticated)
+}
+
+func (s *AuthTestSuite) TestHandler(c *C) {
+	a, err := NewAuthorizer(testConfig())
+	c.Assert(err, IsNil)
+	var requester string
+	handler := a.Handler(RoleSign, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requester = common.RequesterFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/keysign", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	c.Assert(res.Code, Equals, http.StatusUnauthorized)
+
+	req.Header.Set(APIKeyHeader, "reader-key-0123456789")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	c.Assert(res.Code, Equals, http.StatusForbidden)
+
+	req.Header.Set(APIKeyHeader, "signer-key-0123456789")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	// the handler knows who called it
+	c.Assert(requester, Equals, "signer")
+}
+
+func (s *AuthTestSuite) TestTenants(c *C) {
+	cfg := testConfig()
+	cfg.APIKeys = append(cfg.APIKeys, APIKey{Name: "btc", Key: "btc-key-0123456789", Role: RoleSign, Tenants: []string{"btc"}})
+	a, err := NewAuthorizer(cfg)
+	c.Assert(err, IsNil)
+	identity, err := a.Authorize(RoleSign, "btc-key-0123456789", nil)
+	c.Assert(err, IsNil)
+	c.Assert(identity.CanAccess("btc"), Equals, true)
+	c.Assert(identity.CanAccess("eth"), Equals, false)
+	// the requests without a tenant are for the default one
+	c.Assert(identity.CanAccess(""), Equals, false)
+	identity, err = a.Authorize(RoleSign, "signer-key-0123456789", nil)
+	c.Assert(err, IsNil)
+	c.Assert(identity.CanAccess("eth"), Equals, true)
+	c.Assert(identity.CanAccess(""), Equals, true)
+
+	handler := a.Handler(RoleSign, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(tenant string) int {
+		req := httptest.NewRequest(http.MethodPost, "/keysign", nil)
+		req.Header.Set(APIKeyHeader, "btc-key-0123456789")
+		if len(tenant) != 0 {
+			req.Header.Set(TenantHeader, tenant)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+	c.Assert(serve("btc"), Equals, http.StatusOK)
+	c.Assert(serve("eth"), Equals, http.StatusForbidden)
+	c.Assert(serve(""), Equals, http.StatusForbidden)
+}
+
+// writeCertificate write a self-signed certificate and its key to the folder
+func writeCertificate(c *C, folder string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	c.Assert(err, IsNil)
+	certFile := filepath.Join(folder, "cert.pem")
+	keyFile := filepath.Join(folder, "key.pem")
+	c.Assert(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600), IsNil)
+	c.Assert(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600), IsNil)
+	return certFile, keyFile
+}
+
+func (s *AuthTestSuite) TestTLSConfig(c *C) {
+	c.Assert(TLSConfig{}.Enabled(), Equals, false)
+	_, err := TLSConfig{CertFile: filepath.Join(c.MkDir(), "missing.pem")}.Load()
+	c.Assert(err, NotNil)
+
+	certFile, keyFile := writeCertificate(c, c.MkDir())
+	tlsConf, err := TLSConfig{CertFile: certFile, KeyFile: keyFile}.Load()
+	c.Assert(err, IsNil)
+	c.Assert(tlsConf.Certificates, HasLen, 1)
+	c.Assert(tlsConf.ClientAuth, Equals, tls.NoClientCert)
+	_, err = TLSConfig{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true}.Load()
+	c.Assert(err, NotNil)
+
+	tlsConf, err = TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile}.Load()
+	c.Assert(err, IsNil)
+	c.Assert(tlsConf.ClientAuth, Equals, tls.VerifyClientCertIfGiven)
+	tlsConf, err = TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, RequireClientCert: true}.Load()
+	c.Assert(err, IsNil)
+	c.Assert(tlsConf.ClientAuth, Equals, tls.RequireAndVerifyClientCert)
+	_, err = TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}.Load()
+	c.Assert(err, ErrorMatches, "no certificate in the client CA")
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/akildemir/go-tss/common"
+)
+
+const (
+	// APIKeyMetadata is the gRPC metadata the callers send their API key in
+	APIKeyMetadata = "x-api-key"
+	// TenantMetadata is the gRPC metadata that names the tenant the call is for, the calls without it are for
+	// common.DefaultTenant
+	TenantMetadata = "x-tss-tenant"
+)
+
+// TenantFromContext return the tenant the metadata of the incoming call names, empty if it names none
+func TenantFromContext(ctx context.Context) string {
+	return metadataValue(ctx, TenantMetadata)
+}
+
+// metadataValue return the first value of the key in the metadata of the incoming call
+func metadataValue(ctx context.Context, key string) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// UnaryInterceptor only let the callers with the role of the method in roles through, the methods that are not in
+// roles need the admin role
+func (a *Authorizer) UnaryInterceptor(roles map[string]Role) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorizeContext(ctx, info.FullMethod, roles)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is the UnaryInterceptor of the streams
+func (a *Authorizer) StreamInterceptor(roles map[string]Role) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorizeContext(ss.Context(), info.FullMethod, roles)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// identityStream is the stream whose context carries the name of the caller
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorizeContext check the caller of the gRPC method has the role it needs, the error is the gRPC status, the
+// returned context carries the name of the caller
+func (a *Authorizer) authorizeContext(ctx context.Context, method string, roles map[string]Role) (context.Context, error) {
+	required, ok := roles[method]
+	if !ok {
+		required = RoleAdmin
+	}
+	key := metadataValue(ctx, APIKeyMetadata)
+	var state *tls.ConnectionState
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+	identity, err := a.Authorize(required, key, state)
+	if err != nil {
+		log.Warn().Err(err).Str("method", method).Msg("refuse the grpc request")
+		if errors.Is(err, ErrUnauthenticated) {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return ctx, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if tenant := TenantFromContext(ctx); !identity.CanAccess(tenant) {
+		log.Warn().Str("method", method).Str("caller", identity.Name).Str("tenant", tenant).Msg("refuse the grpc request of the tenant")
+		return ctx, status.Errorf(codes.PermissionDenied, "%s can not use the tenant %s", identity.Name, tenant)
+	}
+	if len(identity.Name) != 0 {
+		ctx = common.WithRequester(ctx, identity.Name)
+	}
+	return ctx, nil
+}
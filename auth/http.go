@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/akildemir/go-tss/common"
+)
+
+const (
+	// APIKeyHeader is the http header the callers send their API key in
+	APIKeyHeader = "X-API-Key"
+	// TenantHeader is the http header that names the tenant the request is for, the requests without it are for
+	// common.DefaultTenant
+	TenantHeader = "X-Tss-Tenant"
+)
+
+// Handler only let the callers with the required role through to the handler, the others get 401 without a valid
+// credential and 403 without the role or the tenant of the request
+func (a *Authorizer) Handler(required Role, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := a.Authorize(required, r.Header.Get(APIKeyHeader), r.TLS)
+		if err != nil {
+			log.Warn().Err(err).Str("route", r.URL.Path).Str("remote", r.RemoteAddr).Msg("refuse the http request")
+			if errors.Is(err, ErrUnauthenticated) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if tenant := r.Header.Get(TenantHeader); !identity.CanAccess(tenant) {
+			log.Warn().Str("route", r.URL.Path).Str("caller", identity.Name).Str("tenant", tenant).Msg("refuse the http request of the tenant")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if len(identity.Name) != 0 {
+			log.Debug().Str("route", r.URL.Path).Str("caller", identity.Name).Msg("authorize the http request")
+			r = r.WithContext(common.WithRequester(r.Context(), identity.Name))
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
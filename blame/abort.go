@@ -0,0 +1,65 @@
+package blame
+
+// Abort is the identified abort of a ceremony, the round and the check a party failed, with the messages each
+// culprit signed, so the slashing can verify the culprits sent them without trusting us
+type Abort struct {
+	Round  string       `json:"round"`
+	Cause  string       `json:"cause"`
+	Proofs []AbortProof `json:"proofs"`
+}
+
+// AbortProof is the message a culprit of the abort sent us, with its signature over the message and the msg id
+type AbortProof struct {
+	PubKey    string `json:"pub_key"`
+	Message   []byte `json:"message"`
+	Signature []byte `json:"signature"`
+	// Verified is true if the signature is valid for the culprit
+	Verified bool `json:"verified"`
+}
+
+// NewAbort create the Abort of the blamed nodes, their evidences are verified against their pub keys
+func NewAbort(round, cause, msgID string, nodes []Node) *Abort {
+	proofs := make([]AbortProof, 0, len(nodes))
+	for _, el := range nodes {
+		proofs = append(proofs, AbortProof{
+			PubKey:    el.Pubkey,
+			Message:   el.BlameData,
+			Signature: el.BlameSignature,
+			Verified:  verifyEvidence(el, msgID),
+		})
+	}
+	return &Abort{
+		Round:  round,
+		Cause:  cause,
+		Proofs: proofs,
+	}
+}
+
+// Identified return true if every culprit of the abort is proven by its own signature
+func (a *Abort) Identified() bool {
+	if a == nil || len(a.Proofs) == 0 {
+		return false
+	}
+	for _, el := range a.Proofs {
+		if !el.Verified {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAbort keep the first abort of the ceremony, the later ones are caused by it
+func (m *Manager) SetAbort(abort *Abort) {
+	m.lastMsgLocker.Lock()
+	defer m.lastMsgLocker.Unlock()
+	if m.abort == nil {
+		m.abort = abort
+	}
+}
+
+// GetAbort return the abort SetAbort kept, nil if no party was identified
+func (m *Manager) GetAbort() *Abort {
+	m.lastMsgLocker.RLock()
+	defer m.lastMsgLocker.RUnlock()
+	return m.abort
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/akildemir/go-tss/errcode"
 )
 
 func NewNode(pk string, blameData, blameSig []byte) Node {
@@ -40,6 +42,26 @@ func (b Blame) String() string {
 	return sb.String()
 }
 
+// ErrorCode return the code of the failure the blame reports, it is empty if the blame has no fail reason
+func (b Blame) ErrorCode() errcode.Code {
+	switch b.FailReason {
+	case "":
+		return ""
+	case TssTimeout:
+		return errcode.TssTimeout
+	case TssSyncFail:
+		return errcode.JoinPartyTimeout
+	case TssAborted, TssShutdown:
+		return errcode.PeerAborted
+	case HashCheckFail, TssBrokenMsg:
+		return errcode.PeerMisbehaved
+	case InvalidSig:
+		return errcode.InvalidSignature
+	default:
+		return errcode.Internal
+	}
+}
+
 // SetBlame update the field values of Blame
 func (b *Blame) SetBlame(reason string, nodes []Node, isUnicast bool) {
 	b.blameLock.Lock()
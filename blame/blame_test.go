@@ -2,6 +2,8 @@ package blame
 
 import (
 	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/errcode"
 )
 
 type BlameTestSuite struct{}
@@ -27,3 +29,12 @@ func (BlameTestSuite) TestBlame(c *C) {
 	b.SetBlame("helloworld", nil, false)
 	c.Assert(b.FailReason, Equals, "helloworld")
 }
+
+func (BlameTestSuite) TestErrorCode(c *C) {
+	c.Assert(Blame{}.ErrorCode(), Equals, errcode.Code(""))
+	c.Assert(NewBlame(TssTimeout, nil).ErrorCode(), Equals, errcode.TssTimeout)
+	c.Assert(NewBlame(TssSyncFail, nil).ErrorCode(), Equals, errcode.JoinPartyTimeout)
+	c.Assert(NewBlame(TssShutdown, nil).ErrorCode(), Equals, errcode.PeerAborted)
+	c.Assert(NewBlame(TssBrokenMsg, nil).ErrorCode(), Equals, errcode.PeerMisbehaved)
+	c.Assert(NewBlame(InternalError, nil).ErrorCode(), Equals, errcode.Internal)
+}
@@ -0,0 +1,209 @@
+package blame
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	tcrypto "github.com/tendermint/tendermint/crypto"
+)
+
+// EventSchemaVersion is the version of the Event schema, it is bumped on every change that is not backward compatible
+const EventSchemaVersion = 1
+
+// Severity tells the slashing services how confident we are that the blamed nodes misbehaved on purpose
+type Severity string
+
+const (
+	// SeverityLow the nodes may just be offline or out of sync, it should not be punished on its own
+	SeverityLow Severity = "low"
+	// SeverityMedium the nodes fail to take part in the ceremony in time
+	SeverityMedium Severity = "medium"
+	// SeverityHigh the nodes sent the shares that fail the verification, and every evidence is verified
+	SeverityHigh Severity = "high"
+)
+
+// Evidence is the message the blamed node sent us together with its signature over the message and the msg id
+type Evidence struct {
+	Data      []byte `json:"data"`
+	Signature []byte `json:"signature"`
+	// Verified is true if the signature is valid for the blamed node
+	Verified bool `json:"verified"`
+}
+
+// Offender is the node we blame in the Event
+type Offender struct {
+	PubKey   string    `json:"pub_key"`
+	Evidence *Evidence `json:"evidence,omitempty"`
+}
+
+// Event is the blame outcome of a failed ceremony, it is the payload we post to the blame webhook as json.
+// Every node of the ceremony reports the same blame with the same DedupKey, so the consumers should
+// process each DedupKey once.
+//
+//	{
+//	  "schema_version": 1,
+//	  "dedup_key": "hex sha256 of the ceremony, the msg id, the fail reason and the sorted offenders",
+//	  "ceremony": "keygen|keysign",
+//	  "msg_id": "the id of the ceremony",
+//	  "pool_pub_key": "the pool we sign with, empty for keygen",
+//	  "reporter": "the pub key of the node that reports the event",
+//	  "fail_reason": "the blame.Blame fail reason",
+//	  "severity": "low|medium|high",
+//	  "is_unicast": false,
+//	  "offenders": [{"pub_key": "...", "evidence": {"data": "base64", "signature": "base64", "verified": true}}],
+//	  "time": "RFC3339 time",
+//	  "signature": "base64 signature of the reporter over the SignBytes of the event"
+//	}
+//
+// The evidence of an offender is the wire message it sent us and its signature over the message and the msg id, so
+// the consumers can check the offender sent it. The reporter signs the event with its node key, so the consumers can
+// check who made the accusation.
+type Event struct {
+	SchemaVersion int        `json:"schema_version"`
+	DedupKey      string     `json:"dedup_key"`
+	Ceremony      string     `json:"ceremony"`
+	MsgID         string     `json:"msg_id"`
+	PoolPubKey    string     `json:"pool_pub_key,omitempty"`
+	Reporter      string     `json:"reporter"`
+	FailReason    string     `json:"fail_reason"`
+	Severity      Severity   `json:"severity"`
+	IsUnicast     bool       `json:"is_unicast"`
+	Offenders     []Offender `json:"offenders"`
+	Time          time.Time  `json:"time"`
+	// Signature is the signature of the reporter over the SignBytes, empty if the reporter did not sign it
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// NewEvent create the Event of the given blame, the evidences of the blamed nodes are verified against their pub keys
+func NewEvent(ceremony, msgID, poolPubKey, reporter string, b Blame) Event {
+	offenders := make([]Offender, 0, len(b.BlameNodes))
+	for _, el := range b.BlameNodes {
+		offender := Offender{PubKey: el.Pubkey}
+		if len(el.BlameData) > 0 || len(el.BlameSignature) > 0 {
+			offender.Evidence = &Evidence{
+				Data:      el.BlameData,
+				Signature: el.BlameSignature,
+				Verified:  verifyEvidence(el, msgID),
+			}
+		}
+		offenders = append(offenders, offender)
+	}
+	sort.SliceStable(offenders, func(i, j int) bool {
+		return offenders[i].PubKey < offenders[j].PubKey
+	})
+	return Event{
+		SchemaVersion: EventSchemaVersion,
+		DedupKey:      dedupKey(ceremony, msgID, b.FailReason, offenders),
+		Ceremony:      ceremony,
+		MsgID:         msgID,
+		PoolPubKey:    poolPubKey,
+		Reporter:      reporter,
+		FailReason:    b.FailReason,
+		Severity:      classify(b.FailReason, offenders),
+		IsUnicast:     b.IsUnicast,
+		Offenders:     offenders,
+		Time:          time.Now().UTC(),
+	}
+}
+
+// SignBytes return the bytes the reporter signs, the fields of the event joined by |, the offenders are each the pub
+// key, the sha256 of the evidence data and the evidence signature joined by :
+func (e Event) SignBytes() []byte {
+	offenders := make([]string, 0, len(e.Offenders))
+	for _, el := range e.Offenders {
+		var dataHash, sig string
+		if el.Evidence != nil {
+			h := sha256.Sum256(el.Evidence.Data)
+			dataHash = hex.EncodeToString(h[:])
+			sig = hex.EncodeToString(el.Evidence.Signature)
+		}
+		offenders = append(offenders, strings.Join([]string{el.PubKey, dataHash, sig}, ":"))
+	}
+	return []byte(strings.Join([]string{
+		strconv.Itoa(e.SchemaVersion),
+		e.DedupKey,
+		e.Ceremony,
+		e.MsgID,
+		e.PoolPubKey,
+		e.Reporter,
+		e.FailReason,
+		string(e.Severity),
+		strconv.FormatBool(e.IsUnicast),
+		strings.Join(offenders, ","),
+		e.Time.UTC().Format(time.RFC3339Nano),
+	}, "|"))
+}
+
+// Sign sign the event with the node key of the reporter
+func (e *Event) Sign(privKey tcrypto.PrivKey) error {
+	sig, err := privKey.Sign(e.SignBytes())
+	if err != nil {
+		return fmt.Errorf("fail to sign the blame event: %w", err)
+	}
+	e.Signature = sig
+	return nil
+}
+
+// VerifySignature check the reporter signed the event
+func (e Event) VerifySignature() bool {
+	if len(e.Signature) == 0 {
+		return false
+	}
+	pk, err := sdk.UnmarshalPubKey(sdk.AccPK, e.Reporter)
+	if err != nil {
+		return false
+	}
+	return pk.VerifySignature(e.SignBytes(), e.Signature)
+}
+
+// verifyEvidence check the blamed node signed the blame data together with the msg id
+func verifyEvidence(node Node, msgID string) bool {
+	if len(node.BlameData) == 0 || len(node.BlameSignature) == 0 {
+		return false
+	}
+	pk, err := sdk.UnmarshalPubKey(sdk.AccPK, node.Pubkey)
+	if err != nil {
+		return false
+	}
+	var dataForSign bytes.Buffer
+	dataForSign.Write(node.BlameData)
+	dataForSign.WriteString(msgID)
+	return pk.VerifySignature(dataForSign.Bytes(), node.BlameSignature)
+}
+
+// classify return the severity of the blame, only the broken shares with the verified evidences are high
+func classify(reason string, offenders []Offender) Severity {
+	switch reason {
+	case TssBrokenMsg, HashCheckFail:
+		if len(offenders) == 0 {
+			return SeverityMedium
+		}
+		for _, el := range offenders {
+			if el.Evidence == nil || !el.Evidence.Verified {
+				return SeverityMedium
+			}
+		}
+		return SeverityHigh
+	case TssTimeout:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// dedupKey does not depend on the reporter, so all the nodes that report the same blame get the same key
+func dedupKey(ceremony, msgID, reason string, offenders []Offender) string {
+	keys := make([]string, 0, len(offenders))
+	for _, el := range offenders {
+		keys = append(keys, el.PubKey)
+	}
+	h := sha256.Sum256([]byte(strings.Join([]string{ceremony, msgID, reason, strings.Join(keys, ",")}, "|")))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,125 @@
+package blame
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// the private key of testPubKeys[0]
+const testPriKey = "MjQ1MDc2MmM4MjU5YjRhZjhhNmFjMmI0ZDBkNzBkOGE1ZTBmNDQ5NGI4NzM4OTYyM2E3MmI0OWMzNmE1ODZhNw=="
+
+type EventTestSuite struct{}
+
+var _ = Suite(&EventTestSuite{})
+
+func (EventTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func signedNode(c *C, data []byte, msgID string) Node {
+	priKey, err := conversion.GetPriKey(testPriKey)
+	c.Assert(err, IsNil)
+	var dataForSign bytes.Buffer
+	dataForSign.Write(data)
+	dataForSign.WriteString(msgID)
+	sig, err := priKey.Sign(dataForSign.Bytes())
+	c.Assert(err, IsNil)
+	return NewNode(testPubKeys[0], data, sig)
+}
+
+func (EventTestSuite) TestNewEvent(c *C) {
+	msgID := "msg-id"
+	node := signedNode(c, []byte("broken share"), msgID)
+	ev := NewEvent("keysign", msgID, "pool", testPubKeys[2], NewBlame(TssBrokenMsg, []Node{node}))
+	c.Assert(ev.SchemaVersion, Equals, EventSchemaVersion)
+	c.Assert(ev.Severity, Equals, SeverityHigh)
+	c.Assert(ev.Offenders, HasLen, 1)
+	c.Assert(ev.Offenders[0].PubKey, Equals, testPubKeys[0])
+	c.Assert(ev.Offenders[0].Evidence.Verified, Equals, true)
+
+	// the evidence is signed for another ceremony
+	ev = NewEvent("keysign", "another-msg-id", "pool", testPubKeys[2], NewBlame(TssBrokenMsg, []Node{node}))
+	c.Assert(ev.Offenders[0].Evidence.Verified, Equals, false)
+	c.Assert(ev.Severity, Equals, SeverityMedium)
+
+	// the evidence is claimed for another node
+	forged := NewNode(testPubKeys[1], node.BlameData, node.BlameSignature)
+	ev = NewEvent("keysign", msgID, "pool", testPubKeys[2], NewBlame(HashCheckFail, []Node{node, forged}))
+	c.Assert(ev.Severity, Equals, SeverityMedium)
+
+	ev = NewEvent("keygen", msgID, "", testPubKeys[2], NewBlame(TssTimeout, []Node{NewNode(testPubKeys[1], nil, nil)}))
+	c.Assert(ev.Severity, Equals, SeverityMedium)
+	c.Assert(ev.Offenders[0].Evidence, IsNil)
+	ev = NewEvent("keygen", msgID, "", testPubKeys[2], NewBlame(TssSyncFail, []Node{NewNode(testPubKeys[1], nil, nil)}))
+	c.Assert(ev.Severity, Equals, SeverityLow)
+}
+
+func (EventTestSuite) TestSignEvent(c *C) {
+	priKey, err := conversion.GetPriKey(testPriKey)
+	c.Assert(err, IsNil)
+	msgID := "msg-id"
+	ev := NewEvent("keysign", msgID, "pool", testPubKeys[0], NewBlame(TssBrokenMsg, []Node{signedNode(c, []byte("broken share"), msgID)}))
+	c.Assert(ev.VerifySignature(), Equals, false)
+	c.Assert(ev.Sign(priKey), IsNil)
+	c.Assert(ev.VerifySignature(), Equals, true)
+	// the signature survives the json the consumers receive
+	buf, err := json.Marshal(ev)
+	c.Assert(err, IsNil)
+	var received Event
+	c.Assert(json.Unmarshal(buf, &received), IsNil)
+	c.Assert(received.VerifySignature(), Equals, true)
+
+	// the verdict and the evidence can not be changed once signed
+	tampered := received
+	tampered.FailReason = TssTimeout
+	c.Assert(tampered.VerifySignature(), Equals, false)
+	tampered = received
+	tampered.Offenders = []Offender{{PubKey: testPubKeys[0], Evidence: &Evidence{Data: []byte("another share"), Signature: ev.Offenders[0].Evidence.Signature}}}
+	c.Assert(tampered.VerifySignature(), Equals, false)
+	// the event is signed by another node than the reporter
+	tampered = received
+	tampered.Reporter = testPubKeys[1]
+	c.Assert(tampered.VerifySignature(), Equals, false)
+}
+
+func (EventTestSuite) TestNewAbort(c *C) {
+	msgID := "msg-id"
+	node := signedNode(c, []byte("broken share"), msgID)
+	abort := NewAbort("SignRound7Message", "round 7 consistency check failed", msgID, []Node{node})
+	c.Assert(abort.Proofs, HasLen, 1)
+	c.Assert(abort.Proofs[0].PubKey, Equals, testPubKeys[0])
+	c.Assert(abort.Proofs[0].Message, DeepEquals, node.BlameData)
+	c.Assert(abort.Identified(), Equals, true)
+
+	// the message is claimed for another node
+	forged := NewNode(testPubKeys[1], node.BlameData, node.BlameSignature)
+	abort = NewAbort("SignRound7Message", "round 7 consistency check failed", msgID, []Node{node, forged})
+	c.Assert(abort.Proofs[1].Verified, Equals, false)
+	c.Assert(abort.Identified(), Equals, false)
+	c.Assert(NewAbort("SignRound7Message", "", msgID, nil).Identified(), Equals, false)
+
+	m := NewBlameManager()
+	c.Assert(m.GetAbort(), IsNil)
+	first := NewAbort("SignRound5Message", "commitment verify failed", msgID, []Node{node})
+	m.SetAbort(first)
+	m.SetAbort(abort)
+	c.Assert(m.GetAbort(), Equals, first)
+}
+
+func (EventTestSuite) TestDedupKey(c *C) {
+	nodes := []Node{NewNode(testPubKeys[1], nil, nil), NewNode(testPubKeys[3], nil, nil)}
+	reversed := []Node{nodes[1], nodes[0]}
+	ev1 := NewEvent("keysign", "msg-id", "pool", testPubKeys[0], NewBlame(TssTimeout, nodes))
+	ev2 := NewEvent("keysign", "msg-id", "pool", testPubKeys[2], NewBlame(TssTimeout, reversed))
+	c.Assert(ev1.DedupKey, Equals, ev2.DedupKey)
+	c.Assert(ev1.Offenders, DeepEquals, ev2.Offenders)
+
+	ev3 := NewEvent("keysign", "msg-id", "pool", testPubKeys[0], NewBlame(TssSyncFail, nodes))
+	c.Assert(ev3.DedupKey, Not(Equals), ev1.DedupKey)
+	ev4 := NewEvent("keysign", "msg-id", "pool", testPubKeys[0], NewBlame(TssTimeout, nodes[:1]))
+	c.Assert(ev4.DedupKey, Not(Equals), ev1.DedupKey)
+}
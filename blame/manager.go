@@ -2,6 +2,7 @@ package blame
 
 import (
 	"sync"
+	"time"
 
 	btss "github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -19,7 +20,11 @@ type Manager struct {
 	PartyIDtoP2PID    map[string]peer.ID
 	lastMsgLocker     *sync.RWMutex
 	lastMsg           btss.Message
+	stall             *Stall
+	abort             *Abort
 	acceptedShares    map[RoundInfo][]string
+	acceptedAt        map[string]map[string]time.Time
+	sentAt            map[string]time.Time
 	acceptShareLocker *sync.Mutex
 	localPartyID      string
 }
@@ -36,6 +41,8 @@ func NewBlameManager() *Manager {
 		blame:             &blame,
 		lastMsgLocker:     &sync.RWMutex{},
 		acceptedShares:    make(map[RoundInfo][]string),
+		acceptedAt:        make(map[string]map[string]time.Time),
+		sentAt:            make(map[string]time.Time),
 		acceptShareLocker: &sync.Mutex{},
 	}
 }
@@ -55,6 +62,10 @@ func (m *Manager) GetRoundMgr() *RoundMgr {
 func (m *Manager) UpdateAcceptShare(round RoundInfo, id string) {
 	m.acceptShareLocker.Lock()
 	defer m.acceptShareLocker.Unlock()
+	if m.acceptedAt[round.RoundMsg] == nil {
+		m.acceptedAt[round.RoundMsg] = make(map[string]time.Time)
+	}
+	m.acceptedAt[round.RoundMsg][id] = time.Now()
 	partyList, ok := m.acceptedShares[round]
 	if !ok {
 		partyList := []string{id}
@@ -142,3 +142,80 @@ func (p *policyTestSuite) TestTssMissingShareBlame(c *C) {
 	sort.Strings(results)
 	c.Assert(results, DeepEquals, localTestPubKeys[2:])
 }
+
+func (p *policyTestSuite) TestDetectStall(c *C) {
+	localTestPubKeys := testPubKeys[:]
+	sort.Strings(localTestPubKeys)
+	blameMgr := p.blameMgr
+	c.Assert(blameMgr.GetStall(), IsNil)
+	// nothing arrived, everyone is missing in the first round
+	stall, err := blameMgr.DetectStall([]string{"round1", "round2"})
+	c.Assert(err, IsNil)
+	c.Assert(stall.Round, Equals, "round1")
+	c.Assert(stall.Missing, DeepEquals, localTestPubKeys[1:])
+
+	blameMgr.acceptShareLocker.Lock()
+	blameMgr.acceptedShares[RoundInfo{0, "round1", "123:0"}] = []string{"1", "2", "3"}
+	blameMgr.acceptedShares[RoundInfo{1, "round2", "123:0"}] = []string{"1", "3"}
+	blameMgr.acceptShareLocker.Unlock()
+	stall, err = blameMgr.DetectStall([]string{"round1", "round2"})
+	c.Assert(err, IsNil)
+	c.Assert(stall.Round, Equals, "round2")
+	c.Assert(stall.Missing, DeepEquals, []string{localTestPubKeys[2]})
+	c.Assert(blameMgr.GetStall(), DeepEquals, stall)
+
+	// all the rounds are complete
+	blameMgr.acceptShareLocker.Lock()
+	blameMgr.acceptedShares[RoundInfo{1, "round2", "123:0"}] = []string{"1", "2", "3"}
+	blameMgr.acceptShareLocker.Unlock()
+	stall, err = blameMgr.DetectStall([]string{"round1", "round2"})
+	c.Assert(err, IsNil)
+	c.Assert(stall, IsNil)
+}
+
+func (p *policyTestSuite) TestTraceRounds(c *C) {
+	localTestPubKeys := testPubKeys[:]
+	sort.Strings(localTestPubKeys)
+	blameMgr := p.blameMgr
+	rounds := []string{"round1", "round2", "round3"}
+	// the party never formed
+	trace, err := NewBlameManager().TraceRounds(rounds)
+	c.Assert(err, IsNil)
+	c.Assert(trace, IsNil)
+
+	// nothing happened, the first round never formed
+	trace, err = blameMgr.TraceRounds(rounds)
+	c.Assert(err, IsNil)
+	c.Assert(trace.Reached, Equals, "round1")
+	c.Assert(trace.Rounds, HasLen, 1)
+	c.Assert(trace.Rounds[0].Sent, IsNil)
+	c.Assert(trace.Rounds[0].Started, IsNil)
+	c.Assert(trace.Rounds[0].Missing, DeepEquals, localTestPubKeys[1:])
+	c.Assert(trace.LastRound, DeepEquals, map[string]string{localTestPubKeys[1]: "", localTestPubKeys[2]: "", localTestPubKeys[3]: ""})
+
+	// the message type of tss lib is qualified with its package
+	blameMgr.RoundSent("binance.tsslib.round1")
+	for _, el := range []string{"1", "2", "3"} {
+		blameMgr.UpdateAcceptShare(RoundInfo{0, "round1", "123:0"}, el)
+	}
+	blameMgr.RoundSent("binance.tsslib.round2")
+	blameMgr.UpdateAcceptShare(RoundInfo{1, "round2", "123:0"}, "1")
+	blameMgr.UpdateAcceptShare(RoundInfo{1, "round2", "123:0"}, "3")
+	trace, err = blameMgr.TraceRounds(rounds)
+	c.Assert(err, IsNil)
+	c.Assert(trace.Reached, Equals, "round2")
+	c.Assert(trace.Rounds, HasLen, 3)
+	round1 := trace.Rounds[0]
+	c.Assert(round1.Sent, NotNil)
+	c.Assert(round1.Received, HasLen, 3)
+	c.Assert(round1.Missing, HasLen, 0)
+	c.Assert(round1.Completed, NotNil)
+	c.Assert(round1.Duration, Equals, round1.Completed.Sub(*round1.Started))
+	round2 := trace.Rounds[1]
+	c.Assert(round2.Sent, NotNil)
+	c.Assert(round2.Received, HasLen, 2)
+	c.Assert(round2.Missing, DeepEquals, []string{localTestPubKeys[2]})
+	c.Assert(round2.Completed, IsNil)
+	c.Assert(trace.Rounds[2].Started, IsNil)
+	c.Assert(trace.LastRound, DeepEquals, map[string]string{localTestPubKeys[1]: "round2", localTestPubKeys[2]: "round1", localTestPubKeys[3]: "round2"})
+}
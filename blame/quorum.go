@@ -0,0 +1,69 @@
+package blame
+
+import (
+	"sort"
+)
+
+// Quorum is the blame the participants of a failed ceremony agree on once they shared their views, the views are
+// the signed events of the participants. A node is confirmed if more than half of the views of the other
+// participants blame it, and at least two of them do, it is disputed if fewer of them do, so a node that only a
+// partitioned minority can not hear is not slashed. The participants that did not share their view do not count
+type Quorum struct {
+	// Reporters are the participants whose views are counted, us included
+	Reporters []string `json:"reporters"`
+	// Confirmed are the nodes a quorum of the views blame
+	Confirmed []string `json:"confirmed"`
+	// Disputed are the nodes some of the views blame, but not a quorum of them
+	Disputed []string `json:"disputed"`
+}
+
+// AggregateViews return the quorum of the views of the participants of the ceremony of the msg id, the views of the
+// nodes that are not the participants, of another ceremony or that are not signed by their reporter are dropped, a
+// reporter is counted once
+func AggregateViews(msgID string, participants []string, views []Event) Quorum {
+	isParticipant := make(map[string]bool, len(participants))
+	for _, el := range participants {
+		isParticipant[el] = true
+	}
+	reporters := make(map[string]bool)
+	accusers := make(map[string]map[string]bool)
+	for _, el := range views {
+		if el.MsgID != msgID || !isParticipant[el.Reporter] || reporters[el.Reporter] || !el.VerifySignature() {
+			continue
+		}
+		reporters[el.Reporter] = true
+		for _, o := range el.Offenders {
+			if o.PubKey == el.Reporter {
+				continue
+			}
+			if accusers[o.PubKey] == nil {
+				accusers[o.PubKey] = make(map[string]bool)
+			}
+			accusers[o.PubKey][el.Reporter] = true
+		}
+	}
+	q := Quorum{
+		Reporters: make([]string, 0, len(reporters)),
+		Confirmed: []string{},
+		Disputed:  []string{},
+	}
+	for el := range reporters {
+		q.Reporters = append(q.Reporters, el)
+	}
+	for accused, by := range accusers {
+		// the view of the accused does not count for or against itself
+		voters := len(reporters)
+		if reporters[accused] {
+			voters--
+		}
+		if len(by) >= 2 && len(by)*2 > voters {
+			q.Confirmed = append(q.Confirmed, accused)
+		} else {
+			q.Disputed = append(q.Disputed, accused)
+		}
+	}
+	sort.Strings(q.Reporters)
+	sort.Strings(q.Confirmed)
+	sort.Strings(q.Disputed)
+	return q
+}
@@ -0,0 +1,95 @@
+package blame
+
+import (
+	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type QuorumTestSuite struct{}
+
+var _ = Suite(&QuorumTestSuite{})
+
+func (QuorumTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+// quorumNode is a participant that signs its blame view
+type quorumNode struct {
+	pubKey  string
+	privKey secp256k1.PrivKey
+}
+
+func newQuorumNodes(c *C, n int) []quorumNode {
+	var nodes []quorumNode
+	for i := 0; i < n; i++ {
+		privKey := secp256k1.GenPrivKey()
+		pubKey, err := sdk.MarshalPubKey(sdk.AccPK, &coskey.PubKey{Key: privKey.PubKey().Bytes()})
+		c.Assert(err, IsNil)
+		nodes = append(nodes, quorumNode{pubKey: pubKey, privKey: privKey})
+	}
+	return nodes
+}
+
+// view return the view of the node that blames the given nodes, signed by it
+func (n quorumNode) view(c *C, msgID string, blamed ...quorumNode) Event {
+	var nodes []Node
+	for _, el := range blamed {
+		nodes = append(nodes, NewNode(el.pubKey, nil, nil))
+	}
+	ev := NewEvent("keysign", msgID, "pool", n.pubKey, NewBlame(TssTimeout, nodes))
+	c.Assert(ev.Sign(n.privKey), IsNil)
+	return ev
+}
+
+func (QuorumTestSuite) TestAggregateViews(c *C) {
+	msgID := "msg-id"
+	nodes := newQuorumNodes(c, 5)
+	var participants []string
+	for _, el := range nodes {
+		participants = append(participants, el.pubKey)
+	}
+	outsider := newQuorumNodes(c, 1)[0]
+
+	// nodes[4] is offline, everyone sees it, only nodes[0] can not hear nodes[1], nodes[1] blames it back
+	views := []Event{
+		nodes[0].view(c, msgID, nodes[1], nodes[4]),
+		nodes[1].view(c, msgID, nodes[0], nodes[4]),
+		nodes[2].view(c, msgID, nodes[4]),
+		nodes[3].view(c, msgID, nodes[4]),
+		// the duplicated view, the views of another ceremony and of the nodes that are not participants are dropped
+		nodes[0].view(c, msgID, nodes[2]),
+		nodes[2].view(c, "another-msg-id", nodes[1]),
+		outsider.view(c, msgID, nodes[1]),
+	}
+	forged := nodes[3].view(c, msgID, nodes[1])
+	forged.Reporter = nodes[2].pubKey
+	views = append(views, forged)
+
+	q := AggregateViews(msgID, participants, views)
+	c.Assert(q.Reporters, HasLen, 4)
+	c.Assert(q.Confirmed, DeepEquals, []string{nodes[4].pubKey})
+	disputed := map[string]bool{}
+	for _, el := range q.Disputed {
+		disputed[el] = true
+	}
+	c.Assert(disputed, DeepEquals, map[string]bool{nodes[0].pubKey: true, nodes[1].pubKey: true})
+
+	// the view of a single node confirms no one
+	q = AggregateViews(msgID, participants, views[:1])
+	c.Assert(q.Confirmed, HasLen, 0)
+	c.Assert(q.Disputed, HasLen, 2)
+
+	// the accused does not vote for itself, two of the three other views are a quorum
+	q = AggregateViews(msgID, participants[:4], []Event{
+		nodes[0].view(c, msgID, nodes[3]),
+		nodes[1].view(c, msgID, nodes[3]),
+		nodes[2].view(c, msgID),
+		nodes[3].view(c, msgID),
+	})
+	c.Assert(q.Confirmed, DeepEquals, []string{nodes[3].pubKey})
+	c.Assert(q.Disputed, HasLen, 0)
+}
@@ -0,0 +1,169 @@
+package blame
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// RoundStats is what we saw of a round of a failed ceremony, the times are the wall clock of this node
+type RoundStats struct {
+	Round string `json:"round"`
+	// Sent is when we sent our message of the round, nil if we never did
+	Sent *time.Time `json:"sent,omitempty"`
+	// Received are the times the messages of the round of the peers arrived, by pub key
+	Received map[string]time.Time `json:"received"`
+	// Missing are the peers whose messages of the round never arrived
+	Missing []string `json:"missing"`
+	// Started is the earliest of our message and the messages of the peers of the round, nil if nothing happened
+	Started *time.Time `json:"started,omitempty"`
+	// Completed is when the last message of the round was sent or arrived, nil if the round did not complete
+	Completed *time.Time `json:"completed,omitempty"`
+	// Duration is how long the round took to complete, or how long it ran before the ceremony failed
+	Duration time.Duration `json:"duration"`
+}
+
+// RoundTrace describes how far a failed ceremony got, so the operators can tell a party that never formed its first
+// round from a peer that stopped in a later one
+type RoundTrace struct {
+	// Reached is the first round that did not complete, the last round if they all did
+	Reached string       `json:"reached"`
+	Rounds  []RoundStats `json:"rounds"`
+	// LastRound is the last round each peer sent us a message of, empty if it sent us none
+	LastRound map[string]string `json:"last_round"`
+}
+
+// RoundSent record the first time we sent our message of the round
+func (m *Manager) RoundSent(round string) {
+	m.acceptShareLocker.Lock()
+	defer m.acceptShareLocker.Unlock()
+	if _, ok := m.sentAt[round]; !ok {
+		m.sentAt[round] = time.Now()
+	}
+}
+
+// sentTime return when we sent our message of the round, the message type of tss lib is qualified with its package
+func (m *Manager) sentTime(round string) *time.Time {
+	for msgType, at := range m.sentAt {
+		if msgType == round || strings.HasSuffix(msgType, "."+round) {
+			t := at
+			return &t
+		}
+	}
+	return nil
+}
+
+// TraceRounds return what we saw of the given rounds until the ceremony failed, the trace stops at the first round
+// nothing happened in. It returns nil if the party never formed
+func (m *Manager) TraceRounds(rounds []string) (*RoundTrace, error) {
+	if m.partyInfo == nil {
+		return nil, nil
+	}
+	pubKeys := make(map[string]string)
+	for partyID := range m.PartyIDtoP2PID {
+		if partyID == m.localPartyID {
+			continue
+		}
+		party, ok := m.partyInfo.PartyIDMap[partyID]
+		if !ok {
+			return nil, fmt.Errorf("fail to find the party %s", partyID)
+		}
+		pubKey, err := conversion.PartyIDtoPubKey(party)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get the public key of the party: %w", err)
+		}
+		pubKeys[partyID] = pubKey
+	}
+
+	m.acceptShareLocker.Lock()
+	defer m.acceptShareLocker.Unlock()
+	identifiers := make(map[string]bool)
+	for roundInfo := range m.acceptedShares {
+		identifiers[roundInfo.MsgIdentifier] = true
+	}
+	if len(identifiers) == 0 {
+		identifiers[""] = true
+	}
+	trace := &RoundTrace{
+		Rounds:    make([]RoundStats, 0, len(rounds)),
+		LastRound: make(map[string]string),
+	}
+	for _, el := range pubKeys {
+		trace.LastRound[el] = ""
+	}
+	now := time.Now()
+	for index, el := range rounds {
+		stats := RoundStats{
+			Round:    el,
+			Sent:     m.sentTime(el),
+			Received: make(map[string]time.Time),
+			Missing:  []string{},
+		}
+		// a peer is missing while the message of one of its shares, for one of the messages we sign, is
+		missing := make(map[string]bool)
+		for identifier := range identifiers {
+			accepted := make(map[string]bool)
+			for _, partyID := range m.acceptedShares[RoundInfo{Index: index, RoundMsg: el, MsgIdentifier: identifier}] {
+				accepted[partyID] = true
+			}
+			for partyID, pubKey := range pubKeys {
+				if !accepted[partyID] {
+					missing[pubKey] = true
+				}
+			}
+		}
+		for partyID, at := range m.acceptedAt[el] {
+			pubKey, ok := pubKeys[partyID]
+			if !ok || missing[pubKey] {
+				continue
+			}
+			if last, ok := stats.Received[pubKey]; !ok || at.After(last) {
+				stats.Received[pubKey] = at
+			}
+		}
+		for pubKey := range missing {
+			stats.Missing = append(stats.Missing, pubKey)
+		}
+		sort.Strings(stats.Missing)
+
+		var started, completed *time.Time
+		if stats.Sent != nil {
+			started, completed = stats.Sent, stats.Sent
+		}
+		for partyID, at := range m.acceptedAt[el] {
+			at := at
+			if started == nil || at.Before(*started) {
+				started = &at
+			}
+			if completed == nil || at.After(*completed) {
+				completed = &at
+			}
+			if pubKey, ok := pubKeys[partyID]; ok {
+				trace.LastRound[pubKey] = el
+			}
+		}
+		stats.Started = started
+		switch {
+		case started == nil:
+		case stats.Sent != nil && len(stats.Missing) == 0:
+			stats.Completed = completed
+			stats.Duration = completed.Sub(*started)
+		default:
+			stats.Duration = now.Sub(*started)
+		}
+		trace.Rounds = append(trace.Rounds, stats)
+		if trace.Reached == "" && stats.Completed == nil {
+			trace.Reached = el
+		}
+		if started == nil {
+			break
+		}
+	}
+	if trace.Reached == "" && len(rounds) > 0 {
+		trace.Reached = rounds[len(rounds)-1]
+	}
+	return trace, nil
+}
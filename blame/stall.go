@@ -0,0 +1,80 @@
+package blame
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// Stall describes the round a timed out ceremony got stuck in and the parties whose messages of that round never
+// arrived
+type Stall struct {
+	Round   string   `json:"round"`
+	Missing []string `json:"missing"`
+}
+
+// DetectStall finds the first of the given rounds we have not received the messages of all the peers for, and
+// keeps it for GetStall. It returns nil if all the rounds are complete
+func (m *Manager) DetectStall(rounds []string) (*Stall, error) {
+	if m.partyInfo == nil {
+		return nil, nil
+	}
+	m.acceptShareLocker.Lock()
+	identifiers := make(map[string]bool)
+	for roundInfo := range m.acceptedShares {
+		identifiers[roundInfo.MsgIdentifier] = true
+	}
+	if len(identifiers) == 0 {
+		// nothing arrived, so everyone is missing in the first round
+		identifiers[""] = true
+	}
+	var round string
+	missing := make(map[string]bool)
+	for index, el := range rounds {
+		for identifier := range identifiers {
+			accepted := make(map[string]bool)
+			for _, partyID := range m.acceptedShares[RoundInfo{Index: index, RoundMsg: el, MsgIdentifier: identifier}] {
+				accepted[partyID] = true
+			}
+			for partyID := range m.PartyIDtoP2PID {
+				if partyID != m.localPartyID && !accepted[partyID] {
+					missing[partyID] = true
+				}
+			}
+		}
+		if len(missing) > 0 {
+			round = el
+			break
+		}
+	}
+	m.acceptShareLocker.Unlock()
+
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	partyIDs := make([]string, 0, len(missing))
+	for partyID := range missing {
+		partyIDs = append(partyIDs, partyID)
+	}
+	pubKeys, err := conversion.AccPubKeysFromPartyIDs(partyIDs, m.partyInfo.PartyIDMap)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the public keys of the missing parties: %w", err)
+	}
+	sort.Strings(pubKeys)
+	stall := &Stall{
+		Round:   round,
+		Missing: pubKeys,
+	}
+	m.lastMsgLocker.Lock()
+	m.stall = stall
+	m.lastMsgLocker.Unlock()
+	return stall, nil
+}
+
+// GetStall return the stall DetectStall found, nil if the ceremony did not stall
+func (m *Manager) GetStall() *Stall {
+	m.lastMsgLocker.RLock()
+	defer m.lastMsgLocker.RUnlock()
+	return m.stall
+}
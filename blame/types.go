@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	btss "github.com/binance-chain/tss-lib/tss"
+
+	"github.com/akildemir/go-tss/errcode"
 )
 
 const (
@@ -13,15 +15,19 @@ const (
 	TssSyncFail   = "signers fail to sync before keygen/keysign"
 	TssBrokenMsg  = "tss share verification failed"
 	InternalError = "fail to start the join party "
+	InvalidSig    = "signature verification failed"
+	TssAborted    = "the node restarted in the ceremony"
+	TssShutdown   = "the node shut down in the ceremony"
 )
 
 var (
 	ErrHashFromOwner     = errors.New(" hash sent from data owner")
 	ErrNotEnoughPeer     = errors.New("not enough nodes to evaluate hash")
 	ErrNotMajority       = errors.New("message we received does not match the majority")
-	ErrTssTimeOut        = errors.New("error Tss Timeout")
+	ErrTssTimeOut        = errcode.New(errcode.TssTimeout, "error Tss Timeout")
 	ErrHashCheck         = errors.New("error in processing hash check")
 	ErrHashInconsistency = errors.New("fail to agree on the hash value")
+	ErrTssAborted        = errcode.New(errcode.PeerAborted, "a node restarted in the ceremony")
 )
 
 // PartyInfo the information used by tss key gen and key sign
@@ -0,0 +1,135 @@
+package blame
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	webhookQueueSize   = 64
+	webhookTimeout     = time.Second * 10
+	webhookMaxAttempts = 3
+	// DedupKeyHeader is the http header that carries the Event.DedupKey, so the consumers can drop the duplicates
+	// without decoding the body
+	DedupKeyHeader = "X-Dedup-Key"
+)
+
+// WebhookNotifier posts the blame events to the configured endpoint, the events are posted in the background,
+// so a slow endpoint never holds up the ceremonies
+type WebhookNotifier struct {
+	logger     zerolog.Logger
+	url        string
+	client     *http.Client
+	retryDelay time.Duration
+	events     chan Event
+	stopChan   chan struct{}
+	wg         *sync.WaitGroup
+}
+
+// NewWebhookNotifier create a new instance of WebhookNotifier that posts the events to the given url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	wn := &WebhookNotifier{
+		logger:     log.With().Str("module", "blame_webhook").Logger(),
+		url:        url,
+		client:     &http.Client{Timeout: webhookTimeout},
+		retryDelay: time.Second,
+		events:     make(chan Event, webhookQueueSize),
+		stopChan:   make(chan struct{}),
+		wg:         &sync.WaitGroup{},
+	}
+	wn.wg.Add(1)
+	go wn.process()
+	return wn
+}
+
+// Notify queue the event to be posted, the event is dropped if the queue is full
+func (wn *WebhookNotifier) Notify(ev Event) {
+	select {
+	case wn.events <- ev:
+	default:
+		wn.logger.Error().Str("dedup_key", ev.DedupKey).Msg("the blame webhook queue is full, drop the event")
+	}
+}
+
+// Stop the notifier, the events still in the queue are dropped
+func (wn *WebhookNotifier) Stop() {
+	close(wn.stopChan)
+	wn.wg.Wait()
+}
+
+func (wn *WebhookNotifier) process() {
+	defer wn.wg.Done()
+	for {
+		select {
+		case <-wn.stopChan:
+			return
+		case ev := <-wn.events:
+			if err := wn.post(ev); err != nil {
+				wn.logger.Error().Err(err).Str("dedup_key", ev.DedupKey).Msg("fail to post the blame event")
+			}
+		}
+	}
+}
+
+// post send the event to the endpoint, it retries on the network errors and the server errors
+func (wn *WebhookNotifier) post(ev Event) error {
+	buf, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the blame event: %w", err)
+	}
+	delay := wn.retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := wn.send(ev.DedupKey, buf)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookMaxAttempts {
+			return err
+		}
+		wn.logger.Warn().Err(err).Msgf("fail to post the blame event, retry in %s", delay)
+		select {
+		case <-wn.stopChan:
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// send post the body once, it returns whether it is worth retrying when it fails
+func (wn *WebhookNotifier) send(dedupKey string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, wn.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("fail to create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DedupKeyHeader, dedupKey)
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("fail to post the request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			wn.logger.Error().Err(err).Msg("fail to close the response body")
+		}
+	}()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	// the request is rejected, sending it again will not help
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return false, err
+	}
+	return true, err
+}
@@ -0,0 +1,67 @@
+package blame
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type WebhookTestSuite struct{}
+
+var _ = Suite(&WebhookTestSuite{})
+
+func (WebhookTestSuite) TestNotify(c *C) {
+	var calls int32
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first attempt fails, the notifier should retry
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev Event
+		c.Check(json.NewDecoder(r.Body).Decode(&ev), IsNil)
+		c.Check(r.Header.Get(DedupKeyHeader), Equals, ev.DedupKey)
+		received <- ev
+	}))
+	defer server.Close()
+
+	wn := NewWebhookNotifier(server.URL)
+	wn.retryDelay = time.Millisecond * 10
+	defer wn.Stop()
+	ev := NewEvent("keysign", "msg-id", "pool", testPubKeys[0], NewBlame(TssTimeout, []Node{NewNode(testPubKeys[1], nil, nil)}))
+	wn.Notify(ev)
+	select {
+	case got := <-received:
+		c.Assert(got.DedupKey, Equals, ev.DedupKey)
+		c.Assert(got.Severity, Equals, SeverityMedium)
+		c.Assert(got.Offenders, HasLen, 1)
+	case <-time.After(time.Second * 5):
+		c.Fatal("fail to receive the blame event")
+	}
+	c.Assert(atomic.LoadInt32(&calls), Equals, int32(2))
+}
+
+func (WebhookTestSuite) TestRejected(c *C) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	wn := NewWebhookNotifier(server.URL)
+	wn.retryDelay = time.Millisecond * 10
+	defer wn.Stop()
+	ev := NewEvent("keysign", "msg-id", "pool", testPubKeys[0], NewBlame(TssTimeout, nil))
+	// the rejected event is not retried
+	retry, err := wn.send(ev.DedupKey, []byte("{}"))
+	c.Assert(err, NotNil)
+	c.Assert(retry, Equals, false)
+	c.Assert(wn.post(ev), NotNil)
+	c.Assert(atomic.LoadInt32(&calls), Equals, int32(2))
+}
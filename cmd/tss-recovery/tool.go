@@ -4,6 +4,8 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +27,12 @@ type (
 		ParticipantKeys []string                  `json:"participant_keys"` // the paticipant of last key gen
 		LocalPartyKey   string                    `json:"local_party_key"`
 	}
+	// storedLocalState is the local state with its format version and the sha256 of its json
+	storedLocalState struct {
+		Version  int             `json:"version"`
+		Checksum string          `json:"checksum"`
+		State    json.RawMessage `json:"state"`
+	}
 )
 
 func getTssSecretFile(file string) (KeygenLocalState, error) {
@@ -36,6 +44,18 @@ func getTssSecretFile(file string) (KeygenLocalState, error) {
 	if err != nil {
 		return KeygenLocalState{}, fmt.Errorf("file to read from file(%s): %w", file, err)
 	}
+	var stored storedLocalState
+	if err := json.Unmarshal(buf, &stored); err != nil {
+		return KeygenLocalState{}, fmt.Errorf("fail to unmarshal the local state: %w", err)
+	}
+	// the local states saved before the checksum are the state itself
+	if len(stored.State) > 0 {
+		hash := sha256.Sum256(stored.State)
+		if hex.EncodeToString(hash[:]) != stored.Checksum {
+			return KeygenLocalState{}, errors.New("the checksum of the local state does not match, it is corrupted")
+		}
+		buf = stored.State
+	}
 	var localState KeygenLocalState
 	if err := json.Unmarshal(buf, &localState); nil != err {
 		return KeygenLocalState{}, fmt.Errorf("fail to unmarshal KeygenLocalState: %w", err)
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// adminRoutes return the admin api, every route of it needs the admin role
+func (t *TssHttpServer) adminRoutes() []route {
+	return []route{
+		{path: "/admin/peers", method: http.MethodGet, role: auth.RoleAdmin, handler: t.adminPeersHandler},
+		{path: "/admin/peers/ban", method: http.MethodPost, role: auth.RoleAdmin, handler: t.adminBanPeerHandler},
+		{path: "/admin/peers/unban", method: http.MethodPost, role: auth.RoleAdmin, handler: t.adminUnbanPeerHandler},
+		{path: "/admin/ceremonies", method: http.MethodGet, role: auth.RoleAdmin, handler: t.adminCeremoniesHandler},
+		{path: "/admin/ceremonies/cancel", method: http.MethodPost, role: auth.RoleAdmin, handler: t.adminCancelCeremonyHandler},
+		{path: "/admin/queue", method: http.MethodGet, role: auth.RoleAdmin, handler: t.adminQueueHandler},
+		{path: "/admin/audit", method: http.MethodGet, role: auth.RoleAdmin, handler: t.adminAuditHandler},
+		{path: "/admin/keys", method: http.MethodGet, role: auth.RoleAdmin, handler: t.listKeysHandler},
+		{path: "/admin/keys/healthcheck", method: http.MethodPost, role: auth.RoleAdmin, handler: t.healthCheckHandler},
+	}
+}
+
+// banRequest is the request to ban or unban a peer, the duration is only used by the ban
+type banRequest struct {
+	PeerID   string `json:"peer_id"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// cancelRequest is the request to cancel a ceremony by its msg id
+type cancelRequest struct {
+	MsgID string `json:"msg_id"`
+}
+
+// adminPeersHandler return the peer table with the latencies, the scores and the bans of the peers
+func (t *TssHttpServer) adminPeersHandler(w http.ResponseWriter, r *http.Request) {
+	t.writeJSON(w, t.server(r).GetPeers())
+}
+
+// adminBanPeerHandler ban the peer for the duration of the request and drop its connections
+func (t *TssHttpServer) adminBanPeerHandler(w http.ResponseWriter, r *http.Request) {
+	var req banRequest
+	if !t.decodeRequest(w, r, &req) {
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to parse the ban duration")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := t.server(r).BanPeer(req.PeerID, duration); err != nil {
+		t.logger.Error().Err(err).Msg("fail to ban the peer")
+		t.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// adminUnbanPeerHandler lift the ban or the quarantine on the peer, it is 404 if the peer is neither banned nor
+// quarantined
+func (t *TssHttpServer) adminUnbanPeerHandler(w http.ResponseWriter, r *http.Request) {
+	var req banRequest
+	if !t.decodeRequest(w, r, &req) {
+		return
+	}
+	unbanned, err := t.server(r).UnbanPeer(req.PeerID)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to unban the peer")
+		t.writeError(w, err)
+		return
+	}
+	if !unbanned {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// adminCeremoniesHandler return the ceremonies we take part in and their round
+func (t *TssHttpServer) adminCeremoniesHandler(w http.ResponseWriter, r *http.Request) {
+	t.writeJSON(w, t.server(r).GetActiveCeremonies())
+}
+
+// adminCancelCeremonyHandler cancel the running or the queued ceremony, it is 404 if we do not run it
+func (t *TssHttpServer) adminCancelCeremonyHandler(w http.ResponseWriter, r *http.Request) {
+	var req cancelRequest
+	if !t.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := t.server(r).CancelCeremony(req.MsgID); err != nil {
+		t.logger.Error().Err(err).Str("msg id", req.MsgID).Msg("fail to cancel the ceremony")
+		t.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// adminQueueHandler return the keysigns waiting in the queue
+func (t *TssHttpServer) adminQueueHandler(w http.ResponseWriter, r *http.Request) {
+	t.writeJSON(w, t.server(r).GetQueuedKeySigns())
+}
+
+// adminAuditHandler export the records of the audit log from the seq of the from query on, all of them without it
+func (t *TssHttpServer) adminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	var fromSeq uint64
+	if from := r.URL.Query().Get("from"); len(from) != 0 {
+		var err error
+		fromSeq, err = strconv.ParseUint(from, 10, 64)
+		if err != nil {
+			t.writeError(w, errcode.Errorf(errcode.BadRequest, "fail to parse the from seq: %w", err))
+			return
+		}
+	}
+	records, err := t.server(r).ExportAuditLog(fromSeq)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to export the audit log")
+		t.writeError(w, err)
+		return
+	}
+	t.writeJSON(w, records)
+}
+
+// decodeRequest decode the body of the request, it writes 400 and return false if it can not
+func (t *TssHttpServer) decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	if err := json.NewDecoder(r.Body).Decode(req); nil != err {
+		t.logger.Error().Err(err).Msgf("fail to decode the request of %s", r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON write the value as the json response
+func (t *TssHttpServer) writeJSON(w http.ResponseWriter, value interface{}) {
+	t.writeJSONStatus(w, http.StatusOK, value)
+}
+
+// writeJSONStatus write the value as the json response with the status code
+func (t *TssHttpServer) writeJSONStatus(w http.ResponseWriter, status int, value interface{}) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf); err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
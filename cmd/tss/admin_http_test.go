@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/storage"
+	"github.com/akildemir/go-tss/tss"
+)
+
+type AdminHttpTestSuite struct{}
+
+var _ = Suite(&AdminHttpTestSuite{})
+
+func (AdminHttpTestSuite) TestAdminRoutes(c *C) {
+	authorizer, err := auth.NewAuthorizer(auth.Config{
+		APIKeys: []auth.APIKey{
+			{Name: "signer", Key: "signer-key-0123456789", Role: auth.RoleSign},
+			{Name: "admin", Key: "admin-key-0123456789", Role: auth.RoleAdmin},
+		},
+	})
+	c.Assert(err, IsNil)
+	handler := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}, WithAuthorizer(authorizer)).tssNewHandler()
+	serve := func(method, route, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, route, bytes.NewBufferString(body))
+		if len(key) != 0 {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	c.Assert(serve(http.MethodGet, "/admin/peers", "", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(serve(http.MethodGet, "/admin/peers", "signer-key-0123456789", "").Code, Equals, http.StatusForbidden)
+
+	const key = "admin-key-0123456789"
+	res := serve(http.MethodGet, "/admin/peers", key, "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var peers []tss.PeerInfo
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &peers), IsNil)
+	c.Assert(peers, HasLen, 1)
+	c.Assert(peers[0].Connected, Equals, true)
+
+	c.Assert(serve(http.MethodPost, "/admin/peers/ban", key, `{"peer_id":"peer","duration":"1h"}`).Code, Equals, http.StatusOK)
+	c.Assert(serve(http.MethodPost, "/admin/peers/ban", key, `{"peer_id":"peer","duration":"forever"}`).Code, Equals, http.StatusBadRequest)
+	c.Assert(serve(http.MethodPost, "/admin/peers/ban", key, `{"peer_id":"other","duration":"1h"}`).Code, Equals, http.StatusBadRequest)
+	c.Assert(serve(http.MethodPost, "/admin/peers/unban", key, `{"peer_id":"peer"}`).Code, Equals, http.StatusOK)
+	c.Assert(serve(http.MethodPost, "/admin/peers/unban", key, `{"peer_id":"other"}`).Code, Equals, http.StatusNotFound)
+
+	res = serve(http.MethodGet, "/admin/ceremonies", key, "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var ceremonies []storage.CeremonyRecord
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &ceremonies), IsNil)
+	c.Assert(ceremonies, HasLen, 1)
+	c.Assert(ceremonies[0].Round, Equals, "round1")
+	c.Assert(serve(http.MethodPost, "/admin/ceremonies/cancel", key, `{"msg_id":"msg"}`).Code, Equals, http.StatusOK)
+	c.Assert(serve(http.MethodPost, "/admin/ceremonies/cancel", key, `{"msg_id":"other"}`).Code, Equals, http.StatusNotFound)
+	c.Assert(serve(http.MethodPost, "/admin/ceremonies/cancel", key, `{`).Code, Equals, http.StatusBadRequest)
+
+	res = serve(http.MethodGet, "/admin/queue", key, "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var queued []tss.QueuedKeySign
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &queued), IsNil)
+	c.Assert(queued, DeepEquals, []tss.QueuedKeySign{{MsgID: "queued", PoolPubKey: "pool"}})
+
+	res = serve(http.MethodGet, "/admin/audit?from=2", key, "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var records []storage.AuditRecord
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &records), IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Seq, Equals, uint64(2))
+	c.Assert(serve(http.MethodGet, "/admin/audit?from=last", key, "").Code, Equals, http.StatusBadRequest)
+
+	c.Assert(serve(http.MethodGet, "/admin/keys", key, "").Code, Equals, http.StatusOK)
+	c.Assert(serve(http.MethodPost, "/admin/keys/healthcheck", key, `{"pool_pub_key":"pool","block_height":10}`).Code, Equals, http.StatusOK)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/keysign"
+)
+
+// maxBatchKeySigns is the number of the keysigns a batch can carry
+const maxBatchKeySigns = 64
+
+// batchKeySignRequest is the request of the batch keysign, the keysigns run at the same time, the keysign queue
+// still limits how many of them sign at once
+type batchKeySignRequest struct {
+	Requests []keysign.Request `json:"requests"`
+}
+
+// batchKeySignResult is the outcome of a keysign of the batch, either the response or the error
+type batchKeySignResult struct {
+	Response *keysign.Response `json:"response,omitempty"`
+	Error    *errorResponse    `json:"error,omitempty"`
+}
+
+// batchKeySignResponse carries the results of the keysigns in the order of the requests
+type batchKeySignResponse struct {
+	Results []batchKeySignResult `json:"results"`
+}
+
+// batchKeySignHandler run the keysigns of the batch, a failed keysign does not fail the batch, its result carries
+// the error
+func (t *TssHttpServer) batchKeySignHandler(w http.ResponseWriter, r *http.Request) {
+	var req batchKeySignRequest
+	if !t.decodeRequest(w, r, &req) {
+		return
+	}
+	if len(req.Requests) == 0 || len(req.Requests) > maxBatchKeySigns {
+		t.writeError(w, errcode.Errorf(errcode.BadRequest, "a batch carries 1 to %d keysigns, not %d", maxBatchKeySigns, len(req.Requests)))
+		return
+	}
+	resp := batchKeySignResponse{
+		Results: make([]batchKeySignResult, len(req.Requests)),
+	}
+	wg := &sync.WaitGroup{}
+	for i, el := range req.Requests {
+		wg.Add(1)
+		go func(i int, keySignReq keysign.Request) {
+			defer wg.Done()
+			// the keysigns are cancelled if the caller goes away
+			signResp, err := t.server(r).KeySignContext(r.Context(), keySignReq)
+			if err != nil {
+				t.logger.Error().Err(err).Int("index", i).Msg("fail to key sign in the batch")
+				errResp := newErrorResponse(err)
+				resp.Results[i].Error = &errResp
+				return
+			}
+			resp.Results[i].Response = &signResp
+		}(i, el)
+	}
+	wg.Wait()
+	t.writeJSON(w, resp)
+}
+
+// keygenJobHandler start the keygen of the request as an async job
+func (t *TssHttpServer) keygenJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req keygen.Request
+	if !t.decodeRequest(w, r, &req) {
+		return
+	}
+	server := t.server(r)
+	t.startJob(w, r, "keygen", func(ctx context.Context) (interface{}, error) {
+		resp, err := server.KeygenContext(ctx, req)
+		return resp, err
+	})
+}
+
+// keySignJobHandler start the keysign of the request as an async job
+func (t *TssHttpServer) keySignJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req keysign.Request
+	if !t.decodeRequest(w, r, &req) {
+		return
+	}
+	server := t.server(r)
+	// the job outlives the request, it keeps the name of the caller for the audit log
+	requester := common.RequesterFromContext(r.Context())
+	t.startJob(w, r, "keysign", func(ctx context.Context) (interface{}, error) {
+		resp, err := server.KeySignContext(common.WithRequester(ctx, requester), req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+}
+
+// startJob start the job of the tenant of the request, the response is 202 with the job and the Location of it
+func (t *TssHttpServer) startJob(w http.ResponseWriter, r *http.Request, kind string, run func(ctx context.Context) (interface{}, error)) {
+	j, err := t.jobs.start(t.tenant(r), kind, run)
+	if err != nil {
+		t.logger.Error().Err(err).Msgf("fail to start the %s job", kind)
+		t.writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", apiV2+"/jobs/"+j.ID)
+	t.writeJSONStatus(w, http.StatusAccepted, j)
+}
+
+// getJobHandler return the job, it carries the result once the job is no longer running, the jobs of the other
+// tenants are not found
+func (t *TssHttpServer) getJobHandler(w http.ResponseWriter, r *http.Request) {
+	j, ok := t.jobs.get(t.tenant(r), mux.Vars(r)["id"])
+	if !ok {
+		t.writeError(w, errcode.New(errcode.NotFound, "the job is not found"))
+		return
+	}
+	t.writeJSON(w, j)
+}
+
+// cancelJobHandler cancel the job if it is still running, the job is cancelled once its ceremony aborts
+func (t *TssHttpServer) cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	j, ok := t.jobs.cancelJob(t.tenant(r), mux.Vars(r)["id"])
+	if !ok {
+		t.writeError(w, errcode.New(errcode.NotFound, "the job is not found"))
+		return
+	}
+	t.writeJSONStatus(w, http.StatusAccepted, j)
+}
+
+// tenantsHandler return the ids of the tenants the node serves
+func (t *TssHttpServer) tenantsHandler(w http.ResponseWriter, _ *http.Request) {
+	t.writeJSON(w, t.tenants.IDs())
+}
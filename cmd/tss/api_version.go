@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// the versions of the http api, a version never changes once it is released, the changes that are not backward
+// compatible go to the next one. The routes of v1 are also served at their unversioned paths, the api before the
+// versions, those are deprecated, their responses carry the Deprecation header, the Link header of the v1 route
+// and the Sunset header once the operator sets the date they are removed
+const (
+	apiV1 = "/v1"
+	apiV2 = "/v2"
+)
+
+const (
+	// deprecationHeader tells the caller the route is deprecated, RFC 9745
+	deprecationHeader = "Deprecation"
+	// sunsetHeader is the date the deprecated route is removed, RFC 8594
+	sunsetHeader = "Sunset"
+	// linkHeader points the caller to the route that replaces the deprecated one
+	linkHeader = "Link"
+)
+
+// route is a route of the http api and the role its callers need
+type route struct {
+	path    string
+	method  string
+	role    auth.Role
+	handler http.HandlerFunc
+}
+
+// WithLegacySunset announce the date the unversioned routes are removed, the zero time leaves it out
+func WithLegacySunset(sunset time.Time) HttpServerOption {
+	return func(hs *TssHttpServer) {
+		hs.legacySunset = sunset
+	}
+}
+
+// v1Routes return the routes of v1, they are the api before the versions, v1 keygen and keysign only take the
+// secp256k1 keys
+func (t *TssHttpServer) v1Routes() []route {
+	routes := []route{
+		{path: "/keygen", method: http.MethodPost, role: auth.RoleAdmin, handler: t.keygenHandler},
+		{path: "/keysign", method: http.MethodPost, role: auth.RoleSign, handler: t.keySignHandler},
+		{path: "/reshare", method: http.MethodPost, role: auth.RoleAdmin, handler: t.reshareHandler},
+		{path: "/healthcheck", method: http.MethodPost, role: auth.RoleSign, handler: t.healthCheckHandler},
+		{path: "/retire", method: http.MethodPost, role: auth.RoleAdmin, handler: t.retireHandler},
+		{path: "/delete", method: http.MethodPost, role: auth.RoleAdmin, handler: t.deleteHandler},
+		{path: "/p2pid", method: http.MethodGet, role: auth.RoleRead, handler: t.getP2pIDHandler},
+		{path: "/status", method: http.MethodGet, role: auth.RoleRead, handler: t.getStatusHandler},
+		{path: "/derivepubkey", method: http.MethodGet, role: auth.RoleRead, handler: t.derivePubKeyHandler},
+		{path: "/keys", method: http.MethodGet, role: auth.RoleRead, handler: t.listKeysHandler},
+		{path: "/peerstats", method: http.MethodGet, role: auth.RoleRead, handler: t.getPeerStatsHandler},
+	}
+	return append(routes, t.adminRoutes()...)
+}
+
+// v2Routes return the routes of v2, it is v1 with the keygen and the keysign of all the algos, the batch keysign,
+// the async jobs and the tenants
+func (t *TssHttpServer) v2Routes() []route {
+	replaced := map[string]http.HandlerFunc{
+		"/keygen":  t.keygenV2Handler,
+		"/keysign": t.keySignV2Handler,
+	}
+	var routes []route
+	for _, el := range t.v1Routes() {
+		if handler, ok := replaced[el.path]; ok {
+			el.handler = handler
+		}
+		routes = append(routes, el)
+	}
+	return append(routes,
+		route{path: "/keysign/batch", method: http.MethodPost, role: auth.RoleSign, handler: t.batchKeySignHandler},
+		route{path: "/jobs/keygen", method: http.MethodPost, role: auth.RoleAdmin, handler: t.keygenJobHandler},
+		route{path: "/jobs/keysign", method: http.MethodPost, role: auth.RoleSign, handler: t.keySignJobHandler},
+		route{path: "/jobs/{id}", method: http.MethodGet, role: auth.RoleSign, handler: t.getJobHandler},
+		route{path: "/jobs/{id}", method: http.MethodDelete, role: auth.RoleAdmin, handler: t.cancelJobHandler},
+		route{path: "/tenants", method: http.MethodGet, role: auth.RoleRead, handler: t.tenantsHandler},
+	)
+}
+
+// versionRoutes registers the versions of the api, and the v1 routes at their deprecated unversioned paths
+func (t *TssHttpServer) versionRoutes(router *mux.Router) {
+	v1 := t.v1Routes()
+	for _, el := range v1 {
+		router.Handle(apiV1+el.path, t.authorize(el.role, el.handler)).Methods(el.method)
+	}
+	for _, el := range t.v2Routes() {
+		router.Handle(apiV2+el.path, t.authorize(el.role, el.handler)).Methods(el.method)
+	}
+	for _, el := range v1 {
+		router.Handle(el.path, t.deprecated(apiV1+el.path, t.authorize(el.role, el.handler))).Methods(el.method)
+	}
+}
+
+// deprecated tell the callers of the route it is deprecated and which route replaces it, the first call of each
+// deprecated route is logged so the operator can tell the integrators who still use it
+func (t *TssHttpServer) deprecated(successor string, handler http.Handler) http.Handler {
+	once := &sync.Once{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			t.logger.Warn().Str("route", r.URL.Path).Str("successor", successor).Msg("the deprecated route is called")
+		})
+		w.Header().Set(deprecationHeader, "true")
+		w.Header().Set(linkHeader, "<"+successor+`>; rel="successor-version"`)
+		if !t.legacySunset.IsZero() {
+			w.Header().Set(sunsetHeader, t.legacySunset.UTC().Format(http.TimeFormat))
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// checkV1Algo refuse the keys of the algos other than secp256k1, v1 only takes those, the others need v2
+func checkV1Algo(algo conversion.Algo) error {
+	if algo.OrDefault() != conversion.AlgoSecp256k1 {
+		return errcode.Errorf(errcode.BadRequest, "the %s keys are only supported by %s", algo, apiV2)
+	}
+	return nil
+}
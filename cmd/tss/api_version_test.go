@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/keysign"
+)
+
+type ApiVersionTestSuite struct{}
+
+var _ = Suite(&ApiVersionTestSuite{})
+
+func serveRoute(handler http.Handler, method, route, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, route, bytes.NewBufferString(body))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func (ApiVersionTestSuite) TestVersionRoutes(c *C) {
+	sunset := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	handler := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}, WithLegacySunset(sunset)).tssNewHandler()
+
+	// the versioned routes are not deprecated
+	for _, el := range []string{"/v1/peerstats", "/v2/peerstats", "/v1/admin/queue", "/v2/admin/queue", "/ping", "/health"} {
+		res := serveRoute(handler, http.MethodGet, el, "")
+		c.Assert(res.Code, Equals, http.StatusOK, Commentf(el))
+		c.Assert(res.Header().Get(deprecationHeader), Equals, "", Commentf(el))
+	}
+	// the unversioned routes are the deprecated aliases of v1
+	res := serveRoute(handler, http.MethodGet, "/peerstats", "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Header().Get(deprecationHeader), Equals, "true")
+	c.Assert(res.Header().Get(linkHeader), Equals, `</v1/peerstats>; rel="successor-version"`)
+	c.Assert(res.Header().Get(sunsetHeader), Equals, "Sun, 31 Jan 2027 00:00:00 GMT")
+	res = serveRoute(handler, http.MethodGet, "/admin/queue", "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Header().Get(linkHeader), Equals, `</v1/admin/queue>; rel="successor-version"`)
+	// the new capabilities are only in v2
+	c.Assert(serveRoute(handler, http.MethodPost, "/v1/keysign/batch", `{}`).Code, Equals, http.StatusNotFound)
+	c.Assert(serveRoute(handler, http.MethodGet, "/v1/jobs/id", "").Code, Equals, http.StatusNotFound)
+
+	handler = NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}).tssNewHandler()
+	res = serveRoute(handler, http.MethodGet, "/peerstats", "")
+	c.Assert(res.Header().Get(deprecationHeader), Equals, "true")
+	c.Assert(res.Header().Get(sunsetHeader), Equals, "")
+}
+
+func (ApiVersionTestSuite) TestAlgo(c *C) {
+	handler := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}).tssNewHandler()
+	keygenReq := `{"keys":["a","b","c"],"algo":"ed25519"}`
+	keySignReq := `{"pool_pub_key":"pool","messages":["aGVsbG8="],"algo":"ed25519"}`
+	for _, el := range []string{"/keygen", "/v1/keygen"} {
+		res := serveRoute(handler, http.MethodPost, el, keygenReq)
+		c.Assert(res.Code, Equals, http.StatusBadRequest, Commentf(el))
+		var resp errorResponse
+		c.Assert(json.Unmarshal(res.Body.Bytes(), &resp), IsNil)
+		c.Assert(resp.Code, Equals, errcode.BadRequest)
+	}
+	for _, el := range []string{"/keysign", "/v1/keysign"} {
+		c.Assert(serveRoute(handler, http.MethodPost, el, keySignReq).Code, Equals, http.StatusBadRequest, Commentf(el))
+	}
+	// v1 still takes the secp256k1 keys
+	c.Assert(serveRoute(handler, http.MethodPost, "/v1/keygen", `{"keys":["a","b","c"],"algo":"secp256k1"}`).Code, Equals, http.StatusOK)
+	// v2 passes the algo to the tss server
+	c.Assert(serveRoute(handler, http.MethodPost, "/v2/keygen", keygenReq).Code, Equals, http.StatusOK)
+	c.Assert(serveRoute(handler, http.MethodPost, "/v2/keysign", keySignReq).Code, Equals, http.StatusOK)
+}
+
+func (ApiVersionTestSuite) TestBatchKeySign(c *C) {
+	tssServer := &MockTssServer{}
+	handler := NewTssHttpServer("127.0.0.1:8080", tssServer).tssNewHandler()
+	c.Assert(serveRoute(handler, http.MethodPost, "/v2/keysign/batch", `{`).Code, Equals, http.StatusBadRequest)
+	c.Assert(serveRoute(handler, http.MethodPost, "/v2/keysign/batch", `{"requests":[]}`).Code, Equals, http.StatusBadRequest)
+
+	res := serveRoute(handler, http.MethodPost, "/v2/keysign/batch", `{"requests":[{"pool_pub_key":"a"},{"pool_pub_key":"b"}]}`)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var resp batchKeySignResponse
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &resp), IsNil)
+	c.Assert(resp.Results, HasLen, 2)
+	for _, el := range resp.Results {
+		c.Assert(el.Error, IsNil)
+		c.Assert(el.Response, NotNil)
+	}
+
+	// a failed keysign does not fail the batch
+	tssServer.invalidSig = true
+	res = serveRoute(handler, http.MethodPost, "/v2/keysign/batch", `{"requests":[{"pool_pub_key":"a"}]}`)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	resp = batchKeySignResponse{}
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &resp), IsNil)
+	c.Assert(resp.Results, HasLen, 1)
+	c.Assert(resp.Results[0].Response, IsNil)
+	c.Assert(resp.Results[0].Error, NotNil)
+	c.Assert(resp.Results[0].Error.Code, Equals, errcode.InvalidSignature)
+}
+
+// waitJob poll the job until it is no longer running
+func waitJob(c *C, handler http.Handler, location string) job {
+	for i := 0; i < 100; i++ {
+		res := serveRoute(handler, http.MethodGet, location, "")
+		c.Assert(res.Code, Equals, http.StatusOK)
+		var j job
+		c.Assert(json.Unmarshal(res.Body.Bytes(), &j), IsNil)
+		if j.Status != jobRunning {
+			return j
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	c.Fatal("the job is still running")
+	return job{}
+}
+
+func (ApiVersionTestSuite) TestJobs(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	defer s.jobs.stop()
+	handler := s.tssNewHandler()
+	c.Assert(serveRoute(handler, http.MethodPost, "/v2/jobs/keygen", `{`).Code, Equals, http.StatusBadRequest)
+	c.Assert(serveRoute(handler, http.MethodGet, "/v2/jobs/unknown", "").Code, Equals, http.StatusNotFound)
+	c.Assert(serveRoute(handler, http.MethodDelete, "/v2/jobs/unknown", "").Code, Equals, http.StatusNotFound)
+
+	res := serveRoute(handler, http.MethodPost, "/v2/jobs/keygen", `{"keys":["a","b","c"]}`)
+	c.Assert(res.Code, Equals, http.StatusAccepted)
+	var started job
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &started), IsNil)
+	c.Assert(started.Kind, Equals, "keygen")
+	c.Assert(res.Header().Get("Location"), Equals, "/v2/jobs/"+started.ID)
+	j := waitJob(c, handler, res.Header().Get("Location"))
+	c.Assert(j.Status, Equals, jobSucceeded)
+	c.Assert(j.Finished, NotNil)
+	c.Assert(j.Error, IsNil)
+	buf, err := json.Marshal(j.Result)
+	c.Assert(err, IsNil)
+	var keygenResp keygen.Response
+	c.Assert(json.Unmarshal(buf, &keygenResp), IsNil)
+	c.Assert(keygenResp.PubKey, Not(Equals), "")
+
+	tssServer.invalidSig = true
+	res = serveRoute(handler, http.MethodPost, "/v2/jobs/keysign", `{"pool_pub_key":"pool"}`)
+	c.Assert(res.Code, Equals, http.StatusAccepted)
+	j = waitJob(c, handler, res.Header().Get("Location"))
+	c.Assert(j.Status, Equals, jobFailed)
+	c.Assert(j.Error, NotNil)
+	c.Assert(j.Error.Code, Equals, errcode.InvalidSignature)
+
+	// the job goes on once the caller goes away, until it is cancelled
+	tssServer.invalidSig = false
+	tssServer.blockKeySign = true
+	res = serveRoute(handler, http.MethodPost, "/v2/jobs/keysign", `{"pool_pub_key":"pool"}`)
+	c.Assert(res.Code, Equals, http.StatusAccepted)
+	location := res.Header().Get("Location")
+	res = serveRoute(handler, http.MethodGet, location, "")
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &j), IsNil)
+	c.Assert(j.Status, Equals, jobRunning)
+	c.Assert(serveRoute(handler, http.MethodDelete, location, "").Code, Equals, http.StatusAccepted)
+	j = waitJob(c, handler, location)
+	c.Assert(j.Status, Equals, jobCancelled)
+	c.Assert(j.Error.Code, Equals, errcode.Cancelled)
+}
+
+func (ApiVersionTestSuite) TestJobStore(c *C) {
+	store := newJobStore()
+	release := make(chan struct{})
+	started, err := store.start(common.DefaultTenant, "keysign", func(ctx context.Context) (interface{}, error) {
+		<-release
+		return keysign.Response{}, nil
+	})
+	c.Assert(err, IsNil)
+	close(release)
+	store.stop()
+	j, ok := store.get(common.DefaultTenant, started.ID)
+	c.Assert(ok, Equals, true)
+	c.Assert(j.Status, Equals, jobSucceeded)
+	// the finished jobs are pruned once the retention passes
+	store.lock.Lock()
+	store.prune(time.Now().Add(jobRetention * 2))
+	store.lock.Unlock()
+	_, ok = store.get(common.DefaultTenant, started.ID)
+	c.Assert(ok, Equals, false)
+	// no job starts once the store is stopped
+	_, err = store.start(common.DefaultTenant, "keysign", func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	c.Assert(errcode.Of(err), Equals, errcode.ShuttingDown)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/webhook"
+)
+
+// envPrefix is the prefix of the environment variables that set the flags, TSS_P2P_PORT sets -p2p-port
+const envPrefix = "TSS_"
+
+// reloadableFlags are the flags a SIGHUP applies without a restart
+var reloadableFlags = map[string]bool{
+	"loglevel":     true,
+	"peer":         true,
+	"allowed-peer": true,
+}
+
+// cliOnlyFlags can not be set in the config file or the environment
+var cliOnlyFlags = map[string]bool{
+	"config": true,
+	"h":      true,
+}
+
+// configLoader set the flags the command line leaves unset from the environment and the config file, the command
+// line wins over the environment and the environment wins over the config file
+type configLoader struct {
+	fs        *flag.FlagSet
+	path      string
+	lookupEnv func(string) (string, bool)
+	// explicit are the flags set on the command line, they are never overridden
+	explicit map[string]bool
+	// values are the values of the flags we loaded, a flag repeated on the command line has several
+	values map[string][]string
+}
+
+// newConfigLoader create the loader of the config file of the path, the flag set must be parsed already, an
+// empty path only reads the environment
+func newConfigLoader(fs *flag.FlagSet, path string) *configLoader {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return &configLoader{
+		fs:        fs,
+		path:      path,
+		lookupEnv: os.LookupEnv,
+		explicit:  explicit,
+		values:    make(map[string][]string),
+	}
+}
+
+// envName return the environment variable of the flag
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// isListFlag return true if the flag can be repeated, the flags of the flag package can not, the ones we define
+// with flag.Var can
+func isListFlag(f *flag.Flag) bool {
+	_, ok := f.Value.(flag.Getter)
+	return !ok
+}
+
+// readConfigFile read the yaml config file, the keys are the names of the flags and the values are a value or a
+// list of the values of the repeated flags
+func readConfigFile(path string) (map[string][]string, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the config file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(buf, &raw); err != nil {
+		return nil, fmt.Errorf("fail to parse the config file: %w", err)
+	}
+	values := make(map[string][]string, len(raw))
+	for name, el := range raw {
+		switch v := el.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			for _, item := range v {
+				value, err := scalarValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value of %s: %w", name, err)
+				}
+				values[name] = append(values[name], value)
+			}
+		default:
+			value, err := scalarValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", name, err)
+			}
+			values[name] = []string{value}
+		}
+	}
+	return values, nil
+}
+
+func scalarValue(v interface{}) (string, error) {
+	switch v.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", errors.New("expect a value or a list of values")
+	}
+}
+
+// read return the values of the flags the command line leaves unset, from the config file and the environment,
+// the unknown keys are refused
+func (l *configLoader) read() (map[string][]string, error) {
+	values := make(map[string][]string)
+	if len(l.path) > 0 {
+		fromFile, err := readConfigFile(l.path)
+		if err != nil {
+			return nil, err
+		}
+		for name, el := range fromFile {
+			f := l.fs.Lookup(name)
+			if f == nil || cliOnlyFlags[name] {
+				return nil, fmt.Errorf("unknown key %s in the config file", name)
+			}
+			if len(el) > 1 && !isListFlag(f) {
+				return nil, fmt.Errorf("%s takes a single value", name)
+			}
+			values[name] = el
+		}
+	}
+	l.fs.VisitAll(func(f *flag.Flag) {
+		if cliOnlyFlags[f.Name] {
+			return
+		}
+		value, ok := l.lookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if !isListFlag(f) {
+			values[f.Name] = []string{value}
+			return
+		}
+		// the repeated flags take the values separated by commas
+		values[f.Name] = nil
+		for _, el := range strings.Split(value, ",") {
+			if el = strings.TrimSpace(el); len(el) > 0 {
+				values[f.Name] = append(values[f.Name], el)
+			}
+		}
+	})
+	for name := range l.explicit {
+		delete(values, name)
+	}
+	return values, nil
+}
+
+// load set the flags the command line leaves unset from the environment and the config file
+func (l *configLoader) load() error {
+	values, err := l.read()
+	if err != nil {
+		return err
+	}
+	for name, el := range values {
+		for _, value := range el {
+			if err := l.fs.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value %q of %s: %w", value, name, err)
+			}
+		}
+	}
+	l.values = values
+	return nil
+}
+
+// reload read the config file and the environment again, it return the new values of the reloadable flags that
+// changed, and the names of the other flags that changed, they need a restart. A reloadable flag that is no longer
+// set is back to its default
+func (l *configLoader) reload() (map[string][]string, []string, error) {
+	values, err := l.read()
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make(map[string]bool)
+	for name := range values {
+		names[name] = true
+	}
+	for name := range l.values {
+		names[name] = true
+	}
+	changed := make(map[string][]string)
+	var restart []string
+	for name := range names {
+		if equalValues(l.values[name], values[name]) {
+			continue
+		}
+		if !reloadableFlags[name] {
+			restart = append(restart, name)
+			continue
+		}
+		value, ok := values[name]
+		if f := l.fs.Lookup(name); !ok && !isListFlag(f) {
+			value = []string{f.DefValue}
+		}
+		changed[name] = value
+		l.values[name] = values[name]
+	}
+	sort.Strings(restart)
+	return changed, restart, nil
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateConfig check the values of the flags once they are loaded, so a typo in the config file fails the
+// startup instead of the first ceremony
+func validateConfig(tssConf common.TssConfig, p2pConf p2p.Config) error {
+	if _, err := zerolog.ParseLevel(logLevel); err != nil {
+		return fmt.Errorf("invalid loglevel %q: %w", logLevel, err)
+	}
+	if tssConf.KeyGenTimeout <= 0 || tssConf.KeySignTimeout <= 0 || tssConf.PreParamTimeout <= 0 {
+		return errors.New("gentimeout, signtimeout and preparamtimeout must be positive")
+	}
+	if p2pConf.Port <= 0 || p2pConf.Port > 65535 {
+		return fmt.Errorf("invalid p2p-port %d", p2pConf.Port)
+	}
+	switch stateBackend {
+	case stateBackendFile, stateBackendVault, stateBackendKMS, stateBackendSqlite, stateBackendMemory:
+	default:
+		return fmt.Errorf("unknown state-backend %q", stateBackend)
+	}
+	for name, el := range map[string]int{
+		"max-ceremonies":      tssConf.MaxConcurrentCeremonies,
+		"max-keysigns":        tssConf.MaxConcurrentKeySigns,
+		"max-keysign-queue":   tssConf.MaxKeySignQueue,
+		"presign-pool-size":   tssConf.PresignPoolSize,
+		"preparams-pool-size": tssConf.PreParamsPoolSize,
+		"round-cache-size":    tssConf.RoundCacheSize,
+		"ceremony-log-lines":  tssConf.CeremonyLogLines,
+		"gossip-min-parties":  tssConf.GossipMinParties,
+	} {
+		if el < 0 {
+			return fmt.Errorf("%s can not be negative", name)
+		}
+	}
+	for _, el := range tssConf.WebhookEvents {
+		if _, err := webhook.ParseEventType(el); err != nil {
+			return err
+		}
+	}
+	if _, err := parseSunset(legacyAPISunset); err != nil {
+		return fmt.Errorf("invalid legacy-api-sunset %q: %w", legacyAPISunset, err)
+	}
+	if tssConf.DrainTimeout < 0 || tssConf.KeyDeletionCoolingOff < 0 || tssConf.RetiredKeyRetention < 0 {
+		return errors.New("drain-timeout, key-deletion-cooling-off and retired-key-retention can not be negative")
+	}
+	return nil
+}
+
+// parseSunset parse the YYYY-MM-DD sunset date, the empty one is the zero time
+func parseSunset(date string) (time.Time, error) {
+	if len(date) == 0 {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// reloadConfig apply the reloadable flags of the config file and the environment that changed, the bootstrap peers
+// we saved are kept
+func reloadConfig(loader *configLoader, comm *p2p.Communication, savedPeers []p2p.Multiaddr) error {
+	changed, restart, err := loader.reload()
+	if err != nil {
+		return fmt.Errorf("fail to reload the config: %w", err)
+	}
+	for _, el := range restart {
+		log.Printf("the change of %s needs a restart", el)
+	}
+	if value, ok := changed["loglevel"]; ok {
+		level, err := zerolog.ParseLevel(value[0])
+		if err != nil {
+			return fmt.Errorf("invalid loglevel %q: %w", value[0], err)
+		}
+		zerolog.SetGlobalLevel(level)
+		log.Printf("the log level is set to %s", level)
+	}
+	if value, ok := changed["peer"]; ok {
+		bootstrapPeers := append([]p2p.Multiaddr{}, savedPeers...)
+		for _, el := range value {
+			addr, err := maddr.NewMultiaddr(el)
+			if err != nil {
+				return fmt.Errorf("invalid peer %q: %w", el, err)
+			}
+			bootstrapPeers = append(bootstrapPeers, addr)
+		}
+		comm.SetBootstrapPeers(bootstrapPeers)
+	}
+	if value, ok := changed["allowed-peer"]; ok {
+		allowedPeers := make([]peer.ID, 0, len(value))
+		for _, el := range value {
+			pID, err := peer.Decode(el)
+			if err != nil {
+				return fmt.Errorf("invalid allowed-peer %q: %w", el, err)
+			}
+			allowedPeers = append(allowedPeers, pID)
+		}
+		comm.SetAllowedPeers(allowedPeers)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+type ConfigTestSuite struct{}
+
+var _ = Suite(&ConfigTestSuite{})
+
+type testFlags struct {
+	logLevel string
+	port     int
+	timeout  time.Duration
+	peers    common.StringList
+}
+
+func newTestFlagSet(args []string) (*flag.FlagSet, *testFlags, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	tf := &testFlags{}
+	fs.String("config", "", "config file")
+	fs.StringVar(&tf.logLevel, "loglevel", "info", "log level")
+	fs.IntVar(&tf.port, "p2p-port", 6668, "p2p port")
+	fs.DurationVar(&tf.timeout, "signtimeout", 30*time.Second, "keysign timeout")
+	fs.Var(&tf.peers, "peer", "bootstrap peer")
+	return fs, tf, fs.Parse(args)
+}
+
+func writeConfig(c *C, path, content string) {
+	c.Assert(ioutil.WriteFile(path, []byte(content), 0o600), IsNil)
+}
+
+func (ConfigTestSuite) TestLoad(c *C) {
+	path := filepath.Join(c.MkDir(), "config.yaml")
+	writeConfig(c, path, `
+loglevel: debug
+p2p-port: 7000
+signtimeout: 1m
+peer:
+  - a
+  - b
+`)
+	fs, tf, err := newTestFlagSet([]string{"-p2p-port", "8000"})
+	c.Assert(err, IsNil)
+	loader := newConfigLoader(fs, path)
+	env := map[string]string{"TSS_SIGNTIMEOUT": "2m"}
+	loader.lookupEnv = func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	c.Assert(loader.load(), IsNil)
+	c.Assert(tf.logLevel, Equals, "debug")
+	// the command line wins over the environment and the environment wins over the config file
+	c.Assert(tf.port, Equals, 8000)
+	c.Assert(tf.timeout, Equals, 2*time.Minute)
+	c.Assert([]string(tf.peers), DeepEquals, []string{"a", "b"})
+
+	// only the reloadable flags are applied, the others need a restart
+	writeConfig(c, path, `
+p2p-port: 7000
+signtimeout: 1m
+peer: c
+`)
+	env["TSS_SIGNTIMEOUT"] = "3m"
+	changed, restart, err := loader.reload()
+	c.Assert(err, IsNil)
+	c.Assert(changed, DeepEquals, map[string][]string{
+		"loglevel": {"info"},
+		"peer":     {"c"},
+	})
+	c.Assert(restart, DeepEquals, []string{"signtimeout"})
+	changed, restart, err = loader.reload()
+	c.Assert(err, IsNil)
+	c.Assert(changed, HasLen, 0)
+	c.Assert(restart, DeepEquals, []string{"signtimeout"})
+}
+
+func (ConfigTestSuite) TestLoadInvalid(c *C) {
+	path := filepath.Join(c.MkDir(), "config.yaml")
+	for _, content := range []string{
+		"unknown: 1",
+		"config: other.yaml",
+		"p2p-port: abc",
+		"p2p-port: [1, 2]",
+		"peer: {a: b}",
+		"- a",
+	} {
+		writeConfig(c, path, content)
+		fs, _, err := newTestFlagSet(nil)
+		c.Assert(err, IsNil)
+		c.Assert(newConfigLoader(fs, path).load(), NotNil, Commentf(content))
+	}
+	fs, _, err := newTestFlagSet(nil)
+	c.Assert(err, IsNil)
+	c.Assert(newConfigLoader(fs, filepath.Join(c.MkDir(), "missing.yaml")).load(), NotNil)
+}
+
+func (ConfigTestSuite) TestEnvList(c *C) {
+	fs, tf, err := newTestFlagSet(nil)
+	c.Assert(err, IsNil)
+	loader := newConfigLoader(fs, "")
+	loader.lookupEnv = func(name string) (string, bool) {
+		if name == "TSS_PEER" {
+			return "a, b", true
+		}
+		return "", false
+	}
+	c.Assert(loader.load(), IsNil)
+	c.Assert([]string(tf.peers), DeepEquals, []string{"a", "b"})
+}
+
+func (ConfigTestSuite) TestValidateConfig(c *C) {
+	logLevel, stateBackend = "info", stateBackendFile
+	defer func() {
+		logLevel, stateBackend = "", ""
+	}()
+	tssConf := common.TssConfig{
+		KeyGenTimeout:   time.Minute,
+		KeySignTimeout:  time.Minute,
+		PreParamTimeout: time.Minute,
+	}
+	p2pConf := p2p.Config{Port: 6668}
+	c.Assert(validateConfig(tssConf, p2pConf), IsNil)
+
+	logLevel = "verbose"
+	c.Assert(validateConfig(tssConf, p2pConf), NotNil)
+	logLevel = "info"
+	c.Assert(validateConfig(tssConf, p2p.Config{Port: 70000}), NotNil)
+	stateBackend = "disk"
+	c.Assert(validateConfig(tssConf, p2pConf), NotNil)
+	stateBackend = stateBackendFile
+	tssConf.MaxConcurrentKeySigns = -1
+	c.Assert(validateConfig(tssConf, p2pConf), NotNil)
+	tssConf.MaxConcurrentKeySigns = 0
+	tssConf.WebhookEvents = common.StringList{"keysign.failed", "keysign.done"}
+	c.Assert(validateConfig(tssConf, p2pConf), NotNil)
+	tssConf.WebhookEvents = common.StringList{"keysign.failed"}
+	c.Assert(validateConfig(tssConf, p2pConf), IsNil)
+	legacyAPISunset = "2027-01"
+	c.Assert(validateConfig(tssConf, p2pConf), NotNil)
+	legacyAPISunset = "2027-01-31"
+	c.Assert(validateConfig(tssConf, p2pConf), IsNil)
+	legacyAPISunset = ""
+	tssConf.KeySignTimeout = 0
+	c.Assert(validateConfig(tssConf, p2pConf), NotNil)
+}
+
+func (ConfigTestSuite) TestKeysharesEncrypted(c *C) {
+	defer func() {
+		stateBackend, encryptKeyshares = "", false
+	}()
+	stateBackend = stateBackendFile
+	c.Assert(keysharesEncrypted(), Equals, false)
+	encryptKeyshares = true
+	c.Assert(keysharesEncrypted(), Equals, true)
+	encryptKeyshares = false
+	for _, el := range []string{stateBackendVault, stateBackendKMS} {
+		stateBackend = el
+		c.Assert(keysharesEncrypted(), Equals, true)
+	}
+	// the memory and the sqlite backends keep the keyshares in plaintext
+	for _, el := range []string{stateBackendMemory, stateBackendSqlite} {
+		stateBackend = el
+		c.Assert(keysharesEncrypted(), Equals, false)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	rpprof "runtime/pprof"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akildemir/go-tss/auth"
+)
+
+// diagnosticsPrefix is where the diagnostics are served, they are not versioned as the metrics
+const diagnosticsPrefix = "/debug"
+
+// WithDiagnostics serve pprof, the goroutine dump and the dump of the ceremony state under /debug, they are off
+// unless the operator debugs a stuck ceremony, as the profiles slow the node down
+func WithDiagnostics(enabled bool) HttpServerOption {
+	return func(hs *TssHttpServer) {
+		hs.diagnostics = enabled
+	}
+}
+
+// diagnosticsRoutes registers the diagnostics, they need the admin role as the goroutine dump and the profiles
+// show the internals of the node
+func (t *TssHttpServer) diagnosticsRoutes(router *mux.Router) {
+	if !t.diagnostics {
+		return
+	}
+	router.Handle(diagnosticsPrefix+"/ceremonies", t.authorize(auth.RoleAdmin, t.ceremonyStateHandler)).Methods(http.MethodGet)
+	router.Handle(diagnosticsPrefix+"/goroutines", t.authorize(auth.RoleAdmin, t.goroutinesHandler)).Methods(http.MethodGet)
+	router.Handle(diagnosticsPrefix+"/pprof/cmdline", t.authorize(auth.RoleAdmin, pprof.Cmdline))
+	router.Handle(diagnosticsPrefix+"/pprof/profile", t.authorize(auth.RoleAdmin, pprof.Profile))
+	router.Handle(diagnosticsPrefix+"/pprof/symbol", t.authorize(auth.RoleAdmin, pprof.Symbol))
+	router.Handle(diagnosticsPrefix+"/pprof/trace", t.authorize(auth.RoleAdmin, pprof.Trace))
+	// the index serves the named profiles, heap, goroutine, block, mutex and the others
+	router.PathPrefix(diagnosticsPrefix + "/pprof/").Handler(t.authorize(auth.RoleAdmin, pprof.Index))
+}
+
+// ceremonyStateHandler return the ceremonies of the tenant, the subscribers of their messages, the streams and the
+// queues, the payloads of the messages are never dumped
+func (t *TssHttpServer) ceremonyStateHandler(w http.ResponseWriter, r *http.Request) {
+	t.writeJSON(w, t.server(r).GetDiagnostics())
+}
+
+// goroutinesHandler write the stack of all the goroutines, as the panics print them
+func (t *TssHttpServer) goroutinesHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := rpprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		t.logger.Error().Err(err).Msg("fail to write the goroutine dump")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/tss"
+)
+
+type DiagnosticsTestSuite struct{}
+
+var _ = Suite(&DiagnosticsTestSuite{})
+
+func (DiagnosticsTestSuite) TestDiagnosticsRoutes(c *C) {
+	// the diagnostics are off by default
+	handler := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}).tssNewHandler()
+	for _, el := range []string{"/debug/ceremonies", "/debug/goroutines", "/debug/pprof/"} {
+		c.Assert(serveRoute(handler, http.MethodGet, el, "").Code, Equals, http.StatusNotFound, Commentf(el))
+	}
+
+	authorizer, err := auth.NewAuthorizer(auth.Config{
+		APIKeys: []auth.APIKey{
+			{Name: "reader", Key: "reader-key-0123456789", Role: auth.RoleRead},
+			{Name: "admin", Key: "admin-key-0123456789", Role: auth.RoleAdmin},
+		},
+	})
+	c.Assert(err, IsNil)
+	handler = NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}, WithAuthorizer(authorizer), WithDiagnostics(true)).tssNewHandler()
+	serve := func(route, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, route, nil)
+		req.Header.Set(auth.APIKeyHeader, key)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	// the diagnostics need the admin role
+	for _, el := range []string{"/debug/ceremonies", "/debug/goroutines", "/debug/pprof/heap"} {
+		c.Assert(serve(el, "reader-key-0123456789").Code, Equals, http.StatusForbidden, Commentf(el))
+	}
+
+	const key = "admin-key-0123456789"
+	res := serve("/debug/ceremonies", key)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var d tss.Diagnostics
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &d), IsNil)
+	c.Assert(d.QueuedKeySigns, HasLen, 1)
+	c.Assert(d.QueuedKeySigns[0].MsgID, Equals, "queued")
+
+	res = serve("/debug/goroutines", key)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(strings.Contains(res.Body.String(), "goroutine "), Equals, true)
+	c.Assert(serve("/debug/pprof/", key).Code, Equals, http.StatusOK)
+	c.Assert(serve("/debug/pprof/heap", key).Code, Equals, http.StatusOK)
+	c.Assert(serve("/debug/pprof/cmdline", key).Code, Equals, http.StatusOK)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/akildemir/go-tss/errcode"
+)
+
+const (
+	// maxJobs is the number of the jobs we keep, the new ones are refused as busy once we keep that many
+	maxJobs = 1024
+	// jobRetention is how long we keep the finished jobs, so the callers can poll their result
+	jobRetention = time.Hour
+)
+
+// jobStatus is the state of an async job
+type jobStatus string
+
+const (
+	jobRunning   jobStatus = "running"
+	jobSucceeded jobStatus = "succeeded"
+	jobFailed    jobStatus = "failed"
+	jobCancelled jobStatus = "cancelled"
+)
+
+// job is a keygen or a keysign the v2 api runs in the background, the caller polls it until it is no longer
+// running
+type job struct {
+	ID       string         `json:"id"`
+	Tenant   string         `json:"tenant"`
+	Kind     string         `json:"kind"`
+	Status   jobStatus      `json:"status"`
+	Created  time.Time      `json:"created"`
+	Finished *time.Time     `json:"finished,omitempty"`
+	Result   interface{}    `json:"result,omitempty"`
+	Error    *errorResponse `json:"error,omitempty"`
+	cancel   context.CancelFunc
+}
+
+// jobStore keeps the async jobs in memory, they are lost once the node stops
+type jobStore struct {
+	lock   *sync.Mutex
+	jobs   map[string]*job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+}
+
+// newJobStore create a new instance of jobStore
+func newJobStore() *jobStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobStore{
+		lock:   &sync.Mutex{},
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+		wg:     &sync.WaitGroup{},
+	}
+}
+
+// start run the job of the tenant in the background, it is not bound to the request so the caller can go away, it
+// return the job as it starts
+func (s *jobStore) start(tenant, kind string, run func(ctx context.Context) (interface{}, error)) (job, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ctx.Err() != nil {
+		return job{}, errcode.New(errcode.ShuttingDown, "the node is shutting down")
+	}
+	s.prune(time.Now())
+	if len(s.jobs) >= maxJobs {
+		return job{}, errcode.Errorf(errcode.Busy, "there are %d jobs already", len(s.jobs))
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := &job{
+		ID:      newJobID(),
+		Tenant:  tenant,
+		Kind:    kind,
+		Status:  jobRunning,
+		Created: time.Now().UTC(),
+		cancel:  cancel,
+	}
+	s.jobs[j.ID] = j
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		result, err := run(ctx)
+		s.finish(ctx, j, result, err)
+	}()
+	return *j, nil
+}
+
+// finish record the outcome of the job
+func (s *jobStore) finish(ctx context.Context, j *job, result interface{}, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	finished := time.Now().UTC()
+	j.Finished = &finished
+	j.Result = result
+	switch {
+	case err == nil:
+		j.Status = jobSucceeded
+	case ctx.Err() != nil:
+		j.Status = jobCancelled
+	default:
+		j.Status = jobFailed
+	}
+	if err != nil {
+		resp := newErrorResponse(err)
+		j.Error = &resp
+	}
+}
+
+// get return the job of the id, the jobs of the other tenants are not returned
+func (s *jobStore) get(tenant, id string) (job, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || j.Tenant != tenant {
+		return job{}, false
+	}
+	return *j, true
+}
+
+// cancelJob cancel the job of the id if it is still running, it return false if the tenant has no such job
+func (s *jobStore) cancelJob(tenant, id string) (job, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || j.Tenant != tenant {
+		return job{}, false
+	}
+	j.cancel()
+	return *j, true
+}
+
+// prune remove the jobs that have finished longer than jobRetention ago
+func (s *jobStore) prune(now time.Time) {
+	for id, el := range s.jobs {
+		if el.Finished != nil && now.Sub(*el.Finished) > jobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// stop cancel the jobs that are still running and wait for them to return
+func (s *jobStore) stop() {
+	s.lock.Lock()
+	s.cancel()
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+func newJobID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return hex.EncodeToString([]byte(time.Now().UTC().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(buf)
+}
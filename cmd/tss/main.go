@@ -2,21 +2,36 @@ package main
 
 import (
 	"bufio"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
+	"path"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client/input"
 	golog "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p/core/peer"
+	// the sqlite state backend opens its database with this driver
+	_ "github.com/mattn/go-sqlite3"
 	"gitlab.com/thorchain/binance-sdk/common/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/akildemir/go-tss/auth"
 	"github.com/akildemir/go-tss/common"
 	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/grpcapi"
 	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/replica"
 	"github.com/akildemir/go-tss/storage"
 	"github.com/akildemir/go-tss/tss"
 )
@@ -27,19 +42,101 @@ var (
 	pretty     bool
 	baseFolder string
 	tssAddr    string
+	// legacyAPISunset is the date the unversioned routes of the http api are removed, YYYY-MM-DD
+	legacyAPISunset string
+	// grpcAddr is the address of the gRPC interface, empty disables it
+	grpcAddr string
+	// authConfigFile holds the API keys and the client certificates the callers of the api authenticate with, and
+	// their roles, apiTLS is the certificate the http and the gRPC interfaces are served with
+	authConfigFile string
+	apiTLS         auth.TLSConfig
+	strict         bool
+	// debugTap is the file we write the trace of all the inbound tss messages to
+	debugTap       string
+	debugTapRedact bool
+	// diagnostics serves pprof and the dumps of the goroutines and the ceremony state on the http api
+	diagnostics bool
+	// encryptKeyshares encrypts the keyshares at rest with the passphrase in keyshareKeyFile, or the one read from
+	// stdin, migrateKeyshares encrypts the plaintext keyshares of the home folder and exits
+	encryptKeyshares bool
+	keyshareKeyFile  string
+	migrateKeyshares bool
+	// stateBackend is where the keyshares are kept, file or vault, the token of vault and the secret id of its
+	// approle are read from VAULT_TOKEN and VAULT_SECRET_ID
+	stateBackend string
+	vaultConf    storage.VaultConfig
+	// kmsConf is the KMS key the kms backend encrypts the keyshares with, and the S3 bucket it keeps them in, the
+	// credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	kmsConf kmsConfig
+	// sqlitePath is the database of the sqlite backend
+	sqlitePath string
+	// nodeKeyStore is the keystore the node key is decrypted from instead of reading it from stdin,
+	// exportNodeKeyStore encrypts the node key of stdin into the keystore and exits
+	nodeKeyStore       string
+	exportNodeKeyStore string
+	// exportShare is the pool pub key we export the share of into shareArchive, restoreShare is the archive we
+	// restore the share of, both exit once done
+	exportShare  string
+	shareArchive string
+	restoreShare string
+	// splitShare is the pool pub key we split the share of into fragmentCount fragments written to
+	// fragmentPrefix-<x>.json, any fragmentThreshold of them rebuild it, combineFragments are the fragments we
+	// rebuild and restore the share of, both exit once done
+	splitShare        string
+	fragmentThreshold int
+	fragmentCount     int
+	fragmentPrefix    string
+	combineFragments  common.StringList
+	// archiveKeyFile holds the passphrase the shares of the retired keys are archived with, restoreArchivedKey is
+	// the pool pub key we restore the archived share of, and exit
+	archiveKeyFile     string
+	restoreArchivedKey string
+	// replicaPeer is the standby we stream the keyshares to, sealed to its replica public key replicaPubKey,
+	// replicaPrimaries are the primaries we are the standby of, their keyshares are sealed to the replica key of
+	// replicaKeyFile, genReplicaKey writes a new replica key to the file and exits
+	replicaPeer      string
+	replicaPubKey    string
+	replicaInterval  time.Duration
+	replicaPrimaries common.StringList
+	replicaKeyFile   string
+	genReplicaKey    string
+	// configFile is the yaml file of the flags, the keys are the names of the flags
+	configFile string
+	// tenantsConfigFile is the yaml file of the committees the node serves besides the one of the flags
+	tenantsConfigFile string
+	// version and commit are set at build time through ldflags
+	version string
+	commit  string
 )
 
 func main() {
 	// Parse the cli into configuration structs
-	tssConf, p2pConf := parseFlags()
+	tssConf, p2pConf, loader, err := parseFlags()
 	if help {
 		flag.PrintDefaults()
 		return
 	}
+	if err != nil {
+		log.Fatal(err)
+	}
 	// Setup logging
 	golog.SetAllLoggers(golog.LevelInfo)
 	_ = golog.SetLogLevel("tss-lib", "INFO")
 	common.InitLog(logLevel, pretty, "tss_service")
+	authorizer, apiTLSConfig, err := newAPIAuth()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if strict {
+		if err := common.CheckStrictMode(common.SecurityPosture{
+			EncryptedKeyshares: keysharesEncrypted(),
+			AuthenticatedAPI:   authorizer.Enabled(),
+			PeerAllowlist:      len(p2pConf.AllowedPeers) > 0,
+			TrustedDealer:      tssConf.AllowTrustedDealer,
+		}); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// Setup Bech32 Prefixes
 	conversion.SetupBech32Prefix()
@@ -49,35 +146,89 @@ func main() {
 	}
 	// Read stdin for the private key
 	inBuf := bufio.NewReader(os.Stdin)
-	priKeyBytes, err := input.GetPassword("input node secret key:", inBuf)
-	if err != nil {
-		fmt.Printf("error in get the secret key: %s\n", err.Error())
+	if migrateKeyshares {
+		stateManager, err := newFileStateManager(inBuf, baseFolder)
+		if err != nil {
+			log.Fatal(err)
+		}
+		encrypted, err := stateManager.EncryptFiles()
+		for _, el := range encrypted {
+			fmt.Printf("encrypted %s\n", el)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
-	priKey, err := conversion.GetPriKey(priKeyBytes)
+	if exportShare != "" || restoreShare != "" {
+		if err := runShareBackup(inBuf); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if splitShare != "" || len(combineFragments) > 0 {
+		if err := runShareFragments(inBuf); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(tssConf.RetiredKeyArchiveFolder) == 0 {
+		tssConf.RetiredKeyArchiveFolder = filepath.Join(baseFolder, "archive")
+	}
+	if restoreArchivedKey != "" {
+		if err := runRestoreArchivedKey(inBuf, tssConf.RetiredKeyArchiveFolder); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if genReplicaKey != "" {
+		if err := writeReplicaKey(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if exportNodeKeyStore != "" {
+		if err := writeNodeKeyStore(inBuf); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	tenantConfs, err := loadTenants(tenantsConfigFile, p2pConf)
 	if err != nil {
 		log.Fatal(err)
 	}
+	signer, err := newNodeSigner(inBuf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	priKey := conversion.NewSignerPrivKey(signer)
 
 	// set up tss comms
-	stateManager, err := storage.NewFileStateMgr(baseFolder)
+	stateManager, err := newStateManager(inBuf)
 	if err != nil {
 		log.Fatal(err)
 	}
-	var bootstrapPeers p2p.AddrList
-	savedPeers, err := stateManager.RetrieveP2PAddresses()
+	comm, savedPeers, err := newCommunication(p2pConf, stateManager, signer)
 	if err != nil {
-		bootstrapPeers = p2p.AddrList(p2pConf.BootstrapPeers)
-	} else {
-		bootstrapPeers = savedPeers
-		bootstrapPeers = append(bootstrapPeers, p2p.AddrList(p2pConf.BootstrapPeers)...)
+		log.Fatal(err)
 	}
-	comm, err := p2p.NewCommunication(p2pConf.RendezvousString, bootstrapPeers, p2pConf.Port, p2pConf.ExternalIP)
-	if err != nil {
-		fmt.Errorf("fail to create communication layer: %w", err)
-		return
+
+	if debugTap != "" {
+		if err := startDebugTap(comm, debugTap, debugTapRedact); err != nil {
+			log.Fatal(err)
+		}
 	}
 
+	// the tenants archive the shares of their retired keys with the same passphrase
+	var archiveOpts []tss.Option
+	if tssConf.RetiredKeyRetention > 0 {
+		passphrase, err := readArchivePassphrase(inBuf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		archiveOpts = append(archiveOpts, tss.WithArchivePassphrase(passphrase))
+	}
+	opts := append([]tss.Option{tss.WithStateManager(stateManager)}, archiveOpts...)
 	// init tss module
 	tss, err := tss.NewTss(
 		comm,
@@ -85,44 +236,676 @@ func main() {
 		baseFolder,
 		tssConf,
 		nil,
+		opts...,
 	)
 	if nil != err {
 		log.Fatal(err)
 	}
-	s := NewTssHttpServer(tssAddr, tss)
+	// the other tenants have their own p2p hosts and keyshares, the reload, the replica and the debug tap are the
+	// ones of the default tenant only
+	tenants, err := newTenants(tss, tenantConfs, tssConf, p2pConf, signer, archiveOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stopChan := make(chan struct{})
+	if err := startReplica(comm, stateManager, stopChan); err != nil {
+		log.Fatal(err)
+	}
+	// validateConfig has checked the date
+	sunset, _ := parseSunset(legacyAPISunset)
+	s := NewTssHttpServer(tssAddr, tss, WithAuthorizer(authorizer), WithTLS(apiTLSConfig), WithLegacySunset(sunset), WithTenants(tenants), WithDiagnostics(diagnostics))
 	go func() {
 		if err := s.Start(); err != nil {
 			fmt.Println(err)
 		}
 	}()
+	var grpcServer *grpcapi.Server
+	if len(grpcAddr) != 0 {
+		grpcServer = grpcapi.NewTenantServer(tenants, grpcServerOptions(authorizer, apiTLSConfig)...)
+		go func() {
+			if err := grpcServer.Start(grpcAddr); err != nil {
+				fmt.Println(err)
+			}
+		}()
+	}
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
-	<-ch
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := <-ch; sig == syscall.SIGHUP; sig = <-ch {
+		if err := reloadConfig(loader, comm, savedPeers); err != nil {
+			log.Println(err)
+		}
+	}
+	close(stopChan)
 	fmt.Println("stop ")
+	// the tss server drains the in-flight ceremonies before the apis stop, the ceremony streams of the gRPC api end
+	// once it stopped
 	fmt.Println(s.Stop())
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+}
+
+// newCommunication create the p2p layer of the config, the addresses of the peers saved in the state are dialed
+// with the bootstrap peers, it return them so a reload of the bootstrap peers keeps them
+func newCommunication(p2pConf p2p.Config, stateManager stateStore, signer conversion.Signer) (*p2p.Communication, []p2p.Multiaddr, error) {
+	var bootstrapPeers p2p.AddrList
+	var coldStart p2p.ColdStartConfig
+	savedPeers, err := stateManager.RetrieveP2PAddresses()
+	if err != nil {
+		bootstrapPeers = p2p.AddrList(p2pConf.BootstrapPeers)
+	} else {
+		bootstrapPeers = savedPeers
+		bootstrapPeers = append(bootstrapPeers, p2p.AddrList(p2pConf.BootstrapPeers)...)
+		if p2pConf.ColdStart {
+			coldStart.Peers = savedPeers
+		}
+	}
+	comm, err := p2p.NewCommunication(p2pConf.RendezvousString, bootstrapPeers, p2pConf.Port, p2pConf.ExternalIP,
+		p2p.WithRateLimit(p2pConf.RateLimit),
+		p2p.WithResourceLimits(p2pConf.ResourceLimits),
+		p2p.WithAttestation(p2pConf.Attestation),
+		p2p.WithCompression(p2pConf.Compression),
+		p2p.WithMaxMessageSize(p2pConf.MaxMessageSize),
+		p2p.WithBootstrapRetry(p2pConf.BootstrapRetry),
+		p2p.WithDelivery(p2pConf.Delivery),
+		p2p.WithColdStart(coldStart),
+		p2p.WithLatencyProbe(p2pConf.LatencyProbe),
+		p2p.WithReputation(p2pConf.Reputation, stateManager),
+		p2p.WithGossip(p2pConf.Gossip),
+		p2p.WithAllowedPeers(p2pConf.AllowedPeers),
+		p2p.WithPeerStore(p2pConf.PeerStore, stateManager),
+		p2p.WithReconnect(p2pConf.Reconnect),
+		p2p.WithCircuitBreaker(p2pConf.CircuitBreaker),
+		p2p.WithStreamHandler(p2pConf.StreamHandler),
+		p2p.WithCeremonyStreams(p2pConf.CeremonyStreams),
+		p2p.WithAnnounceAddrs(p2pConf.AnnounceAddrs),
+		p2p.WithNATPortMap(p2pConf.NATPortMap),
+		p2p.WithIdentitySigner(signer))
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to create communication layer: %w", err)
+	}
+	return comm, savedPeers, nil
+}
+
+// newAPIAuth load the authorizer of the callers of the api and the TLS config it is served with, both are nil if
+// they are not configured
+func newAPIAuth() (*auth.Authorizer, *tls.Config, error) {
+	var authorizer *auth.Authorizer
+	if len(authConfigFile) > 0 {
+		cfg, err := auth.LoadConfig(authConfigFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		authorizer, err = auth.NewAuthorizer(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if !apiTLS.Enabled() {
+		if len(apiTLS.ClientCAFile) > 0 {
+			return nil, nil, errors.New("the client certificates need the api to be served over TLS, set -api-tls-cert")
+		}
+		return authorizer, nil, nil
+	}
+	tlsConfig, err := apiTLS.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return authorizer, tlsConfig, nil
+}
+
+// grpcServerOptions return the options of the gRPC server that serve it over TLS and check the roles of the callers
+func grpcServerOptions(authorizer *auth.Authorizer, tlsConfig *tls.Config) []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if authorizer.Enabled() {
+		opts = append(opts,
+			grpc.UnaryInterceptor(authorizer.UnaryInterceptor(grpcapi.MethodRoles)),
+			grpc.StreamInterceptor(authorizer.StreamInterceptor(grpcapi.MethodRoles)))
+	}
+	return opts
+}
+
+// startReplica stream the keyshares to the standby, and take the keyshares of the primaries if we are a standby
+func startReplica(comm *p2p.Communication, stateManager stateStore, stopChan chan struct{}) error {
+	if replicaPeer != "" {
+		standby, err := peer.Decode(replicaPeer)
+		if err != nil {
+			return fmt.Errorf("fail to decode the peer id of the standby: %w", err)
+		}
+		standbyKey, err := replica.ParseKey(replicaPubKey)
+		if err != nil {
+			return err
+		}
+		replicator, err := replica.NewReplicator(comm.GetHost(), stateManager, standby, standbyKey)
+		if err != nil {
+			return err
+		}
+		go replicator.Run(replicaInterval, stopChan)
+	}
+	if len(replicaPrimaries) > 0 {
+		primaries := make([]peer.ID, len(replicaPrimaries))
+		for i, el := range replicaPrimaries {
+			var err error
+			primaries[i], err = peer.Decode(el)
+			if err != nil {
+				return fmt.Errorf("fail to decode the peer id of the primary: %w", err)
+			}
+		}
+		privateKey, err := replica.ReadKeyFile(replicaKeyFile)
+		if err != nil {
+			return err
+		}
+		if _, err := replica.NewStandby(comm.GetHost(), stateManager, primaries, privateKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeReplicaKey write a new replica key of the standby to the file, and print its public key for the primary
+func writeReplicaKey() error {
+	publicKey, privateKey, err := replica.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("fail to generate the replica key: %w", err)
+	}
+	if err := ioutil.WriteFile(genReplicaKey, []byte(hex.EncodeToString(privateKey[:])), 0o600); err != nil {
+		return fmt.Errorf("fail to write the replica key: %w", err)
+	}
+	fmt.Printf("replica public key: %s\n", hex.EncodeToString(publicKey[:]))
+	return nil
+}
+
+const (
+	stateBackendFile   = "file"
+	stateBackendVault  = "vault"
+	stateBackendKMS    = "kms"
+	stateBackendSqlite = "sqlite"
+	stateBackendMemory = "memory"
+)
+
+type kmsConfig struct {
+	KeyID      string
+	Region     string
+	Endpoint   string
+	S3Bucket   string
+	S3Prefix   string
+	S3Endpoint string
+}
+
+// stateStore is the state manager of the node, it persists the reputation and the peerstore of the peers as well
+type stateStore interface {
+	storage.LocalStateManager
+	p2p.ReputationStore
+	p2p.PeerRecordStore
+}
+
+// newStateManager create the state manager of the state backend
+func newStateManager(inBuf *bufio.Reader) (stateStore, error) {
+	return newBackendStateManager(inBuf, baseFolder, "")
+}
+
+// newBackendStateManager create the state manager of the state backend that keeps the state in the home folder,
+// the namespace keeps the state of a tenant apart from the others in vault, the S3 bucket and the sqlite database,
+// it is empty for the default tenant
+func newBackendStateManager(inBuf *bufio.Reader, home, namespace string) (stateStore, error) {
+	switch stateBackend {
+	case stateBackendFile:
+		return newFileStateManager(inBuf, home)
+	case stateBackendVault:
+		if encryptKeyshares {
+			return nil, errors.New("vault encrypts the keyshares, -encrypt-keyshares only applies to the file backend")
+		}
+		conf := vaultConf
+		conf.Token = os.Getenv("VAULT_TOKEN")
+		conf.SecretID = os.Getenv("VAULT_SECRET_ID")
+		if len(namespace) > 0 {
+			conf.Path = path.Join(conf.Path, namespace)
+		}
+		return storage.NewVaultStateMgr(conf)
+	case stateBackendKMS:
+		if encryptKeyshares {
+			return nil, errors.New("the kms encrypts the keyshares, -encrypt-keyshares only applies to the file backend")
+		}
+		return newKMSStateManager(home, namespace)
+	case stateBackendSqlite:
+		if encryptKeyshares {
+			return nil, errors.New("-encrypt-keyshares only applies to the file backend")
+		}
+		dbPath := sqlitePath
+		if len(dbPath) == 0 || len(namespace) > 0 {
+			dbPath = filepath.Join(home, "state.db")
+		}
+		return storage.NewSqliteStateMgr(dbPath)
+	case stateBackendMemory:
+		if encryptKeyshares {
+			return nil, errors.New("-encrypt-keyshares only applies to the file backend")
+		}
+		log.Println("the keyshares are only kept in memory, they are lost once the node stops")
+		return storage.NewMemoryStateMgr(), nil
+	default:
+		return nil, fmt.Errorf("unknown state backend %s", stateBackend)
+	}
+}
+
+// keysharesEncrypted return true if the state backend encrypts the keyshares at rest, the memory backend keeps them in
+// plaintext in the memory of the process, so it does not count
+func keysharesEncrypted() bool {
+	switch stateBackend {
+	case stateBackendVault, stateBackendKMS:
+		return true
+	case stateBackendFile:
+		return encryptKeyshares
+	default:
+		return false
+	}
+}
+
+// newKMSStateManager create the state manager that envelope encrypts the keyshares with the data keys of AWS KMS,
+// they are kept in the home folder, or under the namespace of the prefix of the S3 bucket if it is set
+func newKMSStateManager(home, namespace string) (*storage.KMSStateMgr, error) {
+	creds, err := storage.AWSCredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	region := kmsConf.Region
+	if len(region) == 0 {
+		region = os.Getenv("AWS_REGION")
+	}
+	kms, err := storage.NewAWSKMS(creds, region, kmsConf.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the kms client: %w", err)
+	}
+	var blobs storage.BlobStore
+	if len(kmsConf.S3Bucket) > 0 {
+		prefix := kmsConf.S3Prefix
+		if len(namespace) > 0 {
+			prefix = path.Join(prefix, namespace)
+		}
+		blobs, err = storage.NewS3BlobStore(creds, region, kmsConf.S3Endpoint, kmsConf.S3Bucket, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("fail to create the s3 client: %w", err)
+		}
+	}
+	return storage.NewKMSStateMgr(home, kms, kmsConf.KeyID, blobs)
+}
+
+// newFileStateManager create the state manager of the home folder, the keyshares are encrypted with the passphrase
+// of the key file, or the one read from stdin, if the encryption is enabled
+func newFileStateManager(inBuf *bufio.Reader, home string) (*storage.FileStateMgr, error) {
+	if !encryptKeyshares && !migrateKeyshares {
+		return storage.NewFileStateMgr(home)
+	}
+	var passphrase []byte
+	if keyshareKeyFile != "" {
+		var err error
+		passphrase, err = storage.ReadKeyFile(keyshareKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		pass, err := input.GetPassword("input keyshare passphrase:", inBuf)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get the keyshare passphrase: %w", err)
+		}
+		passphrase = []byte(pass)
+	}
+	return storage.NewEncryptedFileStateMgr(home, passphrase)
+}
+
+// runShareBackup export the share of the pool key into the archive, or restore the share of the archive, the
+// archive is encrypted with the passphrase read from stdin
+func runShareBackup(inBuf *bufio.Reader) error {
+	if exportShare != "" && shareArchive == "" {
+		return errors.New("-share-archive is needed to export the share")
+	}
+	stateManager, err := newStateManager(inBuf)
+	if err != nil {
+		return err
+	}
+	pass, err := input.GetPassword("input share archive passphrase:", inBuf)
+	if err != nil {
+		return fmt.Errorf("fail to get the share archive passphrase: %w", err)
+	}
+	if exportShare != "" {
+		buf, err := storage.ExportShare(stateManager, exportShare, []byte(pass))
+		if err != nil {
+			return fmt.Errorf("fail to export the share: %w", err)
+		}
+		if err := ioutil.WriteFile(shareArchive, buf, 0o600); err != nil {
+			return fmt.Errorf("fail to write the share archive: %w", err)
+		}
+		fmt.Printf("exported the share of %s to %s\n", exportShare, shareArchive)
+		return nil
+	}
+	buf, err := ioutil.ReadFile(restoreShare)
+	if err != nil {
+		return fmt.Errorf("fail to read the share archive: %w", err)
+	}
+	state, err := storage.RestoreShare(stateManager, buf, []byte(pass))
+	if err != nil {
+		return fmt.Errorf("fail to restore the share: %w", err)
+	}
+	fmt.Printf("restored the share of %s\n", state.PubKey)
+	return nil
+}
+
+// runShareFragments split the share of the pool key into the fragment files, or rebuild the share of the fragment
+// files and restore it
+func runShareFragments(inBuf *bufio.Reader) error {
+	if splitShare != "" && fragmentPrefix == "" {
+		return errors.New("-fragment-prefix is needed to split the share")
+	}
+	stateManager, err := newStateManager(inBuf)
+	if err != nil {
+		return err
+	}
+	if splitShare != "" {
+		fragments, err := storage.SplitShare(stateManager, splitShare, fragmentThreshold, fragmentCount)
+		if err != nil {
+			return fmt.Errorf("fail to split the share: %w", err)
+		}
+		for i, el := range fragments {
+			fileName := fmt.Sprintf("%s-%d.json", fragmentPrefix, i+1)
+			if err := ioutil.WriteFile(fileName, el, 0o600); err != nil {
+				return fmt.Errorf("fail to write the fragment: %w", err)
+			}
+			fmt.Printf("wrote the fragment %d of %d to %s\n", i+1, len(fragments), fileName)
+		}
+		return nil
+	}
+	fragments := make([][]byte, len(combineFragments))
+	for i, el := range combineFragments {
+		fragments[i], err = ioutil.ReadFile(el)
+		if err != nil {
+			return fmt.Errorf("fail to read the fragment: %w", err)
+		}
+	}
+	state, err := storage.RestoreShareFragments(stateManager, fragments)
+	if err != nil {
+		return fmt.Errorf("fail to restore the share of the fragments: %w", err)
+	}
+	fmt.Printf("restored the share of %s\n", state.PubKey)
+	return nil
+}
+
+// readArchivePassphrase read the passphrase of the archives of the retired keys from the archive key file, or
+// stdin if it is not set
+func readArchivePassphrase(inBuf *bufio.Reader) ([]byte, error) {
+	if archiveKeyFile != "" {
+		return storage.ReadKeyFile(archiveKeyFile)
+	}
+	pass, err := input.GetPassword("input archive passphrase:", inBuf)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the archive passphrase: %w", err)
+	}
+	return []byte(pass), nil
+}
+
+// runRestoreArchivedKey restore the archived share of the retired key, the key is no longer retired
+func runRestoreArchivedKey(inBuf *bufio.Reader, folder string) error {
+	stateManager, err := newStateManager(inBuf)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readArchivePassphrase(inBuf)
+	if err != nil {
+		return err
+	}
+	state, err := storage.RestoreArchivedShare(stateManager, folder, restoreArchivedKey, passphrase)
+	if err != nil {
+		return fmt.Errorf("fail to restore the archived share: %w", err)
+	}
+	fmt.Printf("restored the archived share of %s\n", state.PubKey)
+	return nil
+}
+
+// readNodeKey read the base64 encoded hex node key from stdin, it return the raw key bytes
+func readNodeKey(inBuf *bufio.Reader) ([]byte, error) {
+	priKeyBytes, err := input.GetPassword("input node secret key:", inBuf)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the secret key: %w", err)
+	}
+	priKey, err := conversion.GetPriKey(priKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return priKey.Bytes(), nil
+}
+
+// newNodeSigner create the signer of the node key, the key is decrypted from the keystore if it is set, or read
+// from stdin, it is kept in memory either way
+func newNodeSigner(inBuf *bufio.Reader) (conversion.Signer, error) {
+	if nodeKeyStore == "" {
+		priKeyBytes, err := readNodeKey(inBuf)
+		if err != nil {
+			return nil, err
+		}
+		return conversion.NewKeySigner(priKeyBytes)
+	}
+	buf, err := ioutil.ReadFile(nodeKeyStore)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the node keystore: %w", err)
+	}
+	pass, err := input.GetPassword("input node keystore passphrase:", inBuf)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the node keystore passphrase: %w", err)
+	}
+	priKeyBytes, err := conversion.DecryptKeyStore(buf, []byte(pass))
+	if err != nil {
+		return nil, err
+	}
+	return conversion.NewKeySigner(priKeyBytes)
+}
+
+// writeNodeKeyStore encrypt the node key of stdin with the passphrase into the export keystore
+func writeNodeKeyStore(inBuf *bufio.Reader) error {
+	priKeyBytes, err := readNodeKey(inBuf)
+	if err != nil {
+		return err
+	}
+	pass, err := input.GetPassword("input node keystore passphrase:", inBuf)
+	if err != nil {
+		return fmt.Errorf("fail to get the node keystore passphrase: %w", err)
+	}
+	buf, err := conversion.EncryptKeyStore(priKeyBytes, []byte(pass))
+	if err != nil {
+		return fmt.Errorf("fail to encrypt the node key: %w", err)
+	}
+	if err := ioutil.WriteFile(exportNodeKeyStore, buf, 0o600); err != nil {
+		return fmt.Errorf("fail to write the node keystore: %w", err)
+	}
+	return nil
 }
 
 // parseFlags - Parses the cli flags
-func parseFlags() (tssConf common.TssConfig, p2pConf p2p.Config) {
+func parseFlags() (tssConf common.TssConfig, p2pConf p2p.Config, loader *configLoader, err error) {
 	// we setup the configure for the general configuration
+	flag.StringVar(&configFile, "config", "", "yaml file of the flags, the keys are the flag names and the repeated flags take a list, the TSS_<FLAG_NAME> environment variables override it and the command line overrides both, SIGHUP reloads loglevel, peer and allowed-peer")
+	flag.StringVar(&tenantsConfigFile, "tenants", "", "yaml file of the committees the node serves besides the one of the flags, each with its own home folder, rendezvous, p2p port and peers, the callers pick one with the X-Tss-Tenant header or the x-tss-tenant gRPC metadata, empty serves the default one only")
 	flag.StringVar(&tssAddr, "tss-port", "127.0.0.1:8080", "tss port")
+	flag.StringVar(&legacyAPISunset, "legacy-api-sunset", "", "date the unversioned routes of the http api are removed, YYYY-MM-DD, it is sent in the Sunset header of their responses, empty leaves it out")
+	flag.StringVar(&grpcAddr, "grpc-addr", "", "address of the gRPC interface of the keygen, the keysign and the reshare, e.g. 127.0.0.1:9090, empty disables it")
+	flag.StringVar(&authConfigFile, "auth-config", "", "json file of the API keys and the client certificate common names the callers of the http and the gRPC interfaces authenticate with, and their roles, read, sign or admin, empty leaves the api open")
+	flag.StringVar(&apiTLS.CertFile, "api-tls-cert", "", "certificate the http and the gRPC interfaces are served over TLS with, empty serves them in plaintext")
+	flag.StringVar(&apiTLS.KeyFile, "api-tls-key", "", "private key of the -api-tls-cert")
+	flag.StringVar(&apiTLS.ClientCAFile, "api-tls-client-ca", "", "CA the client certificates of the callers are verified with")
+	flag.BoolVar(&apiTLS.RequireClientCert, "api-tls-require-client-cert", false, "refuse the callers without a client certificate the -api-tls-client-ca verifies, otherwise they can authenticate with an API key")
 	flag.BoolVar(&help, "h", false, "Display Help")
 	flag.StringVar(&logLevel, "loglevel", "info", "Log Level")
 	flag.BoolVar(&pretty, "pretty-log", false, "Enables unstructured prettified logging. This is useful for local debugging")
 	flag.StringVar(&baseFolder, "home", "", "home folder to store the keygen state file")
+	flag.BoolVar(&strict, "strict", false, "refuse to start unless all the hardened security options are enabled")
+	flag.StringVar(&debugTap, "debug-tap", "", "file we append the trace of all the inbound tss messages to, as json lines, to diagnose the stuck ceremonies, empty disables it")
+	flag.BoolVar(&diagnostics, "diagnostics", false, "serve pprof, the goroutine dump and the dump of the ceremony state under /debug of the http api to the admin role, to debug the stuck ceremonies")
+	flag.BoolVar(&debugTapRedact, "debug-tap-redact", true, "leave the payloads of the tss messages out of the debug trace")
+	flag.BoolVar(&encryptKeyshares, "encrypt-keyshares", false, "encrypt the keyshares, the presignatures and the pre-parameters at rest with the passphrase read from stdin or the key file")
+	flag.StringVar(&keyshareKeyFile, "keyshare-keyfile", "", "file that holds the passphrase of the keyshare encryption, the passphrase is read from stdin if it is empty")
+	flag.StringVar(&stateBackend, "state-backend", stateBackendFile, "where we keep the keyshares, file keeps them in the home folder, vault keeps them in the KV version 2 engine of HashiCorp Vault, kms encrypts them with the data keys of AWS KMS, sqlite keeps them in a sqlite database, memory never writes them to the disk and loses them once the node stops")
+	flag.StringVar(&vaultConf.Address, "vault-addr", "", "url of the vault server, the token is read from VAULT_TOKEN")
+	flag.StringVar(&vaultConf.RoleID, "vault-role-id", "", "role id of the vault approle we login with if VAULT_TOKEN is empty, the secret id is read from VAULT_SECRET_ID")
+	flag.StringVar(&vaultConf.Namespace, "vault-namespace", "", "vault enterprise namespace of the KV engine, empty is the root namespace")
+	flag.StringVar(&vaultConf.Mount, "vault-mount", "secret", "path the vault KV version 2 engine is mounted at")
+	flag.StringVar(&vaultConf.Path, "vault-path", "go-tss", "path in the KV engine we keep the secrets of the node under")
+	flag.IntVar(&vaultConf.MaxAttempts, "vault-max-attempts", 3, "how many times we send a request to vault on the network errors and the server errors")
+	flag.StringVar(&sqlitePath, "sqlite-path", "", "database of the sqlite backend, state.db of the home folder if it is empty, the tenants always keep theirs in their home folder")
+	flag.StringVar(&kmsConf.KeyID, "kms-key-id", "", "id, arn or alias of the AWS KMS key the kms backend encrypts the data keys of the keyshares with")
+	flag.StringVar(&kmsConf.Region, "aws-region", "", "AWS region of the KMS key and the S3 bucket, AWS_REGION if it is empty")
+	flag.StringVar(&kmsConf.Endpoint, "kms-endpoint", "", "endpoint of the KMS api, the one of the region if it is empty")
+	flag.StringVar(&kmsConf.S3Bucket, "kms-s3-bucket", "", "S3 bucket the kms backend keeps the encrypted keyshares in, they are kept in the home folder if it is empty")
+	flag.StringVar(&kmsConf.S3Prefix, "kms-s3-prefix", "", "prefix of the objects of the encrypted keyshares in the S3 bucket")
+	flag.StringVar(&kmsConf.S3Endpoint, "s3-endpoint", "", "endpoint of the S3 api, the one of the region if it is empty")
+	flag.StringVar(&nodeKeyStore, "node-keystore", "", "keystore the node key is decrypted from with the passphrase read from stdin, the node key is read from stdin if it is empty")
+	flag.StringVar(&exportNodeKeyStore, "export-node-keystore", "", "encrypt the node key read from stdin with the passphrase into this keystore, and exit")
+	flag.StringVar(&exportShare, "export-share", "", "pool pub key we export the share of into the -share-archive encrypted with the passphrase read from stdin, and exit")
+	flag.StringVar(&shareArchive, "share-archive", "", "file we write the exported share archive to")
+	flag.StringVar(&restoreShare, "restore-share", "", "share archive we restore the share of with the passphrase read from stdin, and exit")
+	flag.StringVar(&splitShare, "split-share", "", "pool pub key we split the share of into the unencrypted fragments for the offline custodians, and exit")
+	flag.IntVar(&fragmentThreshold, "fragment-threshold", 3, "how many fragments rebuild the split share")
+	flag.IntVar(&fragmentCount, "fragment-count", 5, "how many fragments the share is split into")
+	flag.StringVar(&fragmentPrefix, "fragment-prefix", "", "prefix of the fragment files, the fragment x is written to <prefix>-<x>.json")
+	flag.Var(&combineFragments, "combine-fragment", "fragment file we rebuild the share of, repeat it for each fragment, the share is restored and we exit")
+	flag.StringVar(&archiveKeyFile, "archive-keyfile", "", "file that holds the passphrase the shares of the retired keys are archived with, the passphrase is read from stdin if it is empty")
+	flag.StringVar(&restoreArchivedKey, "restore-archived-key", "", "pool pub key we restore the archived share of from the archive folder, the key is no longer retired, and exit")
+	flag.StringVar(&replicaPeer, "replica-peer", "", "peer id of the standby we stream the keyshares to, empty disables the replication")
+	flag.StringVar(&replicaPubKey, "replica-pubkey", "", "hex encoded replica public key of the standby the keyshares are sealed to")
+	flag.DurationVar(&replicaInterval, "replica-interval", time.Minute, "how often we stream the new and the changed keyshares to the standby")
+	flag.Var(&replicaPrimaries, "replica-primary", "Adds the peer id of a primary we are the standby of, we save the keyshares it streams to us")
+	flag.StringVar(&replicaKeyFile, "replica-keyfile", "", "file that holds the replica key of the standby the primaries seal the keyshares to")
+	flag.StringVar(&genReplicaKey, "gen-replica-key", "", "write a new replica key of the standby to this file and print its public key, and exit")
+	flag.BoolVar(&migrateKeyshares, "migrate-keyshares", false, "encrypt the plaintext keyshares, presignatures and pre-parameters of the home folder with the passphrase, and exit, the node with a passphrase refuses the plaintext ones")
 
 	// we setup the Tss parameter configuration
 	flag.DurationVar(&tssConf.KeyGenTimeout, "gentimeout", 30*time.Second, "keygen timeout")
 	flag.DurationVar(&tssConf.KeySignTimeout, "signtimeout", 30*time.Second, "keysign timeout")
 	flag.DurationVar(&tssConf.PreParamTimeout, "preparamtimeout", 5*time.Minute, "pre-parameter generation timeout")
 	flag.BoolVar(&tssConf.EnableMonitor, "enablemonitor", true, "enable the tss monitor")
+	flag.DurationVar(&tssConf.CanaryInterval, "canary-interval", 0, "how often we run the canary keysign with the canary pool key, 0 disables the canary")
+	flag.StringVar(&tssConf.CanaryPoolPubKey, "canary-pool-pubkey", "", "pool pub key of the dedicated test key the canary keysign signs with")
+	flag.DurationVar(&tssConf.HealthCheckInterval, "health-check-interval", 0, "how often we run the health check keysign of the health check vaults, 0 disables the scheduled health checks")
+	flag.Var(&tssConf.HealthCheckPoolPubKeys, "health-check-pool-pubkey", "Adds the pool pub key of a vault the scheduled health check signs with")
+	flag.StringVar(&tssConf.BlameWebhookURL, "blame-webhook-url", "", "endpoint we post the blame events of the failed ceremonies to, empty disables it")
+	flag.Var(&tssConf.Webhooks, "webhook", "endpoint we post the outcome of the keygens and the keysigns and the blame of the failed ceremonies to, repeat it for more")
+	flag.StringVar(&tssConf.WebhookSecret, "webhook-secret", "", "key of the hmac-sha256 signature of the webhook payloads, empty leaves them unsigned, set TSS_WEBHOOK_SECRET instead to keep it off the command line")
+	flag.Var(&tssConf.WebhookEvents, "webhook-event", "kind of the events we post to the webhooks, keygen.completed, keygen.failed, keysign.completed, keysign.failed or blame.reported, repeat it for more, unset posts them all")
+	flag.IntVar(&tssConf.GossipMinParties, "gossip-min-parties", 0, "number of parties from which the ceremony gossips its broadcast messages, 0 disables it")
+	flag.IntVar(&tssConf.MaxConcurrentCeremonies, "max-ceremonies", 0, "number of the keygens and keysigns we run at the same time, 0 does not limit them, enable -ceremony-streams to run them concurrently")
+	flag.StringVar(&tssConf.PresignPoolPubKey, "presign-pool-pubkey", "", "pool pub key we keep the presignatures of the schnorr keysign for")
+	flag.IntVar(&tssConf.PresignPoolSize, "presign-pool-size", 0, "number of the presignatures we keep ready, 0 disables the presign")
+	flag.DurationVar(&tssConf.PresignInterval, "presign-interval", time.Minute, "how often we check the presignatures and presign again if we are short of them")
+	flag.StringVar(&tssConf.RefreshPoolPubKey, "refresh-pool-pubkey", "", "pool pub key we refresh the shares of")
+	flag.DurationVar(&tssConf.RefreshInterval, "refresh-interval", 0, "how often we refresh the shares of the refresh pool key, 0 disables the refresh")
+	flag.DurationVar(&tssConf.KeyDeletionCoolingOff, "key-deletion-cooling-off", 72*time.Hour, "how long a key stays retired before its share can be deleted, 0 allows the deletion right after the retirement")
+	flag.DurationVar(&tssConf.RetiredKeyRetention, "retired-key-retention", 0, "how long the share of a retired key is kept before it is archived and removed, 0 keeps the shares of the retired keys")
+	flag.StringVar(&tssConf.RetiredKeyArchiveFolder, "retired-key-archive-folder", "", "folder the shares of the retired keys are archived to, the archive folder of the home folder if it is empty")
+	flag.IntVar(&tssConf.PreParamsPoolSize, "preparams-pool-size", 0, "number of the keygen pre-parameters we generate ahead in the background, 0 disables the pool")
+	flag.DurationVar(&tssConf.PreParamsTTL, "preparams-ttl", 24*time.Hour, "how long the pre-parameters of the pool stay fresh, 0 never expires them")
+	flag.StringVar(&tssConf.SignerSelection, "signer-selection", p2p.SignerSelectionFirst, "how the leader picks the keysign signers when more parties join than the threshold needs: first, hash, latency or reputation")
+	flag.IntVar(&tssConf.RoundCacheSize, "round-cache-size", 1024, "number of the tss messages of a ceremony we buffer when they arrive before the local party starts, 0 disables the cache")
+	flag.IntVar(&tssConf.CeremonyLogLines, "ceremony-log-lines", 100, "number of the last log lines of a ceremony attached to its response if it fails, 0 disables the capture")
+	flag.Var(&tssConf.RoundTimeouts, "round-timeout", "Adds the timeout of a tss round as round=duration, e.g. KGRound1Message=1m, the other rounds wait for gentimeout or signtimeout")
+	flag.IntVar(&tssConf.MaxConcurrentKeySigns, "max-keysigns", 0, "number of the keysign requests we run at the same time, the others wait in the keysign queue by priority, 0 disables the queue")
+	flag.IntVar(&tssConf.MaxKeySignQueue, "max-keysign-queue", 0, "number of the keysign requests that wait in the keysign queue, the others are rejected as busy")
+	flag.BoolVar(&tssConf.SerializeKeySigns, "serialize-keysigns", false, "run the queued keysign requests of the same pool key one after the other")
+	flag.BoolVar(&tssConf.SignLedger, "sign-ledger", false, "keep the ledger of the keysigns we take part in and refuse the keysigns that conflict with them")
+	flag.Var(&tssConf.SignLedgerRules, "sign-ledger-rule", "Adds the metadata key of the keysign requests that names what the messages spend, e.g. utxo or nonce, we refuse to sign other messages with a value we have signed")
+	flag.DurationVar(&tssConf.SignLedgerRetention, "sign-ledger-retention", 0, "how long we keep the keysigns in the ledger, 0 keeps them forever")
+	flag.BoolVar(&tssConf.AuditLog, "audit-log", false, "keep the append only, hash chained audit log of the keysigns we are asked to take part in, it is exported at /admin/audit")
+	flag.DurationVar(&tssConf.DrainTimeout, "drain-timeout", 30*time.Second, "how long the in-flight ceremonies can run once we stop, the ceremonies still running by then are aborted")
+	flag.BoolVar(&tssConf.RefuseCorruptedKeyshares, "refuse-corrupted-keyshares", false, "refuse to start when the checksum of a keyshare does not match, otherwise the key is marked unhealthy")
+	flag.DurationVar(&tssConf.LateJoinGraceWindow, "late-join-grace", 0, "how long we replay the messages to the peers who join the party late, 0 disables it")
+	flag.DurationVar(&tssConf.BlameQuorumTimeout, "blame-quorum-timeout", 0, "how long we wait for the participants of a failed ceremony to share their blame, so the nodes a quorum of them blame are confirmed, 0 disables it")
 
 	// we setup the p2p network configuration
 	flag.StringVar(&p2pConf.RendezvousString, "rendezvous", "Asgard",
 		"Unique string to identify group of nodes. Share this with your friends to let them connect with you")
 	flag.IntVar(&p2pConf.Port, "p2p-port", 6668, "listening port local")
 	flag.StringVar(&p2pConf.ExternalIP, "external-ip", "", "external IP of this node")
+	flag.Var(&p2pConf.AnnounceAddrs, "announce-addr", "Adds a multiaddress we announce instead of the listen addresses, for the nodes behind a load balancer")
+	flag.BoolVar(&p2pConf.NATPortMap, "nat-port-map", false, "map the p2p port through UPnP or NAT-PMP, so the peers can dial us without manual router configuration")
 	flag.Var(&p2pConf.BootstrapPeers, "peer", "Adds a peer multiaddress to the bootstrap list")
+	flag.Var(&p2pConf.AllowedPeers, "allowed-peer", "Adds a peer id to the allowlist, we only connect to the peers in the allowlist if it is not empty")
+	defaultRateLimit := p2p.DefaultRateLimitConfig()
+	flag.Float64Var(&p2pConf.RateLimit.PerPeerRate, "peer-stream-rate", defaultRateLimit.PerPeerRate, "inbound streams per second allowed for each peer, 0 disables the limit")
+	flag.IntVar(&p2pConf.RateLimit.PerPeerBurst, "peer-stream-burst", defaultRateLimit.PerPeerBurst, "inbound streams burst allowed for each peer")
+	flag.Float64Var(&p2pConf.RateLimit.GlobalRate, "global-stream-rate", defaultRateLimit.GlobalRate, "inbound streams per second allowed for all peers, 0 disables the limit")
+	flag.IntVar(&p2pConf.RateLimit.GlobalBurst, "global-stream-burst", defaultRateLimit.GlobalBurst, "inbound streams burst allowed for all peers")
+	flag.IntVar(&p2pConf.RateLimit.BanThreshold, "stream-ban-threshold", defaultRateLimit.BanThreshold, "number of rate limited streams within the ban window before the peer is banned, 0 disables the ban")
+	flag.DurationVar(&p2pConf.RateLimit.BanWindow, "stream-ban-window", defaultRateLimit.BanWindow, "window to count the rate limited streams of a peer")
+	flag.DurationVar(&p2pConf.RateLimit.BanDuration, "stream-ban-duration", defaultRateLimit.BanDuration, "how long a peer that abuses the stream rate limit is banned")
+	flag.IntVar(&p2pConf.ResourceLimits.MaxConns, "max-conns", 0, "maximum number of p2p connections, 0 keeps the libp2p default")
+	flag.IntVar(&p2pConf.ResourceLimits.MaxConnsPerPeer, "max-conns-per-peer", 0, "maximum number of p2p connections with a single peer, 0 keeps the libp2p default")
+	flag.IntVar(&p2pConf.ResourceLimits.MaxStreamsPerPeer, "max-streams-per-peer", 0, "maximum number of p2p streams of a single peer, 0 keeps the libp2p default")
+	flag.Int64Var(&p2pConf.ResourceLimits.MaxMemory, "max-p2p-memory", 0, "maximum memory in bytes the p2p host can use, 0 keeps the libp2p default")
+	flag.IntVar(&p2pConf.ResourceLimits.MaxFD, "max-p2p-fd", 0, "maximum number of file descriptors the p2p host can use, 0 keeps the libp2p default")
+	flag.StringVar(&p2pConf.Attestation.ReleasePubKey, "release-pubkey", "", "hex encoded ed25519 public key the official releases are signed with")
+	flag.StringVar(&p2pConf.Attestation.Signature, "build-signature", "", "hex encoded release signature of this build")
+	flag.StringVar((*string)(&p2pConf.Compression.Codec), "p2p-compression", "", "codec to compress the tss messages with(snappy or zstd), empty disables the compression")
+	flag.IntVar(&p2pConf.Compression.Threshold, "p2p-compression-threshold", p2p.DefaultCompressionThreshold, "size in bytes from which we compress the tss messages")
+	flag.IntVar(&p2pConf.MaxMessageSize, "max-message-size", p2p.DefaultMaxMessageSize, "maximum size in bytes of the tss message, the large messages are sent in chunks")
+	defaultBootstrapRetry := p2p.DefaultBootstrapRetryConfig()
+	flag.DurationVar(&p2pConf.BootstrapRetry.InitialBackoff, "bootstrap-retry-backoff", defaultBootstrapRetry.InitialBackoff, "initial delay to retry the bootstrap in the background if no bootstrap peer is reachable, 0 fails the startup instead")
+	flag.DurationVar(&p2pConf.BootstrapRetry.MaxBackoff, "bootstrap-retry-max-backoff", defaultBootstrapRetry.MaxBackoff, "maximum delay between two bootstrap retries")
+	defaultDelivery := p2p.DefaultDeliveryConfig()
+	flag.IntVar(&p2pConf.Delivery.Workers, "delivery-workers", defaultDelivery.Workers, "maximum number of peers we send a tss message to at the same time")
+	flag.DurationVar(&p2pConf.Delivery.PeerTimeout, "delivery-peer-timeout", defaultDelivery.PeerTimeout, "how long we wait for a single peer to take a tss message, 0 only applies the stream timeouts")
+	defaultLatencyProbe := p2p.DefaultLatencyProbeConfig()
+	flag.DurationVar(&p2pConf.LatencyProbe.Interval, "latency-probe-interval", defaultLatencyProbe.Interval, "how often we ping the connected peers to measure the latency, 0 disables it")
+	flag.DurationVar(&p2pConf.LatencyProbe.Timeout, "latency-probe-timeout", defaultLatencyProbe.Timeout, "how long we wait for the pong of a single peer")
+	defaultReputation := p2p.DefaultReputationConfig()
+	flag.DurationVar(&p2pConf.Reputation.HalfLife, "reputation-half-life", defaultReputation.HalfLife, "how long it takes for a peer event to lose half of its weight in the reputation score")
+	flag.Float64Var(&p2pConf.Reputation.BanThreshold, "reputation-ban-threshold", defaultReputation.BanThreshold, "negative reputation score below which we refuse the connections of the peer, 0 disables it")
+	flag.IntVar(&p2pConf.Reputation.Quarantine.Threshold, "quarantine-threshold", defaultReputation.Quarantine.Threshold, "number of the failed ceremonies the peer is blamed in within the quarantine window before it is banned and left out of the signers, 0 disables it")
+	flag.DurationVar(&p2pConf.Reputation.Quarantine.Window, "quarantine-window", defaultReputation.Quarantine.Window, "how far back the blames of the peer are counted")
+	flag.DurationVar(&p2pConf.Reputation.Quarantine.Cooldown, "quarantine-cooldown", defaultReputation.Quarantine.Cooldown, "how long the blamed peer stays quarantined, the operator can lift it with the unban")
+	defaultReconnect := p2p.DefaultReconnectConfig()
+	flag.DurationVar(&p2pConf.Reconnect.Interval, "reconnect-interval", defaultReconnect.Interval, "how often we check the connections to the bootstrap peers and the cached committee members, 0 disables the reconnection")
+	flag.IntVar(&p2pConf.Reconnect.MinPeers, "reconnect-min-peers", defaultReconnect.MinPeers, "number of the bootstrap peers and cached committee members we should stay connected to")
+	flag.DurationVar(&p2pConf.Reconnect.MaxBackoff, "reconnect-max-backoff", defaultReconnect.MaxBackoff, "maximum delay between two reconnections while we are isolated")
+	defaultCircuitBreaker := p2p.DefaultCircuitBreakerConfig()
+	flag.IntVar(&p2pConf.CircuitBreaker.Threshold, "circuit-breaker-threshold", defaultCircuitBreaker.Threshold, "number of the failed dials in a row after which we stop dialing the peer until it is reachable again, 0 disables it")
+	flag.DurationVar(&p2pConf.CircuitBreaker.ProbeInterval, "circuit-breaker-probe-interval", defaultCircuitBreaker.ProbeInterval, "how often we dial the unreachable peers in the background")
+	defaultStreamHandler := p2p.DefaultStreamHandlerConfig()
+	flag.IntVar(&p2pConf.StreamHandler.Workers, "stream-workers", defaultStreamHandler.Workers, "number of the inbound tss streams we read at the same time, 0 reads all the streams at once")
+	flag.IntVar(&p2pConf.StreamHandler.QueueSize, "stream-queue-size", defaultStreamHandler.QueueSize, "number of the inbound tss streams waiting for a worker before we reject them")
+	flag.DurationVar(&p2pConf.PeerStore.TTL, "peerstore-ttl", p2p.DefaultPeerStoreConfig().TTL, "how long the persisted addresses of the connected peers stay valid after a restart")
+	flag.BoolVar(&p2pConf.CeremonyStreams, "ceremony-streams", false, "send the tss messages of each ceremony over its own protocol to the peers that support it")
+	flag.BoolVar(&p2pConf.Gossip, "gossip", false, "join the gossip topic, so the large committees can gossip the broadcast messages")
+	flag.BoolVar(&p2pConf.ColdStart, "cold-start", true, "dial the committee members in the saved address book directly on start, so we are ready to sign before the DHT bootstrap finishes")
 	flag.Parse()
+	loader = newConfigLoader(flag.CommandLine, configFile)
+	if err = loader.load(); err != nil {
+		return
+	}
+	if err = validateConfig(tssConf, p2pConf); err != nil {
+		return
+	}
+	p2pConf.Attestation.Version = version
+	p2pConf.Attestation.Commit = commit
 	return
 }
+
+// debugTapBufferSize is the number of the messages the debug tap holds while we write them to the file
+const debugTapBufferSize = 1024
+
+// startDebugTap write every inbound tss message to the given file until the communication stops
+func startDebugTap(comm *p2p.Communication, path string, redact bool) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("fail to open the debug tap file: %w", err)
+	}
+	msgs, _ := comm.TapMessages(debugTapBufferSize, redact)
+	go func() {
+		defer f.Close()
+		enc := json.NewEncoder(f)
+		for msg := range msgs {
+			if err := enc.Encode(msg); err != nil {
+				log.Printf("fail to write the tss message to the debug tap file: %s", err)
+				return
+			}
+		}
+	}()
+	return nil
+}
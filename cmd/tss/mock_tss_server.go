@@ -1,19 +1,33 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/akildemir/go-tss/blame"
 	"github.com/akildemir/go-tss/common"
 	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
 	"github.com/akildemir/go-tss/keygen"
 	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/storage"
+	"github.com/akildemir/go-tss/tss"
 )
 
 type MockTssServer struct {
 	failToStart   bool
 	failToKeyGen  bool
 	failToKeySign bool
+	invalidSig    bool
+	busy          bool
+	vetoed        bool
+	failToReshare bool
+	discovery     *p2p.DiscoveryEvent
+	// blockKeySign holds the keysign until it is cancelled
+	blockKeySign bool
 }
 
 func (mts *MockTssServer) Start() error {
@@ -37,10 +51,153 @@ func (mts *MockTssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 	return keygen.NewResponse(conversion.GetRandomPubKey(), "whatever", common.Success, blame.Blame{}), nil
 }
 
+func (mts *MockTssServer) KeygenContext(ctx context.Context, req keygen.Request) (keygen.Response, error) {
+	if ctx.Err() != nil {
+		return keygen.Response{}, ctx.Err()
+	}
+	return mts.Keygen(req)
+}
+
+func (mts *MockTssServer) KeySignContext(ctx context.Context, req keysign.Request) (keysign.Response, error) {
+	if mts.blockKeySign {
+		<-ctx.Done()
+		return keysign.Response{}, errcode.Wrap(errcode.Cancelled, ctx.Err())
+	}
+	if ctx.Err() != nil {
+		return keysign.Response{}, ctx.Err()
+	}
+	return mts.KeySign(req)
+}
+
+func (mts *MockTssServer) RetireKey(poolPubKey string) (tss.KeyRetirement, error) {
+	if mts.failToKeySign {
+		return tss.KeyRetirement{}, errcode.New(errcode.BadRequest, "you ask for it")
+	}
+	return tss.KeyRetirement{PoolPubKey: poolPubKey, ConfirmationToken: "token"}, nil
+}
+
+func (mts *MockTssServer) DeleteKey(poolPubKey, confirmationToken string) error {
+	if mts.failToKeySign || confirmationToken != "token" {
+		return errcode.New(errcode.BadRequest, "you ask for it")
+	}
+	return nil
+}
+
+func (mts *MockTssServer) CheckKeyHealth(poolPubKey string, blockHeight int64) (tss.KeyHealth, error) {
+	if mts.failToKeySign {
+		return tss.KeyHealth{}, errcode.New(errcode.BadRequest, "you ask for it")
+	}
+	return tss.KeyHealth{PoolPubKey: poolPubKey, BlockHeight: blockHeight, Success: true}, nil
+}
+
 func (mts *MockTssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	if mts.failToKeySign {
 		return keysign.Response{}, errors.New("you ask for it")
 	}
+	if mts.invalidSig {
+		return keysign.Response{Status: common.Fail}, keysign.ErrInvalidSignature
+	}
+	if mts.busy {
+		return keysign.Response{}, &keysign.BusyError{RetryAfter: 1500 * time.Millisecond, Queued: 3}
+	}
+	if mts.vetoed {
+		return keysign.Response{}, keysign.ErrPolicyRejected
+	}
 	newSig := keysign.NewSignature("", "", "", "")
 	return keysign.NewResponse([]keysign.Signature{newSig}, common.Success, blame.Blame{}), nil
 }
+
+func (mts *MockTssServer) Reshare(req reshare.Request) (reshare.Response, error) {
+	if mts.failToReshare {
+		return reshare.Response{}, errors.New("you ask for it")
+	}
+	return reshare.NewResponse(req.PoolPubKey, "whatever", common.Success, blame.Blame{}), nil
+}
+
+func (mts *MockTssServer) ListKeys() ([]tss.KeyInfo, error) {
+	if mts.failToKeySign {
+		return nil, errors.New("you ask for it")
+	}
+	return []tss.KeyInfo{{PoolPubKey: "pool", Threshold: 2}}, nil
+}
+
+func (mts *MockTssServer) GetStatus() tss.Status {
+	return tss.Status{Discovery: mts.discovery}
+}
+
+func (mts *MockTssServer) GetHealth() tss.Health {
+	return tss.Health{
+		Discovery:     mts.discovery,
+		Connectivity:  p2p.Connectivity{HostStarted: true},
+		KeyshareStore: true,
+	}.Evaluate()
+}
+
+func (mts *MockTssServer) GetPeerStats() map[string]map[string]p2p.ProtocolStats {
+	return map[string]map[string]p2p.ProtocolStats{
+		"peer": {"/p2p/tss/proto": {MessagesIn: 1, MessagesOut: 2}},
+	}
+}
+
+func (mts *MockTssServer) SubscribeCeremonyEvents(bufferSize int) (<-chan tss.CeremonyEvent, func()) {
+	ch := make(chan tss.CeremonyEvent, 1)
+	ch <- tss.CeremonyEvent{Type: tss.CeremonyStarted, MsgID: "msg", Kind: "keysign"}
+	close(ch)
+	return ch, func() {}
+}
+
+func (mts *MockTssServer) GetPeers() []tss.PeerInfo {
+	return []tss.PeerInfo{{PeerID: "peer", Connected: true, Score: 1}}
+}
+
+func (mts *MockTssServer) BanPeer(peerID string, duration time.Duration) error {
+	if peerID != "peer" {
+		return errcode.New(errcode.BadRequest, "unknown peer")
+	}
+	return nil
+}
+
+func (mts *MockTssServer) UnbanPeer(peerID string) (bool, error) {
+	return peerID == "peer", nil
+}
+
+func (mts *MockTssServer) GetActiveCeremonies() []storage.CeremonyRecord {
+	return []storage.CeremonyRecord{{MsgID: "msg", Kind: "keysign", Round: "round1"}}
+}
+
+func (mts *MockTssServer) GetQueuedKeySigns() []tss.QueuedKeySign {
+	return []tss.QueuedKeySign{{MsgID: "queued", PoolPubKey: "pool"}}
+}
+
+func (mts *MockTssServer) GetDiagnostics() tss.Diagnostics {
+	return tss.Diagnostics{QueuedKeySigns: mts.GetQueuedKeySigns()}
+}
+
+func (mts *MockTssServer) GetVersion() tss.VersionInfo {
+	return tss.VersionInfo{
+		Version:            "1.0.0",
+		Commit:             "commit",
+		MinProtocolVersion: p2p.MinProtocolVersion,
+		MaxProtocolVersion: p2p.MaxProtocolVersion,
+		Algos:              []conversion.Algo{conversion.AlgoSecp256k1},
+		SignProtocols:      []conversion.SignProtocol{conversion.SignProtocolGG20},
+		Features:           []string{p2p.FeatureProtobuf},
+	}
+}
+
+func (mts *MockTssServer) ExportAuditLog(fromSeq uint64) ([]storage.AuditRecord, error) {
+	records := []storage.AuditRecord{}
+	for _, el := range []storage.AuditRecord{{Seq: 1, PoolPubKey: "pool"}, {Seq: 2, PoolPubKey: "pool"}} {
+		if el.Seq >= fromSeq {
+			records = append(records, el)
+		}
+	}
+	return records, nil
+}
+
+func (mts *MockTssServer) CancelCeremony(msgID string) error {
+	if msgID != "msg" {
+		return tss.ErrCeremonyNotFound
+	}
+	return nil
+}
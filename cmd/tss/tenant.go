@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/tss"
+)
+
+// tenantConfig is a committee the node serves besides the one of the flags, it has its own p2p host, rendezvous
+// and keyshares, the other settings are the ones of the flags
+type tenantConfig struct {
+	ID string `yaml:"id"`
+	// Home is the folder of the keyshares of the tenant
+	Home         string   `yaml:"home"`
+	Rendezvous   string   `yaml:"rendezvous"`
+	P2PPort      int      `yaml:"p2p_port"`
+	ExternalIP   string   `yaml:"external_ip"`
+	Peers        []string `yaml:"peers"`
+	AllowedPeers []string `yaml:"allowed_peers"`
+	// KeyGenTimeout and KeySignTimeout override gentimeout and signtimeout if they are set
+	KeyGenTimeout  time.Duration `yaml:"keygen_timeout"`
+	KeySignTimeout time.Duration `yaml:"keysign_timeout"`
+}
+
+// tenantsFile is the yaml file of the tenants
+//
+//	tenants:
+//	  - id: btc
+//	    home: /var/lib/tss/btc
+//	    rendezvous: btc-committee
+//	    p2p_port: 6669
+//	    peers: ["/ip4/10.0.0.2/tcp/6669/p2p/16Uiu2..."]
+type tenantsFile struct {
+	Tenants []tenantConfig `yaml:"tenants"`
+}
+
+// loadTenants read the tenants of the yaml file, they can not share the home folder, the rendezvous or the p2p
+// port with each other or with the default tenant
+func loadTenants(path string, p2pConf p2p.Config) ([]tenantConfig, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the tenants file: %w", err)
+	}
+	var f tenantsFile
+	if err := yaml.Unmarshal(buf, &f); err != nil {
+		return nil, fmt.Errorf("fail to parse the tenants file: %w", err)
+	}
+	if err := validateTenants(f.Tenants, p2pConf); err != nil {
+		return nil, err
+	}
+	return f.Tenants, nil
+}
+
+// validateTenants check the tenants are complete and do not collide
+func validateTenants(tenants []tenantConfig, p2pConf p2p.Config) error {
+	if len(tenants) == 0 {
+		return nil
+	}
+	// the passphrase of the default tenant is read from stdin, the tenants can not prompt for theirs
+	if stateBackend == stateBackendFile && encryptKeyshares && len(keyshareKeyFile) == 0 {
+		return errors.New("the tenants need -keyshare-keyfile to encrypt their keyshares")
+	}
+	ids := map[string]bool{common.DefaultTenant: true}
+	homes := map[string]bool{filepath.Clean(baseFolder): true}
+	rendezvous := map[string]bool{p2pConf.RendezvousString: true}
+	ports := map[int]bool{p2pConf.Port: true}
+	for _, el := range tenants {
+		if err := common.ValidateTenantID(el.ID); err != nil {
+			return err
+		}
+		if ids[el.ID] {
+			return fmt.Errorf("the tenant %s is duplicated", el.ID)
+		}
+		ids[el.ID] = true
+		if len(el.Home) == 0 || homes[filepath.Clean(el.Home)] {
+			return fmt.Errorf("the tenant %s needs a home folder of its own", el.ID)
+		}
+		homes[filepath.Clean(el.Home)] = true
+		if len(el.Rendezvous) == 0 || rendezvous[el.Rendezvous] {
+			return fmt.Errorf("the tenant %s needs a rendezvous of its own", el.ID)
+		}
+		rendezvous[el.Rendezvous] = true
+		if el.P2PPort <= 0 || el.P2PPort > 65535 || ports[el.P2PPort] {
+			return fmt.Errorf("the tenant %s needs a p2p port of its own", el.ID)
+		}
+		ports[el.P2PPort] = true
+		if el.KeyGenTimeout < 0 || el.KeySignTimeout < 0 {
+			return fmt.Errorf("the timeouts of the tenant %s can not be negative", el.ID)
+		}
+	}
+	return nil
+}
+
+// p2pConfig return the p2p config of the tenant, it is the one of the flags with the rendezvous, the port and the
+// peers of the tenant
+func (tc tenantConfig) p2pConfig(p2pConf p2p.Config) (p2p.Config, error) {
+	conf := p2pConf
+	conf.RendezvousString = tc.Rendezvous
+	conf.Port = tc.P2PPort
+	conf.ExternalIP = tc.ExternalIP
+	conf.AnnounceAddrs = nil
+	conf.BootstrapPeers = nil
+	for _, el := range tc.Peers {
+		if err := conf.BootstrapPeers.Set(el); err != nil {
+			return conf, fmt.Errorf("invalid peer %q of the tenant %s: %w", el, tc.ID, err)
+		}
+	}
+	conf.AllowedPeers = nil
+	for _, el := range tc.AllowedPeers {
+		if err := conf.AllowedPeers.Set(el); err != nil {
+			return conf, fmt.Errorf("invalid allowed peer %q of the tenant %s: %w", el, tc.ID, err)
+		}
+	}
+	return conf, nil
+}
+
+// tssConfig return the tss config of the tenant, it is the one of the flags without the pool keys of the default
+// tenant the background keysigns use
+func (tc tenantConfig) tssConfig(tssConf common.TssConfig) common.TssConfig {
+	conf := tssConf
+	conf.CanaryInterval = 0
+	conf.CanaryPoolPubKey = ""
+	conf.HealthCheckInterval = 0
+	conf.HealthCheckPoolPubKeys = nil
+	conf.PresignPoolPubKey = ""
+	conf.PresignPoolSize = 0
+	conf.RefreshPoolPubKey = ""
+	conf.RefreshInterval = 0
+	conf.RetiredKeyArchiveFolder = filepath.Join(tc.Home, "archive")
+	if tc.KeyGenTimeout > 0 {
+		conf.KeyGenTimeout = tc.KeyGenTimeout
+	}
+	if tc.KeySignTimeout > 0 {
+		conf.KeySignTimeout = tc.KeySignTimeout
+	}
+	return conf
+}
+
+// newTenantStateManager create the state manager of the keyshares of the tenant, it is the one of the state
+// backend in the home folder of the tenant, and under its id in vault and the S3 bucket
+func newTenantStateManager(tc tenantConfig) (stateStore, error) {
+	return newBackendStateManager(nil, tc.Home, tc.ID)
+}
+
+// newTenant create the tss server of the tenant, it signs with the node key as the default tenant does
+func newTenant(tc tenantConfig, tssConf common.TssConfig, p2pConf p2p.Config, signer conversion.Signer, opts ...tss.Option) (tss.Server, error) {
+	conf, err := tc.p2pConfig(p2pConf)
+	if err != nil {
+		return nil, err
+	}
+	stateManager, err := newTenantStateManager(tc)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the state manager of the tenant %s: %w", tc.ID, err)
+	}
+	comm, _, err := newCommunication(conf, stateManager, signer)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the p2p layer of the tenant %s: %w", tc.ID, err)
+	}
+	opts = append([]tss.Option{tss.WithStateManager(stateManager)}, opts...)
+	server, err := tss.NewTss(comm, conversion.NewSignerPrivKey(signer), tc.Home, tc.tssConfig(tssConf), nil, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the tss server of the tenant %s: %w", tc.ID, err)
+	}
+	return server, nil
+}
+
+// newTenants create the tss servers of the tenants, the default tenant is the tss server of the flags
+func newTenants(defaultServer tss.Server, confs []tenantConfig, tssConf common.TssConfig, p2pConf p2p.Config, signer conversion.Signer, opts ...tss.Option) (*tss.Tenants, error) {
+	tenants := tss.NewTenants(defaultServer)
+	for _, el := range confs {
+		server, err := newTenant(el, tssConf, p2pConf, signer, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := tenants.Add(el.ID, server); err != nil {
+			return nil, err
+		}
+	}
+	return tenants, nil
+}
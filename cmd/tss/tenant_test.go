@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+	"github.com/akildemir/go-tss/tss"
+)
+
+type TenantTestSuite struct{}
+
+var _ = Suite(&TenantTestSuite{})
+
+// serveTenantRoute serve the request of the route for the tenant
+func serveTenantRoute(handler http.Handler, method, route, tenant string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, route, nil)
+	req.Header.Set("X-Tss-Tenant", tenant)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func (TenantTestSuite) TestLoadTenants(c *C) {
+	baseFolder, stateBackend, encryptKeyshares = "/var/lib/tss", stateBackendFile, false
+	defer func() {
+		baseFolder, stateBackend = "", ""
+	}()
+	p2pConf := p2p.Config{RendezvousString: "Asgard", Port: 6668}
+	folder := c.MkDir()
+	path := filepath.Join(folder, "tenants.yaml")
+
+	tenants, err := loadTenants("", p2pConf)
+	c.Assert(err, IsNil)
+	c.Assert(tenants, HasLen, 0)
+	_, err = loadTenants(path, p2pConf)
+	c.Assert(err, NotNil)
+
+	writeConfig(c, path, `
+tenants:
+  - id: btc
+    home: /var/lib/tss/btc
+    rendezvous: btc-committee
+    p2p_port: 6669
+    keysign_timeout: 1m
+  - id: eth
+    home: /var/lib/tss/eth
+    rendezvous: eth-committee
+    p2p_port: 6670
+`)
+	tenants, err = loadTenants(path, p2pConf)
+	c.Assert(err, IsNil)
+	c.Assert(tenants, HasLen, 2)
+	c.Assert(tenants[0].ID, Equals, "btc")
+	c.Assert(tenants[0].KeySignTimeout, Equals, time.Minute)
+
+	valid := tenantConfig{ID: "btc", Home: "/var/lib/tss/btc", Rendezvous: "btc-committee", P2PPort: 6669}
+	for _, el := range []func(tc *tenantConfig){
+		func(tc *tenantConfig) { tc.ID = "default" },
+		func(tc *tenantConfig) { tc.ID = "BTC" },
+		func(tc *tenantConfig) { tc.Home = "" },
+		func(tc *tenantConfig) { tc.Home = "/var/lib/tss/" },
+		func(tc *tenantConfig) { tc.Rendezvous = "Asgard" },
+		func(tc *tenantConfig) { tc.P2PPort = 6668 },
+		func(tc *tenantConfig) { tc.P2PPort = 0 },
+		func(tc *tenantConfig) { tc.KeyGenTimeout = -time.Second },
+	} {
+		tc := valid
+		el(&tc)
+		c.Assert(validateTenants([]tenantConfig{tc}, p2pConf), NotNil, Commentf("%+v", tc))
+	}
+	c.Assert(validateTenants([]tenantConfig{valid, valid}, p2pConf), NotNil)
+	c.Assert(validateTenants([]tenantConfig{valid}, p2pConf), IsNil)
+	// the tenants keep their keyshares in the state backend of the default tenant
+	stateBackend = stateBackendVault
+	c.Assert(validateTenants([]tenantConfig{valid}, p2pConf), IsNil)
+	stateBackend, encryptKeyshares = stateBackendFile, true
+	c.Assert(validateTenants([]tenantConfig{valid}, p2pConf), NotNil)
+	encryptKeyshares = false
+}
+
+func (TenantTestSuite) TestTenantConfig(c *C) {
+	tc := tenantConfig{
+		ID:            "btc",
+		Home:          "/var/lib/tss/btc",
+		Rendezvous:    "btc-committee",
+		P2PPort:       6669,
+		Peers:         []string{"/ip4/10.0.0.2/tcp/6669/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"},
+		KeyGenTimeout: time.Minute,
+	}
+	p2pConf := p2p.Config{RendezvousString: "Asgard", Port: 6668}
+	conf, err := tc.p2pConfig(p2pConf)
+	c.Assert(err, IsNil)
+	c.Assert(conf.RendezvousString, Equals, "btc-committee")
+	c.Assert(conf.Port, Equals, 6669)
+	c.Assert(conf.BootstrapPeers, HasLen, 1)
+	tc.Peers = []string{"whatever"}
+	_, err = tc.p2pConfig(p2pConf)
+	c.Assert(err, NotNil)
+
+	tssConf := tc.tssConfig(common.TssConfig{
+		KeyGenTimeout:     30 * time.Second,
+		KeySignTimeout:    30 * time.Second,
+		CanaryPoolPubKey:  "canary",
+		PresignPoolPubKey: "presign",
+		RefreshPoolPubKey: "refresh",
+	})
+	c.Assert(tssConf.KeyGenTimeout, Equals, time.Minute)
+	c.Assert(tssConf.KeySignTimeout, Equals, 30*time.Second)
+	c.Assert(tssConf.CanaryPoolPubKey, Equals, "")
+	c.Assert(tssConf.PresignPoolPubKey, Equals, "")
+	c.Assert(tssConf.RefreshPoolPubKey, Equals, "")
+	c.Assert(tssConf.RetiredKeyArchiveFolder, Equals, "/var/lib/tss/btc/archive")
+}
+
+func (TenantTestSuite) TestTenantStateManager(c *C) {
+	defer func() {
+		stateBackend, sqlitePath = "", ""
+	}()
+	tc := tenantConfig{ID: "btc", Home: c.MkDir()}
+	// the tenant keeps its keyshares in the state backend of the default tenant, in its own home folder
+	stateBackend, sqlitePath = stateBackendSqlite, filepath.Join(c.MkDir(), "state.db")
+	stateManager, err := newTenantStateManager(tc)
+	c.Assert(err, IsNil)
+	_, ok := stateManager.(*storage.SqliteStateMgr)
+	c.Assert(ok, Equals, true)
+	_, err = os.Stat(filepath.Join(tc.Home, "state.db"))
+	c.Assert(err, IsNil)
+	_, err = os.Stat(sqlitePath)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	stateBackend = stateBackendMemory
+	stateManager, err = newTenantStateManager(tc)
+	c.Assert(err, IsNil)
+	_, ok = stateManager.(*storage.MemoryStateMgr)
+	c.Assert(ok, Equals, true)
+}
+
+func (TenantTestSuite) TestTenantRoutes(c *C) {
+	tenants := tss.NewTenants(&MockTssServer{})
+	c.Assert(tenants.Add("btc", &MockTssServer{failToKeySign: true}), IsNil)
+	c.Assert(tenants.Add("btc", &MockTssServer{}), NotNil)
+	s := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}, WithTenants(tenants))
+	defer s.jobs.stop()
+	handler := s.tssNewHandler()
+
+	res := serveTenantRoute(handler, http.MethodGet, "/v2/tenants", "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var ids []string
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &ids), IsNil)
+	c.Assert(ids, DeepEquals, []string{"btc", "default"})
+
+	// the request goes to the server of its tenant
+	c.Assert(serveTenantRoute(handler, http.MethodGet, "/v2/keys", "").Code, Equals, http.StatusOK)
+	c.Assert(serveTenantRoute(handler, http.MethodGet, "/v2/keys", "default").Code, Equals, http.StatusOK)
+	c.Assert(serveTenantRoute(handler, http.MethodGet, "/keys", "btc").Code, Equals, http.StatusInternalServerError)
+	res = serveTenantRoute(handler, http.MethodGet, "/v1/keys", "eth")
+	c.Assert(res.Code, Equals, http.StatusNotFound)
+	var errResp errorResponse
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &errResp), IsNil)
+	c.Assert(errResp.Code, Equals, errcode.NotFound)
+
+	// the jobs of a tenant are not found by the other tenants
+	j, err := s.jobs.start("btc", "keysign", func(_ context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(serveTenantRoute(handler, http.MethodGet, "/v2/jobs/"+j.ID, "btc").Code, Equals, http.StatusOK)
+	c.Assert(serveTenantRoute(handler, http.MethodGet, "/v2/jobs/"+j.ID, "").Code, Equals, http.StatusNotFound)
+	c.Assert(serveTenantRoute(handler, http.MethodDelete, "/v2/jobs/"+j.ID, "").Code, Equals, http.StatusNotFound)
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -13,45 +17,150 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/hd"
 	"github.com/akildemir/go-tss/keygen"
 	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/reshare"
 	"github.com/akildemir/go-tss/tss"
 )
 
 // TssHttpServer provide http endpoint for tss server
 type TssHttpServer struct {
-	logger    zerolog.Logger
-	tssServer tss.Server
-	s         *http.Server
+	logger     zerolog.Logger
+	tssServer  tss.Server
+	authorizer *auth.Authorizer
+	tlsConfig  *tls.Config
+	// tenants are the committees we serve, tssServer is the default one
+	tenants *tss.Tenants
+	// legacySunset is the date the unversioned routes are removed
+	legacySunset time.Time
+	// diagnostics serves pprof and the dumps of the goroutines and the ceremonies
+	diagnostics bool
+	jobs        *jobStore
+	s           *http.Server
 }
 
-// NewTssHttpServer should only listen to the loopback
-func NewTssHttpServer(tssAddr string, t tss.Server) *TssHttpServer {
+// HttpServerOption configures the authentication of the http server
+type HttpServerOption func(*TssHttpServer)
+
+// WithAuthorizer only let the callers with the role of the route through
+func WithAuthorizer(authorizer *auth.Authorizer) HttpServerOption {
+	return func(hs *TssHttpServer) {
+		hs.authorizer = authorizer
+	}
+}
+
+// WithTenants serve the committees of the tenants, the requests name their tenant in the X-Tss-Tenant header, the
+// tss server of NewTssHttpServer should be the default tenant of them
+func WithTenants(tenants *tss.Tenants) HttpServerOption {
+	return func(hs *TssHttpServer) {
+		hs.tenants = tenants
+	}
+}
+
+// WithTLS serve the api over TLS, the client certificates are verified as the config says
+func WithTLS(tlsConfig *tls.Config) HttpServerOption {
+	return func(hs *TssHttpServer) {
+		hs.tlsConfig = tlsConfig
+	}
+}
+
+// NewTssHttpServer should only listen to the loopback unless the authentication is enabled
+func NewTssHttpServer(tssAddr string, t tss.Server, opts ...HttpServerOption) *TssHttpServer {
 	hs := &TssHttpServer{
 		logger:    log.With().Str("module", "http").Logger(),
 		tssServer: t,
+		jobs:      newJobStore(),
+	}
+	for _, opt := range opts {
+		opt(hs)
+	}
+	if hs.tenants == nil {
+		hs.tenants = tss.NewTenants(t)
 	}
 	s := &http.Server{
-		Addr:    tssAddr,
-		Handler: hs.tssNewHandler(),
+		Addr:      tssAddr,
+		Handler:   hs.tssNewHandler(),
+		TLSConfig: hs.tlsConfig,
 	}
 	hs.s = s
 	return hs
 }
 
-// NewHandler registers the API routes and returns a new HTTP handler
+// NewHandler registers the API routes and returns a new HTTP handler, the keygen and the management of the keys
+// need the admin role, the keysign needs the sign role, the queries need the read role and the probes are public.
+// The probes, the metrics and the version are not versioned, the orchestrators poll them at fixed paths
 func (t *TssHttpServer) tssNewHandler() http.Handler {
 	router := mux.NewRouter()
-	router.Handle("/keygen", http.HandlerFunc(t.keygenHandler)).Methods(http.MethodPost)
-	router.Handle("/keysign", http.HandlerFunc(t.keySignHandler)).Methods(http.MethodPost)
-	router.Handle("/ping", http.HandlerFunc(t.pingHandler)).Methods(http.MethodGet)
-	router.Handle("/p2pid", http.HandlerFunc(t.getP2pIDHandler)).Methods(http.MethodGet)
-	router.Handle("/metrics", promhttp.Handler())
+	router.Handle("/ping", t.authorize(auth.RolePublic, t.pingHandler)).Methods(http.MethodGet)
+	router.Handle("/health", t.authorize(auth.RolePublic, t.healthHandler)).Methods(http.MethodGet)
+	router.Handle("/ready", t.authorize(auth.RolePublic, t.readyHandler)).Methods(http.MethodGet)
+	router.Handle("/version", t.authorize(auth.RoleRead, t.versionHandler)).Methods(http.MethodGet)
+	router.Handle("/metrics", t.authorizer.Handler(auth.RoleRead, promhttp.Handler()))
+	t.versionRoutes(router)
+	t.diagnosticsRoutes(router)
 	router.Use(logMiddleware())
 	return router
 }
 
+// authorize only let the callers with the role and the tenant of the request through to the handler
+func (t *TssHttpServer) authorize(required auth.Role, handler http.HandlerFunc) http.Handler {
+	return t.authorizer.Handler(required, t.withTenant(handler))
+}
+
+type tenantKey struct{}
+
+// requestTenant is the tenant the request is for and its tss server
+type requestTenant struct {
+	id     string
+	server tss.Server
+}
+
+// withTenant pass the tenant of the X-Tss-Tenant header of the request to the handler, the unknown tenants are 404
+func (t *TssHttpServer) withTenant(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := common.TenantOrDefault(r.Header.Get(auth.TenantHeader))
+		server, err := t.tenants.Get(id)
+		if err != nil {
+			t.writeError(w, err)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, requestTenant{id: id, server: server})))
+	}
+}
+
+// server return the tss server of the tenant of the request
+func (t *TssHttpServer) server(r *http.Request) tss.Server {
+	if tenant, ok := r.Context().Value(tenantKey{}).(requestTenant); ok {
+		return tenant.server
+	}
+	return t.tssServer
+}
+
+// tenant return the tenant of the request
+func (t *TssHttpServer) tenant(r *http.Request) string {
+	if tenant, ok := r.Context().Value(tenantKey{}).(requestTenant); ok {
+		return tenant.id
+	}
+	return common.DefaultTenant
+}
+
+// keygenHandler is the v1 keygen, it only generates the secp256k1 keys
 func (t *TssHttpServer) keygenHandler(w http.ResponseWriter, r *http.Request) {
+	t.serveKeygen(w, r, checkV1Algo)
+}
+
+// keygenV2Handler is the v2 keygen, it generates the keys of the algo of the request
+func (t *TssHttpServer) keygenV2Handler(w http.ResponseWriter, r *http.Request) {
+	t.serveKeygen(w, r, nil)
+}
+
+// serveKeygen run the keygen of the request, checkAlgo refuses the algos the version of the api does not take
+func (t *TssHttpServer) serveKeygen(w http.ResponseWriter, r *http.Request, checkAlgo func(conversion.Algo) error) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
@@ -69,8 +178,15 @@ func (t *TssHttpServer) keygenHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	if checkAlgo != nil {
+		if err := checkAlgo(keygenReq.Algo); err != nil {
+			t.writeError(w, err)
+			return
+		}
+	}
 
-	resp, err := t.tssServer.Keygen(keygenReq)
+	// the keygen is cancelled if the caller goes away
+	resp, err := t.server(r).KeygenContext(r.Context(), keygenReq)
 	if err != nil {
 		t.logger.Error().Err(err).Msg("fail to key gen")
 	}
@@ -87,7 +203,54 @@ func (t *TssHttpServer) keygenHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (t *TssHttpServer) reshareHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	t.logger.Info().Msg("receive reshare request")
+	decoder := json.NewDecoder(r.Body)
+	var reshareReq reshare.Request
+	if err := decoder.Decode(&reshareReq); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode reshare request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resp, err := t.server(r).Reshare(reshareReq)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to reshare")
+	}
+	t.logger.Debug().Msgf("resp:%+v", resp)
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// keySignHandler is the v1 keysign, it only signs with the secp256k1 keys
 func (t *TssHttpServer) keySignHandler(w http.ResponseWriter, r *http.Request) {
+	t.serveKeySign(w, r, checkV1Algo)
+}
+
+// keySignV2Handler is the v2 keysign, it signs with the keys of the algo of the request
+func (t *TssHttpServer) keySignV2Handler(w http.ResponseWriter, r *http.Request) {
+	t.serveKeySign(w, r, nil)
+}
+
+// serveKeySign run the keysign of the request, checkAlgo refuses the algos the version of the api does not take
+func (t *TssHttpServer) serveKeySign(w http.ResponseWriter, r *http.Request, checkAlgo func(conversion.Algo) error) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
@@ -106,11 +269,18 @@ func (t *TssHttpServer) keySignHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	if checkAlgo != nil {
+		if err := checkAlgo(keySignReq.Algo); err != nil {
+			t.writeError(w, err)
+			return
+		}
+	}
 	t.logger.Info().Msgf("request:%+v", keySignReq)
-	signResp, err := t.tssServer.KeySign(keySignReq)
+	// the keysign is cancelled if the caller goes away
+	signResp, err := t.server(r).KeySignContext(r.Context(), keySignReq)
 	if err != nil {
 		t.logger.Error().Err(err).Msg("fail to key sign")
-		w.WriteHeader(http.StatusInternalServerError)
+		t.writeError(w, err)
 		return
 	}
 
@@ -126,14 +296,117 @@ func (t *TssHttpServer) keySignHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// errorResponse is the body of the failed requests, the callers decide to retry or to give up with the code
+type errorResponse struct {
+	Code      errcode.Code `json:"code"`
+	Error     string       `json:"error"`
+	Retryable bool         `json:"retryable"`
+}
+
+// newErrorResponse return the body of the error
+func newErrorResponse(err error) errorResponse {
+	code := errcode.Of(err)
+	return errorResponse{
+		Code:      code,
+		Error:     err.Error(),
+		Retryable: code.Retryable(),
+	}
+}
+
+// errorStatus return the http status code of the error code
+func errorStatus(code errcode.Code) int {
+	switch code {
+	case errcode.BadRequest:
+		return http.StatusBadRequest
+	case errcode.NotFound, errcode.ShareNotFound:
+		return http.StatusNotFound
+	case errcode.KeyRetired:
+		return http.StatusGone
+	case errcode.Busy, errcode.ShuttingDown:
+		return http.StatusServiceUnavailable
+	case errcode.Cancelled:
+		return http.StatusConflict
+	case errcode.JoinPartyTimeout, errcode.TssTimeout:
+		return http.StatusGatewayTimeout
+	case errcode.PeerUnreachable, errcode.PeerAborted, errcode.PeerMisbehaved:
+		return http.StatusBadGateway
+	case errcode.InvalidSignature:
+		// the callers should never broadcast the signature, so the invalid signature has its own status code
+		return http.StatusUnprocessableEntity
+	case errcode.PolicyRejected, errcode.DoubleSign:
+		return http.StatusForbidden
+	case errcode.Unsupported:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError write the code of the error, the busy keysigns tell the caller when to send them again
+func (t *TssHttpServer) writeError(w http.ResponseWriter, err error) {
+	var busy *keysign.BusyError
+	if errors.As(err, &busy) {
+		t.writeBusy(w, busy)
+		return
+	}
+	code := errcode.Of(err)
+	buf, errMarshal := json.Marshal(newErrorResponse(err))
+	if errMarshal != nil {
+		t.logger.Error().Err(errMarshal).Msg("fail to marshal the error to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errorStatus(code))
+	if _, err := w.Write(buf); err != nil {
+		t.logger.Error().Err(err).Msg("fail to write response")
+	}
+}
+
+// writeBusy tell the caller to send the keysign again later, with the Retry-After header in seconds
+func (t *TssHttpServer) writeBusy(w http.ResponseWriter, busy *keysign.BusyError) {
+	retryAfter := int64(math.Ceil(busy.RetryAfter.Seconds()))
+	buf, err := json.Marshal(struct {
+		errorResponse
+		RetryAfter int64 `json:"retry_after"`
+		Queued     int   `json:"queued"`
+	}{
+		errorResponse: errorResponse{
+			Code:      errcode.Busy,
+			Error:     busy.Error(),
+			Retryable: true,
+		},
+		RetryAfter: retryAfter,
+		Queued:     busy.Queued,
+	})
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal the busy response to json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	if _, err := w.Write(buf); err != nil {
+		t.logger.Error().Err(err).Msg("fail to write response")
+	}
+}
+
 func (t *TssHttpServer) Start() error {
 	if t.s == nil {
 		return errors.New("invalid http server instance")
 	}
-	if err := t.tssServer.Start(); err != nil {
+	if err := t.tenants.Start(); err != nil {
 		return fmt.Errorf("fail to start tss server: %w", err)
 	}
-	if err := t.s.ListenAndServe(); err != nil {
+	var err error
+	if t.tlsConfig != nil {
+		// the certificates are in the tls config already
+		err = t.s.ListenAndServeTLS("", "")
+	} else {
+		err = t.s.ListenAndServe()
+	}
+	if err != nil {
 		if err != http.ErrServerClosed {
 			return fmt.Errorf("fail to start http server: %w", err)
 		}
@@ -156,14 +429,17 @@ func logMiddleware() mux.MiddlewareFunc {
 	}
 }
 
+// Stop drain the tss server first, so the in-flight requests get their responses while the new ones are refused,
+// then cancel the jobs that are still running and shut down the http server
 func (t *TssHttpServer) Stop() error {
+	t.tenants.Stop()
+	t.jobs.stop()
 	c, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	err := t.s.Shutdown(c)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to shutdown the Tss server gracefully")
 	}
-	t.tssServer.Stop()
 	return err
 }
 
@@ -171,10 +447,227 @@ func (t *TssHttpServer) pingHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (t *TssHttpServer) getP2pIDHandler(w http.ResponseWriter, _ *http.Request) {
-	localPeerID := t.tssServer.GetLocalPeerID()
+// versionHandler return the build, the protocol versions, the algos and the features of the node, the coordinators
+// check the committee runs the same before they trigger a keygen
+func (t *TssHttpServer) versionHandler(w http.ResponseWriter, r *http.Request) {
+	t.writeJSON(w, t.server(r).GetVersion())
+}
+
+func (t *TssHttpServer) getP2pIDHandler(w http.ResponseWriter, r *http.Request) {
+	localPeerID := t.server(r).GetLocalPeerID()
 	_, err := w.Write([]byte(localPeerID))
 	if err != nil {
 		t.logger.Error().Err(err).Msg("fail to write to response")
 	}
 }
+
+func (t *TssHttpServer) getStatusHandler(w http.ResponseWriter, r *http.Request) {
+	buf, err := json.Marshal(t.server(r).GetStatus())
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal status to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// derivePubKeyHandler return the child pub key of the pool_pub_key at the non-hardened derivation path, the
+// keysign with the same derivation_path signs with it
+func (t *TssHttpServer) derivePubKeyHandler(w http.ResponseWriter, r *http.Request) {
+	poolPubKey := r.URL.Query().Get("pool_pub_key")
+	path := r.URL.Query().Get("path")
+	child, chainCode, err := hd.DerivePubKey(poolPubKey, path)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to derive the child pub key")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	pubKey, addr, err := conversion.GetTssPubKey(child)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to get the child pub key")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	buf, err := json.Marshal(hd.ChildPubKey{
+		PubKey:      pubKey,
+		PoolAddress: addr.String(),
+		ChainCode:   hex.EncodeToString(chainCode),
+	})
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// healthCheckRequest asks for the health check keysign of the vault, all the members of the vault should send it
+// with the same block height
+type healthCheckRequest struct {
+	PoolPubKey  string `json:"pool_pub_key"`
+	BlockHeight int64  `json:"block_height"`
+}
+
+// healthCheckHandler run the health check keysign of the vault, it only returns whether the vault signed, the
+// signature is never exposed
+func (t *TssHttpServer) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	var req healthCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode health check request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	result, err := t.server(r).CheckKeyHealth(req.PoolPubKey, req.BlockHeight)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to check the health of the vault")
+		t.writeError(w, err)
+		return
+	}
+	buf, err := json.Marshal(result)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// retireRequest is the request of the retirement or the deletion of a key, the deletion needs the confirmation
+// token the retirement returned
+type retireRequest struct {
+	PoolPubKey        string `json:"pool_pub_key"`
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+}
+
+// retireHandler mark the key as retired, the response carries the confirmation token of the deletion
+func (t *TssHttpServer) retireHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	var req retireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode retire request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	result, err := t.server(r).RetireKey(req.PoolPubKey)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to retire the key")
+		t.writeError(w, err)
+		return
+	}
+	buf, err := json.Marshal(result)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal response to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// deleteHandler delete the share of the retired key
+func (t *TssHttpServer) deleteHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if err := r.Body.Close(); nil != err {
+			t.logger.Error().Err(err).Msg("fail to close request body")
+		}
+	}()
+	var req retireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); nil != err {
+		t.logger.Error().Err(err).Msg("fail to decode delete request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := t.server(r).DeleteKey(req.PoolPubKey, req.ConfirmationToken); err != nil {
+		t.logger.Error().Err(err).Msg("fail to delete the key")
+		t.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// listKeysHandler return the metadata of all the shares the node holds
+func (t *TssHttpServer) listKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := t.server(r).ListKeys()
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to list the keys")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	buf, err := json.Marshal(keys)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal the keys to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// getPeerStatsHandler return the tss message statistics of each peer over each protocol
+func (t *TssHttpServer) getPeerStatsHandler(w http.ResponseWriter, r *http.Request) {
+	buf, err := json.Marshal(t.server(r).GetPeerStats())
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal peer stats to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(buf)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
+
+// healthHandler reports the health of the node, it fails while the host has not started or the keyshares can
+// not be read
+func (t *TssHttpServer) healthHandler(w http.ResponseWriter, r *http.Request) {
+	health := t.server(r).GetHealth()
+	t.writeHealth(w, health, health.Healthy)
+}
+
+// readyHandler reports whether the node can take part in the ceremonies, that is it is healthy, it has joined the
+// p2p network or can reach a signing quorum of the cached committee, and it is not isolated, it keeps failing while
+// we retry the bootstrap without the quorum
+func (t *TssHttpServer) readyHandler(w http.ResponseWriter, r *http.Request) {
+	health := t.server(r).GetHealth()
+	t.writeHealth(w, health, health.Ready)
+}
+
+// writeHealth write the health of the node, with the reasons it is not healthy or not ready, the status code is
+// 503 if ok is false
+func (t *TssHttpServer) writeHealth(w http.ResponseWriter, health tss.Health, ok bool) {
+	buf, err := json.Marshal(health)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal the health to json")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if _, err := w.Write(buf); err != nil {
+		t.logger.Error().Err(err).Msg("fail to write to response")
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -11,7 +13,14 @@ import (
 
 	. "gopkg.in/check.v1"
 
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/hd"
 	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/tss"
 )
 
 func TestPackage(t *testing.T) { TestingT(t) }
@@ -48,6 +57,207 @@ func (TssHttpServerTestSuite) TestPingHandler(c *C) {
 	c.Assert(res.Code, Equals, http.StatusOK)
 }
 
+func (TssHttpServerTestSuite) TestReadyHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+	res := httptest.NewRecorder()
+	s.readyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusServiceUnavailable)
+
+	tssServer.discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryDegraded}
+	res = httptest.NewRecorder()
+	s.readyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusServiceUnavailable)
+
+	// we can sign with the cached committee while the DHT bootstrap is still retrying
+	tssServer.discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryDegraded, QuorumReachable: true}
+	res = httptest.NewRecorder()
+	s.readyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+
+	tssServer.discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryReady}
+	res = httptest.NewRecorder()
+	s.readyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+
+	// we cannot sign while we are isolated from the bootstrap peers and the committee
+	tssServer.discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryReady, Isolated: true}
+	res = httptest.NewRecorder()
+	s.readyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusServiceUnavailable)
+	var health tss.Health
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &health), IsNil)
+	c.Assert(health.Reasons, DeepEquals, []tss.HealthReason{tss.HealthIsolated})
+}
+
+func (TssHttpServerTestSuite) TestHealthHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	res := httptest.NewRecorder()
+	// we are healthy while the discovery is still pending, but not ready
+	s.healthHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var health tss.Health
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &health), IsNil)
+	c.Assert(health.Healthy, Equals, true)
+	c.Assert(health.Ready, Equals, false)
+	c.Assert(health.Reasons, DeepEquals, []tss.HealthReason{tss.HealthDiscoveryPending})
+	c.Assert(health.Connectivity.HostStarted, Equals, true)
+}
+
+func (TssHttpServerTestSuite) TestAuthorization(c *C) {
+	authorizer, err := auth.NewAuthorizer(auth.Config{
+		APIKeys: []auth.APIKey{
+			{Name: "reader", Key: "reader-key-0123456789", Role: auth.RoleRead},
+			{Name: "admin", Key: "admin-key-0123456789", Role: auth.RoleAdmin},
+		},
+	})
+	c.Assert(err, IsNil)
+	s := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}, WithAuthorizer(authorizer))
+	handler := s.tssNewHandler()
+	serve := func(method, route, key string) int {
+		req := httptest.NewRequest(method, route, bytes.NewBufferString("{}"))
+		if len(key) != 0 {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+	// the probes are public
+	c.Assert(serve(http.MethodGet, "/ping", ""), Equals, http.StatusOK)
+	c.Assert(serve(http.MethodGet, "/peerstats", ""), Equals, http.StatusUnauthorized)
+	c.Assert(serve(http.MethodGet, "/peerstats", "reader-key-0123456789"), Equals, http.StatusOK)
+	c.Assert(serve(http.MethodPost, "/keygen", "reader-key-0123456789"), Equals, http.StatusForbidden)
+	c.Assert(serve(http.MethodPost, "/keygen", "admin-key-0123456789"), Equals, http.StatusOK)
+}
+
+func (TssHttpServerTestSuite) TestGetPeerStatsHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodGet, "/peerstats", nil)
+	res := httptest.NewRecorder()
+	s.getPeerStatsHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var stats map[string]map[string]p2p.ProtocolStats
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &stats), IsNil)
+	c.Assert(stats["peer"]["/p2p/tss/proto"].MessagesOut, Equals, int64(2))
+}
+
+func (TssHttpServerTestSuite) TestVersionHandler(c *C) {
+	handler := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{}).tssNewHandler()
+	res := serveRoute(handler, http.MethodGet, "/version", "")
+	c.Assert(res.Code, Equals, http.StatusOK)
+	c.Assert(res.Header().Get(deprecationHeader), Equals, "")
+	var info tss.VersionInfo
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &info), IsNil)
+	c.Assert(info.Version, Equals, "1.0.0")
+	c.Assert(info.MaxProtocolVersion, Equals, p2p.MaxProtocolVersion)
+	c.Assert(info.Algos, DeepEquals, []conversion.Algo{conversion.AlgoSecp256k1})
+}
+
+func (TssHttpServerTestSuite) TestListKeysHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodGet, "/keys", nil)
+	res := httptest.NewRecorder()
+	s.listKeysHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var keys []tss.KeyInfo
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &keys), IsNil)
+	c.Assert(keys, HasLen, 1)
+	c.Assert(keys[0].Threshold, Equals, 2)
+
+	tssServer.failToKeySign = true
+	res = httptest.NewRecorder()
+	s.listKeysHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusInternalServerError)
+}
+
+func (TssHttpServerTestSuite) TestDerivePubKeyHandler(c *C) {
+	conversion.SetupBech32Prefix()
+	poolPubKey := "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodGet, "/derivepubkey?pool_pub_key="+poolPubKey+"&path=m/0/1", nil)
+	res := httptest.NewRecorder()
+	s.derivePubKeyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var child hd.ChildPubKey
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &child), IsNil)
+	c.Assert(child.PubKey, Not(Equals), poolPubKey)
+	c.Assert(child.ChainCode, HasLen, 64)
+
+	// the hardened children need the whole private key
+	req = httptest.NewRequest(http.MethodGet, "/derivepubkey?pool_pub_key="+poolPubKey+"&path=m/0'/1", nil)
+	res = httptest.NewRecorder()
+	s.derivePubKeyHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+}
+
+func (TssHttpServerTestSuite) TestHealthCheckHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodPost, "/healthcheck", bytes.NewBufferString(`{"pool_pub_key":"pool","block_height":10}`))
+	res := httptest.NewRecorder()
+	s.healthCheckHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var result tss.KeyHealth
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &result), IsNil)
+	c.Assert(result.PoolPubKey, Equals, "pool")
+	c.Assert(result.BlockHeight, Equals, int64(10))
+	c.Assert(result.Success, Equals, true)
+
+	req = httptest.NewRequest(http.MethodPost, "/healthcheck", bytes.NewBufferString(`{`))
+	res = httptest.NewRecorder()
+	s.healthCheckHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+
+	tssServer.failToKeySign = true
+	req = httptest.NewRequest(http.MethodPost, "/healthcheck", bytes.NewBufferString(`{"pool_pub_key":"pool","block_height":10}`))
+	res = httptest.NewRecorder()
+	s.healthCheckHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+}
+
+func (TssHttpServerTestSuite) TestRetireHandler(c *C) {
+	tssServer := &MockTssServer{}
+	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+	c.Assert(s, NotNil)
+	req := httptest.NewRequest(http.MethodPost, "/retire", bytes.NewBufferString(`{"pool_pub_key":"pool"}`))
+	res := httptest.NewRecorder()
+	s.retireHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+	var result tss.KeyRetirement
+	c.Assert(json.Unmarshal(res.Body.Bytes(), &result), IsNil)
+	c.Assert(result.PoolPubKey, Equals, "pool")
+	c.Assert(result.ConfirmationToken, Equals, "token")
+
+	req = httptest.NewRequest(http.MethodPost, "/delete", bytes.NewBufferString(`{"pool_pub_key":"pool","confirmation_token":"whatever"}`))
+	res = httptest.NewRecorder()
+	s.deleteHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+
+	req = httptest.NewRequest(http.MethodPost, "/delete", bytes.NewBufferString(`{"pool_pub_key":"pool","confirmation_token":"token"}`))
+	res = httptest.NewRecorder()
+	s.deleteHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusOK)
+
+	tssServer.failToKeySign = true
+	req = httptest.NewRequest(http.MethodPost, "/retire", bytes.NewBufferString(`{"pool_pub_key":"pool"}`))
+	res = httptest.NewRecorder()
+	s.retireHandler(res, req)
+	c.Assert(res.Code, Equals, http.StatusBadRequest)
+}
+
 func (TssHttpServerTestSuite) TestGetP2pIDHandler(c *C) {
 	tssServer := &MockTssServer{}
 	s := NewTssHttpServer("127.0.0.1:8080", tssServer)
@@ -126,6 +336,74 @@ func (TssHttpServerTestSuite) TestKeygenHandler(c *C) {
 	}
 }
 
+func (TssHttpServerTestSuite) TestReshareHandler(c *C) {
+	normalReshareRequest := `{"pool_pub_key":"thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3","old_party_keys":["thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3", "thorpub1addwnpepqtspqyy6gk22u37ztra4hq3hdakc0w0k60sfy849mlml2vrpfr0wvm6uz09", "thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69"],"new_party_keys":["thorpub1addwnpepqtspqyy6gk22u37ztra4hq3hdakc0w0k60sfy849mlml2vrpfr0wvm6uz09", "thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69", "thorpub1addwnpepqfjcw5l4ay5t00c32mmlky7qrppepxzdlkcwfs2fd5u73qrwna0vzag3y4j"]}`
+	testCases := []struct {
+		name          string
+		reqProvider   func() *http.Request
+		setter        func(s *MockTssServer)
+		resultChecker func(c *C, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "method get should return status method not allowed",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/reshare", nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusMethodNotAllowed)
+			},
+		},
+		{
+			name: "nil request body should return status bad request",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/reshare", nil)
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusBadRequest)
+			},
+		},
+		{
+			name: "fail to reshare should still return the response",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/reshare",
+					bytes.NewBufferString(normalReshareRequest))
+			},
+			setter: func(s *MockTssServer) {
+				s.failToReshare = true
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusOK)
+			},
+		},
+		{
+			name: "normal",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/reshare",
+					bytes.NewBufferString(normalReshareRequest))
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusOK)
+				var resp reshare.Response
+				c.Assert(json.Unmarshal(w.Body.Bytes(), &resp), IsNil)
+				c.Assert(resp.PubKey, Equals, "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		c.Log(tc.name)
+		tssServer := &MockTssServer{}
+		s := NewTssHttpServer("127.0.0.1:8080", tssServer)
+		c.Assert(s, NotNil)
+		if tc.setter != nil {
+			tc.setter(tssServer)
+		}
+		req := tc.reqProvider()
+		res := httptest.NewRecorder()
+		s.reshareHandler(res, req)
+		tc.resultChecker(c, res)
+	}
+}
+
 func (TssHttpServerTestSuite) TestKeysignHandler(c *C) {
 	var normalKeySignRequest string = `{
     "pool_pub_key": "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3",
@@ -174,6 +452,61 @@ func (TssHttpServerTestSuite) TestKeysignHandler(c *C) {
 				c.Assert(w.Code, Equals, http.StatusInternalServerError)
 			},
 		},
+		{
+			name: "invalid signature should return status unprocessable entity",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/keysign",
+					bytes.NewBufferString(normalKeySignRequest))
+			},
+			setter: func(s *MockTssServer) {
+				s.invalidSig = true
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusUnprocessableEntity)
+				var resp errorResponse
+				c.Assert(json.Unmarshal(w.Body.Bytes(), &resp), IsNil)
+				c.Assert(resp.Code, Equals, errcode.InvalidSignature)
+				c.Assert(resp.Retryable, Equals, false)
+			},
+		},
+		{
+			name: "keysign vetoed by the signing policy should return status forbidden",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/keysign",
+					bytes.NewBufferString(normalKeySignRequest))
+			},
+			setter: func(s *MockTssServer) {
+				s.vetoed = true
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusForbidden)
+			},
+		},
+		{
+			name: "full keysign queue should return status service unavailable",
+			reqProvider: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/keysign",
+					bytes.NewBufferString(normalKeySignRequest))
+			},
+			setter: func(s *MockTssServer) {
+				s.busy = true
+			},
+			resultChecker: func(c *C, w *httptest.ResponseRecorder) {
+				c.Assert(w.Code, Equals, http.StatusServiceUnavailable)
+				c.Assert(w.Header().Get("Retry-After"), Equals, "2")
+				var resp struct {
+					Code       errcode.Code `json:"code"`
+					Retryable  bool         `json:"retryable"`
+					RetryAfter int64        `json:"retry_after"`
+					Queued     int          `json:"queued"`
+				}
+				c.Assert(json.Unmarshal(w.Body.Bytes(), &resp), IsNil)
+				c.Assert(resp.Code, Equals, errcode.Busy)
+				c.Assert(resp.Retryable, Equals, true)
+				c.Assert(resp.RetryAfter, Equals, int64(2))
+				c.Assert(resp.Queued, Equals, 3)
+			},
+		},
 		{
 			name: "normal",
 			reqProvider: func() *http.Request {
@@ -202,3 +535,28 @@ func (TssHttpServerTestSuite) TestKeysignHandler(c *C) {
 		tc.resultChecker(c, res)
 	}
 }
+
+func (TssHttpServerTestSuite) TestWriteError(c *C) {
+	s := NewTssHttpServer("127.0.0.1:8080", &MockTssServer{})
+	testCases := []struct {
+		err    error
+		status int
+		code   errcode.Code
+	}{
+		{fmt.Errorf("fail to get the share: %w", tss.ErrShareNotFound), http.StatusNotFound, errcode.ShareNotFound},
+		{tss.ErrShuttingDown, http.StatusServiceUnavailable, errcode.ShuttingDown},
+		{fmt.Errorf("fail to join party: %w", p2p.ErrJoinPartyTimeout), http.StatusGatewayTimeout, errcode.JoinPartyTimeout},
+		{errcode.New(errcode.BadRequest, "not enough signers"), http.StatusBadRequest, errcode.BadRequest},
+		{errors.New("you ask for it"), http.StatusInternalServerError, errcode.Internal},
+	}
+	for _, tc := range testCases {
+		res := httptest.NewRecorder()
+		s.writeError(res, tc.err)
+		c.Assert(res.Code, Equals, tc.status)
+		var resp errorResponse
+		c.Assert(json.Unmarshal(res.Body.Bytes(), &resp), IsNil)
+		c.Assert(resp.Code, Equals, tc.code)
+		c.Assert(resp.Error, Equals, tc.err.Error())
+		c.Assert(resp.Retryable, Equals, tc.code.Retryable())
+	}
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+)
+
+// SetProgressHook set the func that is called each time the local party moves on to a new round, with the round
+// and the number of the tss messages we have received so far, the ceremony journal records them
+func (t *TssCommon) SetProgressHook(hook func(round string, received int)) {
+	t.progressLock.Lock()
+	defer t.progressLock.Unlock()
+	t.progressHook = hook
+}
+
+// reportProgress call the progress hook if the round is a new one, the log lines of the ceremony carry the round
+// from now on
+func (t *TssCommon) reportProgress(round string) {
+	t.logContext.SetRound(round)
+	t.blameMgr.RoundSent(round)
+	t.progressLock.Lock()
+	defer t.progressLock.Unlock()
+	if t.progressHook == nil || round == t.lastRound {
+		return
+	}
+	t.lastRound = round
+	t.progressHook(round, int(atomic.LoadInt64(&t.received)))
+}
+
+// GetAbortChan return the channel that is closed once a peer of the party tells us it restarted in the ceremony,
+// the ceremony can not finish without it
+func (t *TssCommon) GetAbortChan() chan struct{} {
+	return t.abortChan
+}
+
+// processAbort blame the peer that restarted in the ceremony and abort the ceremony
+func (t *TssCommon) processAbort(payload []byte, peerID string) error {
+	var abort messages.TssAbort
+	if err := json.Unmarshal(payload, &abort); err != nil {
+		return fmt.Errorf("fail to unmarshal the abort message: %w", err)
+	}
+	sender, err := peer.Decode(peerID)
+	if err != nil {
+		return fmt.Errorf("fail to decode the peer ID: %w", err)
+	}
+	isParticipant := false
+	t.P2PPeersLock.RLock()
+	for _, el := range t.P2PPeers {
+		if el == sender {
+			isParticipant = true
+			break
+		}
+	}
+	t.P2PPeersLock.RUnlock()
+	if !isParticipant {
+		return fmt.Errorf("abort message from %s who is not in the party", peerID)
+	}
+	pubKey, err := conversion.GetPubKeyFromPeerID(peerID)
+	if err != nil {
+		return fmt.Errorf("fail to get the pub key of %s: %w", peerID, err)
+	}
+	t.logger.Warn().Str("peer", peerID).Str("reason", abort.Reason).Msg("the peer aborts the ceremony")
+	// the node that shuts down gracefully is still blamed, so the retries go without it, but it is not slashed
+	reason := blame.TssAborted
+	if abort.Shutdown {
+		reason = blame.TssShutdown
+	}
+	t.abortOnce.Do(func() {
+		t.blameMgr.GetBlame().SetBlame(reason, []blame.Node{blame.NewNode(pubKey, nil, nil)}, false)
+		close(t.abortChan)
+	})
+	return nil
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"encoding/json"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+)
+
+func (t *TssTestSuite) TestProcessAbort(c *C) {
+	tssCommon := NewTssCommon("", nil, TssConfig{}, "message-id", t.privKey, 1)
+	peerA, err := conversion.GetPeerIDFromPubKey(testPubKeys[0])
+	c.Assert(err, IsNil)
+	peerB, err := conversion.GetPeerIDFromPubKey(testPubKeys[1])
+	c.Assert(err, IsNil)
+	tssCommon.P2PPeers = []peer.ID{peerA}
+	payload, err := json.Marshal(messages.TssAbort{Reason: "the node restarted"})
+	c.Assert(err, IsNil)
+	msg := &messages.WrappedMessage{MessageType: messages.TSSAbortMsg, MsgID: "message-id", Payload: payload}
+
+	// only the peers of the party can abort it
+	c.Assert(tssCommon.ProcessOneMessage(msg, peerB.String()), NotNil)
+	select {
+	case <-tssCommon.GetAbortChan():
+		c.Fatal("the ceremony should not be aborted")
+	default:
+	}
+	c.Assert(tssCommon.ProcessOneMessage(msg, peerA.String()), IsNil)
+	<-tssCommon.GetAbortChan()
+	b := tssCommon.GetBlameMgr().GetBlame()
+	c.Assert(b.FailReason, Equals, blame.TssAborted)
+	c.Assert(b.BlameNodes, HasLen, 1)
+	c.Assert(b.BlameNodes[0].Pubkey, Equals, testPubKeys[0])
+	// it is only closed once
+	c.Assert(tssCommon.ProcessOneMessage(msg, peerA.String()), IsNil)
+
+	// the node that shuts down is blamed with its own reason
+	tssCommon = NewTssCommon("", nil, TssConfig{}, "message-id", t.privKey, 1)
+	tssCommon.P2PPeers = []peer.ID{peerA}
+	payload, err = json.Marshal(messages.TssAbort{Reason: "the node is shutting down", Shutdown: true})
+	c.Assert(err, IsNil)
+	msg.Payload = payload
+	c.Assert(tssCommon.ProcessOneMessage(msg, peerA.String()), IsNil)
+	<-tssCommon.GetAbortChan()
+	c.Assert(tssCommon.GetBlameMgr().GetBlame().FailReason, Equals, blame.TssShutdown)
+}
+
+func (t *TssTestSuite) TestReportProgress(c *C) {
+	tssCommon := NewTssCommon("", nil, TssConfig{}, "message-id", t.privKey, 1)
+	tssCommon.reportProgress(messages.KEYGEN1)
+	var rounds []string
+	tssCommon.SetProgressHook(func(round string, received int) {
+		rounds = append(rounds, round)
+	})
+	tssCommon.reportProgress(messages.KEYGEN1)
+	tssCommon.reportProgress(messages.KEYGEN1)
+	tssCommon.reportProgress(messages.KEYGEN2aUnicast)
+	c.Assert(rounds, DeepEquals, []string{messages.KEYGEN1, messages.KEYGEN2aUnicast})
+}
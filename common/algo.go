@@ -0,0 +1,57 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/binance-chain/tss-lib/ecdsa/signing"
+	btss "github.com/binance-chain/tss-lib/tss"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// AlgoParties are the constructors of the local tss parties of an algo
+type AlgoParties struct {
+	// Keygen creates the local keygen party, it sends the save data of the new key to endCh
+	Keygen func(params *btss.Parameters, outCh chan<- btss.Message, endCh chan<- keygen.LocalPartySaveData, preParams keygen.LocalPreParams) btss.Party
+	// Keysign creates the local keysign party of the message, it sends the signature to endCh
+	Keysign func(msg *big.Int, params *btss.Parameters, key keygen.LocalPartySaveData, outCh chan<- btss.Message, endCh chan<- *signing.SignatureData) btss.Party
+}
+
+// algoRegistry maps the algos this node can hold the keys of to their parties. The eddsa parties of tss-lib are not
+// registered, their protobuf messages have the same names as the ecdsa ones, so the two can not be linked into one
+// binary
+var algoRegistry = map[conversion.Algo]AlgoParties{
+	conversion.AlgoSecp256k1: {
+		Keygen: func(params *btss.Parameters, outCh chan<- btss.Message, endCh chan<- keygen.LocalPartySaveData, preParams keygen.LocalPreParams) btss.Party {
+			return keygen.NewLocalParty(params, outCh, endCh, preParams)
+		},
+		Keysign: signing.NewLocalParty,
+	},
+}
+
+// GetAlgoParties return the party constructors of the algo, the empty algo is secp256k1
+func GetAlgoParties(algo conversion.Algo) (AlgoParties, error) {
+	if err := algo.Validate(); err != nil {
+		return AlgoParties{}, err
+	}
+	parties, ok := algoRegistry[algo.OrDefault()]
+	if !ok {
+		return AlgoParties{}, fmt.Errorf("algo %s is not supported by this node", algo)
+	}
+	return parties, nil
+}
+
+// SupportedAlgos return the algos this node can hold the keys of
+func SupportedAlgos() []conversion.Algo {
+	algos := make([]conversion.Algo, 0, len(algoRegistry))
+	for algo := range algoRegistry {
+		algos = append(algos, algo)
+	}
+	sort.Slice(algos, func(i, j int) bool {
+		return algos[i] < algos[j]
+	})
+	return algos
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type AlgoTestSuite struct{}
+
+var _ = Suite(&AlgoTestSuite{})
+
+func (s *AlgoTestSuite) TestGetAlgoParties(c *C) {
+	parties, err := GetAlgoParties("")
+	c.Assert(err, IsNil)
+	c.Assert(parties.Keygen, NotNil)
+	c.Assert(parties.Keysign, NotNil)
+	_, err = GetAlgoParties(conversion.AlgoSecp256k1)
+	c.Assert(err, IsNil)
+	// ed25519 is a known algo, but this node can not hold its keys
+	_, err = GetAlgoParties(conversion.AlgoEd25519)
+	c.Assert(err, ErrorMatches, "algo ed25519 is not supported by this node")
+	_, err = GetAlgoParties("rsa")
+	c.Assert(err, ErrorMatches, "unknown algo rsa")
+	c.Assert(SupportedAlgos(), DeepEquals, []conversion.Algo{conversion.AlgoSecp256k1})
+}
@@ -0,0 +1,173 @@
+package common
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// logOutput is the writer of the global logger, InitLog set it, the ceremony loggers write to it as well
+var logOutput io.Writer = os.Stderr
+
+// CeremonyLog keep the last log lines of a ceremony, they are attached to the response of the failed ceremony so
+// the caller can tell why it failed without the logs of the node
+type CeremonyLog struct {
+	lock    *sync.Mutex
+	size    int
+	lines   []string
+	next    int
+	dropped int
+}
+
+// NewCeremonyLog create the capture of the last size log lines of a ceremony, it return nil if size is not
+// positive, a nil capture keeps nothing
+func NewCeremonyLog(size int) *CeremonyLog {
+	if size <= 0 {
+		return nil
+	}
+	return &CeremonyLog{
+		lock: &sync.Mutex{},
+		size: size,
+	}
+}
+
+// Write keep the log line, the oldest line is dropped once the capture is full
+func (c *CeremonyLog) Write(p []byte) (int, error) {
+	if c == nil {
+		return len(p), nil
+	}
+	line := strings.TrimRight(string(p), "\n")
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.lines) < c.size {
+		c.lines = append(c.lines, line)
+		return len(p), nil
+	}
+	c.lines[c.next] = line
+	c.next = (c.next + 1) % c.size
+	c.dropped++
+	return len(p), nil
+}
+
+// Lines return the log lines we keep, the oldest first
+func (c *CeremonyLog) Lines() []string {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	lines := make([]string, 0, len(c.lines))
+	lines = append(lines, c.lines[c.next:]...)
+	return append(lines, c.lines[:c.next]...)
+}
+
+// Dropped return the number of the oldest log lines that did not fit in the capture
+func (c *CeremonyLog) Dropped() int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.dropped
+}
+
+type ceremonyLogKey struct{}
+
+// WithCeremonyLog return the context that carries the log capture of the ceremony
+func WithCeremonyLog(ctx context.Context, capture *CeremonyLog) context.Context {
+	return context.WithValue(ctx, ceremonyLogKey{}, capture)
+}
+
+// CeremonyLogFromContext return the log capture the context carries, nil if it carries none
+func CeremonyLogFromContext(ctx context.Context) *CeremonyLog {
+	capture, _ := ctx.Value(ceremonyLogKey{}).(*CeremonyLog)
+	return capture
+}
+
+// LogContext is the context of the log lines of a ceremony, every line carries the msg id, the peers of the party
+// once it is formed and the round the local party is in, and it is kept in the capture of the ceremony if it has
+// one
+type LogContext struct {
+	lock    *sync.RWMutex
+	msgID   string
+	peers   []string
+	round   string
+	capture *CeremonyLog
+}
+
+// NewLogContext create the log context of the ceremony of the msg id
+func NewLogContext(msgID string) *LogContext {
+	return &LogContext{
+		lock:  &sync.RWMutex{},
+		msgID: msgID,
+	}
+}
+
+// Logger return the logger of the module in the ceremony
+func (l *LogContext) Logger(module string) zerolog.Logger {
+	return l.logger().With().Str("module", module).Logger()
+}
+
+// WithContext return the context that carries the logger of the ceremony, the p2p layer logs the join party with it
+func (l *LogContext) WithContext(ctx context.Context) context.Context {
+	logger := l.logger()
+	return logger.WithContext(ctx)
+}
+
+func (l *LogContext) logger() zerolog.Logger {
+	return log.Output(l).With().Str("msgID", l.msgID).Logger().Hook(l)
+}
+
+// SetPeers set the peers of the party, they are added to the log lines from now on
+func (l *LogContext) SetPeers(peers []peer.ID) {
+	ids := make([]string, len(peers))
+	for i, el := range peers {
+		ids[i] = el.String()
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.peers = ids
+}
+
+// SetRound set the round the local party is in, it is added to the log lines from now on
+func (l *LogContext) SetRound(round string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.round = round
+}
+
+// SetCapture set the capture the log lines of the ceremony are kept in
+func (l *LogContext) SetCapture(capture *CeremonyLog) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.capture = capture
+}
+
+// Run add the peers and the round to the log line, it is the zerolog hook of the loggers of the ceremony
+func (l *LogContext) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if len(l.peers) > 0 {
+		e.Strs("peers", l.peers)
+	}
+	if len(l.round) > 0 {
+		e.Str("round", l.round)
+	}
+}
+
+// Write write the log line to the output of the global logger and keep it in the capture of the ceremony
+func (l *LogContext) Write(p []byte) (int, error) {
+	l.lock.RLock()
+	capture := l.capture
+	l.lock.RUnlock()
+	if _, err := capture.Write(p); err != nil {
+		return 0, err
+	}
+	return logOutput.Write(p)
+}
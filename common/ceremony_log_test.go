@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+	. "gopkg.in/check.v1"
+)
+
+type CeremonyLogTestSuite struct{}
+
+var _ = Suite(&CeremonyLogTestSuite{})
+
+func (s *CeremonyLogTestSuite) TestCeremonyLog(c *C) {
+	c.Assert(NewCeremonyLog(0), IsNil)
+	var nilCapture *CeremonyLog
+	_, err := nilCapture.Write([]byte("line\n"))
+	c.Assert(err, IsNil)
+	c.Assert(nilCapture.Lines(), IsNil)
+
+	capture := NewCeremonyLog(3)
+	for _, el := range []string{"a\n", "b\n", "c\n"} {
+		_, err := capture.Write([]byte(el))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(capture.Lines(), DeepEquals, []string{"a", "b", "c"})
+	c.Assert(capture.Dropped(), Equals, 0)
+	// the oldest lines are dropped once the capture is full
+	for _, el := range []string{"d\n", "e\n"} {
+		_, err := capture.Write([]byte(el))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(capture.Lines(), DeepEquals, []string{"c", "d", "e"})
+	c.Assert(capture.Dropped(), Equals, 2)
+
+	ctx := WithCeremonyLog(context.Background(), capture)
+	c.Assert(CeremonyLogFromContext(ctx), Equals, capture)
+	c.Assert(CeremonyLogFromContext(context.Background()), IsNil)
+}
+
+func (s *CeremonyLogTestSuite) TestLogContext(c *C) {
+	output := logOutput
+	logOutput = ioutil.Discard
+	defer func() {
+		logOutput = output
+	}()
+	testPeer, err := peer.Decode("16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa")
+	c.Assert(err, IsNil)
+
+	logContext := NewLogContext("message-id")
+	logger := logContext.Logger("keygen")
+	// the lines before the capture is set are not kept
+	logger.Info().Msg("not kept")
+	capture := NewCeremonyLog(10)
+	logContext.SetCapture(capture)
+	logger.Info().Msg("before the party")
+	logContext.SetPeers([]peer.ID{testPeer})
+	logContext.SetRound("KGRound1Message")
+	logger.Info().Msg("in the party")
+	ctxLogger := zerolog.Ctx(logContext.WithContext(context.Background()))
+	ctxLogger.Info().Msg("join party")
+
+	lines := capture.Lines()
+	c.Assert(lines, HasLen, 3)
+	var entries []map[string]interface{}
+	for _, el := range lines {
+		var entry map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(el), &entry), IsNil)
+		c.Assert(entry["msgID"], Equals, "message-id")
+		entries = append(entries, entry)
+	}
+	c.Assert(entries[0]["module"], Equals, "keygen")
+	c.Assert(entries[0]["peers"], IsNil)
+	c.Assert(entries[0]["round"], IsNil)
+	c.Assert(entries[1]["peers"], DeepEquals, []interface{}{testPeer.String()})
+	c.Assert(entries[1]["round"], Equals, "KGRound1Message")
+	c.Assert(entries[2]["message"], Equals, "join party")
+	c.Assert(entries[2]["round"], Equals, "KGRound1Message")
+}
+
+func (t *TssTestSuite) TestSetP2PPeers(c *C) {
+	testPeer, err := peer.Decode("16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa")
+	c.Assert(err, IsNil)
+	tssCommon := NewTssCommon("", nil, TssConfig{}, "message-id", t.privKey, 1)
+	tssCommon.SetP2PPeers([]peer.ID{testPeer})
+	c.Assert(tssCommon.P2PPeers, DeepEquals, []peer.ID{testPeer})
+	c.Assert(tssCommon.GetLogContext().peers, DeepEquals, []string{testPeer.String()})
+	tssCommon.reportProgress("KGRound1Message")
+	c.Assert(tssCommon.GetLogContext().round, Equals, "KGRound1Message")
+}
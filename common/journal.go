@@ -0,0 +1,113 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+// journalEntry is the tss message we have sent to our peers, we keep it so that we can replay
+// it to the peers who join the party late
+type journalEntry struct {
+	msg     messages.WrappedMessage
+	encoded []byte
+	peers   []peer.ID
+}
+
+// withinGraceWindow return true if the party was started within the late join grace window
+func (t *TssCommon) withinGraceWindow() bool {
+	if t.conf.LateJoinGraceWindow <= 0 {
+		return false
+	}
+	t.journalLock.Lock()
+	defer t.journalLock.Unlock()
+	if t.partyStartTime.IsZero() {
+		return false
+	}
+	return time.Since(t.partyStartTime) <= t.conf.LateJoinGraceWindow
+}
+
+// recordJournal keep a copy of the message we sent, we only record the messages within the grace window
+// as only those messages can be replayed to the late joiners
+func (t *TssCommon) recordJournal(msg messages.WrappedMessage, encoded []byte, peers []peer.ID) {
+	if !t.withinGraceWindow() {
+		return
+	}
+	t.journalLock.Lock()
+	defer t.journalLock.Unlock()
+	t.journal = append(t.journal, &journalEntry{
+		msg:     msg,
+		encoded: encoded,
+		peers:   peers,
+	})
+}
+
+// RequestCatchUp ask all the peers to replay the tss messages they have already sent to us,
+// this allows a node that joins the party a few seconds late to catch up with the others
+func (t *TssCommon) RequestCatchUp() {
+	if t.conf.LateJoinGraceWindow <= 0 {
+		return
+	}
+	t.P2PPeersLock.RLock()
+	peerIDs := t.P2PPeers
+	t.P2PPeersLock.RUnlock()
+	if len(peerIDs) == 0 {
+		return
+	}
+	t.renderToP2P(&messages.BroadcastMsgChan{
+		WrappedMessage: messages.WrappedMessage{
+			MessageType: messages.TSSCatchUpMsg,
+			MsgID:       t.msgID,
+		},
+		PeersID: peerIDs,
+	})
+}
+
+// replayJournal send the journaled messages that were addressed to the given peer back to it
+func (t *TssCommon) replayJournal(peerID string) error {
+	if !t.withinGraceWindow() {
+		t.logger.Debug().Msgf("catch up request from %s is out of the grace window", peerID)
+		return nil
+	}
+	requester, err := peer.Decode(peerID)
+	if err != nil {
+		return fmt.Errorf("fail to decode the peer ID: %w", err)
+	}
+	isParticipant := false
+	t.P2PPeersLock.RLock()
+	for _, el := range t.P2PPeers {
+		if el == requester {
+			isParticipant = true
+			break
+		}
+	}
+	t.P2PPeersLock.RUnlock()
+	if !isParticipant {
+		return fmt.Errorf("catch up request from %s who is not in the party", peerID)
+	}
+
+	t.journalLock.Lock()
+	var toReplay []*journalEntry
+	for _, entry := range t.journal {
+		for _, el := range entry.peers {
+			if el == requester {
+				toReplay = append(toReplay, entry)
+				break
+			}
+		}
+	}
+	t.journalLock.Unlock()
+
+	t.logger.Debug().Msgf("replay %d messages to the late joiner %s", len(toReplay), peerID)
+	for _, entry := range toReplay {
+		t.renderToP2P(&messages.BroadcastMsgChan{
+			WrappedMessage: entry.msg,
+			PeersID:        []peer.ID{requester},
+			Encoded:        entry.encoded,
+		})
+	}
+	return nil
+}
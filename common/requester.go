@@ -0,0 +1,19 @@
+package common
+
+import (
+	"context"
+)
+
+type requesterKey struct{}
+
+// WithRequester return the context that carries the name of the caller of the api, so the audit log can tell who
+// asked for the ceremony
+func WithRequester(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, name)
+}
+
+// RequesterFromContext return the name of the caller the context carries, empty if it carries none
+func RequesterFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(requesterKey{}).(string)
+	return name
+}
@@ -0,0 +1,43 @@
+package common
+
+// cacheEarlyJob buffer the job if its local party has not started yet, the message would be stored by the party,
+// but no round would pick it up until another message arrives, so it is replayed once the party starts. It return
+// false if the job should be applied now, that is when the party is running, the cache is disabled or full
+func (t *TssCommon) cacheEarlyJob(job *tssJob) bool {
+	if t.conf.RoundCacheSize <= 0 {
+		return false
+	}
+	t.roundCacheLock.Lock()
+	defer t.roundCacheLock.Unlock()
+	if job.localParty.Running() {
+		return false
+	}
+	if len(t.roundCache) >= t.conf.RoundCacheSize {
+		t.logger.Warn().Msgf("the round cache is full, apply the message of party %s now", job.partyID.Id)
+		return false
+	}
+	t.roundCache = append(t.roundCache, job)
+	return true
+}
+
+// ReplayCachedMessages apply the messages we have buffered for the local parties that have started, the
+// ceremonies call it once they start a local party
+func (t *TssCommon) ReplayCachedMessages() {
+	t.roundCacheLock.Lock()
+	var ready, waiting []*tssJob
+	for _, el := range t.roundCache {
+		if el.localParty.Running() {
+			ready = append(ready, el)
+		} else {
+			waiting = append(waiting, el)
+		}
+	}
+	t.roundCache = waiting
+	t.roundCacheLock.Unlock()
+	if len(ready) > 0 {
+		t.logger.Debug().Msgf("replay %d messages that arrived before the party started", len(ready))
+	}
+	for _, el := range ready {
+		t.applyJob(el)
+	}
+}
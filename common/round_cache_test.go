@@ -0,0 +1,60 @@
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	btsskeygen "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	btss "github.com/binance-chain/tss-lib/tss"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type RoundCacheTestSuite struct{}
+
+var _ = Suite(&RoundCacheTestSuite{})
+
+func (s *RoundCacheTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func newTestKeygenParty(c *C) (btss.Party, []*btss.PartyID) {
+	buf, err := ioutil.ReadFile("../test_data/preParam_test.data")
+	c.Assert(err, IsNil)
+	preParamsBuf, err := hex.DecodeString(strings.Split(string(buf), "\n")[0])
+	c.Assert(err, IsNil)
+	var preParams btsskeygen.LocalPreParams
+	c.Assert(json.Unmarshal(preParamsBuf, &preParams), IsNil)
+
+	partiesID, localPartyID, err := conversion.GetParties(testPubKeys[:], testPubKeys[0])
+	c.Assert(err, IsNil)
+	params := btss.NewParameters(btss.NewPeerContext(partiesID), localPartyID, len(partiesID), 2)
+	outCh := make(chan btss.Message, len(partiesID))
+	endCh := make(chan btsskeygen.LocalPartySaveData, len(partiesID))
+	return btsskeygen.NewLocalParty(params, outCh, endCh, preParams), partiesID
+}
+
+func (s *RoundCacheTestSuite) TestRoundCache(c *C) {
+	party, partiesID := newTestKeygenParty(c)
+	job := newJob(party, []byte("early"), "tester", partiesID[1], true)
+
+	// the cache is disabled
+	tssCommon := NewTssCommon("", nil, TssConfig{}, "test", nil, 1)
+	c.Assert(tssCommon.cacheEarlyJob(job), Equals, false)
+
+	tssCommon = NewTssCommon("", nil, TssConfig{RoundCacheSize: 1}, "test", nil, 1)
+	c.Assert(tssCommon.cacheEarlyJob(job), Equals, true)
+	// the cache is full
+	c.Assert(tssCommon.cacheEarlyJob(newJob(party, []byte("early"), "tester", partiesID[2], true)), Equals, false)
+	// the party has not started, so we keep the message
+	tssCommon.ReplayCachedMessages()
+	c.Assert(tssCommon.roundCache, HasLen, 1)
+
+	c.Assert(party.Start(), IsNil)
+	c.Assert(tssCommon.cacheEarlyJob(job), Equals, false)
+	tssCommon.ReplayCachedMessages()
+	c.Assert(tssCommon.roundCache, HasLen, 0)
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecurityPosture describes the security options the node starts with. The tss messages are always signed by the
+// sender and verified by the receivers, there is no option to turn it off, so it is not part of the posture
+type SecurityPosture struct {
+	// EncryptedKeyshares is true if the keyshares are encrypted at rest
+	EncryptedKeyshares bool
+	// AuthenticatedAPI is true if the api requires the callers to authenticate
+	AuthenticatedAPI bool
+	// PeerAllowlist is true if we only connect to the peers in the allowlist
+	PeerAllowlist bool
+	// TrustedDealer is true if the node takes part in the trusted dealer key import
+	TrustedDealer bool
+}
+
+// SecurityCheck is an item of the strict mode checklist
+type SecurityCheck struct {
+	Name   string
+	Passed bool
+	// Remedy tells the operator how to pass the check
+	Remedy string
+}
+
+// Checks return the strict mode checklist of the posture
+func (sp SecurityPosture) Checks() []SecurityCheck {
+	return []SecurityCheck{
+		{
+			Name:   "keyshares are encrypted at rest",
+			Passed: sp.EncryptedKeyshares,
+			Remedy: "the keyshares are stored in plaintext, enable -encrypt-keyshares or use the vault or the kms backend",
+		},
+		{
+			Name:   "api requires authentication",
+			Passed: sp.AuthenticatedAPI,
+			Remedy: "the api is served without authentication, enable the api authentication",
+		},
+		{
+			Name:   "peers are restricted to an allowlist",
+			Passed: sp.PeerAllowlist,
+			Remedy: "no peer allowlist is set, add the committee members with -allowed-peer",
+		},
+		{
+			Name:   "trusted dealer key import is disabled",
+			Passed: !sp.TrustedDealer,
+			Remedy: "the trusted dealer knows the whole private key, disable AllowTrustedDealer",
+		},
+	}
+}
+
+// StrictModeError is returned when the node refuses to start in strict mode, it reports the whole checklist
+type StrictModeError struct {
+	Checks []SecurityCheck
+}
+
+// Failed return the checks that do not pass
+func (e *StrictModeError) Failed() []SecurityCheck {
+	var failed []SecurityCheck
+	for _, el := range e.Checks {
+		if !el.Passed {
+			failed = append(failed, el)
+		}
+	}
+	return failed
+}
+
+// Error implement the error interface
+func (e *StrictModeError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "strict mode refuses to start, %d of %d security checks failed:", len(e.Failed()), len(e.Checks))
+	for _, el := range e.Checks {
+		if el.Passed {
+			fmt.Fprintf(&sb, "\n  [x] %s", el.Name)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n  [ ] %s: %s", el.Name, el.Remedy)
+	}
+	return sb.String()
+}
+
+// CheckStrictMode return a StrictModeError if the posture does not pass all the security checks
+func CheckStrictMode(sp SecurityPosture) error {
+	e := &StrictModeError{Checks: sp.Checks()}
+	if len(e.Failed()) > 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"errors"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type StrictModeTestSuite struct{}
+
+var _ = Suite(&StrictModeTestSuite{})
+
+func (StrictModeTestSuite) TestCheckStrictMode(c *C) {
+	c.Assert(CheckStrictMode(SecurityPosture{
+		EncryptedKeyshares: true,
+		AuthenticatedAPI:   true,
+		PeerAllowlist:      true,
+	}), IsNil)
+
+	err := CheckStrictMode(SecurityPosture{
+		AuthenticatedAPI: true,
+		TrustedDealer:    true,
+	})
+	c.Assert(err, NotNil)
+	var strictErr *StrictModeError
+	c.Assert(errors.As(err, &strictErr), Equals, true)
+	c.Assert(strictErr.Checks, HasLen, 4)
+	c.Assert(strictErr.Failed(), HasLen, 3)
+	// the report lists the whole checklist
+	c.Assert(strings.HasPrefix(err.Error(), "strict mode refuses to start, 3 of 4 security checks failed"), Equals, true)
+	c.Assert(strings.Contains(err.Error(), "[x] api requires authentication"), Equals, true)
+	c.Assert(strings.Contains(err.Error(), "[ ] keyshares are encrypted at rest:"), Equals, true)
+	c.Assert(strings.Contains(err.Error(), "[ ] trusted dealer key import is disabled:"), Equals, true)
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultTenant is the committee the requests that name no tenant go to, it is the one of the flags of the node
+const DefaultTenant = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidateTenantID check the id of the tenant is 1 to 64 lowercase letters, digits, - or _
+func ValidateTenantID(id string) error {
+	if !tenantIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid tenant id %q, it should be 1 to 64 lowercase letters, digits, - or _", id)
+	}
+	return nil
+}
+
+// TenantOrDefault return the tenant, DefaultTenant if it is empty
+func TenantOrDefault(id string) string {
+	if len(id) == 0 {
+		return DefaultTenant
+	}
+	return id
+}
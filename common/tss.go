@@ -4,14 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	btss "github.com/binance-chain/tss-lib/tss"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 	tcrypto "github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/secp256k1"
 
@@ -25,11 +27,46 @@ import (
 type PartyInfo struct {
 	PartyMap   *sync.Map
 	PartyIDMap map[string]*btss.PartyID
+	// ShareMap keeps the local parties of the other shares of a weighted key by the msg identifier, they run along
+	// with the party of the PartyMap, it is nil if we hold one share
+	ShareMap *sync.Map
+}
+
+// localParties return the local parties the message is for, the shares of a weighted key do not take the messages
+// they sent themselves
+func (p *PartyInfo) localParties(msgIdentifier string, party btss.Party, routing *btss.MessageRouting) []btss.Party {
+	if p.ShareMap == nil {
+		return []btss.Party{party}
+	}
+	data, ok := p.ShareMap.Load(msgIdentifier)
+	if !ok {
+		return []btss.Party{party}
+	}
+	var parties []btss.Party
+	for _, el := range append([]btss.Party{party}, data.([]btss.Party)...) {
+		if el.PartyID().Id == routing.From.Id {
+			continue
+		}
+		if routing.IsBroadcast || partyIDInList(el.PartyID(), routing.To) {
+			parties = append(parties, el)
+		}
+	}
+	return parties
+}
+
+func partyIDInList(partyID *btss.PartyID, l []*btss.PartyID) bool {
+	for _, el := range l {
+		if el.Id == partyID.Id {
+			return true
+		}
+	}
+	return false
 }
 
 type TssCommon struct {
 	conf                        TssConfig
 	logger                      zerolog.Logger
+	logContext                  *LogContext
 	partyLock                   *sync.Mutex
 	partyInfo                   *PartyInfo
 	PartyIDtoP2PID              map[string]peer.ID
@@ -38,6 +75,8 @@ type TssCommon struct {
 	localPeerID                 string
 	broadcastChannel            chan *messages.BroadcastMsgChan
 	TssMsg                      chan *p2p.Message
+	msgChannelsLock             *sync.Mutex
+	msgChannels                 map[messages.THORChainTSSMessageType]chan *p2p.Message
 	P2PPeersLock                *sync.RWMutex
 	P2PPeers                    []peer.ID // most of tss message are broadcast, we store the peers ID to avoid iterating
 	msgID                       string
@@ -50,12 +89,27 @@ type TssCommon struct {
 	cachedWireBroadcastMsgLists *sync.Map
 	cachedWireUnicastMsgLists   *sync.Map
 	msgNum                      int
+	journalLock                 *sync.Mutex
+	journal                     []*journalEntry
+	partyStartTime              time.Time
+	roundCacheLock              *sync.Mutex
+	roundCache                  []*tssJob
+	shareMsgLock                *sync.Mutex
+	shareMsgs                   map[string]bool
+	progressLock                *sync.Mutex
+	progressHook                func(round string, received int)
+	lastRound                   string
+	received                    int64
+	abortChan                   chan struct{}
+	abortOnce                   *sync.Once
 }
 
 func NewTssCommon(peerID string, broadcastChannel chan *messages.BroadcastMsgChan, conf TssConfig, msgID string, privKey tcrypto.PrivKey, msgNum int) *TssCommon {
+	logContext := NewLogContext(msgID)
 	return &TssCommon{
 		conf:                        conf,
-		logger:                      log.With().Str("module", "tsscommon").Logger(),
+		logger:                      logContext.Logger("tsscommon"),
+		logContext:                  logContext,
 		partyLock:                   &sync.Mutex{},
 		partyInfo:                   nil,
 		PartyIDtoP2PID:              make(map[string]peer.ID),
@@ -63,6 +117,8 @@ func NewTssCommon(peerID string, broadcastChannel chan *messages.BroadcastMsgCha
 		unConfirmedMessages:         make(map[string]*LocalCacheItem),
 		broadcastChannel:            broadcastChannel,
 		TssMsg:                      make(chan *p2p.Message, msgNum),
+		msgChannelsLock:             &sync.Mutex{},
+		msgChannels:                 make(map[messages.THORChainTSSMessageType]chan *p2p.Message),
 		P2PPeersLock:                &sync.RWMutex{},
 		P2PPeers:                    nil,
 		msgID:                       msgID,
@@ -75,6 +131,13 @@ func NewTssCommon(peerID string, broadcastChannel chan *messages.BroadcastMsgCha
 		cachedWireBroadcastMsgLists: &sync.Map{},
 		cachedWireUnicastMsgLists:   &sync.Map{},
 		msgNum:                      msgNum,
+		journalLock:                 &sync.Mutex{},
+		roundCacheLock:              &sync.Mutex{},
+		shareMsgLock:                &sync.Mutex{},
+		shareMsgs:                   make(map[string]bool),
+		progressLock:                &sync.Mutex{},
+		abortChan:                   make(chan struct{}),
+		abortOnce:                   &sync.Once{},
 	}
 }
 
@@ -99,6 +162,8 @@ type tssJob struct {
 	isBroadcast    bool
 	localParty     btss.Party
 	acceptedShares map[blame.RoundInfo][]string
+	// untracked is set for the messages the blame manager does not track, it tracks one share of a party per round
+	untracked bool
 }
 
 func newJob(party btss.Party, wireBytes []byte, msgIdentifier string, from *btss.PartyID, isBroadcast bool) *tssJob {
@@ -117,25 +182,34 @@ func (t *TssCommon) doTssJob(tssJobChan chan *tssJob, jobWg *sync.WaitGroup) {
 	}()
 
 	for tssjob := range tssJobChan {
-		party := tssjob.localParty
-		wireBytes := tssjob.wireBytes
-		partyID := tssjob.partyID
-		isBroadcast := tssjob.isBroadcast
-
-		round, err := GetMsgRound(wireBytes, partyID, isBroadcast)
-		if err != nil {
-			t.logger.Error().Err(err).Msg("broken tss share")
+		if t.cacheEarlyJob(tssjob) {
 			continue
 		}
-		round.MsgIdentifier = tssjob.msgIdentifier
+		t.applyJob(tssjob)
+	}
+}
 
-		_, errUp := party.UpdateFromBytes(wireBytes, partyID, isBroadcast)
-		if errUp != nil {
-			err := t.processInvalidMsgBlame(round.RoundMsg, round, errUp)
-			t.logger.Error().Err(err).Msgf("fail to apply the share to tss")
-			continue
-		}
-		// we need to retrieve the partylist again as others may update it once we process apply tss share
+func (t *TssCommon) applyJob(tssjob *tssJob) {
+	party := tssjob.localParty
+	wireBytes := tssjob.wireBytes
+	partyID := tssjob.partyID
+	isBroadcast := tssjob.isBroadcast
+
+	round, err := GetMsgRound(wireBytes, partyID, isBroadcast)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("broken tss share")
+		return
+	}
+	round.MsgIdentifier = tssjob.msgIdentifier
+
+	_, errUp := party.UpdateFromBytes(wireBytes, partyID, isBroadcast)
+	if errUp != nil {
+		err := t.processInvalidMsgBlame(round.RoundMsg, round, errUp)
+		t.logger.Error().Err(err).Msgf("fail to apply the share to tss")
+		return
+	}
+	// we need to retrieve the partylist again as others may update it once we process apply tss share
+	if !tssjob.untracked {
 		t.blameMgr.UpdateAcceptShare(round, partyID.Id)
 	}
 }
@@ -165,6 +239,23 @@ func (t *TssCommon) SetPartyInfo(partyInfo *PartyInfo) {
 	t.partyLock.Lock()
 	defer t.partyLock.Unlock()
 	t.partyInfo = partyInfo
+	// the party starts once we have the party info, the grace window for the late joiners starts from here
+	t.journalLock.Lock()
+	t.partyStartTime = time.Now()
+	t.journalLock.Unlock()
+}
+
+// GetLogContext return the log context of the ceremony, the loggers of the ceremony are derived from it
+func (t *TssCommon) GetLogContext() *LogContext {
+	return t.logContext
+}
+
+// SetP2PPeers set the peers of the party other than us, the log lines of the ceremony carry them from now on
+func (t *TssCommon) SetP2PPeers(peers []peer.ID) {
+	t.P2PPeersLock.Lock()
+	t.P2PPeers = peers
+	t.P2PPeersLock.Unlock()
+	t.logContext.SetPeers(peers)
 }
 
 func (t *TssCommon) getPartyInfo() *PartyInfo {
@@ -218,6 +309,11 @@ func (t *TssCommon) processInvalidMsgBlame(roundInfo string, round blame.RoundIn
 		blameNodes = append(blameNodes, blame.NewNode(pk, msgBody, sig))
 	}
 	t.blameMgr.GetBlame().SetBlame(blame.TssBrokenMsg, blameNodes, unicast)
+	cause := err.Error()
+	if err.Cause() != nil {
+		cause = err.Cause().Error()
+	}
+	t.blameMgr.SetAbort(blame.NewAbort(roundInfo, cause, t.msgID, blameNodes))
 	return fmt.Errorf("fail to set bytes to local party: %w", err)
 }
 
@@ -283,11 +379,21 @@ func (t *TssCommon) updateLocal(wireMsg *messages.WireMessage) error {
 			return err
 		}
 
+		targets := partyInfo.localParties(msg.MsgIdentifier, localMsgParty, msg.Routing)
+		if len(targets) == 0 {
+			continue
+		}
 		// we only allow a message be updated only once.
 		// here we use round + msgIdentifier as the key for the acceptedShares
 		round.MsgIdentifier = msg.MsgIdentifier
-		// if this share is duplicated, we skip this share
-		if t.blameMgr.CheckMsgDuplication(round, partyID.Id) {
+		// the blame manager tracks one share of a party per round, so the unicast messages to the other local
+		// shares of a weighted key are checked on their own
+		tracked := msg.Routing.IsBroadcast || targets[0] == localMsgParty
+		if tracked && t.blameMgr.CheckMsgDuplication(round, partyID.Id) {
+			t.logger.Debug().Msgf("we received the duplicated message from party %s", partyID.Id)
+			continue
+		}
+		if !tracked && t.checkShareMsgDuplication(round, partyID.Id, targets[0].PartyID().Id) {
 			t.logger.Debug().Msgf("we received the duplicated message from party %s", partyID.Id)
 			continue
 		}
@@ -307,14 +413,30 @@ func (t *TssCommon) updateLocal(wireMsg *messages.WireMessage) error {
 			return errors.New(blame.TssBrokenMsg)
 		}
 		t.culpritsLock.RUnlock()
-		job := newJob(localMsgParty, msg.WiredBulkMsgs, round.MsgIdentifier, partyID, msg.Routing.IsBroadcast)
-		tssJobChan <- job
+		for i, party := range targets {
+			job := newJob(party, msg.WiredBulkMsgs, round.MsgIdentifier, partyID, msg.Routing.IsBroadcast)
+			job.untracked = !tracked || i > 0
+			tssJobChan <- job
+		}
 	}
 	close(tssJobChan)
 	jobWg.Wait()
 	return nil
 }
 
+// checkShareMsgDuplication return true if the party has sent the message of the round to the local share before,
+// otherwise it records the message
+func (t *TssCommon) checkShareMsgDuplication(round blame.RoundInfo, from, to string) bool {
+	key := fmt.Sprintf("%s-%s-%s-%s", round.RoundMsg, round.MsgIdentifier, from, to)
+	t.shareMsgLock.Lock()
+	defer t.shareMsgLock.Unlock()
+	if t.shareMsgs[key] {
+		return true
+	}
+	t.shareMsgs[key] = true
+	return false
+}
+
 func (t *TssCommon) checkDupAndUpdateVerMsg(bMsg *messages.BroadcastConfirmMessage, peerID string) bool {
 	localCacheItem := t.TryGetLocalCacheItem(bMsg.Key)
 	// we check whether this node has already sent the VerMsg message to avoid eclipse of others VerMsg
@@ -345,6 +467,7 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 		if err := json.Unmarshal(wrappedMsg.Payload, &wireMsg); nil != err {
 			return fmt.Errorf("fail to unmarshal wire message: %w", err)
 		}
+		atomic.AddInt64(&t.received, 1)
 		return t.processTSSMsg(&wireMsg, wrappedMsg.MessageType, false)
 	case messages.TSSKeyGenVerMsg, messages.TSSKeySignVerMsg:
 		var bMsg messages.BroadcastConfirmMessage
@@ -369,7 +492,7 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 				return fmt.Errorf("duplicated notification from peer %s ignored", peerID)
 			}
 			t.finishedPeers[peerID] = true
-			if len(t.finishedPeers) == len(t.partyInfo.PartyIDMap)-1 {
+			if len(t.finishedPeers) == conversion.CountPartyOwners(t.partyInfo.PartyIDMap)-1 {
 				t.logger.Debug().Msg("we get the confirm of the nodes that generate the signature")
 				close(t.taskDone)
 			}
@@ -395,6 +518,10 @@ func (t *TssCommon) ProcessOneMessage(wrappedMsg *messages.WrappedMessage, peerI
 		}
 		t.logger.Debug().Msg("we got the missing share from the peer")
 		return t.processTSSMsg(wireMsg.Msg, wireMsg.RequestType, true)
+	case messages.TSSCatchUpMsg:
+		return t.replayJournal(peerID)
+	case messages.TSSAbortMsg:
+		return t.processAbort(wrappedMsg.Payload, peerID)
 	}
 
 	return nil
@@ -451,6 +578,7 @@ func (t *TssCommon) sendBulkMsg(wiredMsgType string, tssMsgType messages.THORCha
 	// since all the messages in the list is the same round, so it must have the same dest
 	// we just need to get the routing info of the first message
 	r := wiredMsgList[0].Routing
+	t.reportProgress(wiredMsgType)
 
 	buf, err := json.Marshal(wiredMsgList)
 	if err != nil {
@@ -480,10 +608,15 @@ func (t *TssCommon) sendBulkMsg(wiredMsgType string, tssMsgType messages.THORCha
 	}
 
 	peerIDs := make([]peer.ID, 0)
+	// the other local shares of a weighted key take the message without the network
+	loopback := false
 	if len(r.To) == 0 {
 		t.P2PPeersLock.RLock()
 		peerIDs = t.P2PPeers
 		t.P2PPeersLock.RUnlock()
+		if partyInfo := t.getPartyInfo(); partyInfo != nil && partyInfo.ShareMap != nil {
+			loopback = true
+		}
 	} else {
 		for _, each := range r.To {
 			peerID, ok := t.PartyIDtoP2PID[each.Id]
@@ -491,17 +624,49 @@ func (t *TssCommon) sendBulkMsg(wiredMsgType string, tssMsgType messages.THORCha
 				t.logger.Error().Msg("error in find the P2P ID")
 				continue
 			}
+			if peerID.String() == t.localPeerID {
+				loopback = true
+				continue
+			}
 			peerIDs = append(peerIDs, peerID)
 		}
 	}
+	if loopback {
+		// the local party may be blocked on its out channel until we return, so the shares take it aside
+		go t.applyLocalShares(&wireMsg)
+		if len(peerIDs) == 0 {
+			return nil
+		}
+	}
+	// we marshal the message once, the p2p layer and the journal replay both reuse the bytes
+	encoded, err := wrappedMsg.MarshalProto()
+	if err != nil {
+		return fmt.Errorf("fail to marshal the wrapped message: %w", err)
+	}
 	t.renderToP2P(&messages.BroadcastMsgChan{
 		WrappedMessage: wrappedMsg,
 		PeersID:        peerIDs,
+		Encoded:        encoded,
+		Gossip:         len(r.To) == 0 && t.gossipEnabled(len(peerIDs)+1),
 	})
+	t.recordJournal(wrappedMsg, encoded, peerIDs)
 
 	return nil
 }
 
+// applyLocalShares apply the message of a local party to the other local shares of a weighted key, the message
+// never leaves us, so it needs no hash check
+func (t *TssCommon) applyLocalShares(wireMsg *messages.WireMessage) {
+	if err := t.updateLocal(wireMsg); err != nil {
+		t.logger.Error().Err(err).Msg("fail to apply the message to the local shares")
+	}
+}
+
+// gossipEnabled return true if the ceremony of the given number of parties should gossip its broadcast messages
+func (t *TssCommon) gossipEnabled(parties int) bool {
+	return t.conf.GossipMinParties > 0 && parties >= t.conf.GossipMinParties
+}
+
 func (t *TssCommon) ProcessOutCh(msg btss.Message, msgType messages.THORChainTSSMessageType) error {
 	msgData, r, err := msg.WireBytes()
 	// if we cannot get the wire share, the tss will fail, we just quit.
@@ -509,28 +674,31 @@ func (t *TssCommon) ProcessOutCh(msg btss.Message, msgType messages.THORChainTSS
 		return fmt.Errorf("fail to get wire bytes: %w", err)
 	}
 
+	// the messages are batched by the sending party, the shares of a weighted key send their own batches
 	if r.IsBroadcast {
 		cachedWiredMsg := NewBulkWireMsg(msgData, msg.GetFrom().Moniker, r)
 		// now we store this message in cache
-		dat, ok := t.cachedWireBroadcastMsgLists.Load(msg.Type())
+		key := msg.Type() + ":" + msg.GetFrom().Id
+		dat, ok := t.cachedWireBroadcastMsgLists.Load(key)
 		if !ok {
 			l := []BulkWireMsg{cachedWiredMsg}
-			t.cachedWireBroadcastMsgLists.Store(msg.Type(), l)
+			t.cachedWireBroadcastMsgLists.Store(key, l)
 		} else {
 			cachedList := dat.([]BulkWireMsg)
 			cachedList = append(cachedList, cachedWiredMsg)
-			t.cachedWireBroadcastMsgLists.Store(msg.Type(), cachedList)
+			t.cachedWireBroadcastMsgLists.Store(key, cachedList)
 		}
 	} else {
 		cachedWiredMsg := NewBulkWireMsg(msgData, msg.GetFrom().Moniker, r)
-		dat, ok := t.cachedWireUnicastMsgLists.Load(msg.Type() + ":" + r.To[0].String())
+		key := msg.Type() + ":" + msg.GetFrom().Id + ":" + r.To[0].Id
+		dat, ok := t.cachedWireUnicastMsgLists.Load(key)
 		if !ok {
 			l := []BulkWireMsg{cachedWiredMsg}
-			t.cachedWireUnicastMsgLists.Store(msg.Type()+":"+r.To[0].String(), l)
+			t.cachedWireUnicastMsgLists.Store(key, l)
 		} else {
 			cachedList := dat.([]BulkWireMsg)
 			cachedList = append(cachedList, cachedWiredMsg)
-			t.cachedWireUnicastMsgLists.Store(msg.Type()+":"+r.To[0].String(), cachedList)
+			t.cachedWireUnicastMsgLists.Store(key, cachedList)
 		}
 	}
 	t.cachedWireUnicastMsgLists.Range(func(key, value interface{}) bool {
@@ -550,7 +718,7 @@ func (t *TssCommon) ProcessOutCh(msg btss.Message, msgType messages.THORChainTSS
 
 	t.cachedWireBroadcastMsgLists.Range(func(key, value interface{}) bool {
 		wiredMsgList := value.([]BulkWireMsg)
-		wiredMsgType := key.(string)
+		wiredMsgType := strings.Split(key.(string), ":")[0]
 		if len(wiredMsgList) == t.msgNum {
 			err := t.sendBulkMsg(wiredMsgType, msgType, wiredMsgList)
 			if err != nil {
@@ -663,7 +831,7 @@ func (t *TssCommon) processVerMsg(broadcastConfirmMsg *messages.BroadcastConfirm
 	localCacheItem.UpdateConfirmList(broadcastConfirmMsg.P2PID, broadcastConfirmMsg.Hash)
 	t.logger.Debug().Msgf("total confirmed parties:%+v", localCacheItem.ConfirmedList)
 
-	threshold, err := conversion.GetThreshold(len(partyInfo.PartyIDMap))
+	threshold, err := conversion.GetThreshold(conversion.CountPartyOwners(partyInfo.PartyIDMap))
 	if err != nil {
 		return err
 	}
@@ -749,9 +917,8 @@ func (t *TssCommon) processTSSMsg(wireMsg *messages.WireMessage, msgType message
 		t.logger.Error().Msg("error in find the data owner")
 		return errors.New("error in find the data owner")
 	}
-	keyBytes := dataOwner.GetKey()
 	var pk secp256k1.PubKey
-	pk = keyBytes
+	pk = conversion.PartyOwnerKey(dataOwner)
 	ok = verifySignature(pk, wireMsg.Message, wireMsg.Sig, t.msgID)
 	if !ok {
 		t.logger.Error().Msg("fail to verify the signature")
@@ -794,7 +961,7 @@ func (t *TssCommon) processTSSMsg(wireMsg *messages.WireMessage, msgType message
 	}
 	localCacheItem.UpdateConfirmList(t.localPeerID, msgHash)
 
-	threshold, err := conversion.GetThreshold(len(partyInfo.PartyIDMap))
+	threshold, err := conversion.GetThreshold(conversion.CountPartyOwners(partyInfo.PartyIDMap))
 	if err != nil {
 		return err
 	}
@@ -844,29 +1011,57 @@ func (t *TssCommon) removeKey(key string) {
 	delete(t.unConfirmedMessages, key)
 }
 
+// GetMsgChannel return the inbound channel for the given message type, if the buffer size of the message type
+// is configured, it has its own channel, otherwise it shares the default channel TssMsg
+func (t *TssCommon) GetMsgChannel(msgType messages.THORChainTSSMessageType) chan *p2p.Message {
+	size, ok := t.conf.MsgChannelSizes[msgType]
+	if !ok || size <= 0 {
+		return t.TssMsg
+	}
+	t.msgChannelsLock.Lock()
+	defer t.msgChannelsLock.Unlock()
+	ch, ok := t.msgChannels[msgType]
+	if !ok {
+		ch = make(chan *p2p.Message, size)
+		t.msgChannels[msgType] = ch
+	}
+	return ch
+}
+
+func (t *TssCommon) getInboundCases(finishChan chan struct{}) []reflect.SelectCase {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(finishChan)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(t.TssMsg)},
+	}
+	t.msgChannelsLock.Lock()
+	defer t.msgChannelsLock.Unlock()
+	for _, ch := range t.msgChannels {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+	}
+	return cases
+}
+
 func (t *TssCommon) ProcessInboundMessages(finishChan chan struct{}, wg *sync.WaitGroup) {
 	t.logger.Debug().Msg("start processing inbound messages")
 	defer wg.Done()
 	defer t.logger.Debug().Msg("stop processing inbound messages")
+	// the message type channels are created when we subscribe them, which is before we start processing
+	cases := t.getInboundCases(finishChan)
 	for {
-		select {
-		case <-finishChan:
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 0 || !ok {
 			return
-		case m, ok := <-t.TssMsg:
-			if !ok {
-				return
-			}
-			var wrappedMsg messages.WrappedMessage
-			if err := json.Unmarshal(m.Payload, &wrappedMsg); nil != err {
-				t.logger.Error().Err(err).Msg("fail to unmarshal wrapped message bytes")
-				continue
-			}
-
-			err := t.ProcessOneMessage(&wrappedMsg, m.PeerID.String())
-			if err != nil {
-				t.logger.Error().Err(err).Msg("fail to process the received message")
-			}
+		}
+		m := value.Interface().(*p2p.Message)
+		wrappedMsg, err := messages.UnmarshalWrappedMessage(m.Payload)
+		if err != nil {
+			t.logger.Error().Err(err).Msg("fail to unmarshal wrapped message bytes")
+			continue
+		}
 
+		err = t.ProcessOneMessage(wrappedMsg, m.PeerID.String())
+		if err != nil {
+			t.logger.Error().Err(err).Msg("fail to process the received message")
 		}
 	}
 }
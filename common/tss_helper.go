@@ -79,6 +79,7 @@ func InitLog(level string, pretty bool, serviceValue string) {
 		out = zerolog.ConsoleWriter{Out: os.Stdout}
 	}
 	zerolog.SetGlobalLevel(l)
+	logOutput = out
 	log.Logger = log.Output(out).With().Str("service", serviceValue).Logger()
 }
 
@@ -16,6 +16,7 @@ import (
 	btss "github.com/binance-chain/tss-lib/tss"
 	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	"github.com/libp2p/go-libp2p/core/peer"
 	tcrypto "github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/secp256k1"
 	. "gopkg.in/check.v1"
@@ -476,4 +477,124 @@ func (t *TssTestSuite) TestProcessInvalidMsgBlame(c *C) {
 	}
 	// for the last one, since we do not store the msg before hand, it should return no record of this party
 	c.Assert(blameResult.BlameNodes[2].BlameData, HasLen, 0)
+
+	abort := tssCommonStruct.GetBlameMgr().GetAbort()
+	c.Assert(abort, NotNil)
+	c.Assert(abort.Round, Equals, wiredMsg.RoundInfo)
+	c.Assert(abort.Cause, Equals, "test error")
+	c.Assert(abort.Proofs, HasLen, 3)
+	c.Assert(abort.Proofs[2].Verified, Equals, false)
+	c.Assert(abort.Identified(), Equals, false)
+}
+
+func (t *TssTestSuite) TestLateJoinCatchUp(c *C) {
+	broadcastChannel := make(chan *messages.BroadcastMsgChan, 10)
+	conf := TssConfig{LateJoinGraceWindow: time.Minute}
+	tssCommon := NewTssCommon("", broadcastChannel, conf, "message-id", t.privKey, 1)
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(testPubKeys[:3])
+	c.Assert(err, IsNil)
+	peerA, peerB := peerIDs[0], peerIDs[1]
+	tssCommon.P2PPeers = []peer.ID{peerA, peerB}
+
+	// we do not journal the messages before the party starts
+	tssCommon.recordJournal(messages.WrappedMessage{MsgID: "message-id"}, nil, []peer.ID{peerA, peerB})
+	c.Assert(tssCommon.journal, HasLen, 0)
+
+	tssCommon.SetPartyInfo(&PartyInfo{
+		PartyMap:   nil,
+		PartyIDMap: make(map[string]*btss.PartyID),
+	})
+	tssCommon.recordJournal(messages.WrappedMessage{MsgID: "message-id", Payload: []byte("broadcast")}, []byte("encoded"), []peer.ID{peerA, peerB})
+	tssCommon.recordJournal(messages.WrappedMessage{MsgID: "message-id", Payload: []byte("unicast")}, nil, []peer.ID{peerB})
+	c.Assert(tssCommon.journal, HasLen, 2)
+
+	tssCommon.RequestCatchUp()
+	req := <-broadcastChannel
+	c.Assert(req.WrappedMessage.MessageType, Equals, messages.TSSCatchUpMsg)
+	c.Assert(req.PeersID, HasLen, 2)
+
+	catchUp := &messages.WrappedMessage{
+		MessageType: messages.TSSCatchUpMsg,
+		MsgID:       "message-id",
+	}
+	err = tssCommon.ProcessOneMessage(catchUp, peerA.String())
+	c.Assert(err, IsNil)
+	c.Assert(broadcastChannel, HasLen, 1)
+	replayed := <-broadcastChannel
+	c.Assert(replayed.PeersID, DeepEquals, []peer.ID{peerA})
+	c.Assert(replayed.WrappedMessage.Payload, DeepEquals, []byte("broadcast"))
+	c.Assert(replayed.Encoded, DeepEquals, []byte("encoded"))
+
+	err = tssCommon.ProcessOneMessage(catchUp, peerB.String())
+	c.Assert(err, IsNil)
+	c.Assert(broadcastChannel, HasLen, 2)
+	<-broadcastChannel
+	<-broadcastChannel
+
+	// peers that are not in the party cannot ask for the replay
+	err = tssCommon.ProcessOneMessage(catchUp, peerIDs[2].String())
+	c.Assert(err, NotNil)
+
+	// once the grace window expires, we no longer replay the messages
+	tssCommon.partyStartTime = time.Now().Add(-time.Minute * 2)
+	err = tssCommon.ProcessOneMessage(catchUp, peerA.String())
+	c.Assert(err, IsNil)
+	c.Assert(broadcastChannel, HasLen, 0)
+}
+
+func (t *TssTestSuite) TestGetMsgChannel(c *C) {
+	conf := TssConfig{
+		MsgChannelSizes: map[messages.THORChainTSSMessageType]int{
+			messages.TSSKeySignVerMsg: 10,
+		},
+	}
+	tssCommon := NewTssCommon("", nil, conf, "message-id", t.privKey, 1)
+	c.Assert(tssCommon.GetMsgChannel(messages.TSSKeySignMsg), Equals, tssCommon.TssMsg)
+	verChannel := tssCommon.GetMsgChannel(messages.TSSKeySignVerMsg)
+	c.Assert(cap(verChannel), Equals, 10)
+	c.Assert(tssCommon.GetMsgChannel(messages.TSSKeySignVerMsg), Equals, verChannel)
+
+	// the messages from the dedicated channel are processed as well
+	stopChan := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go tssCommon.ProcessInboundMessages(stopChan, &wg)
+	verChannel <- &p2p.Message{
+		PeerID:  conversion.GetRandomPeerID(),
+		Payload: []byte("invalid"),
+	}
+	time.Sleep(time.Millisecond * 100)
+	c.Assert(verChannel, HasLen, 0)
+	close(stopChan)
+	wg.Wait()
+}
+
+func (t *TssTestSuite) TestGossipEnabled(c *C) {
+	tssCommon := NewTssCommon("", nil, TssConfig{}, "message-id", t.privKey, 1)
+	c.Assert(tssCommon.gossipEnabled(100), Equals, false)
+	tssCommon = NewTssCommon("", nil, TssConfig{GossipMinParties: 40}, "message-id", t.privKey, 1)
+	c.Assert(tssCommon.gossipEnabled(39), Equals, false)
+	c.Assert(tssCommon.gossipEnabled(40), Equals, true)
+}
+
+func (t *TssTestSuite) TestRoundTimeout(c *C) {
+	var timeouts RoundTimeouts
+	c.Assert(timeouts.Set(messages.KEYGEN1+"=1m"), IsNil)
+	c.Assert(timeouts.Set(messages.KEYGEN3+"=10s"), IsNil)
+	c.Assert(timeouts.Set("invalid"), NotNil)
+	c.Assert(timeouts.Set(messages.KEYGEN3+"=ten"), NotNil)
+	c.Assert(timeouts.String(), Equals, "KGRound1Message=1m0s,KGRound3Message=10s")
+
+	conf := TssConfig{RoundTimeouts: timeouts}
+	c.Assert(conf.RoundTimeout(messages.KEYGEN1, time.Second), Equals, time.Minute)
+	c.Assert(conf.RoundTimeout(messages.KEYGEN2b, time.Second), Equals, time.Second)
+}
+
+func (t *TssTestSuite) TestPubKeyList(c *C) {
+	var pubKeys PubKeyList
+	c.Assert(pubKeys.Set(testBlamePubKeys[0]), IsNil)
+	c.Assert(pubKeys.Set(testBlamePubKeys[1]), IsNil)
+	c.Assert(pubKeys.Set(""), NotNil)
+	c.Assert(pubKeys, HasLen, 2)
+	c.Assert(pubKeys.String(), Equals, testBlamePubKeys[0]+","+testBlamePubKeys[1])
 }
@@ -1,9 +1,24 @@
 package common
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
 )
 
+// P2PComm is the part of the p2p layer the keygen and keysign ceremonies use
+type P2PComm interface {
+	GetLocalPeerID() string
+	ExportPeerAddress() map[peer.ID]p2p.AddrList
+}
+
 type TssConfig struct {
 	// Party Timeout defines how long do we wait for the party to form
 	PartyTimeout time.Duration
@@ -15,4 +30,179 @@ type TssConfig struct {
 	PreParamTimeout time.Duration
 	// enable the tss monitor
 	EnableMonitor bool
+	// LateJoinGraceWindow defines how long after the party starts we still replay the messages
+	// we have sent to the peers who join late, 0 disables the catch up
+	LateJoinGraceWindow time.Duration
+	// RoundCacheSize is the number of the tss messages of a ceremony we buffer when they arrive before the local
+	// party starts, they are replayed once it starts, 0 disables the cache
+	RoundCacheSize int
+	// CeremonyLogLines is the number of the last log lines of a ceremony we attach to its response if it fails,
+	// 0 disables the capture
+	CeremonyLogLines int
+	// MsgChannelSizes defines the buffer size of the inbound channel of the given message types, the message
+	// types that are not in the map share the default inbound channel of the party
+	MsgChannelSizes map[messages.THORChainTSSMessageType]int
+	// AllowTrustedDealer allows this node to take part in the trusted dealer key import, the dealer knows the
+	// whole private key, so it should only be enabled for the migration of the legacy keys, the tss service never
+	// sets it
+	AllowTrustedDealer bool
+	// CanaryInterval defines how often we run the canary keysign with CanaryPoolPubKey, 0 disables the canary
+	CanaryInterval time.Duration
+	// CanaryPoolPubKey is the pool pub key of the dedicated test key the canary keysign signs with, the key
+	// should never hold any funds
+	CanaryPoolPubKey string
+	// HealthCheckInterval defines how often we run the health check keysign of HealthCheckPoolPubKeys, 0 disables
+	// the scheduled health checks, they can still be run on demand
+	HealthCheckInterval time.Duration
+	// HealthCheckPoolPubKeys are the pool pub keys of the vaults the scheduled health check signs with
+	HealthCheckPoolPubKeys PubKeyList
+	// BlameWebhookURL is the endpoint we post the blame events of the failed ceremonies to, empty disables it
+	BlameWebhookURL string
+	// Webhooks are the endpoints we post the outcome of the keygens and the keysigns and the blame of the failed
+	// ceremonies to
+	Webhooks StringList
+	// WebhookSecret is the key of the hmac-sha256 of the webhook payloads, empty leaves them unsigned
+	WebhookSecret string
+	// WebhookEvents are the kinds of the events we post to the webhooks, empty posts them all
+	WebhookEvents StringList
+	// GossipMinParties is the number of parties from which the ceremony gossips its broadcast round messages
+	// instead of sending them over the direct streams, 0 always uses the direct streams
+	GossipMinParties int
+	// MaxConcurrentCeremonies is the number of the keygens and keysigns we run at the same time, the others wait
+	// for a free slot up to the party timeout, 0 does not limit them. The concurrent ceremonies should run over
+	// the per-ceremony protocols, or they share the stream limits of the tss protocol
+	MaxConcurrentCeremonies int
+	// MaxConcurrentKeySigns is the number of the keysign requests we run at the same time, the others wait in the
+	// keysign queue in the order of their priority, 0 disables the queue
+	MaxConcurrentKeySigns int
+	// MaxKeySignQueue is the number of the keysign requests that can wait in the queue, the requests beyond it
+	// are rejected as busy with the time to retry after
+	MaxKeySignQueue int
+	// SerializeKeySigns runs the queued keysign requests of the same pool key one after the other
+	SerializeKeySigns bool
+	// PresignPoolPubKey is the pool pub key we keep the presignatures of the schnorr keysign for, all the
+	// participants of the pool presign together
+	PresignPoolPubKey string
+	// PresignPoolSize is the number of the presignatures we keep ready, we presign again once the keysigns have
+	// taken some of them
+	PresignPoolSize int
+	// PresignInterval defines how often we check the presignatures of PresignPoolPubKey, 0 disables the presign
+	PresignInterval time.Duration
+	// RefreshPoolPubKey is the pool pub key we refresh the shares of, all the participants of the pool take part
+	RefreshPoolPubKey string
+	// RefreshInterval defines how often we refresh the shares of RefreshPoolPubKey, 0 disables the refresh
+	RefreshInterval time.Duration
+	// KeyDeletionCoolingOff defines how long a key stays retired before its share can be deleted, 0 allows the
+	// deletion right after the retirement
+	KeyDeletionCoolingOff time.Duration
+	// RetiredKeyRetention defines how long the share of a retired key is kept before it is archived to
+	// RetiredKeyArchiveFolder and removed, 0 keeps the shares of the retired keys
+	RetiredKeyRetention time.Duration
+	// RetiredKeyArchiveFolder is the folder the shares of the retired keys are archived to, encrypted with the
+	// archive passphrase
+	RetiredKeyArchiveFolder string
+	// PreParamsPoolSize is the number of the keygen pre-parameters we generate ahead in the background, 0 disables
+	// the pool
+	PreParamsPoolSize int
+	// PreParamsTTL defines how long the pre-parameters of the pool stay fresh, 0 never expires them
+	PreParamsTTL time.Duration
+	// SignerSelection is the strategy the leader picks the keysign signers with when more parties join than the
+	// threshold needs, first, hash, latency or reputation, the first parties to join are picked if it is empty
+	SignerSelection string
+	// RoundTimeouts defines how long we wait for the messages of the given tss rounds, the rounds that are not in
+	// the map wait for KeyGenTimeout or KeySignTimeout
+	RoundTimeouts RoundTimeouts
+	// SignLedger keeps the ledger of the keysigns we take part in, we refuse the keysigns that conflict with them by
+	// SignLedgerRules
+	SignLedger bool
+	// SignLedgerRules are the metadata keys of the keysign requests that name what the messages spend, like utxo or
+	// nonce, we refuse to sign other messages of a pool key with a value it has signed
+	SignLedgerRules StringList
+	// SignLedgerRetention defines how long we keep the keysigns in the ledger, 0 keeps them forever
+	SignLedgerRetention time.Duration
+	// AuditLog keeps the append only, hash chained log of the keysigns we are asked to take part in, the node refuses
+	// to start if its state manager can not persist it
+	AuditLog bool
+	// RefuseCorruptedKeyshares refuses to start when the checksum of a keyshare does not match, otherwise the key is
+	// marked unhealthy
+	RefuseCorruptedKeyshares bool
+	// DrainTimeout defines how long the in-flight ceremonies can run once we stop, the new ones are refused
+	// meanwhile, the ceremonies still running by then are aborted and the peers are told so
+	DrainTimeout time.Duration
+	// BlameQuorumTimeout defines how long we wait for the participants of a failed ceremony to share their blame,
+	// 0 disables the exchange, the response only carries our own blame then
+	BlameQuorumTimeout time.Duration
+}
+
+// RoundTimeout return how long we wait for the messages of the given round
+func (c TssConfig) RoundTimeout(round string, fallback time.Duration) time.Duration {
+	if timeout, ok := c.RoundTimeouts[round]; ok && timeout > 0 {
+		return timeout
+	}
+	return fallback
+}
+
+// RoundTimeouts maps the message of a tss round to how long we wait for it, it can be set as a flag in the form of
+// round=duration
+type RoundTimeouts map[string]time.Duration
+
+// String implement flag.Value
+func (r RoundTimeouts) String() string {
+	rounds := make([]string, 0, len(r))
+	for round, timeout := range r {
+		rounds = append(rounds, round+"="+timeout.String())
+	}
+	sort.Strings(rounds)
+	return strings.Join(rounds, ",")
+}
+
+// Set implement flag.Value
+func (r *RoundTimeouts) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid round timeout %q, expect round=duration", value)
+	}
+	timeout, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return fmt.Errorf("fail to parse the timeout of round %s: %w", parts[0], err)
+	}
+	if *r == nil {
+		*r = make(RoundTimeouts)
+	}
+	(*r)[parts[0]] = timeout
+	return nil
+}
+
+// StringList is a list of strings, it can be set as a flag that is repeated for each value
+type StringList []string
+
+// String implement flag.Value
+func (l StringList) String() string {
+	return strings.Join(l, ",")
+}
+
+// Set implement flag.Value
+func (l *StringList) Set(value string) error {
+	if len(value) == 0 {
+		return errors.New("empty value")
+	}
+	*l = append(*l, value)
+	return nil
+}
+
+// PubKeyList is a list of pub keys, it can be set as a flag that is repeated for each pub key
+type PubKeyList []string
+
+// String implement flag.Value
+func (l PubKeyList) String() string {
+	return strings.Join(l, ",")
+}
+
+// Set implement flag.Value
+func (l *PubKeyList) Set(value string) error {
+	if len(value) == 0 {
+		return errors.New("empty pub key")
+	}
+	*l = append(*l, value)
+	return nil
 }
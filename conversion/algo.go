@@ -0,0 +1,70 @@
+package conversion
+
+import "fmt"
+
+// Algo is the signature algorithm of a tss key, and so the curve the key is on
+type Algo string
+
+const (
+	// AlgoSecp256k1 keys are on the secp256k1 curve, they sign with ecdsa, or with schnorr in the schnorr sign
+	// modes, it is the default
+	AlgoSecp256k1 Algo = "secp256k1"
+	// AlgoEd25519 keys are on the ed25519 curve, they sign with eddsa
+	AlgoEd25519 Algo = "ed25519"
+)
+
+// OrDefault return the algo, secp256k1 if it is empty, the requests and the keys saved before we record the algo
+// have none
+func (a Algo) OrDefault() Algo {
+	if a == "" {
+		return AlgoSecp256k1
+	}
+	return a
+}
+
+// Validate check the algo is known
+func (a Algo) Validate() error {
+	switch a.OrDefault() {
+	case AlgoSecp256k1, AlgoEd25519:
+		return nil
+	default:
+		return fmt.Errorf("unknown algo %s", a)
+	}
+}
+
+// SignProtocol is the threshold signing protocol a key signs with, it is picked at keygen
+type SignProtocol string
+
+const (
+	// SignProtocolGG20 keys sign ecdsa with GG20, they sign schnorr too if the keysign asks for it, it is the
+	// default
+	SignProtocolGG20 SignProtocol = "gg20"
+	// SignProtocolFROST keys only sign BIP-340 schnorr with FROST, it takes two rounds and no MtA
+	SignProtocolFROST SignProtocol = "frost"
+	// SignProtocolMuSig2 keys are the n-of-n MuSig2 aggregate of a key of each participant, they only sign BIP-340
+	// schnorr and all the participants have to sign, the keygen takes one round and needs no pre-parameters
+	SignProtocolMuSig2 SignProtocol = "musig2"
+)
+
+// OrDefault return the protocol, gg20 if it is empty
+func (p SignProtocol) OrDefault() SignProtocol {
+	if p == "" {
+		return SignProtocolGG20
+	}
+	return p
+}
+
+// Validate check the protocol is known and can sign with the keys of the algo
+func (p SignProtocol) Validate(algo Algo) error {
+	switch p.OrDefault() {
+	case SignProtocolGG20:
+		return nil
+	case SignProtocolFROST, SignProtocolMuSig2:
+		if algo.OrDefault() != AlgoSecp256k1 {
+			return fmt.Errorf("%s only signs with the secp256k1 keys, not the %s ones", p, algo)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sign protocol %s", p)
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"fmt"
 	"math"
 	"math/big"
-	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/binance-chain/tss-lib/crypto"
@@ -39,17 +37,15 @@ func GetPeerIDFromPartyID(partyID *btss.PartyID) (peer.ID, error) {
 	if partyID == nil || !partyID.ValidateBasic() {
 		return "", errors.New("invalid partyID")
 	}
-	pkBytes := partyID.KeyInt().Bytes()
-	return GetPeerIDFromSecp256PubKey(pkBytes)
+	return GetPeerIDFromSecp256PubKey(PartyOwnerKey(partyID))
 }
 
 func PartyIDtoPubKey(party *btss.PartyID) (string, error) {
 	if party == nil || !party.ValidateBasic() {
 		return "", errors.New("invalid party")
 	}
-	partyKeyBytes := party.GetKey()
 	pk := coskey.PubKey{
-		Key: partyKeyBytes,
+		Key: PartyOwnerKey(party),
 	}
 	pubKey, err := sdk.MarshalPubKey(sdk.AccPK, &pk)
 	if err != nil {
@@ -60,6 +56,8 @@ func PartyIDtoPubKey(party *btss.PartyID) (string, error) {
 
 func AccPubKeysFromPartyIDs(partyIDs []string, partyIDMap map[string]*btss.PartyID) ([]string, error) {
 	pubKeys := make([]string, 0)
+	// the shares of a weighted key have the same owner, we only return it once
+	seen := make(map[string]bool)
 	for _, partyID := range partyIDs {
 		blameParty, ok := partyIDMap[partyID]
 		if !ok {
@@ -69,6 +67,10 @@ func AccPubKeysFromPartyIDs(partyIDs []string, partyIDMap map[string]*btss.Party
 		if err != nil {
 			return nil, err
 		}
+		if seen[blamedPubKey] {
+			continue
+		}
+		seen[blamedPubKey] = true
 		pubKeys = append(pubKeys, blamedPubKey)
 	}
 	return pubKeys, nil
@@ -87,10 +89,12 @@ func GetPeersID(partyIDtoP2PID map[string]peer.ID, localPeerID string) []peer.ID
 		return nil
 	}
 	peerIDs := make([]peer.ID, 0, len(partyIDtoP2PID)-1)
+	seen := make(map[peer.ID]bool)
 	for _, value := range partyIDtoP2PID {
-		if value.String() == localPeerID {
+		if value.String() == localPeerID || seen[value] {
 			continue
 		}
+		seen[value] = true
 		peerIDs = append(peerIDs, value)
 	}
 	return peerIDs
@@ -108,31 +112,11 @@ func SetupIDMaps(parties map[string]*btss.PartyID, partyIDtoP2PID map[string]pee
 }
 
 func GetParties(keys []string, localPartyKey string) ([]*btss.PartyID, *btss.PartyID, error) {
-	var localPartyID *btss.PartyID
-	var unSortedPartiesID []*btss.PartyID
-	sort.Strings(keys)
-	for idx, item := range keys {
-		pk, err := sdk.UnmarshalPubKey(sdk.AccPK, item)
-		if err != nil {
-			return nil, nil, fmt.Errorf("fail to get account pub key address(%s): %w", item, err)
-		}
-		key := new(big.Int).SetBytes(pk.Bytes())
-		// Set up the parameters
-		// Note: The `id` and `moniker` fields are for convenience to allow you to easily track participants.
-		// The `id` should be a unique string representing this party in the network and `moniker` can be anything (even left blank).
-		// The `uniqueKey` is a unique identifying key for this peer (such as its p2p public key) as a big.Int.
-		partyID := btss.NewPartyID(strconv.Itoa(idx), "", key)
-		if item == localPartyKey {
-			localPartyID = partyID
-		}
-		unSortedPartiesID = append(unSortedPartiesID, partyID)
-	}
-	if localPartyID == nil {
-		return nil, nil, errors.New("local party is not in the list")
+	partiesID, localPartiesID, err := GetWeightedParties(keys, nil, localPartyKey)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	partiesID := btss.SortPartyIDs(unSortedPartiesID)
-	return partiesID, localPartyID, nil
+	return partiesID, localPartiesID[0], nil
 }
 
 func GetPreviousKeySignUicast(current string) string {
@@ -176,6 +160,21 @@ func BytesToHashString(msg []byte) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// GetThresholdFromSigners return the tss threshold of the signers-of-parties key, the signers should be the
+// majority of the parties, so two groups of them can never both sign
+func GetThresholdFromSigners(signers, parties int) (int, error) {
+	if parties < 2 {
+		return 0, fmt.Errorf("the key needs at least 2 parties, have %d", parties)
+	}
+	if signers > parties {
+		return 0, fmt.Errorf("the key needs %d signers out of %d parties", signers, parties)
+	}
+	if signers*2 <= parties {
+		return 0, fmt.Errorf("%d signers are not the majority of %d parties", signers, parties)
+	}
+	return signers - 1, nil
+}
+
 func GetThreshold(value int) (int, error) {
 	if value < 0 {
 		return 0, errors.New("negative input")
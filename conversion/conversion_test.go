@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/binance-chain/tss-lib/crypto"
+	btss "github.com/binance-chain/tss-lib/tss"
 	"github.com/btcsuite/btcd/btcec"
 	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
@@ -226,3 +227,86 @@ func (p *ConversionTestSuite) TestTssPubKey(c *C) {
 	c.Assert(pk, Equals, "thorpub1addwnpepq2dwek9hkrlxjxadrlmy9fr42gqyq6029q0hked46l3u6a9fxqel6tma5eu")
 	c.Assert(addr.String(), Equals, "bnb17l7cyxqzg4xymnl0alrhqwja276s3rns4256c2")
 }
+
+func (p *ConversionTestSuite) TestGetThresholdFromSigners(c *C) {
+	threshold, err := GetThresholdFromSigners(3, 4)
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 2)
+	threshold, err = GetThresholdFromSigners(4, 4)
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 3)
+	threshold, err = GetThresholdFromSigners(4, 7)
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 3)
+
+	// the signers should be the majority of the parties
+	_, err = GetThresholdFromSigners(2, 4)
+	c.Assert(err, NotNil)
+	_, err = GetThresholdFromSigners(5, 4)
+	c.Assert(err, NotNil)
+	_, err = GetThresholdFromSigners(1, 1)
+	c.Assert(err, NotNil)
+	_, err = GetThresholdFromSigners(0, 3)
+	c.Assert(err, NotNil)
+}
+
+func (p *ConversionTestSuite) TestAlgo(c *C) {
+	c.Assert(Algo("").OrDefault(), Equals, AlgoSecp256k1)
+	c.Assert(AlgoEd25519.OrDefault(), Equals, AlgoEd25519)
+	c.Assert(Algo("").Validate(), IsNil)
+	c.Assert(AlgoSecp256k1.Validate(), IsNil)
+	c.Assert(AlgoEd25519.Validate(), IsNil)
+	c.Assert(Algo("rsa").Validate(), NotNil)
+}
+
+func (p *ConversionTestSuite) TestSignProtocol(c *C) {
+	c.Assert(SignProtocol("").OrDefault(), Equals, SignProtocolGG20)
+	c.Assert(SignProtocolFROST.OrDefault(), Equals, SignProtocolFROST)
+	c.Assert(SignProtocol("").Validate(AlgoEd25519), IsNil)
+	c.Assert(SignProtocolFROST.Validate(""), IsNil)
+	c.Assert(SignProtocolFROST.Validate(AlgoSecp256k1), IsNil)
+	c.Assert(SignProtocolFROST.Validate(AlgoEd25519), NotNil)
+	c.Assert(SignProtocolMuSig2.Validate(AlgoSecp256k1), IsNil)
+	c.Assert(SignProtocolMuSig2.Validate(AlgoEd25519), NotNil)
+	c.Assert(SignProtocol("gg18").Validate(AlgoSecp256k1), NotNil)
+}
+
+func (p *ConversionTestSuite) TestGetWeightedParties(c *C) {
+	weights := map[string]int{p.testPubKeys[1]: 3}
+	c.Assert(ValidateWeights(p.testPubKeys, weights), IsNil)
+	c.Assert(ValidateWeights(p.testPubKeys, map[string]int{"whatever": 2}), NotNil)
+	c.Assert(ValidateWeights(p.testPubKeys, map[string]int{p.testPubKeys[0]: 0}), NotNil)
+	c.Assert(ValidateWeights(p.testPubKeys, map[string]int{p.testPubKeys[0]: MaxShareWeight + 1}), NotNil)
+	c.Assert(TotalShares(p.testPubKeys, weights), Equals, 6)
+	c.Assert(GetWeight(weights, p.testPubKeys[0]), Equals, 1)
+
+	partiesID, localPartiesID, err := GetWeightedParties(p.testPubKeys, weights, p.testPubKeys[1])
+	c.Assert(err, IsNil)
+	c.Assert(partiesID, HasLen, 6)
+	c.Assert(localPartiesID, HasLen, 3)
+	ids := make(map[string]bool)
+	for _, el := range localPartiesID {
+		ids[el.Id] = true
+		pubKey, err := PartyIDtoPubKey(el)
+		c.Assert(err, IsNil)
+		c.Assert(pubKey, Equals, p.testPubKeys[1])
+		peerID, err := GetPeerIDFromPartyID(el)
+		c.Assert(err, IsNil)
+		expected, err := GetPeerIDFromPubKey(p.testPubKeys[1])
+		c.Assert(err, IsNil)
+		c.Assert(peerID, Equals, expected)
+	}
+	c.Assert(ids, HasLen, 3)
+	// the shares of the same owner are only blamed once
+	got, err := AccPubKeysFromPartyIDs([]string{localPartiesID[0].Id, localPartiesID[2].Id}, SetupPartyIDMap(partiesID))
+	c.Assert(err, IsNil)
+	c.Assert(got, DeepEquals, []string{p.testPubKeys[1]})
+
+	// the keys of weight 1 get the same parties as GetParties
+	unweighted, localPartyID, err := GetParties(p.testPubKeys, p.testPubKeys[1])
+	c.Assert(err, IsNil)
+	partiesID, localPartiesID, err = GetWeightedParties(p.testPubKeys, nil, p.testPubKeys[1])
+	c.Assert(err, IsNil)
+	c.Assert(partiesID, DeepEquals, unweighted)
+	c.Assert(localPartiesID, DeepEquals, []*btss.PartyID{localPartyID})
+}
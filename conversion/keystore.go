@@ -0,0 +1,102 @@
+package conversion
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const keyStoreVersion = 1
+
+// KeyStoreScryptN is the scrypt cost of the keystores we create
+var KeyStoreScryptN = 1 << 18
+
+// ErrWrongKeyStorePassphrase is returned when the passphrase does not decrypt the keystore
+var ErrWrongKeyStorePassphrase = errors.New("wrong passphrase, fail to decrypt the keystore")
+
+// KeyStore is the node private key encrypted with AES-256-GCM, the key is derived from a passphrase with scrypt
+type KeyStore struct {
+	Version int `json:"version"`
+	// PubKey is the hex encoded compressed public key, so the keystore can be told apart without the passphrase
+	PubKey     string `json:"pub_key"`
+	Salt       []byte `json:"salt"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func keyStoreCipher(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("fail to derive the keystore key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptKeyStore encrypt the raw secp256k1 node private key with the passphrase, it return the json keystore
+func EncryptKeyStore(priKeyBytes, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	signer, err := NewKeySigner(priKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	ks := KeyStore{
+		Version: keyStoreVersion,
+		PubKey:  hex.EncodeToString(signer.PubKey().SerializeCompressed()),
+		Salt:    make([]byte, 32),
+		N:       KeyStoreScryptN,
+		R:       8,
+		P:       1,
+	}
+	if _, err := io.ReadFull(rand.Reader, ks.Salt); err != nil {
+		return nil, fmt.Errorf("fail to generate the salt: %w", err)
+	}
+	aead, err := keyStoreCipher(passphrase, ks.Salt, ks.N, ks.R, ks.P)
+	if err != nil {
+		return nil, err
+	}
+	ks.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, ks.Nonce); err != nil {
+		return nil, fmt.Errorf("fail to generate the nonce: %w", err)
+	}
+	ks.Ciphertext = aead.Seal(nil, ks.Nonce, priKeyBytes, []byte(ks.PubKey))
+	return json.Marshal(ks)
+}
+
+// DecryptKeyStore decrypt the raw node private key of the json keystore with the passphrase
+func DecryptKeyStore(buf, passphrase []byte) ([]byte, error) {
+	var ks KeyStore
+	if err := json.Unmarshal(buf, &ks); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the keystore: %w", err)
+	}
+	if ks.Version != keyStoreVersion {
+		return nil, fmt.Errorf("unknown keystore version %d", ks.Version)
+	}
+	aead, err := keyStoreCipher(passphrase, ks.Salt, ks.N, ks.R, ks.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(ks.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce of the keystore")
+	}
+	priKeyBytes, err := aead.Open(nil, ks.Nonce, ks.Ciphertext, []byte(ks.PubKey))
+	if err != nil {
+		return nil, ErrWrongKeyStorePassphrase
+	}
+	return priKeyBytes, nil
+}
@@ -0,0 +1,153 @@
+package conversion
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+	tcrypto "github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+// ErrKeyNotExportable is returned when the raw bytes of a key kept by a signer are asked for
+var ErrKeyNotExportable = errors.New("the key is kept by the signer, it can not be exported")
+
+// Signer signs with the secp256k1 identity key of the node, the node and the p2p host only ask the signer for the
+// signatures. Only the signer of a key in memory is delivered, the keystore is decrypted into it, so the key is in the
+// memory of the process. A signer that keeps the key in an HSM behind PKCS#11 is not part of this repo, the embedders
+// of the p2p host can pass their own with p2p.WithIdentitySigner
+type Signer interface {
+	// PubKey return the public key of the identity key
+	PubKey() *btcec.PublicKey
+	// SignDigest return the ecdsa signature of the 32 bytes digest
+	SignDigest(digest []byte) (*big.Int, *big.Int, error)
+}
+
+// keySigner is the signer of a key in memory
+type keySigner struct {
+	priKey *btcec.PrivateKey
+}
+
+// NewKeySigner create the signer of the raw secp256k1 private key
+func NewKeySigner(priKeyBytes []byte) (Signer, error) {
+	if len(priKeyBytes) != btcec.PrivKeyBytesLen {
+		return nil, errors.New("invalid secp256k1 private key")
+	}
+	priKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), priKeyBytes)
+	return &keySigner{priKey: priKey}, nil
+}
+
+func (ks *keySigner) PubKey() *btcec.PublicKey {
+	return ks.priKey.PubKey()
+}
+
+func (ks *keySigner) SignDigest(digest []byte) (*big.Int, *big.Int, error) {
+	sig, err := ks.priKey.Sign(digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig.R, sig.S, nil
+}
+
+// signDigest sign the sha256 digest of the message with the signer, the S is normalized to the low S form the
+// verifiers of the tss messages and libp2p require
+func signDigest(signer Signer, msg []byte) (*btcec.Signature, error) {
+	digest := sha256.Sum256(msg)
+	r, s, err := signer.SignDigest(digest[:])
+	if err != nil {
+		return nil, err
+	}
+	halfOrder := new(big.Int).Rsh(btcec.S256().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s = new(big.Int).Sub(btcec.S256().N, s)
+	}
+	return &btcec.Signature{R: r, S: s}, nil
+}
+
+// signerPrivKey is the tendermint private key of the signer, the node signs the tss messages with it
+type signerPrivKey struct {
+	signer Signer
+}
+
+// NewSignerPrivKey return the private key the tss server signs the tss messages with through the signer
+func NewSignerPrivKey(signer Signer) tcrypto.PrivKey {
+	return signerPrivKey{signer: signer}
+}
+
+// Bytes return nil, the key never leaves the signer
+func (k signerPrivKey) Bytes() []byte {
+	return nil
+}
+
+// Sign return the 64 bytes R || S signature of the message
+func (k signerPrivKey) Sign(msg []byte) ([]byte, error) {
+	sig, err := signDigest(k.signer, msg)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 64)
+	sig.R.FillBytes(buf[:32])
+	sig.S.FillBytes(buf[32:])
+	return buf, nil
+}
+
+func (k signerPrivKey) PubKey() tcrypto.PubKey {
+	return secp256k1.PubKey(k.signer.PubKey().SerializeCompressed())
+}
+
+func (k signerPrivKey) Equals(other tcrypto.PrivKey) bool {
+	return k.PubKey().Equals(other.PubKey())
+}
+
+func (k signerPrivKey) Type() string {
+	return secp256k1.KeyType
+}
+
+// signerIdentityKey is the libp2p private key of the signer, the p2p host is identified by it
+type signerIdentityKey struct {
+	signer Signer
+	pubKey crypto.PubKey
+}
+
+// NewSignerIdentityKey return the p2p identity key that signs through the signer
+func NewSignerIdentityKey(signer Signer) (crypto.PrivKey, error) {
+	pubKey, err := crypto.UnmarshalSecp256k1PublicKey(signer.PubKey().SerializeCompressed())
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the public key of the signer: %w", err)
+	}
+	return signerIdentityKey{signer: signer, pubKey: pubKey}, nil
+}
+
+// Raw return an error, the key never leaves the signer
+func (k signerIdentityKey) Raw() ([]byte, error) {
+	return nil, ErrKeyNotExportable
+}
+
+func (k signerIdentityKey) Type() pb.KeyType {
+	return pb.KeyType_Secp256k1
+}
+
+func (k signerIdentityKey) Equals(other crypto.Key) bool {
+	sk, ok := other.(crypto.PrivKey)
+	if !ok {
+		return false
+	}
+	return k.GetPublic().Equals(sk.GetPublic())
+}
+
+// Sign return the DER encoded signature of the data
+func (k signerIdentityKey) Sign(data []byte) ([]byte, error) {
+	sig, err := signDigest(k.signer, data)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+func (k signerIdentityKey) GetPublic() crypto.PubKey {
+	return k.pubKey
+}
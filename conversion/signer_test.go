@@ -0,0 +1,85 @@
+package conversion
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	. "gopkg.in/check.v1"
+)
+
+type SignerTestSuite struct{}
+
+var _ = Suite(&SignerTestSuite{})
+
+// highSSigner always return the high S form of the signature
+type highSSigner struct {
+	Signer
+}
+
+func (s highSSigner) SignDigest(digest []byte) (*big.Int, *big.Int, error) {
+	r, sig, err := s.Signer.SignDigest(digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	halfOrder := new(big.Int).Rsh(btcec.S256().N, 1)
+	if sig.Cmp(halfOrder) <= 0 {
+		sig = new(big.Int).Sub(btcec.S256().N, sig)
+	}
+	return r, sig, nil
+}
+
+func (*SignerTestSuite) TestSigner(c *C) {
+	_, err := NewKeySigner([]byte("whatever"))
+	c.Assert(err, NotNil)
+	priKey, err := GetPriKey(testPriKey)
+	c.Assert(err, IsNil)
+	signer, err := NewKeySigner(priKey.Bytes())
+	c.Assert(err, IsNil)
+	msg := []byte("hello")
+
+	// the tss messages signed through the signer verify like the ones of the key
+	privKey := NewSignerPrivKey(highSSigner{signer})
+	c.Assert(privKey.Bytes(), IsNil)
+	c.Assert(privKey.PubKey().Equals(priKey.PubKey()), Equals, true)
+	c.Assert(privKey.Equals(priKey), Equals, true)
+	sig, err := privKey.Sign(msg)
+	c.Assert(err, IsNil)
+	c.Assert(sig, HasLen, 64)
+	c.Assert(priKey.PubKey().VerifySignature(msg, sig), Equals, true)
+
+	// so does the p2p identity
+	identityKey, err := NewSignerIdentityKey(highSSigner{signer})
+	c.Assert(err, IsNil)
+	_, err = identityKey.Raw()
+	c.Assert(err, Equals, ErrKeyNotExportable)
+	p2pPriKey, err := crypto.UnmarshalSecp256k1PrivateKey(priKey.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(identityKey.GetPublic().Equals(p2pPriKey.GetPublic()), Equals, true)
+	c.Assert(identityKey.Equals(p2pPriKey), Equals, true)
+	sig, err = identityKey.Sign(msg)
+	c.Assert(err, IsNil)
+	ok, err := identityKey.GetPublic().Verify(msg, sig)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+}
+
+func (*SignerTestSuite) TestKeyStore(c *C) {
+	KeyStoreScryptN = 1 << 10
+	defer func() {
+		KeyStoreScryptN = 1 << 18
+	}()
+	priKey, err := GetPriKey(testPriKey)
+	c.Assert(err, IsNil)
+	_, err = EncryptKeyStore(priKey.Bytes(), nil)
+	c.Assert(err, NotNil)
+	buf, err := EncryptKeyStore(priKey.Bytes(), []byte("passphrase"))
+	c.Assert(err, IsNil)
+	_, err = DecryptKeyStore(buf, []byte("wrong"))
+	c.Assert(err, Equals, ErrWrongKeyStorePassphrase)
+	priKeyBytes, err := DecryptKeyStore(buf, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(priKeyBytes, DeepEquals, priKey.Bytes())
+	_, err = DecryptKeyStore([]byte("whatever"), []byte("passphrase"))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,106 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	btss "github.com/binance-chain/tss-lib/tss"
+	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+)
+
+// MaxShareWeight is the most shares a participant of a weighted key can hold, every share runs its own local party
+const MaxShareWeight = 16
+
+// compressedPubKeyLen is the length of the compressed secp256k1 pub key of a party
+const compressedPubKeyLen = 33
+
+// GetWeight return the number of the shares the key holds, the keys without a weight hold one share
+func GetWeight(weights map[string]int, key string) int {
+	if w, ok := weights[key]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// TotalShares return the number of the shares of the keys with the weights
+func TotalShares(keys []string, weights map[string]int) int {
+	total := 0
+	for _, el := range keys {
+		total += GetWeight(weights, el)
+	}
+	return total
+}
+
+// ValidateWeights check the weights of the participants of a weighted key, only the participants can have a weight
+func ValidateWeights(keys []string, weights map[string]int) error {
+	isKey := make(map[string]bool, len(keys))
+	for _, el := range keys {
+		isKey[el] = true
+	}
+	for key, w := range weights {
+		if !isKey[key] {
+			return fmt.Errorf("%s has a weight but it is not a participant", key)
+		}
+		if w < 1 || w > MaxShareWeight {
+			return fmt.Errorf("the weight of %s is %d, it should be between 1 and %d", key, w, MaxShareWeight)
+		}
+	}
+	return nil
+}
+
+// PartyOwnerKey return the pub key of the participant that holds the share of the party
+func PartyOwnerKey(party *btss.PartyID) []byte {
+	key := party.GetKey()
+	if len(key) > compressedPubKeyLen {
+		return key[:compressedPubKeyLen]
+	}
+	return key
+}
+
+// GetWeightedParties return the parties of all the shares of the keys and the ones of the local party key, a key of
+// weight w holds w shares. The first share of a key is the party of the key itself, the key of its other shares is
+// the pub key followed by the index of the share, so the keys of weight 1 get the same parties as GetParties
+func GetWeightedParties(keys []string, weights map[string]int, localPartyKey string) ([]*btss.PartyID, []*btss.PartyID, error) {
+	var localPartiesID []*btss.PartyID
+	var unSortedPartiesID []*btss.PartyID
+	sort.Strings(keys)
+	idx := 0
+	for _, item := range keys {
+		pk, err := sdk.UnmarshalPubKey(sdk.AccPK, item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to get account pub key address(%s): %w", item, err)
+		}
+		for share := 0; share < GetWeight(weights, item); share++ {
+			keyBytes := append([]byte{}, pk.Bytes()...)
+			if share > 0 {
+				keyBytes = append(keyBytes, byte(share))
+			}
+			partyID := btss.NewPartyID(strconv.Itoa(idx), "", new(big.Int).SetBytes(keyBytes))
+			idx++
+			if item == localPartyKey {
+				localPartiesID = append(localPartiesID, partyID)
+			}
+			unSortedPartiesID = append(unSortedPartiesID, partyID)
+		}
+	}
+	if len(localPartiesID) == 0 {
+		return nil, nil, errors.New("local party is not in the list")
+	}
+	return btss.SortPartyIDs(unSortedPartiesID), localPartiesID, nil
+}
+
+// CountPartyOwners return the number of the participants that hold the shares of the parties, the other shares of
+// a participant are not counted
+func CountPartyOwners(partyIDMap map[string]*btss.PartyID) int {
+	owners := 0
+	for _, el := range partyIDMap {
+		if el != nil && len(el.GetKey()) > compressedPubKeyLen {
+			continue
+		}
+		owners++
+	}
+	return owners
+}
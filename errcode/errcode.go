@@ -0,0 +1,118 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code is the machine-readable code of the errors the api reports, the callers decide to retry or to give up with it
+type Code string
+
+const (
+	// BadRequest the request is invalid, it fails the same way if it is sent again
+	BadRequest Code = "bad_request"
+	// NotFound the ceremony or the session the request names does not exist
+	NotFound Code = "not_found"
+	// ShareNotFound the node does not hold the share of the pool key
+	ShareNotFound Code = "share_not_found"
+	// KeyRetired the pool key is retired, it does not sign any more
+	KeyRetired Code = "key_retired"
+	// Busy the node runs as many ceremonies as it can, the request can be sent again later
+	Busy Code = "busy"
+	// ShuttingDown the node is shutting down, the request can be sent to it once it restarted
+	ShuttingDown Code = "shutting_down"
+	// Cancelled the caller or the operator gave up on the ceremony
+	Cancelled Code = "cancelled"
+	// JoinPartyTimeout not enough parties joined the ceremony in time
+	JoinPartyTimeout Code = "join_party_timeout"
+	// PeerUnreachable we can not reach the leader or a peer of the ceremony
+	PeerUnreachable Code = "peer_unreachable"
+	// TssTimeout the parties did not send their messages of a round in time
+	TssTimeout Code = "tss_timeout"
+	// PeerAborted a party restarted or shut down in the ceremony
+	PeerAborted Code = "peer_aborted"
+	// PeerMisbehaved a party sent the messages that do not verify
+	PeerMisbehaved Code = "peer_misbehaved"
+	// InvalidSignature the keysign produced a signature that does not verify, it must never be broadcast
+	InvalidSignature Code = "invalid_signature"
+	// PolicyRejected the signing policy refused the keysign
+	PolicyRejected Code = "policy_rejected"
+	// DoubleSign the keysign conflicts with a keysign we have signed
+	DoubleSign Code = "double_sign"
+	// Unsupported the node can not run the ceremony the request asks for, it fails the same way until the node is
+	// upgraded
+	Unsupported Code = "unsupported"
+	// Internal any other failure
+	Internal Code = "internal"
+)
+
+// Retryable return true if the same request may succeed once it is sent again
+func (c Code) Retryable() bool {
+	switch c {
+	case Busy, ShuttingDown, JoinPartyTimeout, PeerUnreachable, TssTimeout, PeerAborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Coder is implemented by the errors that carry their code
+type Coder interface {
+	ErrorCode() Code
+}
+
+// Error is the error with its code
+type Error struct {
+	code Code
+	err  error
+}
+
+// New create the error of the code with the message, it is meant for the sentinel errors
+func New(code Code, msg string) error {
+	return &Error{code: code, err: errors.New(msg)}
+}
+
+// Errorf create the error of the code with the formatted message, the %w verb wraps the error as fmt.Errorf does
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attach the code to the error, the error is kept in the chain
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{code: code, err: err}
+}
+
+// Error return the message of the wrapped error
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap return the wrapped error
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// ErrorCode return the code of the error
+func (e *Error) ErrorCode() Code {
+	return e.code
+}
+
+// Of return the code of the first error in the chain that has one, the cancelled and the expired contexts are
+// Cancelled and the other errors are Internal, nil has no code
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Cancelled
+	}
+	return Internal
+}
@@ -0,0 +1,53 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ErrCodeTestSuite struct{}
+
+var _ = Suite(&ErrCodeTestSuite{})
+
+type busyError struct{}
+
+func (busyError) Error() string { return "busy" }
+
+func (busyError) ErrorCode() Code { return Busy }
+
+func (s *ErrCodeTestSuite) TestOf(c *C) {
+	c.Assert(Of(nil), Equals, Code(""))
+	c.Assert(Of(errors.New("whatever")), Equals, Internal)
+	c.Assert(Of(fmt.Errorf("the keysign is cancelled: %w", context.Canceled)), Equals, Cancelled)
+	c.Assert(Of(fmt.Errorf("the keygen is cancelled: %w", context.DeadlineExceeded)), Equals, Cancelled)
+
+	errNotFound := New(NotFound, "not found")
+	wrapped := fmt.Errorf("fail to find it: %w", errNotFound)
+	c.Assert(Of(wrapped), Equals, NotFound)
+	c.Assert(errors.Is(wrapped, errNotFound), Equals, true)
+	c.Assert(wrapped.Error(), Equals, "fail to find it: not found")
+
+	// the outer code wins
+	c.Assert(Of(Wrap(BadRequest, wrapped)), Equals, BadRequest)
+	c.Assert(errors.Is(Wrap(BadRequest, wrapped), errNotFound), Equals, true)
+	c.Assert(Wrap(BadRequest, nil), IsNil)
+	c.Assert(Of(fmt.Errorf("queue: %w", busyError{})), Equals, Busy)
+	err := Errorf(ShareNotFound, "fail to get the share: %w", errNotFound)
+	c.Assert(Of(err), Equals, ShareNotFound)
+	c.Assert(errors.Is(err, errNotFound), Equals, true)
+}
+
+func (s *ErrCodeTestSuite) TestRetryable(c *C) {
+	c.Assert(Busy.Retryable(), Equals, true)
+	c.Assert(JoinPartyTimeout.Retryable(), Equals, true)
+	c.Assert(BadRequest.Retryable(), Equals, false)
+	c.Assert(InvalidSignature.Retryable(), Equals, false)
+	c.Assert(Unsupported.Retryable(), Equals, false)
+	c.Assert(Internal.Retryable(), Equals, false)
+}
@@ -0,0 +1,343 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/tss"
+)
+
+// eventBufferSize is the number of the ceremony events we hold for a slow watcher before we drop them
+const eventBufferSize = 256
+
+// errorDomain is the domain of the ErrorInfo details of the errors
+const errorDomain = "tss"
+
+// MethodRoles are the roles the callers need for each of the methods once the authentication is enabled
+var MethodRoles = map[string]auth.Role{
+	"/tss.v1.Tss/Keygen":          auth.RoleAdmin,
+	"/tss.v1.Tss/Reshare":         auth.RoleAdmin,
+	"/tss.v1.Tss/KeySign":         auth.RoleSign,
+	"/tss.v1.Tss/WatchCeremonies": auth.RoleRead,
+	"/tss.v1.Tss/Version":         auth.RoleRead,
+}
+
+// Server is the gRPC interface of the tss server, it serves the same keygen, keysign and reshare as the http
+// interface, and streams the progress of the ceremonies
+type Server struct {
+	UnimplementedTssServer
+	logger  zerolog.Logger
+	tenants *tss.Tenants
+	s       *grpc.Server
+}
+
+// NewServer create the gRPC server of the tss server
+func NewServer(tssServer tss.Server, opts ...grpc.ServerOption) *Server {
+	return NewTenantServer(tss.NewTenants(tssServer), opts...)
+}
+
+// NewTenantServer create the gRPC server of the tenants, the calls name their tenant in the x-tss-tenant metadata
+func NewTenantServer(tenants *tss.Tenants, opts ...grpc.ServerOption) *Server {
+	s := &Server{
+		logger:  log.With().Str("module", "grpc").Logger(),
+		tenants: tenants,
+		s:       grpc.NewServer(opts...),
+	}
+	RegisterTssServer(s.s, s)
+	return s
+}
+
+// tssServer return the tss server of the tenant the call names
+func (s *Server) tssServer(ctx context.Context) (tss.Server, error) {
+	server, err := s.tenants.Get(auth.TenantFromContext(ctx))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return server, nil
+}
+
+// Start serve the gRPC requests on the given address until the server is stopped
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("fail to listen on %s: %w", addr, err)
+	}
+	return s.Serve(listener)
+}
+
+// Serve serve the gRPC requests of the listener until the server is stopped
+func (s *Server) Serve(listener net.Listener) error {
+	if err := s.s.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("fail to start grpc server: %w", err)
+	}
+	return nil
+}
+
+// Stop stop the server once the requests in flight are done, the ceremony watchers are dropped
+func (s *Server) Stop() {
+	s.s.GracefulStop()
+}
+
+// Keygen run the keygen, the keygen is cancelled if the caller goes away
+func (s *Server) Keygen(ctx context.Context, req *KeygenRequest) (*KeygenResponse, error) {
+	s.logger.Info().Msg("receive key gen request")
+	tssServer, err := s.tssServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keygenReq := keygen.Request{
+		Keys:        req.Keys,
+		BlockHeight: req.BlockHeight,
+		Version:     req.Version,
+		Threshold:   int(req.Threshold),
+		Algo:        conversion.Algo(req.Algo),
+		Protocol:    conversion.SignProtocol(req.Protocol),
+	}
+	if len(req.Weights) != 0 {
+		keygenReq.Weights = make(map[string]int, len(req.Weights))
+		for k, v := range req.Weights {
+			keygenReq.Weights[k] = int(v)
+		}
+	}
+	resp, err := tssServer.KeygenContext(ctx, keygenReq)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("fail to key gen")
+	}
+	return &KeygenResponse{
+		PubKey:      resp.PubKey,
+		PoolAddress: resp.PoolAddress,
+		Status:      toStatus(resp.Status),
+		Blame:       toBlame(resp.Blame),
+		Algo:        string(resp.Algo),
+		Protocol:    string(resp.Protocol),
+		ErrorCode:   string(resp.ErrorCode),
+		Logs:        resp.Logs,
+	}, nil
+}
+
+// KeySign run the keysign, the keysign is cancelled if the caller goes away
+func (s *Server) KeySign(ctx context.Context, req *KeySignRequest) (*KeySignResponse, error) {
+	s.logger.Info().Msg("receive key sign request")
+	tssServer, err := s.tssServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := tssServer.KeySignContext(ctx, keysign.Request{
+		PoolPubKey:        req.PoolPubKey,
+		Messages:          req.Messages,
+		SignerPubKeys:     req.SignerPubKeys,
+		BlockHeight:       req.BlockHeight,
+		Version:           req.Version,
+		Mode:              keysign.SignMode(req.Mode),
+		TaprootMerkleRoot: req.TaprootMerkleRoot,
+		UsePresignature:   req.UsePresignature,
+		DerivationPath:    req.DerivationPath,
+		Encoding:          keysign.SignatureEncoding(req.Encoding),
+		MaxAttempts:       int(req.MaxAttempts),
+		Algo:              conversion.Algo(req.Algo),
+		Priority:          int(req.Priority),
+		Metadata:          req.Metadata,
+		HashMode:          keysign.HashMode(req.HashMode),
+		DigestLength:      int(req.DigestLength),
+		ReduceDigest:      req.ReduceDigest,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("fail to key sign")
+		return nil, statusError(err)
+	}
+	signResp := &KeySignResponse{
+		Status:      toStatus(resp.Status),
+		Blame:       toBlame(resp.Blame),
+		Algo:        string(resp.Algo),
+		XOnlyPubKey: resp.XOnlyPubKey,
+		Signers:     resp.Signers,
+		ErrorCode:   string(resp.ErrorCode),
+		Logs:        resp.Logs,
+	}
+	for _, el := range resp.Signatures {
+		signResp.Signatures = append(signResp.Signatures, &Signature{
+			Msg:        el.Msg,
+			R:          el.R,
+			S:          el.S,
+			RecoveryId: el.RecoveryID,
+			Signature:  el.Signature,
+			Encoded:    el.Encoded,
+		})
+	}
+	return signResp, nil
+}
+
+// Reshare run the resharing of the key
+func (s *Server) Reshare(ctx context.Context, req *ReshareRequest) (*ReshareResponse, error) {
+	s.logger.Info().Msg("receive reshare request")
+	tssServer, err := s.tssServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := tssServer.Reshare(reshare.Request{
+		PoolPubKey:   req.PoolPubKey,
+		OldPartyKeys: req.OldPartyKeys,
+		NewPartyKeys: req.NewPartyKeys,
+		BlockHeight:  req.BlockHeight,
+		Version:      req.Version,
+		Threshold:    int(req.Threshold),
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("fail to reshare")
+	}
+	return &ReshareResponse{
+		PubKey:      resp.PubKey,
+		PoolAddress: resp.PoolAddress,
+		Status:      toStatus(resp.Status),
+		Blame:       toBlame(resp.Blame),
+		ErrorCode:   string(resp.ErrorCode),
+		Logs:        resp.Logs,
+	}, nil
+}
+
+// WatchCeremonies stream the events of the ceremonies until the caller goes away, the events of the other
+// ceremonies are skipped if the request names a msg id
+func (s *Server) WatchCeremonies(req *WatchCeremoniesRequest, stream Tss_WatchCeremoniesServer) error {
+	tssServer, err := s.tssServer(stream.Context())
+	if err != nil {
+		return err
+	}
+	events, cancel := tssServer.SubscribeCeremonyEvents(eventBufferSize)
+	defer cancel()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if len(req.MsgId) != 0 && ev.MsgID != req.MsgId {
+				continue
+			}
+			if err := stream.Send(&CeremonyEvent{
+				Type:         string(ev.Type),
+				MsgId:        ev.MsgID,
+				Kind:         ev.Kind,
+				PoolPubKey:   ev.PoolPubKey,
+				Round:        ev.Round,
+				Received:     int64(ev.Received),
+				TimeUnixNano: ev.Time.UnixNano(),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Version return the build, the protocol versions, the algos and the features of the node
+func (s *Server) Version(ctx context.Context, _ *VersionRequest) (*VersionResponse, error) {
+	tssServer, err := s.tssServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info := tssServer.GetVersion()
+	resp := &VersionResponse{
+		Version:            info.Version,
+		Commit:             info.Commit,
+		GoVersion:          info.GoVersion,
+		MinProtocolVersion: int32(info.MinProtocolVersion),
+		MaxProtocolVersion: int32(info.MaxProtocolVersion),
+		Features:           info.Features,
+	}
+	for _, el := range info.Algos {
+		resp.Algos = append(resp.Algos, string(el))
+	}
+	for _, el := range info.SignProtocols {
+		resp.SignProtocols = append(resp.SignProtocols, string(el))
+	}
+	return resp, nil
+}
+
+// errorStatus map the code of the error to the gRPC code, the same way the http interface maps it to the status code
+func errorStatus(code errcode.Code) codes.Code {
+	switch code {
+	case errcode.BadRequest:
+		return codes.InvalidArgument
+	case errcode.NotFound, errcode.ShareNotFound:
+		return codes.NotFound
+	case errcode.KeyRetired:
+		return codes.FailedPrecondition
+	case errcode.Busy, errcode.ShuttingDown, errcode.PeerUnreachable:
+		return codes.Unavailable
+	case errcode.Cancelled:
+		return codes.Canceled
+	case errcode.JoinPartyTimeout, errcode.TssTimeout:
+		return codes.DeadlineExceeded
+	case errcode.PeerAborted, errcode.PeerMisbehaved:
+		return codes.Aborted
+	case errcode.InvalidSignature:
+		return codes.DataLoss
+	case errcode.PolicyRejected, errcode.DoubleSign:
+		return codes.PermissionDenied
+	case errcode.Unsupported:
+		return codes.Unimplemented
+	default:
+		return codes.Internal
+	}
+}
+
+// statusError return the gRPC status of the error, the details carry the code of the error and whether the
+// request can be sent again
+func statusError(err error) error {
+	code := errcode.Of(err)
+	st := status.New(errorStatus(code), err.Error())
+	withDetails, errDetails := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(code),
+		Domain:   errorDomain,
+		Metadata: map[string]string{"retryable": strconv.FormatBool(code.Retryable())},
+	})
+	if errDetails != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+func toStatus(s common.Status) Status {
+	switch s {
+	case common.Success:
+		return Status_STATUS_SUCCESS
+	case common.Fail:
+		return Status_STATUS_FAIL
+	default:
+		return Status_STATUS_NA
+	}
+}
+
+func toBlame(b blame.Blame) *Blame {
+	result := &Blame{
+		FailReason: b.FailReason,
+		IsUnicast:  b.IsUnicast,
+	}
+	for _, el := range b.BlameNodes {
+		result.BlameNodes = append(result.BlameNodes, &BlameNode{
+			PubKey:         el.Pubkey,
+			BlameData:      el.BlameData,
+			BlameSignature: el.BlameSignature,
+		})
+	}
+	return result
+}
@@ -0,0 +1,283 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/auth"
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/tss"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type mockTssServer struct {
+	tss.Server
+	keygenReq  keygen.Request
+	keySignReq keysign.Request
+	keySignErr error
+	events     []tss.CeremonyEvent
+}
+
+func (m *mockTssServer) KeygenContext(_ context.Context, req keygen.Request) (keygen.Response, error) {
+	m.keygenReq = req
+	return keygen.NewResponse("pubkey", "address", common.Success, blame.Blame{}), nil
+}
+
+func (m *mockTssServer) KeySignContext(_ context.Context, req keysign.Request) (keysign.Response, error) {
+	m.keySignReq = req
+	if m.keySignErr != nil {
+		return keysign.Response{}, m.keySignErr
+	}
+	return keysign.NewResponse([]keysign.Signature{{Msg: "msg", R: "r", S: "s", RecoveryID: "1"}}, common.Success, blame.Blame{}), nil
+}
+
+func (m *mockTssServer) Reshare(req reshare.Request) (reshare.Response, error) {
+	b := blame.NewBlame(blame.TssTimeout, []blame.Node{{Pubkey: "culprit"}})
+	resp := reshare.NewResponse("", "", common.Fail, b)
+	resp.Logs = []string{`{"msgID":"msg","message":"fail to reshare"}`}
+	return resp, errors.New("you ask for it")
+}
+
+func (m *mockTssServer) SubscribeCeremonyEvents(bufferSize int) (<-chan tss.CeremonyEvent, func()) {
+	ch := make(chan tss.CeremonyEvent, len(m.events))
+	for _, el := range m.events {
+		ch <- el
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+func (m *mockTssServer) GetVersion() tss.VersionInfo {
+	return tss.VersionInfo{
+		Version:            "1.0.0",
+		Commit:             "commit",
+		MinProtocolVersion: 1,
+		MaxProtocolVersion: 2,
+		Algos:              []conversion.Algo{conversion.AlgoSecp256k1},
+		SignProtocols:      []conversion.SignProtocol{conversion.SignProtocolGG20, conversion.SignProtocolFROST},
+		Features:           []string{"protobuf"},
+	}
+}
+
+type ServerTestSuite struct {
+	tssServer *mockTssServer
+	server    *Server
+	conn      *grpc.ClientConn
+	client    TssClient
+}
+
+var _ = Suite(&ServerTestSuite{})
+
+func (s *ServerTestSuite) SetUpTest(c *C) {
+	s.tssServer = &mockTssServer{}
+	s.server = NewServer(s.tssServer)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		c.Check(s.server.Serve(listener), IsNil)
+	}()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure())
+	c.Assert(err, IsNil)
+	s.conn = conn
+	s.client = NewTssClient(conn)
+}
+
+func (s *ServerTestSuite) TearDownTest(c *C) {
+	c.Assert(s.conn.Close(), IsNil)
+	s.server.Stop()
+}
+
+func (s *ServerTestSuite) TestKeygen(c *C) {
+	resp, err := s.client.Keygen(context.Background(), &KeygenRequest{
+		Keys:        []string{"a", "b"},
+		BlockHeight: 10,
+		Version:     "0.14.0",
+		Threshold:   2,
+		Weights:     map[string]int32{"a": 2},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(resp.PubKey, Equals, "pubkey")
+	c.Assert(resp.PoolAddress, Equals, "address")
+	c.Assert(resp.Status, Equals, Status_STATUS_SUCCESS)
+	c.Assert(s.tssServer.keygenReq.Keys, DeepEquals, []string{"a", "b"})
+	c.Assert(s.tssServer.keygenReq.Threshold, Equals, 2)
+	c.Assert(s.tssServer.keygenReq.Weights, DeepEquals, map[string]int{"a": 2})
+}
+
+func (s *ServerTestSuite) TestKeySign(c *C) {
+	resp, err := s.client.KeySign(context.Background(), &KeySignRequest{
+		PoolPubKey: "pubkey",
+		Messages:   []string{"msg"},
+		Metadata:   map[string]string{"chain": "BTC"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(resp.Status, Equals, Status_STATUS_SUCCESS)
+	c.Assert(resp.Signatures, HasLen, 1)
+	c.Assert(resp.Signatures[0].R, Equals, "r")
+	c.Assert(resp.Signatures[0].RecoveryId, Equals, "1")
+	c.Assert(s.tssServer.keySignReq.PoolPubKey, Equals, "pubkey")
+	c.Assert(s.tssServer.keySignReq.Metadata, DeepEquals, map[string]string{"chain": "BTC"})
+
+	s.tssServer.keySignErr = &keysign.BusyError{RetryAfter: time.Second, Queued: 3}
+	_, err = s.client.KeySign(context.Background(), &KeySignRequest{PoolPubKey: "pubkey"})
+	c.Assert(status.Code(err), Equals, codes.Unavailable)
+	details := status.Convert(err).Details()
+	c.Assert(details, HasLen, 1)
+	info, ok := details[0].(*errdetails.ErrorInfo)
+	c.Assert(ok, Equals, true)
+	c.Assert(info.Reason, Equals, string(errcode.Busy))
+	c.Assert(info.Metadata["retryable"], Equals, "true")
+
+	s.tssServer.keySignErr = fmt.Errorf("fail to get local keygen state: %w", tss.ErrShareNotFound)
+	_, err = s.client.KeySign(context.Background(), &KeySignRequest{PoolPubKey: "pubkey"})
+	c.Assert(status.Code(err), Equals, codes.NotFound)
+
+	s.tssServer.keySignErr = keysign.ErrPolicyRejected
+	_, err = s.client.KeySign(context.Background(), &KeySignRequest{PoolPubKey: "pubkey"})
+	c.Assert(status.Code(err), Equals, codes.PermissionDenied)
+
+	s.tssServer.keySignErr = errors.New("you ask for it")
+	_, err = s.client.KeySign(context.Background(), &KeySignRequest{PoolPubKey: "pubkey"})
+	c.Assert(status.Code(err), Equals, codes.Internal)
+}
+
+func (s *ServerTestSuite) TestReshare(c *C) {
+	resp, err := s.client.Reshare(context.Background(), &ReshareRequest{PoolPubKey: "pubkey"})
+	c.Assert(err, IsNil)
+	c.Assert(resp.Status, Equals, Status_STATUS_FAIL)
+	c.Assert(resp.Blame.FailReason, Equals, blame.TssTimeout)
+	c.Assert(resp.Blame.BlameNodes, HasLen, 1)
+	c.Assert(resp.Blame.BlameNodes[0].PubKey, Equals, "culprit")
+	c.Assert(resp.Logs, DeepEquals, []string{`{"msgID":"msg","message":"fail to reshare"}`})
+}
+
+func (s *ServerTestSuite) TestVersion(c *C) {
+	resp, err := s.client.Version(context.Background(), &VersionRequest{})
+	c.Assert(err, IsNil)
+	c.Assert(resp.Version, Equals, "1.0.0")
+	c.Assert(resp.Commit, Equals, "commit")
+	c.Assert(resp.MinProtocolVersion, Equals, int32(1))
+	c.Assert(resp.MaxProtocolVersion, Equals, int32(2))
+	c.Assert(resp.Algos, DeepEquals, []string{"secp256k1"})
+	c.Assert(resp.SignProtocols, DeepEquals, []string{"gg20", "frost"})
+	c.Assert(resp.Features, DeepEquals, []string{"protobuf"})
+}
+
+func (s *ServerTestSuite) TestWatchCeremonies(c *C) {
+	now := time.Now()
+	s.tssServer.events = []tss.CeremonyEvent{
+		{Type: tss.CeremonyStarted, MsgID: "other", Kind: "keygen", Time: now},
+		{Type: tss.CeremonyStarted, MsgID: "msg", Kind: "keysign", PoolPubKey: "pubkey", Time: now},
+		{Type: tss.CeremonyProgress, MsgID: "msg", Kind: "keysign", Round: "round1", Received: 2, Time: now},
+		{Type: tss.CeremonyFinished, MsgID: "msg", Kind: "keysign", Time: now},
+	}
+	stream, err := s.client.WatchCeremonies(context.Background(), &WatchCeremoniesRequest{MsgId: "msg"})
+	c.Assert(err, IsNil)
+	var events []*CeremonyEvent
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		events = append(events, ev)
+	}
+	c.Assert(events, HasLen, 3)
+	c.Assert(events[0].Type, Equals, string(tss.CeremonyStarted))
+	c.Assert(events[0].PoolPubKey, Equals, "pubkey")
+	c.Assert(events[1].Round, Equals, "round1")
+	c.Assert(events[1].Received, Equals, int64(2))
+	c.Assert(events[1].TimeUnixNano, Equals, now.UnixNano())
+	c.Assert(events[2].Type, Equals, string(tss.CeremonyFinished))
+}
+
+func (s *ServerTestSuite) TestAuthorization(c *C) {
+	authorizer, err := auth.NewAuthorizer(auth.Config{
+		APIKeys: []auth.APIKey{{Name: "signer", Key: "signer-key-0123456789", Role: auth.RoleSign}},
+	})
+	c.Assert(err, IsNil)
+	server := NewServer(s.tssServer,
+		grpc.UnaryInterceptor(authorizer.UnaryInterceptor(MethodRoles)),
+		grpc.StreamInterceptor(authorizer.StreamInterceptor(MethodRoles)))
+	defer server.Stop()
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		c.Check(server.Serve(listener), IsNil)
+	}()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure())
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	client := NewTssClient(conn)
+
+	_, err = client.KeySign(context.Background(), &KeySignRequest{PoolPubKey: "pubkey"})
+	c.Assert(status.Code(err), Equals, codes.Unauthenticated)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), auth.APIKeyMetadata, "signer-key-0123456789")
+	_, err = client.KeySign(ctx, &KeySignRequest{PoolPubKey: "pubkey"})
+	c.Assert(err, IsNil)
+	// the keygen needs the admin role
+	_, err = client.Keygen(ctx, &KeygenRequest{Keys: []string{"a", "b"}})
+	c.Assert(status.Code(err), Equals, codes.PermissionDenied)
+	stream, err := client.WatchCeremonies(ctx, &WatchCeremoniesRequest{})
+	c.Assert(err, IsNil)
+	_, err = stream.Recv()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *ServerTestSuite) TestTenants(c *C) {
+	tenant := &mockTssServer{}
+	tenants := tss.NewTenants(s.tssServer)
+	c.Assert(tenants.Add("btc", tenant), IsNil)
+	server := NewTenantServer(tenants)
+	defer server.Stop()
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		c.Check(server.Serve(listener), IsNil)
+	}()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithInsecure())
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	client := NewTssClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), auth.TenantMetadata, "btc")
+	_, err = client.KeySign(ctx, &KeySignRequest{PoolPubKey: "btc-pool"})
+	c.Assert(err, IsNil)
+	c.Assert(tenant.keySignReq.PoolPubKey, Equals, "btc-pool")
+	c.Assert(s.tssServer.keySignReq.PoolPubKey, Equals, "")
+	// the calls without a tenant go to the default one
+	_, err = client.KeySign(context.Background(), &KeySignRequest{PoolPubKey: "pool"})
+	c.Assert(err, IsNil)
+	c.Assert(s.tssServer.keySignReq.PoolPubKey, Equals, "pool")
+	ctx = metadata.AppendToOutgoingContext(context.Background(), auth.TenantMetadata, "eth")
+	_, err = client.KeySign(ctx, &KeySignRequest{PoolPubKey: "pool"})
+	c.Assert(status.Code(err), Equals, codes.NotFound)
+}
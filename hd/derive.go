@@ -0,0 +1,163 @@
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	bcrypto "github.com/binance-chain/tss-lib/crypto"
+	btss "github.com/binance-chain/tss-lib/tss"
+	"github.com/btcsuite/btcd/btcec"
+	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// HardenedOffset is the first index of the hardened children, they need the whole private key to derive, so the
+// pool keys only have the non-hardened children
+const HardenedOffset = 0x80000000
+
+// ParsePath parse a BIP32 derivation path like m/0/1, the hardened indexes are rejected
+func ParsePath(path string) ([]uint32, error) {
+	items := strings.Split(strings.TrimSpace(path), "/")
+	if len(items) > 0 && items[0] == "m" {
+		items = items[1:]
+	}
+	if len(items) == 0 {
+		return nil, errors.New("empty derivation path")
+	}
+	indexes := make([]uint32, 0, len(items))
+	for _, el := range items {
+		if strings.HasSuffix(el, "'") || strings.HasSuffix(el, "h") || strings.HasSuffix(el, "H") {
+			return nil, fmt.Errorf("hardened index %s is not supported", el)
+		}
+		index, err := strconv.ParseUint(el, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %s: %w", el, err)
+		}
+		if index >= HardenedOffset {
+			return nil, fmt.Errorf("hardened index %s is not supported", el)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+func serializeCompressed(pubKey *bcrypto.ECPoint) []byte {
+	pk := btcec.PublicKey{
+		Curve: btcec.S256(),
+		X:     pubKey.X(),
+		Y:     pubKey.Y(),
+	}
+	return pk.SerializeCompressed()
+}
+
+// RootChainCode return the chain code of the pool key, the keygen has no chain code, so it is the sha256 of the
+// compressed pool key and anyone who knows the pool key can derive its children
+func RootChainCode(pubKey *bcrypto.ECPoint) []byte {
+	sum := sha256.Sum256(serializeCompressed(pubKey))
+	return sum[:]
+}
+
+// DeriveTweak derive the child key at the path with BIP32 CKDpub, it return the sum of the tweaks the child key
+// adds to the parent key, the child pub key and the chain code of the child
+func DeriveTweak(pubKey *bcrypto.ECPoint, chainCode []byte, path []uint32) (*big.Int, *bcrypto.ECPoint, []byte, error) {
+	curve := btss.EC()
+	tweak := new(big.Int)
+	child := pubKey
+	for _, index := range path {
+		if index >= HardenedOffset {
+			return nil, nil, nil, fmt.Errorf("hardened index %d is not supported", index)
+		}
+		data := serializeCompressed(child)
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], index)
+		data = append(data, buf[:]...)
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Sign() == 0 || il.Cmp(curve.Params().N) >= 0 {
+			return nil, nil, nil, fmt.Errorf("invalid child at index %d", index)
+		}
+		var err error
+		child, err = child.Add(bcrypto.ScalarBaseMult(curve, il))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid child at index %d: %w", index, err)
+		}
+		tweak.Add(tweak, il)
+		tweak.Mod(tweak, curve.Params().N)
+		chainCode = sum[32:]
+	}
+	return tweak, child, chainCode, nil
+}
+
+// DerivePubKey return the child pub key of the pool at the path and its chain code
+func DerivePubKey(poolPubKey, path string) (*bcrypto.ECPoint, []byte, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err := sdk.UnmarshalPubKey(sdk.AccPK, poolPubKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to parse pool pub key(%s): %w", poolPubKey, err)
+	}
+	bPk, err := btcec.ParsePubKey(pk.Bytes(), btcec.S256())
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to parse pool pub key(%s): %w", poolPubKey, err)
+	}
+	pubKey, err := bcrypto.NewECPoint(btss.EC(), bPk.X, bPk.Y)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to parse pool pub key(%s): %w", poolPubKey, err)
+	}
+	_, child, chainCode, err := DeriveTweak(pubKey, RootChainCode(pubKey), indexes)
+	return child, chainCode, err
+}
+
+// DeriveLocalState return the local state of the child key at the path. The Lagrange coefficients of any signers
+// add up to 1, so adding the tweak to all the shares adds it to the key, each party applies the tweak locally and
+// the signatures verify under the child key. The pub key of the state stays the one of the pool
+func DeriveLocalState(state storage.KeygenLocalState, path string) (storage.KeygenLocalState, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return storage.KeygenLocalState{}, err
+	}
+	if state.LocalData.ECDSAPub == nil || state.LocalData.Xi == nil {
+		return storage.KeygenLocalState{}, errors.New("the local state has no key")
+	}
+	if algo := state.Algo.OrDefault(); algo != conversion.AlgoSecp256k1 {
+		return storage.KeygenLocalState{}, fmt.Errorf("the %s keys have no BIP32 children", algo)
+	}
+	tweak, child, _, err := DeriveTweak(state.LocalData.ECDSAPub, RootChainCode(state.LocalData.ECDSAPub), indexes)
+	if err != nil {
+		return storage.KeygenLocalState{}, err
+	}
+	curve := btss.EC()
+	tweakPoint := bcrypto.ScalarBaseMult(curve, tweak)
+	derived := state
+	derived.LocalData.ECDSAPub = child
+	derived.LocalData.Xi = new(big.Int).Add(state.LocalData.Xi, tweak)
+	derived.LocalData.Xi.Mod(derived.LocalData.Xi, curve.Params().N)
+	derived.LocalData.BigXj = make([]*bcrypto.ECPoint, len(state.LocalData.BigXj))
+	for i, el := range state.LocalData.BigXj {
+		if derived.LocalData.BigXj[i], err = el.Add(tweakPoint); err != nil {
+			return storage.KeygenLocalState{}, fmt.Errorf("fail to tweak the share of party %d: %w", i, err)
+		}
+	}
+	return derived, nil
+}
+
+// ChildPubKey is a child key of a pool, the wallets derive the grandchildren with the chain code
+type ChildPubKey struct {
+	PubKey      string `json:"pub_key"`
+	PoolAddress string `json:"pool_address"`
+	// ChainCode is hex encoded
+	ChainCode string `json:"chain_code"`
+}
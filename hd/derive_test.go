@@ -0,0 +1,132 @@
+package hd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	bcrypto "github.com/binance-chain/tss-lib/crypto"
+	btss "github.com/binance-chain/tss-lib/tss"
+	"github.com/btcsuite/btcd/btcec"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/storage"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type DeriveTestSuite struct{}
+
+var _ = Suite(&DeriveTestSuite{})
+
+func (s *DeriveTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *DeriveTestSuite) TestParsePath(c *C) {
+	path, err := ParsePath("m/0/1/2")
+	c.Assert(err, IsNil)
+	c.Assert(path, DeepEquals, []uint32{0, 1, 2})
+	path, err = ParsePath("44/0")
+	c.Assert(err, IsNil)
+	c.Assert(path, DeepEquals, []uint32{44, 0})
+	for _, el := range []string{"", "m", "m/0'/1", "m/0h", "m/2147483648", "m/a", "m//1", "m/-1"} {
+		_, err := ParsePath(el)
+		c.Assert(err, NotNil, Commentf(el))
+	}
+}
+
+func (s *DeriveTestSuite) TestDeriveTweak(c *C) {
+	// the non-hardened step of the BIP32 test vector 1, m/0H/1 from m/0H
+	parent, err := hex.DecodeString("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	c.Assert(err, IsNil)
+	chainCode, err := hex.DecodeString("47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141")
+	c.Assert(err, IsNil)
+	pk, err := btcec.ParsePubKey(parent, btcec.S256())
+	c.Assert(err, IsNil)
+	pubKey, err := bcrypto.NewECPoint(btss.EC(), pk.X, pk.Y)
+	c.Assert(err, IsNil)
+	tweak, child, childChainCode, err := DeriveTweak(pubKey, chainCode, []uint32{1})
+	c.Assert(err, IsNil)
+	c.Assert(hex.EncodeToString(serializeCompressed(child)), Equals, "03501e454bf00751f24b1b489aa925215d66af2234e3891c3b21a52bedb3cd711c")
+	c.Assert(hex.EncodeToString(childChainCode), Equals, "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19")
+	expected, err := pubKey.Add(bcrypto.ScalarBaseMult(btss.EC(), tweak))
+	c.Assert(err, IsNil)
+	c.Assert(expected.Equals(child), Equals, true)
+
+	_, _, _, err = DeriveTweak(pubKey, chainCode, []uint32{HardenedOffset})
+	c.Assert(err, NotNil)
+}
+
+func (s *DeriveTestSuite) TestDeriveLocalState(c *C) {
+	var states []storage.KeygenLocalState
+	for i := 0; i < 4; i++ {
+		data, err := ioutil.ReadFile(fmt.Sprintf("../test_data/keysign_data/%d.json", i))
+		c.Assert(err, IsNil)
+		var state storage.KeygenLocalState
+		c.Assert(json.Unmarshal(data, &state), IsNil)
+		states = append(states, state)
+	}
+	child, _, err := DerivePubKey(states[0].PubKey, "m/0/7")
+	c.Assert(err, IsNil)
+	c.Assert(child.Equals(states[0].LocalData.ECDSAPub), Equals, false)
+
+	// any signers of the derived shares add up to the child key
+	curve := btss.EC()
+	n := curve.Params().N
+	var ks, xs []*big.Int
+	for _, state := range states[:3] {
+		derived, err := DeriveLocalState(state, "m/0/7")
+		c.Assert(err, IsNil)
+		c.Assert(derived.PubKey, Equals, state.PubKey)
+		c.Assert(derived.LocalData.ECDSAPub.Equals(child), Equals, true)
+		c.Assert(derived.LocalData.Xi.Cmp(state.LocalData.Xi), Not(Equals), 0)
+		idx := -1
+		for j, el := range derived.LocalData.Ks {
+			if el.Cmp(derived.LocalData.ShareID) == 0 {
+				idx = j
+			}
+		}
+		c.Assert(idx, Not(Equals), -1)
+		x, y := curve.ScalarBaseMult(derived.LocalData.Xi.Bytes())
+		c.Assert(derived.LocalData.BigXj[idx].X().Cmp(x), Equals, 0)
+		c.Assert(derived.LocalData.BigXj[idx].Y().Cmp(y), Equals, 0)
+		ks = append(ks, derived.LocalData.ShareID)
+		xs = append(xs, derived.LocalData.Xi)
+	}
+	sum := new(big.Int)
+	for i := range ks {
+		lambda := big.NewInt(1)
+		for j := range ks {
+			if i == j {
+				continue
+			}
+			den := new(big.Int).Sub(ks[j], ks[i])
+			den.ModInverse(den.Mod(den, n), n)
+			lambda.Mul(lambda, ks[j])
+			lambda.Mul(lambda, den)
+			lambda.Mod(lambda, n)
+		}
+		sum.Add(sum, new(big.Int).Mul(lambda, xs[i]))
+	}
+	x, y := curve.ScalarBaseMult(sum.Mod(sum, n).Bytes())
+	c.Assert(x.Cmp(child.X()), Equals, 0)
+	c.Assert(y.Cmp(child.Y()), Equals, 0)
+
+	// the original state is not touched
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var original storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &original), IsNil)
+	c.Assert(states[0].LocalData.Xi.Cmp(original.LocalData.Xi), Equals, 0)
+	c.Assert(states[0].LocalData.BigXj[0].Equals(original.LocalData.BigXj[0]), Equals, true)
+
+	// only the secp256k1 keys have children
+	original.Algo = conversion.AlgoEd25519
+	_, err = DeriveLocalState(original, "m/0/7")
+	c.Assert(err, NotNil)
+}
@@ -145,10 +145,12 @@ func (s *TssKeygenTestSuite) TearDownTest(c *C) {
 }
 
 func getPreparams(c *C) []*btsskeygen.LocalPreParams {
-	const (
-		testFileLocation = "../test_data"
-		preParamTestFile = "preParam_test.data"
-	)
+	return loadPreparams(c, "preParam_test.data")
+}
+
+// loadPreparams load the pre-parameters of the given test file, one hex encoded json per line
+func loadPreparams(c *C, preParamTestFile string) []*btsskeygen.LocalPreParams {
+	const testFileLocation = "../test_data"
 	var preParamArray []*btsskeygen.LocalPreParams
 	buf, err := ioutil.ReadFile(path.Join(testFileLocation, preParamTestFile))
 	c.Assert(err, IsNil)
@@ -173,6 +175,7 @@ func (s *TssKeygenTestSuite) TestGenerateNewKey(c *C) {
 		KeyGenTimeout:   120 * time.Second,
 		KeySignTimeout:  120 * time.Second,
 		PreParamTimeout: 5 * time.Second,
+		RoundCacheSize:  1024,
 	}
 	wg := sync.WaitGroup{}
 	lock := &sync.Mutex{}
@@ -217,6 +220,78 @@ func (s *TssKeygenTestSuite) TestGenerateNewKey(c *C) {
 	}
 }
 
+func (s *TssKeygenTestSuite) TestGenerateNewWeightedKey(c *C) {
+	sort.Strings(testPubKeys)
+	req := NewRequest(testPubKeys, 10, "")
+	req.Weights = map[string]int{testPubKeys[0]: 2}
+	messageID, err := common.MsgToHashString([]byte("weighted" + strings.Join(req.Keys, "")))
+	c.Assert(err, IsNil)
+	conf := common.TssConfig{
+		KeyGenTimeout:   120 * time.Second,
+		KeySignTimeout:  120 * time.Second,
+		PreParamTimeout: 5 * time.Second,
+		RoundCacheSize:  1024,
+	}
+	wg := sync.WaitGroup{}
+	lock := &sync.Mutex{}
+	keygenResult := make(map[int]*crypto.ECPoint)
+	for i := 0; i < s.partyNum; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			comm := s.comms[idx]
+			keygenInstance := NewTssKeyGen(
+				comm.GetLocalPeerID(),
+				conf,
+				testPubKeys[idx],
+				comm.BroadcastMsgChan,
+				make(chan struct{}),
+				s.preParams[idx],
+				messageID,
+				s.stateMgrs[idx], s.nodePrivKeys[idx], s.comms[idx])
+			if idx == 0 {
+				keygenInstance.SetSharePreParams(loadPreparams(c, "preParam_share_test.data"))
+			}
+			keygenMsgChannel := keygenInstance.GetTssKeyGenChannels()
+			comm.SetSubscribe(messages.TSSKeyGenMsg, messageID, keygenMsgChannel)
+			comm.SetSubscribe(messages.TSSKeyGenVerMsg, messageID, keygenMsgChannel)
+			comm.SetSubscribe(messages.TSSControlMsg, messageID, keygenMsgChannel)
+			comm.SetSubscribe(messages.TSSTaskDone, messageID, keygenMsgChannel)
+			defer comm.CancelSubscribe(messages.TSSKeyGenMsg, messageID)
+			defer comm.CancelSubscribe(messages.TSSKeyGenVerMsg, messageID)
+			defer comm.CancelSubscribe(messages.TSSControlMsg, messageID)
+			defer comm.CancelSubscribe(messages.TSSTaskDone, messageID)
+			resp, err := keygenInstance.GenerateNewKey(req)
+			c.Assert(err, IsNil)
+			lock.Lock()
+			defer lock.Unlock()
+			keygenResult[idx] = resp
+		}(i)
+	}
+	wg.Wait()
+	c.Assert(keygenResult, HasLen, s.partyNum)
+	ans := keygenResult[0]
+	for _, el := range keygenResult {
+		c.Assert(el.Equals(ans), Equals, true)
+	}
+	pubKey, _, err := conversion.GetTssPubKey(ans)
+	c.Assert(err, IsNil)
+	for i, el := range s.stateMgrs {
+		state, err := el.GetLocalState(pubKey)
+		c.Assert(err, IsNil)
+		c.Assert(state.Weights, DeepEquals, req.Weights)
+		// 5 shares need 4 signers
+		c.Assert(state.Threshold, Equals, 4)
+		if i == 0 {
+			c.Assert(state.ShareData, HasLen, 1)
+			c.Assert(state.ShareData[0].ECDSAPub.Equals(ans), Equals, true)
+			c.Assert(state.ShareData[0].ShareID.Cmp(state.LocalData.ShareID), Not(Equals), 0)
+		} else {
+			c.Assert(state.ShareData, HasLen, 0)
+		}
+	}
+}
+
 func (s *TssKeygenTestSuite) TestGenerateNewKeyWithStop(c *C) {
 	conf := common.TssConfig{
 		KeyGenTimeout:   20 * time.Second,
@@ -0,0 +1,176 @@
+package keygen
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+// preParamsRetryInterval is how long the worker waits after a failed generation
+const preParamsRetryInterval = 10 * time.Second
+
+// PreParamsStore persists the pre-parameters of the pool, so they survive the restarts and the used ones never
+// come back
+type PreParamsStore interface {
+	SavePreParams(preParams []storage.PreParams) error
+	RetrievePreParams() ([]storage.PreParams, error)
+}
+
+// PreParamsPool keeps fresh pre-parameters ready for the keygen, a worker generates them in the background up to
+// the size of the pool and drops the ones older than the ttl
+type PreParamsPool struct {
+	logger    zerolog.Logger
+	lock      *sync.Mutex
+	store     PreParamsStore
+	size      int
+	ttl       time.Duration
+	timeout   time.Duration
+	preParams []storage.PreParams
+	// generate is bkg.GeneratePreParams, the tests replace it
+	generate func(timeout time.Duration, optionalConcurrency ...int) (*bkg.LocalPreParams, error)
+	notify   chan struct{}
+}
+
+// NewPreParamsPool create a new pre-parameters pool, the pre-parameters are only kept in memory if the store is
+// nil, a ttl of 0 never expires them
+func NewPreParamsPool(store PreParamsStore, size int, ttl, timeout time.Duration) *PreParamsPool {
+	p := &PreParamsPool{
+		logger:   log.With().Str("module", "preparams_pool").Logger(),
+		lock:     &sync.Mutex{},
+		store:    store,
+		size:     size,
+		ttl:      ttl,
+		timeout:  timeout,
+		generate: bkg.GeneratePreParams,
+		notify:   make(chan struct{}, 1),
+	}
+	if store != nil {
+		preParams, err := store.RetrievePreParams()
+		if err != nil && !os.IsNotExist(err) {
+			p.logger.Error().Err(err).Msg("fail to retrieve the pre-parameters")
+		}
+		for _, el := range preParams {
+			if el.Data.Validate() {
+				p.preParams = append(p.preParams, el)
+			}
+		}
+	}
+	return p
+}
+
+func (p *PreParamsPool) save() {
+	if p.store == nil {
+		return
+	}
+	if err := p.store.SavePreParams(p.preParams); err != nil {
+		p.logger.Error().Err(err).Msg("fail to save the pre-parameters")
+	}
+}
+
+// dropExpired remove the pre-parameters older than the ttl, the caller holds the lock
+func (p *PreParamsPool) dropExpired() bool {
+	if p.ttl <= 0 {
+		return false
+	}
+	var fresh []storage.PreParams
+	for _, el := range p.preParams {
+		if time.Since(el.CreatedAt) < p.ttl {
+			fresh = append(fresh, el)
+		}
+	}
+	dropped := len(fresh) != len(p.preParams)
+	p.preParams = fresh
+	return dropped
+}
+
+// fill generate the missing pre-parameters one at a time, so a keygen can take the first one before the pool is
+// full, it return false if we stopped or failed
+func (p *PreParamsPool) fill(stopChan chan struct{}) bool {
+	for {
+		p.lock.Lock()
+		if p.dropExpired() {
+			p.save()
+		}
+		missing := p.size - len(p.preParams)
+		p.lock.Unlock()
+		if missing <= 0 {
+			return true
+		}
+		select {
+		case <-stopChan:
+			return false
+		default:
+		}
+		start := time.Now()
+		preParams, err := p.generate(p.timeout)
+		if err != nil || !preParams.Validate() {
+			p.logger.Error().Err(err).Msg("fail to generate the pre-parameters")
+			return false
+		}
+		p.logger.Info().Msgf("generated the pre-parameters in %s", time.Since(start))
+		p.lock.Lock()
+		p.preParams = append(p.preParams, storage.PreParams{Data: *preParams, CreatedAt: time.Now()})
+		p.save()
+		p.lock.Unlock()
+	}
+}
+
+// Run keep the pool full until we stop, it blocks
+func (p *PreParamsPool) Run(stopChan chan struct{}) {
+	for {
+		wait := p.ttl
+		if !p.fill(stopChan) {
+			wait = preParamsRetryInterval
+		}
+		var timer *time.Timer
+		var expire <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			expire = timer.C
+		}
+		select {
+		case <-stopChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-p.notify:
+		case <-expire:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Take remove the oldest fresh pre-parameters from the pool, it return nil if the pool is empty
+func (p *PreParamsPool) Take() *bkg.LocalPreParams {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.dropExpired()
+	if len(p.preParams) == 0 {
+		return nil
+	}
+	preParams := p.preParams[0].Data
+	p.preParams = p.preParams[1:]
+	p.save()
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+	return &preParams
+}
+
+// Count return the number of the fresh pre-parameters in the pool
+func (p *PreParamsPool) Count() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.dropExpired()
+	return len(p.preParams)
+}
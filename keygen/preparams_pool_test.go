@@ -0,0 +1,112 @@
+package keygen
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	btsskeygen "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+type PreParamsPoolTestSuite struct{}
+
+var _ = Suite(&PreParamsPoolTestSuite{})
+
+type mockPreParamsStore struct {
+	lock      sync.Mutex
+	preParams []storage.PreParams
+	saved     int
+}
+
+func (m *mockPreParamsStore) SavePreParams(preParams []storage.PreParams) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.preParams = append([]storage.PreParams{}, preParams...)
+	m.saved++
+	return nil
+}
+
+func (m *mockPreParamsStore) RetrievePreParams() ([]storage.PreParams, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.preParams == nil {
+		return nil, os.ErrNotExist
+	}
+	return m.preParams, nil
+}
+
+func (m *mockPreParamsStore) count() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.preParams)
+}
+
+func waitForCount(c *C, pool *PreParamsPool, expected int) {
+	for i := 0; i < 100; i++ {
+		if pool.Count() == expected {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	c.Fatalf("the pool has %d pre-parameters, expected %d", pool.Count(), expected)
+}
+
+func (s *PreParamsPoolTestSuite) TestRun(c *C) {
+	preParams := getPreparams(c)
+	store := &mockPreParamsStore{}
+	pool := NewPreParamsPool(store, 2, time.Hour, time.Minute)
+	c.Assert(pool.Take(), IsNil)
+	generated := 0
+	var lock sync.Mutex
+	pool.generate = func(timeout time.Duration, optionalConcurrency ...int) (*btsskeygen.LocalPreParams, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		generated++
+		return preParams[generated%len(preParams)], nil
+	}
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	go pool.Run(stopChan)
+	waitForCount(c, pool, 2)
+	c.Assert(store.count(), Equals, 2)
+
+	taken := pool.Take()
+	c.Assert(taken, NotNil)
+	c.Assert(taken.Validate(), Equals, true)
+	// the worker generate another one in place of the one we took
+	waitForCount(c, pool, 2)
+	lock.Lock()
+	c.Assert(generated, Equals, 3)
+	lock.Unlock()
+	c.Assert(store.count(), Equals, 2)
+}
+
+func (s *PreParamsPoolTestSuite) TestLoadAndExpire(c *C) {
+	preParams := getPreparams(c)
+	store := &mockPreParamsStore{
+		preParams: []storage.PreParams{
+			{Data: *preParams[0], CreatedAt: time.Now().Add(-2 * time.Hour)},
+			{Data: *preParams[1], CreatedAt: time.Now()},
+			{Data: btsskeygen.LocalPreParams{}, CreatedAt: time.Now()},
+		},
+	}
+	pool := NewPreParamsPool(store, 2, time.Hour, time.Minute)
+	// the invalid one is dropped at load and the old one has expired
+	c.Assert(pool.Count(), Equals, 1)
+	taken := pool.Take()
+	c.Assert(taken, NotNil)
+	c.Assert(taken.NTildei.Cmp(preParams[1].NTildei), Equals, 0)
+	c.Assert(pool.Take(), IsNil)
+	c.Assert(store.count(), Equals, 0)
+
+	// the worker retry later if the generation fails
+	pool.generate = func(timeout time.Duration, optionalConcurrency ...int) (*btsskeygen.LocalPreParams, error) {
+		return nil, errors.New("timeout")
+	}
+	c.Assert(pool.fill(make(chan struct{})), Equals, false)
+	c.Assert(pool.Count(), Equals, 0)
+}
@@ -1,10 +1,26 @@
 package keygen
 
+import "github.com/akildemir/go-tss/conversion"
+
 // Request request to do keygen
 type Request struct {
 	Keys        []string `json:"keys"`
 	BlockHeight int64    `json:"block_height"`
 	Version     string   `json:"tss_version"`
+	// Threshold is the number of the signers the keysign of the new key needs, it is derived from the number of
+	// the keys if it is 0
+	Threshold int `json:"threshold,omitempty"`
+	// Algo is the algo of the new key, secp256k1 if it is empty
+	Algo conversion.Algo `json:"algo,omitempty"`
+	// Protocol is the signing protocol of the new key, gg20 if it is empty, the frost keys only sign schnorr, the
+	// musig2 keys only sign schnorr with all the parties of the keygen
+	Protocol conversion.SignProtocol `json:"protocol,omitempty"`
+	// Weights is the number of the shares the keys hold, the keys without a weight hold one share. The threshold
+	// is in shares, the participants of a weight above 1 run a local party for each of their shares
+	Weights map[string]int `json:"weights,omitempty"`
+	// Vault asks for the vault keygen, an ecdsa and an eddsa key from one party formation, the node refuses it as
+	// it can not run the eddsa keygen
+	Vault bool `json:"vault,omitempty"`
 }
 
 // NewRequest creeate a new instance of keygen.Request
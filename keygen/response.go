@@ -3,6 +3,9 @@ package keygen
 import (
 	"github.com/akildemir/go-tss/blame"
 	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/p2p"
 )
 
 // Response keygen response
@@ -11,6 +14,27 @@ type Response struct {
 	PoolAddress string        `json:"pool_address"`
 	Status      common.Status `json:"status"`
 	Blame       blame.Blame   `json:"blame"`
+	// Algo is the algo of the new key
+	Algo conversion.Algo `json:"algo,omitempty"`
+	// Protocol is the signing protocol of the new key
+	Protocol conversion.SignProtocol `json:"protocol,omitempty"`
+	// Stall is the round the keygen got stuck in if it timed out
+	Stall *blame.Stall `json:"stall,omitempty"`
+	// Rounds are the rounds the failed keygen got through, the peers whose messages of each round arrived and
+	// when, so the round it failed in and the peers that stopped before it are plain to see
+	Rounds *blame.RoundTrace `json:"rounds,omitempty"`
+	// ErrorCode is the machine-readable code of the failure, it is empty if the keygen succeeded
+	ErrorCode errcode.Code `json:"error_code,omitempty"`
+	// Logs are the last log lines of the keygen if it failed, they carry its msg id, peers and round
+	Logs []string `json:"logs,omitempty"`
+	// JoinParty describes the peers that did not join the party of the keygen in time, and when we saw them
+	JoinParty *p2p.JoinPartyReport `json:"join_party,omitempty"`
+	// Verdict is the blame of the failed keygen signed by this node, with the evidences of the offenders, the
+	// chain can check who reported it and what the offenders sent before it slashes them
+	Verdict *blame.Event `json:"verdict,omitempty"`
+	// BlameQuorum are the nodes the participants of the failed keygen agree on once they shared their verdicts, and
+	// the ones they dispute, it is only set if the blame quorum is enabled
+	BlameQuorum *blame.Quorum `json:"blame_quorum,omitempty"`
 }
 
 // NewResponse create a new instance of keygen.Response
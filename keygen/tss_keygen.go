@@ -10,7 +10,6 @@ import (
 	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
 	btss "github.com/binance-chain/tss-lib/tss"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 	tcrypto "github.com/tendermint/tendermint/crypto"
 
 	"github.com/akildemir/go-tss/blame"
@@ -25,12 +24,13 @@ type TssKeyGen struct {
 	logger          zerolog.Logger
 	localNodePubKey string
 	preParams       *bkg.LocalPreParams
+	sharePreParams  []*bkg.LocalPreParams
 	tssCommonStruct *common.TssCommon
 	stopChan        chan struct{} // channel to indicate whether we should stop
 	localParty      *btss.PartyID
 	stateManager    storage.LocalStateManager
 	commStopChan    chan struct{}
-	p2pComm         *p2p.Communication
+	p2pComm         common.P2PComm
 }
 
 func NewTssKeyGen(localP2PID string,
@@ -42,14 +42,13 @@ func NewTssKeyGen(localP2PID string,
 	msgID string,
 	stateManager storage.LocalStateManager,
 	privateKey tcrypto.PrivKey,
-	p2pComm *p2p.Communication) *TssKeyGen {
+	p2pComm common.P2PComm) *TssKeyGen {
+	tssCommonStruct := common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privateKey, 1)
 	return &TssKeyGen{
-		logger: log.With().
-			Str("module", "keygen").
-			Str("msgID", msgID).Logger(),
+		logger:          tssCommonStruct.GetLogContext().Logger("keygen"),
 		localNodePubKey: localNodePubKey,
 		preParams:       preParam,
-		tssCommonStruct: common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privateKey, 1),
+		tssCommonStruct: tssCommonStruct,
 		stopChan:        stopChan,
 		localParty:      nil,
 		stateManager:    stateManager,
@@ -66,25 +65,62 @@ func (tKeyGen *TssKeyGen) GetTssCommonStruct() *common.TssCommon {
 	return tKeyGen.tssCommonStruct
 }
 
+// SetSharePreParams set the pre-parameters of the other local shares of a weighted key, the shares without them
+// generate their own, no two shares may use the same pre-parameters
+func (tKeyGen *TssKeyGen) SetSharePreParams(preParams []*bkg.LocalPreParams) {
+	tKeyGen.sharePreParams = preParams
+}
+
+func (tKeyGen *TssKeyGen) getSharePreParams(share int) (*bkg.LocalPreParams, error) {
+	if share == 0 {
+		return tKeyGen.preParams, nil
+	}
+	if share <= len(tKeyGen.sharePreParams) && tKeyGen.sharePreParams[share-1] != nil {
+		return tKeyGen.sharePreParams[share-1], nil
+	}
+	tKeyGen.logger.Info().Msgf("generate the pre-parameters of the local share %d", share)
+	return bkg.GeneratePreParams(tKeyGen.tssCommonStruct.GetConf().PreParamTimeout)
+}
+
 func (tKeyGen *TssKeyGen) GenerateNewKey(keygenReq Request) (*bcrypto.ECPoint, error) {
-	partiesID, localPartyID, err := conversion.GetParties(keygenReq.Keys, tKeyGen.localNodePubKey)
+	if err := conversion.ValidateWeights(keygenReq.Keys, keygenReq.Weights); err != nil {
+		return nil, fmt.Errorf("invalid weights: %w", err)
+	}
+	partiesID, localPartiesID, err := conversion.GetWeightedParties(keygenReq.Keys, keygenReq.Weights, tKeyGen.localNodePubKey)
 	if err != nil {
 		return nil, fmt.Errorf("fail to get keygen parties: %w", err)
 	}
 
+	algoParties, err := common.GetAlgoParties(keygenReq.Algo)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the keygen party: %w", err)
+	}
+
 	keyGenLocalStateItem := storage.KeygenLocalState{
 		ParticipantKeys: keygenReq.Keys,
 		LocalPartyKey:   tKeyGen.localNodePubKey,
+		Algo:            keygenReq.Algo.OrDefault(),
+		Protocol:        keygenReq.Protocol.OrDefault(),
+		Weights:         keygenReq.Weights,
 	}
 
 	threshold, err := conversion.GetThreshold(len(partiesID))
+	if keygenReq.Threshold > 0 {
+		threshold, err = conversion.GetThresholdFromSigners(keygenReq.Threshold, len(partiesID))
+	}
 	if err != nil {
 		return nil, err
 	}
+	// the threshold is in shares, no participant of a weighted key may hold enough shares to sign alone
+	for _, el := range keygenReq.Keys {
+		if len(keygenReq.Weights) > 0 && conversion.GetWeight(keygenReq.Weights, el) > threshold {
+			return nil, fmt.Errorf("%s holds %d shares, it can sign without the others", el, conversion.GetWeight(keygenReq.Weights, el))
+		}
+	}
+	keyGenLocalStateItem.Threshold = threshold + 1
 	keyGenPartyMap := new(sync.Map)
 	ctx := btss.NewPeerContext(partiesID)
-	params := btss.NewParameters(ctx, localPartyID, len(partiesID), threshold)
-	outCh := make(chan btss.Message, len(partiesID))
+	outCh := make(chan btss.Message, len(partiesID)*len(localPartiesID))
 	endCh := make(chan bkg.LocalPartySaveData, len(partiesID))
 	errChan := make(chan struct{})
 	if tKeyGen.preParams == nil {
@@ -92,7 +128,15 @@ func (tKeyGen *TssKeyGen) GenerateNewKey(keygenReq Request) (*bcrypto.ECPoint, e
 		return nil, errors.New("error, empty pre-parameters")
 	}
 	blameMgr := tKeyGen.tssCommonStruct.GetBlameMgr()
-	keyGenParty := bkg.NewLocalParty(params, outCh, endCh, *tKeyGen.preParams)
+	var keyGenParties []btss.Party
+	for i, el := range localPartiesID {
+		preParams, err := tKeyGen.getSharePreParams(i)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get the pre-parameters of the local share %d: %w", i, err)
+		}
+		params := btss.NewParameters(ctx, el, len(partiesID), threshold)
+		keyGenParties = append(keyGenParties, algoParties.Keygen(params, outCh, endCh, *preParams))
+	}
 	partyIDMap := conversion.SetupPartyIDMap(partiesID)
 	err1 := conversion.SetupIDMaps(partyIDMap, tKeyGen.tssCommonStruct.PartyIDtoP2PID)
 	err2 := conversion.SetupIDMaps(partyIDMap, blameMgr.PartyIDtoP2PID)
@@ -101,31 +145,39 @@ func (tKeyGen *TssKeyGen) GenerateNewKey(keygenReq Request) (*bcrypto.ECPoint, e
 		return nil, err
 	}
 	// we never run multi keygen, so the moniker is set to default empty value
-	keyGenPartyMap.Store("", keyGenParty)
+	keyGenPartyMap.Store("", keyGenParties[0])
 	partyInfo := &common.PartyInfo{
 		PartyMap:   keyGenPartyMap,
 		PartyIDMap: partyIDMap,
 	}
+	if len(keyGenParties) > 1 {
+		partyInfo.ShareMap = new(sync.Map)
+		partyInfo.ShareMap.Store("", keyGenParties[1:])
+	}
 
 	tKeyGen.tssCommonStruct.SetPartyInfo(partyInfo)
 	blameMgr.SetPartyInfo(keyGenPartyMap, partyIDMap)
-	tKeyGen.tssCommonStruct.P2PPeersLock.Lock()
-	tKeyGen.tssCommonStruct.P2PPeers = conversion.GetPeersID(tKeyGen.tssCommonStruct.PartyIDtoP2PID, tKeyGen.tssCommonStruct.GetLocalPeerID())
-	tKeyGen.tssCommonStruct.P2PPeersLock.Unlock()
+	tKeyGen.tssCommonStruct.SetP2PPeers(conversion.GetPeersID(tKeyGen.tssCommonStruct.PartyIDtoP2PID, tKeyGen.tssCommonStruct.GetLocalPeerID()))
 	var keyGenWg sync.WaitGroup
 	keyGenWg.Add(2)
 	// start keygen
 	go func() {
 		defer keyGenWg.Done()
 		defer tKeyGen.logger.Debug().Msg(">>>>>>>>>>>>>.keyGenParty started")
-		if err := keyGenParty.Start(); nil != err {
-			tKeyGen.logger.Error().Err(err).Msg("fail to start keygen party")
-			close(errChan)
+		for _, keyGenParty := range keyGenParties {
+			if err := keyGenParty.Start(); nil != err {
+				tKeyGen.logger.Error().Err(err).Msg("fail to start keygen party")
+				close(errChan)
+				return
+			}
+			tKeyGen.tssCommonStruct.ReplayCachedMessages()
 		}
 	}()
 	go tKeyGen.tssCommonStruct.ProcessInboundMessages(tKeyGen.commStopChan, &keyGenWg)
+	// ask the peers to replay what we may have missed if we joined the party late
+	tKeyGen.tssCommonStruct.RequestCatchUp()
 
-	r, err := tKeyGen.processKeyGen(errChan, outCh, endCh, keyGenLocalStateItem)
+	r, err := tKeyGen.processKeyGen(errChan, outCh, endCh, keyGenLocalStateItem, localPartiesID)
 	if err != nil {
 		close(tKeyGen.commStopChan)
 		return nil, fmt.Errorf("fail to process key sign: %w", err)
@@ -142,15 +194,38 @@ func (tKeyGen *TssKeyGen) GenerateNewKey(keygenReq Request) (*bcrypto.ECPoint, e
 	return r, err
 }
 
+// shareIndex return the index of the local share the save data is of, -1 if it is none of them
+func (tKeyGen *TssKeyGen) shareIndex(localPartiesID []*btss.PartyID, saveData bkg.LocalPartySaveData) int {
+	if len(localPartiesID) == 1 {
+		return 0
+	}
+	for i, el := range localPartiesID {
+		if saveData.ShareID != nil && saveData.ShareID.Cmp(el.KeyInt()) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
 func (tKeyGen *TssKeyGen) processKeyGen(errChan chan struct{},
 	outCh <-chan btss.Message,
 	endCh <-chan bkg.LocalPartySaveData,
-	keyGenLocalStateItem storage.KeygenLocalState) (*bcrypto.ECPoint, error) {
+	keyGenLocalStateItem storage.KeygenLocalState,
+	localPartiesID []*btss.PartyID) (*bcrypto.ECPoint, error) {
 	defer tKeyGen.logger.Debug().Msg("finished keygen process")
 	tKeyGen.logger.Debug().Msg("start to read messages from local party")
 	tssConf := tKeyGen.tssCommonStruct.GetConf()
 	blameMgr := tKeyGen.tssCommonStruct.GetBlameMgr()
+	// the save data of the local shares in the order of the shares
+	shares := make([]*bkg.LocalPartySaveData, len(localPartiesID))
+	finished := 0
 	for {
+		// we wait for the round of the last message we sent
+		round := messages.KEYGEN1
+		if lastMsg := blameMgr.GetLastMsg(); lastMsg != nil {
+			round = lastMsg.Type()
+		}
+		timeout := tssConf.RoundTimeout(round, tssConf.KeyGenTimeout)
 		select {
 		case <-errChan: // when keyGenParty return
 			tKeyGen.logger.Error().Msg("key gen failed")
@@ -159,9 +234,19 @@ func (tKeyGen *TssKeyGen) processKeyGen(errChan chan struct{},
 		case <-tKeyGen.stopChan: // when TSS processor receive signal to quit
 			return nil, errors.New("received exit signal")
 
-		case <-time.After(tssConf.KeyGenTimeout):
-			// we bail out after KeyGenTimeoutSeconds
-			tKeyGen.logger.Error().Msgf("fail to generate message with %s", tssConf.KeyGenTimeout.String())
+		case <-tKeyGen.tssCommonStruct.GetAbortChan(): // a peer restarted in the keygen, it can not finish
+			tKeyGen.logger.Error().Msg("a peer restarted in the keygen, we abort it")
+			return nil, blame.ErrTssAborted
+
+		case <-time.After(timeout):
+			// we bail out after the timeout of the round
+			tKeyGen.logger.Error().Msgf("fail to generate message with %s in round %s", timeout.String(), round)
+			stall, err := blameMgr.DetectStall(messages.KeygenRounds)
+			if err != nil {
+				tKeyGen.logger.Error().Err(err).Msg("fail to detect the stalled round")
+			} else if stall != nil {
+				tKeyGen.logger.Error().Strs("missing", stall.Missing).Msgf("keygen stalled in round %s", stall.Round)
+			}
 			lastMsg := blameMgr.GetLastMsg()
 			failReason := blameMgr.GetBlame().FailReason
 			if failReason == "" {
@@ -215,6 +300,27 @@ func (tKeyGen *TssKeyGen) processKeyGen(errChan chan struct{},
 
 		case msg := <-endCh:
 			tKeyGen.logger.Debug().Msgf("keygen finished successfully: %s", msg.ECDSAPub.Y().String())
+			share := tKeyGen.shareIndex(localPartiesID, msg)
+			if share < 0 || shares[share] != nil {
+				tKeyGen.logger.Error().Msg("the save data is not of a local share")
+				continue
+			}
+			saveData := msg
+			shares[share] = &saveData
+			finished++
+			// the other local shares are still running the last round
+			if finished < len(shares) {
+				continue
+			}
+			for _, el := range shares[1:] {
+				if !el.ECDSAPub.Equals(shares[0].ECDSAPub) {
+					return nil, errors.New("the local shares generate different pub keys")
+				}
+			}
+			msg = *shares[0]
+			for _, el := range shares[1:] {
+				keyGenLocalStateItem.ShareData = append(keyGenLocalStateItem.ShareData, *el)
+			}
 			err := tKeyGen.tssCommonStruct.NotifyTaskDone()
 			if err != nil {
 				tKeyGen.logger.Error().Err(err).Msg("fail to broadcast the keysign done")
@@ -225,6 +331,8 @@ func (tKeyGen *TssKeyGen) processKeyGen(errChan chan struct{},
 			}
 			keyGenLocalStateItem.LocalData = msg
 			keyGenLocalStateItem.PubKey = pubKey
+			createdAt := time.Now().UTC()
+			keyGenLocalStateItem.CreatedAt = &createdAt
 			if err := tKeyGen.stateManager.SaveLocalState(keyGenLocalStateItem); err != nil {
 				return nil, fmt.Errorf("fail to save keygen result to storage: %w", err)
 			}
@@ -0,0 +1,392 @@
+package keyimport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/binance-chain/tss-lib/crypto"
+	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	btss "github.com/binance-chain/tss-lib/tss"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// keyImportProtocol is the protocol the dealer uses to deliver the shares, libp2p encrypts the streams
+// and authenticates both ends with their node keys, so only the intended party can read its share
+var keyImportProtocol protocol.ID = "/p2p/go-tss/keyimport"
+
+// ErrSessionNotFound is returned when we wait for a session we have not registered
+var ErrSessionNotFound = errcode.New(errcode.NotFound, "key import session not found")
+
+type dealerHello struct {
+	MsgID string `json:"msg_id"`
+}
+
+type dealerShare struct {
+	Share  *big.Int          `json:"share"`
+	Vs     []*crypto.ECPoint `json:"vs"`
+	Params []PublicParams    `json:"params"`
+}
+
+type importAck struct {
+	Error string `json:"error,omitempty"`
+}
+
+type sessionResult struct {
+	pubKey *crypto.ECPoint
+	err    error
+}
+
+type session struct {
+	dealer      peer.ID
+	keys        []string
+	localPubKey string
+	parties     btss.SortedPartyIDs
+	localIdx    int
+	threshold   int
+	preParams   *bkg.LocalPreParams
+	result      chan sessionResult
+}
+
+// KeyImporter split an existing private key into tss shares on the dealer node and deliver them to the
+// committee, the result is saved the same way as a keygen result, so the key can be used to sign right away
+type KeyImporter struct {
+	logger       zerolog.Logger
+	host         host.Host
+	stateManager storage.LocalStateManager
+	lock         *sync.Mutex
+	sessions     map[string]*session
+}
+
+// NewKeyImporter create a new instance of KeyImporter
+func NewKeyImporter(h host.Host, stateManager storage.LocalStateManager) *KeyImporter {
+	ki := &KeyImporter{
+		logger:       log.With().Str("module", "keyimport").Logger(),
+		host:         h,
+		stateManager: stateManager,
+		lock:         &sync.Mutex{},
+		sessions:     make(map[string]*session),
+	}
+	h.SetStreamHandler(keyImportProtocol, ki.handleStream)
+	return ki
+}
+
+// getParties return the sorted parties of the keys, the index of the local party and the tss threshold of the
+// signers, the threshold is derived from the number of the keys if the signers is 0
+func getParties(keys []string, signers int, localPubKey string) (btss.SortedPartyIDs, int, int, error) {
+	// GetParties sorts the keys, we do not want to touch the keys of the caller
+	sortedKeys := make([]string, len(keys))
+	copy(sortedKeys, keys)
+	parties, _, err := conversion.GetParties(sortedKeys, sortedKeys[0])
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("fail to get the parties: %w", err)
+	}
+	threshold, err := conversion.GetThreshold(len(parties))
+	if signers > 0 {
+		threshold, err = conversion.GetThresholdFromSigners(signers, len(parties))
+	}
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	localIdx := -1
+	for i, party := range parties {
+		pubKey, err := conversion.PartyIDtoPubKey(party)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if pubKey == localPubKey {
+			localIdx = i
+		}
+	}
+	return parties, localIdx, threshold, nil
+}
+
+// Expect register a session so that we accept the share the given dealer sends us, it should be called before
+// the nodes sync with each other, otherwise the dealer may reach us before we are ready
+func (ki *KeyImporter) Expect(msgID string, dealer peer.ID, keys []string, signers int, localPubKey string, preParams *bkg.LocalPreParams) error {
+	if len(keys) < 2 {
+		return errors.New("not enough parties to import the key")
+	}
+	parties, localIdx, threshold, err := getParties(keys, signers, localPubKey)
+	if err != nil {
+		return err
+	}
+	if localIdx < 0 {
+		return errors.New("local party is not in the list")
+	}
+	ki.lock.Lock()
+	defer ki.lock.Unlock()
+	if _, ok := ki.sessions[msgID]; ok {
+		return fmt.Errorf("key import session(%s) already exist", msgID)
+	}
+	ki.sessions[msgID] = &session{
+		dealer:      dealer,
+		keys:        keys,
+		localPubKey: localPubKey,
+		parties:     parties,
+		localIdx:    localIdx,
+		threshold:   threshold,
+		preParams:   preParams,
+		result:      make(chan sessionResult, 1),
+	}
+	return nil
+}
+
+// Wait blocks until the dealer delivers our share of the given session, or the timeout
+func (ki *KeyImporter) Wait(msgID string, timeout time.Duration) (*crypto.ECPoint, error) {
+	ki.lock.Lock()
+	s, ok := ki.sessions[msgID]
+	ki.lock.Unlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	defer ki.Cancel(msgID)
+	select {
+	case r := <-s.result:
+		return r.pubKey, r.err
+	case <-time.After(timeout):
+		return nil, errors.New("timeout to receive the share from the dealer")
+	}
+}
+
+// Cancel remove the session of the given msgID, we will no longer accept its share
+func (ki *KeyImporter) Cancel(msgID string) {
+	ki.lock.Lock()
+	defer ki.lock.Unlock()
+	delete(ki.sessions, msgID)
+}
+
+func (ki *KeyImporter) takeSession(msgID string, remotePeer peer.ID) (*session, error) {
+	ki.lock.Lock()
+	defer ki.lock.Unlock()
+	s, ok := ki.sessions[msgID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if s.dealer != remotePeer {
+		return nil, fmt.Errorf("peer %s is not the dealer of the session", remotePeer)
+	}
+	// a session can only be dealt once
+	delete(ki.sessions, msgID)
+	return s, nil
+}
+
+func (ki *KeyImporter) handleStream(stream network.Stream) {
+	remotePeer := stream.Conn().RemotePeer()
+	logger := ki.logger.With().Str("remote peer", remotePeer.String()).Logger()
+	defer func() {
+		if err := stream.Close(); err != nil {
+			logger.Error().Err(err).Msg("fail to close the key import stream")
+		}
+	}()
+	var hello dealerHello
+	if err := readMsg(stream, &hello); err != nil {
+		logger.Error().Err(err).Msg("fail to read the dealer hello")
+		return
+	}
+	s, err := ki.takeSession(hello.MsgID, remotePeer)
+	if err != nil {
+		logger.Error().Err(err).Msgf("reject the key import session(%s)", hello.MsgID)
+		return
+	}
+	pubKey, err := ki.receiveShare(stream, s)
+	if err != nil {
+		logger.Error().Err(err).Msg("fail to import the key share")
+	}
+	s.result <- sessionResult{pubKey: pubKey, err: err}
+}
+
+func (ki *KeyImporter) receiveShare(stream network.Stream, s *session) (*crypto.ECPoint, error) {
+	if err := writeMsg(stream, NewPublicParams(s.preParams)); err != nil {
+		return nil, err
+	}
+	var share dealerShare
+	if err := readMsg(stream, &share); err != nil {
+		return nil, err
+	}
+	if share.Share == nil {
+		return nil, errors.New("empty share from the dealer")
+	}
+	saveData, err := buildSaveData(s.parties, s.localIdx, s.threshold, share.Share, share.Vs, share.Params, s.preParams)
+	if err == nil {
+		err = ki.saveLocalState(saveData, s.keys, s.threshold, s.localPubKey)
+	}
+	ack := importAck{}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	if errAck := writeMsg(stream, ack); errAck != nil {
+		ki.logger.Error().Err(errAck).Msg("fail to send the ack to the dealer")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return saveData.ECDSAPub, nil
+}
+
+func (ki *KeyImporter) saveLocalState(saveData bkg.LocalPartySaveData, keys []string, threshold int, localPubKey string) error {
+	pubKey, _, err := conversion.GetTssPubKey(saveData.ECDSAPub)
+	if err != nil {
+		return fmt.Errorf("fail to get thorchain pubkey: %w", err)
+	}
+	createdAt := time.Now().UTC()
+	state := storage.KeygenLocalState{
+		PubKey:          pubKey,
+		LocalData:       saveData,
+		ParticipantKeys: keys,
+		LocalPartyKey:   localPubKey,
+		Algo:            conversion.AlgoSecp256k1,
+		Threshold:       threshold + 1,
+		CreatedAt:       &createdAt,
+	}
+	if err := ki.stateManager.SaveLocalState(state); err != nil {
+		return fmt.Errorf("fail to save the imported key to storage: %w", err)
+	}
+	return nil
+}
+
+// Deal split the private key into the shares of the given parties and deliver them, it collects the public
+// parameters of the whole committee first since every party needs them to sign. The dealer does not have to
+// be a member of the committee, if it is, its own share is saved once all the other parties have saved theirs.
+func (ki *KeyImporter) Deal(msgID string, keys []string, signers int, localPubKey string, preParams *bkg.LocalPreParams, privKey *big.Int, timeout time.Duration) (*crypto.ECPoint, error) {
+	if len(keys) < 2 {
+		return nil, errors.New("not enough parties to import the key")
+	}
+	parties, localIdx, threshold, err := getParties(keys, signers, localPubKey)
+	if err != nil {
+		return nil, err
+	}
+	streams := make([]network.Stream, len(parties))
+	defer func() {
+		for _, stream := range streams {
+			if stream == nil {
+				continue
+			}
+			if err := stream.Close(); err != nil {
+				ki.logger.Error().Err(err).Msg("fail to close the key import stream")
+			}
+		}
+	}()
+
+	params := make([]PublicParams, len(parties))
+	err = ki.forEachRemote(parties, localIdx, func(idx int, pID peer.ID) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		stream, err := ki.host.NewStream(ctx, pID, keyImportProtocol)
+		if err != nil {
+			return fmt.Errorf("fail to create stream to peer(%s): %w", pID, err)
+		}
+		streams[idx] = stream
+		if err := writeMsg(stream, dealerHello{MsgID: msgID}); err != nil {
+			return err
+		}
+		return readMsg(stream, &params[idx])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to collect the public parameters: %w", err)
+	}
+	if localIdx >= 0 {
+		params[localIdx] = NewPublicParams(preParams)
+	}
+	for i, p := range params {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid public parameters of party %d: %w", i, err)
+		}
+	}
+
+	vs, shares, err := splitKey(privKey, parties, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("fail to split the private key: %w", err)
+	}
+	err = ki.forEachRemote(parties, localIdx, func(idx int, pID peer.ID) error {
+		if err := writeMsg(streams[idx], dealerShare{Share: shares[idx].Share, Vs: vs, Params: params}); err != nil {
+			return err
+		}
+		var ack importAck
+		if err := readMsg(streams[idx], &ack); err != nil {
+			return err
+		}
+		if len(ack.Error) != 0 {
+			return fmt.Errorf("peer %s fail to import its share: %s", pID, ack.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to deliver the shares: %w", err)
+	}
+	if localIdx >= 0 {
+		saveData, err := buildSaveData(parties, localIdx, threshold, shares[localIdx].Share, vs, params, preParams)
+		if err != nil {
+			return nil, err
+		}
+		if err := ki.saveLocalState(saveData, keys, threshold, localPubKey); err != nil {
+			return nil, err
+		}
+	}
+	return vs[0], nil
+}
+
+// forEachRemote run the given function against all the parties except the local one concurrently
+func (ki *KeyImporter) forEachRemote(parties btss.SortedPartyIDs, localIdx int, f func(idx int, pID peer.ID) error) error {
+	wg := sync.WaitGroup{}
+	errs := make([]error, len(parties))
+	for i, party := range parties {
+		if i == localIdx {
+			continue
+		}
+		pID, err := conversion.GetPeerIDFromPartyID(party)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(idx int, pID peer.ID) {
+			defer wg.Done()
+			errs[idx] = f(idx, pID)
+		}(i, pID)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop remove the stream handler
+func (ki *KeyImporter) Stop() {
+	ki.host.RemoveStreamHandler(keyImportProtocol)
+}
+
+func writeMsg(stream network.Stream, msg interface{}) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the key import message: %w", err)
+	}
+	return p2p.WriteStreamWithBuffer(buf, stream)
+}
+
+func readMsg(stream network.Stream, msg interface{}) error {
+	buf, err := p2p.ReadStreamWithBuffer(stream)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(buf, msg); err != nil {
+		return fmt.Errorf("fail to unmarshal the key import message: %w", err)
+	}
+	return nil
+}
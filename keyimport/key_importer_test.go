@@ -0,0 +1,245 @@
+package keyimport
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binance-chain/tss-lib/crypto"
+	"github.com/binance-chain/tss-lib/crypto/vss"
+	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	btss "github.com/binance-chain/tss-lib/tss"
+	tnet "github.com/libp2p/go-libp2p-testing/net"
+	crypto2 "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+var (
+	testPubKeys = []string{
+		"thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69",
+		"thorpub1addwnpepqfjcw5l4ay5t00c32mmlky7qrppepxzdlkcwfs2fd5u73qrwna0vzag3y4j",
+		"thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3",
+		"thorpub1addwnpepqtspqyy6gk22u37ztra4hq3hdakc0w0k60sfy849mlml2vrpfr0wvm6uz09",
+	}
+	testPriKeyArr = []string{
+		"6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=",
+		"528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=",
+		"JFB2LIJZtK+KasK00NcNil4PRJS4c4liOnK0nDalhqc=",
+		"vLMGhVXMOXQVnAE3BUU8fwNj/q0ZbndKkwmxfS5EN9Y=",
+	}
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type KeyImporterTestSuite struct {
+	hosts     []host.Host
+	importers []*KeyImporter
+	states    []storage.LocalStateManager
+	preParams []*bkg.LocalPreParams
+}
+
+var _ = Suite(&KeyImporterTestSuite{})
+
+func (s *KeyImporterTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+	p2p.ApplyDeadline = false
+	s.preParams = getPreparams(c)
+}
+
+func (s *KeyImporterTestSuite) SetUpTest(c *C) {
+	mn := mocknet.New()
+	s.hosts = nil
+	s.importers = nil
+	s.states = nil
+	for i := range testPubKeys {
+		buf, err := base64.StdEncoding.DecodeString(testPriKeyArr[i])
+		c.Assert(err, IsNil)
+		priKey, err := crypto2.UnmarshalSecp256k1PrivateKey(buf)
+		c.Assert(err, IsNil)
+		h, err := mn.AddPeer(priKey, tnet.RandLocalTCPAddress())
+		c.Assert(err, IsNil)
+		stateMgr, err := storage.NewFileStateMgr(c.MkDir())
+		c.Assert(err, IsNil)
+		s.hosts = append(s.hosts, h)
+		s.states = append(s.states, stateMgr)
+		s.importers = append(s.importers, NewKeyImporter(h, stateMgr))
+	}
+	c.Assert(mn.LinkAll(), IsNil)
+	c.Assert(mn.ConnectAllButSelf(), IsNil)
+}
+
+func (s *KeyImporterTestSuite) TearDownTest(c *C) {
+	for _, el := range s.importers {
+		el.Stop()
+	}
+}
+
+func getPreparams(c *C) []*bkg.LocalPreParams {
+	const (
+		testFileLocation = "../test_data"
+		preParamTestFile = "preParam_test.data"
+	)
+	var preParamArray []*bkg.LocalPreParams
+	buf, err := ioutil.ReadFile(path.Join(testFileLocation, preParamTestFile))
+	c.Assert(err, IsNil)
+	preParamsStr := strings.Split(string(buf), "\n")
+	for _, item := range preParamsStr {
+		var preParam bkg.LocalPreParams
+		val, err := hex.DecodeString(item)
+		c.Assert(err, IsNil)
+		c.Assert(json.Unmarshal(val, &preParam), IsNil)
+		preParamArray = append(preParamArray, &preParam)
+	}
+	return preParamArray
+}
+
+func (s *KeyImporterTestSuite) TestSplitKey(c *C) {
+	parties, _, err := conversion.GetParties(testPubKeys, testPubKeys[0])
+	c.Assert(err, IsNil)
+	threshold, err := conversion.GetThreshold(len(parties))
+	c.Assert(err, IsNil)
+	_, _, err = splitKey(big.NewInt(0), parties, threshold)
+	c.Assert(err, NotNil)
+	_, _, err = splitKey(btss.EC().Params().N, parties, threshold)
+	c.Assert(err, NotNil)
+
+	privKey := big.NewInt(123456789)
+	vs, shares, err := splitKey(privKey, parties, threshold)
+	c.Assert(err, IsNil)
+	c.Assert(vs[0].Equals(crypto.ScalarBaseMult(btss.EC(), privKey)), Equals, true)
+	var params []PublicParams
+	for _, el := range s.preParams {
+		params = append(params, NewPublicParams(el))
+	}
+	for i := range parties {
+		saveData, err := buildSaveData(parties, i, threshold, shares[i].Share, vs, params, s.preParams[i])
+		c.Assert(err, IsNil)
+		c.Assert(saveData.ECDSAPub.Equals(vs[0]), Equals, true)
+		for j := range parties {
+			c.Assert(saveData.BigXj[j].Equals(crypto.ScalarBaseMult(btss.EC(), shares[j].Share)), Equals, true)
+		}
+	}
+	// the share does not match the commitments
+	_, err = buildSaveData(parties, 0, threshold, shares[1].Share, vs, params, s.preParams[0])
+	c.Assert(err, NotNil)
+	// the dealer does not use our public parameters
+	_, err = buildSaveData(parties, 0, threshold, shares[0].Share, vs, params, s.preParams[1])
+	c.Assert(err, NotNil)
+	_, err = buildSaveData(parties, 0, threshold, shares[0].Share, vs[:threshold], params, s.preParams[0])
+	c.Assert(err, NotNil)
+	_, err = buildSaveData(parties, 0, threshold, shares[0].Share, vs, params[1:], s.preParams[0])
+	c.Assert(err, NotNil)
+}
+
+func (s *KeyImporterTestSuite) runImport(c *C, dealerIdx int, keys []string, signers int, privKey *big.Int) {
+	msgID := conversion.RandStringBytesMask(64)
+	dealerPeer := s.hosts[dealerIdx].ID()
+	for i, el := range testPubKeys {
+		if i == dealerIdx || !contains(keys, el) {
+			continue
+		}
+		c.Assert(s.importers[i].Expect(msgID, dealerPeer, keys, signers, el, s.preParams[i]), IsNil)
+	}
+	wg := sync.WaitGroup{}
+	for i, el := range testPubKeys {
+		if i == dealerIdx || !contains(keys, el) {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			pubKey, err := s.importers[idx].Wait(msgID, time.Second*10)
+			c.Assert(err, IsNil)
+			c.Assert(pubKey.Equals(crypto.ScalarBaseMult(btss.EC(), privKey)), Equals, true)
+		}(i)
+	}
+	pubKey, err := s.importers[dealerIdx].Deal(msgID, keys, signers, testPubKeys[dealerIdx], s.preParams[dealerIdx], privKey, time.Second*10)
+	c.Assert(err, IsNil)
+	wg.Wait()
+	c.Assert(pubKey.Equals(crypto.ScalarBaseMult(btss.EC(), privKey)), Equals, true)
+
+	// any threshold+1 shares should restore the imported key
+	poolPubKey, _, err := conversion.GetTssPubKey(pubKey)
+	c.Assert(err, IsNil)
+	threshold, err := conversion.GetThreshold(len(keys))
+	if signers > 0 {
+		threshold = signers - 1
+	}
+	c.Assert(err, IsNil)
+	var shares vss.Shares
+	for i, el := range testPubKeys {
+		if !contains(keys, el) {
+			continue
+		}
+		state, err := s.states[i].GetLocalState(poolPubKey)
+		c.Assert(err, IsNil)
+		c.Assert(state.LocalPartyKey, Equals, el)
+		// the keysign of the key needs the threshold of the import
+		stateThreshold, err := state.GetThreshold()
+		c.Assert(err, IsNil)
+		c.Assert(stateThreshold, Equals, threshold)
+		shares = append(shares, &vss.Share{
+			Threshold: threshold,
+			ID:        state.LocalData.ShareID,
+			Share:     state.LocalData.Xi,
+		})
+	}
+	c.Assert(shares, HasLen, len(keys))
+	secret, err := shares[:threshold+1].ReConstruct()
+	c.Assert(err, IsNil)
+	c.Assert(secret.Cmp(privKey), Equals, 0)
+}
+
+func contains(keys []string, key string) bool {
+	for _, el := range keys {
+		if el == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *KeyImporterTestSuite) TestImportKey(c *C) {
+	keys := make([]string, len(testPubKeys))
+	copy(keys, testPubKeys)
+	sort.Strings(keys)
+	s.runImport(c, 0, keys, 0, big.NewInt(987654321))
+}
+
+func (s *KeyImporterTestSuite) TestImportKeyThreshold(c *C) {
+	keys := make([]string, len(testPubKeys))
+	copy(keys, testPubKeys)
+	sort.Strings(keys)
+	s.runImport(c, 1, keys, 4, big.NewInt(24681357))
+	// the signers should be the majority of the parties
+	c.Assert(s.importers[1].Expect(conversion.RandStringBytesMask(64), s.hosts[0].ID(), keys, 2, keys[1], s.preParams[1]), NotNil)
+}
+
+func (s *KeyImporterTestSuite) TestImportKeyDealerNotInCommittee(c *C) {
+	s.runImport(c, 3, testPubKeys[:3], 0, big.NewInt(13579))
+}
+
+func (s *KeyImporterTestSuite) TestRejectUnexpectedDealer(c *C) {
+	msgID := conversion.RandStringBytesMask(64)
+	c.Assert(s.importers[1].Expect(msgID, s.hosts[0].ID(), testPubKeys, 0, testPubKeys[1], s.preParams[1]), IsNil)
+	c.Assert(s.importers[1].Expect(msgID, s.hosts[0].ID(), testPubKeys, 0, testPubKeys[1], s.preParams[1]), NotNil)
+	_, err := s.importers[2].Deal(msgID, testPubKeys, 0, testPubKeys[2], s.preParams[2], big.NewInt(100), time.Second)
+	c.Assert(err, NotNil)
+	s.importers[1].Cancel(msgID)
+	_, err = s.importers[1].Wait(msgID, time.Second)
+	c.Assert(err, Equals, ErrSessionNotFound)
+}
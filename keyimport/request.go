@@ -0,0 +1,27 @@
+package keyimport
+
+// ConfirmationPhrase is the phrase the operator has to put in the request to confirm the risk of the import,
+// the dealer knows the whole private key, so the imported key is only as safe as the dealer node
+const ConfirmationPhrase = "I understand the dealer has seen the whole private key"
+
+// Request request to import an existing private key into tss
+type Request struct {
+	Keys []string `json:"keys"`
+	// Threshold is the number of the signers the keysign of the imported key needs, it is derived from the number
+	// of the keys if it is 0
+	Threshold    int    `json:"threshold,omitempty"`
+	DealerPubKey string `json:"dealer_pub_key"`
+	// PrivateKey is the hex encoded private key to import, only the dealer should set it
+	PrivateKey   string `json:"private_key,omitempty"`
+	Confirmation string `json:"confirmation"`
+}
+
+// NewRequest create a new instance of keyimport.Request
+func NewRequest(keys []string, dealerPubKey, privateKey, confirmation string) Request {
+	return Request{
+		Keys:         keys,
+		DealerPubKey: dealerPubKey,
+		PrivateKey:   privateKey,
+		Confirmation: confirmation,
+	}
+}
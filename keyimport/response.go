@@ -0,0 +1,24 @@
+package keyimport
+
+import (
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+)
+
+// Response key import response
+type Response struct {
+	PubKey      string        `json:"pub_key"`
+	PoolAddress string        `json:"pool_address"`
+	Status      common.Status `json:"status"`
+	Blame       blame.Blame   `json:"blame"`
+}
+
+// NewResponse create a new instance of keyimport.Response
+func NewResponse(pk, addr string, status common.Status, blame blame.Blame) Response {
+	return Response{
+		PubKey:      pk,
+		PoolAddress: addr,
+		Status:      status,
+		Blame:       blame,
+	}
+}
@@ -0,0 +1,126 @@
+package keyimport
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/binance-chain/tss-lib/crypto"
+	"github.com/binance-chain/tss-lib/crypto/paillier"
+	"github.com/binance-chain/tss-lib/crypto/vss"
+	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	btss "github.com/binance-chain/tss-lib/tss"
+)
+
+// PublicParams is the public part of the pre-parameters of a party, every party needs the public parameters
+// of the whole committee to sign
+type PublicParams struct {
+	PaillierPK *paillier.PublicKey `json:"paillier_pk"`
+	NTilde     *big.Int            `json:"ntilde"`
+	H1         *big.Int            `json:"h1"`
+	H2         *big.Int            `json:"h2"`
+}
+
+// NewPublicParams extract the public parameters from the given pre-parameters
+func NewPublicParams(preParams *bkg.LocalPreParams) PublicParams {
+	return PublicParams{
+		PaillierPK: &preParams.PaillierSK.PublicKey,
+		NTilde:     preParams.NTildei,
+		H1:         preParams.H1i,
+		H2:         preParams.H2i,
+	}
+}
+
+func (p PublicParams) validate() error {
+	if p.PaillierPK == nil || p.PaillierPK.N == nil || p.NTilde == nil || p.H1 == nil || p.H2 == nil {
+		return errors.New("incomplete public parameters")
+	}
+	if p.H1.Cmp(p.H2) == 0 {
+		return errors.New("h1 and h2 should not be equal")
+	}
+	return nil
+}
+
+func (p PublicParams) equal(other PublicParams) bool {
+	return p.PaillierPK.N.Cmp(other.PaillierPK.N) == 0 &&
+		p.NTilde.Cmp(other.NTilde) == 0 &&
+		p.H1.Cmp(other.H1) == 0 &&
+		p.H2.Cmp(other.H2) == 0
+}
+
+// splitKey split the private key into the shares of the given parties with the Feldman VSS, the
+// commitments allow each party to verify its share and compute the public shares of the others
+func splitKey(privKey *big.Int, parties btss.SortedPartyIDs, threshold int) (vss.Vs, vss.Shares, error) {
+	curveN := btss.EC().Params().N
+	if privKey.Sign() <= 0 || privKey.Cmp(curveN) >= 0 {
+		return nil, nil, errors.New("invalid private key")
+	}
+	return vss.Create(threshold, privKey, parties.Keys())
+}
+
+// buildSaveData verify the share of the local party against the commitments and build the data
+// we save locally, it is the same data a keygen would produce
+func buildSaveData(parties btss.SortedPartyIDs, localIdx, threshold int, share *big.Int, vs vss.Vs,
+	params []PublicParams, preParams *bkg.LocalPreParams) (bkg.LocalPartySaveData, error) {
+	if localIdx < 0 || localIdx >= len(parties) {
+		return bkg.LocalPartySaveData{}, errors.New("local party is not in the list")
+	}
+	if len(params) != len(parties) {
+		return bkg.LocalPartySaveData{}, fmt.Errorf("expect public parameters of %d parties, got %d", len(parties), len(params))
+	}
+	if len(vs) != threshold+1 {
+		return bkg.LocalPartySaveData{}, fmt.Errorf("expect %d commitments, got %d", threshold+1, len(vs))
+	}
+	for _, v := range vs {
+		if v == nil || !v.ValidateBasic() {
+			return bkg.LocalPartySaveData{}, errors.New("invalid commitment")
+		}
+	}
+	for i, p := range params {
+		if err := p.validate(); err != nil {
+			return bkg.LocalPartySaveData{}, fmt.Errorf("invalid public parameters of party %d: %w", i, err)
+		}
+	}
+	if !params[localIdx].equal(NewPublicParams(preParams)) {
+		return bkg.LocalPartySaveData{}, errors.New("the dealer does not use our public parameters")
+	}
+	localShare := vss.Share{
+		Threshold: threshold,
+		ID:        parties[localIdx].KeyInt(),
+		Share:     share,
+	}
+	if !localShare.Verify(threshold, vs) {
+		return bkg.LocalPartySaveData{}, errors.New("the share does not match the commitments")
+	}
+
+	saveData := bkg.NewLocalPartySaveData(len(parties))
+	saveData.LocalPreParams = *preParams
+	saveData.Xi = share
+	saveData.ShareID = parties[localIdx].KeyInt()
+	saveData.ECDSAPub = vs[0]
+	modQ := new(big.Int).Set(btss.EC().Params().N)
+	for j, party := range parties {
+		kj := party.KeyInt()
+		saveData.Ks[j] = kj
+		saveData.NTildej[j] = params[j].NTilde
+		saveData.H1j[j] = params[j].H1
+		saveData.H2j[j] = params[j].H2
+		saveData.PaillierPKs[j] = params[j].PaillierPK
+		// Xj = v0 + v1*kj + v2*kj^2 + ... + vt*kj^t
+		bigXj := vs[0]
+		t := big.NewInt(1)
+		for c := 1; c <= threshold; c++ {
+			t = new(big.Int).Mod(new(big.Int).Mul(t, kj), modQ)
+			var err error
+			bigXj, err = bigXj.Add(vs[c].ScalarMult(t))
+			if err != nil {
+				return bkg.LocalPartySaveData{}, fmt.Errorf("fail to calculate the public share of party %d: %w", j, err)
+			}
+		}
+		saveData.BigXj[j] = bigXj
+	}
+	if !saveData.BigXj[localIdx].Equals(crypto.ScalarBaseMult(btss.EC(), share)) {
+		return bkg.LocalPartySaveData{}, errors.New("the public share does not match our share")
+	}
+	return saveData, nil
+}
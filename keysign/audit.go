@@ -0,0 +1,141 @@
+package keysign
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+// AuditStore persists the audit log, the records are only ever appended to it
+type AuditStore interface {
+	AppendAuditRecord(record storage.AuditRecord) error
+	RetrieveAuditRecords() ([]storage.AuditRecord, error)
+}
+
+// AuditLog is the append only log of the keysigns we are asked to take part in, each record carries the hash of the
+// previous one, so the operators can prove to the auditors that no record was changed or dropped since
+type AuditLog struct {
+	lock  *sync.Mutex
+	store AuditStore
+	// records are only kept in memory if the store is nil
+	records  []storage.AuditRecord
+	loaded   bool
+	lastSeq  uint64
+	lastHash string
+}
+
+// NewAuditLog create a new audit log, the records are only kept in memory if the store is nil
+func NewAuditLog(store AuditStore) *AuditLog {
+	return &AuditLog{
+		lock:  &sync.Mutex{},
+		store: store,
+	}
+}
+
+// AuditHash return the hash of the record, the hex encoded sha256 of its previous hash and its json without the hash
+func AuditHash(record storage.AuditRecord) (string, error) {
+	record.Hash = ""
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("fail to marshal the audit record to json: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(record.PrevHash))
+	h.Write(buf)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyAuditChain check the records follow each other and their hashes chain, the first record follows the given
+// seq and hash, they are 0 and empty for the first record of the log
+func VerifyAuditChain(records []storage.AuditRecord, prevSeq uint64, prevHash string) error {
+	for _, el := range records {
+		if el.Seq != prevSeq+1 {
+			return fmt.Errorf("the audit record %d follows the record %d", el.Seq, prevSeq)
+		}
+		if el.PrevHash != prevHash {
+			return fmt.Errorf("the audit record %d does not chain to the previous record", el.Seq)
+		}
+		hash, err := AuditHash(el)
+		if err != nil {
+			return err
+		}
+		if el.Hash != hash {
+			return fmt.Errorf("the hash of the audit record %d does not match its content", el.Seq)
+		}
+		prevSeq, prevHash = el.Seq, el.Hash
+	}
+	return nil
+}
+
+// all return all the records of the log, the chain is verified, the caller holds the lock
+func (a *AuditLog) all() ([]storage.AuditRecord, error) {
+	records := a.records
+	if a.store != nil {
+		var err error
+		records, err = a.store.RetrieveAuditRecords()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("fail to retrieve the audit records: %w", err)
+		}
+	}
+	if err := VerifyAuditChain(records, 0, ""); err != nil {
+		return nil, fmt.Errorf("the audit log is tampered: %w", err)
+	}
+	return records, nil
+}
+
+// Append chain the record to the end of the log, it fails if the log we have is tampered with, the record is
+// returned with its seq and its hashes
+func (a *AuditLog) Append(record storage.AuditRecord) (storage.AuditRecord, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if !a.loaded {
+		records, err := a.all()
+		if err != nil {
+			return record, err
+		}
+		if len(records) > 0 {
+			last := records[len(records)-1]
+			a.lastSeq, a.lastHash = last.Seq, last.Hash
+		}
+		a.loaded = true
+	}
+	record.Seq = a.lastSeq + 1
+	record.PrevHash = a.lastHash
+	hash, err := AuditHash(record)
+	if err != nil {
+		return record, err
+	}
+	record.Hash = hash
+	if a.store != nil {
+		if err := a.store.AppendAuditRecord(record); err != nil {
+			return record, fmt.Errorf("fail to append the audit record: %w", err)
+		}
+	} else {
+		a.records = append(a.records, record)
+	}
+	a.lastSeq, a.lastHash = record.Seq, record.Hash
+	return record, nil
+}
+
+// Export return the records of the log from the seq on, the whole chain is verified first, so the exported records
+// can be checked against the hash of the record before them
+func (a *AuditLog) Export(fromSeq uint64) ([]storage.AuditRecord, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	records, err := a.all()
+	if err != nil {
+		return nil, err
+	}
+	ret := []storage.AuditRecord{}
+	for _, el := range records {
+		if el.Seq >= fromSeq {
+			ret = append(ret, el)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,93 @@
+package keysign
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+type AuditTestSuite struct{}
+
+var _ = Suite(&AuditTestSuite{})
+
+func auditRecord(poolPubKey string) storage.AuditRecord {
+	return storage.AuditRecord{
+		Time:       time.Now().UTC(),
+		PoolPubKey: poolPubKey,
+		Messages:   []string{"00ff"},
+		Requester:  "signer",
+		Parties:    []string{"party1", "party2"},
+		Status:     "success",
+	}
+}
+
+func (AuditTestSuite) TestAppend(c *C) {
+	// the records are only kept in memory without a store
+	auditLog := NewAuditLog(nil)
+	first, err := auditLog.Append(auditRecord("pool"))
+	c.Assert(err, IsNil)
+	c.Assert(first.Seq, Equals, uint64(1))
+	c.Assert(first.PrevHash, Equals, "")
+	second, err := auditLog.Append(auditRecord("pool"))
+	c.Assert(err, IsNil)
+	c.Assert(second.Seq, Equals, uint64(2))
+	c.Assert(second.PrevHash, Equals, first.Hash)
+	records, err := auditLog.Export(0)
+	c.Assert(err, IsNil)
+	c.Assert(records, DeepEquals, []storage.AuditRecord{first, second})
+	records, err = auditLog.Export(2)
+	c.Assert(err, IsNil)
+	c.Assert(records, DeepEquals, []storage.AuditRecord{second})
+	records, err = auditLog.Export(3)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+
+	// the log goes on where the store left it
+	store := storage.NewMemoryStateMgr()
+	_, err = NewAuditLog(store).Append(auditRecord("pool"))
+	c.Assert(err, IsNil)
+	third, err := NewAuditLog(store).Append(auditRecord("pool"))
+	c.Assert(err, IsNil)
+	c.Assert(third.Seq, Equals, uint64(2))
+	records, err = store.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(VerifyAuditChain(records, 0, ""), IsNil)
+	// the exported records chain to the record before them
+	c.Assert(VerifyAuditChain(records[1:], records[0].Seq, records[0].Hash), IsNil)
+}
+
+func (AuditTestSuite) TestTampered(c *C) {
+	store := storage.NewMemoryStateMgr()
+	auditLog := NewAuditLog(store)
+	for i := 0; i < 3; i++ {
+		_, err := auditLog.Append(auditRecord("pool"))
+		c.Assert(err, IsNil)
+	}
+	records, err := store.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+
+	changed := append([]storage.AuditRecord{}, records...)
+	changed[1].Messages = []string{"0000"}
+	c.Assert(VerifyAuditChain(changed, 0, ""), NotNil)
+	dropped := []storage.AuditRecord{records[0], records[2]}
+	c.Assert(VerifyAuditChain(dropped, 0, ""), NotNil)
+	// a record whose hash is computed again does not chain to the next one
+	rehashed := append([]storage.AuditRecord{}, records...)
+	rehashed[1].Requester = "someone"
+	rehashed[1].Hash, err = AuditHash(rehashed[1])
+	c.Assert(err, IsNil)
+	c.Assert(VerifyAuditChain(rehashed, 0, ""), NotNil)
+
+	// we do not extend nor export a tampered log
+	tampered := storage.NewMemoryStateMgr()
+	for _, el := range changed {
+		c.Assert(tampered.AppendAuditRecord(el), IsNil)
+	}
+	_, err = NewAuditLog(tampered).Append(auditRecord("pool"))
+	c.Assert(err, NotNil)
+	_, err = NewAuditLog(tampered).Export(0)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,26 @@
+package keysign
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// BusyError is returned when the keysign queue of the server is full, the caller should send the keysign again
+// after RetryAfter instead of waiting for it to time out
+type BusyError struct {
+	// RetryAfter is how long the keysigns in the queue are expected to take
+	RetryAfter time.Duration
+	// Queued is the number of the keysigns waiting in the queue
+	Queued int
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("busy with %d queued keysigns, retry after %s", e.Queued, e.RetryAfter)
+}
+
+// ErrorCode return the code of the busy error
+func (e *BusyError) ErrorCode() errcode.Code {
+	return errcode.Busy
+}
@@ -0,0 +1,95 @@
+package keysign
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// HashMode is the hash the node applies to the messages of the keysign before it signs them
+type HashMode string
+
+const (
+	// HashModeSHA256 signs the SHA256 digests of the messages
+	HashModeSHA256 HashMode = "sha256"
+	// HashModeKeccak256 signs the legacy Keccak256 digests of the messages, as ethereum does
+	HashModeKeccak256 HashMode = "keccak256"
+	// HashModeSHA512 signs the SHA512 digests of the messages, they are longer than the secp256k1 order
+	HashModeSHA512 HashMode = "sha512"
+)
+
+// secp256k1DigestLength is the length of the secp256k1 order in bytes, the longer ecdsa digests are reduced to it
+const secp256k1DigestLength = 32
+
+// Validate check the hash mode is known
+func (h HashMode) Validate() error {
+	switch h {
+	case "", HashModeSHA256, HashModeKeccak256, HashModeSHA512:
+		return nil
+	default:
+		return fmt.Errorf("unknown hash mode %s", h)
+	}
+}
+
+// Hash return the digest of the message, the message itself if the hash mode is empty
+func (h HashMode) Hash(msg []byte) []byte {
+	switch h {
+	case HashModeSHA256:
+		digest := sha256.Sum256(msg)
+		return digest[:]
+	case HashModeKeccak256:
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write(msg)
+		return hasher.Sum(nil)
+	case HashModeSHA512:
+		digest := sha512.Sum512(msg)
+		return digest[:]
+	default:
+		return msg
+	}
+}
+
+// Digests return the digests the keysign of the request signs with a key of the algo, the messages are hashed
+// with the hash mode of the request. Each digest is checked against the digest length of the request and what the
+// key can sign: the schnorr signatures sign the 32 bytes digests, the ecdsa digests longer than the curve order are
+// only signed if the request reduces them to its leftmost bits (SEC1 4.1.3), and the eddsa signatures sign the
+// digests as they are
+func (r Request) Digests(algo conversion.Algo) ([][]byte, error) {
+	if err := r.HashMode.Validate(); err != nil {
+		return nil, err
+	}
+	if r.DigestLength < 0 {
+		return nil, fmt.Errorf("invalid digest length %d", r.DigestLength)
+	}
+	if r.ReduceDigest && (algo.OrDefault() != conversion.AlgoSecp256k1 || r.Mode.IsSchnorr()) {
+		return nil, fmt.Errorf("only the ecdsa digests are reduced to the curve order")
+	}
+	digests := make([][]byte, len(r.Messages))
+	for i, val := range r.Messages {
+		msg, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return nil, fmt.Errorf("fail to decode message(%s): %w", val, err)
+		}
+		digest := r.HashMode.Hash(msg)
+		if r.DigestLength > 0 && len(digest) != r.DigestLength {
+			return nil, fmt.Errorf("the digest of message %s is %d bytes, expect %d", val, len(digest), r.DigestLength)
+		}
+		if algo.OrDefault() == conversion.AlgoSecp256k1 {
+			switch {
+			case r.Mode.IsSchnorr() && len(digest) != secp256k1DigestLength:
+				return nil, fmt.Errorf("the schnorr signatures only sign the 32 bytes digests, the digest of message %s is %d bytes", val, len(digest))
+			case len(digest) > secp256k1DigestLength && !r.ReduceDigest:
+				return nil, fmt.Errorf("the digest of message %s is %d bytes, longer than the curve order, the request has to reduce it", val, len(digest))
+			case len(digest) > secp256k1DigestLength:
+				digest = digest[:secp256k1DigestLength]
+			}
+		}
+		digests[i] = digest
+	}
+	return digests, nil
+}
@@ -0,0 +1,87 @@
+package keysign
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type DigestTestSuite struct{}
+
+var _ = Suite(&DigestTestSuite{})
+
+func (DigestTestSuite) TestHashMode(c *C) {
+	c.Assert(HashMode("").Validate(), IsNil)
+	c.Assert(HashModeKeccak256.Validate(), IsNil)
+	c.Assert(HashMode("md5").Validate(), NotNil)
+
+	msg := []byte("hello")
+	c.Assert(HashMode("").Hash(msg), DeepEquals, msg)
+	sha := sha256.Sum256(msg)
+	c.Assert(HashModeSHA256.Hash(msg), DeepEquals, sha[:])
+	c.Assert(hex.EncodeToString(HashModeKeccak256.Hash(nil)), Equals, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470")
+	c.Assert(HashModeSHA512.Hash(msg), HasLen, 64)
+}
+
+func (DigestTestSuite) TestDigests(c *C) {
+	msg := []byte("hello")
+	req := NewRequest("pool", []string{base64.StdEncoding.EncodeToString(msg)}, 10, nil, "0.14.0")
+
+	// the messages are signed as they are without a hash mode
+	digests, err := req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, IsNil)
+	c.Assert(digests, DeepEquals, [][]byte{msg})
+	req.DigestLength = 32
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, NotNil)
+
+	req.HashMode = HashModeSHA256
+	digests, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, IsNil)
+	sha := sha256.Sum256(msg)
+	c.Assert(digests, DeepEquals, [][]byte{sha[:]})
+	req.Mode = SignModeSchnorr
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, IsNil)
+
+	// the sha512 digests are longer than the curve order, the ecdsa keysign signs their leftmost bits
+	req.Mode = ""
+	req.HashMode = HashModeSHA512
+	req.DigestLength = 0
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, ErrorMatches, ".*the request has to reduce it")
+	req.ReduceDigest = true
+	digests, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, IsNil)
+	sha512Digest := sha512.Sum512(msg)
+	c.Assert(digests, DeepEquals, [][]byte{sha512Digest[:32]})
+
+	// the schnorr signatures can not reduce the digests
+	req.Mode = SignModeTaproot
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, NotNil)
+	req.ReduceDigest = false
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, ErrorMatches, "the schnorr signatures only sign the 32 bytes digests.*")
+
+	// eddsa signs the digests as they are
+	req.Mode = ""
+	digests, err = req.Digests(conversion.AlgoEd25519)
+	c.Assert(err, IsNil)
+	c.Assert(digests, DeepEquals, [][]byte{sha512Digest[:]})
+	req.ReduceDigest = true
+	_, err = req.Digests(conversion.AlgoEd25519)
+	c.Assert(err, NotNil)
+
+	req = NewRequest("pool", []string{"not base64"}, 10, nil, "0.14.0")
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, NotNil)
+	req.HashMode = "md5"
+	_, err = req.Digests(conversion.AlgoSecp256k1)
+	c.Assert(err, ErrorMatches, "unknown hash mode md5")
+}
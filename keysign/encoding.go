@@ -0,0 +1,69 @@
+package keysign
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// SignatureEncoding is the encoding of the signatures the keysign returns besides the R and S
+type SignatureEncoding string
+
+const (
+	// SignatureEncodingDER encodes the ecdsa signatures in DER
+	SignatureEncodingDER SignatureEncoding = "der"
+	// SignatureEncodingCompact encodes the signatures as the 64 bytes R || S, each of them padded to 32 bytes
+	SignatureEncodingCompact SignatureEncoding = "compact"
+)
+
+// Validate check the encoding can encode the signatures of the sign mode
+func (e SignatureEncoding) Validate(mode SignMode) error {
+	switch e {
+	case "", SignatureEncodingCompact:
+		return nil
+	case SignatureEncodingDER:
+		if mode.IsSchnorr() {
+			return errors.New("the schnorr signatures have no DER encoding")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown signature encoding %s", e)
+	}
+}
+
+// NormalizeLowS return the low S form of the ecdsa signature (BIP-62), flipped is true if S is replaced by N - S,
+// the recovery id of the signature flips its parity then
+func NormalizeLowS(s []byte) ([]byte, bool) {
+	n := btcec.S256().N
+	value := new(big.Int).SetBytes(s)
+	if value.Cmp(new(big.Int).Rsh(n, 1)) <= 0 {
+		return s, false
+	}
+	return value.Sub(n, value).Bytes(), true
+}
+
+// EncodeSignature encode the R and S of the signature, it return nil if the encoding is empty
+func EncodeSignature(r, s []byte, encoding SignatureEncoding) ([]byte, error) {
+	if len(r) > 32 || len(s) > 32 {
+		return nil, errors.New("invalid signature")
+	}
+	switch encoding {
+	case "":
+		return nil, nil
+	case SignatureEncodingDER:
+		sig := btcec.Signature{
+			R: new(big.Int).SetBytes(r),
+			S: new(big.Int).SetBytes(s),
+		}
+		return sig.Serialize(), nil
+	case SignatureEncodingCompact:
+		compact := make([]byte, 64)
+		copy(compact[32-len(r):32], r)
+		copy(compact[64-len(s):], s)
+		return compact, nil
+	default:
+		return nil, fmt.Errorf("unknown signature encoding %s", encoding)
+	}
+}
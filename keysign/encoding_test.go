@@ -0,0 +1,72 @@
+package keysign
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	. "gopkg.in/check.v1"
+)
+
+type EncodingTestSuite struct{}
+
+var _ = Suite(&EncodingTestSuite{})
+
+func (EncodingTestSuite) TestValidate(c *C) {
+	for _, el := range []SignatureEncoding{"", SignatureEncodingDER, SignatureEncodingCompact} {
+		c.Assert(el.Validate(SignModeECDSA), IsNil)
+	}
+	c.Assert(SignatureEncodingCompact.Validate(SignModeTaproot), IsNil)
+	c.Assert(SignatureEncodingDER.Validate(SignModeSchnorr), NotNil)
+	c.Assert(SignatureEncoding("pem").Validate(SignModeECDSA), NotNil)
+}
+
+func (EncodingTestSuite) TestNormalizeLowS(c *C) {
+	priKey, err := btcec.NewPrivateKey(btcec.S256())
+	c.Assert(err, IsNil)
+	hash := sha256.Sum256([]byte("helloworld"))
+	sig, err := priKey.Sign(hash[:])
+	c.Assert(err, IsNil)
+	s, flipped := NormalizeLowS(sig.S.Bytes())
+	c.Assert(flipped, Equals, false)
+	c.Assert(s, DeepEquals, sig.S.Bytes())
+
+	// the high S of the same signature verifies too, it is normalized back
+	highS := new(big.Int).Sub(btcec.S256().N, sig.S)
+	c.Assert((&btcec.Signature{R: sig.R, S: highS}).Verify(hash[:], priKey.PubKey()), Equals, true)
+	s, flipped = NormalizeLowS(highS.Bytes())
+	c.Assert(flipped, Equals, true)
+	c.Assert(new(big.Int).SetBytes(s).Cmp(sig.S), Equals, 0)
+}
+
+func (EncodingTestSuite) TestEncodeSignature(c *C) {
+	priKey, err := btcec.NewPrivateKey(btcec.S256())
+	c.Assert(err, IsNil)
+	hash := sha256.Sum256([]byte("helloworld"))
+	sig, err := priKey.Sign(hash[:])
+	c.Assert(err, IsNil)
+	r := sig.R.Bytes()
+	// the short S is padded in the compact encoding
+	s := []byte{0x01, 0x02}
+
+	encoded, err := EncodeSignature(r, sig.S.Bytes(), "")
+	c.Assert(err, IsNil)
+	c.Assert(encoded, IsNil)
+
+	encoded, err = EncodeSignature(r, sig.S.Bytes(), SignatureEncodingDER)
+	c.Assert(err, IsNil)
+	parsed, err := btcec.ParseDERSignature(encoded, btcec.S256())
+	c.Assert(err, IsNil)
+	c.Assert(parsed.Verify(hash[:], priKey.PubKey()), Equals, true)
+
+	encoded, err = EncodeSignature(r, s, SignatureEncodingCompact)
+	c.Assert(err, IsNil)
+	c.Assert(encoded, HasLen, 64)
+	c.Assert(new(big.Int).SetBytes(encoded[:32]).Cmp(sig.R), Equals, 0)
+	c.Assert(encoded[62:], DeepEquals, s)
+
+	_, err = EncodeSignature(r, s, SignatureEncoding("pem"))
+	c.Assert(err, NotNil)
+	_, err = EncodeSignature(make([]byte, 33), s, SignatureEncodingCompact)
+	c.Assert(err, NotNil)
+}
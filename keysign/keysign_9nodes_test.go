@@ -0,0 +1,167 @@
+package keysign
+
+import (
+	"encoding/base64"
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	maddr "github.com/multiformats/go-multiaddr"
+	tcrypto "github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+const (
+	test9NodesPoolPubKey = "thorpub1addwnpepqtml00e2qy42k2kkru6xruqlutq0p0psav8efvndgx6kkdxukty862kar3a"
+	// min9NodesCPU is the number of cores we need to run 10 keysigns of the 9 nodes in a single process
+	min9NodesCPU = 4
+)
+
+var (
+	// test9NodesPubKeys are sorted, the keyshare of the key i is test_data/keysign_data_9/i.json
+	test9NodesPubKeys = []string{
+		"thorpub1addwnpepq2klcmufzzk0cl7qay00s3q0zxug8ce55rl3jjwyta44kjxdnlnrs8dq65j", // peerID is 16Uiu2HAm78qmSpiUd1TnZScxzpAh46CQuPB4er7fJ1gspSpsS5Ls
+		"thorpub1addwnpepqddpxr4naaz0uw9vng7sanqf6adq6plq5equfc36jxzrpuu4faj66ssunw7", // peerID is 16Uiu2HAmJibu2r38Mi4XqY1fVK97zyVTtamDE9kdQ8aczFooMDAt
+		"thorpub1addwnpepqdjvvqpa0amhazjj9hw78kqtyvpu2jj5khgy29y2qnmt0364crax2vuw733", // peerID is 16Uiu2HAmKSNEVYBGteZebmWFJe1X5MPQioTovxJfyrtkUzD34KPA
+		"thorpub1addwnpepqfqas335qul72wyjm3kqwgudh2c9tjxpvk4w0wdv6zj0rw3xcs62v5j64gh", // peerID is 16Uiu2HAkyrhkhzh17rmwUXKuCA9nf3WJ9RqNuKsg8BqfVuzTuLRj
+		"thorpub1addwnpepqtd3g9fegaea8ywlyqnkxv5025mguatfd9s0ynw7vg2m79vcgqchk0emyst", // peerID is 16Uiu2HAmAAs2XsMhn33B5BXwDbrgjWYEdR8eDaLfc3sPe3oj8A8i
+		"thorpub1addwnpepqvfmdqldf4u9fwftfyqkdr8s8zhy7063mxsq7xv6fz3saddaaq4fc9c2z2a", // peerID is 16Uiu2HAmDywWncpRxhs2u6UC7JkZA4hWWPBxMVSodEkVgAyNZNMH
+		"thorpub1addwnpepqvgvt5tlr54ucg2hupr7z0vp0gxnrw3khyyrkl8l2ycgm8j3dsnvwz8gd0l", // peerID is 16Uiu2HAmDnTpphRFYxKLiYVSB3CKi7Nwd5YVegEWF3bqX7PLgZSN
+		"thorpub1addwnpepqwusfr09p8sn4hwl4mctz6eqjlwxdwn88dkae0cgpnhnlw06lmnaxzsl6u2", // peerID is 16Uiu2HAmR7DoVzbfQNZVxbhQm9UhoVSz6aNEipRLRpxJeJp7c9D4
+		"thorpub1addwnpepqwyq9445egtugc7q0hfxxg0klvptksut3y4h08aqgq5sr00uz97fxrlpjtq", // peerID is 16Uiu2HAmMovJpdvWY4CSPLFi5XDpFUVAcyWUweWBZnU3J6mDKBdt
+	}
+	test9NodesPriKeys = []string{
+		"OkZCOyC7jwzNOXnGE+/5pJ5Y6AnqRrXpxjBURJFsuFs=",
+		"bIiHFoZAYKvnCAVzcO3vtEBfLqed//IJ7SwRjcKKoOA=",
+		"A9leCWFje6HOTiB5OG2i64l4A/bQVV7FgMywzpCyiFQ=",
+		"RaJDVbnIpg8ho0jaddIwa/tv1+Wr/afsE4J+RMT2Wc4=",
+		"yUqjArLaZrDUkDXvYfwT4i5eqlJ7vj+HXDc50khNcL4=",
+		"yKJX4zSugyJ4dZbkIN/OvZ9TxdUjAyRYBpvU/+Nbdtg=",
+		"4XgYhntxz3VRsXFQKX/VwqbObTk21eH4B4TImfpW4kM=",
+		"PWqfeaoYi8Lt/NhoXnaJvirdiLFodaLVggsDegm29cM=",
+		"XHJiofUJ2rlnsT+ns/d/eGsqZvO/tEuXSYgvqZNYR/I=",
+	}
+)
+
+// TssKeysign9NodesTestSuite runs the keysigns of a 9 nodes committee, its key needs 6 signers
+type TssKeysign9NodesTestSuite struct {
+	comms        []*p2p.Communication
+	stateMgrs    []storage.LocalStateManager
+	nodePrivKeys []tcrypto.PrivKey
+}
+
+var _ = Suite(&TssKeysign9NodesTestSuite{})
+
+func (s *TssKeysign9NodesTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+	common.InitLog("info", true, "keysign_9nodes_test")
+	// the 9 nodes share the cpu of a single process, a node busy with the proofs of 10 ceremonies may not write the
+	// message of a stream it opened within the read timeout of its peer, which drops the message
+	p2p.ApplyDeadline = false
+	for _, el := range test9NodesPriKeys {
+		buf, err := base64.StdEncoding.DecodeString(el)
+		c.Assert(err, IsNil)
+		s.nodePrivKeys = append(s.nodePrivKeys, secp256k1.PrivKey(buf))
+	}
+}
+
+func (s *TssKeysign9NodesTestSuite) TearDownSuite(c *C) {
+	p2p.ApplyDeadline = true
+}
+
+// skip9Nodes skips the test in the short mode, or if the machine has too few cores for the parties of the 10
+// ceremonies to compute their proofs, the starved nodes then fail to dial each other and drop the connections
+func skip9Nodes(c *C) bool {
+	if testing.Short() {
+		c.Skip("skip the test")
+		return true
+	}
+	if runtime.NumCPU() < min9NodesCPU {
+		c.Skip(fmt.Sprintf("the 9 nodes need at least %d cores", min9NodesCPU))
+		return true
+	}
+	return false
+}
+
+func (s *TssKeysign9NodesTestSuite) SetUpTest(c *C) {
+	if skip9Nodes(c) {
+		return
+	}
+	partyNum := len(test9NodesPubKeys)
+	s.comms = make([]*p2p.Communication, partyNum)
+	s.stateMgrs = make([]storage.LocalStateManager, partyNum)
+	multiAddr, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/17766/p2p/16Uiu2HAm78qmSpiUd1TnZScxzpAh46CQuPB4er7fJ1gspSpsS5Ls")
+	c.Assert(err, IsNil)
+	for i := 0; i < partyNum; i++ {
+		buf, err := base64.StdEncoding.DecodeString(test9NodesPriKeys[i])
+		c.Assert(err, IsNil)
+		var bootstrapPeers []p2p.Multiaddr
+		if i > 0 {
+			bootstrapPeers = []p2p.Multiaddr{multiAddr}
+		}
+		comm, err := p2p.NewCommunication("asgard", bootstrapPeers, 17766+i, "", p2p.WithCeremonyStreams(true))
+		c.Assert(err, IsNil)
+		c.Assert(comm.Start(buf), IsNil)
+		s.comms[i] = comm
+		s.stateMgrs[i] = &MockLocalStateManager{
+			file: fmt.Sprintf("../test_data/keysign_data_9/%d.json", i),
+		}
+	}
+	// the nodes only know the bootstrap node at start, the ceremonies fail to dial a peer that is still being
+	// discovered, so we wait until every node is connected to all the others
+	deadline := time.Now().Add(time.Minute)
+	for !fullyConnected(s.comms) {
+		c.Assert(time.Now().Before(deadline), Equals, true, Commentf("the nodes fail to connect to each other"))
+		time.Sleep(time.Second)
+	}
+}
+
+func fullyConnected(comms []*p2p.Communication) bool {
+	for _, comm := range comms {
+		for _, other := range comms {
+			if comm == other {
+				continue
+			}
+			if comm.GetHost().Network().Connectedness(other.GetHost().ID()) != network.Connected {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *TssKeysign9NodesTestSuite) TearDownTest(c *C) {
+	if skip9Nodes(c) {
+		return
+	}
+	time.Sleep(time.Second)
+	for _, item := range s.comms {
+		c.Assert(item.Stop(), IsNil)
+	}
+}
+
+// TestConcurrentSignMessages run 10 keysigns of the 9 nodes at the same time, the committee is large enough that
+// the ceremonies interleave their rounds on the streams of the same peers
+func (s *TssKeysign9NodesTestSuite) TestConcurrentSignMessages(c *C) {
+	if skip9Nodes(c) {
+		return
+	}
+	pubKeys := make([]string, len(test9NodesPubKeys))
+	copy(pubKeys, test9NodesPubKeys)
+	sort.Strings(pubKeys)
+	conf := common.TssConfig{
+		KeyGenTimeout:   10 * time.Minute,
+		KeySignTimeout:  10 * time.Minute,
+		PreParamTimeout: 5 * time.Second,
+	}
+	runConcurrentKeysigns(c, conf, 10, test9NodesPoolPubKey, pubKeys, s.comms, s.nodePrivKeys, s.stateMgrs)
+}
@@ -141,13 +141,13 @@ func (s *TssKeysignTestSuite) SetUpTest(c *C) {
 		buf, err := base64.StdEncoding.DecodeString(testPriKeyArr[i])
 		c.Assert(err, IsNil)
 		if i == 0 {
-			comm, err := p2p.NewCommunication("asgard", nil, ports[i], "")
+			comm, err := p2p.NewCommunication("asgard", nil, ports[i], "", p2p.WithCeremonyStreams(true))
 			c.Assert(err, IsNil)
 			c.Assert(comm.Start(buf), IsNil)
 			s.comms[i] = comm
 			continue
 		}
-		comm, err := p2p.NewCommunication("asgard", []p2p.Multiaddr{multiAddr}, ports[i], "")
+		comm, err := p2p.NewCommunication("asgard", []p2p.Multiaddr{multiAddr}, ports[i], "", p2p.WithCeremonyStreams(true))
 		c.Assert(err, IsNil)
 		c.Assert(comm.Start(buf), IsNil)
 		s.comms[i] = comm
@@ -236,6 +236,80 @@ func (s *TssKeysignTestSuite) TestSignMessage(c *C) {
 	}
 }
 
+// TestConcurrentSignMessages run 10 keysigns at the same time over the same nodes, each ceremony has its own
+// party, subscriptions and protocol, so they should all get the same signatures on all the nodes
+func (s *TssKeysignTestSuite) TestConcurrentSignMessages(c *C) {
+	if testing.Short() {
+		c.Skip("skip the test")
+		return
+	}
+	sort.Strings(testPubKeys)
+	conf := common.TssConfig{
+		KeyGenTimeout:   120 * time.Second,
+		KeySignTimeout:  120 * time.Second,
+		PreParamTimeout: 5 * time.Second,
+	}
+	runConcurrentKeysigns(c, conf, 10, "thorpub1addwnpepqv6xp3fmm47dfuzglywqvpv8fdjv55zxte4a26tslcezns5czv586u2fw33",
+		testPubKeys, s.comms, s.nodePrivKeys, s.stateMgrs)
+}
+
+// runConcurrentKeysigns run the keysigns of the pool key at the same time over all the nodes, they should all get
+// the same signatures on all the nodes, and no two keysigns the same signature
+func runConcurrentKeysigns(c *C, conf common.TssConfig, ceremonies int, poolPubKey string, pubKeys []string,
+	comms []*p2p.Communication, nodePrivKeys []tcrypto.PrivKey, stateMgrs []storage.LocalStateManager) {
+	wg := sync.WaitGroup{}
+	lock := &sync.Mutex{}
+	keysignResult := make(map[string]map[int]string)
+	for j := 0; j < ceremonies; j++ {
+		msg := fmt.Sprintf("concurrent-%d", j)
+		messageID, err := common.MsgToHashString([]byte(msg))
+		c.Assert(err, IsNil)
+		keysignResult[messageID] = make(map[int]string)
+		for i := range comms {
+			wg.Add(1)
+			go func(idx int, messageID, msg string) {
+				defer wg.Done()
+				comm := comms[idx]
+				keysignIns := NewTssKeySign(comm.GetLocalPeerID(),
+					conf,
+					comm.BroadcastMsgChan,
+					make(chan struct{}), messageID,
+					nodePrivKeys[idx], comm, stateMgrs[idx], 1)
+				keysignMsgChannel := keysignIns.GetTssKeySignChannels()
+
+				comm.SetSubscribe(messages.TSSKeySignMsg, messageID, keysignMsgChannel)
+				comm.SetSubscribe(messages.TSSKeySignVerMsg, messageID, keysignMsgChannel)
+				comm.SetSubscribe(messages.TSSControlMsg, messageID, keysignMsgChannel)
+				comm.SetSubscribe(messages.TSSTaskDone, messageID, keysignMsgChannel)
+				defer comm.CancelSubscribe(messages.TSSKeySignMsg, messageID)
+				defer comm.CancelSubscribe(messages.TSSKeySignVerMsg, messageID)
+				defer comm.CancelSubscribe(messages.TSSControlMsg, messageID)
+				defer comm.CancelSubscribe(messages.TSSTaskDone, messageID)
+
+				localState, err := stateMgrs[idx].GetLocalState(poolPubKey)
+				c.Assert(err, IsNil)
+				sig, err := keysignIns.SignMessage([][]byte{[]byte(msg)}, localState, pubKeys)
+				c.Assert(err, IsNil)
+				c.Assert(sig, HasLen, 1)
+				lock.Lock()
+				defer lock.Unlock()
+				keysignResult[messageID][idx] = string(sig[0].GetSignature())
+			}(i, messageID, msg)
+		}
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, item := range keysignResult {
+		c.Assert(item, HasLen, len(comms))
+		for i := 1; i < len(comms); i++ {
+			c.Assert(item[i], Equals, item[0])
+		}
+		c.Assert(seen[item[0]], Equals, false)
+		seen[item[0]] = true
+	}
+}
+
 func observeAndStop(c *C, tssKeySign *TssKeySign, stopChan chan struct{}) {
 	for {
 		select {
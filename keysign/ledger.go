@@ -0,0 +1,136 @@
+package keysign
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// ErrDoubleSign is returned when the keysign conflicts with a keysign of the pool key we have taken part in, like
+// another transaction that spends the same utxo
+var ErrDoubleSign = errcode.New(errcode.DoubleSign, "the keysign conflicts with a keysign we have signed")
+
+// LedgerStore persists the sign records of the pools, so the ledger survives the restarts
+type LedgerStore interface {
+	SaveSignRecords(poolPubKey string, records []storage.SignRecord) error
+	RetrieveSignRecords(poolPubKey string) ([]storage.SignRecord, error)
+}
+
+// Ledger keeps the records of the keysigns we take part in, and refuses the keysigns of other messages that share
+// the value of a conflict rule with one of them. The rules are the metadata keys of the keysign requests, like utxo
+// or nonce, their values are comma separated
+type Ledger struct {
+	lock      *sync.Mutex
+	store     LedgerStore
+	rules     []string
+	retention time.Duration
+	pools     map[string][]storage.SignRecord
+}
+
+// NewLedger create a new ledger, the records older than the retention are dropped, 0 keeps them forever. The
+// records are only kept in memory if the store is nil
+func NewLedger(store LedgerStore, rules []string, retention time.Duration) *Ledger {
+	return &Ledger{
+		lock:      &sync.Mutex{},
+		store:     store,
+		rules:     rules,
+		retention: retention,
+		pools:     make(map[string][]storage.SignRecord),
+	}
+}
+
+// load return the sign records of the pool, they are read from the store the first time
+func (l *Ledger) load(poolPubKey string) ([]storage.SignRecord, error) {
+	if records, ok := l.pools[poolPubKey]; ok {
+		return records, nil
+	}
+	var records []storage.SignRecord
+	if l.store != nil {
+		var err error
+		records, err = l.store.RetrieveSignRecords(poolPubKey)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("fail to retrieve the sign records: %w", err)
+		}
+	}
+	l.pools[poolPubKey] = records
+	return records, nil
+}
+
+// conflicts return the values of the conflict rules in the metadata
+func (l *Ledger) conflicts(metadata map[string]string) map[string][]string {
+	conflicts := make(map[string][]string)
+	for _, rule := range l.rules {
+		for _, el := range strings.Split(metadata[rule], ",") {
+			if el = strings.TrimSpace(el); len(el) > 0 {
+				conflicts[rule] = append(conflicts[rule], el)
+			}
+		}
+	}
+	return conflicts
+}
+
+// Record check the keysign does not conflict with the keysigns in the ledger, and record it. The same messages
+// can be signed again, as the failed keysigns are tried again
+func (l *Ledger) Record(req PolicyRequest) error {
+	msgs := make([]string, len(req.Messages))
+	for i, el := range req.Messages {
+		msgs[i] = hex.EncodeToString(el)
+	}
+	sort.Strings(msgs)
+	key := strings.Join(msgs, ",")
+	conflicts := l.conflicts(req.Metadata)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	records, err := l.load(req.PoolPubKey)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var kept []storage.SignRecord
+	for _, el := range records {
+		if l.retention > 0 && now.Sub(el.SignedAt) > l.retention {
+			continue
+		}
+		kept = append(kept, el)
+		if strings.Join(el.Messages, ",") == key {
+			continue
+		}
+		for rule, values := range conflicts {
+			for _, value := range values {
+				for _, signed := range el.Conflicts[rule] {
+					if signed == value {
+						return fmt.Errorf("%w: %s %s is signed in message(s) %s at %s", ErrDoubleSign, rule, value, strings.Join(el.Messages, ","), el.SignedAt.Format(time.RFC3339))
+					}
+				}
+			}
+		}
+	}
+	for _, el := range kept {
+		if strings.Join(el.Messages, ",") == key {
+			return nil
+		}
+	}
+	record := storage.SignRecord{
+		Messages: msgs,
+		SignedAt: now,
+	}
+	if len(conflicts) > 0 {
+		record.Conflicts = conflicts
+	}
+	kept = append(kept, record)
+	if l.store != nil {
+		if err := l.store.SaveSignRecords(req.PoolPubKey, kept); err != nil {
+			return fmt.Errorf("fail to save the sign records: %w", err)
+		}
+	}
+	l.pools[req.PoolPubKey] = kept
+	return nil
+}
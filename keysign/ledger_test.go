@@ -0,0 +1,105 @@
+package keysign
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+type mockLedgerStore struct {
+	records map[string][]storage.SignRecord
+	saveErr error
+}
+
+func (m *mockLedgerStore) SaveSignRecords(poolPubKey string, records []storage.SignRecord) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.records[poolPubKey] = records
+	return nil
+}
+
+func (m *mockLedgerStore) RetrieveSignRecords(poolPubKey string) ([]storage.SignRecord, error) {
+	records, ok := m.records[poolPubKey]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return records, nil
+}
+
+type LedgerTestSuite struct{}
+
+var _ = Suite(&LedgerTestSuite{})
+
+func (LedgerTestSuite) TestRecord(c *C) {
+	store := &mockLedgerStore{records: make(map[string][]storage.SignRecord)}
+	ledger := NewLedger(store, []string{"utxo", "nonce"}, 0)
+	first := PolicyRequest{
+		PoolPubKey: "pool",
+		Messages:   [][]byte{[]byte("tx1-in0"), []byte("tx1-in1")},
+		Metadata:   map[string]string{"utxo": "txid:0, txid:1"},
+	}
+	c.Assert(ledger.Record(first), IsNil)
+	c.Assert(store.records["pool"], HasLen, 1)
+	c.Assert(store.records["pool"][0].Conflicts["utxo"], DeepEquals, []string{"txid:0", "txid:1"})
+
+	// the same messages can be signed again, in any order
+	again := first
+	again.Messages = [][]byte{first.Messages[1], first.Messages[0]}
+	c.Assert(ledger.Record(again), IsNil)
+	c.Assert(store.records["pool"], HasLen, 1)
+
+	// another transaction that spends one of the utxos is refused
+	conflict := PolicyRequest{
+		PoolPubKey: "pool",
+		Messages:   [][]byte{[]byte("tx2-in0")},
+		Metadata:   map[string]string{"utxo": "txid:1"},
+	}
+	err := ledger.Record(conflict)
+	c.Assert(errors.Is(err, ErrDoubleSign), Equals, true)
+	c.Assert(store.records["pool"], HasLen, 1)
+
+	// the other pool keys, the other utxos and the metadata that is not a rule do not conflict
+	conflict.PoolPubKey = "other"
+	c.Assert(ledger.Record(conflict), IsNil)
+	c.Assert(ledger.Record(PolicyRequest{
+		PoolPubKey: "pool",
+		Messages:   [][]byte{[]byte("tx3-in0")},
+		Metadata:   map[string]string{"utxo": "txid:2", "memo": "txid:0"},
+	}), IsNil)
+	c.Assert(store.records["pool"], HasLen, 2)
+
+	// the ledger is read back from the store
+	ledger = NewLedger(store, []string{"utxo"}, 0)
+	conflict.PoolPubKey = "pool"
+	c.Assert(errors.Is(ledger.Record(conflict), ErrDoubleSign), Equals, true)
+
+	// we do not sign if we can not record the keysign
+	store.saveErr = errors.New("disk full")
+	c.Assert(ledger.Record(PolicyRequest{PoolPubKey: "pool", Messages: [][]byte{[]byte("tx4")}}), NotNil)
+}
+
+func (LedgerTestSuite) TestRetention(c *C) {
+	store := &mockLedgerStore{records: map[string][]storage.SignRecord{
+		"pool": {
+			{
+				Messages:  []string{"aa"},
+				Conflicts: map[string][]string{"nonce": {"7"}},
+				SignedAt:  time.Now().Add(-2 * time.Hour),
+			},
+		},
+	}}
+	req := PolicyRequest{
+		PoolPubKey: "pool",
+		Messages:   [][]byte{[]byte("bb")},
+		Metadata:   map[string]string{"nonce": "7"},
+	}
+	c.Assert(errors.Is(NewLedger(store, []string{"nonce"}, 0).Record(req), ErrDoubleSign), Equals, true)
+	c.Assert(NewLedger(store, []string{"nonce"}, time.Hour).Record(req), IsNil)
+	c.Assert(store.records["pool"], HasLen, 1)
+	c.Assert(store.records["pool"][0].Messages, DeepEquals, []string{"6262"})
+}
@@ -0,0 +1,63 @@
+package keysign
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// ErrPolicyRejected is returned when the signing policy of the node vetoes the keysign, we do not take part in it
+var ErrPolicyRejected = errcode.New(errcode.PolicyRejected, "the keysign is rejected by the signing policy")
+
+// PolicyRequest is the keysign the signing policy decides on
+type PolicyRequest struct {
+	// PoolPubKey is the key we are asked to sign with
+	PoolPubKey string
+	// Messages are the decoded message hashes we are asked to sign
+	Messages [][]byte
+	// SignerPubKeys are the signers of the request, they are picked by the leader if it is empty
+	SignerPubKeys []string
+	BlockHeight   int64
+	Mode          SignMode
+	// DerivationPath is the path of the child key of the pool we sign with, it is empty if the pool key signs
+	DerivationPath string
+	// Metadata is the metadata of the request, like the transaction the messages come from
+	Metadata map[string]string
+}
+
+// Policy decides whether the node takes part in a keysign, it is asked before we join the party, so the embedders
+// can veto the requests that do not match the transactions they have verified themselves. It returns an error to
+// veto the keysign
+type Policy interface {
+	Check(req PolicyRequest) error
+}
+
+// PolicyFunc is the function that is a Policy
+type PolicyFunc func(req PolicyRequest) error
+
+// Check call the function
+func (f PolicyFunc) Check(req PolicyRequest) error {
+	return f(req)
+}
+
+// NewPolicyRequest return the request the signing policy decides on from the keysign request
+func NewPolicyRequest(req Request) (PolicyRequest, error) {
+	msgs := make([][]byte, len(req.Messages))
+	for i, el := range req.Messages {
+		msg, err := base64.StdEncoding.DecodeString(el)
+		if err != nil {
+			return PolicyRequest{}, fmt.Errorf("fail to decode message(%s): %w", el, err)
+		}
+		msgs[i] = msg
+	}
+	return PolicyRequest{
+		PoolPubKey:     req.PoolPubKey,
+		Messages:       msgs,
+		SignerPubKeys:  req.SignerPubKeys,
+		BlockHeight:    req.BlockHeight,
+		Mode:           req.Mode,
+		DerivationPath: req.DerivationPath,
+		Metadata:       req.Metadata,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package keysign
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type PolicyTestSuite struct{}
+
+var _ = Suite(&PolicyTestSuite{})
+
+func (PolicyTestSuite) TestNewPolicyRequest(c *C) {
+	req := NewRequest("pool", []string{"aGVsbG8=", "d29ybGQ="}, 10, []string{"a", "b"}, "0.14.0")
+	req.Mode = SignModeSchnorr
+	req.Metadata = map[string]string{"tx": "deadbeef"}
+	policyReq, err := NewPolicyRequest(req)
+	c.Assert(err, IsNil)
+	c.Assert(policyReq.PoolPubKey, Equals, "pool")
+	c.Assert(policyReq.Messages, DeepEquals, [][]byte{[]byte("hello"), []byte("world")})
+	c.Assert(policyReq.SignerPubKeys, DeepEquals, []string{"a", "b"})
+	c.Assert(policyReq.BlockHeight, Equals, int64(10))
+	c.Assert(policyReq.Mode, Equals, SignModeSchnorr)
+	c.Assert(policyReq.Metadata["tx"], Equals, "deadbeef")
+
+	req.Messages = []string{"not base64"}
+	_, err = NewPolicyRequest(req)
+	c.Assert(err, NotNil)
+}
+
+func (PolicyTestSuite) TestPolicyFunc(c *C) {
+	var policy Policy = PolicyFunc(func(req PolicyRequest) error {
+		if req.Metadata["tx"] == "" {
+			return errors.New("unknown transaction")
+		}
+		return nil
+	})
+	c.Assert(policy.Check(PolicyRequest{}), ErrorMatches, "unknown transaction")
+	c.Assert(policy.Check(PolicyRequest{Metadata: map[string]string{"tx": "deadbeef"}}), IsNil)
+}
@@ -0,0 +1,35 @@
+package keysign
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// RecoverableSignature return the 65 bytes [R || S || V] signature of the 32 bytes message hash the EVM chains
+// take, V is the recovery id of the pub key. The recovery id is found by recovering the key from the signature, so
+// it does not depend on the signers
+func RecoverableSignature(msgHash, r, s []byte, pubKey *ecdsa.PublicKey) ([]byte, error) {
+	if len(msgHash) != 32 {
+		return nil, errors.New("the message is not a 32 bytes hash")
+	}
+	if len(r) > 32 || len(s) > 32 {
+		return nil, errors.New("invalid signature")
+	}
+	// the compact signature is <27 + recovery id><R><S>
+	compact := make([]byte, 65)
+	copy(compact[33-len(r):33], r)
+	copy(compact[65-len(s):], s)
+	for v := byte(0); v < 4; v++ {
+		compact[0] = 27 + v
+		pk, _, err := btcec.RecoverCompact(btcec.S256(), compact, msgHash)
+		if err != nil {
+			continue
+		}
+		if pk.X.Cmp(pubKey.X) == 0 && pk.Y.Cmp(pubKey.Y) == 0 {
+			return append(compact[1:], v), nil
+		}
+	}
+	return nil, errors.New("the signature does not recover the pub key")
+}
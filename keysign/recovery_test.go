@@ -0,0 +1,51 @@
+package keysign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "gopkg.in/check.v1"
+)
+
+type RecoveryTestSuite struct{}
+
+var _ = Suite(&RecoveryTestSuite{})
+
+func (RecoveryTestSuite) TestRecoverableSignature(c *C) {
+	priKey, err := ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+	c.Assert(err, IsNil)
+	short := false
+	for i := 0; i < 2000 && (i < 50 || !short); i++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		hash := sha256.Sum256(buf[:])
+		sig, err := (*btcec.PrivateKey)(priKey).Sign(hash[:])
+		c.Assert(err, IsNil)
+		r, s := sig.R.Bytes(), sig.S.Bytes()
+		// the R or S with the leading zero bytes are padded
+		short = short || len(r) < 32 || len(s) < 32
+
+		recoverable, err := RecoverableSignature(hash[:], r, s, &priKey.PublicKey)
+		c.Assert(err, IsNil)
+		c.Assert(recoverable, HasLen, 65)
+		c.Assert(recoverable[64] < 2, Equals, true)
+		pubKey, err := crypto.Ecrecover(hash[:], recoverable)
+		c.Assert(err, IsNil)
+		c.Assert(pubKey, DeepEquals, crypto.FromECDSAPub(&priKey.PublicKey))
+	}
+	c.Assert(short, Equals, true)
+
+	hash := sha256.Sum256([]byte("helloworld"))
+	sig, err := (*btcec.PrivateKey)(priKey).Sign(hash[:])
+	c.Assert(err, IsNil)
+	other, err := ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+	c.Assert(err, IsNil)
+	_, err = RecoverableSignature(hash[:], sig.R.Bytes(), sig.S.Bytes(), &other.PublicKey)
+	c.Assert(err, NotNil)
+	_, err = RecoverableSignature([]byte("helloworld"), sig.R.Bytes(), sig.S.Bytes(), &priKey.PublicKey)
+	c.Assert(err, NotNil)
+}
@@ -1,12 +1,65 @@
 package keysign
 
+import "github.com/akildemir/go-tss/conversion"
+
+// SignMode is the kind of the signatures the keysign produces
+type SignMode string
+
+const (
+	// SignModeECDSA signs with the ecdsa signatures, it is the default
+	SignModeECDSA SignMode = "ecdsa"
+	// SignModeSchnorr signs with the BIP-340 schnorr signatures over the even y pool key
+	SignModeSchnorr SignMode = "schnorr"
+	// SignModeTaproot signs with the BIP-340 schnorr signatures over the BIP-341 output key of the pool key, it
+	// spends the taproot outputs through the key path
+	SignModeTaproot SignMode = "taproot"
+)
+
+// IsSchnorr return true if the keysign produces the schnorr signatures
+func (m SignMode) IsSchnorr() bool {
+	return m == SignModeSchnorr || m == SignModeTaproot
+}
+
 // Request request to sign a message
 type Request struct {
 	PoolPubKey    string   `json:"pool_pub_key"` // pub key of the pool that we would like to send this message from
-	Messages      []string `json:"messages"`     // base64 encoded message to be signed
+	Messages      []string `json:"messages"`     // base64 encoded messages to be signed, all of them are signed in one ceremony
 	SignerPubKeys []string `json:"signer_pub_keys"`
 	BlockHeight   int64    `json:"block_height"`
 	Version       string   `json:"tss_version"`
+	// Mode is the kind of the signatures, the ecdsa signatures if it is empty
+	Mode SignMode `json:"mode,omitempty"`
+	// TaprootMerkleRoot is the hex encoded merkle root of the script tree the taproot output commits to, it is
+	// empty if the output has no script path
+	TaprootMerkleRoot string `json:"taproot_merkle_root,omitempty"`
+	// UsePresignature signs the schnorr keysign with the presignatures of the pool in a single round, the
+	// keysign fails if the pool does not have enough presignatures of the signers
+	UsePresignature bool `json:"use_presignature,omitempty"`
+	// DerivationPath is the non-hardened BIP32 path like m/0/1 of the child key of the pool we sign with, the
+	// pool key itself signs if it is empty
+	DerivationPath string `json:"derivation_path,omitempty"`
+	// Encoding is the encoding of the encoded signatures of the response, they are left out if it is empty
+	Encoding SignatureEncoding `json:"encoding,omitempty"`
+	// MaxAttempts is how many times the server tries the ecdsa keysign with the join party of the leader, the
+	// keysign is tried again with another signer subset that leaves out the blamed parties if a signer times
+	// out, it is tried once if it is 0 or 1
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// Algo is the algo of the pool key, the keysign fails if the pool key is of another algo, it is not checked
+	// if it is empty
+	Algo conversion.Algo `json:"algo,omitempty"`
+	// Priority orders the request in the keysign queue of the server, the higher ones run first, the requests of
+	// the same priority run in the order they arrive
+	Priority int `json:"priority,omitempty"`
+	// Metadata is handed to the signing policy of the node with the messages, like the transaction they come
+	// from, it is not part of the message id and it is not sent to the other parties
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// HashMode hashes the messages before they are signed, the messages are the digests to sign if it is empty
+	HashMode HashMode `json:"hash_mode,omitempty"`
+	// DigestLength is the length in bytes each digest has to be, it is not checked if it is 0
+	DigestLength int `json:"digest_length,omitempty"`
+	// ReduceDigest signs the leftmost 256 bits of the ecdsa digests longer than the curve order, like the SHA512
+	// digests, the keysign fails on them if it is false and the request sets the hash mode or the digest length
+	ReduceDigest bool `json:"reduce_digest,omitempty"`
 }
 
 func NewRequest(pk string, msgs []string, blockHeight int64, signers []string, version string) Request {
@@ -1,8 +1,13 @@
 package keysign
 
 import (
+	"encoding/base64"
+
 	"github.com/akildemir/go-tss/blame"
 	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/p2p"
 )
 
 // signature
@@ -11,13 +16,61 @@ type Signature struct {
 	R          string `json:"r"`
 	S          string `json:"s"`
 	RecoveryID string `json:"recovery_id"`
+	// Signature is the base64 encoded 65 bytes [R || S || V] signature the EVM chains take, V is the recovery id,
+	// it is only set for the ecdsa signatures of the 32 bytes message hashes
+	Signature string `json:"signature,omitempty"`
+	// Encoded is the base64 encoded signature in the encoding of the request
+	Encoded string `json:"encoded,omitempty"`
 }
 
 // Response key sign response
 type Response struct {
+	// Signatures are in the order of the messages of the request
 	Signatures []Signature   `json:"signatures"`
 	Status     common.Status `json:"status"`
 	Blame      blame.Blame   `json:"blame"`
+	// Algo is the algo of the key that signed, it is only set by the signers
+	Algo conversion.Algo `json:"algo,omitempty"`
+	// XOnlyPubKey is the hex encoded x-only key the schnorr signatures verify against
+	XOnlyPubKey string `json:"x_only_pub_key,omitempty"`
+	// Signers are the pub keys of the parties who signed, they are only known to the signers, the nodes that
+	// receive the signatures from the signers leave them empty
+	Signers []string `json:"signers,omitempty"`
+	// Attempts are the attempts of the keysign in order, the last one is the response itself, they are only set
+	// if the request allows more than one attempt
+	Attempts []Attempt `json:"attempts,omitempty"`
+	// Stall is the round the keysign got stuck in if it timed out
+	Stall *blame.Stall `json:"stall,omitempty"`
+	// Rounds are the rounds the failed keysign got through, the peers whose messages of each round arrived and
+	// when, so the round it failed in and the peers that stopped before it are plain to see
+	Rounds *blame.RoundTrace `json:"rounds,omitempty"`
+	// Abort is the proof of the parties that made the keysign abort by sending the messages that fail the checks
+	Abort *blame.Abort `json:"abort,omitempty"`
+	// ErrorCode is the machine-readable code of the failure, it is empty if the keysign succeeded
+	ErrorCode errcode.Code `json:"error_code,omitempty"`
+	// Logs are the last log lines of the keysign if it failed, they carry its msg id, peers and round
+	Logs []string `json:"logs,omitempty"`
+	// JoinParty describes the peers that did not join the party of the keysign in time, and when we saw them
+	JoinParty *p2p.JoinPartyReport `json:"join_party,omitempty"`
+	// Verdict is the blame of the failed keysign signed by this node, with the evidences of the offenders, the
+	// chain can check who reported it and what the offenders sent before it slashes them
+	Verdict *blame.Event `json:"verdict,omitempty"`
+	// BlameQuorum are the nodes the participants of the failed keysign agree on once they shared their verdicts, and
+	// the ones they dispute, it is only set if the blame quorum is enabled
+	BlameQuorum *blame.Quorum `json:"blame_quorum,omitempty"`
+}
+
+// Attempt is the result of an attempt of the keysign
+type Attempt struct {
+	Signers     []string             `json:"signers,omitempty"`
+	Status      common.Status        `json:"status"`
+	Blame       blame.Blame          `json:"blame"`
+	Stall       *blame.Stall         `json:"stall,omitempty"`
+	Rounds      *blame.RoundTrace    `json:"rounds,omitempty"`
+	Abort       *blame.Abort         `json:"abort,omitempty"`
+	JoinParty   *p2p.JoinPartyReport `json:"join_party,omitempty"`
+	Verdict     *blame.Event         `json:"verdict,omitempty"`
+	BlameQuorum *blame.Quorum        `json:"blame_quorum,omitempty"`
 }
 
 func NewSignature(msg, r, s, recoveryID string) Signature {
@@ -36,3 +89,31 @@ func NewResponse(signatures []Signature, status common.Status, blame blame.Blame
 		Blame:      blame,
 	}
 }
+
+// OrderSignatures return the signatures in the order of the given base64 encoded messages, the batch keysign
+// produces them in the order of the message hashes. A message is signed once for each time it is requested, the
+// signatures of the messages that are not requested are dropped
+func OrderSignatures(msgs []string, signatures []Signature) []Signature {
+	if len(signatures) == 0 {
+		return signatures
+	}
+	bySignedMsg := make(map[string][]Signature)
+	for _, el := range signatures {
+		bySignedMsg[el.Msg] = append(bySignedMsg[el.Msg], el)
+	}
+	ordered := make([]Signature, 0, len(signatures))
+	for _, msg := range msgs {
+		// the signed messages are re-encoded, so we match them on the decoded bytes
+		buf, err := base64.StdEncoding.DecodeString(msg)
+		if err != nil {
+			continue
+		}
+		key := base64.StdEncoding.EncodeToString(buf)
+		if len(bySignedMsg[key]) == 0 {
+			continue
+		}
+		ordered = append(ordered, bySignedMsg[key][0])
+		bySignedMsg[key] = bySignedMsg[key][1:]
+	}
+	return ordered
+}
@@ -0,0 +1,36 @@
+package keysign
+
+import (
+	"encoding/base64"
+
+	. "gopkg.in/check.v1"
+)
+
+type ResponseTestSuite struct{}
+
+var _ = Suite(&ResponseTestSuite{})
+
+func (ResponseTestSuite) TestOrderSignatures(c *C) {
+	msgA := base64.StdEncoding.EncodeToString([]byte("a"))
+	msgB := base64.StdEncoding.EncodeToString([]byte("b"))
+	msgC := base64.StdEncoding.EncodeToString([]byte("c"))
+	sigs := []Signature{
+		NewSignature(msgC, "rc", "sc", ""),
+		NewSignature(msgB, "rb1", "sb1", ""),
+		NewSignature(msgA, "ra", "sa", ""),
+		NewSignature(msgB, "rb2", "sb2", ""),
+	}
+	ordered := OrderSignatures([]string{msgA, msgB, msgC, msgB}, sigs)
+	c.Assert(ordered, HasLen, 4)
+	c.Assert(ordered[0].R, Equals, "ra")
+	c.Assert(ordered[1].R, Equals, "rb1")
+	c.Assert(ordered[2].R, Equals, "rc")
+	c.Assert(ordered[3].R, Equals, "rb2")
+
+	// the message without the signature is skipped
+	ordered = OrderSignatures([]string{msgC, msgA, base64.StdEncoding.EncodeToString([]byte("d"))}, sigs)
+	c.Assert(ordered, HasLen, 2)
+	c.Assert(ordered[0].R, Equals, "rc")
+	c.Assert(ordered[1].R, Equals, "ra")
+	c.Assert(OrderSignatures([]string{msgA}, nil), IsNil)
+}
@@ -196,7 +196,7 @@ func (s *SignatureNotifier) removeNotifier(n *Notifier) {
 }
 
 // WaitForSignature wait until keysign finished and signature is available
-func (s *SignatureNotifier) WaitForSignature(messageID string, message [][]byte, poolPubKey string, timeout time.Duration, sigChan chan string) ([]*common.ECSignature, error) {
+func (s *SignatureNotifier) WaitForSignature(ctx context.Context, messageID string, message [][]byte, poolPubKey string, timeout time.Duration, sigChan chan string) ([]*common.ECSignature, error) {
 	n, err := NewNotifier(messageID, message, poolPubKey)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create notifier")
@@ -211,6 +211,8 @@ func (s *SignatureNotifier) WaitForSignature(messageID string, message [][]byte,
 		return nil, fmt.Errorf("timeout: didn't receive signature after %s", timeout)
 	case <-sigChan:
 		return nil, p2p.ErrSigGenerated
+	case <-ctx.Done():
+		return nil, fmt.Errorf("stop waiting for the signature: %w", ctx.Err())
 	}
 }
 
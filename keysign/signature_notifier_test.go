@@ -1,6 +1,7 @@
 package keysign
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
@@ -76,7 +77,7 @@ func TestSignatureNotifierHappyPath(t *testing.T) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		sig, err := n1.WaitForSignature(messageID, [][]byte{buf}, poolPubKey, time.Second*30, sigChan)
+		sig, err := n1.WaitForSignature(context.Background(), messageID, [][]byte{buf}, poolPubKey, time.Second*30, sigChan)
 		assert.Nil(t, err)
 		assert.NotNil(t, sig)
 	}()
@@ -13,7 +13,6 @@ import (
 	"github.com/binance-chain/tss-lib/ecdsa/signing"
 	btss "github.com/binance-chain/tss-lib/tss"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 	tcrypto "github.com/tendermint/tendermint/crypto"
 	"go.uber.org/atomic"
 
@@ -31,18 +30,18 @@ type TssKeySign struct {
 	stopChan        chan struct{} // channel to indicate whether we should stop
 	localParties    []*btss.PartyID
 	commStopChan    chan struct{}
-	p2pComm         *p2p.Communication
+	p2pComm         common.P2PComm
 	stateManager    storage.LocalStateManager
 }
 
 func NewTssKeySign(localP2PID string,
 	conf common.TssConfig,
 	broadcastChan chan *messages.BroadcastMsgChan,
-	stopChan chan struct{}, msgID string, privKey tcrypto.PrivKey, p2pComm *p2p.Communication, stateManager storage.LocalStateManager, msgNum int) *TssKeySign {
-	logItems := []string{"keySign", msgID}
+	stopChan chan struct{}, msgID string, privKey tcrypto.PrivKey, p2pComm common.P2PComm, stateManager storage.LocalStateManager, msgNum int) *TssKeySign {
+	tssCommonStruct := common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privKey, msgNum)
 	return &TssKeySign{
-		logger:          log.With().Strs("module", logItems).Logger(),
-		tssCommonStruct: common.NewTssCommon(localP2PID, broadcastChan, conf, msgID, privKey, msgNum),
+		logger:          tssCommonStruct.GetLogContext().Logger("keySign"),
+		tssCommonStruct: tssCommonStruct,
 		stopChan:        stopChan,
 		localParties:    make([]*btss.PartyID, 0),
 		commStopChan:    make(chan struct{}),
@@ -59,65 +58,90 @@ func (tKeySign *TssKeySign) GetTssCommonStruct() *common.TssCommon {
 	return tKeySign.tssCommonStruct
 }
 
-func (tKeySign *TssKeySign) startBatchSigning(keySignPartyMap *sync.Map, msgNum int) bool {
+func (tKeySign *TssKeySign) startBatchSigning(keySignParties []btss.Party) bool {
 	// start the batch sign
 	var keySignWg sync.WaitGroup
 	ret := atomic.NewBool(true)
-	keySignWg.Add(msgNum)
-	keySignPartyMap.Range(func(key, value interface{}) bool {
-		eachParty := value.(btss.Party)
+	keySignWg.Add(len(keySignParties))
+	for _, eachParty := range keySignParties {
 		go func(eachParty btss.Party) {
 			defer keySignWg.Done()
 			if err := eachParty.Start(); err != nil {
 				tKeySign.logger.Error().Err(err).Msg("fail to start key sign party")
 				ret.Store(false)
+				return
 			}
+			tKeySign.tssCommonStruct.ReplayCachedMessages()
 			tKeySign.logger.Info().Msgf("local party(%s) %s is ready", eachParty.PartyID().Id, eachParty.PartyID().Moniker)
 		}(eachParty)
-		return true
-	})
+	}
 	keySignWg.Wait()
 	return ret.Load()
 }
 
 // signMessage
 func (tKeySign *TssKeySign) SignMessage(msgsToSign [][]byte, localStateItem storage.KeygenLocalState, parties []string) ([]*tsslibcommon.ECSignature, error) {
-	partiesID, localPartyID, err := conversion.GetParties(parties, localStateItem.LocalPartyKey)
+	partiesID, localPartiesID, err := conversion.GetWeightedParties(parties, localStateItem.Weights, localStateItem.LocalPartyKey)
 	if err != nil {
 		return nil, fmt.Errorf("fail to form key sign party: %w", err)
 	}
 
-	if !common.Contains(partiesID, localPartyID) {
+	if !common.Contains(partiesID, localPartiesID[0]) {
 		tKeySign.logger.Info().Msgf("we are not in this rounds key sign")
 		return nil, nil
 	}
-	threshold, err := conversion.GetThreshold(len(localStateItem.ParticipantKeys))
+	threshold, err := localStateItem.GetThreshold()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get threshold: %w", err)
+	}
+	algoParties, err := common.GetAlgoParties(localStateItem.Algo)
 	if err != nil {
-		return nil, errors.New("fail to get threshold")
+		return nil, fmt.Errorf("fail to get the keysign party of the key: %w", err)
+	}
+	// a weighted key has a party for each of the local shares
+	localShares := localStateItem.LocalShares()
+	if len(localShares) != len(localPartiesID) {
+		return nil, fmt.Errorf("we hold %d shares of the key, expect %d", len(localShares), len(localPartiesID))
 	}
 
-	outCh := make(chan btss.Message, 2*len(partiesID)*len(msgsToSign))
+	outCh := make(chan btss.Message, 2*len(partiesID)*len(msgsToSign)*len(localShares))
 	endCh := make(chan *signing.SignatureData, len(partiesID)*len(msgsToSign))
 	errCh := make(chan struct{})
 
 	keySignPartyMap := new(sync.Map)
+	var keySignShareMap *sync.Map
+	if len(localShares) > 1 {
+		keySignShareMap = new(sync.Map)
+	}
+	var keySignParties []btss.Party
 	for i, val := range msgsToSign {
 		m, err := common.MsgToHashInt(val)
 		if err != nil {
 			return nil, fmt.Errorf("fail to convert msg to hash int: %w", err)
 		}
 		moniker := m.String() + ":" + strconv.Itoa(i)
-		partiesID, eachLocalPartyID, err := conversion.GetParties(parties, localStateItem.LocalPartyKey)
-		ctx := btss.NewPeerContext(partiesID)
+		partiesID, eachLocalPartiesID, err := conversion.GetWeightedParties(parties, localStateItem.Weights, localStateItem.LocalPartyKey)
 		if err != nil {
 			return nil, fmt.Errorf("error to create parties in batch signging %w\n", err)
 		}
+		ctx := btss.NewPeerContext(partiesID)
 		tKeySign.logger.Info().Msgf("message: (%s) keysign parties: %+v", m.String(), parties)
-		eachLocalPartyID.Moniker = moniker
 		tKeySign.localParties = nil
-		params := btss.NewParameters(ctx, eachLocalPartyID, len(partiesID), threshold)
-		keySignParty := signing.NewLocalParty(m, params, localStateItem.LocalData, outCh, endCh)
-		keySignPartyMap.Store(moniker, keySignParty)
+		var shareParties []btss.Party
+		for j, eachLocalPartyID := range eachLocalPartiesID {
+			eachLocalPartyID.Moniker = moniker
+			params := btss.NewParameters(ctx, eachLocalPartyID, len(partiesID), threshold)
+			keySignParty := algoParties.Keysign(m, params, localShares[j], outCh, endCh)
+			keySignParties = append(keySignParties, keySignParty)
+			if j == 0 {
+				keySignPartyMap.Store(moniker, keySignParty)
+				continue
+			}
+			shareParties = append(shareParties, keySignParty)
+		}
+		if keySignShareMap != nil {
+			keySignShareMap.Store(moniker, shareParties)
+		}
 	}
 
 	blameMgr := tKeySign.tssCommonStruct.GetBlameMgr()
@@ -131,26 +155,27 @@ func (tKeySign *TssKeySign) SignMessage(msgsToSign [][]byte, localStateItem stor
 
 	tKeySign.tssCommonStruct.SetPartyInfo(&common.PartyInfo{
 		PartyMap:   keySignPartyMap,
+		ShareMap:   keySignShareMap,
 		PartyIDMap: partyIDMap,
 	})
 
 	blameMgr.SetPartyInfo(keySignPartyMap, partyIDMap)
 
-	tKeySign.tssCommonStruct.P2PPeersLock.Lock()
-	tKeySign.tssCommonStruct.P2PPeers = conversion.GetPeersID(tKeySign.tssCommonStruct.PartyIDtoP2PID, tKeySign.tssCommonStruct.GetLocalPeerID())
-	tKeySign.tssCommonStruct.P2PPeersLock.Unlock()
+	tKeySign.tssCommonStruct.SetP2PPeers(conversion.GetPeersID(tKeySign.tssCommonStruct.PartyIDtoP2PID, tKeySign.tssCommonStruct.GetLocalPeerID()))
 	var keySignWg sync.WaitGroup
 	keySignWg.Add(2)
 	// start the key sign
 	go func() {
 		defer keySignWg.Done()
-		ret := tKeySign.startBatchSigning(keySignPartyMap, len(msgsToSign))
+		ret := tKeySign.startBatchSigning(keySignParties)
 		if !ret {
 			close(errCh)
 		}
 	}()
 	go tKeySign.tssCommonStruct.ProcessInboundMessages(tKeySign.commStopChan, &keySignWg)
-	results, err := tKeySign.processKeySign(len(msgsToSign), errCh, outCh, endCh)
+	// ask the peers to replay what we may have missed if we joined the party late
+	tKeySign.tssCommonStruct.RequestCatchUp()
+	results, err := tKeySign.processKeySign(len(msgsToSign), len(localShares), errCh, outCh, endCh)
 	if err != nil {
 		close(tKeySign.commStopChan)
 		return nil, fmt.Errorf("fail to process key sign: %w", err)
@@ -164,7 +189,7 @@ func (tKeySign *TssKeySign) SignMessage(msgsToSign [][]byte, localStateItem stor
 	}
 	keySignWg.Wait()
 
-	tKeySign.logger.Info().Msgf("%s successfully sign the message", tKeySign.p2pComm.GetHost().ID().String())
+	tKeySign.logger.Info().Msgf("%s successfully sign the message", tKeySign.p2pComm.GetLocalPeerID())
 	sort.SliceStable(results, func(i, j int) bool {
 		a := new(big.Int).SetBytes(results[i].M)
 		b := new(big.Int).SetBytes(results[j].M)
@@ -178,24 +203,41 @@ func (tKeySign *TssKeySign) SignMessage(msgsToSign [][]byte, localStateItem stor
 	return results, nil
 }
 
-func (tKeySign *TssKeySign) processKeySign(reqNum int, errChan chan struct{}, outCh <-chan btss.Message, endCh <-chan *signing.SignatureData) ([]*tsslibcommon.ECSignature, error) {
+func (tKeySign *TssKeySign) processKeySign(reqNum, shares int, errChan chan struct{}, outCh <-chan btss.Message, endCh <-chan *signing.SignatureData) ([]*tsslibcommon.ECSignature, error) {
 	defer tKeySign.logger.Debug().Msg("key sign finished")
 	tKeySign.logger.Debug().Msg("start to read messages from local party")
 	var signatures []*tsslibcommon.ECSignature
+	signed := make(map[string]bool, reqNum)
+	finished := 0
 
 	tssConf := tKeySign.tssCommonStruct.GetConf()
 	blameMgr := tKeySign.tssCommonStruct.GetBlameMgr()
 
 	for {
+		// we wait for the round of the last message we sent
+		round := messages.KEYSIGN1aUnicast
+		if lastMsg := blameMgr.GetLastMsg(); lastMsg != nil {
+			round = lastMsg.Type()
+		}
+		timeout := tssConf.RoundTimeout(round, tssConf.KeySignTimeout)
 		select {
 		case <-errChan: // when key sign return
 			tKeySign.logger.Error().Msg("key sign failed")
 			return nil, errors.New("error channel closed fail to start local party")
 		case <-tKeySign.stopChan: // when TSS processor receive signal to quit
 			return nil, errors.New("received exit signal")
-		case <-time.After(tssConf.KeySignTimeout):
-			// we bail out after KeySignTimeoutSeconds
-			tKeySign.logger.Error().Msgf("fail to sign message with %s", tssConf.KeySignTimeout.String())
+		case <-tKeySign.tssCommonStruct.GetAbortChan(): // a peer restarted in the keysign, it can not finish
+			tKeySign.logger.Error().Msg("a peer restarted in the keysign, we abort it")
+			return nil, blame.ErrTssAborted
+		case <-time.After(timeout):
+			// we bail out after the timeout of the round
+			tKeySign.logger.Error().Msgf("fail to sign message with %s in round %s", timeout.String(), round)
+			stall, err := blameMgr.DetectStall(messages.KeysignRounds)
+			if err != nil {
+				tKeySign.logger.Error().Err(err).Msg("fail to detect the stalled round")
+			} else if stall != nil {
+				tKeySign.logger.Error().Strs("missing", stall.Missing).Msgf("keysign stalled in round %s", stall.Round)
+			}
 			lastMsg := blameMgr.GetLastMsg()
 			failReason := blameMgr.GetBlame().FailReason
 			if failReason == "" {
@@ -255,8 +297,15 @@ func (tKeySign *TssKeySign) processKeySign(reqNum int, errChan chan struct{}, ou
 			}
 
 		case msg := <-endCh:
-			signatures = append(signatures, msg.GetSignature())
-			if len(signatures) == reqNum {
+			// every local share of a weighted key ends with the same signature, we keep one of them
+			finished++
+			sig := msg.GetSignature()
+			sigKey := string(sig.R) + string(sig.S)
+			if !signed[sigKey] {
+				signed[sigKey] = true
+				signatures = append(signatures, sig)
+			}
+			if finished == reqNum*shares {
 				tKeySign.logger.Debug().Msg("we have done the key sign")
 				err := tKeySign.tssCommonStruct.NotifyTaskDone()
 				if err != nil {
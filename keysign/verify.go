@@ -0,0 +1,33 @@
+package keysign
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/binance-chain/tss-lib/common"
+
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// ErrInvalidSignature is returned when the signature the keysign produced does not verify against the pool pub key,
+// the signature should never be broadcast
+var ErrInvalidSignature = errcode.New(errcode.InvalidSignature, "the signature does not verify against the pool pub key")
+
+// VerifySignatures check each of the signatures against the pub key and the message hash it signed, the signatures
+// and the messages are in the same order
+func VerifySignatures(pubKey *ecdsa.PublicKey, msgs [][]byte, sigs []*common.ECSignature) error {
+	if pubKey == nil {
+		return fmt.Errorf("%w: no pub key", ErrInvalidSignature)
+	}
+	if len(sigs) != len(msgs) {
+		return fmt.Errorf("%w: %d signatures for %d messages", ErrInvalidSignature, len(sigs), len(msgs))
+	}
+	for i, sig := range sigs {
+		if sig == nil || !ecdsa.Verify(pubKey, msgs[i], new(big.Int).SetBytes(sig.R), new(big.Int).SetBytes(sig.S)) {
+			return fmt.Errorf("%w: message %s", ErrInvalidSignature, base64.StdEncoding.EncodeToString(msgs[i]))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package keysign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/binance-chain/tss-lib/common"
+	"github.com/btcsuite/btcd/btcec"
+	. "gopkg.in/check.v1"
+)
+
+type VerifyTestSuite struct{}
+
+var _ = Suite(&VerifyTestSuite{})
+
+func (VerifyTestSuite) TestVerifySignatures(c *C) {
+	priKey, err := ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+	c.Assert(err, IsNil)
+	var msgs [][]byte
+	var sigs []*common.ECSignature
+	for _, el := range []string{"hello", "world"} {
+		hash := sha256.Sum256([]byte(el))
+		sig, err := (*btcec.PrivateKey)(priKey).Sign(hash[:])
+		c.Assert(err, IsNil)
+		msgs = append(msgs, hash[:])
+		sigs = append(sigs, &common.ECSignature{R: sig.R.Bytes(), S: sig.S.Bytes()})
+	}
+	c.Assert(VerifySignatures(&priKey.PublicKey, msgs, sigs), IsNil)
+
+	other, err := ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+	c.Assert(err, IsNil)
+	err = VerifySignatures(&other.PublicKey, msgs, sigs)
+	c.Assert(errors.Is(err, ErrInvalidSignature), Equals, true)
+	// the signatures are not in the order of the messages
+	err = VerifySignatures(&priKey.PublicKey, msgs, []*common.ECSignature{sigs[1], sigs[0]})
+	c.Assert(errors.Is(err, ErrInvalidSignature), Equals, true)
+	err = VerifySignatures(&priKey.PublicKey, msgs, sigs[:1])
+	c.Assert(errors.Is(err, ErrInvalidSignature), Equals, true)
+	err = VerifySignatures(&priKey.PublicKey, msgs, []*common.ECSignature{sigs[0], nil})
+	c.Assert(errors.Is(err, ErrInvalidSignature), Equals, true)
+}
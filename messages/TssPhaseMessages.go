@@ -16,3 +16,10 @@ const (
 	TSSKEYGENROUNDS  = 4
 	TSSKEYSIGNROUNDS = 8
 )
+
+var (
+	// KeygenRounds are the messages of the keygen rounds in order
+	KeygenRounds = []string{KEYGEN1, KEYGEN2aUnicast, KEYGEN2b, KEYGEN3}
+	// KeysignRounds are the messages of the keysign rounds in order
+	KeysignRounds = []string{KEYSIGN1aUnicast, KEYSIGN1b, KEYSIGN2Unicast, KEYSIGN3, KEYSIGN4, KEYSIGN5, KEYSIGN6, KEYSIGN7}
+)
@@ -1,9 +1,12 @@
 package messages
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	btss "github.com/binance-chain/tss-lib/tss"
+	"github.com/golang/protobuf/proto"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -23,6 +26,18 @@ const (
 	TSSControlMsg
 	// TSSTaskDone is the message of Tss process notification
 	TSSTaskDone
+	// TSSCatchUpMsg is the message a late joiner sends to ask the peers to replay their messages
+	TSSCatchUpMsg
+	// TSSSchnorrMsg is the message of the schnorr keysign rounds
+	TSSSchnorrMsg
+	// TSSReshareMsg is the message of the resharing generated by tss-lib, with the committees it is from and to
+	TSSReshareMsg
+	// TSSAbortMsg is the message a node sends once it restarts in the middle of a ceremony, so the peers give up
+	// on it instead of waiting for the timeout
+	TSSAbortMsg
+	// TSSBlameViewMsg is the message a node shares its signed blame of a failed ceremony with, so the participants
+	// can tell the nodes a quorum of them blame
+	TSSBlameViewMsg
 	// Unknown is the message indicates the undefined message type
 	Unknown
 )
@@ -38,6 +53,20 @@ func (msgType THORChainTSSMessageType) String() string {
 		return "TSSKeyGenVerMsg"
 	case TSSKeySignVerMsg:
 		return "TSSKeySignVerMsg"
+	case TSSControlMsg:
+		return "TSSControlMsg"
+	case TSSTaskDone:
+		return "TSSTaskDone"
+	case TSSCatchUpMsg:
+		return "TSSCatchUpMsg"
+	case TSSSchnorrMsg:
+		return "TSSSchnorrMsg"
+	case TSSReshareMsg:
+		return "TSSReshareMsg"
+	case TSSAbortMsg:
+		return "TSSAbortMsg"
+	case TSSBlameViewMsg:
+		return "TSSBlameViewMsg"
 	default:
 		return "Unknown"
 	}
@@ -50,10 +79,49 @@ type WrappedMessage struct {
 	Payload     []byte                  `json:"payload"`
 }
 
+// MarshalProto encode the wrapped message in the protobuf wire format
+func (m *WrappedMessage) MarshalProto() ([]byte, error) {
+	return proto.Marshal(&ProtoWrappedMessage{
+		MessageType: uint32(m.MessageType),
+		MsgID:       m.MsgID,
+		Payload:     m.Payload,
+	})
+}
+
+// UnmarshalWrappedMessage decode the wrapped message from either the json or the protobuf wire format,
+// the json encoded message always starts with '{' which can never be the first byte of a valid protobuf message
+func UnmarshalWrappedMessage(buf []byte) (*WrappedMessage, error) {
+	if len(buf) == 0 {
+		return nil, errors.New("empty wrapped message")
+	}
+	if buf[0] == '{' {
+		var wrappedMsg WrappedMessage
+		if err := json.Unmarshal(buf, &wrappedMsg); err != nil {
+			return nil, fmt.Errorf("fail to unmarshal wrapped message from json: %w", err)
+		}
+		return &wrappedMsg, nil
+	}
+	var protoMsg ProtoWrappedMessage
+	if err := proto.Unmarshal(buf, &protoMsg); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal wrapped message from protobuf: %w", err)
+	}
+	return &WrappedMessage{
+		MessageType: THORChainTSSMessageType(protoMsg.MessageType),
+		MsgID:       protoMsg.MsgID,
+		Payload:     protoMsg.Payload,
+	}, nil
+}
+
 // BroadcastMsgChan is the channel structure for keygen/keysign submit message to p2p network
 type BroadcastMsgChan struct {
 	WrappedMessage WrappedMessage
 	PeersID        []peer.ID
+	// Encoded is the optional protobuf encoding of the WrappedMessage, the p2p layer sends it as it is
+	// instead of marshalling the message again
+	Encoded []byte
+	// Gossip asks the p2p layer to gossip the message instead of opening a stream to each of the peers, the
+	// p2p layer falls back to the streams if it does not gossip
+	Gossip bool
 }
 
 // BroadcastConfirmMessage is used to broadcast to all parties what message they receive
@@ -86,3 +154,10 @@ type TssControl struct {
 type TssTaskNotifier struct {
 	TaskDone bool `json:"task_done"`
 }
+
+// TssAbort is the payload of the TSSAbortMsg
+type TssAbort struct {
+	Reason string `json:"reason"`
+	// Shutdown is set if the node gives up on the ceremony as it shuts down, rather than once it restarted
+	Shutdown bool `json:"shutdown,omitempty"`
+}
@@ -1,6 +1,8 @@
 package messages
 
 import (
+	"crypto/rand"
+	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -20,6 +22,13 @@ func (THORChainTSSMessageTypeSuite) TestTHORChainTSSMessageType_String(c *C) {
 		TSSKeySignMsg:    "TSSKeySignMsg",
 		TSSKeyGenVerMsg:  "TSSKeyGenVerMsg",
 		TSSKeySignVerMsg: "TSSKeySignVerMsg",
+		TSSControlMsg:    "TSSControlMsg",
+		TSSTaskDone:      "TSSTaskDone",
+		TSSCatchUpMsg:    "TSSCatchUpMsg",
+		TSSSchnorrMsg:    "TSSSchnorrMsg",
+		TSSReshareMsg:    "TSSReshareMsg",
+		TSSAbortMsg:      "TSSAbortMsg",
+		TSSBlameViewMsg:  "TSSBlameViewMsg",
 	}
 	for k, v := range m {
 		c.Assert(k.String(), Equals, v)
@@ -42,3 +51,85 @@ func (THORChainTSSMessageTypeSuite) TestWireMessage(c *C) {
 	cacheKey := wm.GetCacheKey()
 	c.Assert(cacheKey, Equals, "1-hello")
 }
+
+func (THORChainTSSMessageTypeSuite) TestUnmarshalWrappedMessage(c *C) {
+	msg := &WrappedMessage{
+		MessageType: TSSKeySignVerMsg,
+		MsgID:       "message-id",
+		Payload:     []byte("hello"),
+	}
+	jsonBytes, err := json.Marshal(msg)
+	c.Assert(err, IsNil)
+	protoBytes, err := msg.MarshalProto()
+	c.Assert(err, IsNil)
+	for _, buf := range [][]byte{jsonBytes, protoBytes} {
+		decoded, err := UnmarshalWrappedMessage(buf)
+		c.Assert(err, IsNil)
+		c.Assert(decoded, DeepEquals, msg)
+	}
+	// the zero message type is omitted in protobuf, it should still be decoded correctly
+	msg.MessageType = TSSKeyGenMsg
+	protoBytes, err = msg.MarshalProto()
+	c.Assert(err, IsNil)
+	decoded, err := UnmarshalWrappedMessage(protoBytes)
+	c.Assert(err, IsNil)
+	c.Assert(decoded.MessageType, Equals, TSSKeyGenMsg)
+
+	_, err = UnmarshalWrappedMessage(nil)
+	c.Assert(err, NotNil)
+	_, err = UnmarshalWrappedMessage([]byte("{invalid"))
+	c.Assert(err, NotNil)
+	_, err = UnmarshalWrappedMessage([]byte{0xff, 0xff})
+	c.Assert(err, NotNil)
+}
+
+// getKeysignWrappedMessage fabricate a wrapped message similar to the broadcast message of a 20 nodes keysign
+func getKeysignWrappedMessage(b *testing.B) *WrappedMessage {
+	payload := make([]byte, 20*1024)
+	if _, err := rand.Read(payload); err != nil {
+		b.Fatal(err)
+	}
+	wireMsg, err := json.Marshal(WireMessage{
+		RoundInfo: "SignRound1Message",
+		Message:   payload,
+		Sig:       payload[:64],
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return &WrappedMessage{
+		MessageType: TSSKeySignMsg,
+		MsgID:       "2ec9ac67b8d1f8ad2d7ac4d8b0b7f7ef1b0cbb2e7e4a1e7b1e4e0b6a2f2d1c0f",
+		Payload:     wireMsg,
+	}
+}
+
+func BenchmarkWrappedMessageJSON(b *testing.B) {
+	msg := getKeysignWrappedMessage(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf, err := json.Marshal(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := UnmarshalWrappedMessage(buf); err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(int64(len(buf)))
+	}
+}
+
+func BenchmarkWrappedMessageProto(b *testing.B) {
+	msg := getKeysignWrappedMessage(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf, err := msg.MarshalProto()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := UnmarshalWrappedMessage(buf); err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(int64(len(buf)))
+	}
+}
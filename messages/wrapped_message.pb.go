@@ -0,0 +1,164 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.14.0
+// source: wrapped_message.proto
+
+package messages
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ProtoWrappedMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MessageType uint32 `protobuf:"varint,1,opt,name=MessageType,proto3" json:"MessageType,omitempty"` // the THORChainTSSMessageType of the message
+	MsgID       string `protobuf:"bytes,2,opt,name=MsgID,proto3" json:"MsgID,omitempty"`              // the id of the tss ceremony
+	Payload     []byte `protobuf:"bytes,3,opt,name=Payload,proto3" json:"Payload,omitempty"`          // the message body
+}
+
+func (x *ProtoWrappedMessage) Reset() {
+	*x = ProtoWrappedMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_wrapped_message_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProtoWrappedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtoWrappedMessage) ProtoMessage() {}
+
+func (x *ProtoWrappedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_wrapped_message_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtoWrappedMessage.ProtoReflect.Descriptor instead.
+func (*ProtoWrappedMessage) Descriptor() ([]byte, []int) {
+	return file_wrapped_message_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProtoWrappedMessage) GetMessageType() uint32 {
+	if x != nil {
+		return x.MessageType
+	}
+	return 0
+}
+
+func (x *ProtoWrappedMessage) GetMsgID() string {
+	if x != nil {
+		return x.MsgID
+	}
+	return ""
+}
+
+func (x *ProtoWrappedMessage) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+var File_wrapped_message_proto protoreflect.FileDescriptor
+
+var file_wrapped_message_proto_rawDesc = []byte{
+	0x0a, 0x15, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x73, 0x22, 0x67, 0x0a, 0x13, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65,
+	0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x4d, 0x73,
+	0x67, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4d, 0x73, 0x67, 0x49, 0x44,
+	0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x26, 0x5a, 0x24, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x6b, 0x69, 0x6c, 0x64, 0x65, 0x6d,
+	0x69, 0x72, 0x2f, 0x67, 0x6f, 0x2d, 0x74, 0x73, 0x73, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_wrapped_message_proto_rawDescOnce sync.Once
+	file_wrapped_message_proto_rawDescData = file_wrapped_message_proto_rawDesc
+)
+
+func file_wrapped_message_proto_rawDescGZIP() []byte {
+	file_wrapped_message_proto_rawDescOnce.Do(func() {
+		file_wrapped_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_wrapped_message_proto_rawDescData)
+	})
+	return file_wrapped_message_proto_rawDescData
+}
+
+var file_wrapped_message_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_wrapped_message_proto_goTypes = []interface{}{
+	(*ProtoWrappedMessage)(nil), // 0: messages.ProtoWrappedMessage
+}
+var file_wrapped_message_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_wrapped_message_proto_init() }
+func file_wrapped_message_proto_init() {
+	if File_wrapped_message_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_wrapped_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProtoWrappedMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_wrapped_message_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_wrapped_message_proto_goTypes,
+		DependencyIndexes: file_wrapped_message_proto_depIdxs,
+		MessageInfos:      file_wrapped_message_proto_msgTypes,
+	}.Build()
+	File_wrapped_message_proto = out.File
+	file_wrapped_message_proto_rawDesc = nil
+	file_wrapped_message_proto_goTypes = nil
+	file_wrapped_message_proto_depIdxs = nil
+}
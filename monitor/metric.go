@@ -15,6 +15,24 @@ type Metric struct {
 	keySignTime      prometheus.Gauge
 	keyGenTime       prometheus.Gauge
 	joinPartyTime    *prometheus.GaugeVec
+	channelOccupancy *prometheus.GaugeVec
+	p2pBytes         *prometheus.GaugeVec
+	canaryCounter    *prometheus.CounterVec
+	canaryTime       prometheus.Gauge
+	canaryHealth     *prometheus.GaugeVec
+	peerBandwidth    *prometheus.GaugeVec
+	protoBandwidth   *prometheus.GaugeVec
+	peerMessages     *prometheus.GaugeVec
+	peerFailures     *prometheus.GaugeVec
+	peerLatency      *prometheus.GaugeVec
+	peerEvents       *prometheus.CounterVec
+	streamHandler    *prometheus.GaugeVec
+	ceremonyCounter  *prometheus.CounterVec
+	ceremonyDuration *prometheus.HistogramVec
+	roundLatency     *prometheus.HistogramVec
+	peerStreams      *prometheus.GaugeVec
+	queueDepth       *prometheus.GaugeVec
+	blameCounter     *prometheus.CounterVec
 	logger           zerolog.Logger
 }
 
@@ -54,6 +72,122 @@ func (m *Metric) KeysignJoinParty(joinpartyTime time.Duration, success bool) {
 	}
 }
 
+// UpdateChannelOccupancy set the occupancy ratio of the subscriber channel of the given message type
+func (m *Metric) UpdateChannelOccupancy(msgType string, occupancy float64) {
+	m.channelOccupancy.WithLabelValues(msgType).Set(occupancy)
+}
+
+// UpdateP2PBytes set the total bytes of the tss messages of the given direction before(raw) and after(wire) the compression
+func (m *Metric) UpdateP2PBytes(direction string, raw, wire int64) {
+	m.p2pBytes.WithLabelValues(direction, "raw").Set(float64(raw))
+	m.p2pBytes.WithLabelValues(direction, "wire").Set(float64(wire))
+}
+
+// UpdateCanary records the result of the canary keysign
+func (m *Metric) UpdateCanary(canaryTime time.Duration, success bool) {
+	if success {
+		m.canaryTime.Set(float64(canaryTime))
+		m.canaryCounter.WithLabelValues("success").Inc()
+	} else {
+		m.canaryCounter.WithLabelValues("failure").Inc()
+	}
+}
+
+// UpdateCanaryParticipant set whether the participant took part in the latest canary keysign properly
+func (m *Metric) UpdateCanaryParticipant(pubKey string, healthy bool) {
+	val := 0.0
+	if healthy {
+		val = 1
+	}
+	m.canaryHealth.WithLabelValues(pubKey).Set(val)
+}
+
+// UpdatePeerBandwidth replace the total bytes we have received from(in) and sent to(out) each of the peers
+func (m *Metric) UpdatePeerBandwidth(in, out map[string]int64) {
+	updateBandwidth(m.peerBandwidth, in, out)
+}
+
+// UpdateProtocolBandwidth replace the total bytes we have received(in) and sent(out) over each of the protocols
+func (m *Metric) UpdateProtocolBandwidth(in, out map[string]int64) {
+	updateBandwidth(m.protoBandwidth, in, out)
+}
+
+// updateBandwidth reset the gauges before we set them, so the peers and protocols that are gone do not linger
+func updateBandwidth(gauge *prometheus.GaugeVec, in, out map[string]int64) {
+	gauge.Reset()
+	for k, v := range in {
+		gauge.WithLabelValues(k, "in").Set(float64(v))
+	}
+	for k, v := range out {
+		gauge.WithLabelValues(k, "out").Set(float64(v))
+	}
+}
+
+// PeerProtocolStat is the tss message traffic with a peer over a protocol
+type PeerProtocolStat struct {
+	Peer        string
+	Protocol    string
+	MessagesIn  int64
+	MessagesOut int64
+	Failures    int64
+	AvgLatency  time.Duration
+}
+
+// UpdatePeerProtocolStats replace the tss message statistics of the peers, the peers that are gone are dropped
+func (m *Metric) UpdatePeerProtocolStats(stats []PeerProtocolStat) {
+	m.peerMessages.Reset()
+	m.peerFailures.Reset()
+	m.peerLatency.Reset()
+	for _, el := range stats {
+		m.peerMessages.WithLabelValues(el.Peer, el.Protocol, "in").Set(float64(el.MessagesIn))
+		m.peerMessages.WithLabelValues(el.Peer, el.Protocol, "out").Set(float64(el.MessagesOut))
+		m.peerFailures.WithLabelValues(el.Peer, el.Protocol).Set(float64(el.Failures))
+		m.peerLatency.WithLabelValues(el.Peer, el.Protocol).Set(el.AvgLatency.Seconds())
+	}
+}
+
+// IncPeerEvent count the peer event of the given type
+func (m *Metric) IncPeerEvent(eventType string) {
+	m.peerEvents.WithLabelValues(eventType).Inc()
+}
+
+// UpdateStreamHandler set the state of the inbound stream workers
+func (m *Metric) UpdateStreamHandler(busy int64, queued int, rejected int64) {
+	m.streamHandler.WithLabelValues("busy").Set(float64(busy))
+	m.streamHandler.WithLabelValues("queued").Set(float64(queued))
+	m.streamHandler.WithLabelValues("rejected").Set(float64(rejected))
+}
+
+// UpdateCeremony count the ceremony of the given type by its outcome and record how long it took
+func (m *Metric) UpdateCeremony(ceremony, outcome string, duration time.Duration) {
+	m.ceremonyCounter.WithLabelValues(ceremony, outcome).Inc()
+	m.ceremonyDuration.WithLabelValues(ceremony, outcome).Observe(duration.Seconds())
+}
+
+// UpdateRoundLatency record how long the local party of the ceremony took to move on from the round
+func (m *Metric) UpdateRoundLatency(ceremony, round string, latency time.Duration) {
+	m.roundLatency.WithLabelValues(ceremony, round).Observe(latency.Seconds())
+}
+
+// UpdatePeerStreams replace the number of the streams we have open with each of the peers
+func (m *Metric) UpdatePeerStreams(streams map[string]int) {
+	m.peerStreams.Reset()
+	for k, v := range streams {
+		m.peerStreams.WithLabelValues(k).Set(float64(v))
+	}
+}
+
+// UpdateQueueDepth set the number of the running and the waiting requests of the queue
+func (m *Metric) UpdateQueueDepth(queue string, running, queued int) {
+	m.queueDepth.WithLabelValues(queue, "running").Set(float64(running))
+	m.queueDepth.WithLabelValues(queue, "queued").Set(float64(queued))
+}
+
+// IncBlame count the blame of the node in the failed ceremony of the given type
+func (m *Metric) IncBlame(ceremony, reason, pubKey string) {
+	m.blameCounter.WithLabelValues(ceremony, reason, pubKey).Inc()
+}
+
 func (m *Metric) Enable() {
 	prometheus.MustRegister(m.keygenCounter)
 	prometheus.MustRegister(m.keysignCounter)
@@ -61,6 +195,24 @@ func (m *Metric) Enable() {
 	prometheus.MustRegister(m.keyGenTime)
 	prometheus.MustRegister(m.keySignTime)
 	prometheus.MustRegister(m.joinPartyTime)
+	prometheus.MustRegister(m.channelOccupancy)
+	prometheus.MustRegister(m.p2pBytes)
+	prometheus.MustRegister(m.canaryCounter)
+	prometheus.MustRegister(m.canaryTime)
+	prometheus.MustRegister(m.canaryHealth)
+	prometheus.MustRegister(m.peerBandwidth)
+	prometheus.MustRegister(m.protoBandwidth)
+	prometheus.MustRegister(m.peerMessages)
+	prometheus.MustRegister(m.peerFailures)
+	prometheus.MustRegister(m.peerLatency)
+	prometheus.MustRegister(m.peerEvents)
+	prometheus.MustRegister(m.streamHandler)
+	prometheus.MustRegister(m.ceremonyCounter)
+	prometheus.MustRegister(m.ceremonyDuration)
+	prometheus.MustRegister(m.roundLatency)
+	prometheus.MustRegister(m.peerStreams)
+	prometheus.MustRegister(m.queueDepth)
+	prometheus.MustRegister(m.blameCounter)
 }
 
 func NewMetric() *Metric {
@@ -122,6 +274,155 @@ func NewMetric() *Metric {
 				Help:      "the time spend for the latest keysign/keygen join party",
 			}, []string{"type"}),
 
+		channelOccupancy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "subscriber_channel_occupancy",
+				Help:      "the occupancy ratio of the inbound subscriber channel of each message type",
+			}, []string{"type"}),
+
+		p2pBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "p2p_bytes",
+				Help:      "the total bytes of the tss messages before(raw) and after(wire) the compression",
+			}, []string{"direction", "encoding"}),
+
+		canaryCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "canary",
+				Help:      "Tss canary keysign success and failure counter",
+			},
+			[]string{"status"},
+		),
+
+		canaryTime: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "canary_time",
+				Help:      "the end to end time spend for the latest canary keysign",
+			},
+		),
+
+		canaryHealth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "canary_participant_health",
+				Help:      "1 if the participant took part in the latest canary keysign properly, 0 if it was blamed",
+			}, []string{"pubkey"}),
+
+		peerBandwidth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "peer_bandwidth_bytes",
+				Help:      "the total bytes we have exchanged with each of the peers",
+			}, []string{"peer", "direction"}),
+
+		protoBandwidth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "protocol_bandwidth_bytes",
+				Help:      "the total bytes we have exchanged over each of the protocols",
+			}, []string{"protocol", "direction"}),
+
+		peerMessages: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "peer_messages",
+				Help:      "the number of tss messages we have exchanged with each of the peers over each of the protocols",
+			}, []string{"peer", "protocol", "direction"}),
+
+		peerFailures: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "peer_failures",
+				Help:      "the number of tss messages we fail to exchange with each of the peers over each of the protocols",
+			}, []string{"peer", "protocol"}),
+
+		peerLatency: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "peer_latency_seconds",
+				Help:      "the average time we take to exchange a tss message with each of the peers over each of the protocols",
+			}, []string{"peer", "protocol"}),
+
+		peerEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "peer_events",
+				Help:      "the number of the peer connections, disconnections, stream failures and bans",
+			}, []string{"event"}),
+
+		streamHandler: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "stream_handler",
+				Help:      "the number of the inbound tss streams being read and waiting for a worker, and the total number of the streams rejected as the queue was full",
+			}, []string{"state"}),
+
+		ceremonyCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "ceremonies",
+				Help:      "the number of the keygens, keysigns, reshares and refreshes by their outcome",
+			}, []string{"type", "outcome"}),
+
+		ceremonyDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "ceremony_duration_seconds",
+				Help:      "the time spend for the keygens, keysigns, reshares and refreshes by their outcome",
+				Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+			}, []string{"type", "outcome"}),
+
+		roundLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "round_latency_seconds",
+				Help:      "the time the local party of the keygens and keysigns spend in each of the rounds",
+				Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+			}, []string{"type", "round"}),
+
+		peerStreams: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "peer_streams",
+				Help:      "the number of the streams we have open with each of the peers",
+			}, []string{"peer"}),
+
+		queueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "queue_depth",
+				Help:      "the number of the running and the waiting ceremonies and keysigns",
+			}, []string{"queue", "state"}),
+
+		blameCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "Tss",
+				Subsystem: "Tss",
+				Name:      "blame",
+				Help:      "the number of the times each of the nodes is blamed for a failed ceremony by the fail reason",
+			}, []string{"type", "reason", "pubkey"}),
+
 		logger: log.With().Str("module", "tssMonitor").Logger(),
 	}
 	return &metrics
@@ -64,3 +64,154 @@ func TestMetric_UpdateKeyGen(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, float64(5), val)
 }
+
+func TestMetric_UpdateChannelOccupancy(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateChannelOccupancy("TSSKeySignMsg", 0.5)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.channelOccupancy.WithLabelValues("TSSKeySignMsg").Write(m))
+	assert.Equal(t, 0.5, m.Gauge.GetValue())
+}
+
+func TestMetric_UpdateP2PBytes(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateP2PBytes("sent", 100, 40)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.p2pBytes.WithLabelValues("sent", "raw").Write(m))
+	assert.Equal(t, float64(100), m.Gauge.GetValue())
+	assert.Nil(t, metrics.p2pBytes.WithLabelValues("sent", "wire").Write(m))
+	assert.Equal(t, float64(40), m.Gauge.GetValue())
+}
+
+func TestMetric_UpdateCanary(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateCanary(time.Second, true)
+	metrics.UpdateCanary(time.Second*2, false)
+	val, err := getCounterValue(metrics.canaryCounter, "success")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), val)
+	val, err = getCounterValue(metrics.canaryCounter, "failure")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), val)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.canaryTime.Write(m))
+	assert.Equal(t, float64(time.Second), m.Gauge.GetValue())
+
+	metrics.UpdateCanaryParticipant("pubkey1", true)
+	metrics.UpdateCanaryParticipant("pubkey2", false)
+	assert.Nil(t, metrics.canaryHealth.WithLabelValues("pubkey1").Write(m))
+	assert.Equal(t, float64(1), m.Gauge.GetValue())
+	assert.Nil(t, metrics.canaryHealth.WithLabelValues("pubkey2").Write(m))
+	assert.Equal(t, float64(0), m.Gauge.GetValue())
+}
+
+func TestMetric_UpdateBandwidth(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdatePeerBandwidth(map[string]int64{"peer1": 100, "peer2": 10}, map[string]int64{"peer1": 50})
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.peerBandwidth.WithLabelValues("peer1", "in").Write(m))
+	assert.Equal(t, float64(100), m.Gauge.GetValue())
+	assert.Nil(t, metrics.peerBandwidth.WithLabelValues("peer1", "out").Write(m))
+	assert.Equal(t, float64(50), m.Gauge.GetValue())
+
+	// the peers that are gone are dropped
+	metrics.UpdatePeerBandwidth(map[string]int64{"peer1": 200}, nil)
+	assert.Nil(t, metrics.peerBandwidth.WithLabelValues("peer1", "in").Write(m))
+	assert.Equal(t, float64(200), m.Gauge.GetValue())
+	assert.False(t, metrics.peerBandwidth.DeleteLabelValues("peer2", "in"))
+
+	metrics.UpdateProtocolBandwidth(map[string]int64{"/p2p/tss": 300}, map[string]int64{"/p2p/tss": 400})
+	assert.Nil(t, metrics.protoBandwidth.WithLabelValues("/p2p/tss", "out").Write(m))
+	assert.Equal(t, float64(400), m.Gauge.GetValue())
+}
+
+func TestMetric_UpdatePeerProtocolStats(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdatePeerProtocolStats([]PeerProtocolStat{
+		{Peer: "peer1", Protocol: "/p2p/tss", MessagesIn: 10, MessagesOut: 20, Failures: 1, AvgLatency: time.Millisecond * 500},
+		{Peer: "peer2", Protocol: "/p2p/tss", MessagesIn: 5},
+	})
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.peerMessages.WithLabelValues("peer1", "/p2p/tss", "out").Write(m))
+	assert.Equal(t, float64(20), m.Gauge.GetValue())
+	assert.Nil(t, metrics.peerFailures.WithLabelValues("peer1", "/p2p/tss").Write(m))
+	assert.Equal(t, float64(1), m.Gauge.GetValue())
+	assert.Nil(t, metrics.peerLatency.WithLabelValues("peer1", "/p2p/tss").Write(m))
+	assert.Equal(t, 0.5, m.Gauge.GetValue())
+
+	// the peers that are gone are dropped
+	metrics.UpdatePeerProtocolStats(nil)
+	assert.False(t, metrics.peerMessages.DeleteLabelValues("peer2", "/p2p/tss", "in"))
+}
+
+func TestMetric_IncPeerEvent(t *testing.T) {
+	metrics := NewMetric()
+	metrics.IncPeerEvent("disconnected")
+	metrics.IncPeerEvent("disconnected")
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.peerEvents.WithLabelValues("disconnected").Write(m))
+	assert.Equal(t, float64(2), m.Counter.GetValue())
+}
+
+func TestMetric_UpdateStreamHandler(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateStreamHandler(2, 5, 7)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.streamHandler.WithLabelValues("queued").Write(m))
+	assert.Equal(t, float64(5), m.Gauge.GetValue())
+	assert.Nil(t, metrics.streamHandler.WithLabelValues("rejected").Write(m))
+	assert.Equal(t, float64(7), m.Gauge.GetValue())
+}
+
+func TestMetric_UpdateCeremony(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateCeremony("keysign", "success", time.Second)
+	metrics.UpdateCeremony("keysign", "success", 3*time.Second)
+	metrics.UpdateCeremony("keysign", "failure", time.Second)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.ceremonyCounter.WithLabelValues("keysign", "success").Write(m))
+	assert.Equal(t, float64(2), m.Counter.GetValue())
+	m = &dto.Metric{}
+	assert.Nil(t, metrics.ceremonyDuration.WithLabelValues("keysign", "success").(prometheus.Metric).Write(m))
+	assert.Equal(t, uint64(2), m.Histogram.GetSampleCount())
+	assert.Equal(t, float64(4), m.Histogram.GetSampleSum())
+}
+
+func TestMetric_UpdateRoundLatency(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateRoundLatency("keygen", "KGRound1Message", 500*time.Millisecond)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.roundLatency.WithLabelValues("keygen", "KGRound1Message").(prometheus.Metric).Write(m))
+	assert.Equal(t, uint64(1), m.Histogram.GetSampleCount())
+	assert.Equal(t, 0.5, m.Histogram.GetSampleSum())
+}
+
+func TestMetric_UpdatePeerStreams(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdatePeerStreams(map[string]int{"peer1": 3, "peer2": 1})
+	metrics.UpdatePeerStreams(map[string]int{"peer1": 2})
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.peerStreams.WithLabelValues("peer1").Write(m))
+	assert.Equal(t, float64(2), m.Gauge.GetValue())
+	// the peers that are gone are dropped
+	assert.False(t, metrics.peerStreams.DeleteLabelValues("peer2"))
+}
+
+func TestMetric_UpdateQueueDepth(t *testing.T) {
+	metrics := NewMetric()
+	metrics.UpdateQueueDepth("keysign", 2, 5)
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.queueDepth.WithLabelValues("keysign", "running").Write(m))
+	assert.Equal(t, float64(2), m.Gauge.GetValue())
+	assert.Nil(t, metrics.queueDepth.WithLabelValues("keysign", "queued").Write(m))
+	assert.Equal(t, float64(5), m.Gauge.GetValue())
+}
+
+func TestMetric_IncBlame(t *testing.T) {
+	metrics := NewMetric()
+	metrics.IncBlame("keysign", "Tss timeout", "pubkey1")
+	metrics.IncBlame("keysign", "Tss timeout", "pubkey1")
+	m := &dto.Metric{}
+	assert.Nil(t, metrics.blameCounter.WithLabelValues("keysign", "Tss timeout", "pubkey1").Write(m))
+	assert.Equal(t, float64(2), m.Counter.GetValue())
+}
@@ -0,0 +1,256 @@
+package p2p
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// AttestationProtocolID is the protocol we use to exchange the build attestations
+var AttestationProtocolID protocol.ID = "/p2p/attestation"
+
+// AttestationStatus describes the result of the verification of a peer's build attestation
+type AttestationStatus string
+
+const (
+	// AttestationVerified the peer runs a build signed by the release key
+	AttestationVerified AttestationStatus = "verified"
+	// AttestationUnsigned the peer does not provide a signature for its build
+	AttestationUnsigned AttestationStatus = "unsigned"
+	// AttestationInvalid the signature of the peer's build does not match the release key
+	AttestationInvalid AttestationStatus = "invalid"
+	// AttestationUnverified we do not have the release key to verify the peer's build
+	AttestationUnverified AttestationStatus = "unverified"
+)
+
+// AttestationConfig defines the build attestation of this node and the key we verify the peers with
+type AttestationConfig struct {
+	// ReleasePubKey is the hex encoded ed25519 public key the official releases are signed with
+	ReleasePubKey string
+	// Version of the build we run
+	Version string
+	// Commit of the build we run
+	Commit string
+	// Signature is the hex encoded release signature of our build
+	Signature string
+}
+
+// BuildAttestation describes the build a node runs
+type BuildAttestation struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	BinaryHash string `json:"binary_hash"`
+	Signature  []byte `json:"signature,omitempty"`
+}
+
+// SignBytes return the bytes the release key signs
+func (a BuildAttestation) SignBytes() []byte {
+	return []byte(fmt.Sprintf("%s|%s|%s", a.Version, a.Commit, a.BinaryHash))
+}
+
+// Verify check the attestation is signed by the given release key
+func (a BuildAttestation) Verify(releaseKey ed25519.PublicKey) AttestationStatus {
+	if len(releaseKey) == 0 {
+		return AttestationUnverified
+	}
+	if len(a.Signature) == 0 {
+		return AttestationUnsigned
+	}
+	if !ed25519.Verify(releaseKey, a.SignBytes(), a.Signature) {
+		return AttestationInvalid
+	}
+	return AttestationVerified
+}
+
+// PeerAttestation is the attestation we received from a peer and its verification result
+type PeerAttestation struct {
+	Attestation BuildAttestation  `json:"attestation"`
+	Status      AttestationStatus `json:"status"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// AttestationService exchanges the build attestations with the peers once we connect to them
+type AttestationService struct {
+	logger     zerolog.Logger
+	host       host.Host
+	local      BuildAttestation
+	releaseKey ed25519.PublicKey
+	lock       *sync.RWMutex
+	peers      map[peer.ID]PeerAttestation
+	notifiee   *network.NotifyBundle
+}
+
+// NewAttestationService create a new instance of AttestationService
+func NewAttestationService(h host.Host, cfg AttestationConfig) (*AttestationService, error) {
+	var releaseKey ed25519.PublicKey
+	if len(cfg.ReleasePubKey) != 0 {
+		buf, err := hex.DecodeString(cfg.ReleasePubKey)
+		if err != nil {
+			return nil, fmt.Errorf("fail to decode the release public key: %w", err)
+		}
+		if len(buf) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid release public key size")
+		}
+		releaseKey = buf
+	}
+	var sig []byte
+	if len(cfg.Signature) != 0 {
+		var err error
+		sig, err = hex.DecodeString(cfg.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("fail to decode the build signature: %w", err)
+		}
+	}
+	binaryHash, err := getBinaryHash()
+	if err != nil {
+		return nil, err
+	}
+	as := &AttestationService{
+		logger: log.With().Str("module", "attestation").Logger(),
+		host:   h,
+		local: BuildAttestation{
+			Version:    cfg.Version,
+			Commit:     cfg.Commit,
+			BinaryHash: binaryHash,
+			Signature:  sig,
+		},
+		releaseKey: releaseKey,
+		lock:       &sync.RWMutex{},
+		peers:      make(map[peer.ID]PeerAttestation),
+	}
+	if len(releaseKey) != 0 && as.local.Verify(releaseKey) != AttestationVerified {
+		as.logger.Warn().Msg("the build we run is not signed by the release key")
+	}
+	as.notifiee = &network.NotifyBundle{
+		ConnectedF: func(_ network.Network, conn network.Conn) {
+			go func() {
+				if err := as.Exchange(conn.RemotePeer()); err != nil {
+					as.logger.Debug().Err(err).Msgf("fail to exchange attestation with peer %s", conn.RemotePeer())
+				}
+			}()
+		},
+	}
+	h.SetStreamHandler(AttestationProtocolID, as.handleStream)
+	h.Network().Notify(as.notifiee)
+	return as, nil
+}
+
+func getBinaryHash() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("fail to find the executable: %w", err)
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return "", fmt.Errorf("fail to open the executable: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("fail to hash the executable: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Local return the attestation of this node
+func (as *AttestationService) Local() BuildAttestation {
+	return as.local
+}
+
+func (as *AttestationService) handleStream(stream network.Stream) {
+	remotePeer := stream.Conn().RemotePeer()
+	defer func() {
+		if err := stream.Close(); err != nil {
+			as.logger.Error().Err(err).Msg("fail to close the attestation stream")
+		}
+	}()
+	if err := as.readAttestation(remotePeer, stream); err != nil {
+		as.logger.Error().Err(err).Msgf("fail to read the attestation from peer %s", remotePeer)
+		return
+	}
+	if err := as.writeAttestation(stream); err != nil {
+		as.logger.Error().Err(err).Msgf("fail to send the attestation to peer %s", remotePeer)
+	}
+}
+
+// Exchange send our attestation to the given peer and record the attestation it replies with
+func (as *AttestationService) Exchange(remotePeer peer.ID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	stream, err := as.host.NewStream(ctx, remotePeer, AttestationProtocolID)
+	if err != nil {
+		return fmt.Errorf("fail to create stream to peer(%s): %w", remotePeer, err)
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			as.logger.Error().Err(err).Msg("fail to close the attestation stream")
+		}
+	}()
+	if err := as.writeAttestation(stream); err != nil {
+		return err
+	}
+	return as.readAttestation(remotePeer, stream)
+}
+
+func (as *AttestationService) writeAttestation(stream network.Stream) error {
+	buf, err := json.Marshal(as.local)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the attestation: %w", err)
+	}
+	return WriteStreamWithBuffer(buf, stream)
+}
+
+func (as *AttestationService) readAttestation(remotePeer peer.ID, stream network.Stream) error {
+	buf, err := ReadStreamWithBuffer(stream)
+	if err != nil {
+		return err
+	}
+	var attestation BuildAttestation
+	if err := json.Unmarshal(buf, &attestation); err != nil {
+		return fmt.Errorf("fail to unmarshal the attestation: %w", err)
+	}
+	status := attestation.Verify(as.releaseKey)
+	if status == AttestationInvalid || status == AttestationUnsigned {
+		as.logger.Warn().Msgf("peer %s runs an unofficial build(%s): version %s, commit %s", remotePeer, status, attestation.Version, attestation.Commit)
+	}
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	as.peers[remotePeer] = PeerAttestation{
+		Attestation: attestation,
+		Status:      status,
+		UpdatedAt:   time.Now(),
+	}
+	return nil
+}
+
+// PeerAttestations return the attestations of the peers we have exchanged with, keyed by peer ID
+func (as *AttestationService) PeerAttestations() map[string]PeerAttestation {
+	as.lock.RLock()
+	defer as.lock.RUnlock()
+	ret := make(map[string]PeerAttestation, len(as.peers))
+	for p, el := range as.peers {
+		ret[p.String()] = el
+	}
+	return ret
+}
+
+// Stop remove the stream handler and stop exchanging with the new peers
+func (as *AttestationService) Stop() {
+	as.host.RemoveStreamHandler(AttestationProtocolID)
+	as.host.Network().StopNotify(as.notifiee)
+}
@@ -0,0 +1,70 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttestationExchange(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 3)
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	binaryHash, err := getBinaryHash()
+	assert.Nil(t, err)
+	official := BuildAttestation{
+		Version:    "1.0.0",
+		Commit:     "abcdef",
+		BinaryHash: binaryHash,
+	}
+	sig := ed25519.Sign(privKey, official.SignBytes())
+	releaseKey := hex.EncodeToString(pubKey)
+
+	as1, err := NewAttestationService(hosts[0], AttestationConfig{
+		ReleasePubKey: releaseKey,
+		Version:       official.Version,
+		Commit:        official.Commit,
+		Signature:     hex.EncodeToString(sig),
+	})
+	assert.Nil(t, err)
+	defer as1.Stop()
+	assert.Equal(t, AttestationVerified, as1.Local().Verify(pubKey))
+	// the node runs the same binary but claims a different commit
+	as2, err := NewAttestationService(hosts[1], AttestationConfig{
+		ReleasePubKey: releaseKey,
+		Version:       official.Version,
+		Commit:        "tampered",
+		Signature:     hex.EncodeToString(sig),
+	})
+	assert.Nil(t, err)
+	defer as2.Stop()
+	as3, err := NewAttestationService(hosts[2], AttestationConfig{
+		ReleasePubKey: releaseKey,
+	})
+	assert.Nil(t, err)
+	defer as3.Stop()
+
+	assert.Nil(t, as1.Exchange(hosts[1].ID()))
+	assert.Nil(t, as1.Exchange(hosts[2].ID()))
+	assert.Nil(t, as2.Exchange(hosts[2].ID()))
+
+	peers := as1.PeerAttestations()
+	assert.Len(t, peers, 2)
+	assert.Equal(t, AttestationInvalid, peers[hosts[1].ID().String()].Status)
+	assert.Equal(t, "tampered", peers[hosts[1].ID().String()].Attestation.Commit)
+	assert.Equal(t, AttestationUnsigned, peers[hosts[2].ID().String()].Status)
+
+	// the exchange works both ways
+	peers = as3.PeerAttestations()
+	assert.Len(t, peers, 2)
+	assert.Equal(t, AttestationVerified, peers[hosts[0].ID().String()].Status)
+
+	_, err = NewAttestationService(hosts[0], AttestationConfig{ReleasePubKey: "invalid"})
+	assert.NotNil(t, err)
+	_, err = NewAttestationService(hosts[0], AttestationConfig{ReleasePubKey: hex.EncodeToString([]byte("short"))})
+	assert.NotNil(t, err)
+}
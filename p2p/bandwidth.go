@@ -0,0 +1,52 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/metrics"
+)
+
+// bandwidthIdleTimeout is how long we keep the counters of the peers and protocols that have gone quiet
+const bandwidthIdleTimeout = time.Hour
+
+// TrafficStats is the total bytes and the current rate in bytes per second of the traffic
+type TrafficStats struct {
+	TotalIn  int64   `json:"total_in"`
+	TotalOut int64   `json:"total_out"`
+	RateIn   float64 `json:"rate_in"`
+	RateOut  float64 `json:"rate_out"`
+}
+
+func newTrafficStats(stats metrics.Stats) TrafficStats {
+	return TrafficStats{
+		TotalIn:  stats.TotalIn,
+		TotalOut: stats.TotalOut,
+		RateIn:   stats.RateIn,
+		RateOut:  stats.RateOut,
+	}
+}
+
+// BandwidthStats is the traffic of the p2p host in total, by peer and by protocol
+type BandwidthStats struct {
+	Total     TrafficStats            `json:"total"`
+	Peers     map[string]TrafficStats `json:"peers"`
+	Protocols map[string]TrafficStats `json:"protocols"`
+}
+
+// Stats return the traffic of the p2p host in total, by peer and by protocol, the peers and protocols
+// that have been idle for an hour are dropped
+func (c *Communication) Stats() BandwidthStats {
+	c.bandwidth.TrimIdle(time.Now().Add(-bandwidthIdleTimeout))
+	stats := BandwidthStats{
+		Total:     newTrafficStats(c.bandwidth.GetBandwidthTotals()),
+		Peers:     make(map[string]TrafficStats),
+		Protocols: make(map[string]TrafficStats),
+	}
+	for p, el := range c.bandwidth.GetBandwidthByPeer() {
+		stats.Peers[p.String()] = newTrafficStats(el)
+	}
+	for p, el := range c.bandwidth.GetBandwidthByProtocol() {
+		stats.Protocols[string(p)] = newTrafficStats(el)
+	}
+	return stats
+}
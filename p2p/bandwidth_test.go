@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type BandwidthTestSuite struct{}
+
+var _ = Suite(&BandwidthTestSuite{})
+
+func (BandwidthTestSuite) TestStats(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2260/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2260, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2261, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	payload := bytes.Repeat([]byte("a"), 100000)
+	report := comm2.deliver([]peer.ID{comm.host.ID()}, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       "bandwidth",
+		Payload:     payload,
+	}), "bandwidth")
+	c.Assert(report.Failed(), HasLen, 0)
+
+	// the meters are updated once a second
+	time.Sleep(time.Second * 2)
+	sent := comm2.Stats()
+	c.Assert(sent.Total.TotalOut >= int64(len(payload)), Equals, true)
+	c.Assert(sent.Peers[comm.host.ID().String()].TotalOut >= int64(len(payload)), Equals, true)
+	c.Assert(sent.Protocols[string(TSSProtoProtocolID)].TotalOut >= int64(len(payload)), Equals, true)
+	received := comm.Stats()
+	c.Assert(received.Peers[comm2.host.ID().String()].TotalIn >= int64(len(payload)), Equals, true)
+}
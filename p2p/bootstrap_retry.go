@@ -0,0 +1,180 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	discoveryutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
+)
+
+// BootstrapRetryConfig defines how we keep retrying the bootstrap in the background if it fails at startup
+type BootstrapRetryConfig struct {
+	// InitialBackoff is the delay before the first retry, 0 disables the retry and the startup fails instead
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two retries
+	MaxBackoff time.Duration
+}
+
+// DefaultBootstrapRetryConfig return the default bootstrap retry configuration
+func DefaultBootstrapRetryConfig() BootstrapRetryConfig {
+	return BootstrapRetryConfig{
+		InitialBackoff: time.Second * 5,
+		MaxBackoff:     time.Minute * 5,
+	}
+}
+
+// Enabled return true if we should retry the bootstrap in the background
+func (cfg BootstrapRetryConfig) Enabled() bool {
+	return cfg.InitialBackoff > 0
+}
+
+// backoff return the delay before the given retry, it doubles on each retry until it reaches MaxBackoff
+func (cfg BootstrapRetryConfig) backoff(attempt int) time.Duration {
+	delay := cfg.InitialBackoff
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if cfg.MaxBackoff > 0 && delay >= cfg.MaxBackoff {
+			return cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// DiscoveryState describes whether we have joined the p2p network
+type DiscoveryState string
+
+const (
+	// DiscoveryPending we have not finished the bootstrap yet
+	DiscoveryPending DiscoveryState = "pending"
+	// DiscoveryDegraded we fail to reach any bootstrap peer and keep retrying in the background
+	DiscoveryDegraded DiscoveryState = "degraded"
+	// DiscoveryReady we have reached the bootstrap peers and announced ourselves
+	DiscoveryReady DiscoveryState = "ready"
+)
+
+// DiscoveryEvent is emitted every time the discovery state changes or a background retry fails
+type DiscoveryEvent struct {
+	State   DiscoveryState `json:"state"`
+	Attempt int            `json:"attempt"`
+	Error   string         `json:"error,omitempty"`
+	// QuorumReachable is true once we have reached a signing quorum of the cached committee directly, it
+	// does not depend on the DHT bootstrap
+	QuorumReachable bool `json:"quorum_reachable"`
+	// Isolated is true while we fail to reconnect to enough of the bootstrap peers and committee members
+	Isolated bool      `json:"isolated"`
+	Time     time.Time `json:"time"`
+}
+
+const discoveryEventBufferSize = 16
+
+type discoveryTracker struct {
+	lock   *sync.RWMutex
+	last   DiscoveryEvent
+	events chan DiscoveryEvent
+}
+
+func newDiscoveryTracker() *discoveryTracker {
+	return &discoveryTracker{
+		lock:   &sync.RWMutex{},
+		last:   DiscoveryEvent{State: DiscoveryPending, Time: time.Now()},
+		events: make(chan DiscoveryEvent, discoveryEventBufferSize),
+	}
+}
+
+func (dt *discoveryTracker) update(state DiscoveryState, attempt int, err error) {
+	ev := DiscoveryEvent{
+		State:   state,
+		Attempt: attempt,
+		Time:    time.Now(),
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	dt.lock.Lock()
+	// the cached committee stays reachable and the isolation stays as it is no matter how the DHT bootstrap goes
+	ev.QuorumReachable = dt.last.QuorumReachable
+	ev.Isolated = dt.last.Isolated
+	dt.last = ev
+	dt.lock.Unlock()
+	dt.emit(ev)
+}
+
+// reachQuorum mark the signing quorum of the cached committee as reachable, the state is kept as it is
+func (dt *discoveryTracker) reachQuorum() {
+	dt.lock.Lock()
+	dt.last.QuorumReachable = true
+	dt.last.Time = time.Now()
+	ev := dt.last
+	dt.lock.Unlock()
+	dt.emit(ev)
+}
+
+// isolate mark us as isolated or not, the event is only emitted if it changes
+func (dt *discoveryTracker) isolate(isolated bool) {
+	dt.lock.Lock()
+	if dt.last.Isolated == isolated {
+		dt.lock.Unlock()
+		return
+	}
+	dt.last.Isolated = isolated
+	dt.last.Time = time.Now()
+	ev := dt.last
+	dt.lock.Unlock()
+	dt.emit(ev)
+}
+
+func (dt *discoveryTracker) emit(ev DiscoveryEvent) {
+	// we never block the discovery for the slow consumers, the latest state can always be queried
+	select {
+	case dt.events <- ev:
+	default:
+	}
+}
+
+func (dt *discoveryTracker) state() DiscoveryEvent {
+	dt.lock.RLock()
+	defer dt.lock.RUnlock()
+	return dt.last
+}
+
+// bootstrap connect to the bootstrap peers, announce ourselves and check we can reach them
+func (c *Communication) bootstrap(ctx context.Context, kademliaDHT *dht.IpfsDHT) error {
+	if err := c.connectToBootstrapPeers(); err != nil {
+		return err
+	}
+	// We use a rendezvous point "meet me here" to announce our location.
+	// This is like telling your friends to meet you at the Eiffel Tower.
+	// the advertisement keeps running once started, so we only start it once
+	c.advertiseOnce.Do(func() {
+		routingDiscovery := routing.NewRoutingDiscovery(kademliaDHT)
+		discoveryutil.Advertise(ctx, routingDiscovery, c.rendezvous)
+	})
+	return c.bootStrapConnectivityCheck()
+}
+
+// retryBootstrap keeps retrying the bootstrap with a capped backoff until it succeeds or we stop
+func (c *Communication) retryBootstrap(ctx context.Context, kademliaDHT *dht.IpfsDHT) {
+	defer c.wg.Done()
+	for attempt := 0; ; attempt++ {
+		delay := c.bootstrapRetry.backoff(attempt)
+		select {
+		case <-c.stopChan:
+			return
+		case <-time.After(delay):
+		}
+		if err := kademliaDHT.Bootstrap(ctx); err != nil {
+			c.logger.Error().Err(err).Msg("fail to bootstrap DHT")
+		}
+		err := c.bootstrap(ctx, kademliaDHT)
+		if err == nil {
+			c.logger.Info().Msgf("recovered the p2p discovery after %d retries", attempt+1)
+			c.discovery.update(DiscoveryReady, attempt+1, nil)
+			return
+		}
+		c.logger.Warn().Err(err).Msgf("bootstrap retry %d failed, retry in %s", attempt+1, c.bootstrapRetry.backoff(attempt+1))
+		c.discovery.update(DiscoveryDegraded, attempt+1, err)
+	}
+}
@@ -0,0 +1,81 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type BootstrapRetryTestSuite struct{}
+
+var _ = Suite(&BootstrapRetryTestSuite{})
+
+func (BootstrapRetryTestSuite) TestBackoff(c *C) {
+	c.Assert(BootstrapRetryConfig{}.Enabled(), Equals, false)
+	cfg := DefaultBootstrapRetryConfig()
+	c.Assert(cfg.Enabled(), Equals, true)
+	c.Assert(cfg.backoff(0), Equals, time.Second*5)
+	c.Assert(cfg.backoff(1), Equals, time.Second*10)
+	c.Assert(cfg.backoff(5), Equals, time.Second*160)
+	c.Assert(cfg.backoff(6), Equals, time.Minute*5)
+	c.Assert(cfg.backoff(100), Equals, time.Minute*5)
+}
+
+func (BootstrapRetryTestSuite) TestDiscoveryTracker(c *C) {
+	dt := newDiscoveryTracker()
+	c.Assert(dt.state().State, Equals, DiscoveryPending)
+	dt.update(DiscoveryDegraded, 1, errors.New("you ask for it"))
+	c.Assert(dt.state().State, Equals, DiscoveryDegraded)
+	c.Assert(dt.state().Error, Equals, "you ask for it")
+	ev := <-dt.events
+	c.Assert(ev.Attempt, Equals, 1)
+	// the tracker never blocks even if nobody consumes the events
+	for i := 0; i < discoveryEventBufferSize*2; i++ {
+		dt.update(DiscoveryDegraded, i, nil)
+	}
+	dt.update(DiscoveryReady, 100, nil)
+	c.Assert(dt.state().State, Equals, DiscoveryReady)
+	c.Assert(dt.state().Attempt, Equals, 100)
+}
+
+func (BootstrapRetryTestSuite) TestRetryBootstrap(c *C) {
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2240/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2241, "",
+		WithBootstrapRetry(BootstrapRetryConfig{InitialBackoff: time.Millisecond * 100, MaxBackoff: time.Second}))
+	c.Assert(err, IsNil)
+	// the bootstrap peer is not up yet, but we still start in the degraded state
+	c.Assert(comm.Start(sk), IsNil)
+	defer comm.Stop()
+	c.Assert(comm.GetDiscoveryState().State, Equals, DiscoveryDegraded)
+	ev := <-comm.DiscoveryEvents()
+	c.Assert(ev.State, Equals, DiscoveryDegraded)
+
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	bootstrap, err := NewCommunication("commTest", nil, 2240, "")
+	c.Assert(err, IsNil)
+	c.Assert(bootstrap.Start(privKey), IsNil)
+	defer bootstrap.Stop()
+
+	timeout := time.After(time.Second * 30)
+	for {
+		select {
+		case ev := <-comm.DiscoveryEvents():
+			if ev.State != DiscoveryReady {
+				continue
+			}
+			c.Assert(ev.Attempt > 0, Equals, true)
+			c.Assert(comm.GetDiscoveryState().State, Equals, DiscoveryReady)
+			return
+		case <-timeout:
+			c.Fatal("fail to recover the bootstrap")
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// CapabilityProtocolID is the protocol we use to exchange the supported protocol versions and features
+var CapabilityProtocolID protocol.ID = "/p2p/capability"
+
+const (
+	// ProtocolVersionJSON the tss messages are sent in json
+	ProtocolVersionJSON = 1
+	// ProtocolVersionProto the tss messages are sent in protobuf, optionally compressed
+	ProtocolVersionProto = 2
+
+	// MinProtocolVersion is the oldest protocol version we still talk
+	MinProtocolVersion = ProtocolVersionJSON
+	// MaxProtocolVersion is the newest protocol version we talk
+	MaxProtocolVersion = ProtocolVersionProto
+)
+
+const (
+	// FeatureProtobuf the node takes the protobuf encoded tss messages
+	FeatureProtobuf = "protobuf"
+	// FeatureGossip the node joins the gossip topic
+	FeatureGossip = "gossip"
+	// FeatureEdDSA the node runs the EdDSA ceremonies, it is only advertised with eddsaEnabled. The ecdsa and the
+	// eddsa parties of the tss-lib we pin register their protobuf messages under the same names, so they cannot be
+	// linked into the same binary until we move to a tss-lib that separates them. The tss server refuses the eddsa
+	// keygen with ErrEdDSAKeygen, and the vault keygen of an ecdsa and an eddsa key with ErrVaultKeygen
+	FeatureEdDSA = "eddsa"
+	// FeatureFROST the node signs the FROST keysigns, the suffix is the wire version of the FROST messages
+	FeatureFROST = "frost/1"
+	// FeatureMuSig2 the node runs the MuSig2 keygens and keysigns, they send the FROST messages of the same wire
+	// version
+	FeatureMuSig2 = "musig2/1"
+)
+
+// eddsaEnabled is false until the eddsa parties can be linked into the binary, see FeatureEdDSA, so we never advertise
+// the eddsa ceremonies and no peer negotiates them with us
+const eddsaEnabled = false
+
+var (
+	// ErrPeerTooOld the peer only talks protocol versions older than the ones we support
+	ErrPeerTooOld = errors.New("peer too old")
+	// ErrPeerTooNew the peer only talks protocol versions newer than the ones we support
+	ErrPeerTooNew = errors.New("peer too new")
+)
+
+// compressionFeature return the feature of the given compression codec
+func compressionFeature(codec CompressionCodec) string {
+	return "compression/" + string(codec)
+}
+
+// Capabilities are the protocol versions and the features a node supports
+type Capabilities struct {
+	MinVersion int      `json:"min_version"`
+	MaxVersion int      `json:"max_version"`
+	Features   []string `json:"features"`
+}
+
+// Supports return true if the given feature is in the capabilities
+func (c Capabilities) Supports(feature string) bool {
+	for _, el := range c.Features {
+		if el == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate return the highest protocol version both capabilities support
+func (c Capabilities) negotiate(remote Capabilities) (int, error) {
+	version := c.MaxVersion
+	if remote.MaxVersion < version {
+		version = remote.MaxVersion
+	}
+	if version < c.MinVersion {
+		return 0, fmt.Errorf("%w: it supports protocol versions %d-%d, we need at least %d", ErrPeerTooOld, remote.MinVersion, remote.MaxVersion, c.MinVersion)
+	}
+	if version < remote.MinVersion {
+		return 0, fmt.Errorf("%w: it needs at least protocol version %d, we support up to %d", ErrPeerTooNew, remote.MinVersion, c.MaxVersion)
+	}
+	return version, nil
+}
+
+// PeerCapability is the capabilities we received from a peer and the protocol version we negotiated
+type PeerCapability struct {
+	Capabilities Capabilities `json:"capabilities"`
+	// Version is the negotiated protocol version, 0 if the peer is incompatible
+	Version   int       `json:"version"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	err       error
+}
+
+// localCapabilities return the capabilities of this node
+func (c *Communication) localCapabilities() Capabilities {
+	features := []string{FeatureProtobuf, FeatureFROST, FeatureMuSig2}
+	// we always accept the compressed streams, no matter which codec we compress with
+	for _, el := range supportedCodecs {
+		features = append(features, compressionFeature(el))
+	}
+	if c.gossipEnabled {
+		features = append(features, FeatureGossip)
+	}
+	if c.ceremonyStreams {
+		features = append(features, FeatureCeremonyStreams)
+	}
+	if eddsaEnabled {
+		features = append(features, FeatureEdDSA)
+	}
+	return Capabilities{
+		MinVersion: MinProtocolVersion,
+		MaxVersion: MaxProtocolVersion,
+		Features:   features,
+	}
+}
+
+// GetCapabilities return the protocol versions and the features this node advertises to its peers
+func (c *Communication) GetCapabilities() Capabilities {
+	return c.localCapabilities()
+}
+
+// startCapabilityExchange exchange the capabilities with the peers once we connect to them
+func (c *Communication) startCapabilityExchange() {
+	c.capNotifiee = &network.NotifyBundle{
+		ConnectedF: func(_ network.Network, conn network.Conn) {
+			go func() {
+				if err := c.ExchangeCapabilities(conn.RemotePeer()); err != nil {
+					c.logger.Debug().Err(err).Msgf("fail to exchange the capabilities with peer %s", conn.RemotePeer())
+				}
+			}()
+		},
+	}
+	c.host.SetStreamHandler(CapabilityProtocolID, c.handleCapabilityStream)
+	c.host.Network().Notify(c.capNotifiee)
+}
+
+func (c *Communication) stopCapabilityExchange() {
+	c.host.RemoveStreamHandler(CapabilityProtocolID)
+	c.host.Network().StopNotify(c.capNotifiee)
+}
+
+func (c *Communication) handleCapabilityStream(stream network.Stream) {
+	remotePeer := stream.Conn().RemotePeer()
+	defer func() {
+		if err := stream.Close(); err != nil {
+			c.logger.Error().Err(err).Msg("fail to close the capability stream")
+		}
+	}()
+	if err := c.readCapabilities(remotePeer, stream); err != nil {
+		c.logger.Error().Err(err).Msgf("fail to read the capabilities from peer %s", remotePeer)
+		return
+	}
+	if err := c.writeCapabilities(stream); err != nil {
+		c.logger.Error().Err(err).Msgf("fail to send the capabilities to peer %s", remotePeer)
+	}
+}
+
+// ExchangeCapabilities send our capabilities to the given peer and negotiate the protocol version with the
+// capabilities it replies with
+func (c *Communication) ExchangeCapabilities(remotePeer peer.ID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	stream, err := c.host.NewStream(ctx, remotePeer, CapabilityProtocolID)
+	if err != nil {
+		// the legacy peers do not exchange the capabilities, the stream protocol negotiation still works
+		return fmt.Errorf("fail to create stream to peer(%s): %w", remotePeer, err)
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			c.logger.Error().Err(err).Msg("fail to close the capability stream")
+		}
+	}()
+	if err := c.writeCapabilities(stream); err != nil {
+		return err
+	}
+	return c.readCapabilities(remotePeer, stream)
+}
+
+func (c *Communication) writeCapabilities(stream network.Stream) error {
+	buf, err := json.Marshal(c.localCapabilities())
+	if err != nil {
+		return fmt.Errorf("fail to marshal the capabilities: %w", err)
+	}
+	return WriteStreamWithBuffer(buf, stream)
+}
+
+func (c *Communication) readCapabilities(remotePeer peer.ID, stream network.Stream) error {
+	buf, err := ReadStreamWithBuffer(stream)
+	if err != nil {
+		return err
+	}
+	var remote Capabilities
+	if err := json.Unmarshal(buf, &remote); err != nil {
+		return fmt.Errorf("fail to unmarshal the capabilities: %w", err)
+	}
+	pc := PeerCapability{
+		Capabilities: remote,
+		UpdatedAt:    time.Now(),
+	}
+	pc.Version, pc.err = c.localCapabilities().negotiate(remote)
+	if pc.err != nil {
+		pc.Error = pc.err.Error()
+		c.logger.Warn().Err(pc.err).Msgf("peer %s is incompatible", remotePeer)
+	}
+	c.capabilityLock.Lock()
+	defer c.capabilityLock.Unlock()
+	c.capabilities[remotePeer] = pc
+	return nil
+}
+
+// tssProtocols return the tss protocols we can open a stream to the peer with to send a message of the given
+// ceremony, in the order we prefer them, it fails if the peer is incompatible
+func (c *Communication) tssProtocols(pID peer.ID, msgID string) ([]protocol.ID, error) {
+	c.capabilityLock.RLock()
+	pc, ok := c.capabilities[pID]
+	c.capabilityLock.RUnlock()
+	if ok && pc.err != nil {
+		return nil, fmt.Errorf("peer %s: %w", pID, pc.err)
+	}
+	// we let the stream protocol negotiation pick the format if we do not know the version of the peer yet
+	if ok && pc.Version < ProtocolVersionProto {
+		return []protocol.ID{TSSProtocolID}, nil
+	}
+	// the peer confirms it takes the per-ceremony protocols, we cannot offer them together with the shared
+	// ones as the host picks the first protocol the peer announces and the per-ceremony ones are never announced
+	if protocols := c.ceremonyProtocols(msgID, pc); len(protocols) > 0 {
+		return protocols, nil
+	}
+	// we prefer the protobuf wire format, the protocol negotiation falls back to json for the legacy peers
+	protocols := []protocol.ID{TSSProtoProtocolID, TSSProtocolID}
+	if c.compression.Codec != CompressionNone && (!ok || pc.Capabilities.Supports(compressionFeature(c.compression.Codec))) {
+		protocols = append([]protocol.ID{compressedProtocolID(c.compression.Codec)}, protocols...)
+	}
+	return protocols, nil
+}
+
+// GetPeerCapabilities return the capabilities of the peers we have exchanged with
+func (c *Communication) GetPeerCapabilities() map[peer.ID]PeerCapability {
+	c.capabilityLock.RLock()
+	defer c.capabilityLock.RUnlock()
+	ret := make(map[peer.ID]PeerCapability, len(c.capabilities))
+	for k, v := range c.capabilities {
+		ret[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,77 @@
+package p2p
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type CapabilityTestSuite struct{}
+
+var _ = Suite(&CapabilityTestSuite{})
+
+func (CapabilityTestSuite) TestNegotiate(c *C) {
+	local := Capabilities{MinVersion: 2, MaxVersion: 3}
+	version, err := local.negotiate(Capabilities{MinVersion: 1, MaxVersion: 2})
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, 2)
+	version, err = local.negotiate(Capabilities{MinVersion: 1, MaxVersion: 5})
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, 3)
+
+	_, err = local.negotiate(Capabilities{MinVersion: 1, MaxVersion: 1})
+	c.Assert(errors.Is(err, ErrPeerTooOld), Equals, true)
+	c.Assert(err, ErrorMatches, "peer too old: it supports protocol versions 1-1, we need at least 2")
+	_, err = local.negotiate(Capabilities{MinVersion: 4, MaxVersion: 5})
+	c.Assert(errors.Is(err, ErrPeerTooNew), Equals, true)
+
+	c.Assert(Capabilities{Features: []string{FeatureProtobuf}}.Supports(FeatureProtobuf), Equals, true)
+	c.Assert(Capabilities{Features: []string{FeatureProtobuf}}.Supports(FeatureEdDSA), Equals, false)
+
+	// the eddsa ceremonies are never advertised
+	comm := &Communication{gossipEnabled: true, ceremonyStreams: true}
+	c.Assert(comm.localCapabilities().Supports(FeatureEdDSA), Equals, false)
+}
+
+func (CapabilityTestSuite) TestExchangeCapabilities(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2350/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2350, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2351, "", WithGossip(true))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	// both sides negotiate the version once they connect
+	c.Assert(waitFor(func() bool {
+		_, ok := comm.GetPeerCapabilities()[comm2.host.ID()]
+		_, ok2 := comm2.GetPeerCapabilities()[comm.host.ID()]
+		return ok && ok2
+	}), Equals, true)
+	pc := comm.GetPeerCapabilities()[comm2.host.ID()]
+	c.Assert(pc.Version, Equals, MaxProtocolVersion)
+	c.Assert(pc.Capabilities.Supports(FeatureGossip), Equals, true)
+	c.Assert(comm2.GetPeerCapabilities()[comm.host.ID()].Capabilities.Supports(FeatureGossip), Equals, false)
+
+	// we refuse to open the tss streams to an incompatible peer with a clear error
+	comm.capabilityLock.Lock()
+	comm.capabilities[comm2.host.ID()] = PeerCapability{err: ErrPeerTooOld}
+	comm.capabilityLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = comm.connectToOnePeer(ctx, comm2.host.ID(), "")
+	c.Assert(errors.Is(err, ErrPeerTooOld), Equals, true)
+}
@@ -0,0 +1,68 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+)
+
+// ceremonyContexts keeps the context of each ceremony we are sending the messages of, so the in-flight deliveries
+// of a cancelled ceremony are torn down. The context is dropped once no delivery of the ceremony is in flight
+type ceremonyContexts struct {
+	lock     *sync.Mutex
+	contexts map[string]*ceremonyContext
+}
+
+type ceremonyContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	refs   int
+}
+
+func newCeremonyContexts() *ceremonyContexts {
+	return &ceremonyContexts{
+		lock:     &sync.Mutex{},
+		contexts: make(map[string]*ceremonyContext),
+	}
+}
+
+// acquire return the context of the delivery of the ceremony, the returned func should be called once the
+// delivery finished
+func (cc *ceremonyContexts) acquire(msgID string) (context.Context, func()) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	el, ok := cc.contexts[msgID]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		el = &ceremonyContext{ctx: ctx, cancel: cancel}
+		cc.contexts[msgID] = el
+	}
+	el.refs++
+	return el.ctx, func() {
+		cc.lock.Lock()
+		defer cc.lock.Unlock()
+		el.refs--
+		if el.refs == 0 {
+			el.cancel()
+			if cc.contexts[msgID] == el {
+				delete(cc.contexts, msgID)
+			}
+		}
+	}
+}
+
+// cancel tear down the in-flight deliveries of the ceremony
+func (cc *ceremonyContexts) cancel(msgID string) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	if el, ok := cc.contexts[msgID]; ok {
+		el.cancel()
+		delete(cc.contexts, msgID)
+	}
+}
+
+// CancelCeremony tear down the in-flight deliveries of the messages of the ceremony and its streams, the
+// ceremony is cancelled and will not send any more messages
+func (c *Communication) CancelCeremony(msgID string) {
+	c.ceremonies.cancel(msgID)
+	c.ReleaseStream(msgID)
+}
@@ -0,0 +1,109 @@
+package p2p
+
+import (
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// FeatureCeremonyStreams the node takes the tss messages over the per-ceremony protocols
+const FeatureCeremonyStreams = "ceremony-streams"
+
+// ceremonyStatsProtocol is the protocol we account the traffic over all the per-ceremony protocols to in the
+// peer statistics, so the number of the protocols we report stays bounded
+const ceremonyStatsProtocol protocol.ID = "/p2p/tss/ceremony"
+
+// CeremonyProtocolID return the protocol that isolates the tss messages of the given ceremony, the messages
+// are sent in protobuf
+func CeremonyProtocolID(msgID string) protocol.ID {
+	return TSSProtocolID + protocol.ID("/"+msgID)
+}
+
+// ceremonyProtocolID return the protocol of the given ceremony compressed with the given codec
+func ceremonyProtocolID(msgID string, codec CompressionCodec) protocol.ID {
+	if codec == CompressionNone {
+		return CeremonyProtocolID(msgID)
+	}
+	return CeremonyProtocolID(msgID) + protocol.ID("/"+string(codec))
+}
+
+// validCeremonyID return true if the message id can be part of a protocol id, the ceremony message ids are
+// hex encoded hashes, so they never clash with the other tss protocols
+func validCeremonyID(msgID string) bool {
+	if len(msgID) == 0 {
+		return false
+	}
+	for _, el := range msgID {
+		if (el < '0' || el > '9') && (el < 'a' || el > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCeremonyProtocol return the message id and the compression codec of a per-ceremony protocol
+func parseCeremonyProtocol(protocolID protocol.ID) (string, CompressionCodec, bool) {
+	prefix := string(TSSProtocolID) + "/"
+	if !strings.HasPrefix(string(protocolID), prefix) {
+		return "", CompressionNone, false
+	}
+	parts := strings.Split(strings.TrimPrefix(string(protocolID), prefix), "/")
+	if !validCeremonyID(parts[0]) {
+		return "", CompressionNone, false
+	}
+	switch len(parts) {
+	case 1:
+		return parts[0], CompressionNone, true
+	case 2:
+		for _, el := range supportedCodecs {
+			if string(el) == parts[1] {
+				return parts[0], el, true
+			}
+		}
+	}
+	return "", CompressionNone, false
+}
+
+// isCeremonyProtocol match the per-ceremony protocols for the stream handler
+func isCeremonyProtocol(protocolID string) bool {
+	_, _, ok := parseCeremonyProtocol(protocol.ID(protocolID))
+	return ok
+}
+
+// ceremonyProtocols return the per-ceremony protocols we can open a stream to the peer with, in the order we
+// prefer them, nothing if the peer does not support them
+func (c *Communication) ceremonyProtocols(msgID string, pc PeerCapability) []protocol.ID {
+	if !c.ceremonyStreams || !validCeremonyID(msgID) || !pc.Capabilities.Supports(FeatureCeremonyStreams) {
+		return nil
+	}
+	protocols := []protocol.ID{CeremonyProtocolID(msgID)}
+	if c.compression.Codec != CompressionNone && pc.Capabilities.Supports(compressionFeature(c.compression.Codec)) {
+		protocols = append([]protocol.ID{ceremonyProtocolID(msgID, c.compression.Codec)}, protocols...)
+	}
+	return protocols
+}
+
+// resetCeremonyStreams reset all the streams we still have open over the protocols of the given ceremony,
+// including the ones we are still reading or writing
+func (c *Communication) resetCeremonyStreams(msgID string) {
+	if c.host == nil || !validCeremonyID(msgID) {
+		return
+	}
+	for _, conn := range c.host.Network().Conns() {
+		for _, stream := range conn.GetStreams() {
+			id, _, ok := parseCeremonyProtocol(stream.Protocol())
+			if !ok || id != msgID {
+				continue
+			}
+			if err := stream.Reset(); err != nil {
+				c.logger.Error().Err(err).Msg("fail to reset the ceremony stream")
+			}
+		}
+	}
+}
+
+// GetCeremonyStats return the tss message traffic over the protocols of the given ceremony, the traffic is
+// dropped once the streams of the ceremony are released
+func (c *Communication) GetCeremonyStats(msgID string) (ProtocolStats, bool) {
+	return c.peerStats.ceremony(msgID)
+}
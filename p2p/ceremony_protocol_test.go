@@ -0,0 +1,120 @@
+package p2p
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type CeremonyProtocolTestSuite struct{}
+
+var _ = Suite(&CeremonyProtocolTestSuite{})
+
+const testCeremonyID = "4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"
+
+func (CeremonyProtocolTestSuite) TestParseCeremonyProtocol(c *C) {
+	c.Assert(CeremonyProtocolID(testCeremonyID), Equals, protocol.ID("/p2p/tss/"+testCeremonyID))
+	msgID, codec, ok := parseCeremonyProtocol(CeremonyProtocolID(testCeremonyID))
+	c.Assert(ok, Equals, true)
+	c.Assert(msgID, Equals, testCeremonyID)
+	c.Assert(codec, Equals, CompressionNone)
+	msgID, codec, ok = parseCeremonyProtocol(ceremonyProtocolID(testCeremonyID, CompressionZstd))
+	c.Assert(ok, Equals, true)
+	c.Assert(msgID, Equals, testCeremonyID)
+	c.Assert(codec, Equals, CompressionZstd)
+	c.Assert(codecForProtocol(ceremonyProtocolID(testCeremonyID, CompressionSnappy)), Equals, CompressionSnappy)
+
+	// the shared tss protocols are not ceremony protocols
+	for _, el := range []protocol.ID{
+		TSSProtocolID,
+		TSSProtoProtocolID,
+		compressedProtocolID(CompressionZstd),
+		ceremonyStatsProtocol,
+		CeremonyProtocolID(testCeremonyID) + "/unknown",
+		CeremonyProtocolID("NotHex"),
+	} {
+		c.Assert(isCeremonyProtocol(string(el)), Equals, false, Commentf("%s", el))
+	}
+	c.Assert(validCeremonyID(""), Equals, false)
+	c.Assert(validCeremonyID("hello"), Equals, false)
+}
+
+func (CeremonyProtocolTestSuite) TestCeremonyStreams(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2400/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2400, "", WithCeremonyStreams(true))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2401, "", WithCeremonyStreams(true))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+	remote := comm.host.ID()
+	c.Assert(waitFor(func() bool {
+		_, ok := comm2.GetPeerCapabilities()[remote]
+		return ok
+	}), Equals, true)
+	protocols, err := comm2.tssProtocols(remote, testCeremonyID)
+	c.Assert(err, IsNil)
+	c.Assert(protocols[0], Equals, CeremonyProtocolID(testCeremonyID))
+	// the message ids that cannot be part of a protocol use the shared protocols
+	protocols, err = comm2.tssProtocols(remote, "hello")
+	c.Assert(err, IsNil)
+	c.Assert(protocols[0], Equals, TSSProtoProtocolID)
+
+	received := make(chan *Message, 1)
+	comm.SetSubscribe(messages.TSSKeySignMsg, testCeremonyID, received)
+	defer comm.CancelSubscribe(messages.TSSKeySignMsg, testCeremonyID)
+	comm2.broadcast([]peer.ID{remote}, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       testCeremonyID,
+		Payload:     []byte("tss keysign message"),
+	}), testCeremonyID)
+	select {
+	case msg := <-received:
+		wrappedMsg, err := messages.UnmarshalWrappedMessage(msg.Payload)
+		c.Assert(err, IsNil)
+		c.Assert(wrappedMsg.Payload, DeepEquals, []byte("tss keysign message"))
+	case <-time.After(time.Second * 10):
+		c.Fatal("fail to receive the message")
+	}
+	stats, ok := comm.GetCeremonyStats(testCeremonyID)
+	c.Assert(ok, Equals, true)
+	c.Assert(stats.MessagesIn, Equals, int64(1))
+	stats, ok = comm2.GetCeremonyStats(testCeremonyID)
+	c.Assert(ok, Equals, true)
+	c.Assert(stats.MessagesOut, Equals, int64(1))
+	// the traffic of all the ceremonies is reported under a single protocol
+	c.Assert(comm.GetPeerStats()[comm2.host.ID()][ceremonyStatsProtocol].MessagesIn, Equals, int64(1))
+
+	// a message of another ceremony over the protocol of this ceremony is dropped
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	c.Assert(comm2.writeToStream(ctx, remote, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       "another",
+		Payload:     []byte("tss keysign message"),
+	}), testCeremonyID), IsNil)
+	c.Assert(waitFor(func() bool {
+		stats, _ := comm.GetCeremonyStats(testCeremonyID)
+		return stats.Failures == 1
+	}), Equals, true)
+
+	comm.ReleaseStream(testCeremonyID)
+	_, ok = comm.GetCeremonyStats(testCeremonyID)
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,86 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+const (
+	// channelNearCapacity is the occupancy ratio we consider the subscriber channel is near its capacity
+	channelNearCapacity = 0.8
+	// channelWarnAfter is how long the channel needs to stay near its capacity before we warn
+	channelWarnAfter = time.Second * 5
+	// channelWarnInterval is the minimal interval between two warnings of the same message type
+	channelWarnInterval = time.Second * 30
+)
+
+type channelState struct {
+	length            int
+	capacity          int
+	nearCapacitySince time.Time
+	lastWarn          time.Time
+}
+
+// channelMonitor keeps track of the occupancy of the subscriber channels, undersized channels
+// block the stream reader and stall the tss rounds, so we warn when a channel stays near its capacity
+type channelMonitor struct {
+	logger zerolog.Logger
+	lock   *sync.Mutex
+	states map[messages.THORChainTSSMessageType]*channelState
+	now    func() time.Time
+}
+
+func newChannelMonitor() *channelMonitor {
+	return &channelMonitor{
+		logger: log.With().Str("module", "channel_monitor").Logger(),
+		lock:   &sync.Mutex{},
+		states: make(map[messages.THORChainTSSMessageType]*channelState),
+		now:    time.Now,
+	}
+}
+
+// observe record the occupancy of the subscriber channel of the given message type
+func (cm *channelMonitor) observe(msgType messages.THORChainTSSMessageType, length, capacity int) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	state, ok := cm.states[msgType]
+	if !ok {
+		state = &channelState{}
+		cm.states[msgType] = state
+	}
+	state.length = length
+	state.capacity = capacity
+	if capacity == 0 || float64(length)/float64(capacity) < channelNearCapacity {
+		state.nearCapacitySince = time.Time{}
+		return
+	}
+	now := cm.now()
+	if state.nearCapacitySince.IsZero() {
+		state.nearCapacitySince = now
+		return
+	}
+	if now.Sub(state.nearCapacitySince) >= channelWarnAfter && now.Sub(state.lastWarn) >= channelWarnInterval {
+		state.lastWarn = now
+		cm.logger.Warn().Msgf("subscriber channel of %s stays near its capacity(%d/%d) for %s, consider to enlarge it", msgType, length, capacity, now.Sub(state.nearCapacitySince))
+	}
+}
+
+// occupancy return the latest observed occupancy ratio of the subscriber channels
+func (cm *channelMonitor) occupancy() map[string]float64 {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	ret := make(map[string]float64, len(cm.states))
+	for msgType, state := range cm.states {
+		if state.capacity == 0 {
+			ret[msgType.String()] = 0
+			continue
+		}
+		ret[msgType.String()] = float64(state.length) / float64(state.capacity)
+	}
+	return ret
+}
@@ -0,0 +1,40 @@
+package p2p
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type ChannelMonitorTestSuite struct{}
+
+var _ = Suite(&ChannelMonitorTestSuite{})
+
+func (ChannelMonitorTestSuite) TestObserve(c *C) {
+	cm := newChannelMonitor()
+	now := time.Now()
+	cm.now = func() time.Time { return now }
+	cm.observe(messages.TSSKeySignMsg, 1, 10)
+	cm.observe(messages.TSSKeySignVerMsg, 0, 0)
+	occupancy := cm.occupancy()
+	c.Assert(occupancy, HasLen, 2)
+	c.Assert(occupancy[messages.TSSKeySignMsg.String()], Equals, 0.1)
+	c.Assert(occupancy[messages.TSSKeySignVerMsg.String()], Equals, float64(0))
+
+	cm.observe(messages.TSSKeySignMsg, 9, 10)
+	state := cm.states[messages.TSSKeySignMsg]
+	c.Assert(state.nearCapacitySince.Equal(now), Equals, true)
+	now = now.Add(channelWarnAfter)
+	cm.observe(messages.TSSKeySignMsg, 10, 10)
+	c.Assert(state.lastWarn.Equal(now), Equals, true)
+	// we do not warn again within the warn interval
+	now = now.Add(time.Second)
+	cm.observe(messages.TSSKeySignMsg, 10, 10)
+	c.Assert(state.lastWarn.Equal(now.Add(-time.Second)), Equals, true)
+	c.Assert(cm.occupancy()[messages.TSSKeySignMsg.String()], Equals, float64(1))
+
+	cm.observe(messages.TSSKeySignMsg, 2, 10)
+	c.Assert(state.nearCapacitySince.IsZero(), Equals, true)
+}
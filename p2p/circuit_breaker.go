@@ -0,0 +1,164 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// ErrCircuitOpen we do not dial the peer as the recent dials to it keep failing
+var ErrCircuitOpen = errcode.New(errcode.PeerUnreachable, "circuit open")
+
+// CircuitBreakerConfig defines when we stop dialing the peers that keep failing
+type CircuitBreakerConfig struct {
+	// Threshold is the number of the failed dials in a row that opens the circuit, 0 disables the breaker
+	Threshold int
+	// ProbeInterval is how often we dial the peers with an open circuit in the background to close it again
+	ProbeInterval time.Duration
+}
+
+// DefaultCircuitBreakerConfig return the default circuit breaker configuration
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Threshold:     3,
+		ProbeInterval: time.Second * 30,
+	}
+}
+
+// Enabled return true if we should fast fail the dials to the peers that keep failing
+func (cfg CircuitBreakerConfig) Enabled() bool {
+	return cfg.Threshold > 0 && cfg.ProbeInterval > 0
+}
+
+type circuitState struct {
+	failures int
+	// openedAt is zero while the circuit is closed
+	openedAt time.Time
+}
+
+// circuitBreaker counts the failed dials in a row of each peer, it opens the circuit of the peer once it
+// reaches the threshold and closes it on the first successful dial or connection
+type circuitBreaker struct {
+	cfg    CircuitBreakerConfig
+	lock   *sync.Mutex
+	states map[peer.ID]*circuitState
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{
+		cfg:    cfg,
+		lock:   &sync.Mutex{},
+		states: make(map[peer.ID]*circuitState),
+	}
+}
+
+// allow return false if the circuit of the peer is open
+func (cb *circuitBreaker) allow(pID peer.ID) bool {
+	if !cb.cfg.Enabled() {
+		return true
+	}
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	state, ok := cb.states[pID]
+	return !ok || state.openedAt.IsZero()
+}
+
+// failure record a failed dial, it return true if the circuit has just opened
+func (cb *circuitBreaker) failure(pID peer.ID) bool {
+	if !cb.cfg.Enabled() {
+		return false
+	}
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	state, ok := cb.states[pID]
+	if !ok {
+		state = &circuitState{}
+		cb.states[pID] = state
+	}
+	state.failures++
+	if state.failures >= cb.cfg.Threshold && state.openedAt.IsZero() {
+		state.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// success reset the failures of the peer, it return true if the circuit was open
+func (cb *circuitBreaker) success(pID peer.ID) bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	state, ok := cb.states[pID]
+	if !ok {
+		return false
+	}
+	delete(cb.states, pID)
+	return !state.openedAt.IsZero()
+}
+
+// open return the peers with an open circuit
+func (cb *circuitBreaker) open() []peer.ID {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	var peers []peer.ID
+	for pID, state := range cb.states {
+		if !state.openedAt.IsZero() {
+			peers = append(peers, pID)
+		}
+	}
+	return peers
+}
+
+func (c *Communication) dialFailed(pID peer.ID) {
+	if c.breaker.failure(pID) {
+		c.logger.Warn().Msgf("fail to dial peer %s %d times in a row, stop dialing it until it is reachable again", pID, c.breaker.cfg.Threshold)
+	}
+}
+
+func (c *Communication) dialSucceeded(pID peer.ID) {
+	if c.breaker.success(pID) {
+		c.logger.Info().Msgf("peer %s is reachable again", pID)
+	}
+}
+
+// probeOpenCircuits dial the peers with an open circuit in the background, so we close the circuit as soon
+// as the peer is back
+func (c *Communication) probeOpenCircuits() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-time.After(c.breaker.cfg.ProbeInterval):
+		}
+		peers := c.breaker.open()
+		if len(peers) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
+		go func() {
+			select {
+			case <-c.stopChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		wg := sync.WaitGroup{}
+		for _, el := range peers {
+			wg.Add(1)
+			go func(pID peer.ID) {
+				defer wg.Done()
+				if err := c.host.Connect(ctx, peer.AddrInfo{ID: pID}); err != nil {
+					c.logger.Debug().Err(err).Msgf("peer %s is still unreachable", pID)
+					return
+				}
+				c.dialSucceeded(pID)
+			}(el)
+		}
+		wg.Wait()
+		cancel()
+	}
+}
@@ -0,0 +1,81 @@
+package p2p
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type CircuitBreakerTestSuite struct{}
+
+var _ = Suite(&CircuitBreakerTestSuite{})
+
+func (CircuitBreakerTestSuite) TestCircuitBreaker(c *C) {
+	pID, err := peer.Decode(latencyTestPeers[0])
+	c.Assert(err, IsNil)
+	cb := newCircuitBreaker(CircuitBreakerConfig{Threshold: 2, ProbeInterval: time.Second})
+	c.Assert(cb.failure(pID), Equals, false)
+	c.Assert(cb.allow(pID), Equals, true)
+	c.Assert(cb.failure(pID), Equals, true)
+	c.Assert(cb.allow(pID), Equals, false)
+	c.Assert(cb.open(), DeepEquals, []peer.ID{pID})
+	// we only report the circuit opening once
+	c.Assert(cb.failure(pID), Equals, false)
+	c.Assert(cb.success(pID), Equals, true)
+	c.Assert(cb.allow(pID), Equals, true)
+	c.Assert(cb.open(), HasLen, 0)
+	c.Assert(cb.success(pID), Equals, false)
+
+	disabled := newCircuitBreaker(CircuitBreakerConfig{})
+	c.Assert(disabled.failure(pID), Equals, false)
+	c.Assert(disabled.failure(pID), Equals, false)
+	c.Assert(disabled.allow(pID), Equals, true)
+}
+
+func (CircuitBreakerTestSuite) TestFastFailDial(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2380/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2380, "",
+		WithCircuitBreaker(CircuitBreakerConfig{Threshold: 2, ProbeInterval: time.Minute}))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+
+	// nobody listens on the address of the peer yet
+	remote, err := peer.Decode(latencyTestPeers[2])
+	c.Assert(err, IsNil)
+	remoteAddr, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/2381")
+	c.Assert(err, IsNil)
+	comm.host.Peerstore().AddAddr(remote, remoteAddr, peerstore.TempAddrTTL)
+	for i := 0; i < 2; i++ {
+		_, err = comm.connectToOnePeer(context.Background(), remote, "")
+		c.Assert(err, NotNil)
+		c.Assert(errors.Is(err, ErrCircuitOpen), Equals, false)
+	}
+	start := time.Now()
+	_, err = comm.connectToOnePeer(context.Background(), remote, "")
+	c.Assert(errors.Is(err, ErrCircuitOpen), Equals, true)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+
+	// the circuit is closed once the peer connects to us
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2381, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+	c.Assert(comm2.host.ID(), Equals, remote)
+	c.Assert(waitFor(func() bool {
+		return comm.breaker.allow(remote)
+	}), Equals, true)
+}
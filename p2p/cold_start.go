@@ -0,0 +1,103 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// ColdStartConfig defines the committee members we dial directly on start, in parallel with the DHT bootstrap
+type ColdStartConfig struct {
+	// Peers are the cached addresses of the committee members, usually the address book we saved after the
+	// last ceremony
+	Peers []Multiaddr
+	// Quorum is the number of the committee members, including us, we need to reach to sign, 0 uses the
+	// threshold+1 of the committee in Peers
+	Quorum int
+}
+
+// Enabled return true if we have any cached committee member to dial
+func (cfg ColdStartConfig) Enabled() bool {
+	return len(cfg.Peers) > 0
+}
+
+// committee return the cached committee members without us, the addresses of the same peer are merged
+func (cfg ColdStartConfig) committee(self peer.ID) []peer.AddrInfo {
+	infos, err := peer.AddrInfosFromP2pAddrs(cfg.Peers...)
+	if err != nil {
+		return nil
+	}
+	members := make([]peer.AddrInfo, 0, len(infos))
+	for _, el := range infos {
+		if el.ID == self {
+			continue
+		}
+		members = append(members, el)
+	}
+	return members
+}
+
+// quorum return the number of the committee members, including us, we need to reach
+func (cfg ColdStartConfig) quorum(committeeSize int) int {
+	if cfg.Quorum > 0 {
+		return cfg.Quorum
+	}
+	threshold, err := conversion.GetThreshold(committeeSize)
+	if err != nil {
+		return committeeSize
+	}
+	return threshold + 1
+}
+
+// dialCachedPeers dial the cached committee members in parallel, and report the quorum as reachable once
+// enough of them answer, so we do not have to wait for the DHT to be ready before we can sign
+func (c *Communication) dialCachedPeers() {
+	defer c.wg.Done()
+	start := time.Now()
+	members := c.coldStart.committee(c.host.ID())
+	if len(members) == 0 {
+		c.logger.Warn().Msg("no valid cached committee member address, skip the cold start")
+		return
+	}
+	quorum := c.coldStart.quorum(len(members) + 1)
+	if quorum <= 1 {
+		c.discovery.reachQuorum()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// we count ourselves in the quorum
+	reached := int32(1)
+	wg := sync.WaitGroup{}
+	for _, el := range members {
+		wg.Add(1)
+		go func(pi peer.AddrInfo) {
+			defer wg.Done()
+			if err := c.host.Connect(ctx, pi); err != nil {
+				c.logger.Debug().Err(err).Msgf("fail to dial the cached committee member %s", pi.ID)
+				return
+			}
+			if int(atomic.AddInt32(&reached, 1)) == quorum {
+				c.logger.Info().Msgf("reach the signing quorum of %d members in %s", quorum, time.Since(start))
+				c.discovery.reachQuorum()
+			}
+		}(el)
+	}
+	wg.Wait()
+	if n := int(atomic.LoadInt32(&reached)); n < quorum {
+		c.logger.Warn().Msgf("only reach %d of the %d committee members we need, wait for the DHT bootstrap", n, quorum)
+	}
+}
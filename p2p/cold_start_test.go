@@ -0,0 +1,90 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type ColdStartTestSuite struct{}
+
+var _ = Suite(&ColdStartTestSuite{})
+
+func (ColdStartTestSuite) TestCommittee(c *C) {
+	c.Assert(ColdStartConfig{}.Enabled(), Equals, false)
+	self, err := peer.Decode("16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+	c.Assert(err, IsNil)
+	var addrs []Multiaddr
+	for _, el := range []string{
+		"/ip4/10.0.0.1/tcp/6668/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh",
+		"/ip4/10.0.0.2/tcp/6668/p2p/16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa",
+		"/ip4/10.0.0.3/tcp/6668/p2p/16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa",
+		"/ip4/10.0.0.4/tcp/6668/p2p/16Uiu2HAmACG5DtqmQsHtXg4G2sLS65ttv84e7MrL4kapkjfmhxAp",
+	} {
+		addr, err := maddr.NewMultiaddr(el)
+		c.Assert(err, IsNil)
+		addrs = append(addrs, addr)
+	}
+	cfg := ColdStartConfig{Peers: addrs}
+	c.Assert(cfg.Enabled(), Equals, true)
+	// we are not in our own committee, and the addresses of the same peer are merged
+	members := cfg.committee(self)
+	c.Assert(members, HasLen, 2)
+	for _, el := range members {
+		c.Assert(el.ID, Not(Equals), self)
+	}
+	c.Assert(cfg.quorum(3), Equals, 2)
+	c.Assert(cfg.quorum(4), Equals, 3)
+	c.Assert(ColdStartConfig{Quorum: 2}.quorum(4), Equals, 2)
+}
+
+func (ColdStartTestSuite) TestReachQuorum(c *C) {
+	dt := newDiscoveryTracker()
+	dt.update(DiscoveryDegraded, 1, nil)
+	dt.reachQuorum()
+	c.Assert(dt.state().State, Equals, DiscoveryDegraded)
+	c.Assert(dt.state().QuorumReachable, Equals, true)
+	// the quorum stays reachable whatever the DHT bootstrap reports
+	dt.update(DiscoveryDegraded, 2, nil)
+	c.Assert(dt.state().QuorumReachable, Equals, true)
+}
+
+func (ColdStartTestSuite) TestDialCachedPeers(c *C) {
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	member, err := NewCommunication("commTest", nil, 2270, "")
+	c.Assert(err, IsNil)
+	c.Assert(member.Start(privKey), IsNil)
+	defer member.Stop()
+
+	// the bootstrap peer is down, but the cached committee member is up
+	bootstrapPeer, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/2272/p2p/16Uiu2HAmACG5DtqmQsHtXg4G2sLS65ttv84e7MrL4kapkjfmhxAp")
+	c.Assert(err, IsNil)
+	cachedPeer, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/2270/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+	c.Assert(err, IsNil)
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", []maddr.Multiaddr{bootstrapPeer}, 2271, "",
+		WithBootstrapRetry(BootstrapRetryConfig{InitialBackoff: time.Minute, MaxBackoff: time.Minute}),
+		WithColdStart(ColdStartConfig{Peers: []Multiaddr{cachedPeer}}))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(sk), IsNil)
+	defer comm.Stop()
+
+	timeout := time.After(time.Second * 10)
+	for {
+		select {
+		case ev := <-comm.DiscoveryEvents():
+			if !ev.QuorumReachable {
+				continue
+			}
+			c.Assert(comm.GetDiscoveryState().State, Equals, DiscoveryDegraded)
+			return
+		case <-timeout:
+			c.Fatal("fail to reach the quorum of the cached committee")
+		}
+	}
+}
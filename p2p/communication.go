@@ -2,7 +2,6 @@ package p2p
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -10,19 +9,21 @@ import (
 	"time"
 
 	"github.com/libp2p/go-libp2p"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
-  "github.com/libp2p/go-libp2p/p2p/discovery/routing"
-	discoveryutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
-	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	maddr "github.com/multiformats/go-multiaddr"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	maddr "github.com/multiformats/go-multiaddr"
 
+	"github.com/akildemir/go-tss/conversion"
 	"github.com/akildemir/go-tss/messages"
 )
 
@@ -34,6 +35,10 @@ var (
 // TSSProtocolID protocol id used for tss
 var TSSProtocolID protocol.ID = "/p2p/tss"
 
+// TSSProtoProtocolID protocol id used for tss with the protobuf wire format, the peers that do not
+// support it fall back to TSSProtocolID which uses json
+var TSSProtoProtocolID protocol.ID = "/p2p/tss/proto"
+
 const (
 	// TimeoutConnecting maximum time for wait for peers to connect
 	TimeoutConnecting = time.Second * 20
@@ -60,10 +65,218 @@ type Communication struct {
 	BroadcastMsgChan chan *messages.BroadcastMsgChan
 	externalAddr     Multiaddr
 	streamMgr        *StreamMgr
+	rateLimiter      *StreamRateLimiter
+	resourceLimits   ResourceLimitConfig
+	attestationConf  *AttestationConfig
+	attestation      *AttestationService
+	identitySigner   conversion.Signer
+	channelMonitor   *channelMonitor
+	compression      CompressionConfig
+	maxMessageSize   int
+	compressionStats *compressionCounter
+	bootstrapRetry   BootstrapRetryConfig
+	coldStart        ColdStartConfig
+	discovery        *discoveryTracker
+	advertiseOnce    *sync.Once
+	delivery         DeliveryConfig
+	ceremonies       *ceremonyContexts
+	parked           *parkedMessages
+	bandwidth        *metrics.BandwidthCounter
+	latencyProbe     LatencyProbeConfig
+	latencyLock      *sync.RWMutex
+	latencies        map[peer.ID]PeerLatency
+	reputation       *Reputation
+	reputationStore  ReputationStore
+	peerStats        *peerStats
+	gossipEnabled    bool
+	allowedPeers     *allowlist
+	bootstrapLock    *sync.RWMutex
+	peerStoreConf    PeerStoreConfig
+	reconnectConf    ReconnectConfig
+	announceAddrs    []Multiaddr
+	natPortMap       bool
+	capabilityLock   *sync.RWMutex
+	capabilities     map[peer.ID]PeerCapability
+	capNotifiee      *network.NotifyBundle
+	events           *eventBus
+	tap              *debugTap
+	breaker          *circuitBreaker
+	streamConf       StreamHandlerConfig
+	streamPool       *streamPool
+	ceremonyStreams  bool
+	libp2pOpts       []libp2p.Option
+	peerRecordStore  PeerRecordStore
+	peerRecords      map[peer.ID]PeerRecord
+	gossipTopic      *pubsub.Topic
+}
+
+// Option is used to apply the optional settings to Communication
+type Option func(c *Communication)
+
+// WithRateLimit limits the inbound tss streams with the given configuration
+func WithRateLimit(cfg RateLimitConfig) Option {
+	return func(c *Communication) {
+		if !cfg.Enabled() {
+			return
+		}
+		c.rateLimiter = NewStreamRateLimiter(cfg)
+	}
+}
+
+// WithResourceLimits limits the connections, streams and memory the p2p host can use
+func WithResourceLimits(cfg ResourceLimitConfig) Option {
+	return func(c *Communication) {
+		c.resourceLimits = cfg
+	}
+}
+
+// WithAttestation exchanges the signed build attestations with the peers we connect to
+func WithAttestation(cfg AttestationConfig) Option {
+	return func(c *Communication) {
+		c.attestationConf = &cfg
+	}
+}
+
+// WithIdentitySigner sign with the identity key of the signer, like the key of an HSM, the key bytes passed to
+// Start are ignored then
+func WithIdentitySigner(signer conversion.Signer) Option {
+	return func(c *Communication) {
+		c.identitySigner = signer
+	}
+}
+
+// WithCompression compress the tss messages with the given codec for the peers that support it
+func WithCompression(cfg CompressionConfig) Option {
+	return func(c *Communication) {
+		c.compression = cfg
+	}
+}
+
+// WithMaxMessageSize set the maximum size of the tss message we send and accept, DefaultMaxMessageSize if it is
+// not positive
+func WithMaxMessageSize(size int) Option {
+	return func(c *Communication) {
+		if size > 0 {
+			c.maxMessageSize = size
+		}
+	}
+}
+
+// WithBootstrapRetry keeps retrying the bootstrap in the background instead of failing the startup
+// when none of the bootstrap peers is reachable
+func WithBootstrapRetry(cfg BootstrapRetryConfig) Option {
+	return func(c *Communication) {
+		c.bootstrapRetry = cfg
+	}
+}
+
+// WithColdStart dials the cached committee members directly on start, so we can sign before the DHT is ready
+func WithColdStart(cfg ColdStartConfig) Option {
+	return func(c *Communication) {
+		c.coldStart = cfg
+	}
+}
+
+// WithLatencyProbe ping the connected peers in the background with the given interval
+func WithLatencyProbe(cfg LatencyProbeConfig) Option {
+	return func(c *Communication) {
+		c.latencyProbe = cfg
+	}
+}
+
+// WithReputation scores the peers with the given config, the reputation is persisted to the store if it is not nil
+func WithReputation(cfg ReputationConfig, store ReputationStore) Option {
+	return func(c *Communication) {
+		c.reputation = NewReputation(cfg)
+		c.reputationStore = store
+	}
+}
+
+// WithPeerStore persists the peerstore to the store when we stop and reloads it when we start
+func WithPeerStore(cfg PeerStoreConfig, store PeerRecordStore) Option {
+	return func(c *Communication) {
+		c.peerStoreConf = cfg
+		c.peerRecordStore = store
+	}
+}
+
+// WithAnnounceAddrs announces the given addresses instead of the listen addresses, for the nodes behind a
+// load balancer, the external ip is announced as well if it is set
+func WithAnnounceAddrs(addrs []Multiaddr) Option {
+	return func(c *Communication) {
+		c.announceAddrs = addrs
+	}
+}
+
+// WithNATPortMap asks the router to map our listen port through UPnP or NAT-PMP, so the peers can dial us
+// without any manual port forwarding
+func WithNATPortMap(enabled bool) Option {
+	return func(c *Communication) {
+		c.natPortMap = enabled
+	}
+}
+
+// WithReconnect keeps us connected to the bootstrap peers and the cached committee members in the background
+func WithReconnect(cfg ReconnectConfig) Option {
+	return func(c *Communication) {
+		c.reconnectConf = cfg
+	}
+}
+
+// WithLibp2pOptions passes the given options to the libp2p host, so the embedders can bring their own
+// transports, muxers, security or peerstore, the host fails to start if an option conflicts with the ones we set
+func WithLibp2pOptions(opts ...libp2p.Option) Option {
+	return func(c *Communication) {
+		c.libp2pOpts = append(c.libp2pOpts, opts...)
+	}
+}
+
+// WithCeremonyStreams sends the tss messages of each ceremony over its own protocol to the peers that support
+// it, so the traffic of the ceremonies is isolated from each other
+func WithCeremonyStreams(enabled bool) Option {
+	return func(c *Communication) {
+		c.ceremonyStreams = enabled
+	}
+}
+
+// WithStreamHandler bounds the number of the inbound tss streams we read at the same time
+func WithStreamHandler(cfg StreamHandlerConfig) Option {
+	return func(c *Communication) {
+		c.streamConf = cfg
+	}
+}
+
+// WithCircuitBreaker fast fails the dials to the peers that keep failing until they are reachable again
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(c *Communication) {
+		c.breaker = newCircuitBreaker(cfg)
+	}
+}
+
+// WithAllowedPeers only connects to the given peers, no peer given allows all the peers
+func WithAllowedPeers(peers []peer.ID) Option {
+	return func(c *Communication) {
+		c.allowedPeers.set(peers)
+	}
+}
+
+// WithGossip joins the gossip topic, so the broadcast messages can be gossiped instead of sent over the
+// direct streams
+func WithGossip(enabled bool) Option {
+	return func(c *Communication) {
+		c.gossipEnabled = enabled
+	}
+}
+
+// WithDelivery set how many peers we send the message to at the same time and how long we wait for each peer
+func WithDelivery(cfg DeliveryConfig) Option {
+	return func(c *Communication) {
+		c.delivery = cfg
+	}
 }
 
 // NewCommunication create a new instance of Communication
-func NewCommunication(rendezvous string, bootstrapPeers []Multiaddr, port int, externalIP string) (*Communication, error) {
+func NewCommunication(rendezvous string, bootstrapPeers []Multiaddr, port int, externalIP string, opts ...Option) (*Communication, error) {
 	addr, err := maddr.NewMultiaddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port))
 	if err != nil {
 		return nil, fmt.Errorf("fail to create listen addr: %w", err)
@@ -75,7 +288,7 @@ func NewCommunication(rendezvous string, bootstrapPeers []Multiaddr, port int, e
 			return nil, fmt.Errorf("fail to create listen with given external IP: %w", err)
 		}
 	}
-	return &Communication{
+	c := &Communication{
 		rendezvous:       rendezvous,
 		bootstrapPeers:   bootstrapPeers,
 		logger:           log.With().Str("module", "communication").Logger(),
@@ -88,7 +301,50 @@ func NewCommunication(rendezvous string, bootstrapPeers []Multiaddr, port int, e
 		BroadcastMsgChan: make(chan *messages.BroadcastMsgChan, 1024),
 		externalAddr:     externalAddr,
 		streamMgr:        NewStreamMgr(),
-	}, nil
+		channelMonitor:   newChannelMonitor(),
+		compressionStats: &compressionCounter{},
+		maxMessageSize:   DefaultMaxMessageSize,
+		discovery:        newDiscoveryTracker(),
+		advertiseOnce:    &sync.Once{},
+		delivery:         DefaultDeliveryConfig(),
+		ceremonies:       newCeremonyContexts(),
+		parked:           newParkedMessages(),
+		bandwidth:        metrics.NewBandwidthCounter(),
+		latencyLock:      &sync.RWMutex{},
+		latencies:        make(map[peer.ID]PeerLatency),
+		reputation:       NewReputation(DefaultReputationConfig()),
+		peerStats:        newPeerStats(),
+		capabilityLock:   &sync.RWMutex{},
+		capabilities:     make(map[peer.ID]PeerCapability),
+		events:           newEventBus(),
+		tap:              newDebugTap(),
+		breaker:          newCircuitBreaker(DefaultCircuitBreakerConfig()),
+		streamConf:       DefaultStreamHandlerConfig(),
+		allowedPeers:     &allowlist{},
+		bootstrapLock:    &sync.RWMutex{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.reputation.onBan = func(pID peer.ID) {
+		c.emitPeerEvent(PeerBanned, pID, "", errors.New("reputation below the ban threshold"))
+	}
+	if err := c.delivery.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.reputation.cfg.Quarantine.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.compression.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.streamConf.Validate(); err != nil {
+		return nil, err
+	}
+	if c.streamConf.Enabled() {
+		c.streamPool = newStreamPool(c.streamConf)
+	}
+	return c, nil
 }
 
 // GetHost return the host
@@ -101,8 +357,17 @@ func (c *Communication) GetLocalPeerID() string {
 	return c.host.ID().String()
 }
 
+// GetBroadcastChannel return the channel the ceremonies submit their messages to
+func (c *Communication) GetBroadcastChannel() chan *messages.BroadcastMsgChan {
+	return c.BroadcastMsgChan
+}
+
 // Broadcast message to Peers
 func (c *Communication) Broadcast(peers []peer.ID, msg []byte, msgID string) {
+	c.broadcast(peers, newRawMessage(msg), msgID)
+}
+
+func (c *Communication) broadcast(peers []peer.ID, msg *encodedMessage, msgID string) {
 	if len(peers) == 0 {
 		return
 	}
@@ -111,43 +376,87 @@ func (c *Communication) Broadcast(peers []peer.ID, msg []byte, msgID string) {
 	go c.broadcastToPeers(peers, msg, msgID)
 }
 
-func (c *Communication) broadcastToPeers(peers []peer.ID, msg []byte, msgID string) {
+func (c *Communication) broadcastToPeers(peers []peer.ID, msg *encodedMessage, msgID string) {
 	defer c.wg.Done()
 	defer func() {
 		c.logger.Debug().Msgf("finished sending message to peer(%v)", peers)
 	}()
-	var wgSend sync.WaitGroup
-	wgSend.Add(len(peers))
-	for _, p := range peers {
-		go func(p peer.ID) {
-			defer wgSend.Done()
-			if err := c.writeToStream(p, msg, msgID); nil != err {
-				c.logger.Error().Err(err).Msg("fail to write to stream")
-			}
-		}(p)
+	report := c.deliver(peers, msg, msgID)
+	for _, el := range report.Peers {
+		if el.Err != nil {
+			c.logger.Error().Err(el.Err).Msgf("fail to write to stream of peer(%s)", el.PeerID)
+		}
 	}
-	wgSend.Wait()
 }
 
-func (c *Communication) writeToStream(pID peer.ID, msg []byte, msgID string) error {
+func (c *Communication) writeToStream(ctx context.Context, pID peer.ID, msg *encodedMessage, msgID string) error {
 	// don't send to ourselves
 	if pID == c.host.ID() {
 		return nil
 	}
-	stream, err := c.connectToOnePeer(pID)
+	start := time.Now()
+	stream, err := c.connectToOnePeer(ctx, pID, msgID)
 	if err != nil {
+		c.peerStats.outbound(pID, noProtocol, 0, 0, true)
+		c.emitPeerEvent(PeerStreamFailure, pID, noProtocol, err)
 		return fmt.Errorf("fail to open stream to peer(%s): %w", pID, err)
 	}
 	if nil == stream {
 		return nil
 	}
+	// the stream deadlines do not cover the whole delivery, so we reset the stream once we run out of time,
+	// the finished flag makes sure we never reset the stream once the message is written
+	var finished int32
+	done := make(chan struct{})
+	defer func() {
+		atomic.StoreInt32(&finished, 1)
+		close(done)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			if atomic.LoadInt32(&finished) == 1 {
+				return
+			}
+			if err := stream.Reset(); err != nil {
+				c.logger.Error().Err(err).Msg("fail to reset the stream")
+			}
+		case <-done:
+		}
+	}()
 
 	defer func() {
 		c.streamMgr.AddStream(msgID, stream)
 	}()
 	c.logger.Debug().Msgf(">>>writing messages to peer(%s)", pID)
 
-	return WriteStreamWithBuffer(msg, stream)
+	protocolID := stream.Protocol()
+	buf, err := msg.forProtocol(protocolID)
+	if err != nil {
+		c.peerStats.outbound(pID, protocolID, 0, 0, true)
+		return fmt.Errorf("fail to marshal the message: %w", err)
+	}
+	rawLen := len(buf)
+	if codec := codecForProtocol(protocolID); codec != CompressionNone {
+		buf, err = msg.compress(codec, c.compression.Threshold)
+		if err != nil {
+			c.peerStats.outbound(pID, protocolID, 0, 0, true)
+			return fmt.Errorf("fail to compress the message: %w", err)
+		}
+	}
+	c.compressionStats.sent(rawLen, len(buf))
+	err = WriteStreamWithLimit(buf, stream, c.maxMessageSize)
+	c.peerStats.outbound(pID, protocolID, len(buf), time.Since(start), err != nil)
+	if err != nil {
+		c.emitPeerEvent(PeerStreamFailure, pID, protocolID, err)
+		return err
+	}
+	// the stream carries a single message, closing our side lets the peer free the stream once it has read it,
+	// rather than holding it until the ceremony ends
+	if err := stream.CloseWrite(); err != nil {
+		c.logger.Debug().Err(err).Msgf("fail to close the stream to peer(%s) for writing", pID)
+	}
+	return nil
 }
 
 func (c *Communication) readFromStream(stream network.Stream) {
@@ -158,50 +467,124 @@ func (c *Communication) readFromStream(stream network.Stream) {
 	case <-c.stopChan:
 		return
 	default:
-		dataBuf, err := ReadStreamWithBuffer(stream)
+		start := time.Now()
+		remotePeer := stream.Conn().RemotePeer()
+		protocolID := stream.Protocol()
+		dataBuf, err := ReadStreamWithLimit(stream, c.maxMessageSize)
 		if err != nil {
+			c.peerStats.inbound(remotePeer, protocolID, 0, 0, true)
+			c.emitPeerEvent(PeerStreamFailure, remotePeer, protocolID, err)
 			c.logger.Error().Err(err).Msgf("fail to read from stream,peerID: %s", peerID)
 			c.streamMgr.AddStream("UNKNOWN", stream)
 			return
 		}
-		var wrappedMsg messages.WrappedMessage
-		if err := json.Unmarshal(dataBuf, &wrappedMsg); nil != err {
+		wireLen := len(dataBuf)
+		if codec := codecForProtocol(stream.Protocol()); codec != CompressionNone {
+			frame := dataBuf
+			dataBuf, err = decompressFrame(codec, frame, c.maxMessageSize)
+			if err != nil {
+				releasePayloadBuffer(frame)
+				c.peerStats.inbound(remotePeer, protocolID, 0, 0, true)
+				c.reputation.Record(remotePeer, EventMalformedMessage)
+				c.logger.Error().Err(err).Msgf("fail to decompress the message from peer: %s", peerID)
+				c.streamMgr.AddStream("UNKNOWN", stream)
+				return
+			}
+			// the decompressed message is a copy, so the frame can be recycled
+			if frame[0] == frameCompressed {
+				releasePayloadBuffer(frame)
+			}
+		}
+		c.compressionStats.received(len(dataBuf), wireLen)
+		wrappedMsg, err := messages.UnmarshalWrappedMessage(dataBuf)
+		if err != nil {
+			releasePayloadBuffer(dataBuf)
+			c.peerStats.inbound(remotePeer, protocolID, 0, 0, true)
+			c.reputation.Record(remotePeer, EventMalformedMessage)
 			c.logger.Error().Err(err).Msg("fail to unmarshal wrapped message bytes")
 			c.streamMgr.AddStream("UNKNOWN", stream)
 			return
 		}
+		// the stream of a ceremony only carries the messages of that ceremony
+		if msgID, _, ok := parseCeremonyProtocol(protocolID); ok && msgID != wrappedMsg.MsgID {
+			releasePayloadBuffer(dataBuf)
+			c.peerStats.inbound(remotePeer, protocolID, 0, 0, true)
+			c.reputation.Record(remotePeer, EventMalformedMessage)
+			c.logger.Error().Msgf("peer %s sends a message of ceremony %s over the protocol of ceremony %s", peerID, wrappedMsg.MsgID, msgID)
+			c.streamMgr.AddStream("UNKNOWN", stream)
+			return
+		}
 		c.logger.Debug().Msgf(">>>>>>>[%s] %s", wrappedMsg.MessageType, string(wrappedMsg.Payload))
+		// we have read the whole message, so the stream is freed on both sides without waiting for the ceremony
+		// to release it, the concurrent ceremonies would run out of the streams otherwise
+		if err := stream.Close(); err != nil {
+			c.logger.Debug().Err(err).Msgf("fail to close the stream of peer: %s", peerID)
+		}
 		c.streamMgr.AddStream(wrappedMsg.MsgID, stream)
 		channel := c.getSubscriber(wrappedMsg.MessageType, wrappedMsg.MsgID)
+		c.tap.mirror(remotePeer, protocolID, wrappedMsg, channel != nil)
 		if nil == channel {
 			c.logger.Debug().Msgf("no MsgID %s found for this message", wrappedMsg.MsgID)
 			c.logger.Debug().Msgf("no MsgID %s found for this message", wrappedMsg.MessageType)
+			// nobody holds the buffer, the unmarshalled message does not share it either
+			releasePayloadBuffer(dataBuf)
+			c.peerStats.inbound(remotePeer, protocolID, wireLen, time.Since(start), false)
 			return
 		}
-		channel <- &Message{
-			PeerID:  stream.Conn().RemotePeer(),
+		// we do not count the time we wait for our own subscriber against the peer
+		c.peerStats.inbound(remotePeer, protocolID, wireLen, time.Since(start), false)
+		c.channelMonitor.observe(wrappedMsg.MessageType, len(channel), cap(channel))
+		msg := &Message{
+			PeerID:  remotePeer,
 			Payload: dataBuf,
 		}
-
+		select {
+		case channel <- msg:
+			return
+		default:
+		}
+		// the ceremony is busy, we wait for it without holding the stream worker, so a slow ceremony does not
+		// hold up the messages of the other ceremonies
+		if !c.parkMessage(wrappedMsg.MessageType, wrappedMsg.MsgID, channel, msg) {
+			c.logger.Warn().Msgf("ceremony %s has too many messages waiting, drop the message of peer %s", wrappedMsg.MsgID, peerID)
+		}
 	}
 }
 
 func (c *Communication) handleStream(stream network.Stream) {
 	peerID := stream.Conn().RemotePeer().String()
 	c.logger.Debug().Msgf("handle stream from peer: %s", peerID)
+	if c.rateLimiter != nil {
+		allowed, banned := c.rateLimiter.Allow(stream.Conn().RemotePeer())
+		if banned {
+			c.logger.Warn().Msgf("peer %s exceeds the stream rate limit repeatedly, ban it temporarily", peerID)
+			c.emitPeerEvent(PeerBanned, stream.Conn().RemotePeer(), stream.Protocol(), errors.New("stream rate limit exceeded"))
+			if err := c.host.Network().ClosePeer(stream.Conn().RemotePeer()); err != nil {
+				c.logger.Error().Err(err).Msgf("fail to close the connection to peer %s", peerID)
+			}
+		}
+		if !allowed {
+			c.logger.Debug().Msgf("reject the stream from peer %s due to rate limit", peerID)
+			if err := stream.Reset(); err != nil {
+				c.logger.Error().Err(err).Msg("fail to reset the stream")
+			}
+			return
+		}
+	}
 	// we will read from that stream
-	c.readFromStream(stream)
+	c.dispatchStream(stream)
 }
 
 func (c *Communication) bootStrapConnectivityCheck() error {
-	if len(c.bootstrapPeers) == 0 {
+	bootstrapPeers := c.getBootstrapPeers()
+	if len(bootstrapPeers) == 0 {
 		c.logger.Error().Msg("we do not have the bootstrap node set, quit the connectivity check")
 		return nil
 	}
 
 	var onlineNodes uint32
 	var wg sync.WaitGroup
-	for _, el := range c.bootstrapPeers {
+	for _, el := range bootstrapPeers {
 		peer, err := peer.AddrInfoFromP2pAddr(el)
 		if err != nil {
 			c.logger.Error().Err(err).Msg("error in decode the bootstrap node, skip it")
@@ -239,31 +622,96 @@ func (c *Communication) bootStrapConnectivityCheck() error {
 
 func (c *Communication) startChannel(privKeyBytes []byte) error {
 	ctx := context.Background()
-	p2pPriKey, err := crypto.UnmarshalSecp256k1PrivateKey(privKeyBytes)
+	var p2pPriKey crypto.PrivKey
+	var err error
+	if c.identitySigner != nil {
+		p2pPriKey, err = conversion.NewSignerIdentityKey(c.identitySigner)
+	} else {
+		p2pPriKey, err = unmarshalIdentityKey(privKeyBytes)
+	}
 	if err != nil {
 		c.logger.Error().Msgf("error is %f", err)
 		return err
 	}
 
 	addressFactory := func(addrs []Multiaddr) []Multiaddr {
+		if len(c.announceAddrs) > 0 {
+			announced := make([]Multiaddr, 0, len(c.announceAddrs)+1)
+			announced = append(announced, c.announceAddrs...)
+			if c.externalAddr != nil {
+				announced = append(announced, c.externalAddr)
+			}
+			return announced
+		}
 		if c.externalAddr != nil {
 			return []Multiaddr{c.externalAddr}
 		}
 		return addrs
 	}
 
-
-	h, err := libp2p.New(
+	hostOpts := []libp2p.Option{
 		libp2p.ListenAddrs([]Multiaddr{c.listenAddr}...),
 		libp2p.Identity(p2pPriKey),
 		libp2p.AddrsFactory(addressFactory),
-	)
+		libp2p.BandwidthReporter(c.bandwidth),
+	}
+	// the gater is always installed, so the peers the operator bans are refused even without the reputation gate
+	hostOpts = append(hostOpts, libp2p.ConnectionGater(&peerGater{
+		allowed:     c.allowedPeers,
+		reputation:  c.reputation,
+		rateLimiter: c.rateLimiter,
+	}))
+	if c.identitySigner != nil {
+		// the quic transport derives its stateless reset key from the raw identity key, which the signer keeps
+		hostOpts = append(hostOpts, libp2p.Transport(tcp.NewTCPTransport))
+	}
+	if c.natPortMap {
+		hostOpts = append(hostOpts, libp2p.NATPortMap())
+	}
+	if c.resourceLimits.Enabled() {
+		rm, err := newResourceManager(c.resourceLimits)
+		if err != nil {
+			return err
+		}
+		hostOpts = append(hostOpts, libp2p.ResourceManager(rm))
+	}
+	hostOpts = append(hostOpts, c.libp2pOpts...)
+
+	h, err := libp2p.New(hostOpts...)
 	if err != nil {
 		return fmt.Errorf("fail to create p2p host: %w", err)
 	}
 	c.host = h
 	c.logger.Info().Msgf("Host created, we are: %s, at: %s", h.ID(), h.Addrs())
+	h.Network().Notify(c.connectionNotifiee())
+	if c.peerRecordStore != nil {
+		c.loadPeerStore()
+	}
 	h.SetStreamHandler(TSSProtocolID, c.handleStream)
+	h.SetStreamHandler(TSSProtoProtocolID, c.handleStream)
+	// we always accept the compressed streams, no matter which codec we compress with
+	for _, codec := range supportedCodecs {
+		h.SetStreamHandler(compressedProtocolID(codec), c.handleStream)
+	}
+	if c.ceremonyStreams {
+		h.SetStreamHandlerMatch(ceremonyStatsProtocol, isCeremonyProtocol, c.handleStream)
+	}
+	c.startCapabilityExchange()
+	if c.attestationConf != nil {
+		c.attestation, err = NewAttestationService(h, *c.attestationConf)
+		if err != nil {
+			return fmt.Errorf("fail to create attestation service: %w", err)
+		}
+	}
+	if c.gossipEnabled {
+		if err := c.startGossip(ctx, h); err != nil {
+			return err
+		}
+	}
+	if c.coldStart.Enabled() {
+		c.wg.Add(1)
+		go c.dialCachedPeers()
+	}
 	// Start a DHT, for use in peer discovery. We can't just make a new DHT
 	// client because we want each peer to maintain its own local copy of the
 	// DHT, so that the bootstrapping node of the DHT can go down without
@@ -278,57 +726,85 @@ func (c *Communication) startChannel(privKeyBytes []byte) error {
 	}
 
 	var connectionErr error
-	for i := 0; i < 5; i++ {
+	// the background retry takes over the retries if it is enabled, so we do not block the startup
+	retries := 5
+	if c.bootstrapRetry.Enabled() {
+		retries = 1
+	}
+	for i := 0; i < retries; i++ {
 		connectionErr = c.connectToBootstrapPeers()
 		if connectionErr == nil {
 			break
 		}
-		c.logger.Error().Msg("cannot connect to any bootstrap node, retry in 5 seconds")
-		time.Sleep(time.Second * 5)
+		if i < retries-1 {
+			c.logger.Error().Msg("cannot connect to any bootstrap node, retry in 5 seconds")
+			time.Sleep(time.Second * 5)
+		}
 	}
-	if connectionErr != nil {
-		return fmt.Errorf("fail to connect to bootstrap peer: %w", connectionErr)
+	if connectionErr == nil {
+		connectionErr = c.bootstrap(ctx, kademliaDHT)
+	} else {
+		connectionErr = fmt.Errorf("fail to connect to bootstrap peer: %w", connectionErr)
 	}
-
-	// We use a rendezvous point "meet me here" to announce our location.
-	// This is like telling your friends to meet you at the Eiffel Tower.
-	routingDiscovery := routing.NewRoutingDiscovery(kademliaDHT)
-	discoveryutil.Advertise(ctx, routingDiscovery, c.rendezvous)
-	err = c.bootStrapConnectivityCheck()
-	if err != nil {
-		return err
+	if connectionErr != nil {
+		if !c.bootstrapRetry.Enabled() {
+			return connectionErr
+		}
+		c.logger.Warn().Err(connectionErr).Msg("fail to bootstrap, keep retrying in the background")
+		c.discovery.update(DiscoveryDegraded, 0, connectionErr)
+		c.wg.Add(1)
+		go c.retryBootstrap(ctx, kademliaDHT)
+		return nil
 	}
+	c.discovery.update(DiscoveryReady, 0, nil)
 
 	c.logger.Info().Msg("Successfully announced!")
 	return nil
 }
 
-func (c *Communication) connectToOnePeer(pID peer.ID) (network.Stream, error) {
+func (c *Communication) connectToOnePeer(ctx context.Context, pID peer.ID, msgID string) (network.Stream, error) {
 	c.logger.Debug().Msgf("peer:%s,current:%s", pID, c.host.ID())
 	// dont connect to itself
 	if pID == c.host.ID() {
 		return nil, nil
 	}
 	c.logger.Debug().Msgf("connect to peer : %s", pID.String())
-	ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
+	protocols, err := c.tssProtocols(pID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	// we do not wait for the connecting timeout of a peer that has been down for a while, the circuit is
+	// closed again by the background probe
+	if !c.breaker.allow(pID) {
+		return nil, fmt.Errorf("peer %s: %w", pID, ErrCircuitOpen)
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, TimeoutConnecting)
 	defer cancel()
-	stream, err := c.host.NewStream(ctx, pID, TSSProtocolID)
+	stream, err := c.host.NewStream(dialCtx, pID, protocols...)
 	if err != nil {
+		c.reputation.Record(pID, EventDialFailure)
+		// the dial is not the fault of the peer if the caller gave up on it, and a peer we are connected to is
+		// reachable, it is only slow to accept the stream
+		if ctx.Err() == nil && c.host.Network().Connectedness(pID) != network.Connected {
+			c.dialFailed(pID)
+		}
 		return nil, fmt.Errorf("fail to create new stream to peer: %s, %w", pID, err)
 	}
+	c.dialSucceeded(pID)
 	return stream, nil
 }
 
 func (c *Communication) connectToBootstrapPeers() error {
 	// Let's connect to the bootstrap nodes first. They will tell us about the
 	// other nodes in the network.
-	if len(c.bootstrapPeers) == 0 {
+	bootstrapPeers := c.getBootstrapPeers()
+	if len(bootstrapPeers) == 0 {
 		c.logger.Info().Msg("no bootstrap node set, we skip the connection")
 		return nil
 	}
 	var wg sync.WaitGroup
-	connRet := make(chan bool, len(c.bootstrapPeers))
-	for _, peerAddr := range c.bootstrapPeers {
+	connRet := make(chan bool, len(bootstrapPeers))
+	for _, peerAddr := range bootstrapPeers {
 		pi, err := peer.AddrInfoFromP2pAddr(peerAddr)
 		if err != nil {
 			return fmt.Errorf("fail to add peer: %w", err)
@@ -348,7 +824,7 @@ func (c *Communication) connectToBootstrapPeers() error {
 		}(connRet)
 	}
 	wg.Wait()
-	for i := 0; i < len(c.bootstrapPeers); i++ {
+	for i := 0; i < len(bootstrapPeers); i++ {
 		if <-connRet {
 			return nil
 		}
@@ -358,10 +834,34 @@ func (c *Communication) connectToBootstrapPeers() error {
 
 // Start will start the communication
 func (c *Communication) Start(priKeyBytes []byte) error {
+	if c.reputationStore != nil {
+		if err := c.reputation.load(c.reputationStore); err != nil {
+			c.logger.Warn().Err(err).Msg("fail to load the reputation of the peers, start from scratch")
+		}
+	}
 	err := c.startChannel(priKeyBytes)
 	if err == nil {
 		c.wg.Add(1)
 		go c.ProcessBroadcast()
+		if c.streamPool != nil {
+			c.startStreamWorkers()
+		}
+		if c.reputationStore != nil {
+			c.wg.Add(1)
+			go c.persistReputation()
+		}
+		if c.latencyProbe.Enabled() {
+			c.wg.Add(1)
+			go c.probeLatency()
+		}
+		if c.reconnectConf.Enabled() {
+			c.wg.Add(1)
+			go c.maintainConnections()
+		}
+		if c.breaker.cfg.Enabled() {
+			c.wg.Add(1)
+			go c.probeOpenCircuits()
+		}
 	}
 	return err
 }
@@ -369,12 +869,25 @@ func (c *Communication) Start(priKeyBytes []byte) error {
 // Stop communication
 func (c *Communication) Stop() error {
 	// we need to stop the handler and the p2p services firstly, then terminate the our communication threads
+	if c.attestation != nil {
+		c.attestation.Stop()
+	}
+	c.stopCapabilityExchange()
+	// the peerstore is closed with the host
+	if c.peerRecordStore != nil {
+		c.savePeerStore()
+	}
 	if err := c.host.Close(); err != nil {
 		c.logger.Err(err).Msg("fail to close host network")
 	}
 
 	close(c.stopChan)
 	c.wg.Wait()
+	if c.streamPool != nil {
+		c.drainStreamQueue()
+	}
+	c.events.close()
+	c.tap.close()
 	return nil
 }
 
@@ -426,13 +939,13 @@ func (c *Communication) ProcessBroadcast() {
 	for {
 		select {
 		case msg := <-c.BroadcastMsgChan:
-			wrappedMsgBytes, err := json.Marshal(msg.WrappedMessage)
-			if err != nil {
-				c.logger.Error().Err(err).Msg("fail to marshal a wrapped message to json bytes")
+			encoded := newBroadcastMessage(msg)
+			c.logger.Debug().Msgf("broadcast message %s to %+v", msg.WrappedMessage, msg.PeersID)
+			if msg.Gossip && c.gossipTopic != nil {
+				c.gossip(msg.PeersID, encoded, msg.WrappedMessage.MsgID)
 				continue
 			}
-			c.logger.Debug().Msgf("broadcast message %s to %+v", msg.WrappedMessage, msg.PeersID)
-			c.Broadcast(msg.PeersID, wrappedMsgBytes, msg.WrappedMessage.MsgID)
+			c.broadcast(msg.PeersID, encoded, msg.WrappedMessage.MsgID)
 
 		case <-c.stopChan:
 			return
@@ -442,4 +955,41 @@ func (c *Communication) ProcessBroadcast() {
 
 func (c *Communication) ReleaseStream(msgID string) {
 	c.streamMgr.ReleaseStream(msgID)
+	if c.ceremonyStreams {
+		c.resetCeremonyStreams(msgID)
+		if stats, ok := c.peerStats.ceremony(msgID); ok {
+			c.logger.Debug().Msgf("ceremony %s: %d messages in, %d messages out, %d bytes in, %d bytes out, %d failures", msgID, stats.MessagesIn, stats.MessagesOut, stats.BytesIn, stats.BytesOut, stats.Failures)
+		}
+	}
+	c.peerStats.releaseCeremony(msgID)
+}
+
+// GetAttestationService return the build attestation service, nil if the attestation is not enabled
+func (c *Communication) GetAttestationService() *AttestationService {
+	return c.attestation
+}
+
+// GetChannelOccupancy return the latest occupancy ratio of the subscriber channels keyed by message type
+func (c *Communication) GetChannelOccupancy() map[string]float64 {
+	return c.channelMonitor.occupancy()
+}
+
+// GetCompressionStats return the number of bytes of the tss messages we have sent and received, before and after the compression
+func (c *Communication) GetCompressionStats() CompressionStats {
+	return c.compressionStats.stats()
+}
+
+// GetDiscoveryState return the latest discovery state, the node is only ready once it has joined the p2p network
+func (c *Communication) GetDiscoveryState() DiscoveryEvent {
+	return c.discovery.state()
+}
+
+// DiscoveryEvents return the channel of the discovery events, the events are dropped if nobody consumes them
+func (c *Communication) DiscoveryEvents() <-chan DiscoveryEvent {
+	return c.discovery.events
+}
+
+// GetRateLimiter return the inbound stream rate limiter, nil if the rate limit is not enabled
+func (c *Communication) GetRateLimiter() *StreamRateLimiter {
+	return c.rateLimiter
 }
@@ -4,12 +4,15 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 
+	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
 	maddr "github.com/multiformats/go-multiaddr"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
 	. "gopkg.in/check.v1"
 
+	"github.com/akildemir/go-tss/conversion"
 	"github.com/akildemir/go-tss/messages"
 )
 
@@ -89,3 +92,68 @@ func (CommunicationTestSuite) TestEstablishP2pCommunication(c *C) {
 	ps = comm4.host.Peerstore()
 	c.Assert(checkExist(ps.Addrs(comm.host.ID()), fakeExternalMultiAddr), Equals, true)
 }
+
+func (CommunicationTestSuite) TestAnnounceAddrs(c *C) {
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	lbAddr, err := maddr.NewMultiaddr("/dns4/tss.example.com/tcp/6668")
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2340, "11.22.33.44",
+		WithAnnounceAddrs([]maddr.Multiaddr{lbAddr}),
+		WithNATPortMap(true))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	// we only announce the given addresses and the external ip
+	c.Assert(comm.host.Addrs(), HasLen, 2)
+	c.Assert(checkExist(comm.host.Addrs(), "/dns4/tss.example.com/tcp/6668"), Equals, true)
+	c.Assert(checkExist(comm.host.Addrs(), "/ip4/11.22.33.44/tcp/2340"), Equals, true)
+}
+
+func (CommunicationTestSuite) TestLibp2pOptions(c *C) {
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2410, "", WithLibp2pOptions(libp2p.Ping(false)))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	for _, el := range comm.host.Mux().Protocols() {
+		c.Assert(el, Not(Equals), ping.ID)
+	}
+
+	// the options cannot override the ones we set
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", nil, 2411, "", WithLibp2pOptions(libp2p.Identity(sk)))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(privKey), NotNil)
+}
+
+func (CommunicationTestSuite) TestIdentitySigner(c *C) {
+	sk, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	skRaw, err := sk.Raw()
+	c.Assert(err, IsNil)
+	signer, err := conversion.NewKeySigner(skRaw)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2450, "", WithIdentitySigner(signer))
+	c.Assert(err, IsNil)
+	// the key bytes are ignored, the host signs through the signer
+	c.Assert(comm.Start(nil), IsNil)
+	defer comm.Stop()
+	id, err := peer.IDFromPrivateKey(sk)
+	c.Assert(err, IsNil)
+	c.Assert(comm.host.ID(), Equals, id)
+
+	// the peers can connect to the host, the handshake is signed by the signer
+	bootstrapAddr, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/2450/p2p/" + id.String())
+	c.Assert(err, IsNil)
+	sk2, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	sk2Raw, err := sk2.Raw()
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{bootstrapAddr}, 2451, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk2Raw), IsNil)
+	defer comm2.Stop()
+}
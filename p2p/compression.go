@@ -0,0 +1,199 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// CompressionCodec is the codec we use to compress the tss messages
+type CompressionCodec string
+
+const (
+	// CompressionNone disables the compression of the messages we send
+	CompressionNone CompressionCodec = ""
+	// CompressionSnappy compress the messages with snappy, it is fast with a moderate ratio
+	CompressionSnappy CompressionCodec = "snappy"
+	// CompressionZstd compress the messages with zstd, it has a better ratio at a higher cpu cost
+	CompressionZstd CompressionCodec = "zstd"
+
+	// DefaultCompressionThreshold is the size in bytes below which we do not bother to compress the message
+	DefaultCompressionThreshold = 1024
+)
+
+const (
+	frameRaw        byte = 0
+	frameCompressed byte = 1
+)
+
+// supportedCodecs are the codecs we can always decode, no matter which one we compress with
+var supportedCodecs = []CompressionCodec{CompressionZstd, CompressionSnappy}
+
+// CompressionConfig defines how we compress the messages we send over the tss streams
+type CompressionConfig struct {
+	// Codec is the codec we prefer, the peers that do not support it get the uncompressed messages
+	Codec CompressionCodec
+	// Threshold is the size in bytes from which we compress the message, the smaller messages are sent as they are
+	Threshold int
+}
+
+// Validate check the codec is supported
+func (cfg CompressionConfig) Validate() error {
+	if cfg.Codec == CompressionNone {
+		return nil
+	}
+	for _, el := range supportedCodecs {
+		if el == cfg.Codec {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported compression codec: %s", cfg.Codec)
+}
+
+// compressedProtocolID return the protocol id of the tss stream compressed with the given codec
+func compressedProtocolID(codec CompressionCodec) protocol.ID {
+	return TSSProtoProtocolID + protocol.ID("/"+string(codec))
+}
+
+// codecForProtocol return the codec negotiated with the given protocol id
+func codecForProtocol(protocolID protocol.ID) CompressionCodec {
+	if _, codec, ok := parseCeremonyProtocol(protocolID); ok {
+		return codec
+	}
+	for _, el := range supportedCodecs {
+		if compressedProtocolID(el) == protocolID {
+			return el
+		}
+	}
+	return CompressionNone
+}
+
+var (
+	zstdOnce     = &sync.Once{}
+	zstdEncoder  *zstd.Encoder
+	zstdErr      error
+	zstdLock     = &sync.Mutex{}
+	zstdDecoders = make(map[int]*zstd.Decoder)
+)
+
+// getZstdEncoder return the shared zstd encoder, it is safe for concurrent use
+func getZstdEncoder() (*zstd.Encoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	})
+	return zstdEncoder, zstdErr
+}
+
+// getZstdDecoder return the shared zstd decoder that does not decode more than the max message size, it is safe
+// for concurrent use
+func getZstdDecoder(maxMessageSize int) (*zstd.Decoder, error) {
+	zstdLock.Lock()
+	defer zstdLock.Unlock()
+	if decoder, ok := zstdDecoders[maxMessageSize]; ok {
+		return decoder, nil
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxMessageSize)))
+	if err != nil {
+		return nil, err
+	}
+	zstdDecoders[maxMessageSize] = decoder
+	return decoder, nil
+}
+
+// compressFrame compress the buf with the given codec if it is not smaller than the threshold, the first byte of
+// the frame tells the receiver whether the rest is compressed
+func compressFrame(codec CompressionCodec, threshold int, buf []byte) ([]byte, error) {
+	if len(buf) < threshold {
+		return append([]byte{frameRaw}, buf...), nil
+	}
+	var compressed []byte
+	switch codec {
+	case CompressionSnappy:
+		compressed = snappy.Encode(nil, buf)
+	case CompressionZstd:
+		encoder, err := getZstdEncoder()
+		if err != nil {
+			return nil, fmt.Errorf("fail to create the zstd encoder: %w", err)
+		}
+		compressed = encoder.EncodeAll(buf, make([]byte, 0, len(buf)/2))
+	default:
+		return nil, fmt.Errorf("unsupported compression codec: %s", codec)
+	}
+	// it does not worth it if the compression does not save anything
+	if len(compressed) >= len(buf) {
+		return append([]byte{frameRaw}, buf...), nil
+	}
+	return append([]byte{frameCompressed}, compressed...), nil
+}
+
+// decompressFrame restore the message from the frame we received, the message can not exceed maxMessageSize
+func decompressFrame(codec CompressionCodec, frame []byte, maxMessageSize int) ([]byte, error) {
+	if len(frame) == 0 {
+		return nil, errors.New("empty frame")
+	}
+	switch frame[0] {
+	case frameRaw:
+		return frame[1:], nil
+	case frameCompressed:
+	default:
+		return nil, fmt.Errorf("unknown frame type: %d", frame[0])
+	}
+	switch codec {
+	case CompressionSnappy:
+		length, err := snappy.DecodedLen(frame[1:])
+		if err != nil {
+			return nil, fmt.Errorf("fail to get the snappy decoded length: %w", err)
+		}
+		if length > maxMessageSize {
+			return nil, fmt.Errorf("message length:%d exceed max message length:%d", length, maxMessageSize)
+		}
+		return snappy.Decode(nil, frame[1:])
+	case CompressionZstd:
+		decoder, err := getZstdDecoder(maxMessageSize)
+		if err != nil {
+			return nil, fmt.Errorf("fail to create the zstd decoder: %w", err)
+		}
+		return decoder.DecodeAll(frame[1:], nil)
+	default:
+		return nil, fmt.Errorf("unsupported compression codec: %s", codec)
+	}
+}
+
+// CompressionStats is the number of bytes of the tss messages before(raw) and after(wire) the compression
+type CompressionStats struct {
+	SentRaw      int64 `json:"sent_raw"`
+	SentWire     int64 `json:"sent_wire"`
+	ReceivedRaw  int64 `json:"received_raw"`
+	ReceivedWire int64 `json:"received_wire"`
+}
+
+type compressionCounter struct {
+	sentRaw      int64
+	sentWire     int64
+	receivedRaw  int64
+	receivedWire int64
+}
+
+func (cc *compressionCounter) sent(raw, wire int) {
+	atomic.AddInt64(&cc.sentRaw, int64(raw))
+	atomic.AddInt64(&cc.sentWire, int64(wire))
+}
+
+func (cc *compressionCounter) received(raw, wire int) {
+	atomic.AddInt64(&cc.receivedRaw, int64(raw))
+	atomic.AddInt64(&cc.receivedWire, int64(wire))
+}
+
+func (cc *compressionCounter) stats() CompressionStats {
+	return CompressionStats{
+		SentRaw:      atomic.LoadInt64(&cc.sentRaw),
+		SentWire:     atomic.LoadInt64(&cc.sentWire),
+		ReceivedRaw:  atomic.LoadInt64(&cc.receivedRaw),
+		ReceivedWire: atomic.LoadInt64(&cc.receivedWire),
+	}
+}
@@ -0,0 +1,130 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type CompressionTestSuite struct{}
+
+var _ = Suite(&CompressionTestSuite{})
+
+func (CompressionTestSuite) TestConfig(c *C) {
+	c.Assert(CompressionConfig{}.Validate(), IsNil)
+	c.Assert(CompressionConfig{Codec: CompressionSnappy}.Validate(), IsNil)
+	c.Assert(CompressionConfig{Codec: CompressionZstd}.Validate(), IsNil)
+	c.Assert(CompressionConfig{Codec: "gzip"}.Validate(), NotNil)
+	_, err := NewCommunication("rendezvous", nil, 6668, "", WithCompression(CompressionConfig{Codec: "gzip"}))
+	c.Assert(err, NotNil)
+
+	c.Assert(codecForProtocol(TSSProtocolID), Equals, CompressionNone)
+	c.Assert(codecForProtocol(TSSProtoProtocolID), Equals, CompressionNone)
+	c.Assert(codecForProtocol(compressedProtocolID(CompressionZstd)), Equals, CompressionZstd)
+	c.Assert(codecForProtocol(compressedProtocolID(CompressionSnappy)), Equals, CompressionSnappy)
+}
+
+func (CompressionTestSuite) TestFrame(c *C) {
+	large := bytes.Repeat([]byte("tss keygen message "), 1024)
+	small := []byte("hello")
+	for _, codec := range supportedCodecs {
+		frame, err := compressFrame(codec, DefaultCompressionThreshold, large)
+		c.Assert(err, IsNil)
+		c.Assert(frame[0], Equals, frameCompressed)
+		c.Assert(len(frame) < len(large), Equals, true)
+		buf, err := decompressFrame(codec, frame, DefaultMaxMessageSize)
+		c.Assert(err, IsNil)
+		c.Assert(buf, DeepEquals, large)
+		// the decompressed message can not exceed the max message size
+		_, err = decompressFrame(codec, frame, len(large)/2)
+		c.Assert(err, NotNil)
+
+		// the message below the threshold is not compressed
+		frame, err = compressFrame(codec, DefaultCompressionThreshold, small)
+		c.Assert(err, IsNil)
+		c.Assert(frame[0], Equals, frameRaw)
+		buf, err = decompressFrame(codec, frame, DefaultMaxMessageSize)
+		c.Assert(err, IsNil)
+		c.Assert(buf, DeepEquals, small)
+
+		_, err = decompressFrame(codec, nil, DefaultMaxMessageSize)
+		c.Assert(err, NotNil)
+		_, err = decompressFrame(codec, []byte{2, 1, 2}, DefaultMaxMessageSize)
+		c.Assert(err, NotNil)
+		_, err = decompressFrame(codec, []byte{frameCompressed, 0xff, 0xff, 0xff}, DefaultMaxMessageSize)
+		c.Assert(err, NotNil)
+	}
+	_, err := compressFrame("gzip", 0, large)
+	c.Assert(err, NotNil)
+}
+
+func (CompressionTestSuite) TestEncodedMessageCompress(c *C) {
+	msg := newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "message-id",
+		Payload:     bytes.Repeat([]byte("a"), 4096),
+	})
+	frame, err := msg.compress(CompressionSnappy, DefaultCompressionThreshold)
+	c.Assert(err, IsNil)
+	frame2, err := msg.compress(CompressionSnappy, DefaultCompressionThreshold)
+	c.Assert(err, IsNil)
+	c.Assert(&frame[0] == &frame2[0], Equals, true)
+	buf, err := decompressFrame(CompressionSnappy, frame, DefaultMaxMessageSize)
+	c.Assert(err, IsNil)
+	wrappedMsg, err := messages.UnmarshalWrappedMessage(buf)
+	c.Assert(err, IsNil)
+	c.Assert(wrappedMsg.MsgID, Equals, "message-id")
+}
+
+func (CompressionTestSuite) TestCompressedStream(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2230/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	bootstrapPrivKey := "6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas="
+	privKey, err := base64.StdEncoding.DecodeString(bootstrapPrivKey)
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+
+	// the first node does not compress, but it still accepts the compressed streams
+	comm, err := NewCommunication("commTest", nil, 2230, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2231, "",
+		WithCompression(CompressionConfig{Codec: CompressionZstd, Threshold: DefaultCompressionThreshold}))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	payload := bytes.Repeat([]byte("tss keygen message "), 1024)
+	received := make(chan *Message, 1)
+	comm.SetSubscribe(messages.TSSKeyGenMsg, "compressed", received)
+	defer comm.CancelSubscribe(messages.TSSKeyGenMsg, "compressed")
+	comm2.broadcast([]peer.ID{comm.host.ID()}, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "compressed",
+		Payload:     payload,
+	}), "compressed")
+
+	select {
+	case msg := <-received:
+		wrappedMsg, err := messages.UnmarshalWrappedMessage(msg.Payload)
+		c.Assert(err, IsNil)
+		c.Assert(wrappedMsg.Payload, DeepEquals, payload)
+	case <-time.After(time.Second * 10):
+		c.Fatal("fail to receive the compressed message")
+	}
+	sent := comm2.GetCompressionStats()
+	c.Assert(sent.SentWire < sent.SentRaw, Equals, true)
+	received2 := comm.GetCompressionStats()
+	c.Assert(received2.ReceivedRaw, Equals, sent.SentRaw)
+	c.Assert(received2.ReceivedWire, Equals, sent.SentWire)
+}
@@ -0,0 +1,49 @@
+package p2p
+
+import (
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Connectivity is how well we are connected to the bootstrap peers and the cached committee members
+type Connectivity struct {
+	HostStarted        bool `json:"host_started"`
+	ConnectedPeers     int  `json:"connected_peers"`
+	BootstrapPeers     int  `json:"bootstrap_peers"`
+	BootstrapConnected int  `json:"bootstrap_connected"`
+	// CommitteePeers are the cached committee members without us
+	CommitteePeers     int `json:"committee_peers"`
+	CommitteeConnected int `json:"committee_connected"`
+	// CommitteeQuorum is the number of the committee members, including us, we need to reach to sign, it is 0
+	// if we have no cached committee
+	CommitteeQuorum int `json:"committee_quorum,omitempty"`
+}
+
+// GetConnectivity return the number of the bootstrap peers and the cached committee members we are connected to
+func (c *Communication) GetConnectivity() Connectivity {
+	bootstrap, _ := peer.AddrInfosFromP2pAddrs(c.getBootstrapPeers()...)
+	ret := Connectivity{
+		BootstrapPeers: len(bootstrap),
+	}
+	if c.host == nil {
+		return ret
+	}
+	ret.HostStarted = true
+	ret.ConnectedPeers = len(c.host.Network().Peers())
+	for _, el := range bootstrap {
+		if c.host.Network().Connectedness(el.ID) == network.Connected {
+			ret.BootstrapConnected++
+		}
+	}
+	members := c.coldStart.committee(c.host.ID())
+	ret.CommitteePeers = len(members)
+	for _, el := range members {
+		if c.host.Network().Connectedness(el.ID) == network.Connected {
+			ret.CommitteeConnected++
+		}
+	}
+	if len(members) > 0 {
+		ret.CommitteeQuorum = c.coldStart.quorum(len(members) + 1)
+	}
+	return ret
+}
@@ -0,0 +1,44 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type ConnectivityTestSuite struct{}
+
+var _ = Suite(&ConnectivityTestSuite{})
+
+func (ConnectivityTestSuite) TestGetConnectivity(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2460/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2460, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.GetConnectivity(), DeepEquals, Connectivity{})
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2461, "",
+		WithColdStart(ColdStartConfig{Peers: []maddr.Multiaddr{validMultiAddr}}))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+	time.Sleep(time.Second)
+
+	connectivity := comm2.GetConnectivity()
+	c.Assert(connectivity.HostStarted, Equals, true)
+	c.Assert(connectivity.BootstrapPeers, Equals, 1)
+	c.Assert(connectivity.BootstrapConnected, Equals, 1)
+	c.Assert(connectivity.CommitteePeers, Equals, 1)
+	c.Assert(connectivity.CommitteeConnected, Equals, 1)
+	c.Assert(connectivity.CommitteeQuorum, Equals, 2)
+}
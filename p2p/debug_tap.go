@@ -0,0 +1,124 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+// TappedMessage is a copy of an inbound tss message we mirror to the debug tap
+type TappedMessage struct {
+	Time        time.Time                        `json:"time"`
+	PeerID      peer.ID                          `json:"peer_id"`
+	Protocol    protocol.ID                      `json:"protocol"`
+	MessageType messages.THORChainTSSMessageType `json:"message_type"`
+	MsgID       string                           `json:"msg_id"`
+	PayloadSize int                              `json:"payload_size"`
+	// Payload is nil if the tap redacts the payloads
+	Payload []byte `json:"payload,omitempty"`
+	// Delivered is false if no ceremony subscribes to the message
+	Delivered bool `json:"delivered"`
+}
+
+type tapSubscriber struct {
+	ch     chan TappedMessage
+	redact bool
+}
+
+// debugTap mirrors every inbound tss message to its subscribers, the slow subscribers miss the messages
+// instead of blocking the ceremonies
+type debugTap struct {
+	lock   *sync.RWMutex
+	subs   map[int]tapSubscriber
+	nextID int
+	closed bool
+}
+
+func newDebugTap() *debugTap {
+	return &debugTap{
+		lock: &sync.RWMutex{},
+		subs: make(map[int]tapSubscriber),
+	}
+}
+
+func (dt *debugTap) subscribe(bufferSize int, redact bool) (<-chan TappedMessage, func()) {
+	dt.lock.Lock()
+	defer dt.lock.Unlock()
+	sub := tapSubscriber{
+		ch:     make(chan TappedMessage, bufferSize),
+		redact: redact,
+	}
+	if dt.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	id := dt.nextID
+	dt.nextID++
+	dt.subs[id] = sub
+	once := &sync.Once{}
+	return sub.ch, func() {
+		once.Do(func() {
+			dt.lock.Lock()
+			defer dt.lock.Unlock()
+			if _, ok := dt.subs[id]; ok {
+				delete(dt.subs, id)
+				close(sub.ch)
+			}
+		})
+	}
+}
+
+// mirror send the message to the subscribers, the payload is copied as the buffer is recycled once the
+// ceremony is done with it
+func (dt *debugTap) mirror(pID peer.ID, protocolID protocol.ID, msg *messages.WrappedMessage, delivered bool) {
+	dt.lock.RLock()
+	defer dt.lock.RUnlock()
+	if len(dt.subs) == 0 {
+		return
+	}
+	tapped := TappedMessage{
+		Time:        time.Now(),
+		PeerID:      pID,
+		Protocol:    protocolID,
+		MessageType: msg.MessageType,
+		MsgID:       msg.MsgID,
+		PayloadSize: len(msg.Payload),
+		Delivered:   delivered,
+	}
+	var payload []byte
+	for _, sub := range dt.subs {
+		el := tapped
+		if !sub.redact {
+			if payload == nil {
+				payload = make([]byte, len(msg.Payload))
+				copy(payload, msg.Payload)
+			}
+			el.Payload = payload
+		}
+		select {
+		case sub.ch <- el:
+		default:
+		}
+	}
+}
+
+// close close the channels of all the subscribers
+func (dt *debugTap) close() {
+	dt.lock.Lock()
+	defer dt.lock.Unlock()
+	dt.closed = true
+	for id, sub := range dt.subs {
+		delete(dt.subs, id)
+		close(sub.ch)
+	}
+}
+
+// TapMessages return a channel that mirrors every inbound tss message of all the ceremonies and the function
+// to cancel the tap, the payloads are left out if redact is true, the messages are dropped if the channel is full, the channel is closed once we stop
+func (c *Communication) TapMessages(bufferSize int, redact bool) (<-chan TappedMessage, func()) {
+	return c.tap.subscribe(bufferSize, redact)
+}
@@ -0,0 +1,88 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type DebugTapTestSuite struct{}
+
+var _ = Suite(&DebugTapTestSuite{})
+
+func (DebugTapTestSuite) TestDebugTap(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2370/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2370, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2371, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	full, cancelFull := comm.TapMessages(4, false)
+	defer cancelFull()
+	redacted, cancelRedacted := comm.TapMessages(4, true)
+	defer cancelRedacted()
+	received := make(chan *Message, 1)
+	comm.SetSubscribe(messages.TSSKeySignMsg, "tapped", received)
+	defer comm.CancelSubscribe(messages.TSSKeySignMsg, "tapped")
+
+	payload := []byte("tss keysign message")
+	comm2.broadcast([]peer.ID{comm.host.ID()}, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       "tapped",
+		Payload:     payload,
+	}), "tapped")
+	select {
+	case <-received:
+	case <-time.After(time.Second * 10):
+		c.Fatal("fail to receive the message")
+	}
+	msg := nextTappedMessage(c, full)
+	c.Assert(msg.PeerID, Equals, comm2.host.ID())
+	c.Assert(msg.MsgID, Equals, "tapped")
+	c.Assert(msg.Payload, DeepEquals, payload)
+	c.Assert(msg.Delivered, Equals, true)
+	msg = nextTappedMessage(c, redacted)
+	c.Assert(msg.MsgID, Equals, "tapped")
+	c.Assert(msg.Payload, IsNil)
+	c.Assert(msg.PayloadSize, Equals, len(payload))
+
+	// the message nobody subscribes to is still mirrored
+	comm2.broadcast([]peer.ID{comm.host.ID()}, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       "unknown",
+		Payload:     payload,
+	}), "unknown")
+	msg = nextTappedMessage(c, full)
+	c.Assert(msg.MsgID, Equals, "unknown")
+	c.Assert(msg.Delivered, Equals, false)
+
+	cancelFull()
+	_, ok := <-full
+	c.Assert(ok, Equals, false)
+}
+
+func nextTappedMessage(c *C, msgs <-chan TappedMessage) TappedMessage {
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(time.Second * 10):
+		c.Fatal("no tapped message")
+	}
+	return TappedMessage{}
+}
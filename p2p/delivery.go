@@ -0,0 +1,126 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+// DeliveryConfig defines how we send a message to the peers
+type DeliveryConfig struct {
+	// Workers is the maximum number of peers we send the message to at the same time
+	Workers int
+	// PeerTimeout is how long we wait for a single peer to take the message, 0 only applies the stream timeouts
+	PeerTimeout time.Duration
+}
+
+// DefaultDeliveryConfig return the default delivery configuration
+func DefaultDeliveryConfig() DeliveryConfig {
+	return DeliveryConfig{
+		Workers:     16,
+		PeerTimeout: time.Second * 30,
+	}
+}
+
+// Validate check the delivery configuration is usable
+func (cfg DeliveryConfig) Validate() error {
+	if cfg.Workers <= 0 {
+		return fmt.Errorf("invalid number of delivery workers: %d", cfg.Workers)
+	}
+	if cfg.PeerTimeout < 0 {
+		return fmt.Errorf("invalid peer delivery timeout: %s", cfg.PeerTimeout)
+	}
+	return nil
+}
+
+// PeerDelivery is the result of sending the message to one peer
+type PeerDelivery struct {
+	PeerID   peer.ID
+	Duration time.Duration
+	Err      error
+}
+
+// DeliveryReport is the result of sending the message to each of the peers, in the same order as the peers
+type DeliveryReport struct {
+	MsgID string
+	Peers []PeerDelivery
+}
+
+// Failed return the peers we fail to send the message to
+func (r DeliveryReport) Failed() []peer.ID {
+	var failed []peer.ID
+	for _, el := range r.Peers {
+		if el.Err != nil {
+			failed = append(failed, el.PeerID)
+		}
+	}
+	return failed
+}
+
+// deliver send the message to the peers concurrently, at most Workers peers at the same time, so a slow
+// peer only holds up its own worker rather than the delivery to everyone else
+func (c *Communication) deliver(peers []peer.ID, msg *encodedMessage, msgID string) DeliveryReport {
+	report := DeliveryReport{
+		MsgID: msgID,
+		Peers: make([]PeerDelivery, len(peers)),
+	}
+	workers := c.delivery.Workers
+	if workers > len(peers) {
+		workers = len(peers)
+	}
+	jobs := make(chan int, len(peers))
+	for i := range peers {
+		jobs <- i
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				report.Peers[idx] = c.deliverToPeer(peers[idx], msg, msgID)
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Communication) deliverToPeer(pID peer.ID, msg *encodedMessage, msgID string) PeerDelivery {
+	ctx, done := c.ceremonies.acquire(msgID)
+	defer done()
+	if c.delivery.PeerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.delivery.PeerTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := c.writeToStream(ctx, pID, msg, msgID)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		c.reputation.Record(pID, EventTimeout)
+		err = fmt.Errorf("fail to deliver within %s: %w", c.delivery.PeerTimeout, err)
+	}
+	return PeerDelivery{
+		PeerID:   pID,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+}
+
+// SendToPeers send the message to the peers and wait until each of them either takes it or fails
+func (c *Communication) SendToPeers(peers []peer.ID, msg []byte, msgID string) DeliveryReport {
+	return c.deliver(peers, newRawMessage(msg), msgID)
+}
+
+// DeliverMessage is the broadcast channel that waits until each of the peers either takes the message or fails,
+// the message is encoded in the format each peer negotiated
+func (c *Communication) DeliverMessage(peers []peer.ID, msg *messages.WrappedMessage) DeliveryReport {
+	return c.deliver(peers, newEncodedMessage(msg), msg.MsgID)
+}
@@ -0,0 +1,109 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type DeliveryTestSuite struct{}
+
+var _ = Suite(&DeliveryTestSuite{})
+
+func (DeliveryTestSuite) TestConfig(c *C) {
+	c.Assert(DefaultDeliveryConfig().Validate(), IsNil)
+	c.Assert(DeliveryConfig{Workers: 1}.Validate(), IsNil)
+	c.Assert(DeliveryConfig{}.Validate(), NotNil)
+	c.Assert(DeliveryConfig{Workers: 1, PeerTimeout: -time.Second}.Validate(), NotNil)
+	_, err := NewCommunication("rendezvous", nil, 6668, "", WithDelivery(DeliveryConfig{}))
+	c.Assert(err, NotNil)
+
+	report := DeliveryReport{
+		Peers: []PeerDelivery{
+			{PeerID: "a"},
+			{PeerID: "b", Err: ErrNotActiveSigner},
+		},
+	}
+	c.Assert(report.Failed(), DeepEquals, []peer.ID{"b"})
+}
+
+func (DeliveryTestSuite) TestDeliver(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2250/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2250, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2251, "",
+		WithDelivery(DeliveryConfig{Workers: 2, PeerTimeout: time.Second * 2}))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	_, pk, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	unreachable, err := peer.IDFromPublicKey(pk)
+	c.Assert(err, IsNil)
+
+	received := make(chan *Message, 1)
+	comm.SetSubscribe(messages.TSSKeySignMsg, "delivery", received)
+	defer comm.CancelSubscribe(messages.TSSKeySignMsg, "delivery")
+	peers := []peer.ID{unreachable, comm.host.ID(), comm2.host.ID()}
+	start := time.Now()
+	report := comm2.deliver(peers, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       "delivery",
+		Payload:     []byte("hello"),
+	}), "delivery")
+	// the unreachable peer does not hold up the delivery for longer than the peer timeout
+	c.Assert(time.Since(start) < time.Second*10, Equals, true)
+	c.Assert(report.MsgID, Equals, "delivery")
+	c.Assert(report.Peers, HasLen, 3)
+	for i, el := range report.Peers {
+		c.Assert(el.PeerID, Equals, peers[i])
+	}
+	c.Assert(report.Failed(), DeepEquals, []peer.ID{unreachable})
+
+	select {
+	case msg := <-received:
+		c.Assert(msg.PeerID, Equals, comm2.host.ID())
+	case <-time.After(time.Second * 10):
+		c.Fatal("fail to receive the message")
+	}
+}
+
+func (DeliveryTestSuite) TestCeremonyContexts(c *C) {
+	cc := newCeremonyContexts()
+	ctx1, done1 := cc.acquire("msg1")
+	ctx2, done2 := cc.acquire("msg1")
+	c.Assert(ctx1, Equals, ctx2)
+	other, doneOther := cc.acquire("msg2")
+
+	cc.cancel("msg1")
+	c.Assert(ctx1.Err(), NotNil)
+	c.Assert(other.Err(), IsNil)
+	done1()
+	done2()
+	c.Assert(cc.contexts, HasLen, 1)
+
+	// the later deliveries of the ceremony get a fresh context
+	ctx3, done3 := cc.acquire("msg1")
+	c.Assert(ctx3.Err(), IsNil)
+	done3()
+	c.Assert(ctx3.Err(), NotNil)
+	doneOther()
+	c.Assert(cc.contexts, HasLen, 0)
+}
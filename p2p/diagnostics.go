@@ -0,0 +1,64 @@
+package p2p
+
+import (
+	"sort"
+)
+
+// SubscriberState is the subscriber of the tss messages of a ceremony and the messages waiting in its channel
+type SubscriberState struct {
+	Topic    string `json:"topic"`
+	MsgID    string `json:"msg_id"`
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+}
+
+// Diagnostics is the state of the p2p layer the operator dumps to debug the stuck ceremonies, it only counts the
+// tss messages and the streams, it never carries their payload
+type Diagnostics struct {
+	Subscribers []SubscriberState `json:"subscribers"`
+	// PeerStreams and ProtocolStreams are the streams we have open, by peer and by protocol
+	PeerStreams     map[string]int `json:"peer_streams"`
+	ProtocolStreams map[string]int `json:"protocol_streams"`
+	// CeremonyStreams are the streams we keep for each ceremony until it is released
+	CeremonyStreams map[string]int     `json:"ceremony_streams"`
+	StreamHandler   StreamHandlerStats `json:"stream_handler"`
+}
+
+// GetDiagnostics return the subscribers, the streams and the inbound stream queue of the p2p layer
+func (c *Communication) GetDiagnostics() Diagnostics {
+	d := Diagnostics{
+		Subscribers:     []SubscriberState{},
+		PeerStreams:     make(map[string]int),
+		ProtocolStreams: make(map[string]int),
+		CeremonyStreams: c.streamMgr.counts(),
+		StreamHandler:   c.GetStreamHandlerStats(),
+	}
+	c.subscriberLocker.Lock()
+	for topic, el := range c.subscribers {
+		for msgID, channel := range el.snapshot() {
+			d.Subscribers = append(d.Subscribers, SubscriberState{
+				Topic:    topic.String(),
+				MsgID:    msgID,
+				Queued:   len(channel),
+				Capacity: cap(channel),
+			})
+		}
+	}
+	c.subscriberLocker.Unlock()
+	sort.Slice(d.Subscribers, func(i, j int) bool {
+		if d.Subscribers[i].MsgID != d.Subscribers[j].MsgID {
+			return d.Subscribers[i].MsgID < d.Subscribers[j].MsgID
+		}
+		return d.Subscribers[i].Topic < d.Subscribers[j].Topic
+	})
+	if c.host == nil {
+		return d
+	}
+	for _, conn := range c.host.Network().Conns() {
+		for _, stream := range conn.GetStreams() {
+			d.PeerStreams[conn.RemotePeer().String()]++
+			d.ProtocolStreams[string(stream.Protocol())]++
+		}
+	}
+	return d
+}
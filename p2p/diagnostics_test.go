@@ -0,0 +1,37 @@
+package p2p
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type DiagnosticsTestSuite struct{}
+
+var _ = Suite(&DiagnosticsTestSuite{})
+
+func (DiagnosticsTestSuite) TestGetDiagnostics(c *C) {
+	comm, err := NewCommunication("commTest", nil, 2392, "", WithStreamHandler(StreamHandlerConfig{Workers: 1, QueueSize: 4}))
+	c.Assert(err, IsNil)
+	d := comm.GetDiagnostics()
+	c.Assert(d.Subscribers, HasLen, 0)
+	c.Assert(d.PeerStreams, HasLen, 0)
+	c.Assert(d.StreamHandler.Workers, Equals, 1)
+
+	channel := make(chan *Message, 4)
+	channel <- &Message{Payload: []byte("secret")}
+	comm.SetSubscribe(messages.TSSKeySignMsg, "msg-2", channel)
+	comm.SetSubscribe(messages.TSSKeySignVerMsg, "msg-2", make(chan *Message))
+	comm.SetSubscribe(messages.TSSKeySignMsg, "msg-1", make(chan *Message, 2))
+	comm.streamMgr.AddStream("msg-1", NewMockNetworkStream())
+	d = comm.GetDiagnostics()
+	c.Assert(d.Subscribers, HasLen, 3)
+	c.Assert(d.Subscribers[0], DeepEquals, SubscriberState{
+		Topic:    messages.TSSKeySignMsg.String(),
+		MsgID:    "msg-1",
+		Capacity: 2,
+	})
+	c.Assert(d.Subscribers[1].MsgID, Equals, "msg-2")
+	c.Assert(d.Subscribers[1].Queued+d.Subscribers[2].Queued, Equals, 1)
+	c.Assert(d.CeremonyStreams, DeepEquals, map[string]int{"msg-1": 1})
+}
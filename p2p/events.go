@@ -0,0 +1,133 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// PeerEventType is the kind of the peer event
+type PeerEventType string
+
+const (
+	// PeerConnected we have opened the first connection to the peer
+	PeerConnected PeerEventType = "connected"
+	// PeerDisconnected we have closed the last connection to the peer
+	PeerDisconnected PeerEventType = "disconnected"
+	// PeerStreamFailure we fail to send a tss message to the peer or to read one from it
+	PeerStreamFailure PeerEventType = "stream_failure"
+	// PeerBanned the peer is banned by the stream rate limit or by its reputation
+	PeerBanned PeerEventType = "banned"
+)
+
+// PeerEvent is emitted when the connection to a peer changes or a peer misbehaves
+type PeerEvent struct {
+	Type     PeerEventType `json:"type"`
+	Peer     peer.ID       `json:"peer"`
+	Protocol protocol.ID   `json:"protocol,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Time     time.Time     `json:"time"`
+}
+
+// eventBus fans out the peer events to the subscribers, the slow subscribers miss the events instead of
+// blocking the p2p layer
+type eventBus struct {
+	lock   *sync.RWMutex
+	subs   map[int]chan PeerEvent
+	nextID int
+	closed bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		lock: &sync.RWMutex{},
+		subs: make(map[int]chan PeerEvent),
+	}
+}
+
+func (eb *eventBus) subscribe(bufferSize int) (<-chan PeerEvent, func()) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	ch := make(chan PeerEvent, bufferSize)
+	if eb.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	id := eb.nextID
+	eb.nextID++
+	eb.subs[id] = ch
+	once := &sync.Once{}
+	return ch, func() {
+		once.Do(func() {
+			eb.lock.Lock()
+			defer eb.lock.Unlock()
+			if _, ok := eb.subs[id]; ok {
+				delete(eb.subs, id)
+				close(ch)
+			}
+		})
+	}
+}
+
+func (eb *eventBus) publish(ev PeerEvent) {
+	eb.lock.RLock()
+	defer eb.lock.RUnlock()
+	for _, ch := range eb.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// close close the channels of all the subscribers
+func (eb *eventBus) close() {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	eb.closed = true
+	for id, ch := range eb.subs {
+		delete(eb.subs, id)
+		close(ch)
+	}
+}
+
+func (c *Communication) emitPeerEvent(eventType PeerEventType, pID peer.ID, protocolID protocol.ID, err error) {
+	ev := PeerEvent{
+		Type:     eventType,
+		Peer:     pID,
+		Protocol: protocolID,
+		Time:     time.Now(),
+	}
+	if err != nil {
+		ev.Reason = err.Error()
+	}
+	c.events.publish(ev)
+}
+
+// SubscribePeerEvents return a channel of the peer events and the function to cancel the subscription, the
+// events are dropped if the channel is full, the channel is closed once we stop
+func (c *Communication) SubscribePeerEvents(bufferSize int) (<-chan PeerEvent, func()) {
+	return c.events.subscribe(bufferSize)
+}
+
+// connectionNotifiee emits the connected and disconnected events, we only emit them for the first and the
+// last connection to the peer, it also closes the circuit of the connected peers
+func (c *Communication) connectionNotifiee() *network.NotifyBundle {
+	return &network.NotifyBundle{
+		ConnectedF: func(n network.Network, conn network.Conn) {
+			if len(n.ConnsToPeer(conn.RemotePeer())) == 1 {
+				c.emitPeerEvent(PeerConnected, conn.RemotePeer(), "", nil)
+			}
+			// the peer is reachable again if it dials us
+			c.dialSucceeded(conn.RemotePeer())
+		},
+		DisconnectedF: func(n network.Network, conn network.Conn) {
+			if n.Connectedness(conn.RemotePeer()) != network.Connected {
+				c.emitPeerEvent(PeerDisconnected, conn.RemotePeer(), "", nil)
+			}
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type EventsTestSuite struct{}
+
+var _ = Suite(&EventsTestSuite{})
+
+func (EventsTestSuite) TestEventBus(c *C) {
+	eb := newEventBus()
+	events, cancel := eb.subscribe(1)
+	events2, cancel2 := eb.subscribe(1)
+	eb.publish(PeerEvent{Type: PeerConnected})
+	// the full subscriber misses the event instead of blocking the bus
+	eb.publish(PeerEvent{Type: PeerDisconnected})
+	c.Assert((<-events).Type, Equals, PeerConnected)
+	c.Assert((<-events2).Type, Equals, PeerConnected)
+	cancel()
+	cancel()
+	_, ok := <-events
+	c.Assert(ok, Equals, false)
+	eb.close()
+	_, ok = <-events2
+	c.Assert(ok, Equals, false)
+	cancel2()
+	// we cannot subscribe once the bus is closed
+	events, _ = eb.subscribe(1)
+	_, ok = <-events
+	c.Assert(ok, Equals, false)
+}
+
+func (EventsTestSuite) TestReputationBan(c *C) {
+	pID, err := peer.Decode(latencyTestPeers[0])
+	c.Assert(err, IsNil)
+	r := NewReputation(ReputationConfig{HalfLife: time.Hour, BanThreshold: -5})
+	var banned []peer.ID
+	r.onBan = func(p peer.ID) {
+		banned = append(banned, p)
+	}
+	r.Record(pID, EventTimeout)
+	c.Assert(banned, HasLen, 0)
+	r.Record(pID, EventBlame)
+	r.Record(pID, EventBlame)
+	// we only report the ban once
+	c.Assert(banned, DeepEquals, []peer.ID{pID})
+}
+
+func (EventsTestSuite) TestPeerEvents(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2360/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2360, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	events, cancel := comm.SubscribePeerEvents(16)
+	defer cancel()
+
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2361, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	remote := comm2.host.ID()
+	c.Assert(nextPeerEvent(c, events, PeerConnected).Peer, Equals, remote)
+	c.Assert(comm2.Stop(), IsNil)
+	c.Assert(nextPeerEvent(c, events, PeerDisconnected).Peer, Equals, remote)
+}
+
+// nextPeerEvent return the next event of the given type, the events of the other types are skipped
+func nextPeerEvent(c *C, events <-chan PeerEvent, eventType PeerEventType) PeerEvent {
+	timeout := time.After(time.Second * 10)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == eventType {
+				return ev
+			}
+		case <-timeout:
+			c.Fatalf("no %s event", eventType)
+		}
+	}
+}
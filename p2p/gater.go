@@ -0,0 +1,75 @@
+package p2p
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+// allowlist is the list of the peers we connect to, it can be replaced while we run
+type allowlist struct {
+	lock  sync.RWMutex
+	peers map[peer.ID]bool
+}
+
+// allows return true if the peer is in the allowlist, an empty or a nil allowlist allows all the peers
+func (a *allowlist) allows(p peer.ID) bool {
+	if a == nil {
+		return true
+	}
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return len(a.peers) == 0 || a.peers[p]
+}
+
+// set replace the peers of the allowlist
+func (a *allowlist) set(peers []peer.ID) {
+	allowed := make(map[peer.ID]bool, len(peers))
+	for _, el := range peers {
+		allowed[el] = true
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.peers = allowed
+}
+
+// peerGater refuses the connections of the peers that are not in the allowlist, or are banned by the reputation or
+// the stream rate limiter
+type peerGater struct {
+	allowed     *allowlist
+	reputation  *Reputation
+	rateLimiter *StreamRateLimiter
+}
+
+func (g *peerGater) refused(p peer.ID) bool {
+	if !g.allowed.allows(p) {
+		return true
+	}
+	if g.rateLimiter != nil && g.rateLimiter.IsBanned(p) {
+		return true
+	}
+	return g.reputation.Banned(p)
+}
+
+func (g *peerGater) InterceptPeerDial(p peer.ID) bool {
+	return !g.refused(p)
+}
+
+func (g *peerGater) InterceptAddrDial(p peer.ID, _ maddr.Multiaddr) bool {
+	return !g.refused(p)
+}
+
+func (g *peerGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (g *peerGater) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	return !g.refused(p)
+}
+
+func (g *peerGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
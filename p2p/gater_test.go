@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	. "gopkg.in/check.v1"
+)
+
+type GaterTestSuite struct{}
+
+var _ = Suite(&GaterTestSuite{})
+
+func (GaterTestSuite) TestPeerGater(c *C) {
+	var peers []peer.ID
+	for _, el := range latencyTestPeers {
+		p, err := peer.Decode(el)
+		c.Assert(err, IsNil)
+		peers = append(peers, p)
+	}
+	reputation := NewReputation(ReputationConfig{HalfLife: time.Hour, BanThreshold: -5})
+	g := &peerGater{reputation: reputation}
+	// no allowlist allows all the peers
+	c.Assert(g.InterceptPeerDial(peers[0]), Equals, true)
+	c.Assert(g.InterceptPeerDial(peers[2]), Equals, true)
+
+	g.allowed = &allowlist{}
+	g.allowed.set([]peer.ID{peers[0], peers[1]})
+	c.Assert(g.InterceptPeerDial(peers[0]), Equals, true)
+	c.Assert(g.InterceptAddrDial(peers[2], nil), Equals, false)
+	c.Assert(g.InterceptSecured(0, peers[2], nil), Equals, false)
+
+	// the allowlist can be replaced while we run
+	g.allowed.set([]peer.ID{peers[2]})
+	c.Assert(g.InterceptPeerDial(peers[0]), Equals, false)
+	c.Assert(g.InterceptPeerDial(peers[2]), Equals, true)
+	g.allowed.set(nil)
+	c.Assert(g.InterceptPeerDial(peers[0]), Equals, true)
+	g.allowed.set([]peer.ID{peers[0], peers[1]})
+
+	// the allowed peer is still refused once it is banned
+	reputation.Record(peers[1], EventBlame)
+	c.Assert(g.InterceptPeerDial(peers[1]), Equals, false)
+
+	// the peer the stream rate limiter bans can not reconnect either
+	g.rateLimiter = NewStreamRateLimiter(RateLimitConfig{PerPeerRate: 1, PerPeerBurst: 1, BanThreshold: 1, BanWindow: time.Minute, BanDuration: time.Minute})
+	allowed, _ := g.rateLimiter.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+	_, banned := g.rateLimiter.Allow(peers[0])
+	c.Assert(banned, Equals, true)
+	c.Assert(g.InterceptSecured(0, peers[0], nil), Equals, false)
+	g.rateLimiter.Unban(peers[0])
+	c.Assert(g.InterceptSecured(0, peers[0], nil), Equals, true)
+}
@@ -0,0 +1,130 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+// gossipProtocolID is the protocol we account the gossiped messages to in the peer statistics
+const gossipProtocolID protocol.ID = "/p2p/tss/gossip"
+
+// gossipTopic return the topic all the nodes of the rendezvous gossip the broadcast messages on, the topic is
+// shared by all the ceremonies, so the mesh is formed long before any ceremony starts
+func gossipTopic(rendezvous string) string {
+	return "/tss/gossip/" + rendezvous
+}
+
+// startGossip join the gossip topic and start to read the messages gossiped by the other nodes
+func (c *Communication) startGossip(ctx context.Context, h host.Host) error {
+	// the gossipsub router runs until we stop
+	ctx, cancel := context.WithCancel(ctx)
+	ps, err := pubsub.NewGossipSub(ctx, h, pubsub.WithMaxMessageSize(c.maxMessageSize))
+	if err != nil {
+		cancel()
+		return fmt.Errorf("fail to create gossipsub: %w", err)
+	}
+	topic, err := ps.Join(gossipTopic(c.rendezvous))
+	if err != nil {
+		cancel()
+		return fmt.Errorf("fail to join the gossip topic: %w", err)
+	}
+	sub, err := topic.Subscribe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("fail to subscribe the gossip topic: %w", err)
+	}
+	c.gossipTopic = topic
+	c.wg.Add(1)
+	go c.readFromGossip(ctx, cancel, sub)
+	return nil
+}
+
+// gossip publish the message on the gossip topic, the peers that have not joined the topic get the message
+// through the direct streams instead
+func (c *Communication) gossip(peers []peer.ID, msg *encodedMessage, msgID string) {
+	joined := make(map[peer.ID]bool)
+	for _, el := range c.gossipTopic.ListPeers() {
+		joined[el] = true
+	}
+	var direct []peer.ID
+	for _, el := range peers {
+		if !joined[el] {
+			direct = append(direct, el)
+		}
+	}
+	if len(direct) == len(peers) {
+		c.broadcast(peers, msg, msgID)
+		return
+	}
+	buf, err := msg.forProtocol(TSSProtoProtocolID)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("fail to marshal the message to gossip")
+		c.broadcast(peers, msg, msgID)
+		return
+	}
+	if err := c.gossipTopic.Publish(context.Background(), buf); err != nil {
+		c.logger.Error().Err(err).Msg("fail to gossip the message, send it through the direct streams")
+		c.broadcast(peers, msg, msgID)
+		return
+	}
+	if len(direct) > 0 {
+		c.logger.Debug().Msgf("peers(%v) have not joined the gossip topic, send it through the direct streams", direct)
+		c.broadcast(direct, msg, msgID)
+	}
+}
+
+// readFromGossip dispatch the gossiped messages to the subscribers in the same way as the messages we read
+// from the streams
+func (c *Communication) readFromGossip(ctx context.Context, cancel context.CancelFunc, sub *pubsub.Subscription) {
+	defer c.wg.Done()
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	defer sub.Cancel()
+	for {
+		gossipMsg, err := sub.Next(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.logger.Error().Err(err).Msg("fail to read the gossip message")
+			}
+			return
+		}
+		from := gossipMsg.GetFrom()
+		if from == c.host.ID() {
+			continue
+		}
+		start := time.Now()
+		wrappedMsg, err := messages.UnmarshalWrappedMessage(gossipMsg.Data)
+		if err != nil {
+			c.peerStats.inbound(from, gossipProtocolID, 0, 0, true)
+			c.reputation.Record(from, EventMalformedMessage)
+			c.logger.Error().Err(err).Msgf("fail to unmarshal the gossip message from peer: %s", from)
+			continue
+		}
+		c.peerStats.inbound(from, gossipProtocolID, len(gossipMsg.Data), time.Since(start), false)
+		channel := c.getSubscriber(wrappedMsg.MessageType, wrappedMsg.MsgID)
+		c.tap.mirror(from, gossipProtocolID, wrappedMsg, channel != nil)
+		if channel == nil {
+			// the gossip topic is shared by all the ceremonies, so we get the messages of the ceremonies we are not in
+			continue
+		}
+		c.channelMonitor.observe(wrappedMsg.MessageType, len(channel), cap(channel))
+		channel <- &Message{
+			PeerID:  from,
+			Payload: gossipMsg.Data,
+		}
+	}
+}
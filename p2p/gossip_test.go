@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type GossipTestSuite struct{}
+
+var _ = Suite(&GossipTestSuite{})
+
+func (GossipTestSuite) TestGossip(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2310/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2310, "", WithGossip(true))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2311, "", WithGossip(true))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	// wait for the mesh to include the other node
+	deadline := time.After(time.Second * 10)
+	for len(comm2.gossipTopic.ListPeers()) == 0 {
+		select {
+		case <-deadline:
+			c.Fatal("the other node does not join the gossip topic")
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+
+	// give the gossipsub heartbeat the time to graft the mesh
+	time.Sleep(time.Second * 2)
+	received := make(chan *Message, 1)
+	comm.SetSubscribe(messages.TSSKeyGenMsg, "gossip", received)
+	defer comm.CancelSubscribe(messages.TSSKeyGenMsg, "gossip")
+	comm2.BroadcastMsgChan <- &messages.BroadcastMsgChan{
+		WrappedMessage: messages.WrappedMessage{
+			MessageType: messages.TSSKeyGenMsg,
+			MsgID:       "gossip",
+			Payload:     []byte("hello"),
+		},
+		PeersID: []peer.ID{comm.host.ID()},
+		Gossip:  true,
+	}
+	select {
+	case msg := <-received:
+		c.Assert(msg.PeerID, Equals, comm2.host.ID())
+		wrappedMsg, err := messages.UnmarshalWrappedMessage(msg.Payload)
+		c.Assert(err, IsNil)
+		c.Assert(wrappedMsg.Payload, DeepEquals, []byte("hello"))
+	case <-time.After(time.Second * 10):
+		c.Fatal("fail to receive the gossip message")
+	}
+	c.Assert(comm.GetPeerStats()[comm2.host.ID()][gossipProtocolID].MessagesIn, Equals, int64(1))
+	// the message is not sent over the direct streams
+	c.Assert(comm.GetPeerStats()[comm2.host.ID()][TSSProtoProtocolID].MessagesIn, Equals, int64(0))
+}
@@ -0,0 +1,27 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+// errEd25519Identity is returned for the ed25519 identity keys. The ed25519 p2p identities are not supported yet, the
+// peer id of the party coordinator, the blame and the signer selection is derived from the secp256k1 pub key of the
+// node, so the peer of an ed25519 host could never be matched to its party. They need a mapping of the peer id to
+// the node pub key first, until then we refuse them rather than start a host no ceremony can reach
+var errEd25519Identity = errors.New("the ed25519 p2p identity can not be mapped to the secp256k1 pub key of the node, use a secp256k1 key")
+
+// unmarshalIdentityKey unmarshal the raw secp256k1 private key bytes into the p2p identity key
+func unmarshalIdentityKey(privKeyBytes []byte) (crypto.PrivKey, error) {
+	switch len(privKeyBytes) {
+	case 32:
+		return crypto.UnmarshalSecp256k1PrivateKey(privKeyBytes)
+	case ed25519.PrivateKeySize:
+		return nil, errEd25519Identity
+	default:
+		return nil, fmt.Errorf("invalid p2p identity key length %d, expect a 32 bytes secp256k1 key", len(privKeyBytes))
+	}
+}
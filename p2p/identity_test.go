@@ -0,0 +1,34 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	. "gopkg.in/check.v1"
+)
+
+type IdentityTestSuite struct{}
+
+var _ = Suite(&IdentityTestSuite{})
+
+func (IdentityTestSuite) TestUnmarshalIdentityKey(c *C) {
+	secpKey, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	c.Assert(err, IsNil)
+	secpRaw, err := secpKey.Raw()
+	c.Assert(err, IsNil)
+	key, err := unmarshalIdentityKey(secpRaw)
+	c.Assert(err, IsNil)
+	c.Assert(key.Type().String(), Equals, "Secp256k1")
+	c.Assert(key.Equals(secpKey), Equals, true)
+
+	// the ed25519 identity can not be matched to the party of the node
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	_, err = unmarshalIdentityKey(edKey)
+	c.Assert(errors.Is(err, errEd25519Identity), Equals, true)
+
+	_, err = unmarshalIdentityKey([]byte("invalid"))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,145 @@
+package p2p
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// JoinStatus is how far a peer got in the join party
+type JoinStatus string
+
+const (
+	// JoinNeverConnected we never had a connection to the peer, it is likely a network issue or the peer is down
+	JoinNeverConnected JoinStatus = "never_connected"
+	// JoinNotSent we were connected to the peer, but it never sent us its join request
+	JoinNotSent JoinStatus = "connected_not_joined"
+	// JoinLate the peer sent its join request after the party was decided
+	JoinLate JoinStatus = "joined_late"
+	// JoinOnTime the peer joined before the party was decided
+	JoinOnTime JoinStatus = "joined"
+)
+
+// PeerJoin is how far a peer got in the join party and when
+type PeerJoin struct {
+	PeerID string `json:"peer_id"`
+	// PubKey is empty if the peer id does not carry the pub key
+	PubKey string     `json:"pub_key,omitempty"`
+	Status JoinStatus `json:"status"`
+	// ConnectedAt is the first time we saw the peer connected in the join party
+	ConnectedAt *time.Time `json:"connected_at,omitempty"`
+	// JoinedAt is the time its join request, or its response if it is the leader, arrived
+	JoinedAt *time.Time `json:"joined_at,omitempty"`
+}
+
+// JoinPartyReport describes the join party that failed to form, so the operators can tell the peers with the
+// network issues from the ones that stall the party
+type JoinPartyReport struct {
+	Leader   string     `json:"leader,omitempty"`
+	Start    time.Time  `json:"start"`
+	Deadline time.Time  `json:"deadline"`
+	Peers    []PeerJoin `json:"peers"`
+}
+
+// peerConnected record the first time we saw the peer connected
+func (ps *PeerStatus) peerConnected(p peer.ID) {
+	ps.peerStatusLock.Lock()
+	defer ps.peerStatusLock.Unlock()
+	if _, ok := ps.peersResponse[p]; !ok {
+		return
+	}
+	if _, ok := ps.connectedAt[p]; !ok {
+		ps.connectedAt[p] = time.Now()
+	}
+}
+
+// peerJoined record the first time the peer joined, it is connected by then as well, the caller holds the lock
+func (ps *PeerStatus) peerJoined(p peer.ID) {
+	now := time.Now()
+	if _, ok := ps.joinedAt[p]; !ok {
+		ps.joinedAt[p] = now
+	}
+	if _, ok := ps.connectedAt[p]; !ok {
+		ps.connectedAt[p] = now
+	}
+}
+
+// joinReport return the report of the peers of the join party, the ones that joined after the deadline are late, a
+// peer that is connected now is counted as connected if we did not see it before
+func (ps *PeerStatus) joinReport(deadline time.Time, connected func(peer.ID) bool) *JoinPartyReport {
+	ps.peerStatusLock.RLock()
+	defer ps.peerStatusLock.RUnlock()
+	report := &JoinPartyReport{
+		Start:    ps.start,
+		Deadline: deadline,
+		Peers:    make([]PeerJoin, 0, len(ps.peersResponse)),
+	}
+	if ps.leader != "NONE" {
+		report.Leader = ps.leader
+	}
+	now := time.Now()
+	for p := range ps.peersResponse {
+		pj := PeerJoin{
+			PeerID: p.String(),
+			Status: JoinNeverConnected,
+		}
+		if pk, err := conversion.GetPubKeyFromPeerID(p.String()); err == nil {
+			pj.PubKey = pk
+		}
+		if t, ok := ps.connectedAt[p]; ok {
+			pj.ConnectedAt = &t
+			pj.Status = JoinNotSent
+		} else if connected(p) {
+			pj.ConnectedAt = &now
+			pj.Status = JoinNotSent
+		}
+		if t, ok := ps.joinedAt[p]; ok {
+			pj.JoinedAt = &t
+			pj.Status = JoinOnTime
+			if t.After(deadline) {
+				pj.Status = JoinLate
+			}
+		}
+		report.Peers = append(report.Peers, pj)
+	}
+	sort.Slice(report.Peers, func(i, j int) bool {
+		return report.Peers[i].PeerID < report.Peers[j].PeerID
+	})
+	return report
+}
+
+// markConnected record the peer is connected in the join party of the msg id
+func (pc *PartyCoordinator) markConnected(msgID string, p peer.ID) {
+	pc.joinPartyGroupLock.Lock()
+	peerGroup, ok := pc.peersGroup[msgID]
+	pc.joinPartyGroupLock.Unlock()
+	if ok {
+		peerGroup.peerConnected(p)
+	}
+}
+
+// keepJoinReport keep the report of the join party that failed, until the stream of the msg id is released
+func (pc *PartyCoordinator) keepJoinReport(msgID string, peerGroup *PeerStatus, deadline time.Time) {
+	report := peerGroup.joinReport(deadline, func(p peer.ID) bool {
+		return pc.host.Network().Connectedness(p) == network.Connected
+	})
+	for _, el := range report.Peers {
+		if el.Status != JoinOnTime {
+			pc.logger.Warn().Str("msgID", msgID).Str("peer", el.PeerID).Str("status", string(el.Status)).Msg("the peer did not join the party in time")
+		}
+	}
+	pc.joinPartyGroupLock.Lock()
+	defer pc.joinPartyGroupLock.Unlock()
+	pc.joinReports[msgID] = report
+}
+
+// JoinPartyReport return the report of the join party of the msg id if it failed to form, nil otherwise
+func (pc *PartyCoordinator) JoinPartyReport(msgID string) *JoinPartyReport {
+	pc.joinPartyGroupLock.Lock()
+	defer pc.joinPartyGroupLock.Unlock()
+	return pc.joinReports[msgID]
+}
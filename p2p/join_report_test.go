@@ -0,0 +1,56 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	. "gopkg.in/check.v1"
+)
+
+type JoinReportTestSuite struct{}
+
+var _ = Suite(&JoinReportTestSuite{})
+
+func (JoinReportTestSuite) TestJoinReport(c *C) {
+	peers := generateRandomPeers(c, 5)
+	sortPeers(peers)
+	ps := NewPeerStatus(peers, peers[0], "NONE", 1)
+
+	// peers[1] joins in time, peers[2] joins late, peers[3] is connected but never joins, peers[4] is never seen
+	_, err := ps.updatePeer(peers[1])
+	c.Assert(err, IsNil)
+	ps.peerConnected(peers[3])
+	ps.peerConnected(peers[3])
+	ps.peerConnected(peers[0])
+	deadline := time.Now()
+	time.Sleep(time.Millisecond)
+	_, err = ps.updatePeer(peers[2])
+	c.Assert(err, IsNil)
+
+	report := ps.joinReport(deadline, func(peer.ID) bool { return false })
+	c.Assert(report.Leader, Equals, "")
+	c.Assert(report.Deadline, Equals, deadline)
+	c.Assert(report.Peers, HasLen, 4)
+	statuses := make(map[string]PeerJoin)
+	for _, el := range report.Peers {
+		statuses[el.PeerID] = el
+	}
+	c.Assert(statuses[peers[1].String()].Status, Equals, JoinOnTime)
+	c.Assert(statuses[peers[1].String()].JoinedAt, NotNil)
+	c.Assert(statuses[peers[2].String()].Status, Equals, JoinLate)
+	c.Assert(statuses[peers[2].String()].JoinedAt.After(deadline), Equals, true)
+	c.Assert(statuses[peers[3].String()].Status, Equals, JoinNotSent)
+	c.Assert(statuses[peers[3].String()].ConnectedAt.Before(deadline), Equals, true)
+	c.Assert(statuses[peers[3].String()].JoinedAt, IsNil)
+	c.Assert(statuses[peers[4].String()].Status, Equals, JoinNeverConnected)
+	c.Assert(statuses[peers[4].String()].ConnectedAt, IsNil)
+
+	// the peer that is connected by the time of the report was connected
+	report = ps.joinReport(deadline, func(p peer.ID) bool { return p == peers[4] })
+	for _, el := range report.Peers {
+		if el.PeerID == peers[4].String() {
+			c.Assert(el.Status, Equals, JoinNotSent)
+			c.Assert(el.ConnectedAt, NotNil)
+		}
+	}
+}
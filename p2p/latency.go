@@ -0,0 +1,155 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+// LatencyProbeConfig defines how often we ping the connected peers in the background
+type LatencyProbeConfig struct {
+	// Interval is the delay between two probes, 0 disables the background probe
+	Interval time.Duration
+	// Timeout is how long we wait for the pong of a single peer
+	Timeout time.Duration
+}
+
+// DefaultLatencyProbeConfig return the default latency probe configuration
+func DefaultLatencyProbeConfig() LatencyProbeConfig {
+	return LatencyProbeConfig{
+		Interval: time.Second * 30,
+		Timeout:  time.Second * 5,
+	}
+}
+
+// Enabled return true if we should probe the peers in the background
+func (cfg LatencyProbeConfig) Enabled() bool {
+	return cfg.Interval > 0
+}
+
+// PeerLatency is the round trip time of the latest ping to the peer
+type PeerLatency struct {
+	RTT   time.Duration `json:"rtt"`
+	Error string        `json:"error,omitempty"`
+	Time  time.Time     `json:"time"`
+}
+
+// Reachable return true if the peer answered the latest ping
+func (pl PeerLatency) Reachable() bool {
+	return len(pl.Error) == 0
+}
+
+// PingPeers ping the given peers in parallel and return the round trip time of each of them, the peers that do
+// not answer before the ctx is done are reported with the error
+func (c *Communication) PingPeers(ctx context.Context, peers []peer.ID) map[peer.ID]PeerLatency {
+	ret := make(map[peer.ID]PeerLatency, len(peers))
+	lock := &sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, el := range peers {
+		if el == c.host.ID() {
+			continue
+		}
+		wg.Add(1)
+		go func(pID peer.ID) {
+			defer wg.Done()
+			latency := c.pingPeer(ctx, pID)
+			lock.Lock()
+			ret[pID] = latency
+			lock.Unlock()
+		}(el)
+	}
+	wg.Wait()
+	return ret
+}
+
+func (c *Communication) pingPeer(ctx context.Context, pID peer.ID) PeerLatency {
+	ctx, cancel := context.WithCancel(ctx)
+	// cancel stops the ping, otherwise it keeps pinging the peer every second
+	defer cancel()
+	latency := PeerLatency{Time: time.Now()}
+	var err error
+	select {
+	case ret, ok := <-ping.Ping(ctx, c.host, pID):
+		switch {
+		case !ok:
+			err = ctx.Err()
+		case ret.Error != nil:
+			err = ret.Error
+		default:
+			latency.RTT = ret.RTT
+		}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		latency.Error = "ping timeout"
+	} else if err != nil {
+		latency.Error = err.Error()
+	}
+	return latency
+}
+
+// GetPeerLatencies return the latest round trip time of the peers we probe in the background
+func (c *Communication) GetPeerLatencies() map[peer.ID]PeerLatency {
+	c.latencyLock.RLock()
+	defer c.latencyLock.RUnlock()
+	ret := make(map[peer.ID]PeerLatency, len(c.latencies))
+	for k, v := range c.latencies {
+		ret[k] = v
+	}
+	return ret
+}
+
+// FastestPeers return the n peers with the lowest latency we probed, the peers that we have not probed yet or
+// did not answer come last, so the signer selection can prefer the low latency subset
+func (c *Communication) FastestPeers(peers []peer.ID, n int) []peer.ID {
+	return fastestPeers(c.GetPeerLatencies(), peers, n)
+}
+
+func fastestPeers(latencies map[peer.ID]PeerLatency, peers []peer.ID, n int) []peer.ID {
+	sorted := make([]peer.ID, len(peers))
+	copy(sorted, peers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		li, okI := latencies[sorted[i]]
+		lj, okJ := latencies[sorted[j]]
+		okI = okI && li.Reachable()
+		okJ = okJ && lj.Reachable()
+		if okI != okJ {
+			return okI
+		}
+		return okI && li.RTT < lj.RTT
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// probeLatency ping all the connected peers every interval, the peers we are no longer connected to are dropped
+func (c *Communication) probeLatency() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.latencyProbe.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+			timeout := c.latencyProbe.Timeout
+			if timeout <= 0 {
+				timeout = c.latencyProbe.Interval
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			latencies := c.PingPeers(ctx, c.host.Network().Peers())
+			cancel()
+			c.latencyLock.Lock()
+			c.latencies = latencies
+			c.latencyLock.Unlock()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package p2p
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+var latencyTestPeers = []string{
+	"16Uiu2HAmACG5DtqmQsHtXg4G2sLS65ttv84e7MrL4kapkjfmhxAp",
+	"16Uiu2HAmAWKWf5vnpiAhfdSQebTbbB3Bg35qtyG7Hr4ce23VFA8V",
+	"16Uiu2HAm2FzqoUdS6Y9Esg2EaGcAG5rVe1r6BFNnmmQr2H3bqafa",
+}
+
+type LatencyTestSuite struct{}
+
+var _ = Suite(&LatencyTestSuite{})
+
+func (LatencyTestSuite) TestFastestPeers(c *C) {
+	var peers []peer.ID
+	for _, el := range latencyTestPeers {
+		p, err := peer.Decode(el)
+		c.Assert(err, IsNil)
+		peers = append(peers, p)
+	}
+	comm, err := NewCommunication("commTest", nil, 6668, "")
+	c.Assert(err, IsNil)
+	comm.latencies = map[peer.ID]PeerLatency{
+		peers[0]: {RTT: time.Millisecond * 30},
+		peers[1]: {Error: "ping timeout"},
+		peers[2]: {RTT: time.Millisecond * 10},
+	}
+	c.Assert(comm.FastestPeers(peers, 2), DeepEquals, []peer.ID{peers[2], peers[0]})
+	fastest := comm.FastestPeers(peers, 10)
+	c.Assert(fastest, HasLen, len(peers))
+	c.Assert(fastest[:2], DeepEquals, []peer.ID{peers[2], peers[0]})
+	c.Assert(comm.GetPeerLatencies()[peers[1]].Reachable(), Equals, false)
+}
+
+func (LatencyTestSuite) TestPingPeers(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2280/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2280, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2281, "",
+		WithLatencyProbe(LatencyProbeConfig{Interval: time.Millisecond * 100, Timeout: time.Second}))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	offline, err := peer.Decode(latencyTestPeers[0])
+	c.Assert(err, IsNil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	latencies := comm2.PingPeers(ctx, []peer.ID{comm.host.ID(), comm2.host.ID(), offline})
+	// we do not ping ourselves
+	c.Assert(latencies, HasLen, 2)
+	c.Assert(latencies[comm.host.ID()].Reachable(), Equals, true)
+	c.Assert(latencies[comm.host.ID()].RTT > 0, Equals, true)
+	c.Assert(latencies[offline].Reachable(), Equals, false)
+
+	// the background probe pings the connected peers
+	timeout := time.After(time.Second * 5)
+	for {
+		if latency, ok := comm2.GetPeerLatencies()[comm.host.ID()]; ok {
+			c.Assert(latency.Reachable(), Equals, true)
+			return
+		}
+		select {
+		case <-timeout:
+			c.Fatal("fail to probe the latency of the connected peer")
+		case <-time.After(time.Millisecond * 50):
+		}
+	}
+}
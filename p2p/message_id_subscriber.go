@@ -47,3 +47,14 @@ func (ms *MessageIDSubscriber) IsEmpty() bool {
 	defer ms.lock.Unlock()
 	return len(ms.subscribers) == 0
 }
+
+// snapshot return the channels of the message ids
+func (ms *MessageIDSubscriber) snapshot() map[string]chan *Message {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ret := make(map[string]chan *Message, len(ms.subscribers))
+	for msgID, el := range ms.subscribers {
+		ret[msgID] = el
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+// maxParkedMessages is how many messages of a ceremony we wait to hand over while its channel is full, the
+// messages beyond it are dropped, so a peer can not pin the memory by flooding a busy ceremony
+const maxParkedMessages = 64
+
+// parkTimeout is how often we check the ceremony of a parked message is still subscribed, the ceremony that
+// finished no longer reads its channel, while a ceremony that is busy verifying the proofs of a large committee
+// may not read it for minutes
+var parkTimeout = time.Minute
+
+// parkedMessages keeps the number of messages of each ceremony we wait to hand over
+type parkedMessages struct {
+	lock   *sync.Mutex
+	counts map[string]int
+}
+
+func newParkedMessages() *parkedMessages {
+	return &parkedMessages{
+		lock:   &sync.Mutex{},
+		counts: make(map[string]int),
+	}
+}
+
+// park return true if we can wait for the ceremony to read one more message, the returned func should be called
+// once we stopped waiting
+func (pm *parkedMessages) park(msgID string) (func(), bool) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	if pm.counts[msgID] >= maxParkedMessages {
+		return nil, false
+	}
+	pm.counts[msgID]++
+	return func() {
+		pm.lock.Lock()
+		defer pm.lock.Unlock()
+		pm.counts[msgID]--
+		if pm.counts[msgID] <= 0 {
+			delete(pm.counts, msgID)
+		}
+	}, true
+}
+
+// parkMessage wait for the busy ceremony to read the message without holding the stream worker, the message is
+// dropped if the ceremony has too many parked messages already, is cancelled or no longer subscribes to it
+func (c *Communication) parkMessage(topic messages.THORChainTSSMessageType, msgID string, channel chan *Message, msg *Message) bool {
+	unpark, ok := c.parked.park(msgID)
+	if !ok {
+		releasePayloadBuffer(msg.Payload)
+		return false
+	}
+	ctx, done := c.ceremonies.acquire(msgID)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer unpark()
+		defer done()
+		if c.waitParked(ctx.Done(), topic, msgID, channel, msg) {
+			return
+		}
+		releasePayloadBuffer(msg.Payload)
+	}()
+	return true
+}
+
+// waitParked return true once the ceremony reads the message
+func (c *Communication) waitParked(cancelled <-chan struct{}, topic messages.THORChainTSSMessageType, msgID string, channel chan *Message, msg *Message) bool {
+	timer := time.NewTimer(parkTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case channel <- msg:
+			return true
+		case <-cancelled:
+			return false
+		case <-timer.C:
+			if c.getSubscriber(topic, msgID) != channel {
+				c.logger.Warn().Msgf("ceremony %s no longer reads the message of peer %s, drop it", msgID, msg.PeerID)
+				return false
+			}
+			timer.Reset(parkTimeout)
+		case <-c.stopChan:
+			return false
+		}
+	}
+}
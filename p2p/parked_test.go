@@ -0,0 +1,89 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type ParkedMessagesTestSuite struct{}
+
+var _ = Suite(&ParkedMessagesTestSuite{})
+
+func newParkTestCommunication() *Communication {
+	return &Communication{
+		logger:     log.Logger,
+		wg:         &sync.WaitGroup{},
+		stopChan:   make(chan struct{}),
+		ceremonies: newCeremonyContexts(),
+		parked:     newParkedMessages(),
+
+		subscribers:      make(map[messages.THORChainTSSMessageType]*MessageIDSubscriber),
+		subscriberLocker: &sync.Mutex{},
+	}
+}
+
+func (ParkedMessagesTestSuite) TestParkMessage(c *C) {
+	comm := newParkTestCommunication()
+	channel := make(chan *Message)
+	for i := 0; i < maxParkedMessages; i++ {
+		c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "ceremony", channel, &Message{}), Equals, true)
+	}
+	// the ceremony has as many parked messages as it can, the others are dropped
+	c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "ceremony", channel, &Message{}), Equals, false)
+	c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "another", make(chan *Message), &Message{}), Equals, true)
+
+	// the ceremony reads a parked message, so there is room for one more
+	<-channel
+	time.Sleep(time.Millisecond * 100)
+	c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "ceremony", channel, &Message{}), Equals, true)
+
+	// the waiters of a cancelled ceremony are gone
+	comm.ceremonies.cancel("ceremony")
+	time.Sleep(time.Millisecond * 100)
+	comm.parked.lock.Lock()
+	c.Assert(comm.parked.counts["ceremony"], Equals, 0)
+	c.Assert(comm.parked.counts["another"], Equals, 1)
+	comm.parked.lock.Unlock()
+
+	close(comm.stopChan)
+	comm.wg.Wait()
+	comm.parked.lock.Lock()
+	c.Assert(comm.parked.counts, HasLen, 0)
+	comm.parked.lock.Unlock()
+}
+
+func (ParkedMessagesTestSuite) TestParkMessageSubscribed(c *C) {
+	parkTimeout = time.Millisecond * 50
+	defer func() {
+		parkTimeout = time.Minute
+	}()
+	comm := newParkTestCommunication()
+	channel := make(chan *Message)
+	comm.SetSubscribe(messages.TSSKeySignMsg, "ceremony", channel)
+	c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "ceremony", channel, &Message{}), Equals, true)
+	c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "finished", make(chan *Message), &Message{}), Equals, true)
+
+	// the busy ceremony gets its message however long it takes to read it, the one that is not subscribed does not
+	time.Sleep(parkTimeout * 4)
+	comm.parked.lock.Lock()
+	c.Assert(comm.parked.counts["ceremony"], Equals, 1)
+	c.Assert(comm.parked.counts["finished"], Equals, 0)
+	comm.parked.lock.Unlock()
+	<-channel
+
+	// the message is dropped once the ceremony unsubscribes
+	c.Assert(comm.parkMessage(messages.TSSKeySignMsg, "ceremony", channel, &Message{}), Equals, true)
+	comm.CancelSubscribe(messages.TSSKeySignMsg, "ceremony")
+	time.Sleep(parkTimeout * 4)
+	comm.parked.lock.Lock()
+	c.Assert(comm.parked.counts, HasLen, 0)
+	comm.parked.lock.Unlock()
+
+	close(comm.stopChan)
+	comm.wg.Wait()
+}
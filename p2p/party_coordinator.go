@@ -16,12 +16,13 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
 	"github.com/akildemir/go-tss/messages"
 )
 
 var (
-	ErrJoinPartyTimeout = errors.New("fail to join party, timeout")
-	ErrLeaderNotReady   = errors.New("leader not reachable")
+	ErrJoinPartyTimeout = errcode.New(errcode.JoinPartyTimeout, "fail to join party, timeout")
+	ErrLeaderNotReady   = errcode.New(errcode.PeerUnreachable, "leader not reachable")
 	ErrSignReceived     = errors.New("signature received")
 	ErrNotActiveSigner  = errors.New("not active signer")
 	ErrSigGenerated     = errors.New("signature generated")
@@ -35,6 +36,11 @@ type PartyCoordinator struct {
 	peersGroup         map[string]*PeerStatus
 	joinPartyGroupLock *sync.Mutex
 	streamMgr          *StreamMgr
+	// joinReports are the reports of the join parties that failed, by msg id
+	joinReports map[string]*JoinPartyReport
+	// selector picks the signers once more peers join the party than the threshold needs, the first peers to join
+	// are picked if it is nil
+	selector SignerSelector
 }
 
 // NewPartyCoordinator create a new instance of PartyCoordinator
@@ -50,6 +56,7 @@ func NewPartyCoordinator(host host.Host, timeout time.Duration) *PartyCoordinato
 		timeout:            timeout,
 		peersGroup:         make(map[string]*PeerStatus),
 		joinPartyGroupLock: &sync.Mutex{},
+		joinReports:        make(map[string]*JoinPartyReport),
 		streamMgr:          NewStreamMgr(),
 	}
 	host.SetStreamHandler(joinPartyProtocol, pc.HandleStream)
@@ -57,6 +64,20 @@ func NewPartyCoordinator(host host.Host, timeout time.Duration) *PartyCoordinato
 	return pc
 }
 
+// ceremonyLogger return the logger of the join party of the ceremony of the msg id, the context may carry the
+// logger of the ceremony
+func (pc *PartyCoordinator) ceremonyLogger(ctx context.Context, msgID string) zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger.With().Str("module", "party_coordinator").Logger()
+	}
+	return pc.logger.With().Str("msgID", msgID).Logger()
+}
+
+// SetSignerSelector set the strategy the leader picks the signers with, it should be set before the parties form
+func (pc *PartyCoordinator) SetSignerSelector(selector SignerSelector) {
+	pc.selector = selector
+}
+
 // Stop the PartyCoordinator rune
 func (pc *PartyCoordinator) Stop() {
 	defer pc.logger.Info().Msg("stop party coordinator")
@@ -76,6 +97,9 @@ func (pc *PartyCoordinator) processRespMsg(respMsg *messages.JoinPartyLeaderComm
 		return
 	}
 	if remotePeer == peerGroup.leader {
+		peerGroup.peerStatusLock.Lock()
+		peerGroup.peerJoined(stream.Conn().RemotePeer())
+		peerGroup.peerStatusLock.Unlock()
 		peerGroup.setLeaderResponse(respMsg)
 		peerGroup.notify <- true
 		err := WriteStreamWithBuffer([]byte("done"), stream)
@@ -307,6 +331,7 @@ func (pc *PartyCoordinator) sendMsgToPeer(msgBuf []byte, msgID string, remotePee
 			pc.logger.Error().Err(err).Msg("fail to close stream")
 		}
 	}()
+	pc.markConnected(msgID, remotePeer)
 	pc.logger.Debug().Msgf("open stream to (%s) successfully", remotePeer)
 	err = WriteStreamWithBuffer(msgBuf, stream)
 	if err != nil {
@@ -323,7 +348,8 @@ func (pc *PartyCoordinator) sendMsgToPeer(msgBuf []byte, msgID string, remotePee
 	return nil
 }
 
-func (pc *PartyCoordinator) joinPartyMember(msgID string, leader string, threshold int, sigChan chan string) ([]peer.ID, error) {
+func (pc *PartyCoordinator) joinPartyMember(ctx context.Context, msgID string, leader string, threshold int, sigChan chan string) ([]peer.ID, error) {
+	logger := pc.ceremonyLogger(ctx, msgID)
 	peerGroup, err := pc.createJoinPartyGroups(msgID, leader, []string{leader}, threshold)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create join party:%w", err)
@@ -350,7 +376,7 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, leader string, thresho
 			default:
 				err := pc.sendRequestToLeader(&msg, leaderPeerID)
 				if err != nil {
-					pc.logger.Debug().Msg("the leader fail to receive our request")
+					logger.Debug().Msg("the leader fail to receive our request")
 				}
 			}
 			time.Sleep(time.Millisecond * 500)
@@ -364,29 +390,37 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, leader string, thresho
 		// now we wait for the leader to notify us who we do the keygen/keysign with
 		select {
 		case <-peerGroup.notify:
-			pc.logger.Debug().Msg("we have receive the response from the leader")
+			logger.Debug().Msg("we have receive the response from the leader")
 			close(done)
 			return
 
 		case <-time.After(pc.timeout):
 			// timeout
 			close(done)
-			pc.logger.Error().Msg("the leader has not reply us")
+			logger.Error().Msg("the leader has not reply us")
 			return
 		case result := <-sigChan:
 			sigNotify = result
 			close(done)
 			return
+		case <-ctx.Done():
+			close(done)
+			return
 		}
 	}()
 	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("join party is cancelled: %w", ctx.Err())
+	}
 
+	deadline := peerGroup.start.Add(pc.timeout)
 	if peerGroup.getLeaderResponse() == nil {
 		leaderPk, err := conversion.GetPubKeyFromPeerID(leader)
 		if err != nil {
-			pc.logger.Error().Msg("leader is not reachable")
+			logger.Error().Msg("leader is not reachable")
 		}
-		pc.logger.Error().Msgf("leader(%s) is not reachable", leaderPk)
+		logger.Error().Msgf("leader(%s) is not reachable", leaderPk)
+		pc.keepJoinReport(msgID, peerGroup, deadline)
 		return nil, ErrLeaderNotReady
 	}
 
@@ -399,32 +433,47 @@ func (pc *PartyCoordinator) joinPartyMember(msgID string, leader string, thresho
 	// also will get blamed.
 	pIDs, err := pc.getPeerIDs(onlineNodes)
 	if err != nil {
-		pc.logger.Error().Err(err).Msg("fail to parse peer id")
+		logger.Error().Err(err).Msg("fail to parse peer id")
 		return nil, err
 	}
 	if len(pIDs) < threshold {
+		pc.keepJoinReport(msgID, peerGroup, deadline)
 		return pIDs, errors.New("not enough peer")
 	}
 
 	if peerGroup.getLeaderResponse().Type == messages.JoinPartyLeaderComm_Success {
 		return pIDs, nil
 	}
-	pc.logger.Error().Msg("leader response with join party timeout")
+	logger.Error().Msg("leader response with join party timeout")
+	pc.keepJoinReport(msgID, peerGroup, deadline)
 	return pIDs, ErrJoinPartyTimeout
 }
 
-func (pc *PartyCoordinator) joinPartyLeader(msgID string, peers []string, threshold int, sigChan chan string) ([]peer.ID, error) {
-	peerGroup, err := pc.createJoinPartyGroups(msgID, pc.host.ID().String(), peers, threshold)
+func (pc *PartyCoordinator) joinPartyLeader(ctx context.Context, msgID string, peers, excluded []string, threshold int, sigChan chan string) ([]peer.ID, error) {
+	logger := pc.ceremonyLogger(ctx, msgID)
+	// the excluded peers are told the result, but they are never picked
+	isExcluded := make(map[string]bool, len(excluded))
+	for _, el := range excluded {
+		isExcluded[el] = true
+	}
+	var candidates []string
+	for _, el := range peers {
+		if !isExcluded[el] || el == pc.host.ID().String() {
+			candidates = append(candidates, el)
+		}
+	}
+	peerGroup, err := pc.createJoinPartyGroups(msgID, pc.host.ID().String(), candidates, threshold)
 	if err != nil {
-		pc.logger.Error().Err(err).Msg("fail to create the join party group")
+		logger.Error().Err(err).Msg("fail to create the join party group")
 		return nil, err
 	}
 	peerGroup.peerStatusLock.Lock()
 	peerGroup.leader = pc.host.ID().String()
+	peerGroup.collectAll = pc.selector != nil
 	peerGroup.peerStatusLock.Unlock()
 	allPeers, err := pc.getPeerIDs(peers)
 	if err != nil {
-		pc.logger.Error().Err(err).Msg("fail to parse peer id")
+		logger.Error().Err(err).Msg("fail to parse peer id")
 		return nil, err
 	}
 	var sigNotify string
@@ -435,15 +484,17 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peers []string, thresh
 		for {
 			select {
 			case <-peerGroup.notify:
-				pc.logger.Debug().Msg("we have enough participants")
+				logger.Debug().Msg("we have enough participants")
 				return
 
 			case <-time.After(pc.timeout / 2):
 				// timeout, reporting to peers before their timeout
-				pc.logger.Error().Msg("leader waits for peers timeout")
+				logger.Error().Msg("leader waits for peers timeout")
 				return
 			case result := <-sigChan:
 				sigNotify = result
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
@@ -451,7 +502,27 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peers []string, thresh
 	if sigNotify == "signature received" {
 		return nil, ErrSignReceived
 	}
+	if ctx.Err() != nil {
+		// we tell the peers the party will not form, so they do not wait for us
+		pc.sendResponseToAll(&messages.JoinPartyLeaderComm{
+			ID:   msgID,
+			Type: messages.JoinPartyLeaderComm_Timeout,
+		}, allPeers)
+		return nil, fmt.Errorf("join party is cancelled: %w", ctx.Err())
+	}
 	onlinePeers, _ := peerGroup.getPeersStatus()
+	if pc.selector != nil && len(onlinePeers) >= threshold {
+		// we give the peers a bit more time to join, so the strategy has candidates to choose from
+		if _, offline := peerGroup.getPeersStatus(); len(offline) > 0 {
+			select {
+			case <-time.After(signerSelectionWindow):
+			case <-pc.stopChan:
+			case <-ctx.Done():
+			}
+			onlinePeers, _ = peerGroup.getPeersStatus()
+		}
+		onlinePeers = pc.selector(msgID, onlinePeers, threshold)
+	}
 	onlinePeers = append(onlinePeers, pc.host.ID())
 
 	tssNodes := make([]string, len(onlinePeers))
@@ -466,9 +537,11 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peers []string, thresh
 	}
 	// we put ourselves(leader) in the online list, so need threshold +1
 	if len(onlinePeers) < threshold+1 {
-		// we notify the failure of the join party to everyone
+		deadline := time.Now()
+		// we notify the failure of the join party to everyone, the peers who join while we do are late
 		msg.Type = messages.JoinPartyLeaderComm_Timeout
 		pc.sendResponseToAll(&msg, allPeers)
+		pc.keepJoinReport(msgID, peerGroup, deadline)
 		return onlinePeers, ErrJoinPartyTimeout
 	}
 	// we notify all the peers who to run keygen/keysign
@@ -478,33 +551,78 @@ func (pc *PartyCoordinator) joinPartyLeader(msgID string, peers []string, thresh
 }
 
 func (pc *PartyCoordinator) JoinPartyWithLeader(msgID string, blockHeight int64, peers []string, threshold int, signChan chan string) ([]peer.ID, string, error) {
+	return pc.JoinPartyWithLeaderExcept(context.Background(), msgID, blockHeight, peers, nil, threshold, signChan)
+}
+
+// JoinPartyWithLeaderExcept join the party with the leader, the leader never picks the excluded peers, so a retried
+// ceremony can leave out the peers who failed it. All the peers pass the same peers, so they agree on the leader.
+// It gives up once the context is cancelled
+func (pc *PartyCoordinator) JoinPartyWithLeaderExcept(ctx context.Context, msgID string, blockHeight int64, peers, excluded []string, threshold int, signChan chan string) ([]peer.ID, string, error) {
 	leader, err := LeaderNode(msgID, blockHeight, peers)
 	if err != nil {
 		return nil, "", err
 	}
 	if pc.host.ID().String() == leader {
-		onlines, err := pc.joinPartyLeader(msgID, peers, threshold, signChan)
+		onlines, err := pc.joinPartyLeader(ctx, msgID, peers, excluded, threshold, signChan)
 		return onlines, leader, err
 	}
 	// now we are just the normal peer
-	onlines, err := pc.joinPartyMember(msgID, leader, threshold, signChan)
+	onlines, err := pc.joinPartyMember(ctx, msgID, leader, threshold, signChan)
 	return onlines, leader, err
 }
 
-// JoinPartyWithRetry this method provide the functionality to join party with retry and back off
-func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]peer.ID, error) {
+// JoinPartyWithRetry this method provide the functionality to join party with retry and back off, it gives up once
+// the context is cancelled
+func (pc *PartyCoordinator) JoinPartyWithRetry(ctx context.Context, msgID string, peers []string) ([]peer.ID, error) {
+	return pc.joinPartyWithTimeout(ctx, msgID, peers, pc.timeout)
+}
+
+// SyncBarrier blocks until all the given peers are reachable and have acknowledged the given token,
+// or the timeout expires. All the peers need to call SyncBarrier with the same token and peer list.
+// It returns the peers(including ourselves) that acknowledged the token, and ErrJoinPartyTimeout if
+// not all of them did it in time.
+func (pc *PartyCoordinator) SyncBarrier(token string, peers []peer.ID, timeout time.Duration) ([]peer.ID, error) {
+	if len(token) == 0 {
+		return nil, errors.New("empty barrier token")
+	}
+	if len(peers) == 0 {
+		return nil, errors.New("no peers to sync with")
+	}
+	if timeout.Nanoseconds() == 0 {
+		timeout = pc.timeout
+	}
+	includeSelf := false
+	peersStr := make([]string, 0, len(peers)+1)
+	for _, el := range peers {
+		if el == pc.host.ID() {
+			includeSelf = true
+		}
+		peersStr = append(peersStr, el.String())
+	}
+	// the join party always count ourselves as online, so we need to be in the list
+	if !includeSelf {
+		peersStr = append(peersStr, pc.host.ID().String())
+	}
+	// we prefix the token to avoid it conflicts with the message id of the keygen/keysign
+	msgID := "barrier-" + token
+	defer pc.ReleaseStream(msgID)
+	return pc.joinPartyWithTimeout(context.Background(), msgID, peersStr, timeout)
+}
+
+func (pc *PartyCoordinator) joinPartyWithTimeout(ctx context.Context, msgID string, peers []string, timeout time.Duration) ([]peer.ID, error) {
+	logger := pc.ceremonyLogger(ctx, msgID)
 	msg := messages.JoinPartyRequest{
 		ID: msgID,
 	}
 	msgSend, err := proto.Marshal(&msg)
 	if err != nil {
-		pc.logger.Error().Msg("fail to marshal the message")
+		logger.Error().Msg("fail to marshal the message")
 		return nil, err
 	}
 
 	peerGroup, err := pc.createJoinPartyGroups(msg.ID, "NONE", peers, 1)
 	if err != nil {
-		pc.logger.Error().Err(err).Msg("fail to create the join party group")
+		logger.Error().Err(err).Msg("fail to create the join party group")
 		return nil, err
 	}
 	defer pc.removePeerGroup(msg.ID)
@@ -531,20 +649,27 @@ func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]
 		for {
 			select {
 			case <-peerGroup.notify:
-				pc.logger.Debug().Msg("we have found the new peer")
+				logger.Debug().Msg("we have found the new peer")
 				if peerGroup.getCoordinationStatus() {
 					close(done)
 					return
 				}
-			case <-time.After(pc.timeout):
+			case <-time.After(timeout):
 				// timeout
 				close(done)
 				return
+			case <-ctx.Done():
+				close(done)
+				return
 			}
 		}
 	}()
 
 	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("join party is cancelled: %w", ctx.Err())
+	}
+	deadline := time.Now()
 	onlinePeers, _ := peerGroup.getPeersStatus()
 	pc.sendRequestToAll(msgID, msgSend, onlinePeers)
 	// we always set ourselves as online
@@ -552,9 +677,14 @@ func (pc *PartyCoordinator) JoinPartyWithRetry(msgID string, peers []string) ([]
 	if len(onlinePeers) == len(peers) {
 		return onlinePeers, nil
 	}
+	pc.keepJoinReport(msgID, peerGroup, deadline)
 	return onlinePeers, ErrJoinPartyTimeout
 }
 
+// ReleaseStream release the streams of the msg id and drop the report of its join party
 func (pc *PartyCoordinator) ReleaseStream(msgID string) {
+	pc.joinPartyGroupLock.Lock()
+	delete(pc.joinReports, msgID)
+	pc.joinPartyGroupLock.Unlock()
 	pc.streamMgr.ReleaseStream(msgID)
 }
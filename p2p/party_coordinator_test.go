@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"math/rand"
 	"sort"
 	"sync"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	tnet "github.com/libp2p/go-libp2p-testing/net"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
@@ -66,7 +68,7 @@ func TestPartyCoordinator(t *testing.T) {
 			defer wg.Done()
 			// we simulate different nodes join at different time
 			time.Sleep(time.Second * time.Duration(rand.Int()%10))
-			onlinePeers, err := coordinator.JoinPartyWithRetry(msgID, peers)
+			onlinePeers, err := coordinator.JoinPartyWithRetry(context.Background(), msgID, peers)
 			if err != nil {
 				t.Error(err)
 			}
@@ -109,7 +111,7 @@ func TestPartyCoordinatorTimeOut(t *testing.T) {
 		wg.Add(1)
 		go func(coordinator *PartyCoordinator) {
 			defer wg.Done()
-			onlinePeers, err := coordinator.JoinPartyWithRetry(msgID, peers)
+			onlinePeers, err := coordinator.JoinPartyWithRetry(context.Background(), msgID, peers)
 			assert.Errorf(t, err, ErrJoinPartyTimeout.Error())
 			var onlinePeersStr []string
 			for _, el := range onlinePeers {
@@ -122,3 +124,83 @@ func TestPartyCoordinatorTimeOut(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestJoinPartyCancelled(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 2)
+	var peers []string
+	for _, el := range hosts {
+		peers = append(peers, el.ID().String())
+	}
+	pc := NewPartyCoordinator(hosts[0], time.Minute)
+	defer pc.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Second, cancel)
+	start := time.Now()
+	_, err := pc.JoinPartyWithRetry(ctx, conversion.RandStringBytesMask(64), peers)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second*10)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(time.Second, cancel)
+	start = time.Now()
+	_, _, err = pc.JoinPartyWithLeaderExcept(ctx, conversion.RandStringBytesMask(64), 10, peers, nil, 1, make(chan string))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second*10)
+}
+
+func TestSyncBarrier(t *testing.T) {
+	ApplyDeadline = false
+	hosts := setupHostsLocally(t, 4)
+	var pcs []*PartyCoordinator
+	var peers []peer.ID
+	for _, el := range hosts {
+		pcs = append(pcs, NewPartyCoordinator(el, time.Second*10))
+		peers = append(peers, el.ID())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+
+	_, err := pcs[0].SyncBarrier("", peers, time.Second)
+	assert.NotNil(t, err)
+	_, err = pcs[0].SyncBarrier("token", nil, time.Second)
+	assert.NotNil(t, err)
+
+	token := conversion.RandStringBytesMask(16)
+	wg := sync.WaitGroup{}
+	for i, el := range pcs {
+		wg.Add(1)
+		go func(idx int, coordinator *PartyCoordinator) {
+			defer wg.Done()
+			time.Sleep(time.Millisecond * time.Duration(rand.Int()%2000))
+			// the barrier should work even the caller does not include itself in the list
+			var others []peer.ID
+			for _, p := range peers {
+				if p != peers[idx] {
+					others = append(others, p)
+				}
+			}
+			onlinePeers, err := coordinator.SyncBarrier(token, others, time.Second*5)
+			assert.Nil(t, err)
+			assert.Len(t, onlinePeers, 4)
+		}(i, el)
+	}
+	wg.Wait()
+
+	// only two of them join the barrier, so it should time out
+	token = conversion.RandStringBytesMask(16)
+	for _, el := range pcs[:2] {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			onlinePeers, err := coordinator.SyncBarrier(token, peers, time.Second)
+			assert.Equal(t, ErrJoinPartyTimeout, err)
+			assert.Len(t, onlinePeers, 2)
+		}(el)
+	}
+	wg.Wait()
+}
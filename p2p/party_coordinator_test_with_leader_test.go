@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"math/rand"
 	"sort"
 	"sync"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	tnet "github.com/libp2p/go-libp2p-testing/net"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
@@ -187,6 +189,12 @@ func TestNewPartyCoordinatorTimeOut(t *testing.T) {
 			sigChan := make(chan string)
 			_, _, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, 3, sigChan)
 			assert.Equal(t, err, ErrLeaderNotReady)
+			// the leader is up, it just does not answer
+			report := coordinator.JoinPartyReport(msgID)
+			assert.NotNil(t, report)
+			assert.Equal(t, leader, report.Leader)
+			assert.Len(t, report.Peers, 1)
+			assert.Equal(t, JoinNotSent, report.Peers[0].Status)
 		}(el)
 
 	}
@@ -201,6 +209,20 @@ func TestNewPartyCoordinatorTimeOut(t *testing.T) {
 			sigChan := make(chan string)
 			onlinePeers, _, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, 3, sigChan)
 			assert.Equal(t, ErrJoinPartyTimeout, err)
+			if coordinator == pcs[0] {
+				// the leader tells the peer that is up but does not join from the ones that joined
+				report := coordinator.JoinPartyReport(msgID)
+				assert.NotNil(t, report)
+				for _, el := range report.Peers {
+					if el.PeerID == pcs[3].host.ID().String() {
+						assert.Equal(t, JoinNotSent, el.Status)
+					} else {
+						assert.Equal(t, JoinOnTime, el.Status)
+					}
+				}
+				coordinator.ReleaseStream(msgID)
+				assert.Nil(t, coordinator.JoinPartyReport(msgID))
+			}
 			var onlinePeersStr []string
 			for _, el := range onlinePeers {
 				onlinePeersStr = append(onlinePeersStr, el.String())
@@ -242,3 +264,93 @@ func TestGetPeerIDs(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Len(t, r2, 0)
 }
+
+func TestPartyCoordinatorSignerSelection(t *testing.T) {
+	hosts := setupHosts(t, 5)
+	var pcs []*PartyCoordinator
+	var peers []string
+	timeout := time.Second * 4
+	for _, el := range hosts {
+		pcs = append(pcs, NewPartyCoordinator(el, timeout))
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+
+	msgID := conversion.RandStringBytesMask(64)
+	leader, err := LeaderNode(msgID, 10, peers)
+	assert.Nil(t, err)
+	var members []peer.ID
+	for _, el := range pcs {
+		el.SetSignerSelector(HashSelector)
+		if el.host.ID().String() != leader {
+			members = append(members, el.host.ID())
+		}
+	}
+	leaderID, err := peer.Decode(leader)
+	assert.Nil(t, err)
+	sortPeerIDs := func(ids []peer.ID) []peer.ID {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+	expected := sortPeerIDs(append(HashSelector(msgID, members, 2), leaderID))
+
+	wg := sync.WaitGroup{}
+	for _, el := range pcs {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			sigChan := make(chan string)
+			onlinePeers, _, err := coordinator.JoinPartyWithLeader(msgID, 10, peers, 2, sigChan)
+			assert.Nil(t, err)
+			// all the peers join, so the leader picks the signers with the hash rather than the first to join
+			assert.Equal(t, expected, sortPeerIDs(onlinePeers))
+		}(el)
+	}
+	wg.Wait()
+}
+
+func TestJoinPartyWithLeaderExcept(t *testing.T) {
+	hosts := setupHosts(t, 4)
+	var pcs []*PartyCoordinator
+	var peers []string
+	timeout := time.Second * 4
+	for _, el := range hosts {
+		pcs = append(pcs, NewPartyCoordinator(el, timeout))
+		peers = append(peers, el.ID().String())
+	}
+	defer func() {
+		for _, el := range pcs {
+			el.Stop()
+		}
+	}()
+
+	msgID := conversion.RandStringBytesMask(64)
+	leader, err := LeaderNode(msgID, 10, peers)
+	assert.Nil(t, err)
+	var excluded peer.ID
+	for _, el := range hosts {
+		if el.ID().String() != leader {
+			excluded = el.ID()
+			break
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	for _, el := range pcs {
+		wg.Add(1)
+		go func(coordinator *PartyCoordinator) {
+			defer wg.Done()
+			sigChan := make(chan string)
+			onlinePeers, _, err := coordinator.JoinPartyWithLeaderExcept(context.Background(), msgID, 10, peers, []string{excluded.String()}, 2, sigChan)
+			assert.Nil(t, err)
+			// the excluded peer is online, but the leader never picks it
+			assert.Len(t, onlinePeers, 3)
+			assert.NotContains(t, onlinePeers, excluded)
+		}(el)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,153 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// noProtocol is the protocol we account the failures to when we fail to open a stream to the peer
+const noProtocol protocol.ID = "none"
+
+// ProtocolStats is the tss message traffic with a peer over a protocol
+type ProtocolStats struct {
+	MessagesIn  int64     `json:"messages_in"`
+	MessagesOut int64     `json:"messages_out"`
+	BytesIn     int64     `json:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out"`
+	Failures    int64     `json:"failures"`
+	LastSeen    time.Time `json:"last_seen"`
+	// AvgLatency is the average time it takes to read and decode an inbound message, or to write an outbound one
+	AvgLatency time.Duration `json:"avg_latency"`
+}
+
+type protocolCounter struct {
+	stats        ProtocolStats
+	latencyTotal time.Duration
+	latencyCount int64
+}
+
+// peerStats records the tss messages we exchange with each peer over each protocol
+type peerStats struct {
+	lock  *sync.Mutex
+	peers map[peer.ID]map[protocol.ID]*protocolCounter
+	// ceremonies is the traffic over the per-ceremony protocols of each ceremony
+	ceremonies map[string]*protocolCounter
+}
+
+func newPeerStats() *peerStats {
+	return &peerStats{
+		lock:       &sync.Mutex{},
+		peers:      make(map[peer.ID]map[protocol.ID]*protocolCounter),
+		ceremonies: make(map[string]*protocolCounter),
+	}
+}
+
+func (ps *peerStats) counter(pID peer.ID, protocolID protocol.ID) *protocolCounter {
+	protocols, ok := ps.peers[pID]
+	if !ok {
+		protocols = make(map[protocol.ID]*protocolCounter)
+		ps.peers[pID] = protocols
+	}
+	pc, ok := protocols[protocolID]
+	if !ok {
+		pc = &protocolCounter{}
+		protocols[protocolID] = pc
+	}
+	return pc
+}
+
+// inbound record a message we received from the peer, a failed message is one we fail to read or decode
+// counters return the counter of the peer over the protocol, and the counter of the ceremony if the protocol
+// is a per-ceremony one
+func (ps *peerStats) counters(pID peer.ID, protocolID protocol.ID) []*protocolCounter {
+	msgID, _, ok := parseCeremonyProtocol(protocolID)
+	if !ok {
+		return []*protocolCounter{ps.counter(pID, protocolID)}
+	}
+	cc, found := ps.ceremonies[msgID]
+	if !found {
+		cc = &protocolCounter{}
+		ps.ceremonies[msgID] = cc
+	}
+	return []*protocolCounter{ps.counter(pID, ceremonyStatsProtocol), cc}
+}
+
+func (ps *peerStats) inbound(pID peer.ID, protocolID protocol.ID, size int, latency time.Duration, failed bool) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for _, pc := range ps.counters(pID, protocolID) {
+		pc.stats.LastSeen = time.Now()
+		if failed {
+			pc.stats.Failures++
+			continue
+		}
+		pc.stats.MessagesIn++
+		pc.stats.BytesIn += int64(size)
+		pc.latencyTotal += latency
+		pc.latencyCount++
+	}
+}
+
+// outbound record a message we sent to the peer, the peer is only seen if it takes the message
+func (ps *peerStats) outbound(pID peer.ID, protocolID protocol.ID, size int, latency time.Duration, failed bool) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for _, pc := range ps.counters(pID, protocolID) {
+		if failed {
+			pc.stats.Failures++
+			continue
+		}
+		pc.stats.LastSeen = time.Now()
+		pc.stats.MessagesOut++
+		pc.stats.BytesOut += int64(size)
+		pc.latencyTotal += latency
+		pc.latencyCount++
+	}
+}
+
+func (pc *protocolCounter) snapshot() ProtocolStats {
+	stats := pc.stats
+	if pc.latencyCount > 0 {
+		stats.AvgLatency = pc.latencyTotal / time.Duration(pc.latencyCount)
+	}
+	return stats
+}
+
+// ceremony return the traffic over the per-ceremony protocols of the given ceremony
+func (ps *peerStats) ceremony(msgID string) (ProtocolStats, bool) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	cc, ok := ps.ceremonies[msgID]
+	if !ok {
+		return ProtocolStats{}, false
+	}
+	return cc.snapshot(), true
+}
+
+func (ps *peerStats) releaseCeremony(msgID string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	delete(ps.ceremonies, msgID)
+}
+
+func (ps *peerStats) snapshot() map[peer.ID]map[protocol.ID]ProtocolStats {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	ret := make(map[peer.ID]map[protocol.ID]ProtocolStats, len(ps.peers))
+	for pID, protocols := range ps.peers {
+		m := make(map[protocol.ID]ProtocolStats, len(protocols))
+		for protocolID, pc := range protocols {
+			m[protocolID] = pc.snapshot()
+		}
+		ret[pID] = m
+	}
+	return ret
+}
+
+// GetPeerStats return the tss message statistics of each peer over each protocol
+func (c *Communication) GetPeerStats() map[peer.ID]map[protocol.ID]ProtocolStats {
+	return c.peerStats.snapshot()
+}
@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type PeerStatsTestSuite struct{}
+
+var _ = Suite(&PeerStatsTestSuite{})
+
+func (PeerStatsTestSuite) TestCounter(c *C) {
+	pID, err := peer.Decode(latencyTestPeers[0])
+	c.Assert(err, IsNil)
+	ps := newPeerStats()
+	ps.inbound(pID, TSSProtoProtocolID, 100, time.Millisecond*10, false)
+	ps.inbound(pID, TSSProtoProtocolID, 300, time.Millisecond*30, false)
+	ps.inbound(pID, TSSProtoProtocolID, 0, 0, true)
+	ps.outbound(pID, TSSProtocolID, 50, time.Millisecond, false)
+	ps.outbound(pID, noProtocol, 0, 0, true)
+	stats := ps.snapshot()
+	c.Assert(stats[pID], HasLen, 3)
+	proto := stats[pID][TSSProtoProtocolID]
+	c.Assert(proto.MessagesIn, Equals, int64(2))
+	c.Assert(proto.BytesIn, Equals, int64(400))
+	c.Assert(proto.Failures, Equals, int64(1))
+	c.Assert(proto.AvgLatency, Equals, time.Millisecond*20)
+	c.Assert(proto.LastSeen.IsZero(), Equals, false)
+	c.Assert(stats[pID][TSSProtocolID].MessagesOut, Equals, int64(1))
+	c.Assert(stats[pID][TSSProtocolID].BytesOut, Equals, int64(50))
+	// the peer we fail to reach is not seen
+	c.Assert(stats[pID][noProtocol].Failures, Equals, int64(1))
+	c.Assert(stats[pID][noProtocol].LastSeen.IsZero(), Equals, true)
+}
+
+func (PeerStatsTestSuite) TestPeerStats(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2300/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2300, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2301, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	received := make(chan *Message, 1)
+	comm.SetSubscribe(messages.TSSKeyGenMsg, "peerstats", received)
+	defer comm.CancelSubscribe(messages.TSSKeyGenMsg, "peerstats")
+	report := comm2.deliver([]peer.ID{comm.host.ID()}, newEncodedMessage(&messages.WrappedMessage{
+		MessageType: messages.TSSKeyGenMsg,
+		MsgID:       "peerstats",
+		Payload:     []byte("hello"),
+	}), "peerstats")
+	c.Assert(report.Failed(), HasLen, 0)
+	select {
+	case <-received:
+	case <-time.After(time.Second * 10):
+		c.Fatal("fail to receive the message")
+	}
+	sent := comm2.GetPeerStats()[comm.host.ID()][TSSProtoProtocolID]
+	c.Assert(sent.MessagesOut, Equals, int64(1))
+	c.Assert(sent.BytesOut > 0, Equals, true)
+	got := comm.GetPeerStats()[comm2.host.ID()][TSSProtoProtocolID]
+	c.Assert(got.MessagesIn, Equals, int64(1))
+	c.Assert(got.BytesIn, Equals, sent.BytesOut)
+}
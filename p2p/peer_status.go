@@ -3,6 +3,7 @@ package p2p
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 
@@ -19,6 +20,13 @@ type PeerStatus struct {
 	leader             string
 	threshold          int
 	reqCount           int
+	// collectAll keeps the peers who respond once we have enough of them, so the leader can choose among them
+	collectAll bool
+	// start is the time the join party started, connectedAt and joinedAt are the times we saw the peers connected
+	// and their join requests
+	start       time.Time
+	connectedAt map[peer.ID]time.Time
+	joinedAt    map[peer.ID]time.Time
 }
 
 func (ps *PeerStatus) getLeaderResponse() *messages.JoinPartyLeaderComm {
@@ -50,6 +58,9 @@ func NewPeerStatus(peerNodes []peer.ID, myPeerID peer.ID, leader string, thresho
 		threshold:          threshold,
 		reqCount:           0,
 		leaderResponseLock: &sync.RWMutex{},
+		start:              time.Now(),
+		connectedAt:        make(map[peer.ID]time.Time),
+		joinedAt:           make(map[peer.ID]time.Time),
 	}
 	return peerStatus
 }
@@ -82,6 +93,7 @@ func (ps *PeerStatus) updatePeer(peerNode peer.ID) (bool, error) {
 	if !ok {
 		return false, errors.New("key not found")
 	}
+	ps.peerJoined(peerNode)
 
 	if ps.leader == "NONE" {
 		if !val {
@@ -92,13 +104,13 @@ func (ps *PeerStatus) updatePeer(peerNode peer.ID) (bool, error) {
 	}
 
 	// we already have enough participants
-	if ps.reqCount >= ps.threshold {
+	if ps.reqCount >= ps.threshold && !ps.collectAll {
 		return false, nil
 	}
 	if !val {
 		ps.peersResponse[peerNode] = true
 		ps.reqCount++
-		if ps.reqCount >= ps.threshold {
+		if ps.reqCount == ps.threshold {
 			return true, nil
 		}
 	}
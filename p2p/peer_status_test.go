@@ -68,3 +68,23 @@ func (s *PeerStatusTestSuite) TestPeerStatus(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(ret, Equals, false)
 }
+
+func (s *PeerStatusTestSuite) TestPeerStatusCollectAll(c *C) {
+	peers := generateRandomPeers(c, 5)
+	peerStatus := NewPeerStatus(peers, peers[0], peers[0].String(), 2)
+	peerStatus.collectAll = true
+
+	ret, err := peerStatus.updatePeer(peers[1])
+	c.Assert(err, IsNil)
+	c.Assert(ret, Equals, false)
+	ret, err = peerStatus.updatePeer(peers[2])
+	c.Assert(err, IsNil)
+	c.Assert(ret, Equals, true)
+	// we keep the peers who respond once we have enough of them, but only notify once
+	ret, err = peerStatus.updatePeer(peers[3])
+	c.Assert(err, IsNil)
+	c.Assert(ret, Equals, false)
+	online, offline := peerStatus.getPeersStatus()
+	c.Assert(online, HasLen, 3)
+	c.Assert(offline, DeepEquals, []peer.ID{peers[4]})
+}
@@ -0,0 +1,104 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+// PeerStoreConfig defines how long the peer addresses we persist stay valid after a restart
+type PeerStoreConfig struct {
+	// TTL is how long we keep the addresses of the peers we are connected to when we stop, the addresses of
+	// the other peers keep the peerstore TTL
+	TTL time.Duration
+}
+
+// DefaultPeerStoreConfig return the default peer store configuration
+func DefaultPeerStoreConfig() PeerStoreConfig {
+	return PeerStoreConfig{
+		TTL: time.Hour * 24,
+	}
+}
+
+// PeerRecord is the addresses of a peer we persist, and the time they expire
+type PeerRecord struct {
+	Addrs  []string  `json:"addrs"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// PeerRecordStore persists the peerstore across the restarts
+type PeerRecordStore interface {
+	SavePeerRecords(records map[peer.ID]PeerRecord) error
+	RetrievePeerRecords() (map[peer.ID]PeerRecord, error)
+}
+
+// loadPeerStore add the addresses we persisted to the peerstore with the TTL they have left, the expired
+// records are dropped
+func (c *Communication) loadPeerStore() {
+	records, err := c.peerRecordStore.RetrievePeerRecords()
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("fail to load the persisted peerstore, discover the peers from scratch")
+		return
+	}
+	now := time.Now()
+	c.peerRecords = make(map[peer.ID]PeerRecord, len(records))
+	for pID, record := range records {
+		ttl := record.Expiry.Sub(now)
+		if ttl <= 0 || pID == c.host.ID() {
+			continue
+		}
+		addrs := make([]maddr.Multiaddr, 0, len(record.Addrs))
+		for _, el := range record.Addrs {
+			addr, err := maddr.NewMultiaddr(el)
+			if err != nil {
+				c.logger.Warn().Err(err).Msgf("invalid persisted address(%s) of peer %s", el, pID)
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+		if len(addrs) == 0 {
+			continue
+		}
+		c.host.Peerstore().AddAddrs(pID, addrs, ttl)
+		c.peerRecords[pID] = record
+	}
+	c.logger.Info().Msgf("load %d peers from the persisted peerstore", len(c.peerRecords))
+}
+
+// savePeerStore persist the addresses in the peerstore, the peers we are connected to are kept for the
+// configured TTL, the others keep the expiry we loaded or the default address TTL
+func (c *Communication) savePeerStore() {
+	now := time.Now()
+	ps := c.host.Peerstore()
+	records := make(map[peer.ID]PeerRecord)
+	for _, pID := range ps.PeersWithAddrs() {
+		if pID == c.host.ID() {
+			continue
+		}
+		addrs := ps.Addrs(pID)
+		if len(addrs) == 0 {
+			continue
+		}
+		record := PeerRecord{
+			Addrs:  make([]string, len(addrs)),
+			Expiry: now.Add(peerstore.AddressTTL),
+		}
+		for i, el := range addrs {
+			record.Addrs[i] = el.String()
+		}
+		if c.host.Network().Connectedness(pID) == network.Connected {
+			record.Expiry = now.Add(c.peerStoreConf.TTL)
+		} else if loaded, ok := c.peerRecords[pID]; ok && loaded.Expiry.After(record.Expiry) {
+			record.Expiry = loaded.Expiry
+		}
+		records[pID] = record
+	}
+	if err := c.peerRecordStore.SavePeerRecords(records); err != nil {
+		c.logger.Error().Err(err).Msg("fail to persist the peerstore")
+		return
+	}
+	c.logger.Info().Msgf("persist %d peers of the peerstore", len(records))
+}
@@ -0,0 +1,78 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type memPeerRecordStore struct {
+	saved map[peer.ID]PeerRecord
+}
+
+func (s *memPeerRecordStore) SavePeerRecords(records map[peer.ID]PeerRecord) error {
+	s.saved = records
+	return nil
+}
+
+func (s *memPeerRecordStore) RetrievePeerRecords() (map[peer.ID]PeerRecord, error) {
+	if s.saved == nil {
+		return nil, errors.New("nothing saved")
+	}
+	return s.saved, nil
+}
+
+type PeerStoreTestSuite struct{}
+
+var _ = Suite(&PeerStoreTestSuite{})
+
+func (PeerStoreTestSuite) TestPersistPeerStore(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2320/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2320, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+
+	expired, err := peer.Decode(latencyTestPeers[0])
+	c.Assert(err, IsNil)
+	store := &memPeerRecordStore{
+		saved: map[peer.ID]PeerRecord{
+			expired: {Addrs: []string{"/ip4/192.168.0.1/tcp/6668"}, Expiry: time.Now().Add(-time.Minute)},
+		},
+	}
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2321, "",
+		WithPeerStore(PeerStoreConfig{TTL: time.Hour}, store))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	// the expired record is not loaded
+	c.Assert(comm2.host.Peerstore().Addrs(expired), HasLen, 0)
+	c.Assert(comm2.Stop(), IsNil)
+
+	record, ok := store.saved[comm.host.ID()]
+	c.Assert(ok, Equals, true)
+	c.Assert(record.Addrs, Not(HasLen), 0)
+	// the connected peer is kept for the configured TTL
+	c.Assert(record.Expiry.After(time.Now().Add(time.Minute*59)), Equals, true)
+	_, ok = store.saved[expired]
+	c.Assert(ok, Equals, false)
+	_, ok = store.saved[comm2.host.ID()]
+	c.Assert(ok, Equals, false)
+
+	// we know the address of the peer as soon as we restart, without any bootstrap peer
+	comm3, err := NewCommunication("commTest", nil, 2322, "", WithPeerStore(PeerStoreConfig{TTL: time.Hour}, store))
+	c.Assert(err, IsNil)
+	c.Assert(comm3.Start(sk), IsNil)
+	defer comm3.Stop()
+	c.Assert(comm3.host.Peerstore().Addrs(comm.host.ID()), Not(HasLen), 0)
+}
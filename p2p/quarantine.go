@@ -0,0 +1,115 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// QuarantineConfig defines when the peers the ceremonies blame are quarantined, a quarantined peer is banned and
+// left out of the signers until its cooldown is over or the operator unbans it
+type QuarantineConfig struct {
+	// Threshold is the number of the ceremonies the peer is blamed in within the window before it is quarantined,
+	// 0 disables the quarantine
+	Threshold int
+	// Window is how far back the blames of the peer are counted
+	Window time.Duration
+	// Cooldown is how long the peer stays quarantined
+	Cooldown time.Duration
+}
+
+// DefaultQuarantineConfig return the default quarantine configuration, the quarantine is disabled by default
+func DefaultQuarantineConfig() QuarantineConfig {
+	return QuarantineConfig{
+		Window:   time.Hour,
+		Cooldown: time.Hour,
+	}
+}
+
+// Enabled return true if the blamed peers are quarantined
+func (cfg QuarantineConfig) Enabled() bool {
+	return cfg.Threshold > 0
+}
+
+// Validate the quarantine configuration
+func (cfg QuarantineConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.Window <= 0 {
+		return errors.New("the quarantine window must be positive")
+	}
+	if cfg.Cooldown <= 0 {
+		return errors.New("the quarantine cooldown must be positive")
+	}
+	return nil
+}
+
+// RecordBlamed count the ceremony the peer is blamed in, it return true and until when once the peer is blamed in
+// enough of them within the window to be quarantined. The blames of a quarantined peer are not counted
+func (r *Reputation) RecordBlamed(pID peer.ID) (time.Time, bool) {
+	cfg := r.cfg.Quarantine
+	if !cfg.Enabled() {
+		return time.Time{}, false
+	}
+	now := r.now()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if until, ok := r.quarantined[pID]; ok && now.Before(until) {
+		return until, false
+	}
+	blamed := []time.Time{now}
+	for _, el := range r.blamed[pID] {
+		if now.Sub(el) < cfg.Window {
+			blamed = append(blamed, el)
+		}
+	}
+	if len(blamed) < cfg.Threshold {
+		r.blamed[pID] = blamed
+		return time.Time{}, false
+	}
+	delete(r.blamed, pID)
+	until := now.Add(cfg.Cooldown)
+	r.quarantined[pID] = until
+	return until, true
+}
+
+// Quarantined return the quarantined peers and until when, the expired quarantines are dropped
+func (r *Reputation) Quarantined() map[peer.ID]time.Time {
+	now := r.now()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ret := make(map[peer.ID]time.Time, len(r.quarantined))
+	for k, v := range r.quarantined {
+		if !now.Before(v) {
+			delete(r.quarantined, k)
+			continue
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+// QuarantineBlamed count the ceremony the peers are blamed in, the peers that are blamed too often are quarantined,
+// their connections are closed at once. It return the peers it quarantined
+func (c *Communication) QuarantineBlamed(pIDs []peer.ID) []peer.ID {
+	var quarantined []peer.ID
+	for _, pID := range pIDs {
+		if pID == c.host.ID() {
+			continue
+		}
+		until, ok := c.reputation.RecordBlamed(pID)
+		if !ok {
+			continue
+		}
+		quarantined = append(quarantined, pID)
+		c.logger.Warn().Msgf("peer %s is blamed in too many ceremonies, it is quarantined until %s", pID, until)
+		c.emitPeerEvent(PeerBanned, pID, "", fmt.Errorf("quarantined until %s", until))
+		if err := c.host.Network().ClosePeer(pID); err != nil {
+			c.logger.Error().Err(err).Msgf("fail to close the connection to peer %s", pID)
+		}
+	}
+	return quarantined
+}
@@ -0,0 +1,74 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	. "gopkg.in/check.v1"
+)
+
+type QuarantineTestSuite struct {
+	peers []peer.ID
+}
+
+var _ = Suite(&QuarantineTestSuite{})
+
+func (s *QuarantineTestSuite) SetUpSuite(c *C) {
+	s.peers = nil
+	for _, el := range latencyTestPeers {
+		p, err := peer.Decode(el)
+		c.Assert(err, IsNil)
+		s.peers = append(s.peers, p)
+	}
+}
+
+func (s *QuarantineTestSuite) TestValidate(c *C) {
+	c.Assert(DefaultQuarantineConfig().Validate(), IsNil)
+	c.Assert(QuarantineConfig{Threshold: 3, Window: time.Hour, Cooldown: time.Hour}.Validate(), IsNil)
+	c.Assert(QuarantineConfig{Threshold: 3, Cooldown: time.Hour}.Validate(), NotNil)
+	c.Assert(QuarantineConfig{Threshold: 3, Window: time.Hour}.Validate(), NotNil)
+}
+
+func (s *QuarantineTestSuite) TestRecordBlamed(c *C) {
+	now := time.Now()
+	// the quarantine is disabled by default
+	r := NewReputation(DefaultReputationConfig())
+	for i := 0; i < 5; i++ {
+		_, ok := r.RecordBlamed(s.peers[0])
+		c.Assert(ok, Equals, false)
+	}
+	c.Assert(r.Banned(s.peers[0]), Equals, false)
+
+	r = NewReputation(ReputationConfig{Quarantine: QuarantineConfig{Threshold: 2, Window: time.Hour, Cooldown: time.Minute}})
+	r.now = func() time.Time { return now }
+	_, ok := r.RecordBlamed(s.peers[0])
+	c.Assert(ok, Equals, false)
+	// the blames out of the window are not counted
+	now = now.Add(time.Hour)
+	_, ok = r.RecordBlamed(s.peers[0])
+	c.Assert(ok, Equals, false)
+	c.Assert(r.Banned(s.peers[0]), Equals, false)
+	until, ok := r.RecordBlamed(s.peers[0])
+	c.Assert(ok, Equals, true)
+	c.Assert(until.Equal(now.Add(time.Minute)), Equals, true)
+	c.Assert(r.Banned(s.peers[0]), Equals, true)
+	c.Assert(r.Quarantined(), HasLen, 1)
+	// a quarantined peer is not quarantined again
+	_, ok = r.RecordBlamed(s.peers[0])
+	c.Assert(ok, Equals, false)
+
+	// the quarantine is over once the cooldown is
+	now = now.Add(time.Minute)
+	c.Assert(r.Banned(s.peers[0]), Equals, false)
+	c.Assert(r.Quarantined(), HasLen, 0)
+
+	// the operator lifts the quarantine, the blames are forgotten
+	_, ok = r.RecordBlamed(s.peers[1])
+	c.Assert(ok, Equals, false)
+	_, ok = r.RecordBlamed(s.peers[1])
+	c.Assert(ok, Equals, true)
+	c.Assert(r.Unban(s.peers[1]), Equals, true)
+	c.Assert(r.Banned(s.peers[1]), Equals, false)
+	_, ok = r.RecordBlamed(s.peers[1])
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,218 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// RateLimitConfig defines the limits we apply to the inbound tss streams
+type RateLimitConfig struct {
+	// PerPeerRate is the number of streams per second a single peer can open, 0 disables the per peer limit
+	PerPeerRate float64
+	// PerPeerBurst is the maximum number of streams a single peer can open in a burst
+	PerPeerBurst int
+	// GlobalRate is the number of streams per second all the peers together can open, 0 disables the global limit
+	GlobalRate float64
+	// GlobalBurst is the maximum number of streams all the peers together can open in a burst
+	GlobalBurst int
+	// BanThreshold is the number of rejected streams within BanWindow before the peer get banned, 0 disables the ban
+	BanThreshold int
+	// BanWindow is the period we count the rejected streams in
+	BanWindow time.Duration
+	// BanDuration defines how long the peer will be banned
+	BanDuration time.Duration
+}
+
+// DefaultRateLimitConfig return the default rate limit configuration, it is generous enough for a
+// keysign batch in a large committee while still stopping a peer that floods us with streams
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		PerPeerRate:  50,
+		PerPeerBurst: 200,
+		GlobalRate:   500,
+		GlobalBurst:  2000,
+		BanThreshold: 100,
+		BanWindow:    time.Minute,
+		BanDuration:  time.Minute * 10,
+	}
+}
+
+// Enabled return true when at least one of the limits is set
+func (cfg RateLimitConfig) Enabled() bool {
+	return cfg.PerPeerRate > 0 || cfg.GlobalRate > 0
+}
+
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	capacity := float64(burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     now,
+	}
+}
+
+func (tb *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(tb.last).Seconds()
+	if elapsed > 0 {
+		tb.tokens += elapsed * tb.rate
+		if tb.tokens > tb.capacity {
+			tb.tokens = tb.capacity
+		}
+		tb.last = now
+	}
+}
+
+func (tb *tokenBucket) allow(now time.Time) bool {
+	tb.refill(now)
+	if tb.tokens < 1 {
+		return false
+	}
+	tb.tokens--
+	return true
+}
+
+// rateLimitIdleTimeout is how long we keep the limit of a peer that opens no stream, the limit of a banned peer is
+// kept until its ban is over
+const rateLimitIdleTimeout = time.Minute * 10
+
+type peerLimit struct {
+	bucket      *tokenBucket
+	rejected    int
+	windowStart time.Time
+	bannedUntil time.Time
+	lastSeen    time.Time
+}
+
+// StreamRateLimiter apply the per peer and global token bucket to the inbound streams,
+// peers that keep exceeding the limit get banned temporarily
+type StreamRateLimiter struct {
+	cfg       RateLimitConfig
+	lock      *sync.Mutex
+	global    *tokenBucket
+	peers     map[peer.ID]*peerLimit
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewStreamRateLimiter create a new instance of StreamRateLimiter
+func NewStreamRateLimiter(cfg RateLimitConfig) *StreamRateLimiter {
+	rl := &StreamRateLimiter{
+		cfg:   cfg,
+		lock:  &sync.Mutex{},
+		peers: make(map[peer.ID]*peerLimit),
+		now:   time.Now,
+	}
+	if cfg.GlobalRate > 0 {
+		rl.global = newTokenBucket(cfg.GlobalRate, cfg.GlobalBurst, rl.now())
+	}
+	return rl
+}
+
+// Allow check whether we accept a new stream from the given peer, the second return value
+// indicates the peer has just been banned
+func (rl *StreamRateLimiter) Allow(remotePeer peer.ID) (bool, bool) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	now := rl.now()
+	rl.sweep(now)
+	pl, ok := rl.peers[remotePeer]
+	if !ok {
+		pl = &peerLimit{windowStart: now}
+		if rl.cfg.PerPeerRate > 0 {
+			pl.bucket = newTokenBucket(rl.cfg.PerPeerRate, rl.cfg.PerPeerBurst, now)
+		}
+		rl.peers[remotePeer] = pl
+	}
+	pl.lastSeen = now
+	if now.Before(pl.bannedUntil) {
+		return false, false
+	}
+	if pl.bucket != nil && !pl.bucket.allow(now) {
+		return false, rl.recordRejection(pl, now)
+	}
+	if rl.global != nil && !rl.global.allow(now) {
+		// the global limit is not the fault of this peer, so we do not count it towards the ban
+		return false, false
+	}
+	return true, false
+}
+
+// sweep drop the limits of the peers that have been idle for a while and are not banned, the limit of a peer that
+// comes back starts with a full bucket, which it would have refilled by then anyway
+func (rl *StreamRateLimiter) sweep(now time.Time) {
+	idle := rateLimitIdleTimeout
+	if rl.cfg.BanWindow > idle {
+		idle = rl.cfg.BanWindow
+	}
+	if now.Sub(rl.lastSweep) < idle {
+		return
+	}
+	rl.lastSweep = now
+	for p, pl := range rl.peers {
+		if now.Sub(pl.lastSeen) >= idle && !now.Before(pl.bannedUntil) {
+			delete(rl.peers, p)
+		}
+	}
+}
+
+func (rl *StreamRateLimiter) recordRejection(pl *peerLimit, now time.Time) bool {
+	if rl.cfg.BanThreshold <= 0 {
+		return false
+	}
+	if now.Sub(pl.windowStart) > rl.cfg.BanWindow {
+		pl.windowStart = now
+		pl.rejected = 0
+	}
+	pl.rejected++
+	if pl.rejected < rl.cfg.BanThreshold {
+		return false
+	}
+	pl.rejected = 0
+	pl.bannedUntil = now.Add(rl.cfg.BanDuration)
+	return true
+}
+
+// IsBanned return true if the given peer is banned at the moment
+func (rl *StreamRateLimiter) IsBanned(remotePeer peer.ID) bool {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	pl, ok := rl.peers[remotePeer]
+	if !ok {
+		return false
+	}
+	return rl.now().Before(pl.bannedUntil)
+}
+
+// Unban lift the ban of the given peer
+func (rl *StreamRateLimiter) Unban(remotePeer peer.ID) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	delete(rl.peers, remotePeer)
+}
+
+// BannedPeers return the peers that are banned at the moment
+func (rl *StreamRateLimiter) BannedPeers() []peer.ID {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	now := rl.now()
+	var banned []peer.ID
+	for p, pl := range rl.peers {
+		if now.Before(pl.bannedUntil) {
+			banned = append(banned, p)
+		}
+	}
+	return banned
+}
@@ -0,0 +1,139 @@
+package p2p
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RateLimiterTestSuite struct{}
+
+var _ = Suite(&RateLimiterTestSuite{})
+
+func (RateLimiterTestSuite) TestPerPeerLimit(c *C) {
+	peers := generateRandomPeers(c, 2)
+	now := time.Now()
+	rl := NewStreamRateLimiter(RateLimitConfig{
+		PerPeerRate:  1,
+		PerPeerBurst: 2,
+	})
+	rl.now = func() time.Time { return now }
+
+	allowed, _ := rl.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+	allowed, _ = rl.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+	allowed, _ = rl.Allow(peers[0])
+	c.Assert(allowed, Equals, false)
+	// other peers are not affected
+	allowed, _ = rl.Allow(peers[1])
+	c.Assert(allowed, Equals, true)
+	// the bucket refills over time
+	now = now.Add(time.Second)
+	allowed, _ = rl.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+	allowed, _ = rl.Allow(peers[0])
+	c.Assert(allowed, Equals, false)
+}
+
+func (RateLimiterTestSuite) TestGlobalLimit(c *C) {
+	peers := generateRandomPeers(c, 3)
+	now := time.Now()
+	rl := NewStreamRateLimiter(RateLimitConfig{
+		GlobalRate:  1,
+		GlobalBurst: 2,
+	})
+	rl.now = func() time.Time { return now }
+	allowed, _ := rl.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+	allowed, _ = rl.Allow(peers[1])
+	c.Assert(allowed, Equals, true)
+	allowed, banned := rl.Allow(peers[2])
+	c.Assert(allowed, Equals, false)
+	c.Assert(banned, Equals, false)
+}
+
+func (RateLimiterTestSuite) TestBan(c *C) {
+	peers := generateRandomPeers(c, 1)
+	now := time.Now()
+	rl := NewStreamRateLimiter(RateLimitConfig{
+		PerPeerRate:  1,
+		PerPeerBurst: 1,
+		BanThreshold: 3,
+		BanWindow:    time.Minute,
+		BanDuration:  time.Minute * 10,
+	})
+	rl.now = func() time.Time { return now }
+	allowed, _ := rl.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+	_, banned := rl.Allow(peers[0])
+	c.Assert(banned, Equals, false)
+	_, banned = rl.Allow(peers[0])
+	c.Assert(banned, Equals, false)
+	_, banned = rl.Allow(peers[0])
+	c.Assert(banned, Equals, true)
+	c.Assert(rl.IsBanned(peers[0]), Equals, true)
+	c.Assert(rl.BannedPeers(), HasLen, 1)
+
+	// even the bucket is refilled, the banned peer is still rejected
+	now = now.Add(time.Minute)
+	allowed, _ = rl.Allow(peers[0])
+	c.Assert(allowed, Equals, false)
+
+	now = now.Add(time.Minute * 10)
+	c.Assert(rl.IsBanned(peers[0]), Equals, false)
+	allowed, _ = rl.Allow(peers[0])
+	c.Assert(allowed, Equals, true)
+
+	rl.Unban(peers[0])
+	c.Assert(rl.BannedPeers(), HasLen, 0)
+}
+
+func (RateLimiterTestSuite) TestConfig(c *C) {
+	c.Assert(RateLimitConfig{}.Enabled(), Equals, false)
+	c.Assert(DefaultRateLimitConfig().Enabled(), Equals, true)
+	comm, err := NewCommunication("rendezvous", nil, 6668, "", WithRateLimit(RateLimitConfig{}))
+	c.Assert(err, IsNil)
+	c.Assert(comm.GetRateLimiter(), IsNil)
+	comm, err = NewCommunication("rendezvous", nil, 6668, "", WithRateLimit(DefaultRateLimitConfig()))
+	c.Assert(err, IsNil)
+	c.Assert(comm.GetRateLimiter(), NotNil)
+}
+
+func (RateLimiterTestSuite) TestEvictIdlePeers(c *C) {
+	peers := generateRandomPeers(c, 3)
+	now := time.Now()
+	rl := NewStreamRateLimiter(RateLimitConfig{
+		PerPeerRate:  1,
+		PerPeerBurst: 1,
+		BanThreshold: 1,
+		BanWindow:    time.Minute,
+		BanDuration:  time.Hour,
+	})
+	rl.now = func() time.Time { return now }
+	for _, el := range peers {
+		allowed, _ := rl.Allow(el)
+		c.Assert(allowed, Equals, true)
+	}
+	// the last peer keeps opening streams until it is banned
+	_, banned := rl.Allow(peers[2])
+	c.Assert(banned, Equals, true)
+	c.Assert(rl.peers, HasLen, 3)
+
+	now = now.Add(rateLimitIdleTimeout / 2)
+	allowed, _ := rl.Allow(peers[1])
+	c.Assert(allowed, Equals, true)
+	now = now.Add(rateLimitIdleTimeout / 2)
+	allowed, _ = rl.Allow(peers[1])
+	c.Assert(allowed, Equals, true)
+	// the idle peer is dropped, the banned one is kept until its ban is over
+	c.Assert(rl.peers, HasLen, 2)
+	_, ok := rl.peers[peers[0]]
+	c.Assert(ok, Equals, false)
+	c.Assert(rl.IsBanned(peers[2]), Equals, true)
+
+	now = now.Add(time.Hour)
+	allowed, _ = rl.Allow(peers[1])
+	c.Assert(allowed, Equals, true)
+	c.Assert(rl.peers, HasLen, 1)
+}
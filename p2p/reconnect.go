@@ -0,0 +1,151 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ReconnectConfig defines how we keep the connections to the bootstrap peers and the cached committee members
+type ReconnectConfig struct {
+	// Interval is the delay between two checks of the connections, 0 disables the reconnection
+	Interval time.Duration
+	// MinPeers is the number of the bootstrap and committee peers we should stay connected to, we are isolated
+	// if we fail to reconnect to that many
+	MinPeers int
+	// MaxBackoff caps the delay between two reconnections while we are isolated
+	MaxBackoff time.Duration
+}
+
+// DefaultReconnectConfig return the default reconnection configuration
+func DefaultReconnectConfig() ReconnectConfig {
+	return ReconnectConfig{
+		Interval:   time.Second * 30,
+		MinPeers:   1,
+		MaxBackoff: time.Minute * 5,
+	}
+}
+
+// Enabled return true if we should check the connections in the background
+func (cfg ReconnectConfig) Enabled() bool {
+	return cfg.Interval > 0
+}
+
+// backoff return the delay before the next check after the given number of failed reconnections in a row
+func (cfg ReconnectConfig) backoff(failures int) time.Duration {
+	delay := cfg.Interval
+	for i := 0; i < failures; i++ {
+		delay *= 2
+		if cfg.MaxBackoff > 0 && delay >= cfg.MaxBackoff {
+			return cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// reconnectTargets return the bootstrap peers and the cached committee members without us, the addresses of
+// the same peer are merged
+func (c *Communication) reconnectTargets() []peer.AddrInfo {
+	bootstrapPeers := c.getBootstrapPeers()
+	addrs := make([]Multiaddr, 0, len(bootstrapPeers)+len(c.coldStart.Peers))
+	addrs = append(addrs, bootstrapPeers...)
+	addrs = append(addrs, c.coldStart.Peers...)
+	infos, err := peer.AddrInfosFromP2pAddrs(addrs...)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("fail to parse the addresses of the peers to reconnect to")
+		return nil
+	}
+	targets := make([]peer.AddrInfo, 0, len(infos))
+	for _, el := range infos {
+		if el.ID == c.host.ID() {
+			continue
+		}
+		targets = append(targets, el)
+	}
+	return targets
+}
+
+// reconnect dial the targets we are not connected to if we are connected to less than minPeers of them, it
+// return the number of the targets we are connected to
+func (c *Communication) reconnect(targets []peer.AddrInfo, minPeers int) int {
+	var disconnected []peer.AddrInfo
+	for _, el := range targets {
+		if c.host.Network().Connectedness(el.ID) != network.Connected {
+			disconnected = append(disconnected, el)
+		}
+	}
+	connected := len(targets) - len(disconnected)
+	if connected >= minPeers {
+		return connected
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutConnecting)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	lock := &sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, el := range disconnected {
+		wg.Add(1)
+		go func(pi peer.AddrInfo) {
+			defer wg.Done()
+			if err := c.host.Connect(ctx, pi); err != nil {
+				c.logger.Debug().Err(err).Msgf("fail to reconnect to peer %s", pi.ID)
+				return
+			}
+			lock.Lock()
+			connected++
+			lock.Unlock()
+		}(el)
+	}
+	wg.Wait()
+	return connected
+}
+
+// maintainConnections keep us connected to at least MinPeers of the bootstrap peers and the cached committee
+// members, we back off while we fail to reconnect and report ourselves as isolated. The targets are read on each
+// check, as the bootstrap peers can be replaced while we run
+func (c *Communication) maintainConnections() {
+	defer c.wg.Done()
+	if len(c.reconnectTargets()) == 0 {
+		c.logger.Info().Msg("no bootstrap peer or cached committee member, wait for the bootstrap peers to reconnect")
+	}
+	failures := 0
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-time.After(c.reconnectConf.backoff(failures)):
+		}
+		targets := c.reconnectTargets()
+		if len(targets) == 0 {
+			continue
+		}
+		minPeers := c.reconnectConf.MinPeers
+		if minPeers <= 0 {
+			minPeers = 1
+		}
+		if minPeers > len(targets) {
+			minPeers = len(targets)
+		}
+		connected := c.reconnect(targets, minPeers)
+		if connected >= minPeers {
+			if failures > 0 {
+				c.logger.Info().Msgf("reconnected to %d peers after %d failed attempts", connected, failures)
+			}
+			failures = 0
+			c.discovery.isolate(false)
+			continue
+		}
+		failures++
+		c.discovery.isolate(true)
+		c.logger.Warn().Msgf("only connected to %d of the %d peers we need, retry in %s", connected, minPeers, c.reconnectConf.backoff(failures))
+	}
+}
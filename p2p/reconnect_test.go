@@ -0,0 +1,66 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type ReconnectTestSuite struct{}
+
+var _ = Suite(&ReconnectTestSuite{})
+
+func (ReconnectTestSuite) TestBackoff(c *C) {
+	cfg := ReconnectConfig{Interval: time.Second, MaxBackoff: time.Second * 5}
+	c.Assert(cfg.backoff(0), Equals, time.Second)
+	c.Assert(cfg.backoff(2), Equals, time.Second*4)
+	c.Assert(cfg.backoff(10), Equals, time.Second*5)
+	c.Assert(ReconnectConfig{}.Enabled(), Equals, false)
+}
+
+func (ReconnectTestSuite) TestReconnect(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2330/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2330, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2331, "",
+		WithReconnect(ReconnectConfig{Interval: time.Millisecond * 100, MinPeers: 1, MaxBackoff: time.Millisecond * 200}))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	// we reconnect to the bootstrap peer once we lose the connection
+	c.Assert(comm2.host.Network().ClosePeer(comm.host.ID()), IsNil)
+	c.Assert(waitFor(func() bool {
+		return comm2.host.Network().Connectedness(comm.host.ID()) == network.Connected
+	}), Equals, true)
+	c.Assert(comm2.GetDiscoveryState().Isolated, Equals, false)
+
+	// we are isolated once the bootstrap peer is gone
+	c.Assert(comm.Stop(), IsNil)
+	c.Assert(waitFor(func() bool {
+		return comm2.GetDiscoveryState().Isolated
+	}), Equals, true)
+}
+
+func waitFor(cond func() bool) bool {
+	deadline := time.After(time.Second * 10)
+	for !cond() {
+		select {
+		case <-deadline:
+			return false
+		case <-time.After(time.Millisecond * 50):
+		}
+	}
+	return true
+}
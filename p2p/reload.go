@@ -0,0 +1,39 @@
+package p2p
+
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// getBootstrapPeers return the bootstrap peers, they can be replaced while we run
+func (c *Communication) getBootstrapPeers() []Multiaddr {
+	c.bootstrapLock.RLock()
+	defer c.bootstrapLock.RUnlock()
+	return append([]Multiaddr{}, c.bootstrapPeers...)
+}
+
+// SetBootstrapPeers replace the bootstrap peers, the reconnection dials the new ones on its next check and the
+// peers that are no longer listed are left connected
+func (c *Communication) SetBootstrapPeers(addrs []Multiaddr) {
+	c.bootstrapLock.Lock()
+	defer c.bootstrapLock.Unlock()
+	c.bootstrapPeers = append([]Multiaddr{}, addrs...)
+	c.logger.Info().Msgf("the bootstrap peers are set to %v", addrs)
+}
+
+// SetAllowedPeers replace the allowlist of the peers, no peer allows all the peers. We close the connections of
+// the peers that are no longer allowed
+func (c *Communication) SetAllowedPeers(peers []peer.ID) {
+	c.allowedPeers.set(peers)
+	c.logger.Info().Msgf("the allowed peers are set to %v", peers)
+	if c.host == nil {
+		return
+	}
+	for _, el := range c.host.Network().Peers() {
+		if c.allowedPeers.allows(el) {
+			continue
+		}
+		if err := c.host.Network().ClosePeer(el); err != nil {
+			c.logger.Error().Err(err).Msgf("fail to close the connection to peer %s", el)
+		}
+	}
+}
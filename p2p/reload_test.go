@@ -0,0 +1,51 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type ReloadTestSuite struct{}
+
+var _ = Suite(&ReloadTestSuite{})
+
+func (ReloadTestSuite) TestSetPeers(c *C) {
+	ApplyDeadline = false
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2470, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", nil, 2471, "",
+		WithReconnect(ReconnectConfig{Interval: time.Millisecond * 100, MinPeers: 1, MaxBackoff: time.Millisecond * 200}))
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	// the reconnection dials the bootstrap peers we set while we run
+	addr, err := maddr.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/2470/p2p/%s", comm.host.ID()))
+	c.Assert(err, IsNil)
+	comm2.SetBootstrapPeers([]maddr.Multiaddr{addr})
+	c.Assert(comm2.GetConnectivity().BootstrapPeers, Equals, 1)
+	c.Assert(waitFor(func() bool {
+		return comm2.host.Network().Connectedness(comm.host.ID()) == network.Connected
+	}), Equals, true)
+
+	// the peers that are no longer allowed are dropped
+	comm2.SetAllowedPeers([]peer.ID{conversion.GetRandomPeerID()})
+	c.Assert(waitFor(func() bool {
+		return comm2.host.Network().Connectedness(comm.host.ID()) != network.Connected
+	}), Equals, true)
+	c.Assert(comm2.allowedPeers.allows(comm.host.ID()), Equals, false)
+}
@@ -0,0 +1,322 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// ReputationEvent is the behaviour of the peer we score
+type ReputationEvent string
+
+const (
+	// EventDialFailure we fail to open a stream to the peer
+	EventDialFailure ReputationEvent = "dial_failure"
+	// EventTimeout the peer does not take our message or does not take part in the ceremony in time
+	EventTimeout ReputationEvent = "timeout"
+	// EventMalformedMessage the peer sends us a message we cannot decode
+	EventMalformedMessage ReputationEvent = "malformed_message"
+	// EventBlame the peer is blamed by the ceremony for anything other than a timeout
+	EventBlame ReputationEvent = "blame"
+	// EventCeremonySuccess the peer takes part in a successful ceremony
+	EventCeremonySuccess ReputationEvent = "ceremony_success"
+)
+
+// reputationWeights is how much each event adds to the score of the peer
+var reputationWeights = map[ReputationEvent]float64{
+	EventDialFailure:      -1,
+	EventTimeout:          -2,
+	EventMalformedMessage: -5,
+	EventBlame:            -10,
+	EventCeremonySuccess:  1,
+}
+
+const reputationPersistInterval = time.Minute
+
+// ReputationConfig defines how the reputation of the peers decays and when we refuse to connect to them
+type ReputationConfig struct {
+	// HalfLife is how long it takes for an event to lose half of its weight, 0 keeps the events forever
+	HalfLife time.Duration
+	// BanThreshold is the score below which we refuse the connections of the peer, it should be negative,
+	// 0 disables the gating
+	BanThreshold float64
+	// Quarantine bans the peers the ceremonies blame too often
+	Quarantine QuarantineConfig
+}
+
+// DefaultReputationConfig return the default reputation configuration, the gating is disabled by default
+func DefaultReputationConfig() ReputationConfig {
+	return ReputationConfig{
+		HalfLife:   time.Hour * 24,
+		Quarantine: DefaultQuarantineConfig(),
+	}
+}
+
+// GateEnabled return true if we should refuse the connections of the peers with a low score
+func (cfg ReputationConfig) GateEnabled() bool {
+	return cfg.BanThreshold < 0
+}
+
+// PeerReputation is the reputation of a peer, the score is the decayed sum of the weights of its events
+type PeerReputation struct {
+	Score   float64                 `json:"score"`
+	Updated time.Time               `json:"updated"`
+	Events  map[ReputationEvent]int `json:"events"`
+}
+
+// ReputationStore persists the reputation of the peers across the restarts
+type ReputationStore interface {
+	SaveReputation(reputation map[peer.ID]PeerReputation) error
+	RetrieveReputation() (map[peer.ID]PeerReputation, error)
+}
+
+// Reputation tracks the reputation of the peers
+type Reputation struct {
+	cfg   ReputationConfig
+	lock  *sync.RWMutex
+	peers map[peer.ID]PeerReputation
+	dirty bool
+	now   func() time.Time
+	// bans are the peers the operator banned and until when, they are banned no matter their score
+	bans map[peer.ID]time.Time
+	// onBan is called once the score of the peer falls below the ban threshold
+	onBan func(pID peer.ID)
+	// blamed are the times the peers are blamed within the quarantine window
+	blamed map[peer.ID][]time.Time
+	// quarantined are the peers the ceremonies blame too often and until when, they are banned no matter their
+	// score
+	quarantined map[peer.ID]time.Time
+}
+
+// NewReputation create a new instance of Reputation
+func NewReputation(cfg ReputationConfig) *Reputation {
+	return &Reputation{
+		cfg:         cfg,
+		lock:        &sync.RWMutex{},
+		peers:       make(map[peer.ID]PeerReputation),
+		now:         time.Now,
+		bans:        make(map[peer.ID]time.Time),
+		blamed:      make(map[peer.ID][]time.Time),
+		quarantined: make(map[peer.ID]time.Time),
+	}
+}
+
+// decay return the score of the reputation at the given time
+func (r *Reputation) decay(rep PeerReputation, now time.Time) float64 {
+	if r.cfg.HalfLife <= 0 || !now.After(rep.Updated) {
+		return rep.Score
+	}
+	return rep.Score * math.Pow(0.5, float64(now.Sub(rep.Updated))/float64(r.cfg.HalfLife))
+}
+
+// Record add the event to the reputation of the peer
+func (r *Reputation) Record(pID peer.ID, event ReputationEvent) {
+	weight, ok := reputationWeights[event]
+	if !ok {
+		return
+	}
+	now := r.now()
+	r.lock.Lock()
+	rep, ok := r.peers[pID]
+	if !ok {
+		rep.Events = make(map[ReputationEvent]int)
+	}
+	previous := r.decay(rep, now)
+	rep.Score = previous + weight
+	rep.Updated = now
+	rep.Events[event]++
+	r.peers[pID] = rep
+	r.dirty = true
+	onBan := r.onBan
+	r.lock.Unlock()
+	banned := r.cfg.GateEnabled() && rep.Score < r.cfg.BanThreshold && previous >= r.cfg.BanThreshold
+	if banned && onBan != nil {
+		onBan(pID)
+	}
+}
+
+// Score return the current score of the peer, the peers we know nothing about score 0
+func (r *Reputation) Score(pID peer.ID) float64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	rep, ok := r.peers[pID]
+	if !ok {
+		return 0
+	}
+	return r.decay(rep, r.now())
+}
+
+// Scores return the reputation of all the peers we have scored with the decay applied
+func (r *Reputation) Scores() map[peer.ID]PeerReputation {
+	now := r.now()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	ret := make(map[peer.ID]PeerReputation, len(r.peers))
+	for k, v := range r.peers {
+		events := make(map[ReputationEvent]int, len(v.Events))
+		for e, n := range v.Events {
+			events[e] = n
+		}
+		ret[k] = PeerReputation{
+			Score:   r.decay(v, now),
+			Updated: v.Updated,
+			Events:  events,
+		}
+	}
+	return ret
+}
+
+// Banned return true if the operator banned the peer, the peer is quarantined or the score of the peer is below the
+// ban threshold
+func (r *Reputation) Banned(pID peer.ID) bool {
+	now := r.now()
+	r.lock.RLock()
+	until, ok := r.bans[pID]
+	quarantined, isQuarantined := r.quarantined[pID]
+	r.lock.RUnlock()
+	if (ok && now.Before(until)) || (isQuarantined && now.Before(quarantined)) {
+		return true
+	}
+	return r.cfg.GateEnabled() && r.Score(pID) < r.cfg.BanThreshold
+}
+
+// Ban ban the peer until the given time no matter its score, the bans are not persisted
+func (r *Reputation) Ban(pID peer.ID, until time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.bans[pID] = until
+}
+
+// Unban lift the ban of the operator and the quarantine on the peer, the blames of the peer are forgotten, it
+// return false if the peer is neither banned nor quarantined
+func (r *Reputation) Unban(pID peer.ID) bool {
+	now := r.now()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	until, ok := r.bans[pID]
+	quarantined, isQuarantined := r.quarantined[pID]
+	delete(r.bans, pID)
+	delete(r.quarantined, pID)
+	delete(r.blamed, pID)
+	return (ok && now.Before(until)) || (isQuarantined && now.Before(quarantined))
+}
+
+// Bans return the peers the operator banned and until when, the expired bans are dropped
+func (r *Reputation) Bans() map[peer.ID]time.Time {
+	now := r.now()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ret := make(map[peer.ID]time.Time, len(r.bans))
+	for k, v := range r.bans {
+		if !now.Before(v) {
+			delete(r.bans, k)
+			continue
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+// Rank return the peers ordered from the highest score to the lowest, so the signer selection can prefer
+// the peers with a good reputation
+func (r *Reputation) Rank(peers []peer.ID) []peer.ID {
+	scores := make(map[peer.ID]float64, len(peers))
+	for _, el := range peers {
+		scores[el] = r.Score(el)
+	}
+	sorted := make([]peer.ID, len(peers))
+	copy(sorted, peers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return scores[sorted[i]] > scores[sorted[j]]
+	})
+	return sorted
+}
+
+// load replace the reputation with the one we persisted
+func (r *Reputation) load(store ReputationStore) error {
+	peers, err := store.RetrieveReputation()
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for k, v := range peers {
+		if v.Events == nil {
+			v.Events = make(map[ReputationEvent]int)
+		}
+		r.peers[k] = v
+	}
+	return nil
+}
+
+// save persist the reputation if it has changed since the last save
+func (r *Reputation) save(store ReputationStore) error {
+	r.lock.Lock()
+	if !r.dirty {
+		r.lock.Unlock()
+		return nil
+	}
+	r.dirty = false
+	r.lock.Unlock()
+	if err := store.SaveReputation(r.Scores()); err != nil {
+		r.lock.Lock()
+		r.dirty = true
+		r.lock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// persistReputation save the reputation of the peers periodically and once more when we stop
+func (c *Communication) persistReputation() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(reputationPersistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopChan:
+			if err := c.reputation.save(c.reputationStore); err != nil {
+				c.logger.Error().Err(err).Msg("fail to save the reputation of the peers")
+			}
+			return
+		case <-ticker.C:
+			if err := c.reputation.save(c.reputationStore); err != nil {
+				c.logger.Error().Err(err).Msg("fail to save the reputation of the peers")
+			}
+		}
+	}
+}
+
+// GetReputation return the reputation of the peers
+func (c *Communication) GetReputation() *Reputation {
+	return c.reputation
+}
+
+// BanPeer ban the peer for the duration and close the connections to it, the ban is lifted once we restart
+func (c *Communication) BanPeer(pID peer.ID, duration time.Duration) error {
+	if duration <= 0 {
+		return errcode.New(errcode.BadRequest, "the ban duration must be positive")
+	}
+	if pID == c.host.ID() {
+		return errcode.New(errcode.BadRequest, "fail to ban ourselves")
+	}
+	c.reputation.Ban(pID, time.Now().Add(duration))
+	c.logger.Warn().Msgf("the operator bans peer %s for %s", pID, duration)
+	c.emitPeerEvent(PeerBanned, pID, "", errors.New("banned by the operator"))
+	if err := c.host.Network().ClosePeer(pID); err != nil {
+		return fmt.Errorf("fail to close the connection to peer %s: %w", pID, err)
+	}
+	return nil
+}
+
+// UnbanPeer lift the ban of the operator or the quarantine on the peer, it return false if the peer is not banned
+func (c *Communication) UnbanPeer(pID peer.ID) bool {
+	return c.reputation.Unban(pID)
+}
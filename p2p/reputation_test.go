@@ -0,0 +1,138 @@
+package p2p
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+)
+
+type memReputationStore struct {
+	saved map[peer.ID]PeerReputation
+}
+
+func (s *memReputationStore) SaveReputation(reputation map[peer.ID]PeerReputation) error {
+	s.saved = reputation
+	return nil
+}
+
+func (s *memReputationStore) RetrieveReputation() (map[peer.ID]PeerReputation, error) {
+	if s.saved == nil {
+		return nil, errors.New("nothing saved")
+	}
+	return s.saved, nil
+}
+
+type ReputationTestSuite struct {
+	peers []peer.ID
+}
+
+var _ = Suite(&ReputationTestSuite{})
+
+func (s *ReputationTestSuite) SetUpSuite(c *C) {
+	s.peers = nil
+	for _, el := range latencyTestPeers {
+		p, err := peer.Decode(el)
+		c.Assert(err, IsNil)
+		s.peers = append(s.peers, p)
+	}
+}
+
+func (s *ReputationTestSuite) TestScore(c *C) {
+	now := time.Now()
+	r := NewReputation(ReputationConfig{HalfLife: time.Hour, BanThreshold: -8})
+	r.now = func() time.Time { return now }
+	c.Assert(r.Score(s.peers[0]), Equals, float64(0))
+	r.Record(s.peers[0], EventBlame)
+	r.Record(s.peers[1], EventCeremonySuccess)
+	r.Record(s.peers[2], EventTimeout)
+	r.Record(s.peers[2], "unknown")
+	c.Assert(r.Score(s.peers[0]), Equals, float64(-10))
+	c.Assert(r.Banned(s.peers[0]), Equals, true)
+	c.Assert(r.Banned(s.peers[2]), Equals, false)
+	c.Assert(r.Rank(s.peers), DeepEquals, []peer.ID{s.peers[1], s.peers[2], s.peers[0]})
+	c.Assert(r.Scores()[s.peers[2]].Events, DeepEquals, map[ReputationEvent]int{EventTimeout: 1})
+
+	// the score halves every half life
+	now = now.Add(time.Hour)
+	c.Assert(r.Score(s.peers[0]), Equals, float64(-5))
+	c.Assert(r.Banned(s.peers[0]), Equals, false)
+	r.Record(s.peers[0], EventMalformedMessage)
+	c.Assert(r.Score(s.peers[0]), Equals, float64(-10))
+	c.Assert(r.Scores()[s.peers[0]].Events, HasLen, 2)
+
+	// the gating is disabled without a negative threshold
+	r2 := NewReputation(DefaultReputationConfig())
+	r2.Record(s.peers[0], EventBlame)
+	c.Assert(r2.Banned(s.peers[0]), Equals, false)
+}
+
+func (s *ReputationTestSuite) TestBan(c *C) {
+	now := time.Now()
+	// the operator bans the peers even if the gating is disabled
+	r := NewReputation(DefaultReputationConfig())
+	r.now = func() time.Time { return now }
+	r.Ban(s.peers[0], now.Add(time.Hour))
+	c.Assert(r.Banned(s.peers[0]), Equals, true)
+	c.Assert(r.Banned(s.peers[1]), Equals, false)
+	c.Assert(r.Bans(), HasLen, 1)
+	c.Assert(r.Unban(s.peers[1]), Equals, false)
+
+	now = now.Add(time.Hour)
+	c.Assert(r.Banned(s.peers[0]), Equals, false)
+	c.Assert(r.Bans(), HasLen, 0)
+
+	r.Ban(s.peers[1], now.Add(time.Minute))
+	c.Assert(r.Unban(s.peers[1]), Equals, true)
+	c.Assert(r.Banned(s.peers[1]), Equals, false)
+}
+
+func (s *ReputationTestSuite) TestPersist(c *C) {
+	store := &memReputationStore{}
+	r := NewReputation(DefaultReputationConfig())
+	c.Assert(r.load(store), NotNil)
+	c.Assert(r.save(store), IsNil)
+	c.Assert(store.saved, IsNil)
+	r.Record(s.peers[0], EventDialFailure)
+	c.Assert(r.save(store), IsNil)
+	c.Assert(store.saved, HasLen, 1)
+
+	r2 := NewReputation(DefaultReputationConfig())
+	c.Assert(r2.load(store), IsNil)
+	c.Assert(r2.Score(s.peers[0]) < 0, Equals, true)
+	r2.Record(s.peers[0], EventDialFailure)
+	c.Assert(r2.Scores()[s.peers[0]].Events[EventDialFailure], Equals, 2)
+}
+
+func (s *ReputationTestSuite) TestGater(c *C) {
+	ApplyDeadline = false
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2290, "",
+		WithReputation(ReputationConfig{HalfLife: time.Hour, BanThreshold: -5}, nil))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", nil, 2291, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	addr, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/2290")
+	c.Assert(err, IsNil)
+	comm.GetReputation().Record(comm2.host.ID(), EventBlame)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	// the banned peer cannot connect to us
+	err = comm2.host.Connect(ctx, peer.AddrInfo{ID: comm.host.ID(), Addrs: []maddr.Multiaddr{addr}})
+	c.Assert(err, NotNil)
+	_, err = comm.connectToOnePeer(ctx, comm2.host.ID(), "")
+	c.Assert(err, NotNil)
+}
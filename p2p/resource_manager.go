@@ -0,0 +1,81 @@
+package p2p
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// ResourceLimitConfig defines the resources the p2p host is allowed to use, so that a runaway
+// peer cannot exhaust the file descriptors or the memory of the node
+type ResourceLimitConfig struct {
+	// MaxConns is the maximum number of connections the host can have, 0 keeps the libp2p default
+	MaxConns int
+	// MaxConnsPerPeer is the maximum number of connections we allow with a single peer, 0 keeps the libp2p default
+	MaxConnsPerPeer int
+	// MaxStreamsPerPeer is the maximum number of streams a single peer can have, 0 keeps the libp2p default
+	MaxStreamsPerPeer int
+	// MaxMemory is the maximum memory in bytes the host can reserve, 0 keeps the libp2p default
+	MaxMemory int64
+	// MaxFD is the maximum number of file descriptors the host can use, 0 keeps the libp2p default
+	MaxFD int
+}
+
+// Enabled return true when at least one of the limits is set
+func (cfg ResourceLimitConfig) Enabled() bool {
+	return cfg.MaxConns > 0 || cfg.MaxConnsPerPeer > 0 || cfg.MaxStreamsPerPeer > 0 || cfg.MaxMemory > 0 || cfg.MaxFD > 0
+}
+
+// limitConfig apply our limits on top of the libp2p default limits which scale with the resources of the machine
+func (cfg ResourceLimitConfig) limitConfig() rcmgr.LimitConfig {
+	limits := rcmgr.DefaultLimits.AutoScale()
+	if cfg.MaxConns > 0 {
+		limits.System.Conns = cfg.MaxConns
+		limits.System.ConnsInbound = cfg.MaxConns
+		limits.System.ConnsOutbound = cfg.MaxConns
+		limits.Transient.Conns = minInt(limits.Transient.Conns, cfg.MaxConns)
+		limits.Transient.ConnsInbound = minInt(limits.Transient.ConnsInbound, cfg.MaxConns)
+		limits.Transient.ConnsOutbound = minInt(limits.Transient.ConnsOutbound, cfg.MaxConns)
+	}
+	if cfg.MaxConnsPerPeer > 0 {
+		limits.PeerDefault.Conns = cfg.MaxConnsPerPeer
+		limits.PeerDefault.ConnsInbound = cfg.MaxConnsPerPeer
+		limits.PeerDefault.ConnsOutbound = cfg.MaxConnsPerPeer
+	}
+	if cfg.MaxStreamsPerPeer > 0 {
+		limits.PeerDefault.Streams = cfg.MaxStreamsPerPeer
+		limits.PeerDefault.StreamsInbound = cfg.MaxStreamsPerPeer
+		limits.PeerDefault.StreamsOutbound = cfg.MaxStreamsPerPeer
+		limits.ProtocolPeerDefault.Streams = minInt(limits.ProtocolPeerDefault.Streams, cfg.MaxStreamsPerPeer)
+		limits.ProtocolPeerDefault.StreamsInbound = minInt(limits.ProtocolPeerDefault.StreamsInbound, cfg.MaxStreamsPerPeer)
+		limits.ProtocolPeerDefault.StreamsOutbound = minInt(limits.ProtocolPeerDefault.StreamsOutbound, cfg.MaxStreamsPerPeer)
+	}
+	if cfg.MaxMemory > 0 {
+		limits.System.Memory = cfg.MaxMemory
+		if limits.Transient.Memory > cfg.MaxMemory {
+			limits.Transient.Memory = cfg.MaxMemory
+		}
+	}
+	if cfg.MaxFD > 0 {
+		limits.System.FD = cfg.MaxFD
+		limits.Transient.FD = minInt(limits.Transient.FD, cfg.MaxFD)
+	}
+	return limits
+}
+
+// newResourceManager create the libp2p resource manager with the given limits
+func newResourceManager(cfg ResourceLimitConfig) (network.ResourceManager, error) {
+	rm, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(cfg.limitConfig()))
+	if err != nil {
+		return nil, fmt.Errorf("fail to create resource manager: %w", err)
+	}
+	return rm, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,36 @@
+package p2p
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ResourceManagerTestSuite struct{}
+
+var _ = Suite(&ResourceManagerTestSuite{})
+
+func (ResourceManagerTestSuite) TestLimitConfig(c *C) {
+	c.Assert(ResourceLimitConfig{}.Enabled(), Equals, false)
+	cfg := ResourceLimitConfig{
+		MaxConns:          64,
+		MaxConnsPerPeer:   4,
+		MaxStreamsPerPeer: 32,
+		MaxMemory:         1 << 28,
+		MaxFD:             128,
+	}
+	c.Assert(cfg.Enabled(), Equals, true)
+	limits := cfg.limitConfig()
+	c.Assert(limits.System.Conns, Equals, 64)
+	c.Assert(limits.System.ConnsInbound, Equals, 64)
+	c.Assert(limits.Transient.Conns <= 64, Equals, true)
+	c.Assert(limits.PeerDefault.Conns, Equals, 4)
+	c.Assert(limits.PeerDefault.Streams, Equals, 32)
+	c.Assert(limits.PeerDefault.StreamsInbound, Equals, 32)
+	c.Assert(limits.ProtocolPeerDefault.Streams <= 32, Equals, true)
+	c.Assert(limits.System.Memory, Equals, int64(1<<28))
+	c.Assert(limits.Transient.Memory <= 1<<28, Equals, true)
+	c.Assert(limits.System.FD, Equals, 128)
+
+	rm, err := newResourceManager(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(rm.Close(), IsNil)
+}
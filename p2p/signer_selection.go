@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// the strategies the leader of the keysign party picks the signers with, once more peers join the party than the
+// threshold needs
+const (
+	// SignerSelectionFirst picks the peers that join the party first, it is the default
+	SignerSelectionFirst = "first"
+	// SignerSelectionHash picks the peers with the lowest hash of the message id and the peer id, so the same
+	// message is signed by the same subset whenever its peers are online
+	SignerSelectionHash = "hash"
+	// SignerSelectionLatency picks the peers with the lowest latency we probed
+	SignerSelectionLatency = "latency"
+	// SignerSelectionReputation picks the peers with the best reputation
+	SignerSelectionReputation = "reputation"
+)
+
+// signerSelectionWindow is how long the leader waits for more peers once it has enough of them, so the strategy
+// has candidates to choose from
+const signerSelectionWindow = time.Second
+
+// SignerSelector return the n peers of the candidates who sign the message
+type SignerSelector func(msgID string, candidates []peer.ID, n int) []peer.ID
+
+// NewSignerSelector create the selector of the strategy, it return nil for the default strategy, the latencies
+// and the reputation are only used by their strategies
+func NewSignerSelector(strategy string, latencies func() map[peer.ID]PeerLatency, reputation *Reputation) (SignerSelector, error) {
+	switch strategy {
+	case "", SignerSelectionFirst:
+		return nil, nil
+	case SignerSelectionHash:
+		return HashSelector, nil
+	case SignerSelectionLatency:
+		if latencies == nil {
+			return nil, fmt.Errorf("the %s signer selection needs the peer latencies", strategy)
+		}
+		return func(msgID string, candidates []peer.ID, n int) []peer.ID {
+			// the hash order breaks the ties between the peers we have not probed
+			return fastestPeers(latencies(), HashSelector(msgID, candidates, len(candidates)), n)
+		}, nil
+	case SignerSelectionReputation:
+		if reputation == nil {
+			return nil, fmt.Errorf("the %s signer selection needs the peer reputation", strategy)
+		}
+		return func(msgID string, candidates []peer.ID, n int) []peer.ID {
+			ranked := reputation.Rank(HashSelector(msgID, candidates, len(candidates)))
+			if n < len(ranked) {
+				ranked = ranked[:n]
+			}
+			return ranked
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown signer selection %s", strategy)
+	}
+}
+
+// HashSelector return the n candidates with the lowest sha256 of the message id and the peer id
+func HashSelector(msgID string, candidates []peer.ID, n int) []peer.ID {
+	hashes := make(map[peer.ID][]byte, len(candidates))
+	for _, el := range candidates {
+		sum := sha256.Sum256([]byte(msgID + el.String()))
+		hashes[el] = sum[:]
+	}
+	sorted := make([]peer.ID, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(hashes[sorted[i]], hashes[sorted[j]]) < 0
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,71 @@
+package p2p
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	. "gopkg.in/check.v1"
+)
+
+type SignerSelectionTestSuite struct{}
+
+var _ = Suite(&SignerSelectionTestSuite{})
+
+func (SignerSelectionTestSuite) TestNewSignerSelector(c *C) {
+	selector, err := NewSignerSelector("", nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(selector, IsNil)
+	selector, err = NewSignerSelector(SignerSelectionFirst, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(selector, IsNil)
+	selector, err = NewSignerSelector(SignerSelectionHash, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(selector, NotNil)
+	_, err = NewSignerSelector(SignerSelectionLatency, nil, nil)
+	c.Assert(err, NotNil)
+	_, err = NewSignerSelector(SignerSelectionReputation, nil, nil)
+	c.Assert(err, NotNil)
+	_, err = NewSignerSelector("random", nil, nil)
+	c.Assert(err, NotNil)
+}
+
+func (SignerSelectionTestSuite) TestHashSelector(c *C) {
+	peers := generateRandomPeers(c, 5)
+	selected := HashSelector("msg", peers, 3)
+	c.Assert(selected, HasLen, 3)
+	// the order of the candidates does not matter
+	reversed := make([]peer.ID, len(peers))
+	for i, el := range peers {
+		reversed[len(peers)-1-i] = el
+	}
+	c.Assert(HashSelector("msg", reversed, 3), DeepEquals, selected)
+	c.Assert(HashSelector("msg", peers, 10), HasLen, 5)
+	c.Assert(HashSelector("msg", peers, 5)[:3], DeepEquals, selected)
+}
+
+func (SignerSelectionTestSuite) TestLatencySelector(c *C) {
+	peers := generateRandomPeers(c, 4)
+	latencies := map[peer.ID]PeerLatency{
+		peers[0]: {RTT: time.Millisecond * 30},
+		peers[1]: {Error: "ping timeout"},
+		peers[2]: {RTT: time.Millisecond * 10},
+	}
+	selector, err := NewSignerSelector(SignerSelectionLatency, func() map[peer.ID]PeerLatency { return latencies }, nil)
+	c.Assert(err, IsNil)
+	c.Assert(selector("msg", peers, 2), DeepEquals, []peer.ID{peers[2], peers[0]})
+	// the peers we have not probed are ordered by the hash
+	selected := selector("msg", peers, 4)
+	c.Assert(selected[2:], DeepEquals, HashSelector("msg", []peer.ID{peers[1], peers[3]}, 2))
+}
+
+func (SignerSelectionTestSuite) TestReputationSelector(c *C) {
+	peers := generateRandomPeers(c, 4)
+	reputation := NewReputation(DefaultReputationConfig())
+	reputation.Record(peers[0], EventBlame)
+	reputation.Record(peers[1], EventCeremonySuccess)
+	reputation.Record(peers[3], EventTimeout)
+	selector, err := NewSignerSelector(SignerSelectionReputation, nil, reputation)
+	c.Assert(err, IsNil)
+	c.Assert(selector("msg", peers, 2), DeepEquals, []peer.ID{peers[1], peers[2]})
+	c.Assert(selector("msg", peers, 4), DeepEquals, []peer.ID{peers[1], peers[2], peers[3], peers[0]})
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"sync"
 	"time"
@@ -18,8 +19,86 @@ const (
 	TimeoutReadPayload  = time.Second * 10
 	TimeoutWritePayload = time.Second * 10
 	MaxPayload          = 20000000 // 20M
+	// chunkedMarker in the length header tells the reader the message is split into chunks
+	chunkedMarker   = 0xFFFFFFFF
+	chunkHeaderSize = 8 // the length and the crc32 checksum of the chunk
 )
 
+// DefaultMaxMessageSize is the maximum size of the message we accept if it is not configured, the messages larger
+// than MaxPayload are split into chunks of at most MaxPayload bytes and reassembled by the reader
+const DefaultMaxMessageSize = 64000000 // 64M
+
+// chunkSize is the size of the chunks we split the large messages into, it is only changed in test
+var chunkSize = MaxPayload
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// maxPooledPayload is the size of the largest payload buffer we keep in the pool, the larger ones are
+// rare and we do not want to pin them in memory
+const maxPooledPayload = 1 << 20
+
+// the buffers below are recycled across the stream reads and writes, so the high throughput keysign
+// batches do not allocate a fresh set of buffers for every message
+var (
+	readerPool = &sync.Pool{
+		New: func() interface{} {
+			return bufio.NewReader(nil)
+		},
+	}
+	writerPool = &sync.Pool{
+		New: func() interface{} {
+			return bufio.NewWriter(nil)
+		},
+	}
+	headerPool = &sync.Pool{
+		New: func() interface{} {
+			return new([chunkHeaderSize]byte)
+		},
+	}
+	payloadPool = &sync.Pool{}
+)
+
+func getReader(stream network.Stream) *bufio.Reader {
+	reader := readerPool.Get().(*bufio.Reader)
+	reader.Reset(stream)
+	return reader
+}
+
+func putReader(reader *bufio.Reader) {
+	reader.Reset(nil)
+	readerPool.Put(reader)
+}
+
+func getWriter(stream network.Stream) *bufio.Writer {
+	writer := writerPool.Get().(*bufio.Writer)
+	writer.Reset(stream)
+	return writer
+}
+
+func putWriter(writer *bufio.Writer) {
+	writer.Reset(nil)
+	writerPool.Put(writer)
+}
+
+// getPayloadBuffer return a buffer of the given length, it reuses the released buffer if it is large enough
+func getPayloadBuffer(length int) []byte {
+	if length <= maxPooledPayload {
+		if buf, ok := payloadPool.Get().(*[]byte); ok && cap(*buf) >= length {
+			return (*buf)[:length]
+		}
+	}
+	return make([]byte, length)
+}
+
+// releasePayloadBuffer give the buffer back to the pool, the caller must not use the buffer afterwards
+func releasePayloadBuffer(buf []byte) {
+	if cap(buf) == 0 || cap(buf) > maxPooledPayload {
+		return
+	}
+	buf = buf[:0]
+	payloadPool.Put(&buf)
+}
+
 // applyDeadline will be true , and only disable it when we are doing test
 // the reason being the p2p network , mocknet, mock stream doesn't support SetReadDeadline ,SetWriteDeadline feature
 var ApplyDeadline = true
@@ -73,39 +152,65 @@ func (sm *StreamMgr) AddStream(msgID string, stream network.Stream) {
 	}
 }
 
-// ReadStreamWithBuffer read data from the given stream
+// counts return the number of the streams we keep for each of the message ids until their ceremony is released
+func (sm *StreamMgr) counts() map[string]int {
+	sm.streamLocker.RLock()
+	defer sm.streamLocker.RUnlock()
+	ret := make(map[string]int, len(sm.unusedStreams))
+	for msgID, el := range sm.unusedStreams {
+		ret[msgID] = len(el)
+	}
+	return ret
+}
+
+// ReadStreamWithBuffer read data from the given stream, the message can not exceed DefaultMaxMessageSize
 func ReadStreamWithBuffer(stream network.Stream) ([]byte, error) {
-	if ApplyDeadline {
-		if err := stream.SetReadDeadline(time.Now().Add(TimeoutReadPayload)); nil != err {
-			if errReset := stream.Reset(); errReset != nil {
-				return nil, errReset
-			}
-			return nil, err
-		}
+	return ReadStreamWithLimit(stream, DefaultMaxMessageSize)
+}
+
+// ReadStreamWithLimit read data from the given stream, the message can not exceed maxMessageSize
+func ReadStreamWithLimit(stream network.Stream, maxMessageSize int) ([]byte, error) {
+	if err := applyReadDeadline(stream); err != nil {
+		return nil, err
 	}
-	streamReader := bufio.NewReader(stream)
-	lengthBytes := make([]byte, LengthHeader)
+	streamReader := getReader(stream)
+	defer putReader(streamReader)
+	header := headerPool.Get().(*[chunkHeaderSize]byte)
+	defer headerPool.Put(header)
+	lengthBytes := header[:LengthHeader]
 	n, err := io.ReadFull(streamReader, lengthBytes)
 	if n != LengthHeader || err != nil {
 		return nil, fmt.Errorf("error in read the message head %w", err)
 	}
 	length := binary.LittleEndian.Uint32(lengthBytes)
+	if length == chunkedMarker {
+		return readChunks(stream, streamReader, header[:], maxMessageSize)
+	}
 	if length > MaxPayload {
 		return nil, fmt.Errorf("payload length:%d exceed max payload length:%d", length, MaxPayload)
 	}
-	dataBuf := make([]byte, length)
+	dataBuf := getPayloadBuffer(int(length))
 	n, err = io.ReadFull(streamReader, dataBuf)
 	if uint32(n) != length || err != nil {
+		releasePayloadBuffer(dataBuf)
 		return nil, fmt.Errorf("short read err(%w), we would like to read: %d, however we only read: %d", err, length, n)
 	}
 	return dataBuf, nil
 }
 
-// WriteStreamWithBuffer write the message to stream
-func WriteStreamWithBuffer(msg []byte, stream network.Stream) error {
-	length := uint32(len(msg))
-	lengthBytes := make([]byte, LengthHeader)
-	binary.LittleEndian.PutUint32(lengthBytes, length)
+func applyReadDeadline(stream network.Stream) error {
+	if ApplyDeadline {
+		if err := stream.SetReadDeadline(time.Now().Add(TimeoutReadPayload)); nil != err {
+			if errReset := stream.Reset(); errReset != nil {
+				return errReset
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func applyWriteDeadline(stream network.Stream) error {
 	if ApplyDeadline {
 		if err := stream.SetWriteDeadline(time.Now().Add(TimeoutWritePayload)); nil != err {
 			if errReset := stream.Reset(); errReset != nil {
@@ -114,7 +219,85 @@ func WriteStreamWithBuffer(msg []byte, stream network.Stream) error {
 			return err
 		}
 	}
-	streamWrite := bufio.NewWriter(stream)
+	return nil
+}
+
+// readChunks reassemble the chunked message, the total length follows the marker, then each chunk
+// comes with its length and crc32 checksum. The buffer grows with the chunks we received, not with the total
+// length the peer claims
+func readChunks(stream network.Stream, streamReader *bufio.Reader, chunkHeader []byte, maxMessageSize int) ([]byte, error) {
+	lengthBytes := chunkHeader[:LengthHeader]
+	if _, err := io.ReadFull(streamReader, lengthBytes); err != nil {
+		return nil, fmt.Errorf("error in read the message total length %w", err)
+	}
+	total := binary.LittleEndian.Uint32(lengthBytes)
+	if uint64(total) > uint64(maxMessageSize) {
+		return nil, fmt.Errorf("message length:%d exceed max message length:%d", total, maxMessageSize)
+	}
+	var dataBuf []byte
+	for uint32(len(dataBuf)) < total {
+		// we give each chunk the full timeout, so the large messages do not time out
+		if err := applyReadDeadline(stream); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(streamReader, chunkHeader); err != nil {
+			return nil, fmt.Errorf("error in read the chunk head %w", err)
+		}
+		length := binary.LittleEndian.Uint32(chunkHeader[:LengthHeader])
+		checksum := binary.LittleEndian.Uint32(chunkHeader[LengthHeader:])
+		if length == 0 || length > MaxPayload || uint64(len(dataBuf))+uint64(length) > uint64(total) {
+			return nil, fmt.Errorf("invalid chunk length:%d", length)
+		}
+		if need := len(dataBuf) + int(length); need > cap(dataBuf) {
+			size := 2 * cap(dataBuf)
+			if size < need {
+				size = need
+			}
+			if size > int(total) {
+				size = int(total)
+			}
+			grown := make([]byte, len(dataBuf), size)
+			copy(grown, dataBuf)
+			dataBuf = grown
+		}
+		chunk := dataBuf[len(dataBuf) : len(dataBuf)+int(length)]
+		n, err := io.ReadFull(streamReader, chunk)
+		if uint32(n) != length || err != nil {
+			return nil, fmt.Errorf("short read err(%w), we would like to read: %d, however we only read: %d", err, length, n)
+		}
+		if crc32.Checksum(chunk, crcTable) != checksum {
+			return nil, fmt.Errorf("chunk checksum mismatch at offset %d", len(dataBuf))
+		}
+		dataBuf = dataBuf[:len(dataBuf)+int(length)]
+	}
+	return dataBuf, nil
+}
+
+// WriteStreamWithBuffer write the message to stream, the messages larger than MaxPayload are split into chunks, the
+// message can not exceed DefaultMaxMessageSize
+func WriteStreamWithBuffer(msg []byte, stream network.Stream) error {
+	return WriteStreamWithLimit(msg, stream, DefaultMaxMessageSize)
+}
+
+// WriteStreamWithLimit write the message to stream, the messages larger than MaxPayload are split into chunks, the
+// message can not exceed maxMessageSize
+func WriteStreamWithLimit(msg []byte, stream network.Stream, maxMessageSize int) error {
+	if len(msg) > maxMessageSize {
+		return fmt.Errorf("message length:%d exceed max message length:%d", len(msg), maxMessageSize)
+	}
+	if len(msg) > chunkSize {
+		return writeChunks(msg, stream)
+	}
+	length := uint32(len(msg))
+	header := headerPool.Get().(*[chunkHeaderSize]byte)
+	defer headerPool.Put(header)
+	lengthBytes := header[:LengthHeader]
+	binary.LittleEndian.PutUint32(lengthBytes, length)
+	if err := applyWriteDeadline(stream); err != nil {
+		return err
+	}
+	streamWrite := getWriter(stream)
+	defer putWriter(streamWrite)
 	n, err := streamWrite.Write(lengthBytes)
 	if n != LengthHeader || err != nil {
 		return fmt.Errorf("fail to write head: %w", err)
@@ -132,3 +315,45 @@ func WriteStreamWithBuffer(msg []byte, stream network.Stream) error {
 	}
 	return nil
 }
+
+func writeChunks(msg []byte, stream network.Stream) error {
+	header := headerPool.Get().(*[chunkHeaderSize]byte)
+	defer headerPool.Put(header)
+	binary.LittleEndian.PutUint32(header[:LengthHeader], chunkedMarker)
+	binary.LittleEndian.PutUint32(header[LengthHeader:], uint32(len(msg)))
+	if err := applyWriteDeadline(stream); err != nil {
+		return err
+	}
+	streamWrite := getWriter(stream)
+	defer putWriter(streamWrite)
+	if _, err := streamWrite.Write(header[:LengthHeader*2]); err != nil {
+		return fmt.Errorf("fail to write head: %w", err)
+	}
+	chunkHeader := header[:]
+	for offset := 0; offset < len(msg); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk := msg[offset:end]
+		if err := applyWriteDeadline(stream); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(chunkHeader[:LengthHeader], uint32(len(chunk)))
+		binary.LittleEndian.PutUint32(chunkHeader[LengthHeader:], crc32.Checksum(chunk, crcTable))
+		if _, err := streamWrite.Write(chunkHeader); err != nil {
+			return fmt.Errorf("fail to write chunk head: %w", err)
+		}
+		n, err := streamWrite.Write(chunk)
+		if err != nil {
+			return err
+		}
+		if n != len(chunk) {
+			return fmt.Errorf("short write, we would like to write: %d, however we only write: %d", len(chunk), n)
+		}
+	}
+	if err := streamWrite.Flush(); err != nil {
+		return fmt.Errorf("fail to flush stream: %w", err)
+	}
+	return nil
+}
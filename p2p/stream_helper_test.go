@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"hash/crc32"
+	"runtime"
 	"strconv"
 	"testing"
 	"time"
@@ -254,3 +256,106 @@ func TestStreamManager(t *testing.T) {
 	streamMgr.ReleaseStream("3")
 	assert.Equal(t, len(streamMgr.unusedStreams), 0)
 }
+
+func TestChunkedPayload(t *testing.T) {
+	ApplyDeadline = true
+	defer func() {
+		chunkSize = MaxPayload
+	}()
+	chunkSize = 1024
+	input := bytes.Repeat([]byte("hello world"), 1000)
+	stream := NewMockNetworkStream()
+	if err := WriteStreamWithBuffer(input, stream); err != nil {
+		t.Fatal(err)
+	}
+	// the length header is the chunked marker followed by the total length
+	assert.Equal(t, binary.LittleEndian.Uint32(stream.Bytes()[:LengthHeader]), uint32(chunkedMarker))
+	output, err := ReadStreamWithBuffer(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bytes.Equal(input, output), true)
+
+	// the corrupted chunk should be rejected
+	stream = NewMockNetworkStream()
+	if err := WriteStreamWithBuffer(input, stream); err != nil {
+		t.Fatal(err)
+	}
+	stream.Bytes()[LengthHeader*2+chunkHeaderSize+10] ^= 0xff
+	_, err = ReadStreamWithBuffer(stream)
+	assert.Equal(t, err != nil, true)
+
+	// the message exceeds the max message size
+	stream = NewMockNetworkStream()
+	if err := WriteStreamWithBuffer(input, stream); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadStreamWithLimit(stream, 2048)
+	assert.Equal(t, err != nil, true)
+	assert.Equal(t, WriteStreamWithLimit(input, NewMockNetworkStream(), 2048) != nil, true)
+
+	// the reader does not allocate the total length the header claims before the chunks arrive
+	stream = NewMockNetworkStream()
+	header := make([]byte, LengthHeader*2+chunkHeaderSize)
+	binary.LittleEndian.PutUint32(header, chunkedMarker)
+	binary.LittleEndian.PutUint32(header[LengthHeader:], DefaultMaxMessageSize)
+	binary.LittleEndian.PutUint32(header[LengthHeader*2:], 16)
+	binary.LittleEndian.PutUint32(header[LengthHeader*3:], crc32.Checksum(input[:16], crcTable))
+	if _, err := stream.Write(append(header, input[:16]...)); err != nil {
+		t.Fatal(err)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = ReadStreamWithBuffer(stream)
+	runtime.ReadMemStats(&after)
+	assert.Equal(t, err != nil, true)
+	assert.Equal(t, after.TotalAlloc-before.TotalAlloc < 1<<20, true)
+}
+
+func TestPayloadBufferPool(t *testing.T) {
+	buf := getPayloadBuffer(100)
+	assert.Equal(t, len(buf), 100)
+	releasePayloadBuffer(buf)
+	// the pool never hands out a buffer smaller than asked for
+	buf = getPayloadBuffer(1000)
+	assert.Equal(t, len(buf), 1000)
+	releasePayloadBuffer(buf)
+	buf = getPayloadBuffer(maxPooledPayload + 1)
+	assert.Equal(t, len(buf), maxPooledPayload+1)
+	releasePayloadBuffer(buf)
+	releasePayloadBuffer(nil)
+
+	// the pooled readers and writers do not leak data between the streams
+	ApplyDeadline = false
+	for i := 0; i < 10; i++ {
+		input := bytes.Repeat([]byte(strconv.Itoa(i)), 100*(i+1))
+		stream := NewMockNetworkStream()
+		if err := WriteStreamWithBuffer(input, stream); err != nil {
+			t.Fatal(err)
+		}
+		output, err := ReadStreamWithBuffer(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, bytes.Equal(input, output), true)
+		releasePayloadBuffer(output)
+	}
+}
+
+func BenchmarkStreamReadWrite(b *testing.B) {
+	ApplyDeadline = false
+	input := bytes.Repeat([]byte("tss keysign message "), 256)
+	stream := NewMockNetworkStream()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := WriteStreamWithBuffer(input, stream); err != nil {
+			b.Fatal(err)
+		}
+		output, err := ReadStreamWithBuffer(stream)
+		if err != nil {
+			b.Fatal(err)
+		}
+		releasePayloadBuffer(output)
+	}
+}
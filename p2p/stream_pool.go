@@ -0,0 +1,158 @@
+package p2p
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// StreamHandlerConfig bounds the number of the inbound tss streams we read at the same time
+type StreamHandlerConfig struct {
+	// Workers is the number of the streams we read at the same time, 0 reads all the streams at once
+	Workers int
+	// QueueSize is the number of the streams waiting for a worker, we reject the streams once it is full
+	QueueSize int
+}
+
+// DefaultStreamHandlerConfig return the default configuration of the inbound stream workers
+func DefaultStreamHandlerConfig() StreamHandlerConfig {
+	return StreamHandlerConfig{
+		Workers:   64,
+		QueueSize: 1024,
+	}
+}
+
+// Enabled return true if we read the inbound streams with a bounded number of workers
+func (cfg StreamHandlerConfig) Enabled() bool {
+	return cfg.Workers > 0
+}
+
+// Validate check the stream handler configuration
+func (cfg StreamHandlerConfig) Validate() error {
+	if cfg.Workers < 0 {
+		return errors.New("the number of stream workers cannot be negative")
+	}
+	if cfg.QueueSize < 0 {
+		return errors.New("the stream queue size cannot be negative")
+	}
+	return nil
+}
+
+// StreamHandlerStats is the state of the inbound stream workers
+type StreamHandlerStats struct {
+	Workers int `json:"workers"`
+	// Busy is the number of the workers reading a stream
+	Busy int64 `json:"busy"`
+	// Queued is the number of the streams waiting for a worker
+	Queued int `json:"queued"`
+	// Rejected is the number of the streams we reset as the queue was full
+	Rejected int64 `json:"rejected"`
+}
+
+// streamPool reads the inbound tss streams with a fixed number of workers, so a burst of streams cannot
+// spawn an unbounded number of readers
+type streamPool struct {
+	cfg      StreamHandlerConfig
+	queue    chan network.Stream
+	busy     int64
+	rejected int64
+}
+
+func newStreamPool(cfg StreamHandlerConfig) *streamPool {
+	return &streamPool{
+		cfg:   cfg,
+		queue: make(chan network.Stream, cfg.QueueSize),
+	}
+}
+
+// enqueue return false if the queue is full
+func (sp *streamPool) enqueue(stream network.Stream) bool {
+	select {
+	case sp.queue <- stream:
+		return true
+	default:
+		atomic.AddInt64(&sp.rejected, 1)
+		return false
+	}
+}
+
+func (sp *streamPool) stats() StreamHandlerStats {
+	return StreamHandlerStats{
+		Workers:  sp.cfg.Workers,
+		Busy:     atomic.LoadInt64(&sp.busy),
+		Queued:   len(sp.queue),
+		Rejected: atomic.LoadInt64(&sp.rejected),
+	}
+}
+
+// startStreamWorkers start the workers reading the queued streams until we stop
+func (c *Communication) startStreamWorkers() {
+	for i := 0; i < c.streamPool.cfg.Workers; i++ {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for {
+				select {
+				case <-c.stopChan:
+					return
+				case stream := <-c.streamPool.queue:
+					atomic.AddInt64(&c.streamPool.busy, 1)
+					c.readFromStream(stream)
+					atomic.AddInt64(&c.streamPool.busy, -1)
+				}
+			}
+		}()
+	}
+}
+
+// drainStreamQueue reset the streams no worker has read before we stop
+func (c *Communication) drainStreamQueue() {
+	for {
+		select {
+		case stream := <-c.streamPool.queue:
+			if err := stream.Reset(); err != nil {
+				c.logger.Error().Err(err).Msg("fail to reset the stream")
+			}
+		default:
+			return
+		}
+	}
+}
+
+// dispatchStream hand the stream over to the workers, the stream is reset if all the workers are busy and
+// the queue is full
+func (c *Communication) dispatchStream(stream network.Stream) {
+	if c.streamPool == nil {
+		c.readFromStream(stream)
+		return
+	}
+	if c.streamPool.enqueue(stream) {
+		return
+	}
+	c.logger.Warn().Msgf("the stream queue is full, reject the stream from peer %s", stream.Conn().RemotePeer())
+	if err := stream.Reset(); err != nil {
+		c.logger.Error().Err(err).Msg("fail to reset the stream")
+	}
+}
+
+// GetStreamHandlerStats return the state of the inbound stream workers
+func (c *Communication) GetStreamHandlerStats() StreamHandlerStats {
+	if c.streamPool == nil {
+		return StreamHandlerStats{}
+	}
+	return c.streamPool.stats()
+}
+
+// GetPeerStreams return the number of the streams we have open with each of the peers, inbound and outbound
+func (c *Communication) GetPeerStreams() map[peer.ID]int {
+	streams := make(map[peer.ID]int)
+	if c.host == nil {
+		return streams
+	}
+	for _, conn := range c.host.Network().Conns() {
+		streams[conn.RemotePeer()] += len(conn.GetStreams())
+	}
+	return streams
+}
@@ -0,0 +1,83 @@
+package p2p
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type StreamPoolTestSuite struct{}
+
+var _ = Suite(&StreamPoolTestSuite{})
+
+func (StreamPoolTestSuite) TestStreamHandlerConfig(c *C) {
+	c.Assert(DefaultStreamHandlerConfig().Validate(), IsNil)
+	c.Assert(DefaultStreamHandlerConfig().Enabled(), Equals, true)
+	c.Assert(StreamHandlerConfig{}.Enabled(), Equals, false)
+	c.Assert(StreamHandlerConfig{Workers: -1}.Validate(), NotNil)
+	c.Assert(StreamHandlerConfig{Workers: 1, QueueSize: -1}.Validate(), NotNil)
+	_, err := NewCommunication("commTest", nil, 2390, "", WithStreamHandler(StreamHandlerConfig{Workers: -1}))
+	c.Assert(err, NotNil)
+}
+
+func (StreamPoolTestSuite) TestEnqueue(c *C) {
+	sp := newStreamPool(StreamHandlerConfig{Workers: 1, QueueSize: 2})
+	c.Assert(sp.enqueue(NewMockNetworkStream()), Equals, true)
+	c.Assert(sp.enqueue(NewMockNetworkStream()), Equals, true)
+	// the queue is full
+	c.Assert(sp.enqueue(NewMockNetworkStream()), Equals, false)
+	c.Assert(sp.stats(), DeepEquals, StreamHandlerStats{
+		Workers:  1,
+		Queued:   2,
+		Rejected: 1,
+	})
+}
+
+func (StreamPoolTestSuite) TestStreamWorkers(c *C) {
+	ApplyDeadline = false
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/2390/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	privKey, err := base64.StdEncoding.DecodeString("6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=")
+	c.Assert(err, IsNil)
+	validMultiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	comm, err := NewCommunication("commTest", nil, 2390, "",
+		WithStreamHandler(StreamHandlerConfig{Workers: 1, QueueSize: 4}))
+	c.Assert(err, IsNil)
+	c.Assert(comm.Start(privKey), IsNil)
+	defer comm.Stop()
+	sk, err := base64.StdEncoding.DecodeString("528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=")
+	c.Assert(err, IsNil)
+	comm2, err := NewCommunication("commTest", []maddr.Multiaddr{validMultiAddr}, 2391, "")
+	c.Assert(err, IsNil)
+	c.Assert(comm2.Start(sk), IsNil)
+	defer comm2.Stop()
+
+	received := make(chan *Message, 3)
+	comm.SetSubscribe(messages.TSSKeySignMsg, "pooled", received)
+	defer comm.CancelSubscribe(messages.TSSKeySignMsg, "pooled")
+	for i := 0; i < 3; i++ {
+		comm2.broadcast([]peer.ID{comm.host.ID()}, newEncodedMessage(&messages.WrappedMessage{
+			MessageType: messages.TSSKeySignMsg,
+			MsgID:       "pooled",
+			Payload:     []byte("tss keysign message"),
+		}), "pooled")
+	}
+	// the single worker reads all the streams one after the other
+	for i := 0; i < 3; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second * 10):
+			c.Fatal("fail to receive the message")
+		}
+	}
+	stats := comm.GetStreamHandlerStats()
+	c.Assert(stats.Workers, Equals, 1)
+	c.Assert(stats.Rejected, Equals, int64(0))
+	_, ok := comm.GetPeerStreams()[comm2.host.ID()]
+	c.Assert(ok, Equals, true)
+}
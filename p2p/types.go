@@ -3,18 +3,48 @@ package p2p
 import (
 	"strings"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	maddr "github.com/multiformats/go-multiaddr"
 )
 
 // A new type we need for writing a custom flag parser
 type addrList []maddr.Multiaddr
 
+// peerList is the flag parser of a list of peer ids
+type peerList []peer.ID
+
 // Config is configuration for P2P
 type Config struct {
 	RendezvousString string
 	Port             int
 	BootstrapPeers   addrList
 	ExternalIP       string
+	// AnnounceAddrs are the addresses we announce instead of the listen addresses
+	AnnounceAddrs addrList
+	// NATPortMap maps the listen port through UPnP or NAT-PMP
+	NATPortMap     bool
+	RateLimit      RateLimitConfig
+	ResourceLimits ResourceLimitConfig
+	Attestation    AttestationConfig
+	Compression    CompressionConfig
+	// MaxMessageSize is the maximum size in bytes of the tss message, DefaultMaxMessageSize if it is 0
+	MaxMessageSize int
+	BootstrapRetry BootstrapRetryConfig
+	Delivery       DeliveryConfig
+	LatencyProbe   LatencyProbeConfig
+	Reputation     ReputationConfig
+	PeerStore      PeerStoreConfig
+	Reconnect      ReconnectConfig
+	CircuitBreaker CircuitBreakerConfig
+	StreamHandler  StreamHandlerConfig
+	// ColdStart dials the committee members in the saved address book directly on start
+	ColdStart bool
+	// CeremonyStreams sends the tss messages of each ceremony over its own protocol
+	CeremonyStreams bool
+	// Gossip joins the gossip topic, so the large committees can gossip the broadcast messages
+	Gossip bool
+	// AllowedPeers is the allowlist of the peers we connect to, empty allows all the peers
+	AllowedPeers peerList
 }
 
 // String implement fmt.Stringer
@@ -35,3 +65,22 @@ func (al *addrList) Set(value string) error {
 	*al = append(*al, addr)
 	return nil
 }
+
+// String implement fmt.Stringer
+func (pl *peerList) String() string {
+	peers := make([]string, len(*pl))
+	for i, el := range *pl {
+		peers[i] = el.String()
+	}
+	return strings.Join(peers, ",")
+}
+
+// Set add the given peer id to the peerList
+func (pl *peerList) Set(value string) error {
+	pID, err := peer.Decode(value)
+	if err != nil {
+		return err
+	}
+	*pl = append(*pl, pID)
+	return nil
+}
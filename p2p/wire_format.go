@@ -0,0 +1,87 @@
+package p2p
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+// encodedMessage encodes the wrapped message lazily in the wire format each peer negotiated, so we
+// encode the message at most once per format no matter how many peers we send it to
+type encodedMessage struct {
+	msg        *messages.WrappedMessage
+	jsonOnce   *sync.Once
+	jsonBytes  []byte
+	jsonErr    error
+	protoOnce  *sync.Once
+	protoBytes []byte
+	protoErr   error
+	// compressed caches the compressed frames keyed by codec
+	compressLock *sync.Mutex
+	compressed   map[CompressionCodec][]byte
+}
+
+func newEncodedMessage(msg *messages.WrappedMessage) *encodedMessage {
+	return &encodedMessage{
+		msg:          msg,
+		jsonOnce:     &sync.Once{},
+		protoOnce:    &sync.Once{},
+		compressLock: &sync.Mutex{},
+		compressed:   make(map[CompressionCodec][]byte),
+	}
+}
+
+// newRawMessage wraps the already encoded message, it is sent as it is whatever the negotiated format is
+func newRawMessage(buf []byte) *encodedMessage {
+	m := newEncodedMessage(nil)
+	m.jsonOnce.Do(func() { m.jsonBytes = buf })
+	m.protoOnce.Do(func() { m.protoBytes = buf })
+	return m
+}
+
+// newBroadcastMessage wraps the message submitted to the broadcast channel, we reuse its protobuf encoding
+// if the sender has already marshalled it
+func newBroadcastMessage(msg *messages.BroadcastMsgChan) *encodedMessage {
+	m := newEncodedMessage(&msg.WrappedMessage)
+	if len(msg.Encoded) != 0 {
+		m.protoOnce.Do(func() { m.protoBytes = msg.Encoded })
+	}
+	return m
+}
+
+// forProtocol return the message in the wire format of the negotiated protocol
+func (m *encodedMessage) forProtocol(protocolID protocol.ID) ([]byte, error) {
+	if protocolID == TSSProtoProtocolID || codecForProtocol(protocolID) != CompressionNone || isCeremonyProtocol(string(protocolID)) {
+		m.protoOnce.Do(func() {
+			m.protoBytes, m.protoErr = m.msg.MarshalProto()
+		})
+		return m.protoBytes, m.protoErr
+	}
+	m.jsonOnce.Do(func() {
+		m.jsonBytes, m.jsonErr = json.Marshal(m.msg)
+	})
+	return m.jsonBytes, m.jsonErr
+}
+
+// compress return the protobuf encoded message compressed with the given codec, the frame is compressed once
+// per codec no matter how many peers we send it to
+func (m *encodedMessage) compress(codec CompressionCodec, threshold int) ([]byte, error) {
+	buf, err := m.forProtocol(TSSProtoProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	m.compressLock.Lock()
+	defer m.compressLock.Unlock()
+	if frame, ok := m.compressed[codec]; ok {
+		return frame, nil
+	}
+	frame, err := compressFrame(codec, threshold, buf)
+	if err != nil {
+		return nil, err
+	}
+	m.compressed[codec] = frame
+	return frame, nil
+}
@@ -0,0 +1,68 @@
+package p2p
+
+import (
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/messages"
+)
+
+type WireFormatTestSuite struct{}
+
+var _ = Suite(&WireFormatTestSuite{})
+
+func (WireFormatTestSuite) TestEncodedMessage(c *C) {
+	msg := &messages.WrappedMessage{
+		MessageType: messages.TSSKeySignMsg,
+		MsgID:       "message-id",
+		Payload:     []byte("hello"),
+	}
+	encoded := newEncodedMessage(msg)
+	jsonBytes, err := encoded.forProtocol(TSSProtocolID)
+	c.Assert(err, IsNil)
+	expected, err := json.Marshal(msg)
+	c.Assert(err, IsNil)
+	c.Assert(jsonBytes, DeepEquals, expected)
+	protoBytes, err := encoded.forProtocol(TSSProtoProtocolID)
+	c.Assert(err, IsNil)
+	expected, err = msg.MarshalProto()
+	c.Assert(err, IsNil)
+	c.Assert(protoBytes, DeepEquals, expected)
+
+	raw := newRawMessage([]byte("raw"))
+	buf, err := raw.forProtocol(TSSProtoProtocolID)
+	c.Assert(err, IsNil)
+	c.Assert(buf, DeepEquals, []byte("raw"))
+	buf, err = raw.forProtocol(TSSProtocolID)
+	c.Assert(err, IsNil)
+	c.Assert(buf, DeepEquals, []byte("raw"))
+}
+
+func (WireFormatTestSuite) TestBroadcastMessage(c *C) {
+	msg := &messages.BroadcastMsgChan{
+		WrappedMessage: messages.WrappedMessage{
+			MessageType: messages.TSSKeySignMsg,
+			MsgID:       "message-id",
+			Payload:     []byte("hello"),
+		},
+		Encoded: []byte("pre-marshalled"),
+	}
+	// the pre-marshalled bytes are sent as they are
+	buf, err := newBroadcastMessage(msg).forProtocol(TSSProtoProtocolID)
+	c.Assert(err, IsNil)
+	c.Assert(buf, DeepEquals, []byte("pre-marshalled"))
+	// the peers on the json protocol still get the json encoding
+	buf, err = newBroadcastMessage(msg).forProtocol(TSSProtocolID)
+	c.Assert(err, IsNil)
+	expected, err := json.Marshal(msg.WrappedMessage)
+	c.Assert(err, IsNil)
+	c.Assert(buf, DeepEquals, expected)
+
+	msg.Encoded = nil
+	buf, err = newBroadcastMessage(msg).forProtocol(TSSProtoProtocolID)
+	c.Assert(err, IsNil)
+	expected, err = msg.WrappedMessage.MarshalProto()
+	c.Assert(err, IsNil)
+	c.Assert(buf, DeepEquals, expected)
+}
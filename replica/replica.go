@@ -0,0 +1,314 @@
+package replica
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// replicaProtocol is the protocol the primary streams its keyshares to the standby with, libp2p authenticates both
+// ends with their node keys, the keyshares are sealed to the replica key of the standby on top of it, so they stay
+// encrypted wherever the standby relays them
+var replicaProtocol protocol.ID = "/p2p/go-tss/replica"
+
+// replicaTimeout is how long we wait for the standby to take a keyshare
+const replicaTimeout = 30 * time.Second
+
+type replicaShare struct {
+	PubKey string `json:"pub_key"`
+	Sealed []byte `json:"sealed"`
+}
+
+type replicaAck struct {
+	Error string `json:"error,omitempty"`
+}
+
+// GenerateKey generate the curve25519 replica key of the standby, the primary seals the keyshares to its public key
+func GenerateKey() (publicKey, privateKey *[32]byte, err error) {
+	return box.GenerateKey(rand.Reader)
+}
+
+// PublicKey return the public key of the replica key
+func PublicKey(privateKey *[32]byte) (*[32]byte, error) {
+	buf, err := curve25519.X25519(privateKey[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the public key: %w", err)
+	}
+	var publicKey [32]byte
+	copy(publicKey[:], buf)
+	return &publicKey, nil
+}
+
+// ParseKey parse the hex encoded replica key
+func ParseKey(s string) (*[32]byte, error) {
+	buf, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("fail to decode the replica key: %w", err)
+	}
+	if len(buf) != 32 {
+		return nil, fmt.Errorf("the replica key is %d bytes, expect 32", len(buf))
+	}
+	var key [32]byte
+	copy(key[:], buf)
+	storage.Zeroize(buf)
+	return &key, nil
+}
+
+// ReadKeyFile read the hex encoded replica key of the file
+func ReadKeyFile(filePathName string) (*[32]byte, error) {
+	buf, err := ioutil.ReadFile(filePathName)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the replica key file: %w", err)
+	}
+	defer storage.Zeroize(buf)
+	return ParseKey(string(buf))
+}
+
+// Replicator streams the keyshares of the primary to its standby, so the standby can take over the node key of the
+// primary without copying the files. It syncs the keyshares the standby has not acknowledged on each round, the
+// keyshares that change, like the refreshed or the retired ones, are sent again
+type Replicator struct {
+	logger       zerolog.Logger
+	host         host.Host
+	stateManager storage.LocalStateManager
+	lister       storage.LocalStateLister
+	standby      peer.ID
+	standbyKey   *[32]byte
+	lock         *sync.Mutex
+	// synced is the hash of the keyshare the standby has acknowledged of each key
+	synced map[string]string
+}
+
+// NewReplicator create the replicator of the keyshares to the given standby, the state manager has to list the keys
+func NewReplicator(h host.Host, stateManager storage.LocalStateManager, standby peer.ID, standbyKey *[32]byte) (*Replicator, error) {
+	lister, ok := stateManager.(storage.LocalStateLister)
+	if !ok {
+		return nil, errors.New("the state manager can not list the keys to replicate")
+	}
+	return &Replicator{
+		logger:       log.With().Str("module", "replicator").Logger(),
+		host:         h,
+		stateManager: stateManager,
+		lister:       lister,
+		standby:      standby,
+		standbyKey:   standbyKey,
+		lock:         &sync.Mutex{},
+		synced:       make(map[string]string),
+	}, nil
+}
+
+// Run sync the keyshares with the standby in the given interval until the stop channel is closed
+func (r *Replicator) Run(interval time.Duration, stopChan chan struct{}) {
+	for {
+		if _, err := r.Sync(); err != nil {
+			r.logger.Error().Err(err).Msg("fail to replicate the keyshares to the standby")
+		}
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Sync send the keyshares the standby has not acknowledged, it return the pub keys it has sent
+func (r *Replicator) Sync() ([]string, error) {
+	pubKeys, err := r.lister.ListLocalStates()
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var sent []string
+	for _, el := range pubKeys {
+		sealed, hash, err := r.seal(el)
+		if err != nil {
+			r.logger.Error().Err(err).Str("pool pub key", el).Msg("fail to seal the keyshare")
+			continue
+		}
+		if sealed == nil {
+			continue
+		}
+		if err := r.send(replicaShare{PubKey: el, Sealed: sealed}); err != nil {
+			return sent, fmt.Errorf("fail to send the keyshare of %s: %w", el, err)
+		}
+		r.synced[el] = hash
+		sent = append(sent, el)
+	}
+	return sent, nil
+}
+
+// seal return the keyshare of the key sealed to the replica key of the standby, and the hash of the keyshare, the
+// keyshare is not sealed if the standby has acknowledged it. The lock is held by the caller
+func (r *Replicator) seal(pubKey string) ([]byte, string, error) {
+	state, err := r.stateManager.GetLocalState(pubKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("fail to get the local state: %w", err)
+	}
+	defer storage.ZeroizeLocalState(&state)
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return nil, "", fmt.Errorf("fail to marshal the local state: %w", err)
+	}
+	defer storage.Zeroize(buf)
+	hash := sha256.Sum256(buf)
+	if r.synced[pubKey] == hex.EncodeToString(hash[:]) {
+		return nil, r.synced[pubKey], nil
+	}
+	sealed, err := box.SealAnonymous(nil, buf, r.standbyKey, rand.Reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("fail to seal the local state: %w", err)
+	}
+	return sealed, hex.EncodeToString(hash[:]), nil
+}
+
+func (r *Replicator) send(share replicaShare) error {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaTimeout)
+	defer cancel()
+	stream, err := r.host.NewStream(ctx, r.standby, replicaProtocol)
+	if err != nil {
+		return fmt.Errorf("fail to create stream to the standby(%s): %w", r.standby, err)
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			r.logger.Error().Err(err).Msg("fail to close the replica stream")
+		}
+	}()
+	if err := writeMsg(stream, share); err != nil {
+		return err
+	}
+	var ack replicaAck
+	if err := readMsg(stream, &ack); err != nil {
+		return err
+	}
+	if len(ack.Error) != 0 {
+		return fmt.Errorf("the standby fail to save the keyshare: %s", ack.Error)
+	}
+	return nil
+}
+
+// Standby takes the keyshares its primaries stream to it and saves them, they replace the keyshares it holds
+type Standby struct {
+	logger       zerolog.Logger
+	host         host.Host
+	stateManager storage.LocalStateManager
+	primaries    map[peer.ID]bool
+	publicKey    *[32]byte
+	privateKey   *[32]byte
+}
+
+// NewStandby create the standby of the given primaries, the keyshares are sealed to the public key of the replica
+// key
+func NewStandby(h host.Host, stateManager storage.LocalStateManager, primaries []peer.ID, privateKey *[32]byte) (*Standby, error) {
+	if len(primaries) == 0 {
+		return nil, errors.New("the standby has no primary")
+	}
+	publicKey, err := PublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	s := &Standby{
+		logger:       log.With().Str("module", "standby").Logger(),
+		host:         h,
+		stateManager: stateManager,
+		primaries:    make(map[peer.ID]bool),
+		publicKey:    publicKey,
+		privateKey:   privateKey,
+	}
+	for _, el := range primaries {
+		s.primaries[el] = true
+	}
+	h.SetStreamHandler(replicaProtocol, s.handleStream)
+	return s, nil
+}
+
+func (s *Standby) handleStream(stream network.Stream) {
+	remotePeer := stream.Conn().RemotePeer()
+	logger := s.logger.With().Str("remote peer", remotePeer.String()).Logger()
+	defer func() {
+		if err := stream.Close(); err != nil {
+			logger.Error().Err(err).Msg("fail to close the replica stream")
+		}
+	}()
+	if !s.primaries[remotePeer] {
+		logger.Error().Msg("reject the keyshare of the peer that is not our primary")
+		_ = stream.Reset()
+		return
+	}
+	var share replicaShare
+	if err := readMsg(stream, &share); err != nil {
+		logger.Error().Err(err).Msg("fail to read the keyshare")
+		return
+	}
+	ack := replicaAck{}
+	if err := s.saveShare(share); err != nil {
+		logger.Error().Err(err).Str("pool pub key", share.PubKey).Msg("fail to save the keyshare")
+		ack.Error = err.Error()
+	} else {
+		logger.Info().Str("pool pub key", share.PubKey).Msg("the keyshare is replicated")
+	}
+	if err := writeMsg(stream, ack); err != nil {
+		logger.Error().Err(err).Msg("fail to send the ack to the primary")
+	}
+}
+
+func (s *Standby) saveShare(share replicaShare) error {
+	buf, ok := box.OpenAnonymous(nil, share.Sealed, s.publicKey, s.privateKey)
+	if !ok {
+		return errors.New("fail to open the sealed keyshare")
+	}
+	defer storage.Zeroize(buf)
+	var state storage.KeygenLocalState
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return fmt.Errorf("fail to unmarshal the keyshare: %w", err)
+	}
+	defer storage.ZeroizeLocalState(&state)
+	if state.PubKey != share.PubKey {
+		return fmt.Errorf("the keyshare of %s holds the key %s", share.PubKey, state.PubKey)
+	}
+	return s.stateManager.SaveLocalState(state)
+}
+
+// Stop remove the stream handler
+func (s *Standby) Stop() {
+	s.host.RemoveStreamHandler(replicaProtocol)
+}
+
+func writeMsg(stream network.Stream, msg interface{}) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the replica message: %w", err)
+	}
+	return p2p.WriteStreamWithBuffer(buf, stream)
+}
+
+func readMsg(stream network.Stream, msg interface{}) error {
+	buf, err := p2p.ReadStreamWithBuffer(stream)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(buf, msg); err != nil {
+		return fmt.Errorf("fail to unmarshal the replica message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package replica
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type ReplicaTestSuite struct{}
+
+var _ = Suite(&ReplicaTestSuite{})
+
+func (s *ReplicaTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+	p2p.ApplyDeadline = false
+}
+
+func newHosts(c *C, n int) []host.Host {
+	mn := mocknet.New()
+	hosts := make([]host.Host, n)
+	for i := range hosts {
+		h, err := mn.GenPeer()
+		c.Assert(err, IsNil)
+		hosts[i] = h
+	}
+	c.Assert(mn.LinkAll(), IsNil)
+	c.Assert(mn.ConnectAllButSelf(), IsNil)
+	return hosts
+}
+
+func (s *ReplicaTestSuite) TestKeys(c *C) {
+	publicKey, privateKey, err := GenerateKey()
+	c.Assert(err, IsNil)
+	derived, err := PublicKey(privateKey)
+	c.Assert(err, IsNil)
+	c.Assert(derived, DeepEquals, publicKey)
+	filePathName := filepath.Join(c.MkDir(), "replica.key")
+	c.Assert(ioutil.WriteFile(filePathName, []byte(hex.EncodeToString(privateKey[:])+"\n"), 0o600), IsNil)
+	key, err := ReadKeyFile(filePathName)
+	c.Assert(err, IsNil)
+	c.Assert(key, DeepEquals, privateKey)
+	_, err = ParseKey("abcd")
+	c.Assert(err, NotNil)
+	_, err = ParseKey("whatever")
+	c.Assert(err, NotNil)
+}
+
+func (s *ReplicaTestSuite) TestReplicate(c *C) {
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	primaryState, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(primaryState.SaveLocalState(state), IsNil)
+
+	hosts := newHosts(c, 3)
+	publicKey, privateKey, err := GenerateKey()
+	c.Assert(err, IsNil)
+	standbyState := storage.NewMemoryStateMgr()
+	standby, err := NewStandby(hosts[1], standbyState, []peer.ID{hosts[0].ID()}, privateKey)
+	c.Assert(err, IsNil)
+	defer standby.Stop()
+	_, err = NewStandby(hosts[1], standbyState, nil, privateKey)
+	c.Assert(err, NotNil)
+
+	// the peer that is not the primary of the standby is rejected
+	stranger, err := NewReplicator(hosts[2], primaryState, hosts[1].ID(), publicKey)
+	c.Assert(err, IsNil)
+	_, err = stranger.Sync()
+	c.Assert(err, NotNil)
+	_, err = standbyState.GetLocalState(state.PubKey)
+	c.Assert(err, NotNil)
+
+	// the keyshare sealed to another replica key can not be opened
+	otherKey, _, err := GenerateKey()
+	c.Assert(err, IsNil)
+	replicator, err := NewReplicator(hosts[0], primaryState, hosts[1].ID(), otherKey)
+	c.Assert(err, IsNil)
+	_, err = replicator.Sync()
+	c.Assert(err, NotNil)
+
+	replicator, err = NewReplicator(hosts[0], primaryState, hosts[1].ID(), publicKey)
+	c.Assert(err, IsNil)
+	sent, err := replicator.Sync()
+	c.Assert(err, IsNil)
+	c.Assert(sent, DeepEquals, []string{state.PubKey})
+	replicated, err := standbyState.GetLocalState(state.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(replicated.LocalPartyKey, Equals, state.LocalPartyKey)
+	c.Assert(replicated.LocalData.Xi, DeepEquals, state.LocalData.Xi)
+
+	// the keyshare the standby has is not sent again until it changes
+	sent, err = replicator.Sync()
+	c.Assert(err, IsNil)
+	c.Assert(sent, HasLen, 0)
+	retiredAt := time.Now().UTC()
+	state.RetiredAt = &retiredAt
+	c.Assert(primaryState.SaveLocalState(state), IsNil)
+	sent, err = replicator.Sync()
+	c.Assert(err, IsNil)
+	c.Assert(sent, DeepEquals, []string{state.PubKey})
+	replicated, err = standbyState.GetLocalState(state.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(replicated.Retired(), Equals, true)
+}
@@ -0,0 +1,34 @@
+package reshare
+
+// Request request to move the key of a pool from the old committee to the new committee, the pub key of the pool
+// does not change, the threshold of the new committee is derived from its size like the keygen
+type Request struct {
+	PoolPubKey string `json:"pool_pub_key"`
+	// OldPartyKeys are the members of the pool who hand over their shares, they should be more than the
+	// threshold of the pool
+	OldPartyKeys []string `json:"old_party_keys"`
+	// NewPartyKeys are the members who hold the shares of the pool once the resharing is done
+	NewPartyKeys []string `json:"new_party_keys"`
+	BlockHeight  int64    `json:"block_height"`
+	Version      string   `json:"tss_version"`
+	// Threshold is the number of the signers the keysign needs once the new committee holds the key, it is derived
+	// from the size of the new committee if it is 0
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// NewRequest create a new instance of reshare.Request
+func NewRequest(poolPubKey string, oldPartyKeys, newPartyKeys []string, blockHeight int64, version string) Request {
+	return Request{
+		PoolPubKey:   poolPubKey,
+		OldPartyKeys: oldPartyKeys,
+		NewPartyKeys: newPartyKeys,
+		BlockHeight:  blockHeight,
+		Version:      version,
+	}
+}
+
+// NewRefreshRequest create a request to refresh the shares of the pool, the committee hands its shares over to
+// itself, so the members get new shares of the same key and the old shares can no longer be combined with them
+func NewRefreshRequest(poolPubKey string, partyKeys []string, blockHeight int64, version string) Request {
+	return NewRequest(poolPubKey, partyKeys, partyKeys, blockHeight, version)
+}
@@ -0,0 +1,38 @@
+package reshare
+
+import (
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+// Response reshare response
+type Response struct {
+	PubKey      string        `json:"pub_key"`
+	PoolAddress string        `json:"pool_address"`
+	Status      common.Status `json:"status"`
+	Blame       blame.Blame   `json:"blame"`
+	// ErrorCode is the machine-readable code of the failure, it is empty if the resharing succeeded
+	ErrorCode errcode.Code `json:"error_code,omitempty"`
+	// Logs are the last log lines of the resharing if it failed, they carry its msg id, peers and round
+	Logs []string `json:"logs,omitempty"`
+	// JoinParty describes the peers that did not join the party of the resharing in time, and when we saw them
+	JoinParty *p2p.JoinPartyReport `json:"join_party,omitempty"`
+	// Verdict is the blame of the failed resharing signed by this node, with the evidences of the offenders, the
+	// chain can check who reported it and what the offenders sent before it slashes them
+	Verdict *blame.Event `json:"verdict,omitempty"`
+	// BlameQuorum are the nodes the participants of the failed resharing agree on once they shared their verdicts, and
+	// the ones they dispute, it is only set if the blame quorum is enabled
+	BlameQuorum *blame.Quorum `json:"blame_quorum,omitempty"`
+}
+
+// NewResponse create a new instance of reshare.Response
+func NewResponse(pk, addr string, status common.Status, blame blame.Blame) Response {
+	return Response{
+		PubKey:      pk,
+		PoolAddress: addr,
+		Status:      status,
+		Blame:       blame,
+	}
+}
@@ -0,0 +1,494 @@
+package reshare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	bcrypto "github.com/binance-chain/tss-lib/crypto"
+	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/binance-chain/tss-lib/ecdsa/resharing"
+	btss "github.com/binance-chain/tss-lib/tss"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// oldKeyScale scales the party keys of the old committee, so a node in both committees has a distinct party in
+// each of them. The lagrange coefficients at 0 do not change when all the keys are scaled, so the old shares
+// still add up to the key, and the new committee keeps the pub keys as its party keys for the later keysigns
+var oldKeyScale = big.NewInt(2)
+
+// wireMsg is a tss-lib message of the resharing, a node in both committees runs a party for each of them, so we
+// tell which party of the sender sent it and which parties of the receiver it goes to
+type wireMsg struct {
+	FromOld     bool   `json:"from_old"`
+	ToOld       bool   `json:"to_old"`
+	ToNew       bool   `json:"to_new"`
+	IsBroadcast bool   `json:"is_broadcast"`
+	Payload     []byte `json:"payload"`
+}
+
+// committee is the sorted party ids of the members of a committee
+type committee struct {
+	ids     btss.SortedPartyIDs
+	pubKeys map[string]string
+	byKey   map[string]*btss.PartyID
+}
+
+func newCommittee(keys []string, scale *big.Int) (*committee, error) {
+	// GetParties sorts the keys, we do not want to touch the keys of the caller
+	sortedKeys := make([]string, len(keys))
+	copy(sortedKeys, keys)
+	sort.Strings(sortedKeys)
+	parties, _, err := conversion.GetParties(sortedKeys, sortedKeys[0])
+	if err != nil {
+		return nil, err
+	}
+	c := &committee{
+		pubKeys: make(map[string]string, len(parties)),
+		byKey:   make(map[string]*btss.PartyID, len(parties)),
+	}
+	var unsorted btss.UnSortedPartyIDs
+	for _, el := range parties {
+		pubKey, err := conversion.PartyIDtoPubKey(el)
+		if err != nil {
+			return nil, err
+		}
+		key := new(big.Int).Mul(el.KeyInt(), scale)
+		partyID := btss.NewPartyID(el.Id, el.Moniker, key)
+		unsorted = append(unsorted, partyID)
+		c.pubKeys[partyID.Id] = pubKey
+		c.byKey[pubKey] = partyID
+	}
+	c.ids = btss.SortPartyIDs(unsorted)
+	return c, nil
+}
+
+func (c *committee) pubKey(partyID *btss.PartyID) (string, bool) {
+	pk, ok := c.pubKeys[partyID.Id]
+	if !ok || c.byKey[pk].KeyInt().Cmp(partyID.KeyInt()) != 0 {
+		return "", false
+	}
+	return pk, true
+}
+
+// TssReshare moves the key of a pool from the old committee to the new committee with the tss-lib resharing
+type TssReshare struct {
+	logger          zerolog.Logger
+	logContext      *common.LogContext
+	localNodePubKey string
+	msgID           string
+	conf            common.TssConfig
+	preParams       *bkg.LocalPreParams
+	stateManager    storage.LocalStateManager
+	broadcastChan   chan *messages.BroadcastMsgChan
+	stopChan        chan struct{}
+	msgChan         chan *p2p.Message
+	blame           blame.Blame
+}
+
+// NewTssReshare create a new instance of TssReshare
+func NewTssReshare(localNodePubKey string,
+	conf common.TssConfig,
+	broadcastChan chan *messages.BroadcastMsgChan,
+	stopChan chan struct{},
+	preParams *bkg.LocalPreParams,
+	msgID string,
+	stateManager storage.LocalStateManager) *TssReshare {
+	logContext := common.NewLogContext(msgID)
+	return &TssReshare{
+		logger:          logContext.Logger("reshare"),
+		logContext:      logContext,
+		localNodePubKey: localNodePubKey,
+		msgID:           msgID,
+		conf:            conf,
+		preParams:       preParams,
+		stateManager:    stateManager,
+		broadcastChan:   broadcastChan,
+		stopChan:        stopChan,
+		msgChan:         make(chan *p2p.Message, 256),
+	}
+}
+
+// GetMsgChannel return the channel we read the messages of the other members from
+func (tr *TssReshare) GetMsgChannel() chan *p2p.Message {
+	return tr.msgChan
+}
+
+// GetLogContext return the log context of the resharing
+func (tr *TssReshare) GetLogContext() *common.LogContext {
+	return tr.logContext
+}
+
+// GetBlame return the members to blame once the resharing fails
+func (tr *TssReshare) GetBlame() blame.Blame {
+	return tr.blame
+}
+
+func contains(keys []string, key string) bool {
+	for _, el := range keys {
+		if el == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Reshare run the resharing, the members of the new committee save their shares of the pool once it is done.
+// The members of the old committee keep their shares, they should retire them once the new committee has signed
+// with the key
+func (tr *TssReshare) Reshare(req Request) (*bcrypto.ECPoint, error) {
+	if len(req.OldPartyKeys) == 0 || len(req.NewPartyKeys) == 0 {
+		return nil, errors.New("both committees should have members")
+	}
+	inOld := contains(req.OldPartyKeys, tr.localNodePubKey)
+	inNew := contains(req.NewPartyKeys, tr.localNodePubKey)
+	if !inOld && !inNew {
+		return nil, errors.New("we are not a member of the resharing")
+	}
+	oldMembers, err := newCommittee(req.OldPartyKeys, oldKeyScale)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the old committee: %w", err)
+	}
+	newMembers, err := newCommittee(req.NewPartyKeys, big.NewInt(1))
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the new committee: %w", err)
+	}
+	newThreshold, err := conversion.GetThreshold(len(req.NewPartyKeys))
+	if req.Threshold > 0 {
+		newThreshold, err = conversion.GetThresholdFromSigners(req.Threshold, len(req.NewPartyKeys))
+	}
+	if err != nil {
+		return nil, err
+	}
+	// only the old committee checks the old threshold, it has the local state of the pool to get it from
+	oldThreshold := len(req.OldPartyKeys) - 1
+	oldCtx := btss.NewPeerContext(oldMembers.ids)
+	newCtx := btss.NewPeerContext(newMembers.ids)
+	outCh := make(chan btss.Message, 2*(len(req.OldPartyKeys)+len(req.NewPartyKeys)+5))
+	oldEndCh := make(chan bkg.LocalPartySaveData, 1)
+	newEndCh := make(chan bkg.LocalPartySaveData, 1)
+
+	var oldParty, newParty btss.Party
+	if inOld {
+		localState, err := tr.stateManager.GetLocalState(req.PoolPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get local keygen state: %w", err)
+		}
+		if localState.Weighted() {
+			return nil, errors.New("the weighted keys can not be reshared")
+		}
+		if localState.Protocol == conversion.SignProtocolMuSig2 {
+			return nil, errors.New("the musig2 keys have no threshold shares to reshare")
+		}
+		for _, el := range req.OldPartyKeys {
+			if !contains(localState.ParticipantKeys, el) {
+				return nil, fmt.Errorf("%s is not a member of the pool", el)
+			}
+		}
+		oldThreshold, err = localState.GetThreshold()
+		if err != nil {
+			return nil, err
+		}
+		if len(req.OldPartyKeys) <= oldThreshold {
+			return nil, fmt.Errorf("not enough members of the old committee, threshold=%d and members=%d", oldThreshold, len(req.OldPartyKeys))
+		}
+		key := localState.LocalData
+		key.Ks = make([]*big.Int, len(localState.LocalData.Ks))
+		for i, el := range localState.LocalData.Ks {
+			key.Ks[i] = new(big.Int).Mul(el, oldKeyScale)
+		}
+		// the old party wipes its share once it is done, it should not touch the share of the local state
+		key.Xi = new(big.Int).Set(localState.LocalData.Xi)
+		params := btss.NewReSharingParameters(oldCtx, newCtx, oldMembers.byKey[tr.localNodePubKey], len(req.OldPartyKeys), oldThreshold, len(req.NewPartyKeys), newThreshold)
+		oldParty = resharing.NewLocalParty(params, key, outCh, oldEndCh)
+	}
+	if inNew {
+		if tr.preParams == nil {
+			return nil, errors.New("error, empty pre-parameters")
+		}
+		save := bkg.NewLocalPartySaveData(len(req.NewPartyKeys))
+		save.LocalPreParams = *tr.preParams
+		params := btss.NewReSharingParameters(oldCtx, newCtx, newMembers.byKey[tr.localNodePubKey], len(req.OldPartyKeys), oldThreshold, len(req.NewPartyKeys), newThreshold)
+		newParty = resharing.NewLocalParty(params, save, outCh, newEndCh)
+	}
+
+	session := &session{
+		TssReshare:   tr,
+		oldCommittee: oldMembers,
+		newCommittee: newMembers,
+		oldParty:     oldParty,
+		newParty:     newParty,
+		newThreshold: newThreshold,
+		heard:        make(map[string]bool),
+	}
+	// the new party waits for the old committee, so we start it first
+	for _, party := range []btss.Party{newParty, oldParty} {
+		if party == nil {
+			continue
+		}
+		if err := party.Start(); err != nil {
+			session.setBrokenBlame(err)
+			return nil, fmt.Errorf("fail to start the resharing party: %w", err)
+		}
+	}
+	return session.process(req, outCh, oldEndCh, newEndCh)
+}
+
+// session is a running resharing with the local parties of each committee, a party is nil if we are not a member
+// of its committee
+type session struct {
+	*TssReshare
+	oldCommittee *committee
+	newCommittee *committee
+	oldParty     btss.Party
+	newParty     btss.Party
+	newThreshold int
+	// heard are the members we have got a message from
+	heard map[string]bool
+}
+
+func (s *session) process(req Request, outCh <-chan btss.Message, oldEndCh, newEndCh <-chan bkg.LocalPartySaveData) (*bcrypto.ECPoint, error) {
+	oldDone := s.oldParty == nil
+	var newKey *bcrypto.ECPoint
+	timeout := time.After(s.conf.KeyGenTimeout)
+	for !oldDone || (s.newParty != nil && newKey == nil) {
+		select {
+		case <-s.stopChan:
+			return nil, errors.New("received exit signal")
+
+		case <-timeout:
+			s.logger.Error().Msgf("fail to reshare in %s", s.conf.KeyGenTimeout)
+			s.setTimeoutBlame()
+			return nil, blame.ErrTssTimeOut
+
+		case msg := <-outCh:
+			if err := s.send(msg); err != nil {
+				return nil, err
+			}
+
+		case m := <-s.msgChan:
+			if err := s.receive(m); err != nil {
+				return nil, err
+			}
+
+		case <-oldEndCh:
+			s.logger.Info().Msg("the old committee has handed over its shares")
+			oldDone = true
+
+		case data := <-newEndCh:
+			pubKey, _, err := conversion.GetTssPubKey(data.ECDSAPub)
+			if err != nil {
+				return nil, fmt.Errorf("fail to get thorchain pubkey: %w", err)
+			}
+			if pubKey != req.PoolPubKey {
+				return nil, fmt.Errorf("the resharing changes the pool pub key to %s", pubKey)
+			}
+			createdAt := time.Now().UTC()
+			state := storage.KeygenLocalState{
+				PubKey:          pubKey,
+				LocalData:       data,
+				ParticipantKeys: req.NewPartyKeys,
+				LocalPartyKey:   s.localNodePubKey,
+				Threshold:       s.newThreshold + 1,
+				Algo:            conversion.AlgoSecp256k1,
+				CreatedAt:       &createdAt,
+			}
+			if err := s.stateManager.SaveLocalState(state); err != nil {
+				return nil, fmt.Errorf("fail to save reshare result to storage: %w", err)
+			}
+			newKey = data.ECDSAPub
+		}
+	}
+	if newKey == nil {
+		// we only hand over our share, the key is the one of the pool
+		localState, err := s.stateManager.GetLocalState(req.PoolPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get local keygen state: %w", err)
+		}
+		return localState.LocalData.ECDSAPub, nil
+	}
+	return newKey, nil
+}
+
+// send deliver the message of a local party to the parties it is routed to, the other local party takes it
+// directly
+func (s *session) send(msg btss.Message) error {
+	wireBytes, routing, err := msg.WireBytes()
+	if err != nil {
+		return fmt.Errorf("fail to get wire bytes: %w", err)
+	}
+	s.logContext.SetRound(msg.Type())
+	fromOld := s.oldParty != nil && msg.GetFrom().KeyInt().Cmp(s.oldParty.PartyID().KeyInt()) == 0
+	to := routing.To
+	if len(to) == 0 {
+		to = append(append([]*btss.PartyID{}, s.oldCommittee.ids...), s.newCommittee.ids...)
+	}
+	type target struct {
+		toOld, toNew bool
+	}
+	targets := make(map[string]*target)
+	for _, el := range to {
+		pubKey, isOld := s.oldCommittee.pubKey(el)
+		if !isOld {
+			var ok bool
+			pubKey, ok = s.newCommittee.pubKey(el)
+			if !ok {
+				s.logger.Error().Msgf("fail to find the member of party %s", el.Id)
+				continue
+			}
+		}
+		t, ok := targets[pubKey]
+		if !ok {
+			t = &target{}
+			targets[pubKey] = t
+		}
+		if isOld {
+			t.toOld = true
+		} else {
+			t.toNew = true
+		}
+	}
+	groups := make(map[target][]peer.ID)
+	for pubKey, t := range targets {
+		if pubKey == s.localNodePubKey {
+			if err := s.updateLocal(wireBytes, msg.GetFrom(), routing.IsBroadcast, t.toOld && !fromOld, t.toNew && fromOld); err != nil {
+				return err
+			}
+			continue
+		}
+		peerID, err := conversion.GetPeerIDFromPubKey(pubKey)
+		if err != nil {
+			return fmt.Errorf("fail to convert pub key to peer id: %w", err)
+		}
+		groups[*t] = append(groups[*t], peerID)
+	}
+	for t, peers := range groups {
+		buf, err := json.Marshal(wireMsg{
+			FromOld:     fromOld,
+			ToOld:       t.toOld,
+			ToNew:       t.toNew,
+			IsBroadcast: routing.IsBroadcast,
+			Payload:     wireBytes,
+		})
+		if err != nil {
+			return fmt.Errorf("fail to marshal the reshare message: %w", err)
+		}
+		s.broadcastChan <- &messages.BroadcastMsgChan{
+			WrappedMessage: messages.WrappedMessage{
+				MessageType: messages.TSSReshareMsg,
+				MsgID:       s.msgID,
+				Payload:     buf,
+			},
+			PeersID: peers,
+		}
+	}
+	return nil
+}
+
+// receive apply the message of another member to the local parties it is routed to
+func (s *session) receive(m *p2p.Message) error {
+	wrappedMsg, err := messages.UnmarshalWrappedMessage(m.Payload)
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("fail to unmarshal the wrapped message from %s", m.PeerID)
+		return nil
+	}
+	var msg wireMsg
+	if err := json.Unmarshal(wrappedMsg.Payload, &msg); err != nil {
+		s.logger.Error().Err(err).Msgf("fail to unmarshal the reshare message from %s", m.PeerID)
+		return nil
+	}
+	pubKey, err := conversion.GetPubKeyFromPeerID(m.PeerID.String())
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("fail to get the pub key of %s", m.PeerID)
+		return nil
+	}
+	s.heard[pubKey] = true
+	c := s.newCommittee
+	if msg.FromOld {
+		c = s.oldCommittee
+	}
+	from, ok := c.byKey[pubKey]
+	if !ok {
+		s.logger.Error().Msgf("receive a reshare message from %s who is not a member of the committee", m.PeerID)
+		return nil
+	}
+	return s.updateLocal(msg.Payload, from, msg.IsBroadcast, msg.ToOld, msg.ToNew)
+}
+
+func (s *session) updateLocal(wireBytes []byte, from *btss.PartyID, isBroadcast, toOld, toNew bool) error {
+	for _, el := range []struct {
+		party btss.Party
+		to    bool
+	}{{s.oldParty, toOld}, {s.newParty, toNew}} {
+		if el.party == nil || !el.to {
+			continue
+		}
+		if _, err := el.party.UpdateFromBytes(wireBytes, from, isBroadcast); err != nil {
+			s.setBrokenBlame(err)
+			return fmt.Errorf("fail to apply the reshare message: %w", err)
+		}
+	}
+	return nil
+}
+
+// memberPubKey return the pub key of the member of the party of either committee
+func (s *session) memberPubKey(partyID *btss.PartyID) (string, bool) {
+	if pk, ok := s.oldCommittee.pubKey(partyID); ok {
+		return pk, true
+	}
+	return s.newCommittee.pubKey(partyID)
+}
+
+func (s *session) setBrokenBlame(err *btss.Error) {
+	var nodes []blame.Node
+	for _, el := range err.Culprits() {
+		if pk, ok := s.memberPubKey(el); ok {
+			nodes = append(nodes, blame.NewNode(pk, nil, nil))
+		}
+	}
+	s.blame = blame.NewBlame(blame.TssBrokenMsg, nodes)
+}
+
+// setTimeoutBlame blame the members the local parties are still waiting for
+func (s *session) setTimeoutBlame() {
+	seen := make(map[string]bool)
+	var nodes []blame.Node
+	for _, party := range []btss.Party{s.oldParty, s.newParty} {
+		if party == nil {
+			continue
+		}
+		for _, el := range party.WaitingFor() {
+			pk, ok := s.memberPubKey(el)
+			if !ok || seen[pk] || pk == s.localNodePubKey {
+				continue
+			}
+			seen[pk] = true
+			nodes = append(nodes, blame.NewNode(pk, nil, nil))
+		}
+	}
+	// a party only moves to the next round on a message, so it waits for nobody if it has got none
+	if len(nodes) == 0 {
+		for _, c := range []*committee{s.oldCommittee, s.newCommittee} {
+			for _, el := range c.ids {
+				pk := c.pubKeys[el.Id]
+				if seen[pk] || s.heard[pk] || pk == s.localNodePubKey {
+					continue
+				}
+				seen[pk] = true
+				nodes = append(nodes, blame.NewNode(pk, nil, nil))
+			}
+		}
+	}
+	s.blame = blame.NewBlame(blame.TssTimeout, nodes)
+}
@@ -0,0 +1,281 @@
+package reshare
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bkg "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	btss "github.com/binance-chain/tss-lib/tss"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+var (
+	testPubKeys = []string{
+		"thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69",
+		"thorpub1addwnpepqfjcw5l4ay5t00c32mmlky7qrppepxzdlkcwfs2fd5u73qrwna0vzag3y4j",
+		"thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3",
+		"thorpub1addwnpepqtspqyy6gk22u37ztra4hq3hdakc0w0k60sfy849mlml2vrpfr0wvm6uz09",
+	}
+	testPriKeyArr = []string{
+		"6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=",
+		"528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=",
+		"JFB2LIJZtK+KasK00NcNil4PRJS4c4liOnK0nDalhqc=",
+		"vLMGhVXMOXQVnAE3BUU8fwNj/q0ZbndKkwmxfS5EN9Y=",
+	}
+)
+
+type TssReshareTestSuite struct {
+	comms       []*p2p.Communication
+	localStates []storage.KeygenLocalState
+	preParams   []*bkg.LocalPreParams
+}
+
+var _ = Suite(&TssReshareTestSuite{})
+
+func getPreparams(c *C) []*bkg.LocalPreParams {
+	buf, err := ioutil.ReadFile(path.Join("../test_data", "preParam_test.data"))
+	c.Assert(err, IsNil)
+	var preParams []*bkg.LocalPreParams
+	for _, item := range strings.Split(string(buf), "\n") {
+		var preParam bkg.LocalPreParams
+		val, err := hex.DecodeString(item)
+		c.Assert(err, IsNil)
+		c.Assert(json.Unmarshal(val, &preParam), IsNil)
+		preParams = append(preParams, &preParam)
+	}
+	return preParams
+}
+
+func (s *TssReshareTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+	common.InitLog("info", true, "reshare_test")
+	ports := []int{
+		20666, 20667, 20668, 20669,
+	}
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/20666/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	multiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	s.preParams = getPreparams(c)
+	for i := range testPriKeyArr {
+		buf, err := base64.StdEncoding.DecodeString(testPriKeyArr[i])
+		c.Assert(err, IsNil)
+		var bootstrapPeers []maddr.Multiaddr
+		if i > 0 {
+			bootstrapPeers = []maddr.Multiaddr{multiAddr}
+		}
+		comm, err := p2p.NewCommunication("asgard", bootstrapPeers, ports[i], "")
+		c.Assert(err, IsNil)
+		c.Assert(comm.Start(buf), IsNil)
+		s.comms = append(s.comms, comm)
+
+		data, err := ioutil.ReadFile(fmt.Sprintf("../test_data/keysign_data/%d.json", i))
+		c.Assert(err, IsNil)
+		var localState storage.KeygenLocalState
+		c.Assert(json.Unmarshal(data, &localState), IsNil)
+		s.localStates = append(s.localStates, localState)
+	}
+	// the nodes only know each other once the discovery is done, the streams to the others fail till then
+	for _, comm := range s.comms {
+		for _, other := range s.comms {
+			for i := 0; i < 100 && len(comm.GetHost().Peerstore().Addrs(other.GetHost().ID())) == 0; i++ {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}
+}
+
+func (s *TssReshareTestSuite) TearDownSuite(c *C) {
+	for _, el := range s.comms {
+		c.Assert(el.Stop(), IsNil)
+	}
+}
+
+func (s *TssReshareTestSuite) TestReshare(c *C) {
+	poolPubKey := s.localStates[0].PubKey
+	// node 0 leaves, node 2 joins, nodes 1 and 3 are in both committees
+	oldMembers := []int{0, 1, 3}
+	newMembers := []int{1, 2, 3}
+	var oldKeys, newKeys []string
+	for _, idx := range oldMembers {
+		oldKeys = append(oldKeys, s.localStates[idx].LocalPartyKey)
+	}
+	for _, idx := range newMembers {
+		newKeys = append(newKeys, s.localStates[idx].LocalPartyKey)
+	}
+	req := NewRequest(poolPubKey, oldKeys, newKeys, 10, "")
+	// all the new committee signs, the size of the committee would derive 2 signers
+	req.Threshold = 3
+	msgID, err := common.MsgToHashString([]byte("reshare"))
+	c.Assert(err, IsNil)
+	conf := common.TssConfig{
+		KeyGenTimeout: 60 * time.Second,
+	}
+
+	stateMgrs := make([]*storage.FileStateMgr, len(s.comms))
+	instances := make([]*TssReshare, len(s.comms))
+	// all the nodes subscribe before any of them sends, the messages of an unknown ceremony are dropped
+	for i, comm := range s.comms {
+		stateMgr, err := storage.NewFileStateMgr(c.MkDir())
+		c.Assert(err, IsNil)
+		c.Assert(stateMgr.SaveLocalState(s.localStates[i]), IsNil)
+		stateMgrs[i] = stateMgr
+		instances[i] = NewTssReshare(s.localStates[i].LocalPartyKey, conf, comm.BroadcastMsgChan, make(chan struct{}), s.preParams[i], msgID, stateMgr)
+		comm.SetSubscribe(messages.TSSReshareMsg, msgID, instances[i].GetMsgChannel())
+	}
+	errs := make([]error, len(s.comms))
+	wg := sync.WaitGroup{}
+	for i := range s.comms {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tr := instances[i]
+			pubKey, err := tr.Reshare(req)
+			if err == nil && !pubKey.Equals(s.localStates[0].LocalData.ECDSAPub) {
+				err = fmt.Errorf("node %d gets another key", i)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for _, comm := range s.comms {
+		comm.CancelSubscribe(messages.TSSReshareMsg, msgID)
+	}
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+
+	// the node who leaves keeps its share, the new committee adds up to the same key
+	state, err := stateMgrs[0].GetLocalState(poolPubKey)
+	c.Assert(err, IsNil)
+	c.Assert(state.LocalData.Xi.Cmp(s.localStates[0].LocalData.Xi), Equals, 0)
+	var ks []*big.Int
+	var xs []*big.Int
+	for _, idx := range newMembers {
+		state, err := stateMgrs[idx].GetLocalState(poolPubKey)
+		c.Assert(err, IsNil)
+		c.Assert(state.ParticipantKeys, DeepEquals, newKeys)
+		c.Assert(state.Threshold, Equals, 3)
+		c.Assert(state.LocalData.Xi.Cmp(s.localStates[idx].LocalData.Xi), Not(Equals), 0)
+		ks = append(ks, state.LocalData.ShareID)
+		xs = append(xs, state.LocalData.Xi)
+	}
+	s.checkShares(c, ks, xs)
+}
+
+// checkShares check the shares add up to the key of the pool
+func (s *TssReshareTestSuite) checkShares(c *C, ks, xs []*big.Int) {
+	curve := btss.EC()
+	sum := new(big.Int)
+	for i := range ks {
+		lambda := big.NewInt(1)
+		for j := range ks {
+			if i == j {
+				continue
+			}
+			den := new(big.Int).Sub(ks[j], ks[i])
+			den.ModInverse(den.Mod(den, curve.Params().N), curve.Params().N)
+			lambda.Mul(lambda, ks[j])
+			lambda.Mul(lambda, den)
+			lambda.Mod(lambda, curve.Params().N)
+		}
+		sum.Add(sum, new(big.Int).Mul(lambda, xs[i]))
+	}
+	sum.Mod(sum, curve.Params().N)
+	x, y := curve.ScalarBaseMult(sum.Bytes())
+	c.Assert(x.Cmp(s.localStates[0].LocalData.ECDSAPub.X()), Equals, 0)
+	c.Assert(y.Cmp(s.localStates[0].LocalData.ECDSAPub.Y()), Equals, 0)
+}
+
+func (s *TssReshareTestSuite) TestRefresh(c *C) {
+	poolPubKey := s.localStates[0].PubKey
+	req := NewRefreshRequest(poolPubKey, s.localStates[0].ParticipantKeys, 10, "")
+	msgID, err := common.MsgToHashString([]byte("refresh"))
+	c.Assert(err, IsNil)
+	conf := common.TssConfig{
+		KeyGenTimeout: 60 * time.Second,
+	}
+
+	stateMgrs := make([]*storage.FileStateMgr, len(s.comms))
+	instances := make([]*TssReshare, len(s.comms))
+	// all the nodes subscribe before any of them sends, the messages of an unknown ceremony are dropped
+	for i, comm := range s.comms {
+		stateMgr, err := storage.NewFileStateMgr(c.MkDir())
+		c.Assert(err, IsNil)
+		c.Assert(stateMgr.SaveLocalState(s.localStates[i]), IsNil)
+		stateMgrs[i] = stateMgr
+		instances[i] = NewTssReshare(s.localStates[i].LocalPartyKey, conf, comm.BroadcastMsgChan, make(chan struct{}), s.preParams[i], msgID, stateMgr)
+		comm.SetSubscribe(messages.TSSReshareMsg, msgID, instances[i].GetMsgChannel())
+	}
+	errs := make([]error, len(s.comms))
+	wg := sync.WaitGroup{}
+	for i := range s.comms {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tr := instances[i]
+			_, errs[i] = tr.Reshare(req)
+		}(i)
+	}
+	wg.Wait()
+	for _, comm := range s.comms {
+		comm.CancelSubscribe(messages.TSSReshareMsg, msgID)
+	}
+	for _, err := range errs {
+		c.Assert(err, IsNil)
+	}
+
+	// all the shares are replaced and keep the share ids of the members
+	var ks []*big.Int
+	var xs []*big.Int
+	for i := range s.comms {
+		state, err := stateMgrs[i].GetLocalState(poolPubKey)
+		c.Assert(err, IsNil)
+		c.Assert(state.LocalData.ShareID.Cmp(s.localStates[i].LocalData.ShareID), Equals, 0)
+		c.Assert(state.LocalData.Xi.Cmp(s.localStates[i].LocalData.Xi), Not(Equals), 0)
+		ks = append(ks, state.LocalData.ShareID)
+		xs = append(xs, state.LocalData.Xi)
+	}
+	s.checkShares(c, ks, xs)
+}
+
+func (s *TssReshareTestSuite) TestReshareTimeout(c *C) {
+	poolPubKey := s.localStates[0].PubKey
+	msgID, err := common.MsgToHashString([]byte("reshare-timeout"))
+	c.Assert(err, IsNil)
+	stateMgr, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateMgr.SaveLocalState(s.localStates[0]), IsNil)
+	comm := s.comms[0]
+	tr := NewTssReshare(s.localStates[0].LocalPartyKey, common.TssConfig{KeyGenTimeout: time.Second}, comm.BroadcastMsgChan, make(chan struct{}), s.preParams[0], msgID, stateMgr)
+	comm.SetSubscribe(messages.TSSReshareMsg, msgID, tr.GetMsgChannel())
+	defer comm.CancelSubscribe(messages.TSSReshareMsg, msgID)
+	req := NewRequest(poolPubKey, []string{testPubKeys[0], testPubKeys[1], testPubKeys[3]}, []string{testPubKeys[1], testPubKeys[2]}, 10, "")
+	_, err = tr.Reshare(req)
+	c.Assert(err, Equals, blame.ErrTssTimeOut)
+	c.Assert(tr.GetBlame().FailReason, Equals, blame.TssTimeout)
+	c.Assert(tr.GetBlame().BlameNodes, Not(HasLen), 0)
+
+	// the old committee should be more than the threshold of the pool
+	req = NewRequest(poolPubKey, []string{testPubKeys[0], testPubKeys[1]}, []string{testPubKeys[1], testPubKeys[2]}, 10, "")
+	_, err = tr.Reshare(req)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,246 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binance-chain/tss-lib/ecdsa/signing"
+	"github.com/binance-chain/tss-lib/test"
+	btss "github.com/binance-chain/tss-lib/tss"
+	golog "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// benchSigners are the parties of the test key that sign in the benchmarks, 3 of the 4 parties, so FROST, MuSig2
+// and GG20 sign with the same committee. The messages are routed in memory, there is no network latency
+var benchSigners = []int{0, 1, 3}
+
+func loadBenchSigners(b *testing.B) ([]storage.KeygenLocalState, []string) {
+	conversion.SetupBech32Prefix()
+	if err := golog.SetLogLevel("tss-lib", "warn"); err != nil {
+		b.Fatal(err)
+	}
+	var states []storage.KeygenLocalState
+	var signerPubKeys []string
+	for _, idx := range benchSigners {
+		data, err := ioutil.ReadFile(fmt.Sprintf("../test_data/keysign_data/%d.json", idx))
+		if err != nil {
+			b.Fatal(err)
+		}
+		var state storage.KeygenLocalState
+		if err := json.Unmarshal(data, &state); err != nil {
+			b.Fatal(err)
+		}
+		states = append(states, state)
+		signerPubKeys = append(signerPubKeys, state.LocalPartyKey)
+	}
+	return states, signerPubKeys
+}
+
+func BenchmarkSignFROST(b *testing.B) {
+	states, signerPubKeys := loadBenchSigners(b)
+	msg := sha256.Sum256([]byte("benchmark"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		signFROST(b, states, signerPubKeys, fmt.Sprintf("bench-%d", i), msg[:])
+	}
+}
+
+func BenchmarkSignGG20(b *testing.B) {
+	states, signerPubKeys := loadBenchSigners(b)
+	msg := sha256.Sum256([]byte("benchmark"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		signGG20(b, states, signerPubKeys, msg[:])
+	}
+}
+
+func BenchmarkSignMuSig2(b *testing.B) {
+	_, signerPubKeys := loadBenchSigners(b)
+	states := make([]storage.KeygenLocalState, len(signerPubKeys))
+	runInMemory(b, signerPubKeys, "bench-musig2-keygen", func(i int, ts *TssSchnorr) {
+		state, err := ts.GenerateMuSig2Key(signerPubKeys, signerPubKeys[i])
+		if err != nil {
+			b.Error(err)
+		}
+		states[i] = state
+	})
+	msg := sha256.Sum256([]byte("benchmark"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		signFROST(b, states, signerPubKeys, fmt.Sprintf("bench-%d", i), msg[:])
+	}
+}
+
+// benchmarkWireFormat sign with a 20 node MuSig2 committee, every message between the nodes is encoded by
+// marshal, it reports the size of the messages the nodes broadcast in a keysign
+func benchmarkWireFormat(b *testing.B, marshal func(*messages.WrappedMessage) ([]byte, error)) {
+	conversion.SetupBech32Prefix()
+	signerPubKeys := make([]string, 20)
+	for i := range signerPubKeys {
+		signerPubKeys[i] = conversion.GetRandomPubKey()
+	}
+	// the parties sort the keys in place, they are sorted already so the index of each party stays the same
+	sort.Strings(signerPubKeys)
+	states := make([]storage.KeygenLocalState, len(signerPubKeys))
+	runInMemory(b, signerPubKeys, "bench-wire-keygen", func(i int, ts *TssSchnorr) {
+		state, err := ts.GenerateMuSig2Key(signerPubKeys, signerPubKeys[i])
+		if err != nil {
+			b.Error(err)
+		}
+		states[i] = state
+	})
+	var sent int64
+	countingMarshal := func(msg *messages.WrappedMessage) ([]byte, error) {
+		buf, err := marshal(msg)
+		atomic.AddInt64(&sent, int64(len(buf)))
+		return buf, err
+	}
+	msg := sha256.Sum256([]byte("benchmark"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runInMemoryWire(b, signerPubKeys, fmt.Sprintf("bench-%d", i), countingMarshal, func(j int, ts *TssSchnorr) {
+			if _, _, err := ts.SignMessages([][]byte{msg[:]}, states[j], signerPubKeys, false, nil); err != nil {
+				b.Error(err)
+			}
+		})
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&sent))/float64(b.N), "wire-B/op")
+}
+
+func BenchmarkKeysign20JSON(b *testing.B) {
+	benchmarkWireFormat(b, func(msg *messages.WrappedMessage) ([]byte, error) {
+		return json.Marshal(msg)
+	})
+}
+
+func BenchmarkKeysign20Proto(b *testing.B) {
+	benchmarkWireFormat(b, func(msg *messages.WrappedMessage) ([]byte, error) {
+		return msg.MarshalProto()
+	})
+}
+
+// signFROST sign the message with the schnorr keysign, the musig2 keys sign with MuSig2
+func signFROST(b *testing.B, states []storage.KeygenLocalState, signerPubKeys []string, msgID string, msg []byte) {
+	runInMemory(b, signerPubKeys, msgID, func(i int, ts *TssSchnorr) {
+		if _, _, err := ts.SignMessages([][]byte{msg}, states[i], signerPubKeys, false, nil); err != nil {
+			b.Error(err)
+		}
+	})
+}
+
+// runInMemory run the ceremony of each party with the messages routed in memory
+func runInMemory(b *testing.B, pubKeys []string, msgID string, run func(i int, ts *TssSchnorr)) {
+	runInMemoryWire(b, pubKeys, msgID, func(msg *messages.WrappedMessage) ([]byte, error) {
+		return json.Marshal(msg)
+	}, run)
+}
+
+// runInMemoryWire run the ceremony of each party with the messages encoded by marshal and routed in memory
+func runInMemoryWire(b *testing.B, pubKeys []string, msgID string, marshal func(*messages.WrappedMessage) ([]byte, error), run func(i int, ts *TssSchnorr)) {
+	conf := common.TssConfig{KeySignTimeout: time.Minute, KeyGenTimeout: time.Minute}
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	instances := make(map[peer.ID]*TssSchnorr, len(pubKeys))
+	broadcastChans := make(map[peer.ID]chan *messages.BroadcastMsgChan, len(pubKeys))
+	for _, el := range pubKeys {
+		peerID, err := conversion.GetPeerIDFromPubKey(el)
+		if err != nil {
+			b.Fatal(err)
+		}
+		broadcastChans[peerID] = make(chan *messages.BroadcastMsgChan, len(pubKeys))
+		instances[peerID] = NewTssSchnorr(peerID.String(), conf, broadcastChans[peerID], stopChan, msgID)
+	}
+	for from, broadcastChan := range broadcastChans {
+		go func(from peer.ID, broadcastChan chan *messages.BroadcastMsgChan) {
+			for {
+				select {
+				case <-stopChan:
+					return
+				case msg := <-broadcastChan:
+					buf, err := marshal(&msg.WrappedMessage)
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					for _, to := range msg.PeersID {
+						instances[to].GetMsgChannel() <- &p2p.Message{PeerID: from, Payload: buf}
+					}
+				}
+			}
+		}(from, broadcastChan)
+	}
+	wg := sync.WaitGroup{}
+	for i, el := range pubKeys {
+		peerID, err := conversion.GetPeerIDFromPubKey(el)
+		if err != nil {
+			b.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int, ts *TssSchnorr) {
+			defer wg.Done()
+			run(i, ts)
+		}(i, instances[peerID])
+	}
+	wg.Wait()
+}
+
+func signGG20(b *testing.B, states []storage.KeygenLocalState, signerPubKeys []string, msg []byte) {
+	m := new(big.Int).SetBytes(msg)
+	outCh := make(chan btss.Message, 2*len(states))
+	endCh := make(chan *signing.SignatureData, len(states))
+	errCh := make(chan *btss.Error, len(states))
+	parties := make([]btss.Party, len(states))
+	for _, state := range states {
+		partiesID, localPartyID, err := conversion.GetParties(signerPubKeys, state.LocalPartyKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+		threshold, err := state.GetThreshold()
+		if err != nil {
+			b.Fatal(err)
+		}
+		params := btss.NewParameters(btss.NewPeerContext(partiesID), localPartyID, len(partiesID), threshold)
+		parties[localPartyID.Index] = signing.NewLocalParty(m, params, state.LocalData, outCh, endCh)
+	}
+	for _, el := range parties {
+		go func(party btss.Party) {
+			if err := party.Start(); err != nil {
+				errCh <- err
+			}
+		}(el)
+	}
+	for ended := 0; ended < len(parties); {
+		select {
+		case err := <-errCh:
+			b.Fatal(err)
+		case msg := <-outCh:
+			dest := msg.GetTo()
+			if dest == nil {
+				for _, el := range parties {
+					if el.PartyID().Index != msg.GetFrom().Index {
+						go test.SharedPartyUpdater(el, msg, errCh)
+					}
+				}
+				continue
+			}
+			go test.SharedPartyUpdater(parties[dest[0].Index], msg, errCh)
+		case <-endCh:
+			ended++
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+const (
+	tagChallenge = "BIP0340/challenge"
+	tagTapTweak  = "TapTweak"
+	// tagBinding is the tag of the binding factors of the nonce commitments, it is not part of BIP-340
+	tagBinding = "go-tss/schnorr/binding"
+)
+
+var curve = btcec.S256()
+
+// taggedHash is the tagged hash of BIP-340, sha256(sha256(tag) || sha256(tag) || data)
+func taggedHash(tag string, data ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, el := range data {
+		h.Write(el)
+	}
+	return h.Sum(nil)
+}
+
+// hashToScalar return the tagged hash of the data reduced by the curve order
+func hashToScalar(tag string, data ...[]byte) *big.Int {
+	e := new(big.Int).SetBytes(taggedHash(tag, data...))
+	return e.Mod(e, curve.N)
+}
+
+// bytes32 return the 32 bytes big endian encoding of the integer
+func bytes32(v *big.Int) []byte {
+	buf := make([]byte, 32)
+	return v.FillBytes(buf)
+}
+
+func isEven(y *big.Int) bool {
+	return y.Bit(0) == 0
+}
+
+func negateY(y *big.Int) *big.Int {
+	if y.Sign() == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(curve.P, y)
+}
+
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+// liftX return the point with the given x coordinate and the even y coordinate
+func liftX(x *big.Int) (*big.Int, *big.Int, error) {
+	if x.Cmp(curve.P) >= 0 {
+		return nil, nil, errors.New("x is not a field element")
+	}
+	c := new(big.Int).Exp(x, big.NewInt(3), curve.P)
+	c.Add(c, curve.B)
+	c.Mod(c, curve.P)
+	exp := new(big.Int).Add(curve.P, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(c, exp, curve.P)
+	if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(c) != 0 {
+		return nil, nil, errors.New("x is not on the curve")
+	}
+	if !isEven(y) {
+		y = negateY(y)
+	}
+	return x, y, nil
+}
+
+// Verify check the BIP-340 signature of the message against the x-only pub key
+func Verify(pubKey, msg, sig []byte) bool {
+	if len(pubKey) != 32 || len(sig) != 64 {
+		return false
+	}
+	px, py, err := liftX(new(big.Int).SetBytes(pubKey))
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	e := hashToScalar(tagChallenge, sig[:32], pubKey, msg)
+	// R = s*G - e*P
+	sx, sy := curve.ScalarBaseMult(bytes32(s))
+	ex, ey := curve.ScalarMult(px, py, bytes32(e))
+	rx, ry := curve.Add(sx, sy, ex, negateY(ey))
+	if isInfinity(rx, ry) {
+		return false
+	}
+	return isEven(ry) && rx.Cmp(r) == 0
+}
+
+// TaprootTweak return the BIP-341 tweak of the output key that commits to the given merkle root of the script
+// tree, the merkle root is empty if the output has no script path
+func TaprootTweak(internalKey, merkleRoot []byte) (*big.Int, error) {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return nil, errors.New("the merkle root should be 32 bytes")
+	}
+	t := new(big.Int).SetBytes(taggedHash(tagTapTweak, internalKey, merkleRoot))
+	if t.Cmp(curve.N) >= 0 {
+		return nil, errors.New("the tweak is out of range")
+	}
+	return t, nil
+}
@@ -0,0 +1,79 @@
+package schnorr
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type BIP340TestSuite struct{}
+
+var _ = Suite(&BIP340TestSuite{})
+
+func mustDecode(c *C, s string) []byte {
+	buf, err := hex.DecodeString(s)
+	c.Assert(err, IsNil)
+	return buf
+}
+
+func (s *BIP340TestSuite) TestVerify(c *C) {
+	// the test vectors of BIP-340
+	testCases := []struct {
+		pubKey string
+		msg    string
+		sig    string
+		valid  bool
+	}{
+		{
+			pubKey: "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			msg:    "0000000000000000000000000000000000000000000000000000000000000000",
+			sig:    "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+			valid:  true,
+		},
+		{
+			pubKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			msg:    "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			sig:    "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+			valid:  true,
+		},
+		{
+			// the pub key is not on the curve
+			pubKey: "EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+			msg:    "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			sig:    "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+			valid:  false,
+		},
+		{
+			// R has an odd y
+			pubKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			msg:    "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			sig:    "FFF97BD5755EEEA420453A14355235D382F6472F8568A18B2F057A14602975563CC27944640AC607CD107AE10923D9EF7A73C643E166BE5EBEAFA34B1AC553E2",
+			valid:  false,
+		},
+	}
+	for _, tc := range testCases {
+		c.Check(Verify(mustDecode(c, tc.pubKey), mustDecode(c, tc.msg), mustDecode(c, tc.sig)), Equals, tc.valid)
+	}
+	c.Assert(Verify(make([]byte, 31), make([]byte, 32), make([]byte, 64)), Equals, false)
+}
+
+func (s *BIP340TestSuite) TestTaprootOutputKey(c *C) {
+	// the first receiving address of the test vectors of BIP-86
+	px, py, err := liftX(new(big.Int).SetBytes(mustDecode(c, "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115")))
+	c.Assert(err, IsNil)
+	sk, err := newSigningKey(px, py, true, nil)
+	c.Assert(err, IsNil)
+	c.Assert(hex.EncodeToString(sk.x), Equals, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c")
+
+	sk, err = newSigningKey(px, negateY(py), false, nil)
+	c.Assert(err, IsNil)
+	c.Assert(sk.negShares, Equals, true)
+	c.Assert(sk.y.Cmp(py), Equals, 0)
+
+	_, err = TaprootTweak(sk.x, []byte("short"))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,213 @@
+package schnorr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/binance-chain/tss-lib/crypto"
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	btss "github.com/binance-chain/tss-lib/tss"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/storage"
+)
+
+const (
+	tagKeyAggList = "KeyAgg list"
+	tagKeyAggCoef = "KeyAgg coefficient"
+	tagNonceCoef  = "MuSig/noncecoef"
+)
+
+// keyAgg return the BIP-327 key aggregation coefficient of each of the compressed pub keys and the aggregate key,
+// the coefficients keep a party from picking its key to cancel out the keys of the others
+func keyAgg(pubKeys [][]byte) ([]*big.Int, *big.Int, *big.Int, error) {
+	list := taggedHash(tagKeyAggList, pubKeys...)
+	var second []byte
+	for _, el := range pubKeys[1:] {
+		if !bytes.Equal(el, pubKeys[0]) {
+			second = el
+			break
+		}
+	}
+	coefs := make([]*big.Int, len(pubKeys))
+	qx, qy := new(big.Int), new(big.Int)
+	for i, el := range pubKeys {
+		px, py, err := decompress(el)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("fail to parse the pub key of party %d: %w", i, err)
+		}
+		// the second distinct key has the coefficient 1, as BIP-327 does
+		if bytes.Equal(el, second) {
+			coefs[i] = big.NewInt(1)
+		} else {
+			coefs[i] = hashToScalar(tagKeyAggCoef, list, el)
+		}
+		ax, ay := curve.ScalarMult(px, py, bytes32(coefs[i]))
+		qx, qy = curve.Add(qx, qy, ax, ay)
+	}
+	if isInfinity(qx, qy) {
+		return nil, nil, nil, errors.New("the aggregate key is the point at infinity")
+	}
+	return coefs, qx, qy, nil
+}
+
+// encodeNonce return the compressed nonce point, the point at infinity is the 33 zero bytes of BIP-327
+func encodeNonce(x, y *big.Int) []byte {
+	if isInfinity(x, y) {
+		return make([]byte, 33)
+	}
+	return compress(x, y)
+}
+
+// muSig2Binding bind all the signers with the nonce coefficient of BIP-327, it hashes the aggregate nonces, the
+// key and the message
+func muSig2Binding(m []byte, _ int, sk *signingKey, signers []*signer, _ []*roundMsg, d, e []point) []*big.Int {
+	r1x, r1y, r2x, r2y := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for j := range signers {
+		r1x, r1y = curve.Add(r1x, r1y, d[j].x, d[j].y)
+		r2x, r2y = curve.Add(r2x, r2y, e[j].x, e[j].y)
+	}
+	b := hashToScalar(tagNonceCoef, encodeNonce(r1x, r1y), encodeNonce(r2x, r2y), sk.x, m)
+	rho := make([]*big.Int, len(signers))
+	for j := range rho {
+		rho[j] = b
+	}
+	return rho
+}
+
+// muSig2Parties return the parties of the keys sorted by their party key, the index of the local party and the
+// peers we send our messages to
+func muSig2Parties(keys []string, localPartyKey string) ([]*signer, int, []peer.ID, error) {
+	partiesID, localPartyID, err := conversion.GetParties(keys, localPartyKey)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("fail to form the musig2 party: %w", err)
+	}
+	signers := make([]*signer, len(partiesID))
+	localIdx := -1
+	var peers []peer.ID
+	for i, el := range partiesID {
+		pubKey, err := conversion.PartyIDtoPubKey(el)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("fail to get the pub key of the party: %w", err)
+		}
+		peerID, err := conversion.GetPeerIDFromPubKey(pubKey)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("fail to get the peer id of the party: %w", err)
+		}
+		signers[i] = &signer{pubKey: pubKey, peerID: peerID, key: el.KeyInt()}
+		if el.Id == localPartyID.Id {
+			localIdx = i
+			continue
+		}
+		peers = append(peers, peerID)
+	}
+	return signers, localIdx, peers, nil
+}
+
+// muSig2SigningParty return the signers of the musig2 key with their pub keys and key aggregation coefficients,
+// the key is n-of-n so all its participants have to sign
+func muSig2SigningParty(localState storage.KeygenLocalState, signerPubKeys []string) ([]*signer, int, []peer.ID, error) {
+	if localState.LocalData.ECDSAPub == nil || localState.LocalData.Xi == nil {
+		return nil, 0, nil, errors.New("the local state has no key share")
+	}
+	if signerSetKey(signerPubKeys) != signerSetKey(localState.ParticipantKeys) {
+		return nil, 0, nil, fmt.Errorf("the musig2 key needs all its %d participants to sign", len(localState.ParticipantKeys))
+	}
+	signers, localIdx, peers, err := muSig2Parties(localState.ParticipantKeys, localState.LocalPartyKey)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(localState.LocalData.BigXj) != len(signers) || len(localState.LocalData.Ks) != len(signers) {
+		return nil, 0, nil, errors.New("the local state does not have the pub key of each participant")
+	}
+	pubKeys := make([][]byte, len(signers))
+	for i, el := range signers {
+		if localState.LocalData.Ks[i] == nil || localState.LocalData.Ks[i].Cmp(el.key) != 0 || localState.LocalData.BigXj[i] == nil {
+			return nil, 0, nil, fmt.Errorf("the local state has no pub key of %s", el.pubKey)
+		}
+		el.x, el.y = localState.LocalData.BigXj[i].X(), localState.LocalData.BigXj[i].Y()
+		pubKeys[i] = compress(el.x, el.y)
+	}
+	coefs, qx, qy, err := keyAgg(pubKeys)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if qx.Cmp(localState.LocalData.ECDSAPub.X()) != 0 || qy.Cmp(localState.LocalData.ECDSAPub.Y()) != 0 {
+		return nil, 0, nil, errors.New("the pub keys of the participants do not aggregate to the pool key")
+	}
+	for i, el := range signers {
+		el.lambda = coefs[i]
+	}
+	return signers, localIdx, peers, nil
+}
+
+// GenerateMuSig2Key generate the n-of-n MuSig2 key of the keys, each party picks its own secret key and
+// broadcasts the pub key in a single round, the aggregate key is the pool key. It return the local state of the
+// key, it is not saved
+func (ts *TssSchnorr) GenerateMuSig2Key(keys []string, localPartyKey string) (storage.KeygenLocalState, error) {
+	parties, localIdx, peers, err := muSig2Parties(keys, localPartyKey)
+	if err != nil {
+		return storage.KeygenLocalState{}, err
+	}
+	x, err := randomScalar()
+	if err != nil {
+		return storage.KeygenLocalState{}, fmt.Errorf("fail to generate the secret key: %w", err)
+	}
+	px, py := curve.ScalarBaseMult(bytes32(x))
+	local := &roundMsg{Round: roundKey, Commitments: [][]byte{compress(px, py)}}
+	if err := ts.broadcast(local, peers); err != nil {
+		return storage.KeygenLocalState{}, err
+	}
+	msgs, err := ts.collect(roundKey, parties, localIdx, local, ts.conf.KeyGenTimeout, func(msg *roundMsg) bool {
+		if len(msg.Commitments) != 1 {
+			return false
+		}
+		_, _, err := decompress(msg.Commitments[0])
+		return err == nil
+	})
+	if err != nil {
+		return storage.KeygenLocalState{}, err
+	}
+	pubKeys := make([][]byte, len(parties))
+	for i, el := range msgs {
+		pubKeys[i] = el.Commitments[0]
+	}
+	_, qx, qy, err := keyAgg(pubKeys)
+	if err != nil {
+		return storage.KeygenLocalState{}, err
+	}
+
+	saveData := keygen.NewLocalPartySaveData(len(parties))
+	saveData.Xi = x
+	saveData.ShareID = parties[localIdx].key
+	for i, el := range parties {
+		saveData.Ks[i] = el.key
+		bx, by, err := decompress(pubKeys[i])
+		if err != nil {
+			return storage.KeygenLocalState{}, err
+		}
+		if saveData.BigXj[i], err = crypto.NewECPoint(btss.EC(), bx, by); err != nil {
+			return storage.KeygenLocalState{}, fmt.Errorf("fail to get the pub key of %s: %w", el.pubKey, err)
+		}
+	}
+	if saveData.ECDSAPub, err = crypto.NewECPoint(btss.EC(), qx, qy); err != nil {
+		return storage.KeygenLocalState{}, fmt.Errorf("fail to get the aggregate key: %w", err)
+	}
+	pubKey, _, err := conversion.GetTssPubKey(saveData.ECDSAPub)
+	if err != nil {
+		return storage.KeygenLocalState{}, fmt.Errorf("fail to get the pool pub key: %w", err)
+	}
+	ts.logger.Info().Msgf("%s successfully generate the musig2 key %s", ts.localPeerID, pubKey)
+	return storage.KeygenLocalState{
+		PubKey:          pubKey,
+		LocalData:       saveData,
+		ParticipantKeys: keys,
+		LocalPartyKey:   localPartyKey,
+		Threshold:       len(keys),
+		Algo:            conversion.AlgoSecp256k1,
+		Protocol:        conversion.SignProtocolMuSig2,
+	}, nil
+}
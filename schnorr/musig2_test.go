@@ -0,0 +1,135 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type MuSig2TestSuite struct{}
+
+var _ = Suite(&MuSig2TestSuite{})
+
+func (s *MuSig2TestSuite) TestKeyAgg(c *C) {
+	// the key aggregation test vectors of BIP-327
+	pubKeys := []string{
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"03DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		"023590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66",
+	}
+	testCases := []struct {
+		indices  []int
+		expected string
+	}{
+		{indices: []int{0, 1, 2}, expected: "90539EEDE565F5D054F32CC0C220126889ED1E5D193BAF15AEF344FE59D4610C"},
+		{indices: []int{2, 1, 0}, expected: "6204DE8B083426DC6EAF9502D27024D53FC826BF7D2012148A0575435DF54B2B"},
+		{indices: []int{0, 0, 0}, expected: "B436E3BAD62B8CD409969A224731C193D051162D8C5AE8B109306127DA3AA935"},
+		{indices: []int{0, 0, 1, 1}, expected: "69BC22BFA5D106306E48A20679DE1D7389386124D07571D0D872686028C26A3E"},
+	}
+	for _, tc := range testCases {
+		var keys [][]byte
+		for _, idx := range tc.indices {
+			keys = append(keys, mustDecode(c, pubKeys[idx]))
+		}
+		_, qx, _, err := keyAgg(keys)
+		c.Assert(err, IsNil)
+		c.Assert(strings.ToUpper(fmt.Sprintf("%x", bytes32(qx))), Equals, tc.expected)
+	}
+	_, _, _, err := keyAgg([][]byte{mustDecode(c, pubKeys[0]), {0x02, 0x01}})
+	c.Assert(err, NotNil)
+}
+
+// muSig2Keygen run the musig2 keygen of the given parties, it return the local state of each party
+func (s *TssSchnorrTestSuite) muSig2Keygen(c *C, msgID string, parties []int) []storage.KeygenLocalState {
+	conf := common.TssConfig{KeyGenTimeout: 10 * time.Second}
+	var keys []string
+	for _, idx := range parties {
+		keys = append(keys, testPubKeys[idx])
+	}
+	states := make([]storage.KeygenLocalState, len(parties))
+	errs := make([]error, len(parties))
+	wg := sync.WaitGroup{}
+	for i, idx := range parties {
+		wg.Add(1)
+		go func(i, idx int) {
+			defer wg.Done()
+			comm := s.comms[idx]
+			ts := NewTssSchnorr(comm.GetLocalPeerID(), conf, comm.BroadcastMsgChan, make(chan struct{}), msgID)
+			comm.SetSubscribe(messages.TSSSchnorrMsg, msgID, ts.GetMsgChannel())
+			defer comm.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+			states[i], errs[i] = ts.GenerateMuSig2Key(keys, testPubKeys[idx])
+		}(i, idx)
+	}
+	wg.Wait()
+	for i := range parties {
+		c.Assert(errs[i], IsNil)
+		c.Assert(states[i].PubKey, Equals, states[0].PubKey)
+		c.Assert(states[i].Protocol, Equals, conversion.SignProtocolMuSig2)
+		c.Assert(states[i].Threshold, Equals, len(parties))
+		// the key signs with the local state we read back from the storage
+		buf, err := json.Marshal(states[i])
+		c.Assert(err, IsNil)
+		states[i] = storage.KeygenLocalState{}
+		c.Assert(json.Unmarshal(buf, &states[i]), IsNil)
+	}
+	return states
+}
+
+func (s *TssSchnorrTestSuite) TestMuSig2(c *C) {
+	parties := []int{0, 1, 3}
+	states := s.muSig2Keygen(c, "musig2-keygen", parties)
+	msg1 := sha256.Sum256([]byte("helloworld"))
+	msg2 := sha256.Sum256([]byte("musig2"))
+	msgs := [][]byte{msg1[:], msg2[:]}
+	var signerPubKeys []string
+	for _, idx := range parties {
+		signerPubKeys = append(signerPubKeys, testPubKeys[idx])
+	}
+	conf := common.TssConfig{KeySignTimeout: 10 * time.Second}
+	for _, taproot := range []bool{false, true} {
+		msgID := fmt.Sprintf("musig2-%v", taproot)
+		sigs := make([][][]byte, len(parties))
+		keys := make([][]byte, len(parties))
+		errs := make([]error, len(parties))
+		wg := sync.WaitGroup{}
+		for i, idx := range parties {
+			wg.Add(1)
+			go func(i, idx int) {
+				defer wg.Done()
+				comm := s.comms[idx]
+				ts := NewTssSchnorr(comm.GetLocalPeerID(), conf, comm.BroadcastMsgChan, make(chan struct{}), msgID)
+				comm.SetSubscribe(messages.TSSSchnorrMsg, msgID, ts.GetMsgChannel())
+				defer comm.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+				sigs[i], keys[i], errs[i] = ts.SignMessages(msgs, states[i], signerPubKeys, taproot, nil)
+			}(i, idx)
+		}
+		wg.Wait()
+		expected, err := XOnlyPubKey(states[0], taproot, nil)
+		c.Assert(err, IsNil)
+		for i := range parties {
+			c.Assert(errs[i], IsNil)
+			c.Assert(keys[i], DeepEquals, expected)
+			c.Assert(sigs[i], DeepEquals, sigs[0])
+			for j, m := range msgs {
+				c.Assert(Verify(keys[i], m, sigs[i][j]), Equals, true)
+			}
+		}
+	}
+
+	// the musig2 key is n-of-n, and it has no threshold shares to presign with
+	ts := NewTssSchnorr(s.comms[0].GetLocalPeerID(), conf, s.comms[0].BroadcastMsgChan, make(chan struct{}), "musig2-subset")
+	_, _, err := ts.SignMessages(msgs, states[0], signerPubKeys[:2], false, nil)
+	c.Assert(err, ErrorMatches, "the musig2 key needs all its 3 participants to sign")
+	_, err = ts.Presign(states[0], signerPubKeys, 1)
+	c.Assert(err, NotNil)
+}
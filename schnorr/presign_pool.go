@@ -0,0 +1,161 @@
+package schnorr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+// PresignStore persists the presignatures of the pools, so they survive the restarts and the used ones never
+// come back
+type PresignStore interface {
+	SavePresignatures(poolPubKey string, presigs []storage.Presignature) error
+	RetrievePresignatures(poolPubKey string) ([]storage.Presignature, error)
+}
+
+// PresignPool keeps the presignatures of each pool until the keysign takes them, a presignature is taken only
+// once, it is removed from the store before it is handed out
+type PresignPool struct {
+	lock  *sync.Mutex
+	store PresignStore
+	pools map[string][]storage.Presignature
+	// used are the ids we have handed out, so they are never added back
+	used map[string]bool
+}
+
+// NewPresignPool create a new presignature pool, the presignatures are only kept in memory if the store is nil
+func NewPresignPool(store PresignStore) *PresignPool {
+	return &PresignPool{
+		lock:  &sync.Mutex{},
+		store: store,
+		pools: make(map[string][]storage.Presignature),
+		used:  make(map[string]bool),
+	}
+}
+
+func signerSetKey(signers []string) string {
+	sorted := make([]string, len(signers))
+	copy(sorted, signers)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// load return the presignatures of the pool, they are read from the store the first time
+func (p *PresignPool) load(poolPubKey string) ([]storage.Presignature, error) {
+	if presigs, ok := p.pools[poolPubKey]; ok {
+		return presigs, nil
+	}
+	var presigs []storage.Presignature
+	if p.store != nil {
+		var err error
+		presigs, err = p.store.RetrievePresignatures(poolPubKey)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("fail to retrieve the presignatures: %w", err)
+		}
+	}
+	p.pools[poolPubKey] = presigs
+	return presigs, nil
+}
+
+func (p *PresignPool) save(poolPubKey string, presigs []storage.Presignature) error {
+	if p.store == nil {
+		return nil
+	}
+	return p.store.SavePresignatures(poolPubKey, presigs)
+}
+
+// Add put the presignatures of a presign ceremony into the pool
+func (p *PresignPool) Add(poolPubKey string, presigs []storage.Presignature) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	current, err := p.load(poolPubKey)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(current))
+	for _, el := range current {
+		known[el.ID] = true
+	}
+	updated := append([]storage.Presignature{}, current...)
+	for _, el := range presigs {
+		if el.PoolPubKey != poolPubKey {
+			return errors.New("the presignature belongs to another pool")
+		}
+		if p.used[el.ID] || known[el.ID] {
+			return fmt.Errorf("the presignature %s is already known", el.ID)
+		}
+		known[el.ID] = true
+		updated = append(updated, el)
+	}
+	if err := p.save(poolPubKey, updated); err != nil {
+		return fmt.Errorf("fail to save the presignatures: %w", err)
+	}
+	p.pools[poolPubKey] = updated
+	return nil
+}
+
+// Count return the number of the presignatures of the pool the given signers can use
+func (p *PresignPool) Count(poolPubKey string, signers []string) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	presigs, err := p.load(poolPubKey)
+	if err != nil {
+		return 0, err
+	}
+	key := signerSetKey(signers)
+	count := 0
+	for _, el := range presigs {
+		if signerSetKey(el.Signers) == key {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Take remove n presignatures of the given signers from the pool and return them, all the signers take the
+// same ones as they pick the presignatures with the lowest ids. The presignatures are gone even if the
+// keysign fails afterwards, as the peers may have seen the partial signatures
+func (p *PresignPool) Take(poolPubKey string, signers []string, n int) ([]storage.Presignature, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	presigs, err := p.load(poolPubKey)
+	if err != nil {
+		return nil, err
+	}
+	key := signerSetKey(signers)
+	var candidates []storage.Presignature
+	for _, el := range presigs {
+		if signerSetKey(el.Signers) == key {
+			candidates = append(candidates, el)
+		}
+	}
+	if len(candidates) < n {
+		return nil, fmt.Errorf("not enough presignatures, want %d and have %d", n, len(candidates))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+	taken := candidates[:n]
+	takenIDs := make(map[string]bool, n)
+	for _, el := range taken {
+		takenIDs[el.ID] = true
+		p.used[el.ID] = true
+	}
+	var remaining []storage.Presignature
+	for _, el := range presigs {
+		if !takenIDs[el.ID] {
+			remaining = append(remaining, el)
+		}
+	}
+	// the presignatures are burnt in memory even if we fail to save, so we never hand them out twice
+	p.pools[poolPubKey] = remaining
+	if err := p.save(poolPubKey, remaining); err != nil {
+		return nil, fmt.Errorf("fail to remove the presignatures from the store: %w", err)
+	}
+	return taken, nil
+}
@@ -0,0 +1,97 @@
+package schnorr
+
+import (
+	"errors"
+	"os"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+type PresignPoolTestSuite struct{}
+
+var _ = Suite(&PresignPoolTestSuite{})
+
+type mockPresignStore struct {
+	presigs map[string][]storage.Presignature
+	saveErr error
+}
+
+func (m *mockPresignStore) SavePresignatures(poolPubKey string, presigs []storage.Presignature) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.presigs[poolPubKey] = presigs
+	return nil
+}
+
+func (m *mockPresignStore) RetrievePresignatures(poolPubKey string) ([]storage.Presignature, error) {
+	presigs, ok := m.presigs[poolPubKey]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return presigs, nil
+}
+
+func newPresigs(pool string, signers []string, ids ...string) []storage.Presignature {
+	var presigs []storage.Presignature
+	for _, id := range ids {
+		presigs = append(presigs, storage.Presignature{
+			ID:         id,
+			PoolPubKey: pool,
+			Signers:    signers,
+		})
+	}
+	return presigs
+}
+
+func (s *PresignPoolTestSuite) TestTake(c *C) {
+	store := &mockPresignStore{presigs: make(map[string][]storage.Presignature)}
+	pool := NewPresignPool(store)
+	signers := []string{"a", "b", "c"}
+	c.Assert(pool.Add("pool", newPresigs("pool", signers, "3", "1", "2")), IsNil)
+	c.Assert(pool.Add("pool", newPresigs("pool", []string{"a", "b"}, "4")), IsNil)
+	c.Assert(pool.Add("pool", newPresigs("other", signers, "5")), NotNil)
+	c.Assert(pool.Add("pool", newPresigs("pool", signers, "1")), NotNil)
+
+	count, err := pool.Count("pool", []string{"c", "b", "a"})
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 3)
+
+	// all the signers take the lowest ids whatever the order of the signers
+	taken, err := pool.Take("pool", []string{"b", "a", "c"}, 2)
+	c.Assert(err, IsNil)
+	c.Assert(taken, HasLen, 2)
+	c.Assert(taken[0].ID, Equals, "1")
+	c.Assert(taken[1].ID, Equals, "2")
+	c.Assert(store.presigs["pool"], HasLen, 2)
+
+	_, err = pool.Take("pool", signers, 2)
+	c.Assert(err, NotNil)
+	// a used presignature never comes back
+	c.Assert(pool.Add("pool", newPresigs("pool", signers, "1")), NotNil)
+
+	// a new pool reads the presignatures from the store
+	reloaded := NewPresignPool(store)
+	count, err = reloaded.Count("pool", signers)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 1)
+	count, err = reloaded.Count("unknown", signers)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 0)
+}
+
+func (s *PresignPoolTestSuite) TestTakeFailToSave(c *C) {
+	store := &mockPresignStore{presigs: make(map[string][]storage.Presignature)}
+	pool := NewPresignPool(store)
+	signers := []string{"a", "b"}
+	c.Assert(pool.Add("pool", newPresigs("pool", signers, "1", "2")), IsNil)
+	store.saveErr = errors.New("disk full")
+	_, err := pool.Take("pool", signers, 1)
+	c.Assert(err, NotNil)
+	// the presignature is burnt even if we fail to save
+	count, err := pool.Count("pool", signers)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, 1)
+}
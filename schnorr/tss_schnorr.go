@@ -0,0 +1,741 @@
+package schnorr
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+const (
+	roundCommit = 1
+	roundSign   = 2
+	// roundKey is the single round of the musig2 keygen, the parties broadcast their pub keys
+	roundKey = 3
+
+	// wireVersion is the version of the FROST messages we send, the messages without a version come from the
+	// nodes before we versioned them and have the same format. Bump it, and p2p.FeatureFROST, once the format
+	// changes in a way the older nodes can not read
+	wireVersion = 1
+)
+
+// roundName return the name of the round the log lines carry
+func roundName(round int) string {
+	switch round {
+	case roundCommit:
+		return "commit"
+	case roundSign:
+		return "sign"
+	case roundKey:
+		return "key"
+	default:
+		return fmt.Sprintf("round%d", round)
+	}
+}
+
+// roundMsg is what a signer broadcasts in each round, the commitments are the compressed nonce points D and E
+// of each message, the partials are the partial signatures of each message
+type roundMsg struct {
+	Version     int      `json:"version,omitempty"`
+	Round       int      `json:"round"`
+	Commitments [][]byte `json:"commitments,omitempty"`
+	Partials    [][]byte `json:"partials,omitempty"`
+	// Presignatures are the ids of the presignatures the nonces of the partial signatures come from
+	Presignatures []string `json:"presignatures,omitempty"`
+}
+
+type signer struct {
+	pubKey string
+	peerID peer.ID
+	key    *big.Int
+	// x and y are the public share of the signer
+	x, y *big.Int
+	// lambda is the lagrange coefficient of the signer in the signing party
+	lambda *big.Int
+}
+
+type nonce struct {
+	d, e *big.Int
+}
+
+// TssSchnorr signs the messages with BIP-340 schnorr signatures over the secp256k1 key of an ecdsa keygen, the
+// signers exchange the nonce commitments in the first round and the partial signatures in the second one
+type TssSchnorr struct {
+	logger        zerolog.Logger
+	logContext    *common.LogContext
+	localPeerID   string
+	msgID         string
+	conf          common.TssConfig
+	broadcastChan chan *messages.BroadcastMsgChan
+	stopChan      chan struct{}
+	msgChan       chan *p2p.Message
+	blame         blame.Blame
+	// received keeps the messages of each round by the sender, the signers may be one round ahead of us
+	received map[int]map[peer.ID]*roundMsg
+}
+
+// NewTssSchnorr create a new instance of TssSchnorr
+func NewTssSchnorr(localP2PID string, conf common.TssConfig, broadcastChan chan *messages.BroadcastMsgChan, stopChan chan struct{}, msgID string) *TssSchnorr {
+	logContext := common.NewLogContext(msgID)
+	return &TssSchnorr{
+		logger:        logContext.Logger("schnorr"),
+		logContext:    logContext,
+		localPeerID:   localP2PID,
+		msgID:         msgID,
+		conf:          conf,
+		broadcastChan: broadcastChan,
+		stopChan:      stopChan,
+		msgChan:       make(chan *p2p.Message, 256),
+		received:      make(map[int]map[peer.ID]*roundMsg),
+	}
+}
+
+// GetMsgChannel return the channel we read the messages of the other signers from
+func (ts *TssSchnorr) GetMsgChannel() chan *p2p.Message {
+	return ts.msgChan
+}
+
+// GetLogContext return the log context of the signing
+func (ts *TssSchnorr) GetLogContext() *common.LogContext {
+	return ts.logContext
+}
+
+// GetBlame return the signers to blame once the signing fails
+func (ts *TssSchnorr) GetBlame() blame.Blame {
+	return ts.blame
+}
+
+// signingKey is the even y key the signatures verify against, negShares is true if the shares are negated to get
+// the even y internal key, negKey is true if the tweaked key is negated to get the even y output key
+type signingKey struct {
+	x         []byte
+	y         *big.Int
+	tweak     *big.Int
+	negShares bool
+	negKey    bool
+}
+
+// newSigningKey return the key we sign with, the internal key of the pool if it is not tweaked, or the BIP-341
+// output key committing to the merkle root if it is
+func newSigningKey(px, py *big.Int, taproot bool, merkleRoot []byte) (*signingKey, error) {
+	sk := &signingKey{
+		x:         bytes32(px),
+		y:         py,
+		tweak:     new(big.Int),
+		negShares: !isEven(py),
+	}
+	if sk.negShares {
+		sk.y = negateY(py)
+	}
+	if !taproot {
+		return sk, nil
+	}
+	tweak, err := TaprootTweak(sk.x, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	tx, ty := curve.ScalarBaseMult(bytes32(tweak))
+	qx, qy := curve.Add(px, sk.y, tx, ty)
+	if isInfinity(qx, qy) {
+		return nil, errors.New("the tweaked key is the point at infinity")
+	}
+	sk.x = bytes32(qx)
+	sk.y = qy
+	sk.tweak = tweak
+	sk.negKey = !isEven(qy)
+	return sk, nil
+}
+
+// XOnlyPubKey return the x-only key the signatures of the pool verify against, it is the BIP-341 output key if
+// taproot is true
+func XOnlyPubKey(localState storage.KeygenLocalState, taproot bool, merkleRoot []byte) ([]byte, error) {
+	if localState.LocalData.ECDSAPub == nil {
+		return nil, errors.New("the local state has no pub key")
+	}
+	sk, err := newSigningKey(localState.LocalData.ECDSAPub.X(), localState.LocalData.ECDSAPub.Y(), taproot, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	return sk.x, nil
+}
+
+// getSigners return the signers sorted by their party key with their public shares and lagrange coefficients,
+// and the index of the local signer
+func getSigners(localState storage.KeygenLocalState, signerPubKeys []string) ([]*signer, int, error) {
+	partiesID, localPartyID, err := conversion.GetParties(signerPubKeys, localState.LocalPartyKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fail to form the signing party: %w", err)
+	}
+	known := make(map[string]bool, len(localState.LocalData.Ks))
+	for _, el := range localState.LocalData.Ks {
+		known[el.String()] = true
+	}
+	for _, el := range partiesID {
+		if !known[el.KeyInt().String()] {
+			return nil, 0, errors.New("the signer is not a member of the keygen")
+		}
+	}
+	saveData := keygen.BuildLocalSaveDataSubset(localState.LocalData, partiesID)
+	signers := make([]*signer, len(partiesID))
+	localIdx := -1
+	for i, el := range partiesID {
+		pubKey, err := conversion.PartyIDtoPubKey(el)
+		if err != nil {
+			return nil, 0, fmt.Errorf("fail to get the pub key of the signer: %w", err)
+		}
+		peerID, err := conversion.GetPeerIDFromPubKey(pubKey)
+		if err != nil {
+			return nil, 0, fmt.Errorf("fail to get the peer id of the signer: %w", err)
+		}
+		signers[i] = &signer{
+			pubKey: pubKey,
+			peerID: peerID,
+			key:    new(big.Int).Mod(saveData.Ks[i], curve.N),
+			x:      saveData.BigXj[i].X(),
+			y:      saveData.BigXj[i].Y(),
+		}
+		if el.Id == localPartyID.Id {
+			localIdx = i
+		}
+	}
+	for i, el := range signers {
+		lambda := big.NewInt(1)
+		for j, other := range signers {
+			if i == j {
+				continue
+			}
+			diff := new(big.Int).Sub(other.key, el.key)
+			diff.Mod(diff, curve.N)
+			if diff.Sign() == 0 {
+				return nil, 0, errors.New("two signers have the same key")
+			}
+			lambda.Mul(lambda, other.key)
+			lambda.Mul(lambda, diff.ModInverse(diff, curve.N))
+			lambda.Mod(lambda, curve.N)
+		}
+		el.lambda = lambda
+	}
+	return signers, localIdx, nil
+}
+
+func randomScalar() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, curve.N)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+func compress(x, y *big.Int) []byte {
+	return (&btcec.PublicKey{Curve: curve, X: x, Y: y}).SerializeCompressed()
+}
+
+func decompress(buf []byte) (*big.Int, *big.Int, error) {
+	pk, err := btcec.ParsePubKey(buf, curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk.X, pk.Y, nil
+}
+
+// signingParty return the signers sorted by their party key, the index of the local signer and the peers we
+// send our messages to
+func signingParty(localState storage.KeygenLocalState, signerPubKeys []string) ([]*signer, int, []peer.ID, error) {
+	if localState.LocalData.ECDSAPub == nil || localState.LocalData.Xi == nil {
+		return nil, 0, nil, errors.New("the local state has no key share")
+	}
+	if localState.Protocol == conversion.SignProtocolMuSig2 {
+		return nil, 0, nil, errors.New("the musig2 keys have no threshold shares")
+	}
+	signers, localIdx, err := getSigners(localState, signerPubKeys)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	threshold, err := localState.GetThreshold()
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("fail to get threshold: %w", err)
+	}
+	if len(signers) <= threshold {
+		return nil, 0, nil, fmt.Errorf("not enough signers, threshold=%d and signers=%d", threshold, len(signers))
+	}
+	var peers []peer.ID
+	for i, el := range signers {
+		if i != localIdx {
+			peers = append(peers, el.peerID)
+		}
+	}
+	return signers, localIdx, peers, nil
+}
+
+// newNonces generate n pairs of nonces, it return them with their compressed commitments D and E
+func newNonces(n int) ([]nonce, [][]byte, error) {
+	nonces := make([]nonce, n)
+	commitments := make([][]byte, 0, 2*n)
+	for i := 0; i < n; i++ {
+		d, err := randomScalar()
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to generate the nonce: %w", err)
+		}
+		e, err := randomScalar()
+		if err != nil {
+			return nil, nil, fmt.Errorf("fail to generate the nonce: %w", err)
+		}
+		nonces[i] = nonce{d: d, e: e}
+		dx, dy := curve.ScalarBaseMult(bytes32(d))
+		ex, ey := curve.ScalarBaseMult(bytes32(e))
+		commitments = append(commitments, compress(dx, dy), compress(ex, ey))
+	}
+	return nonces, commitments, nil
+}
+
+func checkMessages(msgsToSign [][]byte) error {
+	for _, el := range msgsToSign {
+		if len(el) != 32 {
+			return errors.New("the schnorr signatures only sign the 32 bytes messages")
+		}
+	}
+	return nil
+}
+
+// SignMessages sign each of the 32 bytes messages with the signers, it return the 64 bytes BIP-340 signatures
+// and the x-only key they verify against, the key is the BIP-341 output key committing to the merkle root if
+// taproot is true. The musig2 keys sign with MuSig2, all their participants have to be the signers
+func (ts *TssSchnorr) SignMessages(msgsToSign [][]byte, localState storage.KeygenLocalState, signerPubKeys []string, taproot bool, merkleRoot []byte) ([][]byte, []byte, error) {
+	if err := checkMessages(msgsToSign); err != nil {
+		return nil, nil, err
+	}
+	// the musig2 keys sign the same two rounds, the nonces of all the signers are bound with one factor
+	party, bind := signingParty, binding(frostBinding)
+	if localState.Protocol == conversion.SignProtocolMuSig2 {
+		party, bind = muSig2SigningParty, muSig2Binding
+	}
+	signers, localIdx, peers, err := party(localState, signerPubKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, err := newSigningKey(localState.LocalData.ECDSAPub.X(), localState.LocalData.ECDSAPub.Y(), taproot, merkleRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to get the signing key: %w", err)
+	}
+
+	// round 1, commit to the nonces of each message
+	nonces, commitments, err := newNonces(len(msgsToSign))
+	if err != nil {
+		return nil, nil, err
+	}
+	localCommit := &roundMsg{Round: roundCommit, Commitments: commitments}
+	if err := ts.broadcast(localCommit, peers); err != nil {
+		return nil, nil, err
+	}
+	commits, err := ts.collect(roundCommit, signers, localIdx, localCommit, ts.conf.KeySignTimeout, func(msg *roundMsg) bool {
+		return len(msg.Commitments) == 2*len(msgsToSign)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	signatures, err := ts.sign(msgsToSign, localState, sk, signers, localIdx, peers, commits, nonces, nil, bind)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signatures, sk.x, nil
+}
+
+type point struct {
+	x, y *big.Int
+}
+
+// binding return the factor each signer binds its second nonce with in the i-th message, d and e are the nonce
+// points of the signers
+type binding func(m []byte, i int, sk *signingKey, signers []*signer, commits []*roundMsg, d, e []point) []*big.Int
+
+// frostBinding bind each signer with its own factor over the commitments of all the signers
+func frostBinding(m []byte, i int, _ *signingKey, signers []*signer, commits []*roundMsg, _, _ []point) []*big.Int {
+	var encoded []byte
+	for j, el := range signers {
+		encoded = append(encoded, bytes32(el.key)...)
+		encoded = append(encoded, commits[j].Commitments[2*i]...)
+		encoded = append(encoded, commits[j].Commitments[2*i+1]...)
+	}
+	rho := make([]*big.Int, len(signers))
+	for j, el := range signers {
+		rho[j] = hashToScalar(tagBinding, bytes32(el.key), m, encoded)
+	}
+	return rho
+}
+
+// sign run the round of the partial signatures with the nonces all the signers have committed to, and
+// aggregate the partial signatures, the ids of the presignatures the nonces come from are checked against the
+// ones of the other signers
+func (ts *TssSchnorr) sign(msgsToSign [][]byte, localState storage.KeygenLocalState, sk *signingKey, signers []*signer, localIdx int, peers []peer.ID, commits []*roundMsg, nonces []nonce, presigIDs []string, bind binding) ([][]byte, error) {
+	// round 2, compute the group nonce and the partial signature of each message
+	type session struct {
+		// kx and ky are the nonce points of each signer after the binding and the even y adjustment
+		kx, ky []*big.Int
+		rx     *big.Int
+		c      *big.Int
+	}
+	sessions := make([]session, len(msgsToSign))
+	partials := make([][]byte, len(msgsToSign))
+	localX := new(big.Int).Set(localState.LocalData.Xi)
+	if sk.negShares {
+		localX.Sub(curve.N, localX)
+	}
+	for i, m := range msgsToSign {
+		s := session{
+			kx: make([]*big.Int, len(signers)),
+			ky: make([]*big.Int, len(signers)),
+		}
+		d := make([]point, len(signers))
+		e := make([]point, len(signers))
+		for j, el := range signers {
+			var err error
+			d[j].x, d[j].y, err = decompress(commits[j].Commitments[2*i])
+			if err == nil {
+				e[j].x, e[j].y, err = decompress(commits[j].Commitments[2*i+1])
+			}
+			if err != nil {
+				ts.blame = blame.NewBlame(blame.TssBrokenMsg, []blame.Node{blame.NewNode(el.pubKey, nil, nil)})
+				return nil, fmt.Errorf("fail to parse the nonce commitment: %w", err)
+			}
+		}
+		rx, ry := new(big.Int), new(big.Int)
+		rho := bind(m, i, sk, signers, commits, d, e)
+		for j := range signers {
+			bx, by := curve.ScalarMult(e[j].x, e[j].y, bytes32(rho[j]))
+			s.kx[j], s.ky[j] = curve.Add(d[j].x, d[j].y, bx, by)
+			rx, ry = curve.Add(rx, ry, s.kx[j], s.ky[j])
+		}
+		if isInfinity(rx, ry) {
+			return nil, errors.New("the group nonce is the point at infinity")
+		}
+		negNonce := !isEven(ry)
+		if negNonce {
+			for j := range signers {
+				s.ky[j] = negateY(s.ky[j])
+			}
+		}
+		s.rx = rx
+		s.c = hashToScalar(tagChallenge, bytes32(rx), sk.x, m)
+		sessions[i] = s
+
+		// z = k + c * lambda * x, with the signs that make both R and the key even
+		k := new(big.Int).Mul(rho[localIdx], nonces[i].e)
+		k.Add(k, nonces[i].d)
+		k.Mod(k, curve.N)
+		if negNonce {
+			k.Sub(curve.N, k)
+		}
+		z := new(big.Int).Mul(ts.shareFactor(s.c, signers[localIdx].lambda, sk.negKey), localX)
+		z.Add(z, k)
+		z.Mod(z, curve.N)
+		partials[i] = bytes32(z)
+	}
+	localSign := &roundMsg{Round: roundSign, Partials: partials, Presignatures: presigIDs}
+	if err := ts.broadcast(localSign, peers); err != nil {
+		return nil, err
+	}
+	signs, err := ts.collect(roundSign, signers, localIdx, localSign, ts.conf.KeySignTimeout, func(msg *roundMsg) bool {
+		if len(msg.Presignatures) != len(presigIDs) {
+			return false
+		}
+		for i, el := range presigIDs {
+			if msg.Presignatures[i] != el {
+				return false
+			}
+		}
+		return len(msg.Partials) == len(msgsToSign)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// verify the partial signatures so we can blame the signer of an invalid one, and aggregate them
+	signatures := make([][]byte, len(msgsToSign))
+	// a signer is blamed once, however many of the messages it signed badly
+	var culprits []blame.Node
+	blamed := make(map[string]bool)
+	for i, m := range msgsToSign {
+		s := sessions[i]
+		sum := new(big.Int)
+		for j, el := range signers {
+			z := new(big.Int).SetBytes(signs[j].Partials[i])
+			if j != localIdx && !ts.verifyPartial(z, s.kx[j], s.ky[j], el, s.c, sk) {
+				if !blamed[el.pubKey] {
+					blamed[el.pubKey] = true
+					culprits = append(culprits, blame.NewNode(el.pubKey, nil, nil))
+				}
+				continue
+			}
+			sum.Add(sum, z)
+		}
+		if len(culprits) > 0 {
+			continue
+		}
+		tweak := new(big.Int).Mul(s.c, sk.tweak)
+		if sk.negKey {
+			tweak.Neg(tweak)
+		}
+		sum.Add(sum, tweak)
+		sum.Mod(sum, curve.N)
+		sig := append(bytes32(s.rx), bytes32(sum)...)
+		if !Verify(sk.x, m, sig) {
+			return nil, errors.New("fail to verify the aggregated signature")
+		}
+		signatures[i] = sig
+	}
+	if len(culprits) > 0 {
+		ts.blame = blame.NewBlame(blame.TssBrokenMsg, culprits)
+		return nil, errors.New("invalid partial signature")
+	}
+	ts.logger.Info().Msgf("%s successfully sign the messages with schnorr signatures", ts.localPeerID)
+	return signatures, nil
+}
+
+// Presign run the round of the nonce commitments before the messages are known, it return count presignatures
+// the signers can later sign one message each with in a single round
+func (ts *TssSchnorr) Presign(localState storage.KeygenLocalState, signerPubKeys []string, count int) ([]storage.Presignature, error) {
+	if count <= 0 {
+		return nil, errors.New("the count of the presignatures should be positive")
+	}
+	signers, localIdx, peers, err := signingParty(localState, signerPubKeys)
+	if err != nil {
+		return nil, err
+	}
+	nonces, commitments, err := newNonces(count)
+	if err != nil {
+		return nil, err
+	}
+	localCommit := &roundMsg{Round: roundCommit, Commitments: commitments}
+	if err := ts.broadcast(localCommit, peers); err != nil {
+		return nil, err
+	}
+	commits, err := ts.collect(roundCommit, signers, localIdx, localCommit, ts.conf.KeySignTimeout, func(msg *roundMsg) bool {
+		if len(msg.Commitments) != 2*count {
+			return false
+		}
+		for _, el := range msg.Commitments {
+			if _, _, err := decompress(el); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortedSigners := make([]string, len(signers))
+	for i, el := range signers {
+		sortedSigners[i] = el.pubKey
+	}
+	sort.Strings(sortedSigners)
+	now := time.Now()
+	presigs := make([]storage.Presignature, count)
+	for i := 0; i < count; i++ {
+		id := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", ts.msgID, i)))
+		presigs[i] = storage.Presignature{
+			ID:          hex.EncodeToString(id[:]),
+			PoolPubKey:  localState.PubKey,
+			Signers:     sortedSigners,
+			Commitments: make(map[string][][]byte, len(signers)),
+			D:           bytes32(nonces[i].d),
+			E:           bytes32(nonces[i].e),
+			CreatedAt:   now,
+		}
+		for j, el := range signers {
+			presigs[i].Commitments[el.pubKey] = [][]byte{commits[j].Commitments[2*i], commits[j].Commitments[2*i+1]}
+		}
+	}
+	ts.logger.Info().Msgf("%s successfully generate %d presignatures", ts.localPeerID, count)
+	return presigs, nil
+}
+
+// SignMessagesWithPresignatures sign each of the messages with one of the presignatures, only the round of the
+// partial signatures is left. The presignatures must have been taken out of the pool, a presignature that signs
+// two messages leaks the key share
+func (ts *TssSchnorr) SignMessagesWithPresignatures(msgsToSign [][]byte, localState storage.KeygenLocalState, presigs []storage.Presignature, taproot bool, merkleRoot []byte) ([][]byte, []byte, error) {
+	if err := checkMessages(msgsToSign); err != nil {
+		return nil, nil, err
+	}
+	if len(presigs) != len(msgsToSign) {
+		return nil, nil, fmt.Errorf("need one presignature for each message, messages=%d and presignatures=%d", len(msgsToSign), len(presigs))
+	}
+	signerPubKeys := presigs[0].Signers
+	for _, el := range presigs {
+		if el.PoolPubKey != localState.PubKey {
+			return nil, nil, errors.New("the presignature belongs to another pool")
+		}
+		if signerSetKey(el.Signers) != signerSetKey(signerPubKeys) {
+			return nil, nil, errors.New("the presignatures have different signers")
+		}
+	}
+	signers, localIdx, peers, err := signingParty(localState, signerPubKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, err := newSigningKey(localState.LocalData.ECDSAPub.X(), localState.LocalData.ECDSAPub.Y(), taproot, merkleRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to get the signing key: %w", err)
+	}
+	commits := make([]*roundMsg, len(signers))
+	for j := range commits {
+		commits[j] = &roundMsg{Round: roundCommit}
+	}
+	nonces := make([]nonce, len(presigs))
+	presigIDs := make([]string, len(presigs))
+	for i, el := range presigs {
+		for j, s := range signers {
+			commitment, ok := el.Commitments[s.pubKey]
+			if !ok || len(commitment) != 2 {
+				return nil, nil, fmt.Errorf("the presignature %s has no commitment of %s", el.ID, s.pubKey)
+			}
+			commits[j].Commitments = append(commits[j].Commitments, commitment...)
+		}
+		nonces[i] = nonce{d: new(big.Int).SetBytes(el.D), e: new(big.Int).SetBytes(el.E)}
+		dx, dy := curve.ScalarBaseMult(el.D)
+		ex, ey := curve.ScalarBaseMult(el.E)
+		local := el.Commitments[signers[localIdx].pubKey]
+		if !bytes.Equal(local[0], compress(dx, dy)) || !bytes.Equal(local[1], compress(ex, ey)) {
+			return nil, nil, fmt.Errorf("the nonces of the presignature %s do not match the commitment", el.ID)
+		}
+		presigIDs[i] = el.ID
+	}
+	signatures, err := ts.sign(msgsToSign, localState, sk, signers, localIdx, peers, commits, nonces, presigIDs, frostBinding)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signatures, sk.x, nil
+}
+
+// shareFactor return c * lambda, negated if the key is negated
+func (ts *TssSchnorr) shareFactor(c, lambda *big.Int, negKey bool) *big.Int {
+	f := new(big.Int).Mul(c, lambda)
+	f.Mod(f, curve.N)
+	if negKey && f.Sign() != 0 {
+		f.Sub(curve.N, f)
+	}
+	return f
+}
+
+// verifyPartial check z*G == K + c*lambda*X with the signs of the key applied to the public share X
+func (ts *TssSchnorr) verifyPartial(z, kx, ky *big.Int, el *signer, c *big.Int, sk *signingKey) bool {
+	if z.Cmp(curve.N) >= 0 {
+		return false
+	}
+	x, y := el.x, el.y
+	if sk.negShares {
+		y = negateY(y)
+	}
+	lx, ly := curve.ScalarBaseMult(bytes32(z))
+	sx, sy := curve.ScalarMult(x, y, bytes32(ts.shareFactor(c, el.lambda, sk.negKey)))
+	rx, ry := curve.Add(kx, ky, sx, sy)
+	return lx.Cmp(rx) == 0 && ly.Cmp(ry) == 0
+}
+
+func (ts *TssSchnorr) broadcast(msg *roundMsg, peers []peer.ID) error {
+	ts.logContext.SetPeers(peers)
+	ts.logContext.SetRound(roundName(msg.Round))
+	msg.Version = wireVersion
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the schnorr message: %w", err)
+	}
+	ts.broadcastChan <- &messages.BroadcastMsgChan{
+		WrappedMessage: messages.WrappedMessage{
+			MessageType: messages.TSSSchnorrMsg,
+			MsgID:       ts.msgID,
+			Payload:     buf,
+		},
+		PeersID: peers,
+	}
+	return nil
+}
+
+// collect wait for the message of the given round from all the signers, the signers that miss the round are
+// blamed once we time out
+func (ts *TssSchnorr) collect(round int, signers []*signer, localIdx int, local *roundMsg, timeout time.Duration, valid func(*roundMsg) bool) ([]*roundMsg, error) {
+	result := make([]*roundMsg, len(signers))
+	result[localIdx] = local
+	index := make(map[peer.ID]int, len(signers))
+	for i, el := range signers {
+		index[el.peerID] = i
+	}
+	missing := func() []blame.Node {
+		var nodes []blame.Node
+		for i, el := range signers {
+			if result[i] == nil {
+				nodes = append(nodes, blame.NewNode(el.pubKey, nil, nil))
+			}
+		}
+		return nodes
+	}
+	take := func() bool {
+		for pID, msg := range ts.received[round] {
+			idx, ok := index[pID]
+			if !ok || result[idx] != nil {
+				continue
+			}
+			if !valid(msg) {
+				ts.logger.Error().Msgf("receive a malformed schnorr message of round %d from %s", round, pID)
+				delete(ts.received[round], pID)
+				continue
+			}
+			result[idx] = msg
+		}
+		return len(missing()) == 0
+	}
+	timer := time.After(timeout)
+	for !take() {
+		select {
+		case <-ts.stopChan:
+			return nil, errors.New("received exit signal")
+		case <-timer:
+			ts.blame = blame.NewBlame(blame.TssTimeout, missing())
+			return nil, blame.ErrTssTimeOut
+		case msg := <-ts.msgChan:
+			wrappedMsg, err := messages.UnmarshalWrappedMessage(msg.Payload)
+			if err != nil {
+				ts.logger.Error().Err(err).Msgf("fail to unmarshal the wrapped message from %s", msg.PeerID)
+				continue
+			}
+			var el roundMsg
+			if err := json.Unmarshal(wrappedMsg.Payload, &el); err != nil {
+				ts.logger.Error().Err(err).Msgf("fail to unmarshal the schnorr message from %s", msg.PeerID)
+				continue
+			}
+			if el.Version > wireVersion {
+				ts.logger.Error().Msgf("drop the schnorr message of wire version %d from %s, we only read up to %d", el.Version, msg.PeerID, wireVersion)
+				continue
+			}
+			if _, ok := ts.received[el.Round]; !ok {
+				ts.received[el.Round] = make(map[peer.ID]*roundMsg)
+			}
+			// we keep the first message of each signer in each round
+			if _, ok := ts.received[el.Round][msg.PeerID]; !ok {
+				ts.received[el.Round][msg.PeerID] = &el
+			}
+		}
+	}
+	return result, nil
+}
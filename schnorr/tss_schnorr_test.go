@@ -0,0 +1,240 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+var (
+	testPubKeys = []string{
+		"thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69",
+		"thorpub1addwnpepqfjcw5l4ay5t00c32mmlky7qrppepxzdlkcwfs2fd5u73qrwna0vzag3y4j",
+		"thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3",
+		"thorpub1addwnpepqtspqyy6gk22u37ztra4hq3hdakc0w0k60sfy849mlml2vrpfr0wvm6uz09",
+	}
+	testPriKeyArr = []string{
+		"6LABmWB4iXqkqOJ9H0YFEA2CSSx6bA7XAKGyI/TDtas=",
+		"528pkgjuCWfHx1JihEjiIXS7jfTS/viEdAbjqVvSifQ=",
+		"JFB2LIJZtK+KasK00NcNil4PRJS4c4liOnK0nDalhqc=",
+		"vLMGhVXMOXQVnAE3BUU8fwNj/q0ZbndKkwmxfS5EN9Y=",
+	}
+)
+
+type TssSchnorrTestSuite struct {
+	comms       []*p2p.Communication
+	localStates []storage.KeygenLocalState
+}
+
+var _ = Suite(&TssSchnorrTestSuite{})
+
+func (s *TssSchnorrTestSuite) SetUpSuite(c *C) {
+	conversion.SetupBech32Prefix()
+	common.InitLog("info", true, "schnorr_test")
+	ports := []int{
+		19666, 19667, 19668, 19669,
+	}
+	bootstrapPeer := "/ip4/127.0.0.1/tcp/19666/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh"
+	multiAddr, err := maddr.NewMultiaddr(bootstrapPeer)
+	c.Assert(err, IsNil)
+	for i := range testPriKeyArr {
+		buf, err := base64.StdEncoding.DecodeString(testPriKeyArr[i])
+		c.Assert(err, IsNil)
+		var bootstrapPeers []maddr.Multiaddr
+		if i > 0 {
+			bootstrapPeers = []maddr.Multiaddr{multiAddr}
+		}
+		comm, err := p2p.NewCommunication("asgard", bootstrapPeers, ports[i], "")
+		c.Assert(err, IsNil)
+		c.Assert(comm.Start(buf), IsNil)
+		s.comms = append(s.comms, comm)
+
+		data, err := ioutil.ReadFile(fmt.Sprintf("../test_data/keysign_data/%d.json", i))
+		c.Assert(err, IsNil)
+		var localState storage.KeygenLocalState
+		c.Assert(json.Unmarshal(data, &localState), IsNil)
+		s.localStates = append(s.localStates, localState)
+	}
+}
+
+func (s *TssSchnorrTestSuite) TearDownSuite(c *C) {
+	for _, el := range s.comms {
+		c.Assert(el.Stop(), IsNil)
+	}
+}
+
+// sign run the schnorr keysign with the given signers, it return the result of each signer
+func (s *TssSchnorrTestSuite) sign(c *C, msgID string, msgs [][]byte, signers []int, taproot bool) ([][][]byte, [][]byte, []error) {
+	conf := common.TssConfig{
+		KeySignTimeout: 10 * time.Second,
+	}
+	var signerPubKeys []string
+	for _, idx := range signers {
+		signerPubKeys = append(signerPubKeys, testPubKeys[idx])
+	}
+	sigs := make([][][]byte, len(signers))
+	keys := make([][]byte, len(signers))
+	errs := make([]error, len(signers))
+	wg := sync.WaitGroup{}
+	for i, idx := range signers {
+		wg.Add(1)
+		go func(i, idx int) {
+			defer wg.Done()
+			comm := s.comms[idx]
+			ts := NewTssSchnorr(comm.GetLocalPeerID(), conf, comm.BroadcastMsgChan, make(chan struct{}), msgID)
+			comm.SetSubscribe(messages.TSSSchnorrMsg, msgID, ts.GetMsgChannel())
+			defer comm.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+			sigs[i], keys[i], errs[i] = ts.SignMessages(msgs, s.localStates[idx], signerPubKeys, taproot, nil)
+		}(i, idx)
+	}
+	wg.Wait()
+	return sigs, keys, errs
+}
+
+func (s *TssSchnorrTestSuite) TestSignMessages(c *C) {
+	msg1 := sha256.Sum256([]byte("helloworld"))
+	msg2 := sha256.Sum256([]byte("taproot"))
+	msgs := [][]byte{msg1[:], msg2[:]}
+	for _, taproot := range []bool{false, true} {
+		msgID, err := common.MsgToHashString([]byte(fmt.Sprintf("schnorr-%v", taproot)))
+		c.Assert(err, IsNil)
+		sigs, keys, errs := s.sign(c, msgID, msgs, []int{0, 1, 3}, taproot)
+		expected, err := XOnlyPubKey(s.localStates[0], taproot, nil)
+		c.Assert(err, IsNil)
+		for i := range errs {
+			c.Assert(errs[i], IsNil)
+			c.Assert(keys[i], DeepEquals, expected)
+			c.Assert(sigs[i], DeepEquals, sigs[0])
+			for j, m := range msgs {
+				c.Assert(Verify(keys[i], m, sigs[i][j]), Equals, true)
+			}
+		}
+	}
+}
+
+func (s *TssSchnorrTestSuite) TestSignMessagesTimeout(c *C) {
+	msg := sha256.Sum256([]byte("timeout"))
+	msgID, err := common.MsgToHashString([]byte("schnorr-timeout"))
+	c.Assert(err, IsNil)
+	comm := s.comms[0]
+	ts := NewTssSchnorr(comm.GetLocalPeerID(), common.TssConfig{KeySignTimeout: time.Second}, comm.BroadcastMsgChan, make(chan struct{}), msgID)
+	comm.SetSubscribe(messages.TSSSchnorrMsg, msgID, ts.GetMsgChannel())
+	defer comm.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+	_, _, err = ts.SignMessages([][]byte{msg[:]}, s.localStates[0], []string{testPubKeys[0], testPubKeys[1], testPubKeys[2]}, false, nil)
+	c.Assert(err, Equals, blame.ErrTssTimeOut)
+	c.Assert(ts.GetBlame().FailReason, Equals, blame.TssTimeout)
+	c.Assert(ts.GetBlame().BlameNodes, HasLen, 2)
+
+	_, _, err = ts.SignMessages([][]byte{[]byte("not hashed")}, s.localStates[0], testPubKeys, false, nil)
+	c.Assert(err, NotNil)
+}
+
+func (s *TssSchnorrTestSuite) TestWireVersion(c *C) {
+	msg := sha256.Sum256([]byte("wire version"))
+	comm := s.comms[0]
+	ts := NewTssSchnorr(comm.GetLocalPeerID(), common.TssConfig{KeySignTimeout: time.Second}, comm.BroadcastMsgChan, make(chan struct{}), "wire-version")
+	_, commitments, err := newNonces(1)
+	c.Assert(err, IsNil)
+	// we do not read the messages of a newer wire version
+	for _, idx := range []int{1, 2} {
+		peerID, err := peer.Decode(s.comms[idx].GetLocalPeerID())
+		c.Assert(err, IsNil)
+		buf, err := json.Marshal(roundMsg{Version: wireVersion + 1, Round: roundCommit, Commitments: commitments})
+		c.Assert(err, IsNil)
+		wrapped, err := json.Marshal(messages.WrappedMessage{MessageType: messages.TSSSchnorrMsg, MsgID: "wire-version", Payload: buf})
+		c.Assert(err, IsNil)
+		ts.GetMsgChannel() <- &p2p.Message{PeerID: peerID, Payload: wrapped}
+	}
+	_, _, err = ts.SignMessages([][]byte{msg[:]}, s.localStates[0], testPubKeys[:3], false, nil)
+	c.Assert(err, Equals, blame.ErrTssTimeOut)
+	c.Assert(ts.GetBlame().BlameNodes, HasLen, 2)
+	c.Assert(ts.received[roundCommit], HasLen, 0)
+}
+
+func (s *TssSchnorrTestSuite) TestSignMessagesWithPresignatures(c *C) {
+	conf := common.TssConfig{
+		KeySignTimeout: 10 * time.Second,
+	}
+	signers := []int{0, 1, 3}
+	var signerPubKeys []string
+	for _, idx := range signers {
+		signerPubKeys = append(signerPubKeys, testPubKeys[idx])
+	}
+	run := func(msgID string, f func(ts *TssSchnorr, i, idx int)) {
+		wg := sync.WaitGroup{}
+		for i, idx := range signers {
+			wg.Add(1)
+			go func(i, idx int) {
+				defer wg.Done()
+				comm := s.comms[idx]
+				ts := NewTssSchnorr(comm.GetLocalPeerID(), conf, comm.BroadcastMsgChan, make(chan struct{}), msgID)
+				comm.SetSubscribe(messages.TSSSchnorrMsg, msgID, ts.GetMsgChannel())
+				defer comm.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+				f(ts, i, idx)
+			}(i, idx)
+		}
+		wg.Wait()
+	}
+
+	presigs := make([][]storage.Presignature, len(signers))
+	errs := make([]error, len(signers))
+	msgID, err := common.MsgToHashString([]byte("schnorr-presign"))
+	c.Assert(err, IsNil)
+	run(msgID, func(ts *TssSchnorr, i, idx int) {
+		presigs[i], errs[i] = ts.Presign(s.localStates[idx], signerPubKeys, 2)
+	})
+	for i := range signers {
+		c.Assert(errs[i], IsNil)
+		c.Assert(presigs[i], HasLen, 2)
+		for j, el := range presigs[i] {
+			c.Assert(el.ID, Equals, presigs[0][j].ID)
+			c.Assert(el.Commitments, DeepEquals, presigs[0][j].Commitments)
+			c.Assert(el.Signers, HasLen, 3)
+		}
+	}
+
+	msg1 := sha256.Sum256([]byte("presign"))
+	msg2 := sha256.Sum256([]byte("one round"))
+	msgs := [][]byte{msg1[:], msg2[:]}
+	sigs := make([][][]byte, len(signers))
+	keys := make([][]byte, len(signers))
+	msgID, err = common.MsgToHashString([]byte("schnorr-presign-sign"))
+	c.Assert(err, IsNil)
+	run(msgID, func(ts *TssSchnorr, i, idx int) {
+		sigs[i], keys[i], errs[i] = ts.SignMessagesWithPresignatures(msgs, s.localStates[idx], presigs[i], true, nil)
+	})
+	expected, err := XOnlyPubKey(s.localStates[0], true, nil)
+	c.Assert(err, IsNil)
+	for i := range signers {
+		c.Assert(errs[i], IsNil)
+		c.Assert(keys[i], DeepEquals, expected)
+		c.Assert(sigs[i], DeepEquals, sigs[0])
+		for j, m := range msgs {
+			c.Assert(Verify(keys[i], m, sigs[i][j]), Equals, true)
+		}
+	}
+
+	// the nonces must match the commitment, and there must be a presignature for each message
+	ts := NewTssSchnorr(s.comms[0].GetLocalPeerID(), conf, s.comms[0].BroadcastMsgChan, make(chan struct{}), "broken")
+	_, _, err = ts.SignMessagesWithPresignatures(msgs[:1], s.localStates[0], presigs[0], false, nil)
+	c.Assert(err, NotNil)
+	broken := presigs[0][0]
+	broken.D = presigs[0][1].D
+	_, _, err = ts.SignMessagesWithPresignatures(msgs[:1], s.localStates[0], []storage.Presignature{broken}, false, nil)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveFilePathName return the file of the archived share of the key in the archive folder
+func archiveFilePathName(folder, pubKey string) (string, error) {
+	if err := checkPubKey(pubKey); err != nil {
+		return "", err
+	}
+	return filepath.Join(folder, fmt.Sprintf("archive-%s.json", pubKey)), nil
+}
+
+// ArchiveShare export the share of the key to the archive folder encrypted with the passphrase, it return the file
+// of the archive. The share is not removed, the caller deletes it once the archive is written
+func ArchiveShare(stateManager LocalStateManager, folder, pubKey string, passphrase []byte) (string, error) {
+	filePathName, err := archiveFilePathName(folder, pubKey)
+	if err != nil {
+		return "", err
+	}
+	buf, err := ExportShare(stateManager, pubKey, passphrase)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return "", fmt.Errorf("fail to create the archive folder: %w", err)
+	}
+	if err := replaceFile(filePathName, "archive-*"+tempFileSuffix, buf); err != nil {
+		return "", fmt.Errorf("fail to write the archive: %w", err)
+	}
+	return filePathName, nil
+}
+
+// RestoreArchivedShare restore the share of the key from the archive folder with the passphrase, the key is no
+// longer retired, so it signs again and is not archived again. The archive is kept
+func RestoreArchivedShare(stateManager LocalStateManager, folder, pubKey string, passphrase []byte) (KeygenLocalState, error) {
+	filePathName, err := archiveFilePathName(folder, pubKey)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	buf, err := ioutil.ReadFile(filePathName)
+	if err != nil {
+		return KeygenLocalState{}, fmt.Errorf("fail to read the archive: %w", err)
+	}
+	var archive ShareArchive
+	if err := json.Unmarshal(buf, &archive); err != nil {
+		return KeygenLocalState{}, fmt.Errorf("fail to unmarshal the share archive: %w", err)
+	}
+	if archive.PubKey != pubKey {
+		return KeygenLocalState{}, fmt.Errorf("the archive of %s holds the key %s", pubKey, archive.PubKey)
+	}
+	state, err := RestoreShare(stateManager, buf, passphrase)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	state.RetiredAt = nil
+	state.DeletionTokenHash = ""
+	if err := stateManager.SaveLocalState(state); err != nil {
+		return KeygenLocalState{}, fmt.Errorf("fail to save the local state: %w", err)
+	}
+	return state, nil
+}
+
+// ListArchivedShares return the pub keys of the archived shares of the archive folder
+func ListArchivedShares(folder string) ([]string, error) {
+	fileNames, err := filepath.Glob(filepath.Join(folder, "archive-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	pubKeys := make([]string, 0, len(fileNames))
+	for _, el := range fileNames {
+		pubKeys = append(pubKeys, strings.TrimSuffix(strings.TrimPrefix(filepath.Base(el), "archive-"), ".json"))
+	}
+	return pubKeys, nil
+}
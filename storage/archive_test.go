@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"os"
+	"time"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type ArchiveTestSuite struct{}
+
+var _ = Suite(&ArchiveTestSuite{})
+
+func (s *ArchiveTestSuite) TestArchiveShare(c *C) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	conversion.SetupBech32Prefix()
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	retiredAt := time.Now().UTC()
+	stateItem := KeygenLocalState{
+		PubKey:            "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:         keygen.NewLocalPartySaveData(5),
+		ParticipantKeys:   []string{"A", "B", "C"},
+		LocalPartyKey:     "A",
+		RetiredAt:         &retiredAt,
+		DeletionTokenHash: "hash",
+	}
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	folder := c.MkDir() + "/archive"
+	_, err = ArchiveShare(fsm, folder, "whatever", []byte("passphrase"))
+	c.Assert(err, NotNil)
+	filePathName, err := ArchiveShare(fsm, folder, stateItem.PubKey, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	info, err := os.Stat(filePathName)
+	c.Assert(err, IsNil)
+	c.Assert(info.Mode().Perm(), Equals, os.FileMode(0o600))
+	pubKeys, err := ListArchivedShares(folder)
+	c.Assert(err, IsNil)
+	c.Assert(pubKeys, DeepEquals, []string{stateItem.PubKey})
+
+	// the node holds the share
+	_, err = RestoreArchivedShare(fsm, folder, stateItem.PubKey, []byte("passphrase"))
+	c.Assert(err, Equals, ErrShareExists)
+	c.Assert(fsm.DeleteLocalState(stateItem.PubKey), IsNil)
+	_, err = RestoreArchivedShare(fsm, folder, stateItem.PubKey, []byte("wrong"))
+	c.Assert(err, Equals, ErrWrongPassphrase)
+	state, err := RestoreArchivedShare(fsm, folder, stateItem.PubKey, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(state.Retired(), Equals, false)
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.Retired(), Equals, false)
+	c.Assert(item.DeletionTokenHash, Equals, "")
+	c.Assert(item.ParticipantKeys, DeepEquals, stateItem.ParticipantKeys)
+}
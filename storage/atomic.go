@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// tempFileSuffix is the suffix of the temporary files the state is written to before it replaces the files
+const tempFileSuffix = ".tmp"
+
+// syncFile flush the file to the disk, the tests replace it to crash a write
+var syncFile = func(f *os.File) error {
+	return f.Sync()
+}
+
+// replaceFile replace the file with the content at once, the content is written to a temporary file in the same
+// folder, flushed to the disk and renamed over the file, then the folder is flushed so the rename survives a
+// crash as well. Only we can read the file. A crash leaves either the old or the new file, never a partial one
+func replaceFile(filePathName, pattern string, buf []byte) error {
+	folder := filepath.Dir(filePathName)
+	tmp, err := ioutil.TempFile(folder, pattern)
+	if err != nil {
+		return fmt.Errorf("fail to create the file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("fail to set the mode of the file: %w", err)
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return fmt.Errorf("fail to write the file: %w", err)
+	}
+	if err := syncFile(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("fail to sync the file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("fail to close the file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filePathName); err != nil {
+		return fmt.Errorf("fail to replace the file: %w", err)
+	}
+	return syncFolder(folder)
+}
+
+// syncFolder flush the entries of the folder to the disk
+func syncFolder(folder string) error {
+	f, err := os.Open(folder)
+	if err != nil {
+		return fmt.Errorf("fail to open the folder: %w", err)
+	}
+	defer f.Close()
+	if err := syncFile(f); err != nil {
+		return fmt.Errorf("fail to sync the folder: %w", err)
+	}
+	return nil
+}
+
+// removeTempFiles remove the temporary files a crash has left in the folder, the files they were meant to
+// replace are intact
+func removeTempFiles(folder string) error {
+	tempFiles, err := filepath.Glob(filepath.Join(folder, "*"+tempFileSuffix))
+	if err != nil {
+		return err
+	}
+	for _, el := range tempFiles {
+		log.Warn().Str("module", "file_state_mgr").Msgf("remove the temporary file %s of an interrupted write", el)
+		if err := os.Remove(el); err != nil {
+			return fmt.Errorf("fail to remove the temporary file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type AtomicWriteTestSuite struct{}
+
+var _ = Suite(&AtomicWriteTestSuite{})
+
+func (s *AtomicWriteTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *AtomicWriteTestSuite) TestCrashedWrite(c *C) {
+	folder := c.MkDir()
+	fsm, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	filePathName := filepath.Join(folder, "localstate-"+stateItem.PubKey+".json")
+	fi, err := os.Stat(filePathName)
+	c.Assert(err, IsNil)
+	c.Assert(fi.Mode().Perm(), Equals, os.FileMode(0o600))
+
+	// the write fails before the new file replaces the old one
+	defer func() {
+		syncFile = func(f *os.File) error {
+			return f.Sync()
+		}
+	}()
+	syncFile = func(f *os.File) error {
+		return errors.New("disk is gone")
+	}
+	stateItem.LocalPartyKey = "B"
+	c.Assert(fsm.SaveLocalState(stateItem), ErrorMatches, "fail to sync the file.*")
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+	tempFiles, err := filepath.Glob(filepath.Join(folder, "*"+tempFileSuffix))
+	c.Assert(err, IsNil)
+	c.Assert(tempFiles, HasLen, 0)
+}
+
+func (s *AtomicWriteTestSuite) TestRemoveTempFiles(c *C) {
+	folder := c.MkDir()
+	fsm, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:        "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:     keygen.NewLocalPartySaveData(5),
+		LocalPartyKey: "A",
+	}
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+
+	// the node crashed in the middle of writing the new file
+	tempFile := filepath.Join(folder, "localstate-123"+tempFileSuffix)
+	c.Assert(ioutil.WriteFile(tempFile, []byte(`{"pub_key":`), 0o600), IsNil)
+	fsm, err = NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(tempFile)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuditRecord is a keysign we have been asked to take part in, the records are chained by their hashes, so a record
+// that is changed or dropped breaks the chain
+type AuditRecord struct {
+	// Seq is the position of the record in the audit log, the first record is 1
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	// PoolPubKey is the key we are asked to sign with
+	PoolPubKey string `json:"pool_pub_key"`
+	// Messages are the hex encoded sha256 of the messages of the keysign in the order of the request, a message that
+	// is not base64 is hashed as it came
+	Messages []string `json:"messages"`
+	// Requester is the name of the caller of the api, empty if the api is open to everyone
+	Requester string `json:"requester,omitempty"`
+	// Parties are the pub keys of the signers of the request
+	Parties []string `json:"parties"`
+	// Signers are the pub keys of the parties who signed, they are only known if we are one of them
+	Signers []string `json:"signers,omitempty"`
+	Status  string   `json:"status"`
+	// ErrorCode is the machine-readable code of the failure, it is empty if the keysign succeeded
+	ErrorCode string `json:"error_code,omitempty"`
+	// PrevHash is the hash of the previous record, empty for the first record
+	PrevHash string `json:"prev_hash"`
+	// Hash is the hex encoded sha256 of the previous hash and the record without its hash
+	Hash string `json:"hash"`
+}
+
+func (fsm *FileStateMgr) getAuditFilePathName() (string, error) {
+	if len(fsm.folder) < 1 {
+		return "", errors.New("base file path is invalid")
+	}
+	return filepath.Join(fsm.folder, "audit.log"), nil
+}
+
+// AppendAuditRecord append the record to the audit log on file, a json line each, the file is only ever appended to
+func (fsm *FileStateMgr) AppendAuditRecord(record AuditRecord) error {
+	filePathName, err := fsm.getAuditFilePathName()
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the audit record to json: %w", err)
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	f, err := os.OpenFile(filePathName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("fail to open the audit log: %w", err)
+	}
+	if _, err := f.Write(append(buf, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("fail to write the audit record: %w", err)
+	}
+	if err := syncFile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("fail to sync the audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// RetrieveAuditRecords read the records of the audit log from file
+func (fsm *FileStateMgr) RetrieveAuditRecords() ([]AuditRecord, error) {
+	filePathName, err := fsm.getAuditFilePathName()
+	if err != nil {
+		return nil, err
+	}
+	fsm.writeLock.RLock()
+	defer fsm.writeLock.RUnlock()
+	f, err := os.Open(filePathName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("fail to unmarshal the audit record %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fail to read the audit log: %w", err)
+	}
+	return records, nil
+}
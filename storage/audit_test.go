@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AuditTestSuite struct{}
+
+var _ = Suite(&AuditTestSuite{})
+
+func testAuditRecords() []AuditRecord {
+	return []AuditRecord{{
+		Seq:        1,
+		Time:       time.Now().UTC(),
+		PoolPubKey: "pool",
+		Messages:   []string{"00ff"},
+		Requester:  "signer",
+		Parties:    []string{"A", "B"},
+		Status:     "success",
+		Hash:       "hash1",
+	}, {
+		Seq:        2,
+		Time:       time.Now().UTC(),
+		PoolPubKey: "pool",
+		Messages:   []string{"ff00"},
+		Parties:    []string{"A", "B"},
+		Signers:    []string{"A"},
+		Status:     "fail",
+		ErrorCode:  "timeout",
+		PrevHash:   "hash1",
+		Hash:       "hash2",
+	}}
+}
+
+func (s *AuditTestSuite) TestAppendAuditRecord(c *C) {
+	folder := c.MkDir()
+	fsm, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrieveAuditRecords()
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+	records := testAuditRecords()
+	for _, el := range records {
+		c.Assert(fsm.AppendAuditRecord(el), IsNil)
+	}
+	stored, err := fsm.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(stored, DeepEquals, records)
+	// the records are appended a json line each
+	buf, err := ioutil.ReadFile(filepath.Join(folder, "audit.log"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(string(buf), "\n"), Equals, 2)
+
+	msm := NewMemoryStateMgr()
+	for _, el := range records {
+		c.Assert(msm.AppendAuditRecord(el), IsNil)
+	}
+	stored, err = msm.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(stored, DeepEquals, records)
+}
+
+func (s *AuditTestSuite) TestAppendAuditRecordSqlite(c *C) {
+	path := filepath.Join(c.MkDir(), "tss.db")
+	ssm, err := NewSqliteStateMgr(path)
+	c.Assert(err, IsNil)
+	stored, err := ssm.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(stored, HasLen, 0)
+	records := testAuditRecords()
+	for _, el := range records {
+		c.Assert(ssm.AppendAuditRecord(el), IsNil)
+	}
+	// a record is never overwritten
+	c.Assert(ssm.AppendAuditRecord(records[0]), NotNil)
+	c.Assert(ssm.Close(), IsNil)
+
+	// the records outlive the process
+	ssm, err = NewSqliteStateMgr(path)
+	c.Assert(err, IsNil)
+	defer ssm.Close()
+	stored, err = ssm.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(stored, HasLen, len(records))
+	for i, el := range stored {
+		c.Assert(el.Time.Equal(records[i].Time), Equals, true)
+		el.Time = records[i].Time
+		c.Assert(el, DeepEquals, records[i])
+	}
+}
+
+func (s *AuditTestSuite) TestAppendAuditRecordVault(c *C) {
+	server := httptest.NewServer(newMockVault())
+	defer server.Close()
+	vsm, err := NewVaultStateMgr(VaultConfig{Address: server.URL, Token: "root"})
+	c.Assert(err, IsNil)
+	stored, err := vsm.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(stored, HasLen, 0)
+	// more than 9 records, the records are in the order of their seq whatever the order vault lists them in
+	var records []AuditRecord
+	for i := 0; i < 12; i++ {
+		records = append(records, AuditRecord{
+			Seq:        uint64(i + 1),
+			Time:       time.Now().UTC(),
+			PoolPubKey: "pool",
+			Status:     "success",
+		})
+	}
+	for _, el := range records {
+		c.Assert(vsm.AppendAuditRecord(el), IsNil)
+	}
+	// a record is never overwritten
+	c.Assert(vsm.AppendAuditRecord(records[0]), NotNil)
+	stored, err = vsm.RetrieveAuditRecords()
+	c.Assert(err, IsNil)
+	c.Assert(stored, HasLen, len(records))
+	for i, el := range stored {
+		c.Assert(el.Seq, Equals, records[i].Seq)
+		c.Assert(el.Time.Equal(records[i].Time), Equals, true)
+	}
+}
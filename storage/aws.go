@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsTimeout    = time.Second * 10
+	awsDateFormat = "20060102T150405Z"
+)
+
+// AWSCredentials are the credentials the requests to AWS are signed with
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set for the temporary credentials
+	SessionToken string
+}
+
+// AWSCredentialsFromEnv read the credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+func AWSCredentialsFromEnv() (AWSCredentials, error) {
+	creds := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if len(creds.AccessKeyID) == 0 || len(creds.SecretAccessKey) == 0 {
+		return AWSCredentials{}, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are needed")
+	}
+	return creds, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(buf []byte) string {
+	h := sha256.Sum256(buf)
+	return hex.EncodeToString(h[:])
+}
+
+// uriEncode encode the string the way the signature version 4 wants it, all the bytes but the unreserved characters
+// of RFC 3986 are percent encoded with upper case hex, so the space is %20 rather than +, and / is encoded unless it
+// is the separator of the path
+func uriEncode(s string, path bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~', path && ch == '/':
+			sb.WriteByte(ch)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", ch)
+		}
+	}
+	return sb.String()
+}
+
+// canonicalURI return the canonical path of the request, it is encoded once as S3 wants it, the other services we
+// call, KMS, are only ever sent /, so their double encoding never differs from it
+func canonicalURI(path string) string {
+	if len(path) == 0 {
+		return "/"
+	}
+	return uriEncode(path, true)
+}
+
+// canonicalQuery return the canonical query string of the request, the parameters are encoded one by one and sorted
+// by their encoded name and then their encoded value, a parameter without a value is kept with an empty one
+func canonicalQuery(rawQuery string) string {
+	if len(rawQuery) == 0 {
+		return ""
+	}
+	var params [][2]string
+	for _, el := range strings.Split(rawQuery, "&") {
+		if len(el) == 0 {
+			continue
+		}
+		k, v := el, ""
+		if i := strings.Index(el, "="); i >= 0 {
+			k, v = el[:i], el[i+1:]
+		}
+		// the parameters that are not escaped properly are signed as they are sent
+		if unescaped, err := url.QueryUnescape(k); err == nil {
+			k = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(v); err == nil {
+			v = unescaped
+		}
+		params = append(params, [2]string{uriEncode(k, false), uriEncode(v, false)})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+	ret := make([]string, len(params))
+	for i, el := range params {
+		ret[i] = el[0] + "=" + el[1]
+	}
+	return strings.Join(ret, "&")
+}
+
+// signV4 sign the request with the AWS signature version 4, all the headers of the request are signed
+func signV4(req *http.Request, body []byte, creds AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(awsDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if len(creds.SessionToken) > 0 {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// awsClient send the signed requests of a service of AWS
+type awsClient struct {
+	creds    AWSCredentials
+	region   string
+	service  string
+	endpoint string
+	client   *http.Client
+}
+
+func newAWSClient(creds AWSCredentials, region, service, endpoint string) (*awsClient, error) {
+	if len(region) == 0 {
+		return nil, errors.New("aws region is empty")
+	}
+	if len(endpoint) == 0 {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, region)
+	}
+	return &awsClient{
+		creds:    creds,
+		region:   region,
+		service:  service,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		client:   &http.Client{Timeout: awsTimeout},
+	}, nil
+}
+
+// do sign and send the request, it return the status code and the body of the response
+func (ac *awsClient) do(method, path string, headers map[string]string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, ac.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("fail to create the request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	signV4(req, body, ac.creds, ac.region, ac.service, time.Now())
+	resp, err := ac.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("fail to send the request: %w", err)
+	}
+	defer resp.Body.Close()
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("fail to read the response body: %w", err)
+	}
+	return resp.StatusCode, buf, nil
+}
+
+// AWSKMS is the KMS the data keys are generated and decrypted with
+type AWSKMS struct {
+	client *awsClient
+}
+
+// NewAWSKMS create a new AWSKMS of the region, the endpoint of the region is used if the endpoint is empty
+func NewAWSKMS(creds AWSCredentials, region, endpoint string) (*AWSKMS, error) {
+	client, err := newAWSClient(creds, region, "kms", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSKMS{client: client}, nil
+}
+
+// call the action of the KMS json api
+func (k *AWSKMS) call(action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the %s request: %w", action, err)
+	}
+	status, buf, err := k.client.do(http.MethodPost, "/", map[string]string{
+		"Content-Type": "application/x-amz-json-1.1",
+		"X-Amz-Target": "TrentService." + action,
+	}, body)
+	if err != nil {
+		return fmt.Errorf("fail to call %s: %w", action, err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("fail to call %s, unexpected status code: %d, %s", action, status, string(buf))
+	}
+	if err := json.Unmarshal(buf, out); err != nil {
+		return fmt.Errorf("fail to unmarshal the %s response: %w", action, err)
+	}
+	return nil
+}
+
+// GenerateDataKey return a new AES-256 data key, in plaintext and encrypted with the KMS key
+func (k *AWSKMS) GenerateDataKey(keyID string) ([]byte, []byte, error) {
+	var resp struct {
+		CiphertextBlob []byte
+		Plaintext      []byte
+	}
+	if err := k.call("GenerateDataKey", map[string]string{"KeyId": keyID, "KeySpec": "AES_256"}, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+// Decrypt return the plaintext of the encrypted data key
+func (k *AWSKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte
+	}
+	if err := k.call("Decrypt", map[string]interface{}{"KeyId": keyID, "CiphertextBlob": ciphertext}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// S3BlobStore keeps the blobs as the objects of a S3 bucket
+type S3BlobStore struct {
+	client *awsClient
+	bucket string
+	prefix string
+}
+
+// NewS3BlobStore create a new S3BlobStore that keeps the blobs under the prefix of the bucket, the requests are
+// path style, the endpoint of the region is used if the endpoint is empty
+func NewS3BlobStore(creds AWSCredentials, region, endpoint, bucket, prefix string) (*S3BlobStore, error) {
+	if len(bucket) == 0 {
+		return nil, errors.New("s3 bucket is empty")
+	}
+	client, err := newAWSClient(creds, region, "s3", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &S3BlobStore{client: client, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+func (s *S3BlobStore) objectPath(name string) string {
+	if len(s.prefix) == 0 {
+		return "/" + s.bucket + "/" + name
+	}
+	return "/" + s.bucket + "/" + s.prefix + "/" + name
+}
+
+// PutBlob write the object
+func (s *S3BlobStore) PutBlob(name string, buf []byte) error {
+	status, body, err := s.client.do(http.MethodPut, s.objectPath(name), map[string]string{
+		"X-Amz-Content-Sha256": sha256Hex(buf),
+	}, buf)
+	if err != nil {
+		return fmt.Errorf("fail to put the object(%s): %w", name, err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("fail to put the object(%s), unexpected status code: %d, %s", name, status, string(body))
+	}
+	return nil
+}
+
+// GetBlob read the object, the error is os.ErrNotExist if the object does not exist
+func (s *S3BlobStore) GetBlob(name string) ([]byte, error) {
+	status, body, err := s.client.do(http.MethodGet, s.objectPath(name), map[string]string{
+		"X-Amz-Content-Sha256": sha256Hex(nil),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the object(%s): %w", name, err)
+	}
+	if status == http.StatusNotFound {
+		return nil, &os.PathError{Op: "get", Path: name, Err: os.ErrNotExist}
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("fail to get the object(%s), unexpected status code: %d, %s", name, status, string(body))
+	}
+	return body, nil
+}
+
+// DeleteBlob delete the object
+func (s *S3BlobStore) DeleteBlob(name string) error {
+	status, body, err := s.client.do(http.MethodDelete, s.objectPath(name), map[string]string{
+		"X-Amz-Content-Sha256": sha256Hex(nil),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("fail to delete the object(%s): %w", name, err)
+	}
+	if status != http.StatusNoContent && status != http.StatusOK {
+		return fmt.Errorf("fail to delete the object(%s), unexpected status code: %d, %s", name, status, string(body))
+	}
+	return nil
+}
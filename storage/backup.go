@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// shareArchiveVersion is the version of the format of the share archives
+const shareArchiveVersion = 1
+
+// ErrShareExists is returned when the archive is restored on a node that holds the share of the key
+var ErrShareExists = errors.New("the node holds the share of the key")
+
+// ShareArchive is the backup of the share of a key, the share is encrypted with AES-256-GCM, the key is derived
+// from the passphrase with scrypt. The metadata is in the clear, so the archive can be told apart without the
+// passphrase, it is the additional data of the ciphertext so it can not be altered
+type ShareArchive struct {
+	Version       int             `json:"version"`
+	PubKey        string          `json:"pub_key"`
+	Algo          conversion.Algo `json:"algo,omitempty"`
+	LocalPartyKey string          `json:"local_party_key"`
+	CreatedAt     time.Time       `json:"created_at"`
+	KDF           string          `json:"kdf"`
+	Salt          []byte          `json:"salt"`
+	N             int             `json:"n"`
+	R             int             `json:"r"`
+	P             int             `json:"p"`
+	Nonce         []byte          `json:"nonce"`
+	Ciphertext    []byte          `json:"ciphertext"`
+}
+
+func (a ShareArchive) additionalData() []byte {
+	return []byte(fmt.Sprintf("%d|%s|%s|%s|%d", a.Version, a.PubKey, a.Algo, a.LocalPartyKey, a.CreatedAt.UnixNano()))
+}
+
+// ExportShare return the archive of the share of the key encrypted with the passphrase
+func ExportShare(stateManager LocalStateManager, pubKey string, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	state, err := stateManager.GetLocalState(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the local state: %w", err)
+	}
+	plaintext, err := marshalLocalState(state)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(plaintext)
+	archive := ShareArchive{
+		Version:       shareArchiveVersion,
+		PubKey:        state.PubKey,
+		Algo:          state.Algo,
+		LocalPartyKey: state.LocalPartyKey,
+		CreatedAt:     time.Now().UTC(),
+		KDF:           "scrypt",
+		Salt:          make([]byte, 32),
+		N:             scryptN,
+		R:             8,
+		P:             1,
+	}
+	if _, err := io.ReadFull(rand.Reader, archive.Salt); err != nil {
+		return nil, fmt.Errorf("fail to generate the salt: %w", err)
+	}
+	aead, err := newScryptCipher(passphrase, archive.Salt, archive.N, archive.R, archive.P)
+	if err != nil {
+		return nil, err
+	}
+	archive.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, archive.Nonce); err != nil {
+		return nil, fmt.Errorf("fail to generate the nonce: %w", err)
+	}
+	archive.Ciphertext = aead.Seal(nil, archive.Nonce, plaintext, archive.additionalData())
+	return json.Marshal(archive)
+}
+
+// RestoreShare decrypt the archive with the passphrase and save the share, it refuses to replace the share the
+// node holds, a corrupted share can be replaced
+func RestoreShare(stateManager LocalStateManager, buf, passphrase []byte) (KeygenLocalState, error) {
+	var archive ShareArchive
+	if err := json.Unmarshal(buf, &archive); err != nil {
+		return KeygenLocalState{}, fmt.Errorf("fail to unmarshal the share archive: %w", err)
+	}
+	if archive.Version != shareArchiveVersion {
+		return KeygenLocalState{}, fmt.Errorf("unknown version %d of the share archive", archive.Version)
+	}
+	if archive.KDF != "scrypt" {
+		return KeygenLocalState{}, fmt.Errorf("unknown kdf %s", archive.KDF)
+	}
+	aead, err := newScryptCipher(passphrase, archive.Salt, archive.N, archive.R, archive.P)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	if len(archive.Nonce) != aead.NonceSize() {
+		return KeygenLocalState{}, errors.New("invalid nonce of the share archive")
+	}
+	plaintext, err := aead.Open(nil, archive.Nonce, archive.Ciphertext, archive.additionalData())
+	if err != nil {
+		return KeygenLocalState{}, ErrWrongPassphrase
+	}
+	defer Zeroize(plaintext)
+	state, err := unmarshalLocalState(archive.PubKey, plaintext)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	if err := restoreLocalState(stateManager, state); err != nil {
+		return KeygenLocalState{}, err
+	}
+	return state, nil
+}
+
+// restoreLocalState save the restored share unless the node holds an intact share of the key
+func restoreLocalState(stateManager LocalStateManager, state KeygenLocalState) error {
+	if _, err := stateManager.GetLocalState(state.PubKey); err == nil {
+		return ErrShareExists
+	}
+	if err := stateManager.SaveLocalState(state); err != nil {
+		return fmt.Errorf("fail to save the local state: %w", err)
+	}
+	return nil
+}
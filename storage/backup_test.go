@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type BackupTestSuite struct{}
+
+var _ = Suite(&BackupTestSuite{})
+
+func (s *BackupTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *BackupTestSuite) TestExportRestoreShare(c *C) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+		Algo:            conversion.AlgoSecp256k1,
+	}
+	_, err = ExportShare(fsm, stateItem.PubKey, []byte("passphrase"))
+	c.Assert(err, NotNil)
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	_, err = ExportShare(fsm, stateItem.PubKey, nil)
+	c.Assert(err, NotNil)
+	buf, err := ExportShare(fsm, stateItem.PubKey, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(buf), "participant_keys"), Equals, false)
+	var archive ShareArchive
+	c.Assert(json.Unmarshal(buf, &archive), IsNil)
+	c.Assert(archive.PubKey, Equals, stateItem.PubKey)
+	c.Assert(archive.LocalPartyKey, Equals, "A")
+
+	// the share is restored on the new node, whose state is encrypted
+	newFolder := c.MkDir()
+	newFsm, err := NewEncryptedFileStateMgr(newFolder, []byte("keyshare passphrase"))
+	c.Assert(err, IsNil)
+	_, err = RestoreShare(newFsm, buf, []byte("wrong"))
+	c.Assert(err, Equals, ErrWrongPassphrase)
+	state, err := RestoreShare(newFsm, buf, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(state.PubKey, Equals, stateItem.PubKey)
+	item, err := newFsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.ParticipantKeys, DeepEquals, stateItem.ParticipantKeys)
+	_, err = RestoreShare(newFsm, buf, []byte("passphrase"))
+	c.Assert(err, Equals, ErrShareExists)
+
+	// a corrupted share is replaced
+	filePathName := filepath.Join(newFolder, "localstate-"+stateItem.PubKey+".json")
+	c.Assert(ioutil.WriteFile(filePathName, []byte("{"), 0o600), IsNil)
+	_, err = RestoreShare(newFsm, buf, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	_, err = newFsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+
+	// the metadata can not be altered
+	archive.LocalPartyKey = "B"
+	altered, err := json.Marshal(archive)
+	c.Assert(err, IsNil)
+	_, err = RestoreShare(fsm, altered, []byte("passphrase"))
+	c.Assert(errors.Is(err, ErrWrongPassphrase), Equals, true)
+	archive.Version = 2
+	altered, err = json.Marshal(archive)
+	c.Assert(err, IsNil)
+	_, err = RestoreShare(fsm, altered, []byte("passphrase"))
+	c.Assert(err, ErrorMatches, "unknown version.*")
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+const ceremoniesName = "ceremonies"
+
+// CeremonyRecord is the journal entry of a ceremony we take part in, it is written before the ceremony starts,
+// updated as the local party moves on to the next round, and removed once the ceremony ends. The ones left at the
+// restart are the ceremonies the crash interrupted
+type CeremonyRecord struct {
+	MsgID        string    `json:"msg_id"`
+	Kind         string    `json:"kind"`
+	PoolPubKey   string    `json:"pool_pub_key,omitempty"`
+	Participants []string  `json:"participants"`
+	StartedAt    time.Time `json:"started_at"`
+	// Round is the latest round the local party sent its messages of, Received is the number of the tss messages
+	// we had received by then
+	Round    string `json:"round,omitempty"`
+	Received int    `json:"received"`
+}
+
+// SaveCeremonies replace the ceremony journal in the file
+func (fsm *FileStateMgr) SaveCeremonies(records []CeremonyRecord) error {
+	if len(fsm.folder) < 1 {
+		return errors.New("base file path is invalid")
+	}
+	buf, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the ceremonies to json: %w", err)
+	}
+	filePathName := filepath.Join(fsm.folder, ceremoniesName+".json")
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, ceremoniesName+"-*"+tempFileSuffix, buf)
+}
+
+// RetrieveCeremonies read the ceremony journal from the file
+func (fsm *FileStateMgr) RetrieveCeremonies() ([]CeremonyRecord, error) {
+	if len(fsm.folder) < 1 {
+		return nil, errors.New("base file path is invalid")
+	}
+	filePathName := filepath.Join(fsm.folder, ceremoniesName+".json")
+	fsm.writeLock.RLock()
+	buf, err := ioutil.ReadFile(filePathName)
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var records []CeremonyRecord
+	if err := json.Unmarshal(buf, &records); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the ceremonies: %w", err)
+	}
+	return records, nil
+}
+
+// SaveCeremonies replace the ceremony journal in the database
+func (ssm *SqliteStateMgr) SaveCeremonies(records []CeremonyRecord) error {
+	return ssm.put("kv", "name", ceremoniesName, records)
+}
+
+// RetrieveCeremonies read the ceremony journal from the database
+func (ssm *SqliteStateMgr) RetrieveCeremonies() ([]CeremonyRecord, error) {
+	var records []CeremonyRecord
+	if err := ssm.get("kv", "name", ceremoniesName, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SaveCeremonies replace the ceremony journal
+func (msm *MemoryStateMgr) SaveCeremonies(records []CeremonyRecord) error {
+	return msm.put("kv", ceremoniesName, records)
+}
+
+// RetrieveCeremonies read the ceremony journal
+func (msm *MemoryStateMgr) RetrieveCeremonies() ([]CeremonyRecord, error) {
+	var records []CeremonyRecord
+	if err := msm.get("kv", ceremoniesName, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type CeremonyJournalTestSuite struct{}
+
+var _ = Suite(&CeremonyJournalTestSuite{})
+
+func (s *CeremonyJournalTestSuite) TestSaveCeremonies(c *C) {
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrieveCeremonies()
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+	records := []CeremonyRecord{{
+		MsgID:        "msg-1",
+		Kind:         "keygen",
+		Participants: []string{"A", "B"},
+		StartedAt:    time.Now().UTC().Truncate(time.Second),
+		Round:        "KGRound1Message",
+		Received:     1,
+	}}
+	c.Assert(fsm.SaveCeremonies(records), IsNil)
+	stored, err := fsm.RetrieveCeremonies()
+	c.Assert(err, IsNil)
+	c.Assert(stored, DeepEquals, records)
+	c.Assert(fsm.SaveCeremonies(nil), IsNil)
+	stored, err = fsm.RetrieveCeremonies()
+	c.Assert(err, IsNil)
+	c.Assert(stored, HasLen, 0)
+
+	msm := NewMemoryStateMgr()
+	c.Assert(msm.SaveCeremonies(records), IsNil)
+	stored, err = msm.RetrieveCeremonies()
+	c.Assert(err, IsNil)
+	c.Assert(stored, DeepEquals, records)
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrLocalStateCorrupted is returned when the local state does not match its checksum
+var ErrLocalStateCorrupted = errors.New("the local state is corrupted")
+
+// LocalStateLister is implemented by the state managers that can list the keys they hold the local state of
+type LocalStateLister interface {
+	ListLocalStates() ([]string, error)
+}
+
+// storedLocalState is the local state as it is saved, the checksum is the sha256 of the json of the state, so a
+// corrupted share is caught when it is read instead of failing the keysign
+type storedLocalState struct {
+	Version  int             `json:"version"`
+	Checksum string          `json:"checksum"`
+	State    json.RawMessage `json:"state"`
+}
+
+func checksum(buf []byte) string {
+	hash := sha256.Sum256(buf)
+	return hex.EncodeToString(hash[:])
+}
+
+// newStoredLocalState return the local state with its format version and checksum
+func newStoredLocalState(state KeygenLocalState) (storedLocalState, error) {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return storedLocalState{}, fmt.Errorf("fail to marshal KeygenLocalState to json: %w", err)
+	}
+	return storedLocalState{
+		Version:  localStateVersion,
+		Checksum: checksum(buf),
+		State:    buf,
+	}, nil
+}
+
+// marshalLocalState return the json of the local state with its format version and checksum
+func marshalLocalState(state KeygenLocalState) ([]byte, error) {
+	stored, err := newStoredLocalState(state)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(stored)
+}
+
+// storedLocalStateVersion return the format version of the saved local state, the local states saved before we
+// record the checksum are the version 0
+func storedLocalStateVersion(buf []byte) (int, error) {
+	var stored storedLocalState
+	if err := json.Unmarshal(buf, &stored); err != nil {
+		return 0, err
+	}
+	return stored.Version, nil
+}
+
+// unmarshalLocalState verify the checksum of the saved local state of the pub key, migrate it to the current format
+// and unmarshal it, the local states saved before we record the checksum are read as the version 0
+func unmarshalLocalState(pubKey string, buf []byte) (KeygenLocalState, error) {
+	var stored storedLocalState
+	if err := json.Unmarshal(buf, &stored); err != nil {
+		return KeygenLocalState{}, fmt.Errorf("%w: fail to unmarshal the local state of %s: %s", ErrLocalStateCorrupted, pubKey, err)
+	}
+	stateBuf := buf
+	if stored.Version != 0 || len(stored.State) > 0 {
+		if stored.Version > localStateVersion {
+			return KeygenLocalState{}, fmt.Errorf("unknown format version %d of the local state of %s", stored.Version, pubKey)
+		}
+		if checksum(stored.State) != stored.Checksum {
+			return KeygenLocalState{}, fmt.Errorf("%w: the checksum of the local state of %s does not match", ErrLocalStateCorrupted, pubKey)
+		}
+		stateBuf = stored.State
+	}
+	stateBuf, err := migrateLocalState(stored.Version, stateBuf)
+	if err != nil {
+		return KeygenLocalState{}, fmt.Errorf("%w: fail to migrate the local state of %s: %s", ErrLocalStateCorrupted, pubKey, err)
+	}
+	var localState KeygenLocalState
+	if err := json.Unmarshal(stateBuf, &localState); err != nil {
+		return KeygenLocalState{}, fmt.Errorf("%w: fail to unmarshal KeygenLocalState of %s: %s", ErrLocalStateCorrupted, pubKey, err)
+	}
+	if localState.PubKey != pubKey {
+		return KeygenLocalState{}, fmt.Errorf("%w: the local state of %s holds the key %s", ErrLocalStateCorrupted, pubKey, localState.PubKey)
+	}
+	return localState, nil
+}
+
+// VerifyLocalStates read the local state of each key the state manager holds and verify its checksum, it return
+// the error of each key that fails
+func VerifyLocalStates(lister LocalStateLister, stateManager LocalStateManager) (map[string]error, error) {
+	pubKeys, err := lister.ListLocalStates()
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	failed := make(map[string]error)
+	for _, el := range pubKeys {
+		if _, err := stateManager.GetLocalState(el); err != nil {
+			failed[el] = err
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type ChecksumTestSuite struct{}
+
+var _ = Suite(&ChecksumTestSuite{})
+
+func (s *ChecksumTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *ChecksumTestSuite) TestLocalStateChecksum(c *C) {
+	folder := c.MkDir()
+	fsm, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	filePathName := filepath.Join(folder, "localstate-"+stateItem.PubKey+".json")
+	buf, err := ioutil.ReadFile(filePathName)
+	c.Assert(err, IsNil)
+	var stored storedLocalState
+	c.Assert(json.Unmarshal(buf, &stored), IsNil)
+	c.Assert(stored.Version, Equals, localStateVersion)
+	c.Assert(stored.Checksum, Equals, checksum(stored.State))
+	pubKeys, err := fsm.ListLocalStates()
+	c.Assert(err, IsNil)
+	c.Assert(pubKeys, DeepEquals, []string{stateItem.PubKey})
+	failed, err := VerifyLocalStates(fsm, fsm)
+	c.Assert(err, IsNil)
+	c.Assert(failed, HasLen, 0)
+
+	// a flipped bit of the share is caught
+	corrupted := strings.Replace(string(buf), `"local_party_key":"A"`, `"local_party_key":"B"`, 1)
+	c.Assert(corrupted, Not(Equals), string(buf))
+	c.Assert(ioutil.WriteFile(filePathName, []byte(corrupted), 0o600), IsNil)
+	_, err = fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrLocalStateCorrupted), Equals, true)
+	failed, err = VerifyLocalStates(fsm, fsm)
+	c.Assert(err, IsNil)
+	c.Assert(failed, HasLen, 1)
+	c.Assert(errors.Is(failed[stateItem.PubKey], ErrLocalStateCorrupted), Equals, true)
+
+	// so is a truncated file
+	c.Assert(ioutil.WriteFile(filePathName, buf[:len(buf)/2], 0o600), IsNil)
+	_, err = fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrLocalStateCorrupted), Equals, true)
+
+	// the local states saved before the checksum are read as they are
+	legacy, err := json.Marshal(stateItem)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filePathName, legacy, 0o600), IsNil)
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+
+	// the share of another key is refused
+	otherPubKey := "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
+	c.Assert(ioutil.WriteFile(filepath.Join(folder, "localstate-"+otherPubKey+".json"), buf, 0o600), IsNil)
+	_, err = fsm.GetLocalState(otherPubKey)
+	c.Assert(errors.Is(err, ErrLocalStateCorrupted), Equals, true)
+
+	stored.Version = localStateVersion + 1
+	buf, err = json.Marshal(stored)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filePathName, buf, 0o600), IsNil)
+	_, err = fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, ErrorMatches, "unknown format version.*")
+}
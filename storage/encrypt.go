@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptionFileName = "encryption.json"
+	// encryptedFileVersion is the version of the format of the encrypted files
+	encryptedFileVersion = 1
+)
+
+// scryptN is the scrypt cost of the key the files are encrypted with, the tests lower it
+var scryptN = 1 << 15
+
+var (
+	// ErrWrongPassphrase is returned when the passphrase does not decrypt the files of the folder
+	ErrWrongPassphrase = errors.New("wrong passphrase, fail to decrypt the keyshare")
+	// ErrEncryptedFile is returned when the file is encrypted and the state manager has no passphrase
+	ErrEncryptedFile = errors.New("the file is encrypted, the passphrase is needed")
+	// ErrPlaintextFile is returned when the state manager has a passphrase and the file is not encrypted, anyone
+	// who can write to the folder could plant a keyshare otherwise
+	ErrPlaintextFile = errors.New("the file is not encrypted, encrypt the plaintext files with EncryptFiles(-migrate-keyshares) first")
+	// encryptionCheck is the plaintext we encrypt in the encryption file, so a wrong passphrase is caught on start
+	encryptionCheck = []byte("go-tss keyshare encryption")
+)
+
+// encryptionParams are the parameters of the key derived from the passphrase, they are saved in the folder, so
+// all the files of the folder are encrypted with the same key
+type encryptionParams struct {
+	KDF   string `json:"kdf"`
+	Salt  []byte `json:"salt"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Check []byte `json:"check"`
+}
+
+// encryptedFile is the content of an encrypted file, the file name is the additional data of the AES-256-GCM
+// ciphertext, so the share of a key can not be swapped in for another one
+type encryptedFile struct {
+	Version    int    `json:"encrypted"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewEncryptedFileStateMgr create a new FileStateMgr that encrypts the keyshares, the presignatures and the
+// pre-parameters at rest with AES-256-GCM, the key is derived from the passphrase with scrypt. The plaintext files
+// saved before are refused, EncryptFiles encrypts them
+func NewEncryptedFileStateMgr(folder string, passphrase []byte) (*FileStateMgr, error) {
+	if len(folder) < 1 {
+		return nil, errors.New("base file path is invalid")
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	fsm, err := NewFileStateMgr(folder)
+	if err != nil {
+		return nil, err
+	}
+	fsm.aead, err = loadEncryptionKey(filepath.Join(folder, encryptionFileName), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return fsm, nil
+}
+
+// newScryptCipher return the AES-256-GCM cipher of the key derived from the passphrase with scrypt
+func newScryptCipher(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("fail to derive the encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the gcm cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// ReadKeyFile read the passphrase from the key file, the trailing new line is dropped
+func ReadKeyFile(filePathName string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(filePathName)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the key file: %w", err)
+	}
+	return bytes.TrimRight(buf, "\r\n"), nil
+}
+
+// loadEncryptionKey derive the key from the passphrase with the parameters of the encryption file, the file is
+// created with a new salt if the folder has none
+func loadEncryptionKey(filePathName string, passphrase []byte) (cipher.AEAD, error) {
+	var params encryptionParams
+	buf, err := ioutil.ReadFile(filePathName)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(buf, &params); err != nil {
+			return nil, fmt.Errorf("fail to unmarshal the encryption parameters: %w", err)
+		}
+		if params.KDF != "scrypt" {
+			return nil, fmt.Errorf("unknown kdf %s", params.KDF)
+		}
+	case os.IsNotExist(err):
+		params = encryptionParams{KDF: "scrypt", Salt: make([]byte, 32), N: scryptN, R: 8, P: 1}
+		if _, err := rand.Read(params.Salt); err != nil {
+			return nil, fmt.Errorf("fail to generate the salt: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("fail to read the encryption parameters: %w", err)
+	}
+	aead, err := newScryptCipher(passphrase, params.Salt, params.N, params.R, params.P)
+	if err != nil {
+		return nil, err
+	}
+	if params.Check != nil {
+		if _, err := openFile(aead, encryptionFileName, params.Check); err != nil {
+			return nil, err
+		}
+		return aead, nil
+	}
+	params.Check, err = sealFile(aead, encryptionFileName, encryptionCheck)
+	if err != nil {
+		return nil, err
+	}
+	buf, err = json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal the encryption parameters to json: %w", err)
+	}
+	if err := replaceFile(filePathName, "encryption-*"+tempFileSuffix, buf); err != nil {
+		return nil, fmt.Errorf("fail to write the encryption parameters: %w", err)
+	}
+	return aead, nil
+}
+
+func sealFile(aead cipher.AEAD, name string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("fail to generate the nonce: %w", err)
+	}
+	buf, err := json.Marshal(encryptedFile{
+		Version:    encryptedFileVersion,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(name)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to marshal the encrypted file to json: %w", err)
+	}
+	return buf, nil
+}
+
+func openFile(aead cipher.AEAD, name string, buf []byte) ([]byte, error) {
+	var f encryptedFile
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the encrypted file: %w", err)
+	}
+	if f.Version != encryptedFileVersion {
+		return nil, fmt.Errorf("unknown version %d of the encrypted file", f.Version)
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce of the encrypted file")
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, []byte(name))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// isEncrypted return true if the content of the file is encrypted
+func isEncrypted(buf []byte) bool {
+	var f encryptedFile
+	return json.Unmarshal(buf, &f) == nil && f.Version > 0
+}
+
+// Encrypted return true if the state manager encrypts the files
+func (fsm *FileStateMgr) Encrypted() bool {
+	return fsm.aead != nil
+}
+
+// seal return the content of the file we write, it is encrypted if the state manager has a passphrase
+func (fsm *FileStateMgr) seal(filePathName string, buf []byte) ([]byte, error) {
+	if fsm.aead == nil {
+		return buf, nil
+	}
+	return sealFile(fsm.aead, filepath.Base(filePathName), buf)
+}
+
+// open return the plaintext of the file we read, the plaintext files are only read if the state manager has no
+// passphrase
+func (fsm *FileStateMgr) open(filePathName string, buf []byte) ([]byte, error) {
+	if !isEncrypted(buf) {
+		if fsm.aead != nil {
+			return nil, fmt.Errorf("fail to read file(%s): %w", filePathName, ErrPlaintextFile)
+		}
+		return buf, nil
+	}
+	if fsm.aead == nil {
+		return nil, ErrEncryptedFile
+	}
+	return openFile(fsm.aead, filepath.Base(filePathName), buf)
+}
+
+// EncryptFiles encrypt the plaintext keyshares, presignatures and pre-parameters of the folder, it return the
+// names of the files it has encrypted. Each file is replaced at once, but the plaintext may stay in the disk blocks
+// the file used
+func (fsm *FileStateMgr) EncryptFiles() ([]string, error) {
+	if fsm.aead == nil {
+		return nil, errors.New("the state manager has no passphrase")
+	}
+	var filePathNames []string
+	for _, pattern := range []string{"localstate-*.json", "presign-*.json", preParamsFileName} {
+		matches, err := filepath.Glob(filepath.Join(fsm.folder, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("fail to list the files: %w", err)
+		}
+		filePathNames = append(filePathNames, matches...)
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	var encrypted []string
+	for _, filePathName := range filePathNames {
+		buf, err := ioutil.ReadFile(filePathName)
+		if err != nil {
+			return encrypted, fmt.Errorf("fail to read file(%s): %w", filePathName, err)
+		}
+		if isEncrypted(buf) {
+			continue
+		}
+		sealed, err := fsm.seal(filePathName, buf)
+		if err != nil {
+			return encrypted, err
+		}
+		if err := replaceFile(filePathName, "encrypt-*.tmp", sealed); err != nil {
+			return encrypted, fmt.Errorf("fail to replace file(%s): %w", filePathName, err)
+		}
+		encrypted = append(encrypted, filepath.Base(filePathName))
+	}
+	return encrypted, nil
+}
@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// KMS generates the data keys the keyshares are encrypted with, and decrypts them
+type KMS interface {
+	// GenerateDataKey return a new AES-256 data key, in plaintext and encrypted with the KMS key
+	GenerateDataKey(keyID string) ([]byte, []byte, error)
+	// Decrypt return the plaintext of the data key the KMS key has encrypted
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// BlobStore keeps the encrypted keyshares
+type BlobStore interface {
+	PutBlob(name string, buf []byte) error
+	// GetBlob return os.ErrNotExist if the blob does not exist
+	GetBlob(name string) ([]byte, error)
+	DeleteBlob(name string) error
+}
+
+// FolderBlobStore keeps the blobs as the files of a folder
+type FolderBlobStore struct {
+	fsm *FileStateMgr
+}
+
+// NewFolderBlobStore create a new FolderBlobStore of the folder
+func NewFolderBlobStore(folder string) (*FolderBlobStore, error) {
+	if len(folder) < 1 {
+		return nil, errors.New("base file path is invalid")
+	}
+	fsm, err := NewFileStateMgr(folder)
+	if err != nil {
+		return nil, err
+	}
+	return &FolderBlobStore{fsm: fsm}, nil
+}
+
+// PutBlob replace the file of the blob at once
+func (f *FolderBlobStore) PutBlob(name string, buf []byte) error {
+	f.fsm.writeLock.Lock()
+	defer f.fsm.writeLock.Unlock()
+	return replaceFile(filepath.Join(f.fsm.folder, name), "blob-*.tmp", buf)
+}
+
+// GetBlob read the file of the blob
+func (f *FolderBlobStore) GetBlob(name string) ([]byte, error) {
+	f.fsm.writeLock.RLock()
+	defer f.fsm.writeLock.RUnlock()
+	return ioutil.ReadFile(filepath.Join(f.fsm.folder, name))
+}
+
+// DeleteBlob remove the file of the blob
+func (f *FolderBlobStore) DeleteBlob(name string) error {
+	f.fsm.writeLock.Lock()
+	defer f.fsm.writeLock.Unlock()
+	return os.Remove(filepath.Join(f.fsm.folder, name))
+}
+
+// envelope is a blob encrypted with AES-256-GCM under a data key, the data key is encrypted with the KMS key,
+// the blob name is the additional data, so the share of a key can not be swapped in for another one
+type envelope struct {
+	KeyID        string `json:"key_id"`
+	EncryptedKey []byte `json:"encrypted_key"`
+	Nonce        []byte `json:"nonce"`
+	Ciphertext   []byte `json:"ciphertext"`
+}
+
+// KMSStateMgr envelope encrypts the keyshares, the presignatures and the pre-parameters with the data keys of a
+// KMS, and keeps the ciphertexts in the blob store. The other state is not secret, it is kept in the folder. Each
+// write encrypts with a new data key, and the blobs of another KMS key are encrypted again with the current one
+// once they are read, so the keyshares follow the rotation of the KMS key
+type KMSStateMgr struct {
+	*FileStateMgr
+	logger zerolog.Logger
+	kms    KMS
+	keyID  string
+	blobs  BlobStore
+	// dataKeys caches the plaintext of the data keys by their ciphertext, so a read needs no call to the KMS
+	dataKeysLock *sync.Mutex
+	dataKeys     map[string][]byte
+}
+
+// NewKMSStateMgr create a new instance of the KMSStateMgr which implements LocalStateManager, the blobs are kept
+// in the folder if the blob store is nil
+func NewKMSStateMgr(folder string, kms KMS, keyID string, blobs BlobStore) (*KMSStateMgr, error) {
+	if kms == nil || len(keyID) == 0 {
+		return nil, errors.New("kms key id is empty")
+	}
+	fsm, err := NewFileStateMgr(folder)
+	if err != nil {
+		return nil, err
+	}
+	if blobs == nil {
+		blobs, err = NewFolderBlobStore(folder)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &KMSStateMgr{
+		FileStateMgr: fsm,
+		logger:       log.With().Str("module", "kms_state_mgr").Logger(),
+		kms:          kms,
+		keyID:        keyID,
+		blobs:        blobs,
+		dataKeysLock: &sync.Mutex{},
+		dataKeys:     make(map[string][]byte),
+	}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypt the value with a new data key and write it to the blob store
+func (ksm *KMSStateMgr) seal(name string, value interface{}) error {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the %s to json: %w", name, err)
+	}
+	key, encryptedKey, err := ksm.kms.GenerateDataKey(ksm.keyID)
+	if err != nil {
+		return fmt.Errorf("fail to generate the data key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("fail to generate the nonce: %w", err)
+	}
+	buf, err = json.Marshal(envelope{
+		KeyID:        ksm.keyID,
+		EncryptedKey: encryptedKey,
+		Nonce:        nonce,
+		Ciphertext:   aead.Seal(nil, nonce, buf, []byte(name)),
+	})
+	if err != nil {
+		return fmt.Errorf("fail to marshal the envelope to json: %w", err)
+	}
+	if err := ksm.blobs.PutBlob(name, buf); err != nil {
+		return err
+	}
+	ksm.cacheDataKey(encryptedKey, key)
+	return nil
+}
+
+func (ksm *KMSStateMgr) cacheDataKey(encryptedKey, key []byte) {
+	ksm.dataKeysLock.Lock()
+	defer ksm.dataKeysLock.Unlock()
+	ksm.dataKeys[hex.EncodeToString(encryptedKey)] = key
+}
+
+// dataKey return the plaintext of the data key of the envelope
+func (ksm *KMSStateMgr) dataKey(env envelope) ([]byte, error) {
+	ksm.dataKeysLock.Lock()
+	key, ok := ksm.dataKeys[hex.EncodeToString(env.EncryptedKey)]
+	ksm.dataKeysLock.Unlock()
+	if ok {
+		return key, nil
+	}
+	key, err := ksm.kms.Decrypt(env.KeyID, env.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to decrypt the data key: %w", err)
+	}
+	ksm.cacheDataKey(env.EncryptedKey, key)
+	return key, nil
+}
+
+// open read the blob and decrypt it into the value, the blob is encrypted again with the current KMS key if
+// another one has encrypted it
+func (ksm *KMSStateMgr) open(name string, value interface{}) error {
+	buf, err := ksm.blobs.GetBlob(name)
+	if err != nil {
+		return err
+	}
+	var env envelope
+	if err := json.Unmarshal(buf, &env); err != nil {
+		return fmt.Errorf("fail to unmarshal the envelope: %w", err)
+	}
+	key, err := ksm.dataKey(env)
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return errors.New("invalid nonce of the envelope")
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, []byte(name))
+	if err != nil {
+		return fmt.Errorf("fail to decrypt the %s: %w", name, err)
+	}
+	if err := json.Unmarshal(plaintext, value); err != nil {
+		return fmt.Errorf("fail to unmarshal the %s: %w", name, err)
+	}
+	if env.KeyID != ksm.keyID {
+		ksm.logger.Info().Str("from", env.KeyID).Str("to", ksm.keyID).Msgf("rotate the kms key of %s", name)
+		if err := ksm.seal(name, value); err != nil {
+			ksm.logger.Error().Err(err).Msgf("fail to rotate the kms key of %s", name)
+		}
+	}
+	return nil
+}
+
+// SaveLocalState encrypt the local state and save it to the blob store
+func (ksm *KMSStateMgr) SaveLocalState(state KeygenLocalState) error {
+	filePathName, err := ksm.getFilePathName(state.PubKey)
+	if err != nil {
+		return err
+	}
+	stored, err := newStoredLocalState(state)
+	if err != nil {
+		return err
+	}
+	defer Zeroize(stored.State)
+	return ksm.seal(filepath.Base(filePathName), stored)
+}
+
+// GetLocalState read the local state from the blob store
+func (ksm *KMSStateMgr) GetLocalState(pubKey string) (KeygenLocalState, error) {
+	buf, err := ksm.getStoredLocalState(pubKey)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	return loadLocalState(pubKey, buf)
+}
+
+func (ksm *KMSStateMgr) getStoredLocalState(pubKey string) ([]byte, error) {
+	if len(pubKey) == 0 {
+		return nil, errors.New("pub key is empty")
+	}
+	filePathName, err := ksm.getFilePathName(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	var buf json.RawMessage
+	if err := ksm.open(filepath.Base(filePathName), &buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ListLocalStates return the pub keys of the local states, only the blobs in the folder can be listed
+func (ksm *KMSStateMgr) ListLocalStates() ([]string, error) {
+	if _, ok := ksm.blobs.(*FolderBlobStore); !ok {
+		return nil, errors.New("the blob store can not list the local states")
+	}
+	return ksm.FileStateMgr.ListLocalStates()
+}
+
+// DeleteLocalState delete the local state and the presignatures of the key from the blob store
+func (ksm *KMSStateMgr) DeleteLocalState(pubKey string) error {
+	filePathName, err := ksm.getFilePathName(pubKey)
+	if err != nil {
+		return err
+	}
+	if err := ksm.blobs.DeleteBlob(filepath.Base(filePathName)); err != nil {
+		return fmt.Errorf("fail to delete the local state: %w", err)
+	}
+	presignFilePathName, err := ksm.getPresignFilePathName(pubKey)
+	if err != nil {
+		return err
+	}
+	if err := ksm.blobs.DeleteBlob(filepath.Base(presignFilePathName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("fail to delete the presignatures: %w", err)
+	}
+	return nil
+}
+
+// SavePresignatures encrypt the presignatures of the pool and save them to the blob store
+func (ksm *KMSStateMgr) SavePresignatures(poolPubKey string, presigs []Presignature) error {
+	filePathName, err := ksm.getPresignFilePathName(poolPubKey)
+	if err != nil {
+		return err
+	}
+	return ksm.seal(filepath.Base(filePathName), presigs)
+}
+
+// RetrievePresignatures read the presignatures of the pool from the blob store
+func (ksm *KMSStateMgr) RetrievePresignatures(poolPubKey string) ([]Presignature, error) {
+	filePathName, err := ksm.getPresignFilePathName(poolPubKey)
+	if err != nil {
+		return nil, err
+	}
+	var presigs []Presignature
+	if err := ksm.open(filepath.Base(filePathName), &presigs); err != nil {
+		return nil, err
+	}
+	return presigs, nil
+}
+
+// SavePreParams encrypt the pre-parameters and save them to the blob store
+func (ksm *KMSStateMgr) SavePreParams(preParams []PreParams) error {
+	return ksm.seal(preParamsFileName, preParams)
+}
+
+// RetrievePreParams read the pre-parameters from the blob store
+func (ksm *KMSStateMgr) RetrievePreParams() ([]PreParams, error) {
+	var preParams []PreParams
+	if err := ksm.open(preParamsFileName, &preParams); err != nil {
+		return nil, err
+	}
+	return preParams, nil
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// mockKMS encrypts the data keys with a master key of each key id
+type mockKMS struct {
+	lock     sync.Mutex
+	keys     map[string][]byte
+	decrypts int
+}
+
+func newMockKMS(keyIDs ...string) *mockKMS {
+	m := &mockKMS{keys: make(map[string][]byte)}
+	for _, el := range keyIDs {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		m.keys[el] = key
+	}
+	return m
+}
+
+func (m *mockKMS) GenerateDataKey(keyID string) ([]byte, []byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	master, ok := m.keys[keyID]
+	if !ok {
+		return nil, nil, errors.New("key not found")
+	}
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	aead, err := newGCM(master)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	return key, aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (m *mockKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.decrypts++
+	master, ok := m.keys[keyID]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	aead, err := newGCM(master)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil)
+}
+
+type KMSStateMgrTestSuite struct{}
+
+var _ = Suite(&KMSStateMgrTestSuite{})
+
+func (s *KMSStateMgrTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *KMSStateMgrTestSuite) TestKMSStateMgr(c *C) {
+	kms := newMockKMS("key1")
+	folder := c.MkDir()
+	_, err := NewKMSStateMgr(folder, kms, "", nil)
+	c.Assert(err, NotNil)
+	ksm, err := NewKMSStateMgr(folder, kms, "key1", nil)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	_, err = ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(ksm.SaveLocalState(stateItem), IsNil)
+	buf, err := ioutil.ReadFile(filepath.Join(folder, "localstate-"+stateItem.PubKey+".json"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(buf), "local_party_key"), Equals, false)
+
+	// a restarted node decrypts the data key with the KMS
+	ksm, err = NewKMSStateMgr(folder, kms, "key1", nil)
+	c.Assert(err, IsNil)
+	item, err := ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+	_, err = ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(kms.decrypts, Equals, 1)
+
+	c.Assert(ksm.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1", D: []byte("d")}}), IsNil)
+	presigs, err := ksm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(presigs[0].D, DeepEquals, []byte("d"))
+	c.Assert(ksm.SavePreParams([]PreParams{{CreatedAt: time.Now()}}), IsNil)
+	preParams, err := ksm.RetrievePreParams()
+	c.Assert(err, IsNil)
+	c.Assert(preParams, HasLen, 1)
+
+	// the share of another key can not be swapped in
+	otherPubKey := "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
+	c.Assert(ioutil.WriteFile(filepath.Join(folder, "localstate-"+otherPubKey+".json"), buf, 0o600), IsNil)
+	_, err = ksm.GetLocalState(otherPubKey)
+	c.Assert(err, NotNil)
+
+	// the state that is not secret stays in the folder
+	c.Assert(ksm.SaveSignRecords(stateItem.PubKey, []SignRecord{{Messages: []string{"aa"}}}), IsNil)
+	_, err = os.Stat(filepath.Join(folder, "ledger-"+stateItem.PubKey+".json"))
+	c.Assert(err, IsNil)
+
+	c.Assert(ksm.DeleteLocalState(stateItem.PubKey), IsNil)
+	_, err = ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = ksm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *KMSStateMgrTestSuite) TestKeyRotation(c *C) {
+	kms := newMockKMS("key1", "key2")
+	folder := c.MkDir()
+	ksm, err := NewKMSStateMgr(folder, kms, "key1", nil)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:        "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:     keygen.NewLocalPartySaveData(5),
+		LocalPartyKey: "A",
+	}
+	c.Assert(ksm.SaveLocalState(stateItem), IsNil)
+	blobName := "localstate-" + stateItem.PubKey + ".json"
+
+	// the share is encrypted again with the new key once it is read
+	ksm, err = NewKMSStateMgr(folder, kms, "key2", nil)
+	c.Assert(err, IsNil)
+	item, err := ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+	buf, err := ioutil.ReadFile(filepath.Join(folder, blobName))
+	c.Assert(err, IsNil)
+	var env envelope
+	c.Assert(json.Unmarshal(buf, &env), IsNil)
+	c.Assert(env.KeyID, Equals, "key2")
+
+	// the old key is not needed anymore
+	delete(kms.keys, "key1")
+	ksm, err = NewKMSStateMgr(folder, kms, "key2", nil)
+	c.Assert(err, IsNil)
+	_, err = ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+
+	// the share can not be read without the KMS key
+	delete(kms.keys, "key2")
+	ksm, err = NewKMSStateMgr(folder, kms, "key2", nil)
+	c.Assert(err, IsNil)
+	_, err = ksm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, ErrorMatches, "fail to decrypt the data key.*")
+}
+
+func (s *KMSStateMgrTestSuite) TestSignV4(c *C) {
+	// the cases of the signature version 4 test suite
+	testCases := []struct {
+		name      string
+		path      string
+		signature string
+	}{
+		{"get-vanilla", "/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-empty-query-key", "/?Param1=value1", "a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb"},
+		{"get-vanilla-query-order-key-case", "/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"get-vanilla-query-unreserved", "/?-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", "9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197"},
+		{"get-vanilla-utf8-query", "/?ሴ=bar", "2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04"},
+		{"get-utf8", "/ሴ", "8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85"},
+		{"get-space", "/example space/", "652487583200325589f1fba4c7e578f72c47cb61beeca81406b39ddec1366741"},
+	}
+	now, err := time.Parse(awsDateFormat, "20150830T123600Z")
+	c.Assert(err, IsNil)
+	for _, tc := range testCases {
+		c.Log(tc.name)
+		req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com"+tc.path, nil)
+		c.Assert(err, IsNil)
+		signV4(req, nil, AWSCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		}, "us-east-1", "service", now)
+		c.Assert(req.Header.Get("Authorization"), Equals, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature="+tc.signature)
+	}
+}
+
+func (s *KMSStateMgrTestSuite) TestCanonicalQuery(c *C) {
+	c.Assert(canonicalQuery(""), Equals, "")
+	// the space is %20 and the + is encoded, the name sorts before the value
+	c.Assert(canonicalQuery("b=x+y&a-b=1&a=2&c=%2B&d"), Equals, "a=2&a-b=1&b=x%20y&c=%2B&d=")
+	c.Assert(canonicalQuery("p=value2&p=Value1&p=value1"), Equals, "p=Value1&p=value1&p=value2")
+	c.Assert(canonicalURI(""), Equals, "/")
+	c.Assert(canonicalURI("/bucket/a b+c"), Equals, "/bucket/a%20b%2Bc")
+}
+
+func (s *KMSStateMgrTestSuite) TestAWSClients(c *C) {
+	creds := AWSCredentials{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "token"}
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=id/") || r.Header.Get("X-Amz-Security-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GenerateDataKey":
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": []byte("key"), "CiphertextBlob": []byte("encrypted")})
+			return
+		case "TrentService.Decrypt":
+			var req struct{ CiphertextBlob []byte }
+			_ = json.Unmarshal(body, &req)
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": append([]byte("plain-"), req.CiphertextBlob...)})
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			buf, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(buf)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	kms, err := NewAWSKMS(creds, "us-east-1", server.URL)
+	c.Assert(err, IsNil)
+	key, encryptedKey, err := kms.GenerateDataKey("alias/tss")
+	c.Assert(err, IsNil)
+	c.Assert(string(key), Equals, "key")
+	c.Assert(string(encryptedKey), Equals, "encrypted")
+	key, err = kms.Decrypt("alias/tss", encryptedKey)
+	c.Assert(err, IsNil)
+	c.Assert(string(key), Equals, "plain-encrypted")
+
+	_, err = NewS3BlobStore(creds, "us-east-1", server.URL, "", "")
+	c.Assert(err, NotNil)
+	blobs, err := NewS3BlobStore(creds, "us-east-1", server.URL, "bucket", "/node1/")
+	c.Assert(err, IsNil)
+	_, err = blobs.GetBlob("blob")
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(blobs.PutBlob("blob", []byte("hello")), IsNil)
+	c.Assert(objects["/bucket/node1/blob"], DeepEquals, []byte("hello"))
+	buf, err := blobs.GetBlob("blob")
+	c.Assert(err, IsNil)
+	c.Assert(buf, DeepEquals, []byte("hello"))
+	c.Assert(blobs.DeleteBlob("blob"), IsNil)
+	c.Assert(objects, HasLen, 0)
+
+	blobs.client.creds.AccessKeyID = "other"
+	c.Assert(blobs.PutBlob("blob", []byte("hello")), ErrorMatches, ".*unexpected status code: 403.*")
+}
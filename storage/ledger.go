@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// SignRecord is a keysign of a pool key we have taken part in
+type SignRecord struct {
+	// Messages are the sorted hex encoded message hashes of the keysign
+	Messages []string `json:"messages"`
+	// Conflicts are the values of the metadata keys of the conflict rules, like the utxos the messages spend
+	Conflicts map[string][]string `json:"conflicts,omitempty"`
+	SignedAt  time.Time           `json:"signed_at"`
+}
+
+func (fsm *FileStateMgr) getLedgerFilePathName(poolPubKey string) (string, error) {
+	ret, err := conversion.CheckKeyOnCurve(poolPubKey)
+	if err != nil {
+		return "", err
+	}
+	if !ret {
+		return "", errors.New("invalid pubkey for file name")
+	}
+	if len(fsm.folder) < 1 {
+		return "", errors.New("base file path is invalid")
+	}
+	return filepath.Join(fsm.folder, fmt.Sprintf("ledger-%s.json", poolPubKey)), nil
+}
+
+// SaveSignRecords replace the sign records of the pool on file, the file is replaced at once so a record is never
+// lost after a crash
+func (fsm *FileStateMgr) SaveSignRecords(poolPubKey string, records []SignRecord) error {
+	filePathName, err := fsm.getLedgerFilePathName(poolPubKey)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the sign records to json: %w", err)
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, "ledger-*.tmp", buf)
+}
+
+// RetrieveSignRecords read the sign records of the pool from file
+func (fsm *FileStateMgr) RetrieveSignRecords(poolPubKey string) ([]SignRecord, error) {
+	filePathName, err := fsm.getLedgerFilePathName(poolPubKey)
+	if err != nil {
+		return nil, err
+	}
+	fsm.writeLock.RLock()
+	buf, err := ioutil.ReadFile(filePathName)
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var records []SignRecord
+	if err := json.Unmarshal(buf, &records); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the sign records: %w", err)
+	}
+	return records, nil
+}
@@ -2,14 +2,17 @@ package storage
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/binance-chain/tss-lib/ecdsa/keygen"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -25,6 +28,72 @@ type KeygenLocalState struct {
 	LocalData       keygen.LocalPartySaveData `json:"local_data"`
 	ParticipantKeys []string                  `json:"participant_keys"` // the paticipant of last key gen
 	LocalPartyKey   string                    `json:"local_party_key"`
+	// Threshold is the number of the signers the keysign of the key needs, it is 0 for the keys saved before we
+	// record it, their threshold is derived from the number of the participants
+	Threshold int `json:"threshold,omitempty"`
+	// Algo is the algo of the key, it is empty for the secp256k1 keys saved before we record it
+	Algo conversion.Algo `json:"algo,omitempty"`
+	// Protocol is the signing protocol of the key, it is empty for the gg20 keys saved before we record it
+	Protocol conversion.SignProtocol `json:"protocol,omitempty"`
+	// Weights is the number of the shares the participants of a weighted key hold, it is empty for the keys of one
+	// share per participant
+	Weights map[string]int `json:"weights,omitempty"`
+	// ShareData is the save data of the other shares the local party holds of a weighted key, in the order of the
+	// shares
+	ShareData []keygen.LocalPartySaveData `json:"share_data,omitempty"`
+	// CreatedAt is when the share was generated, it is nil for the shares saved before we record it
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// RetiredAt is when the key was retired, the retired keys refuse the keysign and their share can be deleted
+	// once the cooling-off period is over
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	// DeletionTokenHash is the hash of the confirmation token the deletion of the retired key needs
+	DeletionTokenHash string `json:"deletion_token_hash,omitempty"`
+}
+
+// Retired return whether the key is retired
+func (s KeygenLocalState) Retired() bool {
+	return s.RetiredAt != nil
+}
+
+// Weighted return whether the participants of the key hold different numbers of shares
+func (s KeygenLocalState) Weighted() bool {
+	return len(s.Weights) > 0
+}
+
+// GetThreshold return the tss threshold of the key, the keysign needs more signers than it
+func (s KeygenLocalState) GetThreshold() (int, error) {
+	shares := conversion.TotalShares(s.ParticipantKeys, s.Weights)
+	if s.Threshold == 0 {
+		return conversion.GetThreshold(shares)
+	}
+	return conversion.GetThresholdFromSigners(s.Threshold, shares)
+}
+
+// GetPartyThreshold return the number of the parties the keysign needs besides the leader, the participants of a
+// weighted key are counted from the lightest ones, so whoever joins the keysign holds more shares than the threshold
+func (s KeygenLocalState) GetPartyThreshold() (int, error) {
+	threshold, err := s.GetThreshold()
+	if err != nil || len(s.Weights) == 0 {
+		return threshold, err
+	}
+	weights := make([]int, len(s.ParticipantKeys))
+	for i, el := range s.ParticipantKeys {
+		weights[i] = conversion.GetWeight(s.Weights, el)
+	}
+	sort.Ints(weights)
+	shares := 0
+	for i, w := range weights {
+		shares += w
+		if shares > threshold {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("the participants hold %d shares, the threshold is %d", shares, threshold)
+}
+
+// LocalShares return the save data of all the shares the local party holds
+func (s KeygenLocalState) LocalShares() []keygen.LocalPartySaveData {
+	return append([]keygen.LocalPartySaveData{s.LocalData}, s.ShareData...)
 }
 
 // LocalStateManager provide necessary methods to manage the local state, save it , and read it back
@@ -40,6 +109,8 @@ type LocalStateManager interface {
 type FileStateMgr struct {
 	folder    string
 	writeLock *sync.RWMutex
+	// aead encrypts the secret files, they are saved in plaintext if it is nil
+	aead cipher.AEAD
 }
 
 // NewFileStateMgr create a new instance of the FileStateMgr which implements LocalStateManager
@@ -51,6 +122,9 @@ func NewFileStateMgr(folder string) (*FileStateMgr, error) {
 				return nil, err
 			}
 		}
+		if err := removeTempFiles(folder); err != nil {
+			return nil, err
+		}
 	}
 	return &FileStateMgr{
 		folder:    folder,
@@ -76,39 +150,63 @@ func (fsm *FileStateMgr) getFilePathName(pubKey string) (string, error) {
 
 // SaveLocalState save the local state to file
 func (fsm *FileStateMgr) SaveLocalState(state KeygenLocalState) error {
-	buf, err := json.Marshal(state)
+	buf, err := marshalLocalState(state)
 	if err != nil {
-		return fmt.Errorf("fail to marshal KeygenLocalState to json: %w", err)
+		return err
 	}
+	defer Zeroize(buf)
 	filePathName, err := fsm.getFilePathName(state.PubKey)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filePathName, buf, 0o655)
+	buf, err = fsm.seal(filePathName, buf)
+	if err != nil {
+		return err
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, "localstate-*"+tempFileSuffix, buf)
 }
 
 // GetLocalState read the local state from file system
 func (fsm *FileStateMgr) GetLocalState(pubKey string) (KeygenLocalState, error) {
+	buf, err := fsm.getStoredLocalState(pubKey)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	return loadLocalState(pubKey, buf)
+}
+
+func (fsm *FileStateMgr) getStoredLocalState(pubKey string) ([]byte, error) {
 	if len(pubKey) == 0 {
-		return KeygenLocalState{}, errors.New("pub key is empty")
+		return nil, errors.New("pub key is empty")
 	}
 	filePathName, err := fsm.getFilePathName(pubKey)
 	if err != nil {
-		return KeygenLocalState{}, err
+		return nil, err
 	}
 	if _, err := os.Stat(filePathName); os.IsNotExist(err) {
-		return KeygenLocalState{}, err
+		return nil, err
 	}
 
 	buf, err := ioutil.ReadFile(filePathName)
 	if err != nil {
-		return KeygenLocalState{}, fmt.Errorf("file to read from file(%s): %w", filePathName, err)
+		return nil, fmt.Errorf("file to read from file(%s): %w", filePathName, err)
 	}
-	var localState KeygenLocalState
-	if err := json.Unmarshal(buf, &localState); nil != err {
-		return KeygenLocalState{}, fmt.Errorf("fail to unmarshal KeygenLocalState: %w", err)
+	return fsm.open(filePathName, buf)
+}
+
+// ListLocalStates return the pub keys of the local state files
+func (fsm *FileStateMgr) ListLocalStates() ([]string, error) {
+	fileNames, err := filepath.Glob(filepath.Join(fsm.folder, "localstate-*.json"))
+	if err != nil {
+		return nil, err
 	}
-	return localState, nil
+	pubKeys := make([]string, 0, len(fileNames))
+	for _, el := range fileNames {
+		pubKeys = append(pubKeys, strings.TrimSuffix(strings.TrimPrefix(filepath.Base(el), "localstate-"), ".json"))
+	}
+	return pubKeys, nil
 }
 
 func (fsm *FileStateMgr) SaveAddressBook(address map[peer.ID]p2p.AddrList) error {
@@ -133,7 +231,7 @@ func (fsm *FileStateMgr) SaveAddressBook(address map[peer.ID]p2p.AddrList) error
 	}
 	fsm.writeLock.Lock()
 	defer fsm.writeLock.Unlock()
-	return ioutil.WriteFile(filePathName, buf.Bytes(), 0o655)
+	return replaceFile(filePathName, "address_book-*"+tempFileSuffix, buf.Bytes())
 }
 
 func (fsm *FileStateMgr) RetrieveP2PAddresses() (p2p.AddrList, error) {
@@ -168,3 +266,71 @@ func (fsm *FileStateMgr) RetrieveP2PAddresses() (p2p.AddrList, error) {
 	}
 	return peerAddresses, nil
 }
+
+// SaveReputation save the reputation of the peers to file
+func (fsm *FileStateMgr) SaveReputation(reputation map[peer.ID]p2p.PeerReputation) error {
+	if len(fsm.folder) < 1 {
+		return errors.New("base file path is invalid")
+	}
+	buf, err := json.Marshal(reputation)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the reputation to json: %w", err)
+	}
+	filePathName := filepath.Join(fsm.folder, "reputation.json")
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, "reputation-*"+tempFileSuffix, buf)
+}
+
+// RetrieveReputation read the reputation of the peers from file
+func (fsm *FileStateMgr) RetrieveReputation() (map[peer.ID]p2p.PeerReputation, error) {
+	if len(fsm.folder) < 1 {
+		return nil, errors.New("base file path is invalid")
+	}
+	filePathName := filepath.Join(fsm.folder, "reputation.json")
+	fsm.writeLock.RLock()
+	buf, err := ioutil.ReadFile(filePathName)
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var reputation map[peer.ID]p2p.PeerReputation
+	if err := json.Unmarshal(buf, &reputation); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the reputation: %w", err)
+	}
+	return reputation, nil
+}
+
+// SavePeerRecords save the persisted peerstore to file
+func (fsm *FileStateMgr) SavePeerRecords(records map[peer.ID]p2p.PeerRecord) error {
+	if len(fsm.folder) < 1 {
+		return errors.New("base file path is invalid")
+	}
+	buf, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the peer records to json: %w", err)
+	}
+	filePathName := filepath.Join(fsm.folder, "peerstore.json")
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, "peerstore-*"+tempFileSuffix, buf)
+}
+
+// RetrievePeerRecords read the persisted peerstore from file
+func (fsm *FileStateMgr) RetrievePeerRecords() (map[peer.ID]p2p.PeerRecord, error) {
+	if len(fsm.folder) < 1 {
+		return nil, errors.New("base file path is invalid")
+	}
+	filePathName := filepath.Join(fsm.folder, "peerstore.json")
+	fsm.writeLock.RLock()
+	buf, err := ioutil.ReadFile(filePathName)
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var records map[peer.ID]p2p.PeerRecord
+	if err := json.Unmarshal(buf, &records); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the peer records: %w", err)
+	}
+	return records, nil
+}
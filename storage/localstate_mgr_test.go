@@ -1,10 +1,15 @@
 package storage
 
 import (
+	"errors"
+	"io/ioutil"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/binance-chain/tss-lib/ecdsa/keygen"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -74,6 +79,46 @@ func (s *FileStateMgrTestSuite) TestSaveLocalState(c *C) {
 	c.Assert(reflect.DeepEqual(stateItem, item), Equals, true)
 }
 
+func (s *FileStateMgrTestSuite) TestGetThreshold(c *C) {
+	state := KeygenLocalState{
+		ParticipantKeys: []string{"A", "B", "C", "D", "E", "F", "G"},
+	}
+	// the keys saved before the threshold is recorded derive it
+	threshold, err := state.GetThreshold()
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 4)
+	state.Threshold = 4
+	threshold, err = state.GetThreshold()
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 3)
+	state.Threshold = 3
+	_, err = state.GetThreshold()
+	c.Assert(err, NotNil)
+}
+
+func (s *FileStateMgrTestSuite) TestGetPartyThreshold(c *C) {
+	state := KeygenLocalState{
+		ParticipantKeys: []string{"A", "B", "C", "D"},
+	}
+	threshold, err := state.GetPartyThreshold()
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 2)
+	// 7 shares need 5 signers, the 4 lightest participants hold them
+	state.Weights = map[string]int{"A": 4}
+	threshold, err = state.GetThreshold()
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 4)
+	threshold, err = state.GetPartyThreshold()
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 3)
+	state.Weights = map[string]int{"A": 2, "B": 2, "C": 2}
+	state.Threshold = 5
+	threshold, err = state.GetPartyThreshold()
+	c.Assert(err, IsNil)
+	c.Assert(threshold, Equals, 2)
+	c.Assert(state.LocalShares(), HasLen, 1)
+}
+
 func (s *FileStateMgrTestSuite) TestSaveAddressBook(c *C) {
 	testAddresses := make(map[peer.ID]p2p.AddrList)
 	var t *testing.T
@@ -103,3 +148,283 @@ func (s *FileStateMgrTestSuite) TestSaveAddressBook(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(item, HasLen, 3)
 }
+
+func (s *FileStateMgrTestSuite) TestSaveReputation(c *C) {
+	var t *testing.T
+	id1 := tnet.RandIdentityOrFatal(t)
+	id2 := tnet.RandIdentityOrFatal(t)
+	reputation := map[peer.ID]p2p.PeerReputation{
+		id1.ID(): {Score: -10, Events: map[p2p.ReputationEvent]int{p2p.EventBlame: 1}},
+		id2.ID(): {Score: 1, Events: map[p2p.ReputationEvent]int{p2p.EventCeremonySuccess: 1}},
+	}
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrieveReputation()
+	c.Assert(err, NotNil)
+	c.Assert(fsm.SaveReputation(reputation), IsNil)
+	item, err := fsm.RetrieveReputation()
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 2)
+	c.Assert(item[id1.ID()].Score, Equals, float64(-10))
+	c.Assert(item[id1.ID()].Events[p2p.EventBlame], Equals, 1)
+}
+
+func (s *FileStateMgrTestSuite) TestSavePeerRecords(c *C) {
+	var t *testing.T
+	id1 := tnet.RandIdentityOrFatal(t)
+	expiry := time.Now().Add(time.Hour).UTC().Round(time.Second)
+	records := map[peer.ID]p2p.PeerRecord{
+		id1.ID(): {Addrs: []string{"/ip4/192.168.0.1/tcp/6668"}, Expiry: expiry},
+	}
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrievePeerRecords()
+	c.Assert(err, NotNil)
+	c.Assert(fsm.SavePeerRecords(records), IsNil)
+	item, err := fsm.RetrievePeerRecords()
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 1)
+	c.Assert(item[id1.ID()].Addrs, DeepEquals, records[id1.ID()].Addrs)
+	c.Assert(item[id1.ID()].Expiry.Equal(expiry), Equals, true)
+}
+
+func (s *FileStateMgrTestSuite) TestSavePresignatures(c *C) {
+	poolPubKey := "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq"
+	presigs := []Presignature{
+		{
+			ID:          "1",
+			PoolPubKey:  poolPubKey,
+			Signers:     []string{"a", "b"},
+			Commitments: map[string][][]byte{"a": {[]byte("d"), []byte("e")}},
+			D:           []byte("d"),
+			E:           []byte("e"),
+		},
+	}
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrievePresignatures(poolPubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = fsm.RetrievePresignatures("invalid")
+	c.Assert(err, NotNil)
+	c.Assert(fsm.SavePresignatures(poolPubKey, presigs), IsNil)
+	item, err := fsm.RetrievePresignatures(poolPubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 1)
+	c.Assert(item[0].Commitments, DeepEquals, presigs[0].Commitments)
+	c.Assert(item[0].D, DeepEquals, presigs[0].D)
+	c.Assert(fsm.SavePresignatures(poolPubKey, nil), IsNil)
+	item, err = fsm.RetrievePresignatures(poolPubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 0)
+}
+
+func (s *FileStateMgrTestSuite) TestSaveSignRecords(c *C) {
+	poolPubKey := "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq"
+	records := []SignRecord{
+		{
+			Messages:  []string{"aa", "bb"},
+			Conflicts: map[string][]string{"utxo": {"txid:0"}},
+			SignedAt:  time.Now().UTC().Round(time.Second),
+		},
+	}
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrieveSignRecords(poolPubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(fsm.SaveSignRecords("invalid", records), NotNil)
+	c.Assert(fsm.SaveSignRecords(poolPubKey, records), IsNil)
+	item, err := fsm.RetrieveSignRecords(poolPubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 1)
+	c.Assert(item[0].Messages, DeepEquals, records[0].Messages)
+	c.Assert(item[0].Conflicts, DeepEquals, records[0].Conflicts)
+	c.Assert(item[0].SignedAt.Equal(records[0].SignedAt), Equals, true)
+}
+
+func (s *FileStateMgrTestSuite) TestSavePreParams(c *C) {
+	createdAt := time.Now().UTC().Round(time.Second)
+	preParams := []PreParams{
+		{
+			Data:      keygen.LocalPreParams{NTildei: big.NewInt(35), H1i: big.NewInt(4), H2i: big.NewInt(9)},
+			CreatedAt: createdAt,
+		},
+	}
+	folder := c.MkDir()
+	fsm, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	_, err = fsm.RetrievePreParams()
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(fsm.SavePreParams(preParams), IsNil)
+	info, err := os.Stat(filepath.Join(folder, preParamsFileName))
+	c.Assert(err, IsNil)
+	c.Assert(info.Mode().Perm(), Equals, os.FileMode(0o600))
+	item, err := fsm.RetrievePreParams()
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 1)
+	c.Assert(item[0].Data.NTildei.Cmp(preParams[0].Data.NTildei), Equals, 0)
+	c.Assert(item[0].CreatedAt.Equal(createdAt), Equals, true)
+	c.Assert(fsm.SavePreParams(nil), IsNil)
+	item, err = fsm.RetrievePreParams()
+	c.Assert(err, IsNil)
+	c.Assert(item, HasLen, 0)
+}
+
+func (s *FileStateMgrTestSuite) TestDeleteLocalState(c *C) {
+	retiredAt := time.Now().UTC().Round(time.Second)
+	stateItem := KeygenLocalState{
+		PubKey:            "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:         keygen.NewLocalPartySaveData(5),
+		ParticipantKeys:   []string{"A", "B", "C"},
+		LocalPartyKey:     "A",
+		RetiredAt:         &retiredAt,
+		DeletionTokenHash: "hash",
+	}
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(fsm.DeleteLocalState(stateItem.PubKey), NotNil)
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.Retired(), Equals, true)
+	c.Assert(item.RetiredAt.Equal(retiredAt), Equals, true)
+	c.Assert(item.DeletionTokenHash, Equals, "hash")
+	c.Assert(fsm.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1"}}), IsNil)
+	c.Assert(fsm.DeleteLocalState(stateItem.PubKey), IsNil)
+	_, err = fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = fsm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *FileStateMgrTestSuite) TestEncryptedFileStateMgr(c *C) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	folder := c.MkDir()
+	_, err := NewEncryptedFileStateMgr(folder, nil)
+	c.Assert(err, NotNil)
+	_, err = NewEncryptedFileStateMgr("", []byte("passphrase"))
+	c.Assert(err, NotNil)
+	fsm, err := NewEncryptedFileStateMgr(folder, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(fsm.Encrypted(), Equals, true)
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	c.Assert(fsm.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1", D: []byte("d")}}), IsNil)
+	c.Assert(fsm.SavePreParams([]PreParams{{Data: keygen.LocalPreParams{NTildei: big.NewInt(35)}}}), IsNil)
+
+	// the share is not on the disk in plaintext
+	buf, err := ioutil.ReadFile(filepath.Join(folder, "localstate-"+stateItem.PubKey+".json"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(buf), "local_party_key"), Equals, false)
+	c.Assert(isEncrypted(buf), Equals, true)
+
+	// the same passphrase decrypts the files after a restart
+	fsm, err = NewEncryptedFileStateMgr(folder, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(reflect.DeepEqual(stateItem, item), Equals, true)
+	presigs, err := fsm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(presigs[0].D, DeepEquals, []byte("d"))
+	preParams, err := fsm.RetrievePreParams()
+	c.Assert(err, IsNil)
+	c.Assert(preParams[0].Data.NTildei.Int64(), Equals, int64(35))
+
+	// the files can not be read without the passphrase
+	plain, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	_, err = plain.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrEncryptedFile), Equals, true)
+
+	// the share of another key can not be swapped in
+	otherPubKey := "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
+	c.Assert(ioutil.WriteFile(filepath.Join(folder, "localstate-"+otherPubKey+".json"), buf, 0o600), IsNil)
+	_, err = fsm.GetLocalState(otherPubKey)
+	c.Assert(errors.Is(err, ErrWrongPassphrase), Equals, true)
+}
+
+func (s *FileStateMgrTestSuite) TestWrongPassphrase(c *C) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	folder := c.MkDir()
+	fsm, err := NewEncryptedFileStateMgr(folder, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:    "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData: keygen.NewLocalPartySaveData(5),
+	}
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+
+	// the wrong passphrase is refused on start
+	_, err = NewEncryptedFileStateMgr(folder, []byte("wrong"))
+	c.Assert(errors.Is(err, ErrWrongPassphrase), Equals, true)
+
+	// the files of another passphrase are refused too
+	other, err := NewEncryptedFileStateMgr(folder, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	other.aead, err = loadEncryptionKey(filepath.Join(c.MkDir(), encryptionFileName), []byte("wrong"))
+	c.Assert(err, IsNil)
+	_, err = other.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrWrongPassphrase), Equals, true)
+}
+
+func (s *FileStateMgrTestSuite) TestEncryptFiles(c *C) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+	stateItem := KeygenLocalState{
+		PubKey:        "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:     keygen.NewLocalPartySaveData(5),
+		LocalPartyKey: "A",
+	}
+	folder := c.MkDir()
+	plain, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	_, err = plain.EncryptFiles()
+	c.Assert(err, NotNil)
+	c.Assert(plain.SaveLocalState(stateItem), IsNil)
+	c.Assert(plain.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1"}}), IsNil)
+	c.Assert(plain.SaveSignRecords(stateItem.PubKey, []SignRecord{{Messages: []string{"aa"}}}), IsNil)
+
+	// the plaintext files are refused before the migration
+	fsm, err := NewEncryptedFileStateMgr(folder, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	_, err = fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrPlaintextFile), Equals, true)
+	_, err = fsm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrPlaintextFile), Equals, true)
+
+	encrypted, err := fsm.EncryptFiles()
+	c.Assert(err, IsNil)
+	c.Assert(encrypted, DeepEquals, []string{
+		"localstate-" + stateItem.PubKey + ".json",
+		"presign-" + stateItem.PubKey + ".json",
+	})
+	// the migration is done once
+	encrypted, err = fsm.EncryptFiles()
+	c.Assert(err, IsNil)
+	c.Assert(encrypted, HasLen, 0)
+	_, err = plain.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, ErrEncryptedFile), Equals, true)
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+	// the sign ledger is not secret, it stays in plaintext
+	_, err = plain.RetrieveSignRecords(stateItem.PubKey)
+	c.Assert(err, IsNil)
+}
+
+func (s *FileStateMgrTestSuite) TestReadKeyFile(c *C) {
+	filePathName := filepath.Join(c.MkDir(), "keyfile")
+	c.Assert(ioutil.WriteFile(filePathName, []byte("passphrase\n"), 0o600), IsNil)
+	passphrase, err := ReadKeyFile(filePathName)
+	c.Assert(err, IsNil)
+	c.Assert(string(passphrase), Equals, "passphrase")
+	_, err = ReadKeyFile(filePathName + "-missing")
+	c.Assert(err, NotNil)
+}
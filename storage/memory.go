@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+
+	"github.com/akildemir/go-tss/p2p"
+)
+
+const memoryLocalStates = "local_states"
+
+// MemoryStateMgr keeps the state in memory, nothing is written to the disk, so it is all lost once the process
+// exits. It is for the tests and the ephemeral committees whose keys are only used for a short while
+type MemoryStateMgr struct {
+	lock *sync.RWMutex
+	// values are the json of the values, keyed by the table and the key, so the callers never share the memory of
+	// the values we hold
+	values map[string][]byte
+}
+
+// NewMemoryStateMgr create a new instance of the MemoryStateMgr
+func NewMemoryStateMgr() *MemoryStateMgr {
+	return &MemoryStateMgr{
+		lock:   &sync.RWMutex{},
+		values: make(map[string][]byte),
+	}
+}
+
+func memoryKey(table, key string) string {
+	return table + "/" + key
+}
+
+// put save the value as json under the key of the table
+func (msm *MemoryStateMgr) put(table, key string, value interface{}) error {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the %s to json: %w", table, err)
+	}
+	msm.lock.Lock()
+	defer msm.lock.Unlock()
+	msm.values[memoryKey(table, key)] = buf
+	return nil
+}
+
+// get read the json value of the key of the table, the error is os.ErrNotExist if there is no value
+func (msm *MemoryStateMgr) get(table, key string, value interface{}) error {
+	msm.lock.RLock()
+	buf, ok := msm.values[memoryKey(table, key)]
+	msm.lock.RUnlock()
+	if !ok {
+		return &os.PathError{Op: "read", Path: memoryKey(table, key), Err: os.ErrNotExist}
+	}
+	if err := json.Unmarshal(buf, value); err != nil {
+		return fmt.Errorf("fail to unmarshal the %s(%s): %w", table, key, err)
+	}
+	return nil
+}
+
+// SaveLocalState save the local state in memory
+func (msm *MemoryStateMgr) SaveLocalState(state KeygenLocalState) error {
+	if len(state.PubKey) == 0 {
+		return errors.New("pub key is empty")
+	}
+	stored, err := newStoredLocalState(state)
+	if err != nil {
+		return err
+	}
+	defer Zeroize(stored.State)
+	return msm.put(memoryLocalStates, state.PubKey, stored)
+}
+
+// GetLocalState read the local state of the pub key
+func (msm *MemoryStateMgr) GetLocalState(pubKey string) (KeygenLocalState, error) {
+	buf, err := msm.getStoredLocalState(pubKey)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	return loadLocalState(pubKey, buf)
+}
+
+func (msm *MemoryStateMgr) getStoredLocalState(pubKey string) ([]byte, error) {
+	if len(pubKey) == 0 {
+		return nil, errors.New("pub key is empty")
+	}
+	var buf json.RawMessage
+	if err := msm.get(memoryLocalStates, pubKey, &buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ListLocalStates return the pub keys of the local states we hold
+func (msm *MemoryStateMgr) ListLocalStates() ([]string, error) {
+	msm.lock.RLock()
+	defer msm.lock.RUnlock()
+	prefix := memoryKey(memoryLocalStates, "")
+	var pubKeys []string
+	for k := range msm.values {
+		if strings.HasPrefix(k, prefix) {
+			pubKeys = append(pubKeys, strings.TrimPrefix(k, prefix))
+		}
+	}
+	sort.Strings(pubKeys)
+	return pubKeys, nil
+}
+
+// DeleteLocalState zero the local state of the key before it drops it, the presignatures of the key are dropped as
+// well
+func (msm *MemoryStateMgr) DeleteLocalState(pubKey string) error {
+	msm.lock.Lock()
+	defer msm.lock.Unlock()
+	key := memoryKey(memoryLocalStates, pubKey)
+	buf, ok := msm.values[key]
+	if !ok {
+		return &os.PathError{Op: "delete", Path: key, Err: os.ErrNotExist}
+	}
+	for i := range buf {
+		buf[i] = 0
+	}
+	delete(msm.values, key)
+	delete(msm.values, memoryKey("presignatures", pubKey))
+	return nil
+}
+
+func (msm *MemoryStateMgr) SaveAddressBook(address map[peer.ID]p2p.AddrList) error {
+	var records []string
+	for peer, addrs := range address {
+		for _, addr := range addrs {
+			// we do not save the loopback addr
+			if strings.Contains(addr.String(), "127.0.0.1") {
+				continue
+			}
+			records = append(records, addr.String()+"/p2p/"+peer.String())
+		}
+	}
+	return msm.put("kv", addressBookName, records)
+}
+
+func (msm *MemoryStateMgr) RetrieveP2PAddresses() (p2p.AddrList, error) {
+	var records []string
+	if err := msm.get("kv", addressBookName, &records); err != nil {
+		return nil, err
+	}
+	var peerAddresses []p2p.Multiaddr
+	for _, el := range records {
+		addr, err := maddr.NewMultiaddr(el)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address in address book %w", err)
+		}
+		peerAddresses = append(peerAddresses, addr)
+	}
+	return peerAddresses, nil
+}
+
+// SaveReputation save the reputation of the peers in memory
+func (msm *MemoryStateMgr) SaveReputation(reputation map[peer.ID]p2p.PeerReputation) error {
+	return msm.put("kv", reputationName, reputation)
+}
+
+// RetrieveReputation read the reputation of the peers
+func (msm *MemoryStateMgr) RetrieveReputation() (map[peer.ID]p2p.PeerReputation, error) {
+	var reputation map[peer.ID]p2p.PeerReputation
+	if err := msm.get("kv", reputationName, &reputation); err != nil {
+		return nil, err
+	}
+	return reputation, nil
+}
+
+// SavePeerRecords save the peerstore in memory
+func (msm *MemoryStateMgr) SavePeerRecords(records map[peer.ID]p2p.PeerRecord) error {
+	return msm.put("kv", peerstoreName, records)
+}
+
+// RetrievePeerRecords read the saved peerstore
+func (msm *MemoryStateMgr) RetrievePeerRecords() (map[peer.ID]p2p.PeerRecord, error) {
+	var records map[peer.ID]p2p.PeerRecord
+	if err := msm.get("kv", peerstoreName, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SavePresignatures replace the presignatures of the pool
+func (msm *MemoryStateMgr) SavePresignatures(poolPubKey string, presigs []Presignature) error {
+	return msm.put("presignatures", poolPubKey, presigs)
+}
+
+// RetrievePresignatures read the presignatures of the pool
+func (msm *MemoryStateMgr) RetrievePresignatures(poolPubKey string) ([]Presignature, error) {
+	var presigs []Presignature
+	if err := msm.get("presignatures", poolPubKey, &presigs); err != nil {
+		return nil, err
+	}
+	return presigs, nil
+}
+
+// SaveSignRecords replace the sign records of the pool
+func (msm *MemoryStateMgr) SaveSignRecords(poolPubKey string, records []SignRecord) error {
+	return msm.put("sign_records", poolPubKey, records)
+}
+
+// RetrieveSignRecords read the sign records of the pool
+func (msm *MemoryStateMgr) RetrieveSignRecords(poolPubKey string) ([]SignRecord, error) {
+	var records []SignRecord
+	if err := msm.get("sign_records", poolPubKey, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// AppendAuditRecord append the record to the audit log
+func (msm *MemoryStateMgr) AppendAuditRecord(record AuditRecord) error {
+	records, err := msm.RetrieveAuditRecords()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return msm.put("audit", "", append(records, record))
+}
+
+// RetrieveAuditRecords read the records of the audit log
+func (msm *MemoryStateMgr) RetrieveAuditRecords() ([]AuditRecord, error) {
+	var records []AuditRecord
+	if err := msm.get("audit", "", &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SavePreParams replace the pre-parameters
+func (msm *MemoryStateMgr) SavePreParams(preParams []PreParams) error {
+	return msm.put("kv", preParamsName, preParams)
+}
+
+// RetrievePreParams read the pre-parameters
+func (msm *MemoryStateMgr) RetrievePreParams() ([]PreParams, error) {
+	var preParams []PreParams
+	if err := msm.get("kv", preParamsName, &preParams); err != nil {
+		return nil, err
+	}
+	return preParams, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"os"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+type MemoryStateMgrTestSuite struct{}
+
+var _ = Suite(&MemoryStateMgrTestSuite{})
+
+func (s *MemoryStateMgrTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *MemoryStateMgrTestSuite) TestLocalState(c *C) {
+	msm := NewMemoryStateMgr()
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+		Algo:            conversion.AlgoSecp256k1,
+		Protocol:        conversion.SignProtocolGG20,
+	}
+	c.Assert(msm.SaveLocalState(KeygenLocalState{}), NotNil)
+	_, err := msm.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+	c.Assert(msm.SaveLocalState(stateItem), IsNil)
+	item, err := msm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item, DeepEquals, stateItem)
+	// the caller does not share the memory of the state we hold
+	item.ParticipantKeys[0] = "D"
+	item, err = msm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.ParticipantKeys[0], Equals, "A")
+
+	pubKeys, err := msm.ListLocalStates()
+	c.Assert(err, IsNil)
+	c.Assert(pubKeys, DeepEquals, []string{stateItem.PubKey})
+	c.Assert(msm.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1"}}), IsNil)
+	c.Assert(msm.DeleteLocalState(stateItem.PubKey), IsNil)
+	_, err = msm.GetLocalState(stateItem.PubKey)
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+	_, err = msm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+	c.Assert(errors.Is(msm.DeleteLocalState(stateItem.PubKey), os.ErrNotExist), Equals, true)
+}
+
+func (s *MemoryStateMgrTestSuite) TestPeers(c *C) {
+	msm := NewMemoryStateMgr()
+	_, err := msm.RetrieveP2PAddresses()
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+	id, err := peer.Decode("16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+	c.Assert(err, IsNil)
+	addr, err := maddr.NewMultiaddr("/ip4/192.168.3.5/tcp/6668")
+	c.Assert(err, IsNil)
+	c.Assert(msm.SaveAddressBook(map[peer.ID]p2p.AddrList{id: {addr}}), IsNil)
+	addrs, err := msm.RetrieveP2PAddresses()
+	c.Assert(err, IsNil)
+	c.Assert(addrs, HasLen, 1)
+
+	c.Assert(msm.SaveReputation(map[peer.ID]p2p.PeerReputation{id: {}}), IsNil)
+	reputation, err := msm.RetrieveReputation()
+	c.Assert(err, IsNil)
+	c.Assert(reputation, HasLen, 1)
+}
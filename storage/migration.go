@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// localStateVersion is the format version of the local states we save, it is the number of the migrations
+const localStateVersion = 2
+
+// localStateMigration migrate the json of the local state from its format version to the next one
+type localStateMigration func(state json.RawMessage) (json.RawMessage, error)
+
+// localStateMigrations are the migrations of the local state format, the one at i migrates the version i to i+1.
+// A change of the format appends its migration and bumps localStateVersion, so the operators never edit the files
+var localStateMigrations = []localStateMigration{
+	// the version 0 is the bare state saved before we record the checksum, the version 1 wraps it as it is
+	func(state json.RawMessage) (json.RawMessage, error) {
+		return state, nil
+	},
+	migrateExplicitAlgo,
+}
+
+// migrateLocalState migrate the json of the local state of the version to the current format
+func migrateLocalState(version int, state json.RawMessage) (json.RawMessage, error) {
+	var err error
+	for i := version; i < localStateVersion; i++ {
+		state, err = localStateMigrations[i](state)
+		if err != nil {
+			return nil, fmt.Errorf("fail to migrate the version %d: %w", i, err)
+		}
+	}
+	return state, nil
+}
+
+// migrateExplicitAlgo record the algo and the protocol of the keys saved before we record them, they are the
+// secp256k1 gg20 keys
+func migrateExplicitAlgo(state json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(state, &fields); err != nil {
+		return nil, err
+	}
+	defaults := map[string]interface{}{
+		"algo":     conversion.AlgoSecp256k1,
+		"protocol": conversion.SignProtocolGG20,
+	}
+	for k, v := range defaults {
+		if len(fields[k]) > 0 && string(fields[k]) != `""` {
+			continue
+		}
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = buf
+	}
+	return json.Marshal(fields)
+}
+
+// storedLocalStateGetter is implemented by the state managers of this package, it return the local state as it is
+// saved, before it is migrated
+type storedLocalStateGetter interface {
+	getStoredLocalState(pubKey string) ([]byte, error)
+}
+
+// MigrateLocalStates save the local states of the outdated format versions in the current one, it return the keys
+// that are migrated. The local states that can not be read are left as they are
+func MigrateLocalStates(lister LocalStateLister, stateManager LocalStateManager) ([]string, error) {
+	getter, ok := stateManager.(storedLocalStateGetter)
+	if !ok {
+		return nil, errors.New("the state manager can not read the saved local states")
+	}
+	pubKeys, err := lister.ListLocalStates()
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	var migrated []string
+	for _, el := range pubKeys {
+		buf, err := getter.getStoredLocalState(el)
+		if err != nil {
+			continue
+		}
+		if version, err := storedLocalStateVersion(buf); err != nil || version >= localStateVersion {
+			continue
+		}
+		state, err := stateManager.GetLocalState(el)
+		if err != nil {
+			continue
+		}
+		if err := stateManager.SaveLocalState(state); err != nil {
+			return migrated, fmt.Errorf("fail to save the migrated local state of %s: %w", el, err)
+		}
+		migrated = append(migrated, el)
+	}
+	return migrated, nil
+}
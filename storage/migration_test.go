@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type MigrationTestSuite struct{}
+
+var _ = Suite(&MigrationTestSuite{})
+
+func (s *MigrationTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *MigrationTestSuite) TestMigrateLocalStates(c *C) {
+	c.Assert(localStateMigrations, HasLen, localStateVersion)
+	folder := c.MkDir()
+	fsm, err := NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	otherItem := stateItem
+	otherItem.PubKey = "thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3"
+	otherItem.Algo = conversion.AlgoSecp256k1
+	otherItem.Protocol = conversion.SignProtocolMuSig2
+
+	// the bare state of the version 0, and the wrapped state of the version 1
+	legacy, err := json.Marshal(stateItem)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(folder, "localstate-"+stateItem.PubKey+".json"), legacy, 0o600), IsNil)
+	otherBuf, err := json.Marshal(otherItem)
+	c.Assert(err, IsNil)
+	buf, err := json.Marshal(storedLocalState{Version: 1, Checksum: checksum(otherBuf), State: otherBuf})
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(folder, "localstate-"+otherItem.PubKey+".json"), buf, 0o600), IsNil)
+
+	item, err := fsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.Algo, Equals, conversion.AlgoSecp256k1)
+	c.Assert(item.Protocol, Equals, conversion.SignProtocolGG20)
+	item, err = fsm.GetLocalState(otherItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.Protocol, Equals, conversion.SignProtocolMuSig2)
+
+	migrated, err := MigrateLocalStates(fsm, fsm)
+	c.Assert(err, IsNil)
+	c.Assert(migrated, HasLen, 2)
+	for _, el := range []string{stateItem.PubKey, otherItem.PubKey} {
+		buf, err := fsm.getStoredLocalState(el)
+		c.Assert(err, IsNil)
+		version, err := storedLocalStateVersion(buf)
+		c.Assert(err, IsNil)
+		c.Assert(version, Equals, localStateVersion)
+	}
+	item, err = fsm.GetLocalState(otherItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.Protocol, Equals, conversion.SignProtocolMuSig2)
+	c.Assert(item.ParticipantKeys, DeepEquals, otherItem.ParticipantKeys)
+
+	// the current ones are left as they are
+	migrated, err = MigrateLocalStates(fsm, fsm)
+	c.Assert(err, IsNil)
+	c.Assert(migrated, HasLen, 0)
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+)
+
+const preParamsFileName = "preparams.json"
+
+// PreParams is a set of the pre-parameters of the keygen we have generated ahead, they hold the Paillier private
+// key, so they are as secret as the key shares
+type PreParams struct {
+	Data      keygen.LocalPreParams `json:"data"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// SavePreParams replace the pre-parameters on file, the file is replaced at once so a used one never comes back
+// after a crash
+func (fsm *FileStateMgr) SavePreParams(preParams []PreParams) error {
+	if len(fsm.folder) < 1 {
+		return errors.New("base file path is invalid")
+	}
+	buf, err := json.Marshal(preParams)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the pre-parameters to json: %w", err)
+	}
+	filePathName := filepath.Join(fsm.folder, preParamsFileName)
+	buf, err = fsm.seal(filePathName, buf)
+	if err != nil {
+		return err
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, "preparams-*"+tempFileSuffix, buf)
+}
+
+// RetrievePreParams read the pre-parameters from file
+func (fsm *FileStateMgr) RetrievePreParams() ([]PreParams, error) {
+	if len(fsm.folder) < 1 {
+		return nil, errors.New("base file path is invalid")
+	}
+	filePathName := filepath.Join(fsm.folder, preParamsFileName)
+	fsm.writeLock.RLock()
+	buf, err := ioutil.ReadFile(filePathName)
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	buf, err = fsm.open(filePathName, buf)
+	if err != nil {
+		return nil, err
+	}
+	var preParams []PreParams
+	if err := json.Unmarshal(buf, &preParams); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the pre-parameters: %w", err)
+	}
+	return preParams, nil
+}
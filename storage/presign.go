@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// Presignature is a pair of nonces a signer has committed to before the message is known, so the keysign only
+// needs the round of the partial signatures. The nonces are secret and must never sign two messages
+type Presignature struct {
+	// ID is the same on all the signers of the presignature
+	ID         string `json:"id"`
+	PoolPubKey string `json:"pool_pub_key"`
+	// Signers are the sorted pub keys of the signers the presignature can be used with
+	Signers []string `json:"signers"`
+	// Commitments are the compressed nonce points D and E of each signer keyed by the signer pub key
+	Commitments map[string][][]byte `json:"commitments"`
+	// D and E are the local secret nonces
+	D         []byte    `json:"d"`
+	E         []byte    `json:"e"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (fsm *FileStateMgr) getPresignFilePathName(poolPubKey string) (string, error) {
+	ret, err := conversion.CheckKeyOnCurve(poolPubKey)
+	if err != nil {
+		return "", err
+	}
+	if !ret {
+		return "", errors.New("invalid pubkey for file name")
+	}
+	if len(fsm.folder) < 1 {
+		return "", errors.New("base file path is invalid")
+	}
+	return filepath.Join(fsm.folder, fmt.Sprintf("presign-%s.json", poolPubKey)), nil
+}
+
+// SavePresignatures replace the presignatures of the pool on file, the file is replaced at once so a used
+// presignature never comes back after a crash
+func (fsm *FileStateMgr) SavePresignatures(poolPubKey string, presigs []Presignature) error {
+	filePathName, err := fsm.getPresignFilePathName(poolPubKey)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(presigs)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the presignatures to json: %w", err)
+	}
+	buf, err = fsm.seal(filePathName, buf)
+	if err != nil {
+		return err
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	return replaceFile(filePathName, "presign-*.tmp", buf)
+}
+
+// RetrievePresignatures read the presignatures of the pool from file
+func (fsm *FileStateMgr) RetrievePresignatures(poolPubKey string) ([]Presignature, error) {
+	filePathName, err := fsm.getPresignFilePathName(poolPubKey)
+	if err != nil {
+		return nil, err
+	}
+	fsm.writeLock.RLock()
+	buf, err := ioutil.ReadFile(filePathName)
+	fsm.writeLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	buf, err = fsm.open(filePathName, buf)
+	if err != nil {
+		return nil, err
+	}
+	var presigs []Presignature
+	if err := json.Unmarshal(buf, &presigs); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the presignatures: %w", err)
+	}
+	return presigs, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+// KeyDeleter is the LocalStateManager that can delete the share of a key
+type KeyDeleter interface {
+	DeleteLocalState(pubKey string) error
+}
+
+// DeleteLocalState overwrite the local state file of the key with random bytes before it removes the file, so the
+// share can not be read back from the disk blocks the file used, the presignatures of the key are removed as well
+func (fsm *FileStateMgr) DeleteLocalState(pubKey string) error {
+	filePathName, err := fsm.getFilePathName(pubKey)
+	if err != nil {
+		return err
+	}
+	fsm.writeLock.Lock()
+	defer fsm.writeLock.Unlock()
+	if err := overwriteFile(filePathName); err != nil {
+		return fmt.Errorf("fail to overwrite the local state file: %w", err)
+	}
+	if err := os.Remove(filePathName); err != nil {
+		return fmt.Errorf("fail to remove the local state file: %w", err)
+	}
+	if len(fsm.folder) == 0 {
+		return nil
+	}
+	presignFilePathName, err := fsm.getPresignFilePathName(pubKey)
+	if err != nil {
+		return err
+	}
+	if err := overwriteFile(presignFilePathName); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("fail to overwrite the presignature file: %w", err)
+	}
+	return os.Remove(presignFilePathName)
+}
+
+// overwriteFile write random bytes over the whole content of the file and flush them to the disk
+func overwriteFile(filePathName string) error {
+	f, err := os.OpenFile(filePathName, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+
+	bkeygen "github.com/binance-chain/tss-lib/ecdsa/keygen"
+)
+
+// Zeroize overwrite the buffer with zeros
+func Zeroize(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// zeroizeInt overwrite the words of the int with zeros, the int is 0 afterwards
+func zeroizeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	x.SetInt64(0)
+}
+
+// ZeroizePreParams overwrite the secrets of the pre-parameters with zeros, the public parameters are kept
+func ZeroizePreParams(preParams *bkeygen.LocalPreParams) {
+	if preParams == nil {
+		return
+	}
+	if preParams.PaillierSK != nil {
+		zeroizeInt(preParams.PaillierSK.LambdaN)
+		zeroizeInt(preParams.PaillierSK.PhiN)
+	}
+	zeroizeInt(preParams.Alpha)
+	zeroizeInt(preParams.Beta)
+	zeroizeInt(preParams.P)
+	zeroizeInt(preParams.Q)
+}
+
+func zeroizeSaveData(data *bkeygen.LocalPartySaveData) {
+	ZeroizePreParams(&data.LocalPreParams)
+	zeroizeInt(data.Xi)
+}
+
+// ZeroizeLocalState overwrite the secrets of the share with zeros once we are done with it, the local state is
+// useless afterwards. The ints are shared with the copies of the local state, so it is only called by the owner
+// of the local state, which is the ceremony that read it
+func ZeroizeLocalState(state *KeygenLocalState) {
+	if state == nil {
+		return
+	}
+	zeroizeSaveData(&state.LocalData)
+	for i := range state.ShareData {
+		zeroizeSaveData(&state.ShareData[i])
+	}
+}
+
+// String describe the local state without its share, so the share never ends up in the logs
+func (s KeygenLocalState) String() string {
+	return fmt.Sprintf("KeygenLocalState{PubKey: %s, Algo: %s, Protocol: %s, Participants: %d}", s.PubKey, s.Algo.OrDefault(), s.Protocol.OrDefault(), len(s.ParticipantKeys))
+}
+
+// GoString is the String of the local state for %#v
+func (s KeygenLocalState) GoString() string {
+	return s.String()
+}
+
+// loadLocalState unmarshal the saved local state in the locked memory, the saved bytes are wiped once they are read
+func loadLocalState(pubKey string, buf []byte) (KeygenLocalState, error) {
+	unlock := lockMemory(buf)
+	defer unlock()
+	return unmarshalLocalState(pubKey, buf)
+}
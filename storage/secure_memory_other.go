@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package storage
+
+// lockMemory can not lock the memory on this platform, the returned function wipes the buffer
+func lockMemory(buf []byte) func() {
+	return func() {
+		Zeroize(buf)
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/binance-chain/tss-lib/crypto/paillier"
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+)
+
+type SecureMemoryTestSuite struct{}
+
+var _ = Suite(&SecureMemoryTestSuite{})
+
+func (s *SecureMemoryTestSuite) TestZeroizeLocalState(c *C) {
+	xi := big.NewInt(0).Lsh(big.NewInt(12345), 200)
+	words := xi.Bits()
+	shareID := big.NewInt(7)
+	state := KeygenLocalState{
+		PubKey:    "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData: keygen.NewLocalPartySaveData(3),
+	}
+	state.LocalData.Xi = xi
+	state.LocalData.ShareID = shareID
+	state.LocalData.PaillierSK = &paillier.PrivateKey{LambdaN: big.NewInt(11), PhiN: big.NewInt(13)}
+	state.LocalData.P = big.NewInt(17)
+	state.ShareData = []keygen.LocalPartySaveData{{LocalSecrets: keygen.LocalSecrets{Xi: big.NewInt(19)}}}
+	ZeroizeLocalState(&state)
+	c.Assert(xi.Sign(), Equals, 0)
+	for _, el := range words {
+		c.Assert(el, Equals, big.Word(0))
+	}
+	c.Assert(state.LocalData.PaillierSK.LambdaN.Sign(), Equals, 0)
+	c.Assert(state.LocalData.PaillierSK.PhiN.Sign(), Equals, 0)
+	c.Assert(state.LocalData.P.Sign(), Equals, 0)
+	c.Assert(state.ShareData[0].Xi.Sign(), Equals, 0)
+	// the share id is public
+	c.Assert(shareID.Int64(), Equals, int64(7))
+	ZeroizeLocalState(nil)
+
+	buf := []byte("secret")
+	unlock := lockMemory(buf)
+	unlock()
+	c.Assert(buf, DeepEquals, make([]byte, 6))
+
+	// the local state never prints its share
+	c.Assert(fmt.Sprintf("%+v", state), Not(Matches), ".*LocalData.*")
+	c.Assert(fmt.Sprintf("%#v", state), Matches, "KeygenLocalState.*"+state.PubKey+".*")
+}
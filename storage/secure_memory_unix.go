@@ -0,0 +1,23 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package storage
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// lockMemory keep the buffer out of the swap, the returned function wipes the buffer and unlocks it. Locking is
+// best effort, the limit of the locked memory of the process may refuse it, the buffer is wiped all the same
+func lockMemory(buf []byte) func() {
+	if len(buf) == 0 {
+		return func() {}
+	}
+	locked := unix.Mlock(buf) == nil
+	return func() {
+		Zeroize(buf)
+		if locked {
+			_ = unix.Munlock(buf)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// shareFragmentVersion is the version of the format of the share fragments
+const shareFragmentVersion = 1
+
+// maxShareFragments is the number of the fragments the share can be split into, the x of a fragment is a non zero
+// byte
+const maxShareFragments = 255
+
+// ShareFragment is a fragment of the share of a key, any Threshold of the fragments of the same split rebuild the
+// share, less of them tell nothing about it. Each byte of the share is split with Shamir's secret sharing over
+// GF(2^8)
+type ShareFragment struct {
+	Version int `json:"version"`
+	// SplitID is the same random id for all the fragments of a split, so the fragments of two splits are not mixed
+	SplitID   string `json:"split_id"`
+	PubKey    string `json:"pub_key"`
+	Threshold int    `json:"threshold"`
+	Total     int    `json:"total"`
+	X         byte   `json:"x"`
+	Data      []byte `json:"data"`
+}
+
+// gf256Exp and gf256Log are the tables of the powers and the logarithms of the generator 3 of GF(2^8) with the
+// polynomial x^8 + x^4 + x^3 + x + 1
+var gf256Exp, gf256Log = func() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		log[x] = byte(i)
+		// multiply by 3, which is x * 2 xor x
+		x2 := x << 1
+		if x&0x80 != 0 {
+			x2 ^= 0x1b
+		}
+		x = x2 ^ x
+	}
+	return exp, log
+}()
+
+func gf256Mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf256Exp[(int(gf256Log[a])+int(gf256Log[b]))%255]
+}
+
+func gf256Div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gf256Exp[(int(gf256Log[a])-int(gf256Log[b])+255)%255]
+}
+
+// shamirSplit split the secret into total parts, any threshold of them rebuild it, the part of x is at x-1
+func shamirSplit(secret []byte, threshold, total int) ([][]byte, error) {
+	if threshold < 2 || threshold > total || total > maxShareFragments {
+		return nil, fmt.Errorf("invalid threshold %d of %d fragments", threshold, total)
+	}
+	parts := make([][]byte, total)
+	for i := range parts {
+		parts[i] = make([]byte, len(secret))
+	}
+	coefficients := make([]byte, threshold)
+	for i, el := range secret {
+		coefficients[0] = el
+		if _, err := io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("fail to generate the coefficients: %w", err)
+		}
+		for j := range parts {
+			// evaluate the polynomial at x = j+1 with the Horner's method
+			x := byte(j + 1)
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = gf256Mul(y, x) ^ coefficients[k]
+			}
+			parts[j][i] = y
+		}
+	}
+	return parts, nil
+}
+
+// shamirCombine rebuild the secret of the parts with the Lagrange interpolation at 0
+func shamirCombine(xs []byte, parts [][]byte) []byte {
+	secret := make([]byte, len(parts[0]))
+	for i := range xs {
+		// the Lagrange basis polynomial of xs[i] at 0
+		basis := byte(1)
+		for j := range xs {
+			if i != j {
+				basis = gf256Mul(basis, gf256Div(xs[j], xs[j]^xs[i]))
+			}
+		}
+		for k, el := range parts[i] {
+			secret[k] ^= gf256Mul(el, basis)
+		}
+	}
+	return secret
+}
+
+// SplitShare split the share of the key into total fragments, any threshold of them rebuild it. The fragments are
+// for the offline custodians, they are not encrypted, so each of them has to be kept as secret as the share
+func SplitShare(stateManager LocalStateManager, pubKey string, threshold, total int) ([][]byte, error) {
+	state, err := stateManager.GetLocalState(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get the local state: %w", err)
+	}
+	secret, err := marshalLocalState(state)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(secret)
+	parts, err := shamirSplit(secret, threshold, total)
+	if err != nil {
+		return nil, err
+	}
+	splitID := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, splitID); err != nil {
+		return nil, fmt.Errorf("fail to generate the split id: %w", err)
+	}
+	fragments := make([][]byte, total)
+	for i, el := range parts {
+		fragments[i], err = json.Marshal(ShareFragment{
+			Version:   shareFragmentVersion,
+			SplitID:   hex.EncodeToString(splitID),
+			PubKey:    state.PubKey,
+			Threshold: threshold,
+			Total:     total,
+			X:         byte(i + 1),
+			Data:      el,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fail to marshal the fragment to json: %w", err)
+		}
+	}
+	return fragments, nil
+}
+
+// CombineShare rebuild the share of the fragments, they have to be of the same split and at least its threshold,
+// the checksum of the share tells whether the fragments are intact
+func CombineShare(fragments [][]byte) (KeygenLocalState, error) {
+	if len(fragments) == 0 {
+		return KeygenLocalState{}, errors.New("no fragment")
+	}
+	var first ShareFragment
+	xs := make([]byte, 0, len(fragments))
+	parts := make([][]byte, 0, len(fragments))
+	for i, el := range fragments {
+		var fragment ShareFragment
+		if err := json.Unmarshal(el, &fragment); err != nil {
+			return KeygenLocalState{}, fmt.Errorf("fail to unmarshal the fragment: %w", err)
+		}
+		if fragment.Version != shareFragmentVersion {
+			return KeygenLocalState{}, fmt.Errorf("unknown version %d of the fragment", fragment.Version)
+		}
+		if i == 0 {
+			first = fragment
+		}
+		if fragment.SplitID != first.SplitID || fragment.PubKey != first.PubKey || fragment.Threshold != first.Threshold {
+			return KeygenLocalState{}, errors.New("the fragments are of different splits")
+		}
+		if fragment.X == 0 || bytes.IndexByte(xs, fragment.X) >= 0 {
+			return KeygenLocalState{}, fmt.Errorf("invalid or duplicated fragment %d", fragment.X)
+		}
+		if len(fragment.Data) != len(first.Data) {
+			return KeygenLocalState{}, fmt.Errorf("the fragment %d is truncated", fragment.X)
+		}
+		xs = append(xs, fragment.X)
+		parts = append(parts, fragment.Data)
+	}
+	if len(parts) < first.Threshold {
+		return KeygenLocalState{}, fmt.Errorf("%d fragments are needed, got %d", first.Threshold, len(parts))
+	}
+	secret := shamirCombine(xs, parts)
+	defer Zeroize(secret)
+	return unmarshalLocalState(first.PubKey, secret)
+}
+
+// RestoreShareFragments rebuild the share of the fragments and save it, it refuses to replace the share the node
+// holds, a corrupted share can be replaced
+func RestoreShareFragments(stateManager LocalStateManager, fragments [][]byte) (KeygenLocalState, error) {
+	state, err := CombineShare(fragments)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	if err := restoreLocalState(stateManager, state); err != nil {
+		return KeygenLocalState{}, err
+	}
+	return state, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type ShamirTestSuite struct{}
+
+var _ = Suite(&ShamirTestSuite{})
+
+func (s *ShamirTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *ShamirTestSuite) TestShamir(c *C) {
+	for i := 1; i < 256; i++ {
+		c.Assert(gf256Mul(byte(i), gf256Div(1, byte(i))), Equals, byte(1))
+	}
+	secret := []byte("the share of the key")
+	_, err := shamirSplit(secret, 1, 3)
+	c.Assert(err, NotNil)
+	_, err = shamirSplit(secret, 4, 3)
+	c.Assert(err, NotNil)
+	_, err = shamirSplit(secret, 3, 256)
+	c.Assert(err, NotNil)
+	parts, err := shamirSplit(secret, 3, 5)
+	c.Assert(err, IsNil)
+	c.Assert(parts, HasLen, 5)
+	c.Assert(shamirCombine([]byte{1, 3, 5}, [][]byte{parts[0], parts[2], parts[4]}), DeepEquals, secret)
+	c.Assert(shamirCombine([]byte{4, 2, 3, 1}, [][]byte{parts[3], parts[1], parts[2], parts[0]}), DeepEquals, secret)
+	c.Assert(bytes.Equal(shamirCombine([]byte{1, 2}, [][]byte{parts[0], parts[1]}), secret), Equals, false)
+}
+
+func (s *ShamirTestSuite) TestSplitRestoreShare(c *C) {
+	fsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+		Algo:            conversion.AlgoSecp256k1,
+	}
+	_, err = SplitShare(fsm, stateItem.PubKey, 2, 3)
+	c.Assert(err, NotNil)
+	c.Assert(fsm.SaveLocalState(stateItem), IsNil)
+	fragments, err := SplitShare(fsm, stateItem.PubKey, 2, 3)
+	c.Assert(err, IsNil)
+	c.Assert(fragments, HasLen, 3)
+	var fragment ShareFragment
+	c.Assert(json.Unmarshal(fragments[1], &fragment), IsNil)
+	c.Assert(fragment.PubKey, Equals, stateItem.PubKey)
+	c.Assert(fragment.X, Equals, byte(2))
+
+	_, err = CombineShare(fragments[:1])
+	c.Assert(err, ErrorMatches, "2 fragments are needed.*")
+	_, err = CombineShare([][]byte{fragments[0], fragments[0]})
+	c.Assert(err, ErrorMatches, "invalid or duplicated fragment.*")
+	others, err := SplitShare(fsm, stateItem.PubKey, 2, 3)
+	c.Assert(err, IsNil)
+	_, err = CombineShare([][]byte{fragments[0], others[1]})
+	c.Assert(err, ErrorMatches, "the fragments are of different splits")
+
+	// a corrupted fragment is caught by the checksum of the share
+	fragment.Data[0] ^= 0xff
+	corrupted, err := json.Marshal(fragment)
+	c.Assert(err, IsNil)
+	_, err = CombineShare([][]byte{fragments[0], corrupted})
+	c.Assert(err, NotNil)
+
+	_, err = RestoreShareFragments(fsm, [][]byte{fragments[2], fragments[0]})
+	c.Assert(err, Equals, ErrShareExists)
+	newFsm, err := NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	state, err := RestoreShareFragments(newFsm, [][]byte{fragments[2], fragments[0]})
+	c.Assert(err, IsNil)
+	c.Assert(state.PubKey, Equals, stateItem.PubKey)
+	item, err := newFsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.ParticipantKeys, DeepEquals, stateItem.ParticipantKeys)
+}
@@ -0,0 +1,410 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+// SqliteDriverName is the database/sql driver the sqlite database is opened with, the binary has to link a sqlite
+// driver that registers it, cmd/tss links github.com/mattn/go-sqlite3
+var SqliteDriverName = "sqlite3"
+
+const (
+	sqliteBusyTimeout = 5000
+	addressBookName   = "address_book"
+	reputationName    = "reputation"
+	peerstoreName     = "peerstore"
+	preParamsName     = "preparams"
+)
+
+// sqliteMigrations are the schema migrations, the version of the schema is the user_version of the database, a
+// new migration is only appended, the applied ones never change
+var sqliteMigrations = []string{
+	`CREATE TABLE local_states (
+		pub_key TEXT PRIMARY KEY,
+		value BLOB NOT NULL
+	);
+	CREATE TABLE presignatures (
+		pub_key TEXT PRIMARY KEY,
+		value BLOB NOT NULL
+	);
+	CREATE TABLE sign_records (
+		pub_key TEXT PRIMARY KEY,
+		value BLOB NOT NULL
+	);
+	CREATE TABLE kv (
+		name TEXT PRIMARY KEY,
+		value BLOB NOT NULL
+	);`,
+	`CREATE TABLE audit_records (
+		seq INTEGER PRIMARY KEY,
+		value BLOB NOT NULL
+	);`,
+}
+
+// SqliteStateMgr keeps the local state in a sqlite database, the local states are looked up by the pub key
+// through the index of the table, so a node with many keys does not scan a folder on each request
+type SqliteStateMgr struct {
+	db *sql.DB
+}
+
+// NewSqliteStateMgr open the sqlite database of the path, and migrate its schema to the latest version
+func NewSqliteStateMgr(path string) (*SqliteStateMgr, error) {
+	if len(path) == 0 {
+		return nil, errors.New("sqlite database path is empty")
+	}
+	db, err := sql.Open(SqliteDriverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("fail to open the sqlite database: %w", err)
+	}
+	// the pragmas apply to a connection, so all the requests share the same one, sqlite serializes the writes
+	// anyway
+	db.SetMaxOpenConns(1)
+	ssm, err := NewSqliteStateMgrWithDB(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return ssm, nil
+}
+
+// NewSqliteStateMgrWithDB create a new instance of the SqliteStateMgr which implements LocalStateManager with the
+// opened database, it enables the WAL mode and migrates the schema to the latest version
+func NewSqliteStateMgrWithDB(db *sql.DB) (*SqliteStateMgr, error) {
+	pragmas := []string{
+		"PRAGMA journal_mode=WAL",
+		fmt.Sprintf("PRAGMA busy_timeout=%d", sqliteBusyTimeout),
+		"PRAGMA synchronous=FULL",
+	}
+	for _, el := range pragmas {
+		if _, err := db.Exec(el); err != nil {
+			return nil, fmt.Errorf("fail to set %s: %w", el, err)
+		}
+	}
+	ssm := &SqliteStateMgr{db: db}
+	if err := ssm.migrate(); err != nil {
+		return nil, err
+	}
+	return ssm, nil
+}
+
+// migrate apply the migrations the database has not applied yet, each of them in a transaction
+func (ssm *SqliteStateMgr) migrate() error {
+	var version int
+	if err := ssm.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("fail to get the schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("the schema version %d is newer than the latest one %d", version, len(sqliteMigrations))
+	}
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := ssm.db.Begin()
+		if err != nil {
+			return fmt.Errorf("fail to begin the migration: %w", err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[version]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("fail to migrate the schema to version %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version=%d", version+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("fail to set the schema version %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("fail to commit the migration to version %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+// Close close the database
+func (ssm *SqliteStateMgr) Close() error {
+	return ssm.db.Close()
+}
+
+func checkPubKey(pubKey string) error {
+	ret, err := conversion.CheckKeyOnCurve(pubKey)
+	if err != nil {
+		return err
+	}
+	if !ret {
+		return errors.New("invalid pubkey for the database")
+	}
+	return nil
+}
+
+// put write the value as json to the row of the table
+func (ssm *SqliteStateMgr) put(table, column, key string, value interface{}) error {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the %s to json: %w", table, err)
+	}
+	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (%s, value) VALUES (?, ?)", table, column)
+	if _, err := ssm.db.Exec(query, key, buf); err != nil {
+		return fmt.Errorf("fail to write the %s(%s): %w", table, key, err)
+	}
+	return nil
+}
+
+// get read the json value of the row of the table, the error is os.ErrNotExist if the row does not exist
+func (ssm *SqliteStateMgr) get(table, column, key string, value interface{}) error {
+	var buf []byte
+	query := fmt.Sprintf("SELECT value FROM %s WHERE %s = ?", table, column)
+	err := ssm.db.QueryRow(query, key).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &os.PathError{Op: "read", Path: table + "/" + key, Err: os.ErrNotExist}
+	}
+	if err != nil {
+		return fmt.Errorf("fail to read the %s(%s): %w", table, key, err)
+	}
+	if err := json.Unmarshal(buf, value); err != nil {
+		return fmt.Errorf("fail to unmarshal the %s(%s): %w", table, key, err)
+	}
+	return nil
+}
+
+// SaveLocalState save the local state to the database
+func (ssm *SqliteStateMgr) SaveLocalState(state KeygenLocalState) error {
+	if err := checkPubKey(state.PubKey); err != nil {
+		return err
+	}
+	stored, err := newStoredLocalState(state)
+	if err != nil {
+		return err
+	}
+	defer Zeroize(stored.State)
+	return ssm.put("local_states", "pub_key", state.PubKey, stored)
+}
+
+// GetLocalState read the local state of the pub key from the database
+func (ssm *SqliteStateMgr) GetLocalState(pubKey string) (KeygenLocalState, error) {
+	buf, err := ssm.getStoredLocalState(pubKey)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	return loadLocalState(pubKey, buf)
+}
+
+func (ssm *SqliteStateMgr) getStoredLocalState(pubKey string) ([]byte, error) {
+	if len(pubKey) == 0 {
+		return nil, errors.New("pub key is empty")
+	}
+	if err := checkPubKey(pubKey); err != nil {
+		return nil, err
+	}
+	var buf json.RawMessage
+	if err := ssm.get("local_states", "pub_key", pubKey, &buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ListLocalStates return the pub keys of the local states in the database
+func (ssm *SqliteStateMgr) ListLocalStates() ([]string, error) {
+	rows, err := ssm.db.Query("SELECT pub_key FROM local_states")
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	defer rows.Close()
+	var pubKeys []string
+	for rows.Next() {
+		var pubKey string
+		if err := rows.Scan(&pubKey); err != nil {
+			return nil, fmt.Errorf("fail to scan the pub key: %w", err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, rows.Err()
+}
+
+// DeleteLocalState delete the local state and the presignatures of the key in a transaction
+func (ssm *SqliteStateMgr) DeleteLocalState(pubKey string) error {
+	if err := checkPubKey(pubKey); err != nil {
+		return err
+	}
+	tx, err := ssm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("fail to begin the transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	result, err := tx.Exec("DELETE FROM local_states WHERE pub_key = ?", pubKey)
+	if err != nil {
+		return fmt.Errorf("fail to delete the local state: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("fail to delete the local state: %w", err)
+	}
+	if count == 0 {
+		return &os.PathError{Op: "delete", Path: "local_states/" + pubKey, Err: os.ErrNotExist}
+	}
+	if _, err := tx.Exec("DELETE FROM presignatures WHERE pub_key = ?", pubKey); err != nil {
+		return fmt.Errorf("fail to delete the presignatures: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("fail to commit the deletion: %w", err)
+	}
+	return nil
+}
+
+func (ssm *SqliteStateMgr) SaveAddressBook(address map[peer.ID]p2p.AddrList) error {
+	var records []string
+	for peer, addrs := range address {
+		for _, addr := range addrs {
+			// we do not save the loopback addr
+			if strings.Contains(addr.String(), "127.0.0.1") {
+				continue
+			}
+			records = append(records, addr.String()+"/p2p/"+peer.String())
+		}
+	}
+	return ssm.put("kv", "name", addressBookName, records)
+}
+
+func (ssm *SqliteStateMgr) RetrieveP2PAddresses() (p2p.AddrList, error) {
+	var records []string
+	if err := ssm.get("kv", "name", addressBookName, &records); err != nil {
+		return nil, err
+	}
+	var peerAddresses []p2p.Multiaddr
+	for _, el := range records {
+		addr, err := maddr.NewMultiaddr(el)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address in address book %w", err)
+		}
+		peerAddresses = append(peerAddresses, addr)
+	}
+	return peerAddresses, nil
+}
+
+// SaveReputation save the reputation of the peers to the database
+func (ssm *SqliteStateMgr) SaveReputation(reputation map[peer.ID]p2p.PeerReputation) error {
+	return ssm.put("kv", "name", reputationName, reputation)
+}
+
+// RetrieveReputation read the reputation of the peers from the database
+func (ssm *SqliteStateMgr) RetrieveReputation() (map[peer.ID]p2p.PeerReputation, error) {
+	var reputation map[peer.ID]p2p.PeerReputation
+	if err := ssm.get("kv", "name", reputationName, &reputation); err != nil {
+		return nil, err
+	}
+	return reputation, nil
+}
+
+// SavePeerRecords save the persisted peerstore to the database
+func (ssm *SqliteStateMgr) SavePeerRecords(records map[peer.ID]p2p.PeerRecord) error {
+	return ssm.put("kv", "name", peerstoreName, records)
+}
+
+// RetrievePeerRecords read the persisted peerstore from the database
+func (ssm *SqliteStateMgr) RetrievePeerRecords() (map[peer.ID]p2p.PeerRecord, error) {
+	var records map[peer.ID]p2p.PeerRecord
+	if err := ssm.get("kv", "name", peerstoreName, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SavePresignatures replace the presignatures of the pool in the database
+func (ssm *SqliteStateMgr) SavePresignatures(poolPubKey string, presigs []Presignature) error {
+	if err := checkPubKey(poolPubKey); err != nil {
+		return err
+	}
+	return ssm.put("presignatures", "pub_key", poolPubKey, presigs)
+}
+
+// RetrievePresignatures read the presignatures of the pool from the database
+func (ssm *SqliteStateMgr) RetrievePresignatures(poolPubKey string) ([]Presignature, error) {
+	if err := checkPubKey(poolPubKey); err != nil {
+		return nil, err
+	}
+	var presigs []Presignature
+	if err := ssm.get("presignatures", "pub_key", poolPubKey, &presigs); err != nil {
+		return nil, err
+	}
+	return presigs, nil
+}
+
+// SaveSignRecords replace the sign records of the pool in the database
+func (ssm *SqliteStateMgr) SaveSignRecords(poolPubKey string, records []SignRecord) error {
+	if err := checkPubKey(poolPubKey); err != nil {
+		return err
+	}
+	return ssm.put("sign_records", "pub_key", poolPubKey, records)
+}
+
+// RetrieveSignRecords read the sign records of the pool from the database
+func (ssm *SqliteStateMgr) RetrieveSignRecords(poolPubKey string) ([]SignRecord, error) {
+	if err := checkPubKey(poolPubKey); err != nil {
+		return nil, err
+	}
+	var records []SignRecord
+	if err := ssm.get("sign_records", "pub_key", poolPubKey, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SavePreParams replace the pre-parameters in the database
+func (ssm *SqliteStateMgr) SavePreParams(preParams []PreParams) error {
+	return ssm.put("kv", "name", preParamsName, preParams)
+}
+
+// RetrievePreParams read the pre-parameters from the database
+func (ssm *SqliteStateMgr) RetrievePreParams() ([]PreParams, error) {
+	var preParams []PreParams
+	if err := ssm.get("kv", "name", preParamsName, &preParams); err != nil {
+		return nil, err
+	}
+	return preParams, nil
+}
+
+// AppendAuditRecord append the record to the audit log in the database, the rows are only ever inserted, a record of
+// the same seq is refused
+func (ssm *SqliteStateMgr) AppendAuditRecord(record AuditRecord) error {
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the audit record to json: %w", err)
+	}
+	if _, err := ssm.db.Exec("INSERT INTO audit_records (seq, value) VALUES (?, ?)", record.Seq, buf); err != nil {
+		return fmt.Errorf("fail to write the audit record(%d): %w", record.Seq, err)
+	}
+	return nil
+}
+
+// RetrieveAuditRecords read the records of the audit log from the database in the order of their seq
+func (ssm *SqliteStateMgr) RetrieveAuditRecords() ([]AuditRecord, error) {
+	rows, err := ssm.db.Query("SELECT value FROM audit_records ORDER BY seq")
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the audit records: %w", err)
+	}
+	defer rows.Close()
+	var records []AuditRecord
+	for rows.Next() {
+		var buf []byte
+		if err := rows.Scan(&buf); err != nil {
+			return nil, fmt.Errorf("fail to read the audit record %d: %w", len(records)+1, err)
+		}
+		var record AuditRecord
+		if err := json.Unmarshal(buf, &record); err != nil {
+			return nil, fmt.Errorf("fail to unmarshal the audit record %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fail to read the audit records: %w", err)
+	}
+	return records, nil
+}
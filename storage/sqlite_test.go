@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	_ "github.com/mattn/go-sqlite3"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+)
+
+type SqliteStateMgrTestSuite struct{}
+
+var _ = Suite(&SqliteStateMgrTestSuite{})
+
+func (s *SqliteStateMgrTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *SqliteStateMgrTestSuite) TestSqliteStateMgr(c *C) {
+	_, err := NewSqliteStateMgr("")
+	c.Assert(err, NotNil)
+	path := filepath.Join(c.MkDir(), "state.db")
+	ssm, err := NewSqliteStateMgr(path)
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	_, err = ssm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(ssm.SaveLocalState(KeygenLocalState{PubKey: "whatever"}), NotNil)
+	c.Assert(ssm.SaveLocalState(stateItem), IsNil)
+	c.Assert(ssm.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1"}}), IsNil)
+	c.Assert(ssm.SaveSignRecords(stateItem.PubKey, []SignRecord{{Messages: []string{"aa"}}}), IsNil)
+	c.Assert(ssm.SavePreParams([]PreParams{{}}), IsNil)
+	c.Assert(ssm.Close(), IsNil)
+
+	// the migrated database opens again with the state
+	ssm, err = NewSqliteStateMgr(path)
+	c.Assert(err, IsNil)
+	defer ssm.Close()
+	var version int
+	c.Assert(ssm.db.QueryRow("PRAGMA user_version").Scan(&version), IsNil)
+	c.Assert(version, Equals, len(sqliteMigrations))
+	var journalMode string
+	c.Assert(ssm.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode), IsNil)
+	c.Assert(journalMode, Equals, "wal")
+	item, err := ssm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.ParticipantKeys, DeepEquals, stateItem.ParticipantKeys)
+	presigs, err := ssm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(presigs, HasLen, 1)
+	records, err := ssm.RetrieveSignRecords(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	preParams, err := ssm.RetrievePreParams()
+	c.Assert(err, IsNil)
+	c.Assert(preParams, HasLen, 1)
+
+	c.Assert(ssm.DeleteLocalState(stateItem.PubKey), IsNil)
+	_, err = ssm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = ssm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(os.IsNotExist(ssm.DeleteLocalState(stateItem.PubKey)), Equals, true)
+}
+
+func (s *SqliteStateMgrTestSuite) TestNewerSchema(c *C) {
+	path := filepath.Join(c.MkDir(), "state.db")
+	ssm, err := NewSqliteStateMgr(path)
+	c.Assert(err, IsNil)
+	_, err = ssm.db.Exec("PRAGMA user_version=100")
+	c.Assert(err, IsNil)
+	c.Assert(ssm.Close(), IsNil)
+	_, err = NewSqliteStateMgr(path)
+	c.Assert(err, ErrorMatches, "the schema version 100 is newer.*")
+}
@@ -0,0 +1,511 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+const (
+	vaultNamespaceHeader = "X-Vault-Namespace"
+	vaultTokenHeader     = "X-Vault-Token"
+	defaultVaultMount    = "secret"
+	defaultVaultPath     = "go-tss"
+	defaultVaultTimeout  = time.Second * 10
+	defaultVaultAttempts = 3
+)
+
+// VaultConfig is the configuration of the HashiCorp Vault KV version 2 engine the state is kept in
+type VaultConfig struct {
+	// Address is the url of the vault server, like https://vault:8200
+	Address string
+	// Token authenticates the requests, the AppRole login is used if it is empty
+	Token    string
+	RoleID   string
+	SecretID string
+	// Namespace is the vault enterprise namespace of the engine, empty is the root namespace
+	Namespace string
+	// Mount is the path the KV engine is mounted at, secret if it is empty
+	Mount string
+	// Path is the path under the mount all the secrets of the node are kept under, go-tss if it is empty
+	Path string
+	// Timeout is how long a request to vault can take, 10s if it is 0
+	Timeout time.Duration
+	// MaxAttempts is how many times a request is sent on the network errors and the server errors, 3 if it is 0
+	MaxAttempts int
+}
+
+// VaultStateMgr keeps the local state in vault, so the keyshares never touch the local disk
+type VaultStateMgr struct {
+	logger     zerolog.Logger
+	conf       VaultConfig
+	client     *http.Client
+	retryDelay time.Duration
+	tokenLock  *sync.RWMutex
+	token      string
+}
+
+// NewVaultStateMgr create a new instance of the VaultStateMgr which implements LocalStateManager, it logs in with
+// the AppRole if the config has no token
+func NewVaultStateMgr(conf VaultConfig) (*VaultStateMgr, error) {
+	if len(conf.Address) == 0 {
+		return nil, errors.New("vault address is empty")
+	}
+	if len(conf.Token) == 0 && (len(conf.RoleID) == 0 || len(conf.SecretID) == 0) {
+		return nil, errors.New("vault token or approle role id and secret id are needed")
+	}
+	conf.Address = strings.TrimRight(conf.Address, "/")
+	if len(conf.Mount) == 0 {
+		conf.Mount = defaultVaultMount
+	}
+	if len(conf.Path) == 0 {
+		conf.Path = defaultVaultPath
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = defaultVaultTimeout
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = defaultVaultAttempts
+	}
+	vsm := &VaultStateMgr{
+		logger:     log.With().Str("module", "vault_state_mgr").Logger(),
+		conf:       conf,
+		client:     &http.Client{Timeout: conf.Timeout},
+		retryDelay: time.Second,
+		tokenLock:  &sync.RWMutex{},
+		token:      conf.Token,
+	}
+	if len(vsm.token) == 0 {
+		if err := vsm.login(); err != nil {
+			return nil, err
+		}
+	}
+	return vsm, nil
+}
+
+// login get a new token with the AppRole
+func (vsm *VaultStateMgr) login() error {
+	body, err := json.Marshal(map[string]string{
+		"role_id":   vsm.conf.RoleID,
+		"secret_id": vsm.conf.SecretID,
+	})
+	if err != nil {
+		return fmt.Errorf("fail to marshal the approle login: %w", err)
+	}
+	status, buf, err := vsm.send(http.MethodPost, "/v1/auth/approle/login", body, false)
+	if err != nil {
+		return fmt.Errorf("fail to login with the approle: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("fail to login with the approle, unexpected status code: %d", status)
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(buf, &resp); err != nil {
+		return fmt.Errorf("fail to unmarshal the approle login: %w", err)
+	}
+	if len(resp.Auth.ClientToken) == 0 {
+		return errors.New("the approle login has no token")
+	}
+	vsm.tokenLock.Lock()
+	vsm.token = resp.Auth.ClientToken
+	vsm.tokenLock.Unlock()
+	return nil
+}
+
+// do send the request to vault, it retries on the network errors and the server errors, and logs in again with
+// the AppRole once if the token is refused
+func (vsm *VaultStateMgr) do(method, path string, body []byte) (int, []byte, error) {
+	delay := vsm.retryDelay
+	relogin := len(vsm.conf.Token) == 0
+	for attempt := 1; ; attempt++ {
+		status, buf, err := vsm.send(method, path, body, true)
+		if err == nil && status == http.StatusForbidden && relogin {
+			relogin = false
+			if err := vsm.login(); err != nil {
+				return 0, nil, err
+			}
+			attempt--
+			continue
+		}
+		retry := err != nil || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+		if !retry || attempt >= vsm.conf.MaxAttempts {
+			return status, buf, err
+		}
+		vsm.logger.Warn().Err(err).Int("status", status).Msgf("fail to %s %s, retry in %s", method, path, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// send the request once
+func (vsm *VaultStateMgr) send(method, path string, body []byte, auth bool) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, vsm.conf.Address+path, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("fail to create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(vsm.conf.Namespace) > 0 {
+		req.Header.Set(vaultNamespaceHeader, vsm.conf.Namespace)
+	}
+	if auth {
+		vsm.tokenLock.RLock()
+		req.Header.Set(vaultTokenHeader, vsm.token)
+		vsm.tokenLock.RUnlock()
+	}
+	resp, err := vsm.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("fail to send the request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			vsm.logger.Error().Err(err).Msg("fail to close the response body")
+		}
+	}()
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("fail to read the response body: %w", err)
+	}
+	return resp.StatusCode, buf, nil
+}
+
+func (vsm *VaultStateMgr) secretPath(kind, name string) string {
+	return fmt.Sprintf("%s/%s/%s", vsm.conf.Path, kind, name)
+}
+
+// put write the value as json to the secret
+func (vsm *VaultStateMgr) put(secretPath string, value interface{}) error {
+	return vsm.write(secretPath, value, nil)
+}
+
+// putNew write the value as json to the secret only if the secret does not exist yet, with the check-and-set of
+// the KV engine
+func (vsm *VaultStateMgr) putNew(secretPath string, value interface{}) error {
+	return vsm.write(secretPath, value, map[string]interface{}{"cas": 0})
+}
+
+func (vsm *VaultStateMgr) write(secretPath string, value interface{}, options map[string]interface{}) error {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the secret to json: %w", err)
+	}
+	req := map[string]interface{}{
+		"data": map[string]string{"value": string(buf)},
+	}
+	if options != nil {
+		req["options"] = options
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the secret to json: %w", err)
+	}
+	status, _, err := vsm.do(http.MethodPost, fmt.Sprintf("/v1/%s/data/%s", vsm.conf.Mount, secretPath), body)
+	if err != nil {
+		return fmt.Errorf("fail to write the secret(%s): %w", secretPath, err)
+	}
+	if status != http.StatusOK && status != http.StatusNoContent {
+		return fmt.Errorf("fail to write the secret(%s), unexpected status code: %d", secretPath, status)
+	}
+	return nil
+}
+
+// get read the json value of the secret, the error is os.ErrNotExist if the secret does not exist
+func (vsm *VaultStateMgr) get(secretPath string, value interface{}) error {
+	status, buf, err := vsm.do(http.MethodGet, fmt.Sprintf("/v1/%s/data/%s", vsm.conf.Mount, secretPath), nil)
+	if err != nil {
+		return fmt.Errorf("fail to read the secret(%s): %w", secretPath, err)
+	}
+	if status == http.StatusNotFound {
+		return &os.PathError{Op: "read", Path: secretPath, Err: os.ErrNotExist}
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("fail to read the secret(%s), unexpected status code: %d", secretPath, status)
+	}
+	var resp struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf, &resp); err != nil {
+		return fmt.Errorf("fail to unmarshal the secret(%s): %w", secretPath, err)
+	}
+	if err := json.Unmarshal([]byte(resp.Data.Data["value"]), value); err != nil {
+		return fmt.Errorf("fail to unmarshal the secret(%s): %w", secretPath, err)
+	}
+	return nil
+}
+
+// destroy delete all the versions of the secret
+func (vsm *VaultStateMgr) destroy(secretPath string) error {
+	status, _, err := vsm.do(http.MethodDelete, fmt.Sprintf("/v1/%s/metadata/%s", vsm.conf.Mount, secretPath), nil)
+	if err != nil {
+		return fmt.Errorf("fail to delete the secret(%s): %w", secretPath, err)
+	}
+	if status != http.StatusNoContent && status != http.StatusOK && status != http.StatusNotFound {
+		return fmt.Errorf("fail to delete the secret(%s), unexpected status code: %d", secretPath, status)
+	}
+	return nil
+}
+
+// keyPath return the path of the secret of the pool key
+func (vsm *VaultStateMgr) keyPath(kind, pubKey string) (string, error) {
+	ret, err := conversion.CheckKeyOnCurve(pubKey)
+	if err != nil {
+		return "", err
+	}
+	if !ret {
+		return "", errors.New("invalid pubkey for secret path")
+	}
+	return vsm.secretPath(kind, pubKey), nil
+}
+
+// SaveLocalState save the local state to vault
+func (vsm *VaultStateMgr) SaveLocalState(state KeygenLocalState) error {
+	secretPath, err := vsm.keyPath("localstate", state.PubKey)
+	if err != nil {
+		return err
+	}
+	stored, err := newStoredLocalState(state)
+	if err != nil {
+		return err
+	}
+	defer Zeroize(stored.State)
+	return vsm.put(secretPath, stored)
+}
+
+// GetLocalState read the local state from vault
+func (vsm *VaultStateMgr) GetLocalState(pubKey string) (KeygenLocalState, error) {
+	buf, err := vsm.getStoredLocalState(pubKey)
+	if err != nil {
+		return KeygenLocalState{}, err
+	}
+	return loadLocalState(pubKey, buf)
+}
+
+func (vsm *VaultStateMgr) getStoredLocalState(pubKey string) ([]byte, error) {
+	if len(pubKey) == 0 {
+		return nil, errors.New("pub key is empty")
+	}
+	secretPath, err := vsm.keyPath("localstate", pubKey)
+	if err != nil {
+		return nil, err
+	}
+	var buf json.RawMessage
+	if err := vsm.get(secretPath, &buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ListLocalStates return the pub keys of the local states in vault
+func (vsm *VaultStateMgr) ListLocalStates() ([]string, error) {
+	keys, err := vsm.list("localstate")
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	return keys, nil
+}
+
+// list return the names of the secrets of the kind
+func (vsm *VaultStateMgr) list(kind string) ([]string, error) {
+	metadataPath := fmt.Sprintf("/v1/%s/metadata/%s", vsm.conf.Mount, vsm.secretPath(kind, ""))
+	status, buf, err := vsm.do("LIST", metadataPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", status)
+	}
+	var resp struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf, &resp); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the secrets: %w", err)
+	}
+	return resp.Data.Keys, nil
+}
+
+// DeleteLocalState delete all the versions of the local state and the presignatures of the key
+func (vsm *VaultStateMgr) DeleteLocalState(pubKey string) error {
+	secretPath, err := vsm.keyPath("localstate", pubKey)
+	if err != nil {
+		return err
+	}
+	if err := vsm.get(secretPath, &json.RawMessage{}); err != nil {
+		return err
+	}
+	if err := vsm.destroy(secretPath); err != nil {
+		return err
+	}
+	return vsm.destroy(vsm.secretPath("presign", pubKey))
+}
+
+func (vsm *VaultStateMgr) SaveAddressBook(address map[peer.ID]p2p.AddrList) error {
+	var records []string
+	for peer, addrs := range address {
+		for _, addr := range addrs {
+			// we do not save the loopback addr
+			if strings.Contains(addr.String(), "127.0.0.1") {
+				continue
+			}
+			records = append(records, addr.String()+"/p2p/"+peer.String())
+		}
+	}
+	return vsm.put(vsm.secretPath("p2p", "address_book"), records)
+}
+
+func (vsm *VaultStateMgr) RetrieveP2PAddresses() (p2p.AddrList, error) {
+	var records []string
+	if err := vsm.get(vsm.secretPath("p2p", "address_book"), &records); err != nil {
+		return nil, err
+	}
+	var peerAddresses []p2p.Multiaddr
+	for _, el := range records {
+		addr, err := maddr.NewMultiaddr(el)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address in address book %w", err)
+		}
+		peerAddresses = append(peerAddresses, addr)
+	}
+	return peerAddresses, nil
+}
+
+// SaveReputation save the reputation of the peers to vault
+func (vsm *VaultStateMgr) SaveReputation(reputation map[peer.ID]p2p.PeerReputation) error {
+	return vsm.put(vsm.secretPath("p2p", "reputation"), reputation)
+}
+
+// RetrieveReputation read the reputation of the peers from vault
+func (vsm *VaultStateMgr) RetrieveReputation() (map[peer.ID]p2p.PeerReputation, error) {
+	var reputation map[peer.ID]p2p.PeerReputation
+	if err := vsm.get(vsm.secretPath("p2p", "reputation"), &reputation); err != nil {
+		return nil, err
+	}
+	return reputation, nil
+}
+
+// SavePeerRecords save the persisted peerstore to vault
+func (vsm *VaultStateMgr) SavePeerRecords(records map[peer.ID]p2p.PeerRecord) error {
+	return vsm.put(vsm.secretPath("p2p", "peerstore"), records)
+}
+
+// RetrievePeerRecords read the persisted peerstore from vault
+func (vsm *VaultStateMgr) RetrievePeerRecords() (map[peer.ID]p2p.PeerRecord, error) {
+	var records map[peer.ID]p2p.PeerRecord
+	if err := vsm.get(vsm.secretPath("p2p", "peerstore"), &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SavePresignatures replace the presignatures of the pool in vault
+func (vsm *VaultStateMgr) SavePresignatures(poolPubKey string, presigs []Presignature) error {
+	secretPath, err := vsm.keyPath("presign", poolPubKey)
+	if err != nil {
+		return err
+	}
+	return vsm.put(secretPath, presigs)
+}
+
+// RetrievePresignatures read the presignatures of the pool from vault
+func (vsm *VaultStateMgr) RetrievePresignatures(poolPubKey string) ([]Presignature, error) {
+	secretPath, err := vsm.keyPath("presign", poolPubKey)
+	if err != nil {
+		return nil, err
+	}
+	var presigs []Presignature
+	if err := vsm.get(secretPath, &presigs); err != nil {
+		return nil, err
+	}
+	return presigs, nil
+}
+
+// SaveSignRecords replace the sign records of the pool in vault
+func (vsm *VaultStateMgr) SaveSignRecords(poolPubKey string, records []SignRecord) error {
+	secretPath, err := vsm.keyPath("ledger", poolPubKey)
+	if err != nil {
+		return err
+	}
+	return vsm.put(secretPath, records)
+}
+
+// RetrieveSignRecords read the sign records of the pool from vault
+func (vsm *VaultStateMgr) RetrieveSignRecords(poolPubKey string) ([]SignRecord, error) {
+	secretPath, err := vsm.keyPath("ledger", poolPubKey)
+	if err != nil {
+		return nil, err
+	}
+	var records []SignRecord
+	if err := vsm.get(secretPath, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SavePreParams replace the pre-parameters in vault
+func (vsm *VaultStateMgr) SavePreParams(preParams []PreParams) error {
+	return vsm.put(vsm.secretPath("keygen", "preparams"), preParams)
+}
+
+// RetrievePreParams read the pre-parameters from vault
+func (vsm *VaultStateMgr) RetrievePreParams() ([]PreParams, error) {
+	var preParams []PreParams
+	if err := vsm.get(vsm.secretPath("keygen", "preparams"), &preParams); err != nil {
+		return nil, err
+	}
+	return preParams, nil
+}
+
+// AppendAuditRecord write the record to its own secret in vault, an existing record is never overwritten
+func (vsm *VaultStateMgr) AppendAuditRecord(record AuditRecord) error {
+	return vsm.putNew(vsm.secretPath("audit", fmt.Sprintf("%020d", record.Seq)), record)
+}
+
+// RetrieveAuditRecords read the records of the audit log from vault in the order of their seq
+func (vsm *VaultStateMgr) RetrieveAuditRecords() ([]AuditRecord, error) {
+	names, err := vsm.list("audit")
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the audit records: %w", err)
+	}
+	records := make([]AuditRecord, 0, len(names))
+	for _, el := range names {
+		var record AuditRecord
+		if err := vsm.get(vsm.secretPath("audit", el), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Seq < records[j].Seq
+	})
+	return records, nil
+}
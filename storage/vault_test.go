@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+// mockVault is a KV version 2 engine mounted at secret with the AppRole auth
+type mockVault struct {
+	lock      sync.Mutex
+	token     string
+	namespace string
+	secrets   map[string]map[string]string
+	failures  int
+	logins    int
+}
+
+func newMockVault() *mockVault {
+	return &mockVault{
+		token:   "root",
+		secrets: make(map[string]map[string]string),
+	}
+}
+
+func (m *mockVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if r.Header.Get(vaultNamespaceHeader) != m.namespace {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if m.failures > 0 {
+		m.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["role_id"] != "role" || req["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.logins++
+		m.token = "approle-token"
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]string{"client_token": m.token},
+		})
+		return
+	}
+	if r.Header.Get(vaultTokenHeader) != m.token {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		switch r.Method {
+		case http.MethodPost:
+			var req struct {
+				Data    map[string]string `json:"data"`
+				Options struct {
+					Cas *int `json:"cas"`
+				} `json:"options"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			// the check-and-set of 0 only writes a secret that does not exist yet
+			if _, ok := m.secrets[path]; ok && req.Options.Cas != nil && *req.Options.Cas == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			m.secrets[path] = req.Data
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]int{"version": 1}})
+		case http.MethodGet:
+			data, ok := m.secrets[path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": data},
+			})
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/") && r.Method == "LIST":
+		prefix := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/")
+		var keys []string
+		for k := range m.secrets {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, strings.TrimPrefix(k, prefix))
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"keys": keys},
+		})
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/") && r.Method == http.MethodDelete:
+		delete(m.secrets, strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+type VaultStateMgrTestSuite struct{}
+
+var _ = Suite(&VaultStateMgrTestSuite{})
+
+func (s *VaultStateMgrTestSuite) SetUpTest(c *C) {
+	conversion.SetupBech32Prefix()
+}
+
+func (s *VaultStateMgrTestSuite) TestNewVaultStateMgr(c *C) {
+	vault := newMockVault()
+	server := httptest.NewServer(vault)
+	defer server.Close()
+	_, err := NewVaultStateMgr(VaultConfig{Token: "root"})
+	c.Assert(err, NotNil)
+	_, err = NewVaultStateMgr(VaultConfig{Address: server.URL, RoleID: "role"})
+	c.Assert(err, NotNil)
+	_, err = NewVaultStateMgr(VaultConfig{Address: server.URL, RoleID: "role", SecretID: "wrong"})
+	c.Assert(err, NotNil)
+	vsm, err := NewVaultStateMgr(VaultConfig{Address: server.URL, RoleID: "role", SecretID: "secret"})
+	c.Assert(err, IsNil)
+	c.Assert(vsm.token, Equals, "approle-token")
+	c.Assert(vault.logins, Equals, 1)
+}
+
+func (s *VaultStateMgrTestSuite) TestSaveLocalState(c *C) {
+	vault := newMockVault()
+	vault.namespace = "ns1"
+	server := httptest.NewServer(vault)
+	defer server.Close()
+	vsm, err := NewVaultStateMgr(VaultConfig{Address: server.URL + "/", Token: "root", Namespace: "ns1"})
+	c.Assert(err, IsNil)
+	stateItem := KeygenLocalState{
+		PubKey:          "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq",
+		LocalData:       keygen.NewLocalPartySaveData(5),
+		ParticipantKeys: []string{"A", "B", "C"},
+		LocalPartyKey:   "A",
+	}
+	_, err = vsm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(vsm.SaveLocalState(KeygenLocalState{PubKey: "invalid"}), NotNil)
+	c.Assert(vsm.SaveLocalState(stateItem), IsNil)
+	c.Assert(vault.secrets, HasLen, 1)
+	c.Assert(vault.secrets["go-tss/localstate/"+stateItem.PubKey], NotNil)
+	item, err := vsm.GetLocalState(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, "A")
+	c.Assert(item.ParticipantKeys, DeepEquals, stateItem.ParticipantKeys)
+	pubKeys, err := vsm.ListLocalStates()
+	c.Assert(err, IsNil)
+	c.Assert(pubKeys, DeepEquals, []string{stateItem.PubKey})
+
+	c.Assert(vsm.SavePresignatures(stateItem.PubKey, []Presignature{{ID: "1", D: []byte("d")}}), IsNil)
+	presigs, err := vsm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(presigs[0].D, DeepEquals, []byte("d"))
+	c.Assert(vsm.DeleteLocalState(stateItem.PubKey), IsNil)
+	_, err = vsm.GetLocalState(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = vsm.RetrievePresignatures(stateItem.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(vsm.DeleteLocalState(stateItem.PubKey), NotNil)
+
+	// the other namespaces do not see the secrets
+	vsm.conf.Namespace = "ns2"
+	_, err = vsm.RetrievePreParams()
+	c.Assert(err, NotNil)
+}
+
+func (s *VaultStateMgrTestSuite) TestAddressBook(c *C) {
+	server := httptest.NewServer(newMockVault())
+	defer server.Close()
+	vsm, err := NewVaultStateMgr(VaultConfig{Address: server.URL, Token: "root"})
+	c.Assert(err, IsNil)
+	_, err = vsm.RetrieveP2PAddresses()
+	c.Assert(os.IsNotExist(err), Equals, true)
+	id, err := peer.Decode("16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+	c.Assert(err, IsNil)
+	addr, err := maddr.NewMultiaddr("/ip4/192.168.3.5/tcp/6668")
+	c.Assert(err, IsNil)
+	loopback, err := maddr.NewMultiaddr("/ip4/127.0.0.1/tcp/6668")
+	c.Assert(err, IsNil)
+	c.Assert(vsm.SaveAddressBook(map[peer.ID]p2p.AddrList{id: {addr, loopback}}), IsNil)
+	addrs, err := vsm.RetrieveP2PAddresses()
+	c.Assert(err, IsNil)
+	c.Assert(addrs, HasLen, 1)
+	c.Assert(addrs[0].String(), Equals, "/ip4/192.168.3.5/tcp/6668/p2p/16Uiu2HAm4TmEzUqy3q3Dv7HvdoSboHk5sFj2FH3npiN5vDbJC6gh")
+
+	c.Assert(vsm.SaveReputation(map[peer.ID]p2p.PeerReputation{id: {Score: 2}}), IsNil)
+	reputation, err := vsm.RetrieveReputation()
+	c.Assert(err, IsNil)
+	c.Assert(reputation[id].Score, Equals, float64(2))
+}
+
+func (s *VaultStateMgrTestSuite) TestRetry(c *C) {
+	vault := newMockVault()
+	server := httptest.NewServer(vault)
+	defer server.Close()
+	vsm, err := NewVaultStateMgr(VaultConfig{Address: server.URL, RoleID: "role", SecretID: "secret", MaxAttempts: 3})
+	c.Assert(err, IsNil)
+	vsm.retryDelay = time.Millisecond
+
+	// the server errors are retried
+	vault.failures = 2
+	c.Assert(vsm.SavePreParams([]PreParams{{CreatedAt: time.Now()}}), IsNil)
+	vault.failures = 3
+	_, err = vsm.RetrievePreParams()
+	c.Assert(err, ErrorMatches, ".*unexpected status code: 503")
+	vault.failures = 0
+
+	// we login again once the token expires
+	vault.token = "rotated"
+	preParams, err := vsm.RetrievePreParams()
+	c.Assert(err, IsNil)
+	c.Assert(preParams, HasLen, 1)
+	c.Assert(vault.logins, Equals, 2)
+
+	// the static tokens are not renewed
+	vsm, err = NewVaultStateMgr(VaultConfig{Address: server.URL, Token: "root"})
+	c.Assert(err, IsNil)
+	_, err = vsm.RetrievePreParams()
+	c.Assert(err, ErrorMatches, ".*unexpected status code: 403")
+}
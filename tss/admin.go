@@ -0,0 +1,187 @@
+package tss
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// ErrCeremonyNotFound is returned if the ceremony to cancel is not running nor queued on this node
+var ErrCeremonyNotFound = errcode.New(errcode.NotFound, "the ceremony is not running on this node")
+
+// PeerInfo is the row of a peer in the peer table of the admin api
+type PeerInfo struct {
+	PeerID    string   `json:"peer_id"`
+	Connected bool     `json:"connected"`
+	Addrs     []string `json:"addrs,omitempty"`
+	// Latency is nil until we ping the peer
+	Latency *p2p.PeerLatency `json:"latency,omitempty"`
+	Score   float64          `json:"score"`
+	Streams int              `json:"streams"`
+	// BannedUntil is set while the operator bans the peer
+	BannedUntil *time.Time `json:"banned_until,omitempty"`
+	// QuarantinedUntil is set while the peer is quarantined for the ceremonies it is blamed in
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty"`
+}
+
+// ceremonyCancels are the cancel funcs of the running and the queued ceremonies by their msg id, a nil one tracks
+// nothing
+type ceremonyCancels struct {
+	lock    *sync.Mutex
+	cancels map[string]*ceremonyCancel
+}
+
+type ceremonyCancel struct {
+	cancel context.CancelFunc
+}
+
+func newCeremonyCancels() *ceremonyCancels {
+	return &ceremonyCancels{
+		lock:    &sync.Mutex{},
+		cancels: make(map[string]*ceremonyCancel),
+	}
+}
+
+// track keep the cancel func of the ceremony, the returned func should be called once the ceremony finished
+func (cc *ceremonyCancels) track(msgID string, cancel context.CancelFunc) func() {
+	if cc == nil {
+		return func() {}
+	}
+	entry := &ceremonyCancel{cancel: cancel}
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	cc.cancels[msgID] = entry
+	return func() {
+		cc.lock.Lock()
+		defer cc.lock.Unlock()
+		// the same request may be sent again once the first one finished
+		if cc.cancels[msgID] == entry {
+			delete(cc.cancels, msgID)
+		}
+	}
+}
+
+// cancel cancel the ceremony, it return false if the ceremony is not tracked
+func (cc *ceremonyCancels) cancel(msgID string) bool {
+	if cc == nil {
+		return false
+	}
+	cc.lock.Lock()
+	entry, ok := cc.cancels[msgID]
+	cc.lock.Unlock()
+	if ok {
+		entry.cancel()
+	}
+	return ok
+}
+
+// cancelAll cancel all the ceremonies
+func (cc *ceremonyCancels) cancelAll() {
+	if cc == nil {
+		return
+	}
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	for _, el := range cc.cancels {
+		el.cancel()
+	}
+}
+
+// GetPeers return the peer table, the connected peers and the peers we have a latency, a reputation, a ban or a
+// quarantine of
+func (t *TssServer) GetPeers() []PeerInfo {
+	h := t.p2pCommunication.GetHost()
+	peers := make(map[peer.ID]*PeerInfo)
+	row := func(pID peer.ID) *PeerInfo {
+		if el, ok := peers[pID]; ok {
+			return el
+		}
+		el := &PeerInfo{PeerID: pID.String()}
+		peers[pID] = el
+		return el
+	}
+	for _, pID := range h.Network().Peers() {
+		el := row(pID)
+		el.Connected = true
+		for _, addr := range h.Peerstore().Addrs(pID) {
+			el.Addrs = append(el.Addrs, addr.String())
+		}
+	}
+	for pID, latency := range t.p2pCommunication.GetPeerLatencies() {
+		latency := latency
+		row(pID).Latency = &latency
+	}
+	reputation := t.p2pCommunication.GetReputation()
+	if reputation != nil {
+		for pID, el := range reputation.Scores() {
+			row(pID).Score = el.Score
+		}
+		for pID, until := range reputation.Bans() {
+			until := until
+			row(pID).BannedUntil = &until
+		}
+		for pID, until := range reputation.Quarantined() {
+			until := until
+			row(pID).QuarantinedUntil = &until
+		}
+	}
+	for pID, streams := range t.p2pCommunication.GetPeerStreams() {
+		row(pID).Streams = streams
+	}
+	delete(peers, h.ID())
+	ret := make([]PeerInfo, 0, len(peers))
+	for _, el := range peers {
+		ret = append(ret, *el)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].PeerID < ret[j].PeerID
+	})
+	return ret
+}
+
+// BanPeer refuse the connections of the peer for the duration, the connections to it are closed at once
+func (t *TssServer) BanPeer(peerID string, duration time.Duration) error {
+	pID, err := peer.Decode(peerID)
+	if err != nil {
+		return errcode.Errorf(errcode.BadRequest, "fail to decode the peer id: %w", err)
+	}
+	return t.p2pCommunication.BanPeer(pID, duration)
+}
+
+// UnbanPeer lift the ban or the quarantine on the peer, it return false if the peer is neither banned nor quarantined
+func (t *TssServer) UnbanPeer(peerID string) (bool, error) {
+	pID, err := peer.Decode(peerID)
+	if err != nil {
+		return false, errcode.Errorf(errcode.BadRequest, "fail to decode the peer id: %w", err)
+	}
+	return t.p2pCommunication.UnbanPeer(pID), nil
+}
+
+// GetActiveCeremonies return the ceremonies we take part in and the round they are in
+func (t *TssServer) GetActiveCeremonies() []storage.CeremonyRecord {
+	return t.ceremonyJournal.active()
+}
+
+// GetQueuedKeySigns return the keysigns waiting for their turn
+func (t *TssServer) GetQueuedKeySigns() []QueuedKeySign {
+	if t.keySignQueue == nil {
+		return []QueuedKeySign{}
+	}
+	return t.keySignQueue.snapshot()
+}
+
+// CancelCeremony give up on the running or the queued ceremony, the ceremony fails without blaming anyone
+func (t *TssServer) CancelCeremony(msgID string) error {
+	if !t.ceremonyCancels.cancel(msgID) {
+		return ErrCeremonyNotFound
+	}
+	t.logger.Warn().Str("msg id", msgID).Msg("the operator cancels the ceremony")
+	return nil
+}
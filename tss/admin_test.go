@@ -0,0 +1,89 @@
+package tss
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+)
+
+type AdminTestSuite struct{}
+
+var _ = Suite(&AdminTestSuite{})
+
+func (s *AdminTestSuite) TestCancelCeremony(c *C) {
+	t := &TssServer{logger: log.Logger}
+	// the server without the registry cancels nothing
+	c.Assert(t.CancelCeremony("msg"), Equals, ErrCeremonyNotFound)
+
+	t.ceremonyCancels = newCeremonyCancels()
+	ctx, cancel := context.WithCancel(context.Background())
+	untrack := t.ceremonyCancels.track("msg", cancel)
+	c.Assert(t.CancelCeremony("msg"), IsNil)
+	c.Assert(ctx.Err(), Equals, context.Canceled)
+	untrack()
+	c.Assert(t.CancelCeremony("msg"), Equals, ErrCeremonyNotFound)
+
+	// the ceremony sent again keeps its cancel func once the first one is untracked
+	_, cancel1 := context.WithCancel(context.Background())
+	untrack1 := t.ceremonyCancels.track("msg", cancel1)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	untrack2 := t.ceremonyCancels.track("msg", cancel2)
+	untrack1()
+	c.Assert(t.CancelCeremony("msg"), IsNil)
+	c.Assert(ctx2.Err(), Equals, context.Canceled)
+	untrack2()
+}
+
+func (s *AdminTestSuite) TestQueuedKeySigns(c *C) {
+	t := &TssServer{}
+	c.Assert(t.GetQueuedKeySigns(), HasLen, 0)
+
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	t.keySignQueue = newKeySignQueue(1, 3, false, time.Second)
+	release, err := t.keySignQueue.acquire("running", "pool", 0, stopChan)
+	c.Assert(err, IsNil)
+	defer release()
+	admitted := make(chan string, 2)
+	acquireAsync(t.keySignQueue, "low", 0, admitted, stopChan)
+	waitQueued(c, t.keySignQueue, 1)
+	acquireAsync(t.keySignQueue, "high", 5, admitted, stopChan)
+	waitQueued(c, t.keySignQueue, 2)
+	queued := t.GetQueuedKeySigns()
+	c.Assert(queued, HasLen, 2)
+	c.Assert(queued[0].MsgID, Equals, "high")
+	c.Assert(queued[0].Priority, Equals, 5)
+	c.Assert(queued[1].PoolPubKey, Equals, "low")
+	c.Assert(queued[1].QueuedAt.IsZero(), Equals, false)
+}
+
+func (s *AdminTestSuite) TestActiveCeremonies(c *C) {
+	t := &TssServer{}
+	c.Assert(t.GetActiveCeremonies(), HasLen, 0)
+	t.ceremonyJournal, _ = newCeremonyJournal(nil, log.Logger)
+	t.ceremonyJournal.begin("keygen", "msg-1", "", nil)
+	t.ceremonyJournal.begin("keysign", "msg-2", "pool", nil)
+	t.ceremonyJournal.progress("msg-2", "round2", 4)
+	active := t.GetActiveCeremonies()
+	c.Assert(active, HasLen, 2)
+	c.Assert(active[1].MsgID, Equals, "msg-2")
+	c.Assert(active[1].Round, Equals, "round2")
+	t.ceremonyJournal.end("msg-1")
+	c.Assert(t.GetActiveCeremonies(), HasLen, 1)
+}
+
+func (s *AdminTestSuite) TestDiagnostics(c *C) {
+	t := &TssServer{}
+	d := t.GetDiagnostics()
+	c.Assert(d.Goroutines > 0, Equals, true)
+	c.Assert(d.Ceremonies, HasLen, 0)
+	c.Assert(d.QueuedKeySigns, HasLen, 0)
+
+	t.ceremonyJournal, _ = newCeremonyJournal(nil, log.Logger)
+	t.ceremonyJournal.begin("keysign", "msg-1", "pool", nil)
+	d = t.GetDiagnostics()
+	c.Assert(d.Ceremonies, HasLen, 1)
+	c.Assert(d.Ceremonies[0].MsgID, Equals, "msg-1")
+}
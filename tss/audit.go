@@ -0,0 +1,74 @@
+package tss
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// the outcomes of the keysigns in the audit log
+const (
+	auditSuccess = "success"
+	auditFail    = "fail"
+	// auditNotSigned we did not sign, as we were not picked or the signers did it without us
+	auditNotSigned = "not_signed"
+)
+
+// ErrAuditLogDisabled is returned by the export of the audit log if we do not keep one
+var ErrAuditLogDisabled = errcode.New(errcode.NotFound, "the audit log is not enabled")
+
+// auditKeySign append the keysign to the audit log, the keysign is done by then, so a failure to record it is only
+// logged
+func (t *TssServer) auditKeySign(ctx context.Context, req keysign.Request, resp keysign.Response, err error) {
+	if t.auditLog == nil {
+		return
+	}
+	// the log only keeps the hashes of the messages, the messages themselves are the business of the caller
+	msgs := make([]string, len(req.Messages))
+	for i, el := range req.Messages {
+		buf, err := base64.StdEncoding.DecodeString(el)
+		if err != nil {
+			// the request is refused, we hash the message as it came
+			buf = []byte(el)
+		}
+		hash := sha256.Sum256(buf)
+		msgs[i] = hex.EncodeToString(hash[:])
+	}
+	record := storage.AuditRecord{
+		Time:       time.Now().UTC(),
+		PoolPubKey: req.PoolPubKey,
+		Messages:   msgs,
+		Requester:  common.RequesterFromContext(ctx),
+		Parties:    req.SignerPubKeys,
+		Signers:    resp.Signers,
+		Status:     auditNotSigned,
+		ErrorCode:  string(errorCode(resp.Status, resp.Blame, err)),
+	}
+	switch {
+	case err != nil || resp.Status == common.Fail:
+		record.Status = auditFail
+	case resp.Status == common.Success:
+		record.Status = auditSuccess
+	}
+	record, err = t.auditLog.Append(record)
+	if err != nil {
+		t.logger.Error().Err(err).Str("pool pub key", req.PoolPubKey).Msg("fail to append the keysign to the audit log")
+		return
+	}
+	t.logger.Debug().Uint64("seq", record.Seq).Str("pool pub key", req.PoolPubKey).Msg("the keysign is in the audit log")
+}
+
+// ExportAuditLog return the records of the audit log from the seq on, the chain of the log is verified first
+func (t *TssServer) ExportAuditLog(fromSeq uint64) ([]storage.AuditRecord, error) {
+	if t.auditLog == nil {
+		return nil, ErrAuditLogDisabled
+	}
+	return t.auditLog.Export(fromSeq)
+}
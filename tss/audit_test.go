@@ -0,0 +1,62 @@
+package tss
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keysign"
+)
+
+type AuditTestSuite struct{}
+
+var _ = Suite(&AuditTestSuite{})
+
+func (s *AuditTestSuite) TestAuditKeySign(c *C) {
+	t := &TssServer{logger: log.Logger}
+	req := keysign.Request{
+		PoolPubKey:    "pool",
+		Messages:      []string{base64.StdEncoding.EncodeToString([]byte{0x00, 0xff})},
+		SignerPubKeys: []string{"A", "B", "C"},
+	}
+	// nothing is recorded without the audit log
+	t.auditKeySign(context.Background(), req, keysign.Response{Status: common.Success}, nil)
+	_, err := t.ExportAuditLog(0)
+	c.Assert(errors.Is(err, ErrAuditLogDisabled), Equals, true)
+
+	t.auditLog = keysign.NewAuditLog(nil)
+	ctx := common.WithRequester(context.Background(), "signer")
+	t.auditKeySign(ctx, req, keysign.Response{Status: common.Success, Signers: []string{"A", "B"}}, nil)
+	t.auditKeySign(ctx, req, keysign.Response{Status: common.Fail, Blame: blame.NewBlame(blame.TssTimeout, nil)}, nil)
+	t.auditKeySign(context.Background(), req, keysign.Response{Status: common.NA}, nil)
+	t.auditKeySign(ctx, keysign.Request{PoolPubKey: "pool", Messages: []string{"not base64"}}, keysign.Response{}, errcode.New(errcode.BadRequest, "bad request"))
+
+	records, err := t.ExportAuditLog(0)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 4)
+	c.Assert(keysign.VerifyAuditChain(records, 0, ""), IsNil)
+	// only the hashes of the messages are kept
+	hash := sha256.Sum256([]byte{0x00, 0xff})
+	c.Assert(records[0].Messages, DeepEquals, []string{hex.EncodeToString(hash[:])})
+	c.Assert(records[0].Requester, Equals, "signer")
+	c.Assert(records[0].Parties, DeepEquals, []string{"A", "B", "C"})
+	c.Assert(records[0].Signers, DeepEquals, []string{"A", "B"})
+	c.Assert(records[0].Status, Equals, auditSuccess)
+	c.Assert(records[0].ErrorCode, Equals, "")
+	c.Assert(records[1].Status, Equals, auditFail)
+	c.Assert(records[1].ErrorCode, Equals, string(errcode.TssTimeout))
+	c.Assert(records[2].Status, Equals, auditNotSigned)
+	c.Assert(records[2].Requester, Equals, "")
+	c.Assert(records[3].Status, Equals, auditFail)
+	c.Assert(records[3].ErrorCode, Equals, string(errcode.BadRequest))
+	hash = sha256.Sum256([]byte("not base64"))
+	c.Assert(records[3].Messages, DeepEquals, []string{hex.EncodeToString(hash[:])})
+}
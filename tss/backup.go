@@ -0,0 +1,70 @@
+package tss
+
+import (
+	"errors"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+// ExportShare return the archive of the share of the pool key encrypted with the passphrase, the node can be
+// moved to new hardware or backed up offline with it. It is not exposed through the http api
+func (t *TssServer) ExportShare(poolPubKey string, passphrase []byte) ([]byte, error) {
+	if len(poolPubKey) == 0 {
+		return nil, errors.New("empty pool pub key")
+	}
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	buf, err := storage.ExportShare(t.stateManager, poolPubKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	t.logger.Info().Str("pool pub key", poolPubKey).Msg("the share is exported")
+	return buf, nil
+}
+
+// RestoreShare restore the share of the archive with the passphrase, it return the pool pub key of the share, the
+// share the node holds is never replaced
+func (t *TssServer) RestoreShare(archive, passphrase []byte) (string, error) {
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	state, err := storage.RestoreShare(t.stateManager, archive, passphrase)
+	if err != nil {
+		return "", err
+	}
+	t.healthLock.Lock()
+	delete(t.keyHealth, state.PubKey)
+	t.healthLock.Unlock()
+	t.logger.Info().Str("pool pub key", state.PubKey).Msg("the share is restored")
+	return state.PubKey, nil
+}
+
+// SplitShare split the share of the pool key into total fragments for the offline custodians, any threshold of
+// them rebuild the share. It is not exposed through the http api
+func (t *TssServer) SplitShare(poolPubKey string, threshold, total int) ([][]byte, error) {
+	if len(poolPubKey) == 0 {
+		return nil, errors.New("empty pool pub key")
+	}
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	fragments, err := storage.SplitShare(t.stateManager, poolPubKey, threshold, total)
+	if err != nil {
+		return nil, err
+	}
+	t.logger.Info().Str("pool pub key", poolPubKey).Msgf("the share is split into %d of %d fragments", threshold, total)
+	return fragments, nil
+}
+
+// RestoreShareFragments rebuild the share of the fragments and restore it, it return the pool pub key of the share
+func (t *TssServer) RestoreShareFragments(fragments [][]byte) (string, error) {
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	state, err := storage.RestoreShareFragments(t.stateManager, fragments)
+	if err != nil {
+		return "", err
+	}
+	t.healthLock.Lock()
+	delete(t.keyHealth, state.PubKey)
+	t.healthLock.Unlock()
+	t.logger.Info().Str("pool pub key", state.PubKey).Msg("the share is restored of the fragments")
+	return state.PubKey, nil
+}
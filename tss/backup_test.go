@@ -0,0 +1,85 @@
+package tss
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type BackupTestSuite struct{}
+
+var _ = Suite(&BackupTestSuite{})
+
+func (s *BackupTestSuite) TestExportRestoreShare(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+		healthLock:   &sync.RWMutex{},
+		keyHealth:    make(map[string]KeyHealth),
+	}
+	_, err = t.ExportShare("", []byte("passphrase"))
+	c.Assert(err, NotNil)
+	archive, err := t.ExportShare(state.PubKey, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	_, err = t.RestoreShare(archive, []byte("passphrase"))
+	c.Assert(err, Equals, storage.ErrShareExists)
+
+	newStateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	t.stateManager = newStateManager
+	t.keyHealth[state.PubKey] = KeyHealth{PoolPubKey: state.PubKey, Error: "the local state is corrupted"}
+	poolPubKey, err := t.RestoreShare(archive, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(poolPubKey, Equals, state.PubKey)
+	c.Assert(t.GetKeyHealth(), HasLen, 0)
+	item, err := newStateManager.GetLocalState(state.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(item.LocalPartyKey, Equals, state.LocalPartyKey)
+}
+
+func (s *BackupTestSuite) TestSplitRestoreShareFragments(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+		healthLock:   &sync.RWMutex{},
+		keyHealth:    make(map[string]KeyHealth),
+	}
+	_, err = t.SplitShare("", 2, 3)
+	c.Assert(err, NotNil)
+	fragments, err := t.SplitShare(state.PubKey, 2, 3)
+	c.Assert(err, IsNil)
+	c.Assert(fragments, HasLen, 3)
+
+	newStateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	t.stateManager = newStateManager
+	t.keyHealth[state.PubKey] = KeyHealth{PoolPubKey: state.PubKey, Error: "the local state is corrupted"}
+	poolPubKey, err := t.RestoreShareFragments(fragments[1:])
+	c.Assert(err, IsNil)
+	c.Assert(poolPubKey, Equals, state.PubKey)
+	c.Assert(t.GetKeyHealth(), HasLen, 0)
+}
@@ -0,0 +1,128 @@
+package tss
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+// FeatureBlameQuorum the node shares its blame of the failed ceremonies with the participants and reports the nodes
+// a quorum of them blame
+const FeatureBlameQuorum = "blame-quorum"
+
+// blameViews are the views of the failed ceremony the participants share with us
+type blameViews struct {
+	msgID   string
+	channel chan *p2p.Message
+}
+
+// subscribeBlameViews subscribe to the blame views of the ceremony before it runs, so we have the views of the
+// participants that fail it before us. It return nil if the blame quorum is disabled, the views are dropped once
+// the returned func is called
+func (t *TssServer) subscribeBlameViews(msgID string, participants int) (*blameViews, func()) {
+	if t.conf.BlameQuorumTimeout <= 0 {
+		return nil, func() {}
+	}
+	views := &blameViews{
+		msgID:   msgID,
+		channel: make(chan *p2p.Message, participants),
+	}
+	t.p2pCommunication.SetSubscribe(messages.TSSBlameViewMsg, msgID, views.channel)
+	return views, func() {
+		t.p2pCommunication.CancelSubscribe(messages.TSSBlameViewMsg, msgID)
+	}
+}
+
+// confirmBlame share our view of the failed ceremony with the participants, and wait for their views until we have
+// them all or the blame quorum timeout is over. Our view is the verdict, or the signed event that blames no one if
+// we have none. It return nil if the blame quorum is disabled
+func (t *TssServer) confirmBlame(views *blameViews, ceremony, poolPubKey string, verdict *blame.Event, participants []string) *blame.Quorum {
+	if views == nil {
+		return nil
+	}
+	logger := t.logger.With().Str("msg id", views.msgID).Logger()
+	view := verdict
+	if view == nil {
+		ev := blame.NewEvent(ceremony, views.msgID, poolPubKey, t.localNodePubKey, blame.Blame{})
+		if t.privateKey != nil {
+			if err := ev.Sign(t.privateKey); err != nil {
+				logger.Error().Err(err).Msg("fail to sign the blame view")
+			}
+		}
+		view = &ev
+	}
+	collected := []blame.Event{*view}
+	payload, err := json.Marshal(view)
+	if err != nil {
+		logger.Error().Err(err).Msg("fail to marshal the blame view")
+		return nil
+	}
+	// the views are only taken from the participants, each of them is expected once
+	expected := make(map[peer.ID]bool)
+	localPeerID := t.p2pCommunication.GetLocalPeerID()
+	for _, el := range participants {
+		peerID, err := conversion.GetPeerIDFromPubKey(el)
+		if err != nil || peerID.String() == localPeerID {
+			continue
+		}
+		expected[peerID] = true
+	}
+	peers := make([]peer.ID, 0, len(expected))
+	for el := range expected {
+		peers = append(peers, el)
+	}
+	select {
+	case t.p2pCommunication.GetBroadcastChannel() <- &messages.BroadcastMsgChan{
+		WrappedMessage: messages.WrappedMessage{
+			MessageType: messages.TSSBlameViewMsg,
+			MsgID:       views.msgID,
+			Payload:     payload,
+		},
+		PeersID: peers,
+	}:
+	case <-t.stopChan:
+		return nil
+	}
+
+	timer := time.NewTimer(t.conf.BlameQuorumTimeout)
+	defer timer.Stop()
+	for len(expected) > 0 {
+		select {
+		case msg := <-views.channel:
+			if !expected[msg.PeerID] {
+				continue
+			}
+			wrappedMsg, err := messages.UnmarshalWrappedMessage(msg.Payload)
+			if err != nil {
+				logger.Warn().Err(err).Str("peer", msg.PeerID.String()).Msg("fail to unmarshal the blame view message")
+				continue
+			}
+			var ev blame.Event
+			if err := json.Unmarshal(wrappedMsg.Payload, &ev); err != nil {
+				logger.Warn().Err(err).Str("peer", msg.PeerID.String()).Msg("fail to unmarshal the blame view")
+				continue
+			}
+			// the view has to be signed by the peer that sent it
+			if reporter, err := conversion.GetPeerIDFromPubKey(ev.Reporter); err != nil || reporter != msg.PeerID {
+				logger.Warn().Str("peer", msg.PeerID.String()).Msg("the blame view is not reported by the peer that sent it")
+				continue
+			}
+			delete(expected, msg.PeerID)
+			collected = append(collected, ev)
+		case <-timer.C:
+			logger.Warn().Int("missing", len(expected)).Msg("not all the participants shared their blame view in time")
+			expected = nil
+		case <-t.stopChan:
+			expected = nil
+		}
+	}
+	q := blame.AggregateViews(views.msgID, participants, collected)
+	logger.Info().Strs("confirmed", q.Confirmed).Strs("disputed", q.Disputed).Int("views", len(q.Reporters)).Msg("the blame of the participants")
+	return &q
+}
@@ -0,0 +1,132 @@
+package tss
+
+import (
+	"encoding/json"
+	"time"
+
+	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+type BlameQuorumTestSuite struct{}
+
+var _ = Suite(&BlameQuorumTestSuite{})
+
+// subscribeComm is the Communication that keeps the channels of the subscribers
+type subscribeComm struct {
+	broadcastComm
+	subscribers map[string]chan *p2p.Message
+}
+
+func (s *subscribeComm) SetSubscribe(_ messages.THORChainTSSMessageType, msgID string, channel chan *p2p.Message) {
+	s.subscribers[msgID] = channel
+}
+
+func (s *subscribeComm) CancelSubscribe(_ messages.THORChainTSSMessageType, msgID string) {
+	delete(s.subscribers, msgID)
+}
+
+// blameViewNode is a participant that shares its blame view
+type blameViewNode struct {
+	pubKey  string
+	peerID  peer.ID
+	privKey secp256k1.PrivKey
+}
+
+func newBlameViewNode(c *C) blameViewNode {
+	privKey := secp256k1.GenPrivKey()
+	pubKey, err := sdk.MarshalPubKey(sdk.AccPK, &coskey.PubKey{Key: privKey.PubKey().Bytes()})
+	c.Assert(err, IsNil)
+	peerID, err := conversion.GetPeerIDFromPubKey(pubKey)
+	c.Assert(err, IsNil)
+	return blameViewNode{pubKey: pubKey, peerID: peerID, privKey: privKey}
+}
+
+// message return the message of the blame view of the node sent by the given peer
+func (n blameViewNode) message(c *C, msgID string, from peer.ID, blamed ...string) *p2p.Message {
+	var nodes []blame.Node
+	for _, el := range blamed {
+		nodes = append(nodes, blame.NewNode(el, nil, nil))
+	}
+	ev := blame.NewEvent("keysign", msgID, "pool", n.pubKey, blame.NewBlame(blame.TssTimeout, nodes))
+	c.Assert(ev.Sign(n.privKey), IsNil)
+	payload, err := json.Marshal(ev)
+	c.Assert(err, IsNil)
+	buf, err := json.Marshal(messages.WrappedMessage{MessageType: messages.TSSBlameViewMsg, MsgID: msgID, Payload: payload})
+	c.Assert(err, IsNil)
+	return &p2p.Message{PeerID: from, Payload: buf}
+}
+
+func (s *BlameQuorumTestSuite) TestConfirmBlame(c *C) {
+	conversion.SetupBech32Prefix()
+	nodes := []blameViewNode{newBlameViewNode(c), newBlameViewNode(c), newBlameViewNode(c)}
+	participants := []string{nodes[0].pubKey, nodes[1].pubKey, nodes[2].pubKey}
+	comm := &subscribeComm{
+		broadcastComm: broadcastComm{
+			localPeerID:      nodes[0].peerID.String(),
+			broadcastChannel: make(chan *messages.BroadcastMsgChan, 1),
+		},
+		subscribers: make(map[string]chan *p2p.Message),
+	}
+	t := &TssServer{
+		logger:           log.Logger,
+		p2pCommunication: comm,
+		stopChan:         make(chan struct{}),
+		localNodePubKey:  nodes[0].pubKey,
+		privateKey:       nodes[0].privKey,
+		tssMetrics:       newRecordingMetrics(),
+	}
+
+	// the blame quorum is disabled
+	views, unsubscribe := t.subscribeBlameViews("msg", len(participants))
+	c.Assert(views, IsNil)
+	c.Assert(comm.subscribers, HasLen, 0)
+	unsubscribe()
+	c.Assert(t.confirmBlame(views, "keysign", "pool", nil, participants), IsNil)
+
+	t.conf = common.TssConfig{BlameQuorumTimeout: time.Second}
+	views, unsubscribe = t.subscribeBlameViews("msg", len(participants))
+	c.Assert(comm.subscribers["msg"], NotNil)
+	// nodes[1] agrees with us on nodes[2], nodes[2] blames nodes[1] back, the view nodes[2] sends on behalf of
+	// nodes[1] is dropped
+	comm.subscribers["msg"] <- nodes[1].message(c, "msg", nodes[2].peerID, nodes[0].pubKey)
+	comm.subscribers["msg"] <- nodes[1].message(c, "msg", nodes[1].peerID, nodes[2].pubKey)
+	comm.subscribers["msg"] <- nodes[2].message(c, "msg", nodes[2].peerID, nodes[1].pubKey)
+	verdict := t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssTimeout, []blame.Node{blame.NewNode(nodes[2].pubKey, nil, nil)}))
+	q := t.confirmBlame(views, "keysign", "pool", verdict, participants)
+	c.Assert(q, NotNil)
+	c.Assert(q.Reporters, HasLen, 3)
+	c.Assert(q.Confirmed, DeepEquals, []string{nodes[2].pubKey})
+	c.Assert(q.Disputed, DeepEquals, []string{nodes[1].pubKey})
+	msg := <-comm.broadcastChannel
+	c.Assert(msg.WrappedMessage.MessageType, Equals, messages.TSSBlameViewMsg)
+	c.Assert(msg.PeersID, HasLen, 2)
+	var shared blame.Event
+	c.Assert(json.Unmarshal(msg.WrappedMessage.Payload, &shared), IsNil)
+	c.Assert(shared.VerifySignature(), Equals, true)
+	unsubscribe()
+	c.Assert(comm.subscribers, HasLen, 0)
+
+	// we share the view that blames no one if we have no verdict, and give up on the views that do not arrive
+	t.conf.BlameQuorumTimeout = 10 * time.Millisecond
+	views, unsubscribe = t.subscribeBlameViews("msg-2", len(participants))
+	defer unsubscribe()
+	q = t.confirmBlame(views, "keysign", "pool", nil, participants)
+	c.Assert(q.Reporters, DeepEquals, []string{nodes[0].pubKey})
+	c.Assert(q.Confirmed, HasLen, 0)
+	c.Assert(q.Disputed, HasLen, 0)
+	msg = <-comm.broadcastChannel
+	c.Assert(json.Unmarshal(msg.WrappedMessage.Payload, &shared), IsNil)
+	c.Assert(shared.Offenders, HasLen, 0)
+	c.Assert(shared.VerifySignature(), Equals, true)
+}
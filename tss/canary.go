@@ -0,0 +1,104 @@
+package tss
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+)
+
+// CanaryResult is the outcome of the latest canary keysign
+type CanaryResult struct {
+	Time           time.Time     `json:"time"`
+	Latency        time.Duration `json:"latency"`
+	Success        bool          `json:"success"`
+	Error          string        `json:"error,omitempty"`
+	UnhealthyNodes []string      `json:"unhealthy_nodes,omitempty"`
+}
+
+// canaryMessage return the message the canary keysign of the given slot signs, all the nodes derive the
+// same message from the slot so they join the same party without any coordination
+func canaryMessage(poolPubKey string, slot int64) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("go-tss-canary-%s-%d", poolPubKey, slot)))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// canaryScheduler runs the canary keysign at the start of every slot of CanaryInterval
+func (t *TssServer) canaryScheduler() {
+	interval := int64(t.conf.CanaryInterval)
+	for {
+		slot := time.Now().UnixNano()/interval + 1
+		select {
+		case <-t.stopChan:
+			return
+		case <-time.After(time.Until(time.Unix(0, slot*interval))):
+		}
+		t.runCanary(slot)
+	}
+}
+
+// runCanary signs the canary message of the given slot with the dedicated test key, the canary has a low
+// priority, so it gives up the slot if we are busy with a real ceremony
+func (t *TssServer) runCanary(slot int64) {
+	if atomic.LoadInt64(&t.activeCeremonies) > 0 {
+		t.logger.Info().Msgf("skip the canary keysign of slot %d as we are busy with other ceremonies", slot)
+		return
+	}
+	poolPubKey := t.conf.CanaryPoolPubKey
+	localState, err := t.getLocalState(poolPubKey)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to get the local state of the canary key")
+		t.recordCanary(CanaryResult{
+			Time:  time.Now(),
+			Error: err.Error(),
+		}, nil)
+		return
+	}
+
+	req := keysign.NewRequest(poolPubKey, []string{canaryMessage(poolPubKey, slot)}, slot, nil, messages.NEWJOINPARTYVERSION)
+	start := time.Now()
+	resp, err := t.runKeySign(req)
+	result := CanaryResult{
+		Time:    start,
+		Latency: time.Since(start),
+		Success: err == nil && resp.Status == common.Success,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	for _, el := range resp.Blame.BlameNodes {
+		result.UnhealthyNodes = append(result.UnhealthyNodes, el.Pubkey)
+	}
+	if result.Success {
+		t.logger.Info().Msgf("canary keysign of slot %d succeeded in %s", slot, result.Latency)
+	} else {
+		t.logger.Warn().Err(err).Strs("unhealthy nodes", result.UnhealthyNodes).Msgf("canary keysign of slot %d failed", slot)
+	}
+	t.recordCanary(result, localState.ParticipantKeys)
+}
+
+func (t *TssServer) recordCanary(result CanaryResult, participants []string) {
+	t.tssMetrics.UpdateCanary(result.Latency, result.Success)
+	unhealthy := make(map[string]bool, len(result.UnhealthyNodes))
+	for _, el := range result.UnhealthyNodes {
+		unhealthy[el] = true
+	}
+	for _, el := range participants {
+		t.tssMetrics.UpdateCanaryParticipant(el, !unhealthy[el])
+	}
+	t.canaryLock.Lock()
+	defer t.canaryLock.Unlock()
+	t.canaryResult = &result
+}
+
+// GetCanaryResult return the result of the latest canary keysign, nil if the canary has not run yet
+func (t *TssServer) GetCanaryResult() *CanaryResult {
+	t.canaryLock.RLock()
+	defer t.canaryLock.RUnlock()
+	return t.canaryResult
+}
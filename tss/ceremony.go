@@ -0,0 +1,62 @@
+package tss
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// acquireCeremony take a slot for a keygen or keysign, each of them runs its own party with its own
+// subscriptions, so they only share the slots. It waits up to the party timeout for a free slot, the peers
+// would have given up on the party by then
+func (t *TssServer) acquireCeremony() error {
+	if t.draining() {
+		return ErrShuttingDown
+	}
+	if t.ceremonySlots != nil {
+		atomic.AddInt64(&t.waitingCeremonies, 1)
+		defer atomic.AddInt64(&t.waitingCeremonies, -1)
+		select {
+		case t.ceremonySlots <- struct{}{}:
+		case <-time.After(t.conf.PartyTimeout):
+			return errors.New("fail to get a ceremony slot, too many ceremonies are running")
+		case <-t.drainChan:
+			return ErrShuttingDown
+		case <-t.stopChan:
+			return errors.New("received exit signal")
+		}
+	}
+	atomic.AddInt64(&t.activeCeremonies, 1)
+	return nil
+}
+
+// releaseCeremony give back the slot taken by acquireCeremony
+func (t *TssServer) releaseCeremony() {
+	atomic.AddInt64(&t.activeCeremonies, -1)
+	if t.ceremonySlots != nil {
+		<-t.ceremonySlots
+	}
+}
+
+// ceremonyStopChan return the stop channel of a ceremony, it is closed once the context is cancelled or the server
+// stops, the returned func should be called once the ceremony finished
+func (t *TssServer) ceremonyStopChan(ctx context.Context) (chan struct{}, func()) {
+	if ctx.Done() == nil {
+		return t.stopChan, func() {}
+	}
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stopChan)
+		case <-t.stopChan:
+			close(stopChan)
+		case <-done:
+		}
+	}()
+	return stopChan, func() {
+		close(done)
+	}
+}
@@ -0,0 +1,102 @@
+package tss
+
+import (
+	"sync"
+	"time"
+)
+
+// CeremonyEventType is the kind of the ceremony event
+type CeremonyEventType string
+
+const (
+	// CeremonyStarted the local party of the ceremony has joined the party
+	CeremonyStarted CeremonyEventType = "started"
+	// CeremonyProgress the local party of the ceremony has moved on to a new round
+	CeremonyProgress CeremonyEventType = "progress"
+	// CeremonyFinished the ceremony has finished, it may have failed
+	CeremonyFinished CeremonyEventType = "finished"
+)
+
+// CeremonyEvent is emitted when a keygen or a keysign starts, moves on to a new round or finishes
+type CeremonyEvent struct {
+	Type       CeremonyEventType `json:"type"`
+	MsgID      string            `json:"msg_id"`
+	Kind       string            `json:"kind"`
+	PoolPubKey string            `json:"pool_pub_key,omitempty"`
+	Round      string            `json:"round,omitempty"`
+	Received   int               `json:"received,omitempty"`
+	Time       time.Time         `json:"time"`
+}
+
+// ceremonyEventBus fans out the ceremony events to the subscribers, the slow subscribers miss the events instead
+// of blocking the ceremonies
+type ceremonyEventBus struct {
+	lock   *sync.RWMutex
+	subs   map[int]chan CeremonyEvent
+	nextID int
+	closed bool
+}
+
+func newCeremonyEventBus() *ceremonyEventBus {
+	return &ceremonyEventBus{
+		lock: &sync.RWMutex{},
+		subs: make(map[int]chan CeremonyEvent),
+	}
+}
+
+func (eb *ceremonyEventBus) subscribe(bufferSize int) (<-chan CeremonyEvent, func()) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	ch := make(chan CeremonyEvent, bufferSize)
+	if eb.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	id := eb.nextID
+	eb.nextID++
+	eb.subs[id] = ch
+	once := &sync.Once{}
+	return ch, func() {
+		once.Do(func() {
+			eb.lock.Lock()
+			defer eb.lock.Unlock()
+			if _, ok := eb.subs[id]; ok {
+				delete(eb.subs, id)
+				close(ch)
+			}
+		})
+	}
+}
+
+func (eb *ceremonyEventBus) publish(ev CeremonyEvent) {
+	eb.lock.RLock()
+	defer eb.lock.RUnlock()
+	for _, ch := range eb.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// close close the channels of all the subscribers
+func (eb *ceremonyEventBus) close() {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	eb.closed = true
+	for id, ch := range eb.subs {
+		delete(eb.subs, id)
+		close(ch)
+	}
+}
+
+// SubscribeCeremonyEvents return a channel of the ceremony events and the function to cancel the subscription,
+// the events are dropped if the channel is full, the channel is closed once we stop
+func (t *TssServer) SubscribeCeremonyEvents(bufferSize int) (<-chan CeremonyEvent, func()) {
+	if t.ceremonyJournal == nil {
+		ch := make(chan CeremonyEvent)
+		close(ch)
+		return ch, func() {}
+	}
+	return t.ceremonyJournal.events.subscribe(bufferSize)
+}
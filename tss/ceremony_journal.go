@@ -0,0 +1,207 @@
+package tss
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog"
+
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// CeremonyJournalStore persists the journal of the ceremonies we take part in
+type CeremonyJournalStore interface {
+	SaveCeremonies(records []storage.CeremonyRecord) error
+	RetrieveCeremonies() ([]storage.CeremonyRecord, error)
+}
+
+// ceremonyJournal is the write-ahead journal of the keygens and the keysigns we take part in, a nil journal records
+// nothing. The local party of a ceremony can not be resumed once the process restarts, its secrets are gone, so the
+// ceremonies the journal finds at the restart are aborted, the peers are told so they blame us at once instead of
+// waiting for the timeout
+type ceremonyJournal struct {
+	logger  zerolog.Logger
+	lock    *sync.Mutex
+	store   CeremonyJournalStore
+	records map[string]storage.CeremonyRecord
+	events  *ceremonyEventBus
+}
+
+// newCeremonyJournal create the journal, it return the ceremonies the previous run of the node left unfinished,
+// they are kept in the journal until they are aborted
+func newCeremonyJournal(store CeremonyJournalStore, logger zerolog.Logger) (*ceremonyJournal, []storage.CeremonyRecord) {
+	j := &ceremonyJournal{
+		logger:  logger,
+		lock:    &sync.Mutex{},
+		store:   store,
+		records: make(map[string]storage.CeremonyRecord),
+		events:  newCeremonyEventBus(),
+	}
+	if store == nil {
+		return j, nil
+	}
+	interrupted, err := store.RetrieveCeremonies()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error().Err(err).Msg("fail to read the ceremony journal")
+	}
+	for _, el := range interrupted {
+		j.records[el.MsgID] = el
+	}
+	return j, interrupted
+}
+
+// save write the journal, the lock is held by the caller
+func (j *ceremonyJournal) save() {
+	if j.store == nil {
+		return
+	}
+	records := make([]storage.CeremonyRecord, 0, len(j.records))
+	for _, el := range j.records {
+		records = append(records, el)
+	}
+	sort.Slice(records, func(i, k int) bool {
+		return records[i].StartedAt.Before(records[k].StartedAt)
+	})
+	if err := j.store.SaveCeremonies(records); err != nil {
+		j.logger.Error().Err(err).Msg("fail to save the ceremony journal")
+	}
+}
+
+// begin record the ceremony before it starts
+func (j *ceremonyJournal) begin(kind, msgID, poolPubKey string, participants []peer.ID) {
+	if j == nil {
+		return
+	}
+	record := storage.CeremonyRecord{
+		MsgID:        msgID,
+		Kind:         kind,
+		PoolPubKey:   poolPubKey,
+		Participants: make([]string, len(participants)),
+		StartedAt:    time.Now().UTC(),
+	}
+	for i, el := range participants {
+		record.Participants[i] = el.String()
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.records[msgID] = record
+	j.save()
+	j.publish(CeremonyStarted, record)
+}
+
+// progress record the round the local party of the ceremony moved on to
+func (j *ceremonyJournal) progress(msgID, round string, received int) {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	record, ok := j.records[msgID]
+	if !ok {
+		return
+	}
+	record.Round = round
+	record.Received = received
+	j.records[msgID] = record
+	j.save()
+	j.publish(CeremonyProgress, record)
+}
+
+// end remove the ceremony from the journal
+func (j *ceremonyJournal) end(msgID string) {
+	if j == nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	record, ok := j.records[msgID]
+	if !ok {
+		return
+	}
+	delete(j.records, msgID)
+	j.save()
+	j.publish(CeremonyFinished, record)
+}
+
+// active return the ceremonies in the journal in the order they started
+func (j *ceremonyJournal) active() []storage.CeremonyRecord {
+	if j == nil {
+		return nil
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	records := make([]storage.CeremonyRecord, 0, len(j.records))
+	for _, el := range j.records {
+		records = append(records, el)
+	}
+	sort.Slice(records, func(i, k int) bool {
+		return records[i].StartedAt.Before(records[k].StartedAt)
+	})
+	return records
+}
+
+// publish emit the event of the ceremony to the subscribers
+func (j *ceremonyJournal) publish(eventType CeremonyEventType, record storage.CeremonyRecord) {
+	j.events.publish(CeremonyEvent{
+		Type:       eventType,
+		MsgID:      record.MsgID,
+		Kind:       record.Kind,
+		PoolPubKey: record.PoolPubKey,
+		Round:      record.Round,
+		Received:   record.Received,
+		Time:       time.Now().UTC(),
+	})
+}
+
+// abortInterruptedCeremonies tell the peers of the ceremonies the previous run of the node left unfinished that we
+// have restarted, the ceremonies that would have timed out by now are only dropped from the journal
+func (t *TssServer) abortInterruptedCeremonies(interrupted []storage.CeremonyRecord) {
+	payload, err := json.Marshal(messages.TssAbort{Reason: "the node restarted"})
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal the abort message")
+		return
+	}
+	for _, el := range interrupted {
+		timeout := t.conf.KeySignTimeout
+		if el.Kind == "keygen" {
+			timeout = t.conf.KeyGenTimeout
+		}
+		if time.Since(el.StartedAt) < t.conf.PartyTimeout+timeout {
+			peers := t.ceremonyPeers(el)
+			t.logger.Warn().Str("msg id", el.MsgID).Str("round", el.Round).Msgf("the %s is interrupted by the restart, we abort it", el.Kind)
+			select {
+			case t.p2pCommunication.GetBroadcastChannel() <- &messages.BroadcastMsgChan{
+				WrappedMessage: messages.WrappedMessage{
+					MessageType: messages.TSSAbortMsg,
+					MsgID:       el.MsgID,
+					Payload:     payload,
+				},
+				PeersID: peers,
+			}:
+			case <-t.stopChan:
+				return
+			}
+		}
+		t.ceremonyJournal.end(el.MsgID)
+	}
+}
+
+// ceremonyPeers return the participants of the ceremony but us
+func (t *TssServer) ceremonyPeers(record storage.CeremonyRecord) []peer.ID {
+	localPeerID := t.p2pCommunication.GetLocalPeerID()
+	var peers []peer.ID
+	for _, p := range record.Participants {
+		peerID, err := peer.Decode(p)
+		if err != nil || p == localPeerID {
+			continue
+		}
+		peers = append(peers, peerID)
+	}
+	return peers
+}
@@ -0,0 +1,126 @@
+package tss
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type CeremonyJournalTestSuite struct{}
+
+var _ = Suite(&CeremonyJournalTestSuite{})
+
+// broadcastComm is the Communication that only has the broadcast channel
+type broadcastComm struct {
+	Communication
+	localPeerID      string
+	broadcastChannel chan *messages.BroadcastMsgChan
+}
+
+func (b *broadcastComm) GetLocalPeerID() string {
+	return b.localPeerID
+}
+
+func (b *broadcastComm) GetBroadcastChannel() chan *messages.BroadcastMsgChan {
+	return b.broadcastChannel
+}
+
+func (s *CeremonyJournalTestSuite) TestCeremonyJournal(c *C) {
+	conversion.SetupBech32Prefix()
+	var journal *ceremonyJournal
+	journal.begin("keygen", "msg-1", "", nil)
+	journal.end("msg-1")
+
+	peers := make([]peer.ID, 3)
+	for i, el := range []string{
+		"thorpub1addwnpepqtdklw8tf3anjz7nn5fly3uvq2e67w2apn560s4smmrt9e3x52nt2svmmu3",
+		"thorpub1addwnpepqtspqyy6gk22u37ztra4hq3hdakc0w0k60sfy849mlml2vrpfr0wvm6uz09",
+		"thorpub1addwnpepq2ryyje5zr09lq7gqptjwnxqsy2vcdngvwd6z7yt5yjcnyj8c8cn559xe69",
+	} {
+		peerID, err := conversion.GetPeerIDFromPubKey(el)
+		c.Assert(err, IsNil)
+		peers[i] = peerID
+	}
+	store := storage.NewMemoryStateMgr()
+	journal, interrupted := newCeremonyJournal(store, log.Logger)
+	c.Assert(interrupted, HasLen, 0)
+	journal.begin("keygen", "msg-1", "", peers)
+	journal.begin("keysign", "msg-2", "pool", peers)
+	journal.progress("msg-2", messages.KEYSIGN2Unicast, 3)
+	journal.progress("msg-3", messages.KEYSIGN2Unicast, 3)
+	journal.end("msg-1")
+
+	// the ceremonies left in the journal are the interrupted ones
+	journal, interrupted = newCeremonyJournal(store, log.Logger)
+	c.Assert(interrupted, HasLen, 1)
+	c.Assert(interrupted[0].MsgID, Equals, "msg-2")
+	c.Assert(interrupted[0].PoolPubKey, Equals, "pool")
+	c.Assert(interrupted[0].Round, Equals, messages.KEYSIGN2Unicast)
+	c.Assert(interrupted[0].Received, Equals, 3)
+	c.Assert(interrupted[0].Participants, HasLen, 3)
+
+	// the ceremony that would have timed out is dropped, the others are aborted
+	stale := interrupted[0]
+	stale.MsgID = "msg-0"
+	stale.StartedAt = time.Now().Add(-time.Hour)
+	journal.records[stale.MsgID] = stale
+	comm := &broadcastComm{
+		localPeerID:      peers[0].String(),
+		broadcastChannel: make(chan *messages.BroadcastMsgChan, 2),
+	}
+	t := &TssServer{
+		conf:             common.TssConfig{PartyTimeout: time.Minute, KeySignTimeout: time.Minute},
+		logger:           log.Logger,
+		p2pCommunication: comm,
+		stopChan:         make(chan struct{}),
+		ceremonyJournal:  journal,
+	}
+	t.abortInterruptedCeremonies([]storage.CeremonyRecord{stale, interrupted[0]})
+	c.Assert(comm.broadcastChannel, HasLen, 1)
+	msg := <-comm.broadcastChannel
+	c.Assert(msg.WrappedMessage.MessageType, Equals, messages.TSSAbortMsg)
+	c.Assert(msg.WrappedMessage.MsgID, Equals, "msg-2")
+	c.Assert(msg.PeersID, DeepEquals, peers[1:])
+	records, err := store.RetrieveCeremonies()
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
+
+func (s *CeremonyJournalTestSuite) TestCeremonyEvents(c *C) {
+	t := &TssServer{}
+	events, cancel := t.SubscribeCeremonyEvents(10)
+	_, ok := <-events
+	c.Assert(ok, Equals, false)
+	cancel()
+
+	t.ceremonyJournal, _ = newCeremonyJournal(nil, log.Logger)
+	events, cancel = t.SubscribeCeremonyEvents(2)
+	t.ceremonyJournal.begin("keysign", "msg-1", "pool", nil)
+	t.ceremonyJournal.progress("msg-1", messages.KEYSIGN2Unicast, 3)
+	// the subscriber is full, the event is dropped
+	t.ceremonyJournal.end("msg-1")
+	ev := <-events
+	c.Assert(ev.Type, Equals, CeremonyStarted)
+	c.Assert(ev.MsgID, Equals, "msg-1")
+	c.Assert(ev.Kind, Equals, "keysign")
+	c.Assert(ev.PoolPubKey, Equals, "pool")
+	ev = <-events
+	c.Assert(ev.Type, Equals, CeremonyProgress)
+	c.Assert(ev.Round, Equals, messages.KEYSIGN2Unicast)
+	c.Assert(ev.Received, Equals, 3)
+	cancel()
+	_, ok = <-events
+	c.Assert(ok, Equals, false)
+
+	events, _ = t.SubscribeCeremonyEvents(2)
+	t.ceremonyJournal.events.close()
+	_, ok = <-events
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,121 @@
+package tss
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+)
+
+type CeremonyTestSuite struct{}
+
+var _ = Suite(&CeremonyTestSuite{})
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func (s *CeremonyTestSuite) TestCeremonyStopChan(c *C) {
+	t := &TssServer{stopChan: make(chan struct{})}
+	stopChan, release := t.ceremonyStopChan(context.Background())
+	c.Assert(stopChan, Equals, t.stopChan)
+	release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopChan, release = t.ceremonyStopChan(ctx)
+	defer release()
+	c.Assert(stopChan, Not(Equals), t.stopChan)
+	cancel()
+	c.Assert(isClosed(stopChan), Equals, true)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stopChan, release = t.ceremonyStopChan(ctx)
+	defer release()
+	close(t.stopChan)
+	c.Assert(isClosed(stopChan), Equals, true)
+}
+
+// recordingMetrics is the Metrics that records the round latencies, the ceremonies and the blames
+type recordingMetrics struct {
+	Metrics
+	lock       *sync.Mutex
+	rounds     map[string]time.Duration
+	ceremonies map[string]int
+	blames     map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		lock:       &sync.Mutex{},
+		rounds:     make(map[string]time.Duration),
+		ceremonies: make(map[string]int),
+		blames:     make(map[string]int),
+	}
+}
+
+func (m *recordingMetrics) UpdateRoundLatency(ceremony, round string, latency time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.rounds[ceremony+"/"+round] = latency
+}
+
+func (m *recordingMetrics) UpdateCeremony(ceremony, outcome string, duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ceremonies[ceremony+"/"+outcome]++
+}
+
+func (m *recordingMetrics) IncBlame(ceremony, reason, pubKey string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.blames[ceremony+"/"+pubKey]++
+}
+
+func (m *recordingMetrics) roundCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.rounds)
+}
+
+func (s *CeremonyTestSuite) TestCeremonyMetrics(c *C) {
+	metrics := newRecordingMetrics()
+	t := &TssServer{stopChan: make(chan struct{}), tssMetrics: metrics}
+	events := make(chan CeremonyEvent, 10)
+	done := make(chan struct{})
+	go func() {
+		t.watchCeremonyEvents(events, func() {})
+		close(done)
+	}()
+	start := time.Now()
+	events <- CeremonyEvent{Type: CeremonyStarted, MsgID: "msg", Kind: "keysign", Time: start}
+	events <- CeremonyEvent{Type: CeremonyProgress, MsgID: "msg", Kind: "keysign", Round: "round1", Time: start.Add(time.Second)}
+	events <- CeremonyEvent{Type: CeremonyProgress, MsgID: "msg", Kind: "keysign", Round: "round2", Time: start.Add(3 * time.Second)}
+	events <- CeremonyEvent{Type: CeremonyFinished, MsgID: "msg", Kind: "keysign", Round: "round2", Time: start.Add(6 * time.Second)}
+	close(events)
+	<-done
+	c.Assert(metrics.roundCount(), Equals, 2)
+	c.Assert(metrics.rounds["keysign/round1"], Equals, 2*time.Second)
+	c.Assert(metrics.rounds["keysign/round2"], Equals, 3*time.Second)
+
+	t.observeCeremony("keygen", start, common.Success, false)
+	t.observeCeremony("keygen", start, common.Fail, false)
+	t.observeCeremony("keygen", start, common.Fail, true)
+	c.Assert(metrics.ceremonies, DeepEquals, map[string]int{
+		"keygen/success":   1,
+		"keygen/failure":   1,
+		"keygen/cancelled": 1,
+	})
+
+	t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssTimeout, []blame.Node{{Pubkey: "a"}, {Pubkey: "b"}}))
+	c.Assert(metrics.blames, DeepEquals, map[string]int{"keysign/a": 1, "keysign/b": 1})
+}
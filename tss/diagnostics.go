@@ -0,0 +1,31 @@
+package tss
+
+import (
+	"runtime"
+
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// Diagnostics is the state of the ceremonies the operator dumps to debug the stuck ones, the tss messages and the
+// keysign requests are left out, only the ids, the rounds and the counts are kept
+type Diagnostics struct {
+	Goroutines     int                      `json:"goroutines"`
+	Ceremonies     []storage.CeremonyRecord `json:"ceremonies"`
+	QueuedKeySigns []QueuedKeySign          `json:"queued_keysigns"`
+	P2P            p2p.Diagnostics          `json:"p2p"`
+}
+
+// GetDiagnostics return the ceremonies we take part in, the keysigns waiting for their turn and the state of the
+// p2p layer they run over
+func (t *TssServer) GetDiagnostics() Diagnostics {
+	d := Diagnostics{
+		Goroutines:     runtime.NumGoroutine(),
+		Ceremonies:     t.GetActiveCeremonies(),
+		QueuedKeySigns: t.GetQueuedKeySigns(),
+	}
+	if t.p2pCommunication != nil {
+		d.P2P = t.p2pCommunication.GetDiagnostics()
+	}
+	return d
+}
@@ -0,0 +1,145 @@
+package tss
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// KeyHealth is the outcome of the health check keysign of a vault, the signature is never exposed
+type KeyHealth struct {
+	PoolPubKey     string        `json:"pool_pub_key"`
+	BlockHeight    int64         `json:"block_height"`
+	Time           time.Time     `json:"time"`
+	Latency        time.Duration `json:"latency"`
+	Success        bool          `json:"success"`
+	Error          string        `json:"error,omitempty"`
+	UnhealthyNodes []string      `json:"unhealthy_nodes,omitempty"`
+}
+
+// healthCheckMessage return the message the health check of the vault at the given height signs, it is the hash
+// of a well-known text, so it can never be the hash of a transaction
+func healthCheckMessage(poolPubKey string, blockHeight int64) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("go-tss-health-check-%s-%d", poolPubKey, blockHeight)))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// CheckKeyHealth run a keysign of the health check message with the vault to check it can still sign, all the
+// members of the vault need to run it with the same block height. The signature is dropped, only the outcome is
+// returned, the failures are not reported as the blame of the vault
+func (t *TssServer) CheckKeyHealth(poolPubKey string, blockHeight int64) (KeyHealth, error) {
+	if len(poolPubKey) == 0 {
+		return KeyHealth{}, errcode.New(errcode.BadRequest, "empty pool pub key")
+	}
+	if _, err := t.getLocalState(poolPubKey); err != nil {
+		return KeyHealth{}, fmt.Errorf("fail to get the local state of the vault: %w", err)
+	}
+	req := keysign.NewRequest(poolPubKey, []string{healthCheckMessage(poolPubKey, blockHeight)}, blockHeight, nil, messages.NEWJOINPARTYVERSION)
+	start := time.Now()
+	resp, err := t.runKeySign(req)
+	result := KeyHealth{
+		PoolPubKey:  poolPubKey,
+		BlockHeight: blockHeight,
+		Time:        start,
+		Latency:     time.Since(start),
+		Success:     err == nil && resp.Status == common.Success,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	for _, el := range resp.Blame.BlameNodes {
+		result.UnhealthyNodes = append(result.UnhealthyNodes, el.Pubkey)
+	}
+	if result.Success {
+		t.logger.Info().Str("pool pub key", poolPubKey).Msgf("health check keysign at %d succeeded in %s", blockHeight, result.Latency)
+	} else {
+		t.logger.Warn().Err(err).Str("pool pub key", poolPubKey).Strs("unhealthy nodes", result.UnhealthyNodes).Msgf("health check keysign at %d failed", blockHeight)
+	}
+	t.healthLock.Lock()
+	t.keyHealth[poolPubKey] = result
+	t.healthLock.Unlock()
+	return result, nil
+}
+
+// healthCheckScheduler runs the health check of each of HealthCheckPoolPubKeys at the start of every slot of
+// HealthCheckInterval, the slot is the block height of the health check
+func (t *TssServer) healthCheckScheduler() {
+	interval := int64(t.conf.HealthCheckInterval)
+	for {
+		slot := time.Now().UnixNano()/interval + 1
+		select {
+		case <-t.stopChan:
+			return
+		case <-time.After(time.Until(time.Unix(0, slot*interval))):
+		}
+		// the health check has a low priority, so it gives up the slot if we are busy with a real ceremony
+		if atomic.LoadInt64(&t.activeCeremonies) > 0 {
+			t.logger.Info().Msgf("skip the health check of slot %d as we are busy with other ceremonies", slot)
+			continue
+		}
+		for _, el := range t.conf.HealthCheckPoolPubKeys {
+			if _, err := t.CheckKeyHealth(el, slot); err != nil {
+				t.logger.Error().Err(err).Str("pool pub key", el).Msg("fail to check the health of the vault")
+			}
+		}
+	}
+}
+
+// GetKeyHealth return the latest health check of each vault we have checked
+func (t *TssServer) GetKeyHealth() map[string]KeyHealth {
+	t.healthLock.RLock()
+	defer t.healthLock.RUnlock()
+	if len(t.keyHealth) == 0 {
+		return nil
+	}
+	result := make(map[string]KeyHealth, len(t.keyHealth))
+	for k, v := range t.keyHealth {
+		result[k] = v
+	}
+	return result
+}
+
+// verifyLocalStates verify the checksum of all the keyshares, the corrupted keys are marked unhealthy, or we
+// refuse to start if RefuseCorruptedKeyshares is set, so the corruption is found before a keysign fails on it
+func (t *TssServer) verifyLocalStates() error {
+	lister, ok := t.stateManager.(storage.LocalStateLister)
+	if !ok {
+		t.logger.Warn().Msg("the state manager can not list the keyshares, skip the verification")
+		return nil
+	}
+	failed, err := storage.VerifyLocalStates(lister, t.stateManager)
+	if err != nil {
+		t.logger.Warn().Err(err).Msg("fail to verify the keyshares")
+		return nil
+	}
+	if len(failed) > 0 && t.conf.RefuseCorruptedKeyshares {
+		pubKeys := make([]string, 0, len(failed))
+		for k := range failed {
+			pubKeys = append(pubKeys, k)
+		}
+		sort.Strings(pubKeys)
+		return fmt.Errorf("the keyshares of %s fail the verification: %w", strings.Join(pubKeys, ","), failed[pubKeys[0]])
+	}
+	now := time.Now()
+	t.healthLock.Lock()
+	defer t.healthLock.Unlock()
+	for k, v := range failed {
+		t.logger.Error().Err(v).Str("pool pub key", k).Msg("the keyshare fails the verification, the key is unhealthy")
+		t.keyHealth[k] = KeyHealth{
+			PoolPubKey: k,
+			Time:       now,
+			Error:      v.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package tss
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type HealthCheckTestSuite struct{}
+
+var _ = Suite(&HealthCheckTestSuite{})
+
+func (s *HealthCheckTestSuite) TestVerifyLocalStates(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	folder := c.MkDir()
+	stateManager, err := storage.NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		healthLock:   &sync.RWMutex{},
+		keyHealth:    make(map[string]KeyHealth),
+	}
+	c.Assert(t.verifyLocalStates(), IsNil)
+	c.Assert(t.GetKeyHealth(), HasLen, 0)
+
+	// the corrupted share marks the key unhealthy
+	filePathName := filepath.Join(folder, "localstate-"+state.PubKey+".json")
+	buf, err := ioutil.ReadFile(filePathName)
+	c.Assert(err, IsNil)
+	corrupted := strings.Replace(string(buf), state.LocalPartyKey, "whatever", 1)
+	c.Assert(ioutil.WriteFile(filePathName, []byte(corrupted), 0o600), IsNil)
+	c.Assert(t.verifyLocalStates(), IsNil)
+	health := t.GetKeyHealth()
+	c.Assert(health, HasLen, 1)
+	c.Assert(health[state.PubKey].Success, Equals, false)
+	c.Assert(health[state.PubKey].Error, Matches, "the local state is corrupted.*")
+
+	// or refuses the start
+	t.conf = common.TssConfig{RefuseCorruptedKeyshares: true}
+	c.Assert(t.verifyLocalStates(), ErrorMatches, "the keyshares of "+state.PubKey+" fail the verification.*")
+}
@@ -1,55 +1,165 @@
 package tss
 
 import (
+	"context"
+	"fmt"
 	"time"
 
+	bkeygen "github.com/binance-chain/tss-lib/ecdsa/keygen"
+
 	"github.com/akildemir/go-tss/blame"
 	"github.com/akildemir/go-tss/common"
 	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
 	"github.com/akildemir/go-tss/keygen"
 	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/webhook"
 )
 
+// ErrVaultKeygen is returned for the vault keygen, it needs the eddsa keygen party next to the ecdsa one, and the
+// eddsa parties of the tss-lib we pin register their protobuf messages under the same names as the ecdsa ones, so
+// they can not be linked into the same binary
+var ErrVaultKeygen = errcode.New(errcode.Unsupported, "the vault keygen of an ecdsa and an eddsa key is not supported by this node")
+
+// ErrEdDSAKeygen is returned for the keygen of the ed25519 keys, the eddsa parties can not be linked for the same
+// reason as the ones of the vault keygen
+var ErrEdDSAKeygen = errcode.New(errcode.Unsupported, "the eddsa keygen of the ed25519 keys is not supported by this node")
+
 func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
-	t.tssKeyGenLocker.Lock()
-	defer t.tssKeyGenLocker.Unlock()
+	return t.KeygenContext(context.Background(), req)
+}
+
+// KeygenContext is the Keygen that gives up once the context is cancelled, the join party, the tss-lib party loop
+// and the messages and the streams of the keygen are torn down
+func (t *TssServer) KeygenContext(ctx context.Context, req keygen.Request) (keygen.Response, error) {
+	startTime := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var views *blameViews
+	if msgID, err := t.requestToMsgId(req); err == nil {
+		defer t.ceremonyCancels.track(msgID, cancel)()
+		var unsubscribe func()
+		views, unsubscribe = t.subscribeBlameViews(msgID, len(req.Keys))
+		defer unsubscribe()
+	}
+	// the failure response carries the last log lines of the keygen
+	capture := common.NewCeremonyLog(t.conf.CeremonyLogLines)
+	resp, err := t.runKeygen(common.WithCeremonyLog(ctx, capture), req)
+	t.observeCeremony("keygen", startTime, resp.Status, ctx.Err() != nil)
+	msgID, errID := t.requestToMsgId(req)
+	if ctx.Err() != nil {
+		// we gave up on the keygen ourselves, so we blame no one
+		if errID == nil {
+			t.notifyCeremony(webhook.KeygenCompleted, webhook.KeygenFailed, msgID, "", common.Fail, errcode.Cancelled)
+		}
+		return keygen.Response{Status: common.Fail, ErrorCode: errcode.Cancelled, Logs: capture.Lines()}, fmt.Errorf("the keygen is cancelled: %w", ctx.Err())
+	}
+	resp.ErrorCode = errorCode(resp.Status, resp.Blame, err)
+	if resp.Status == common.Fail {
+		resp.Logs = capture.Lines()
+	}
+	t.recordReputation(resp.Status, resp.Blame, req.Keys)
+	if errID == nil {
+		if resp.Status == common.Fail {
+			resp.Verdict = t.reportBlame("keygen", msgID, "", resp.Blame)
+			resp.BlameQuorum = t.confirmBlame(views, "keygen", "", resp.Verdict, req.Keys)
+			t.quarantineBlamed(resp.Blame, resp.BlameQuorum)
+		}
+		t.notifyCeremony(webhook.KeygenCompleted, webhook.KeygenFailed, msgID, resp.PubKey, resp.Status, resp.ErrorCode)
+	}
+	return resp, err
+}
+
+func (t *TssServer) runKeygen(ctx context.Context, req keygen.Request) (keygen.Response, error) {
+	if req.Vault {
+		return keygen.Response{}, ErrVaultKeygen
+	}
+	if req.Algo.OrDefault() == conversion.AlgoEd25519 {
+		return keygen.Response{}, ErrEdDSAKeygen
+	}
+	if _, err := common.GetAlgoParties(req.Algo); err != nil {
+		return keygen.Response{}, errcode.Wrap(errcode.BadRequest, err)
+	}
+	if err := req.Protocol.Validate(req.Algo); err != nil {
+		return keygen.Response{}, errcode.Wrap(errcode.BadRequest, err)
+	}
+	if len(req.Weights) > 0 && req.Protocol.OrDefault() != conversion.SignProtocolGG20 {
+		return keygen.Response{}, errcode.Errorf(errcode.BadRequest, "the %s keys can not be weighted", req.Protocol.OrDefault())
+	}
+	if req.Protocol == conversion.SignProtocolMuSig2 && req.Threshold > 0 && req.Threshold != len(req.Keys) {
+		return keygen.Response{}, errcode.Errorf(errcode.BadRequest, "the musig2 keys need all the %d parties to sign", len(req.Keys))
+	}
+	// the keygens are no longer serialized with the tssKeyGenLocker, they only share the ceremony slots with the
+	// keysigns. Each keygen has its own msg id, party and subscriptions, and the pre-parameters are either taken
+	// from the pool, so no two keygens get the same ones, or the default ones, which tss-lib only reads and which are
+	// only zeroized once the server stops. The key import still holds the lock as it runs outside the slots
+	if err := t.acquireCeremony(); err != nil {
+		return keygen.Response{}, err
+	}
+	defer t.releaseCeremony()
 	status := common.Success
 	msgID, err := t.requestToMsgId(req)
 	if err != nil {
 		return keygen.Response{}, err
 	}
 
+	stopChan, releaseStopChan := t.ceremonyStopChan(ctx)
+	defer releaseStopChan()
+	if req.Protocol == conversion.SignProtocolMuSig2 {
+		return t.runMuSig2Keygen(ctx, req, msgID, stopChan)
+	}
 	keygenInstance := keygen.NewTssKeyGen(
 		t.p2pCommunication.GetLocalPeerID(),
 		t.conf,
 		t.localNodePubKey,
-		t.p2pCommunication.BroadcastMsgChan,
-		t.stopChan,
-		t.preParams,
+		t.p2pCommunication.GetBroadcastChannel(),
+		stopChan,
+		t.getPreParams(),
 		msgID,
 		t.stateManager,
 		t.privateKey,
 		t.p2pCommunication)
+	// the other local shares of a weighted key need their own pre-parameters, we have them ready before we join
+	// the party as the others would not wait for us to generate them
+	if weight := conversion.GetWeight(req.Weights, t.localNodePubKey); weight > 1 {
+		sharePreParams, err := t.getSharePreParams(weight - 1)
+		if err != nil {
+			return keygen.Response{}, err
+		}
+		keygenInstance.SetSharePreParams(sharePreParams)
+	}
 
-	keygenMsgChannel := keygenInstance.GetTssKeyGenChannels()
-	t.p2pCommunication.SetSubscribe(messages.TSSKeyGenMsg, msgID, keygenMsgChannel)
-	t.p2pCommunication.SetSubscribe(messages.TSSKeyGenVerMsg, msgID, keygenMsgChannel)
-	t.p2pCommunication.SetSubscribe(messages.TSSControlMsg, msgID, keygenMsgChannel)
-	t.p2pCommunication.SetSubscribe(messages.TSSTaskDone, msgID, keygenMsgChannel)
+	tssCommon := keygenInstance.GetTssCommonStruct()
+	// the log lines of the keygen carry its msg id, peers and round, and they are kept for the failure response
+	logContext := tssCommon.GetLogContext()
+	logContext.SetCapture(common.CeremonyLogFromContext(ctx))
+	ctx = logContext.WithContext(ctx)
+	logger := logContext.Logger("tss")
+	t.p2pCommunication.SetSubscribe(messages.TSSKeyGenMsg, msgID, tssCommon.GetMsgChannel(messages.TSSKeyGenMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSKeyGenVerMsg, msgID, tssCommon.GetMsgChannel(messages.TSSKeyGenVerMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSControlMsg, msgID, tssCommon.GetMsgChannel(messages.TSSControlMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSTaskDone, msgID, tssCommon.GetMsgChannel(messages.TSSTaskDone))
+	t.p2pCommunication.SetSubscribe(messages.TSSCatchUpMsg, msgID, tssCommon.GetMsgChannel(messages.TSSCatchUpMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSAbortMsg, msgID, tssCommon.GetMsgChannel(messages.TSSAbortMsg))
 
 	defer func() {
 		t.p2pCommunication.CancelSubscribe(messages.TSSKeyGenMsg, msgID)
 		t.p2pCommunication.CancelSubscribe(messages.TSSKeyGenVerMsg, msgID)
 		t.p2pCommunication.CancelSubscribe(messages.TSSControlMsg, msgID)
 		t.p2pCommunication.CancelSubscribe(messages.TSSTaskDone, msgID)
+		t.p2pCommunication.CancelSubscribe(messages.TSSCatchUpMsg, msgID)
+		t.p2pCommunication.CancelSubscribe(messages.TSSAbortMsg, msgID)
 
+		if ctx.Err() != nil {
+			t.p2pCommunication.CancelCeremony(msgID)
+		}
 		t.p2pCommunication.ReleaseStream(msgID)
 		t.partyCoordinator.ReleaseStream(msgID)
 	}()
 	sigChan := make(chan string)
 	blameMgr := keygenInstance.GetTssCommonStruct().GetBlameMgr()
 	joinPartyStartTime := time.Now()
-	onlinePeers, leader, errJoinParty := t.joinParty(msgID, req.Version, req.BlockHeight, req.Keys, len(req.Keys)-1, sigChan)
+	onlinePeers, leader, errJoinParty := t.joinParty(ctx, msgID, req.Version, req.BlockHeight, req.Keys, nil, len(req.Keys)-1, sigChan)
 	joinPartyTime := time.Since(joinPartyStartTime)
 	if errJoinParty != nil {
 		t.tssMetrics.KeygenJoinParty(joinPartyTime, false)
@@ -57,7 +167,7 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 		// this indicate we are processing the leaderless join party
 		if leader == "NONE" {
 			if onlinePeers == nil {
-				t.logger.Error().Err(err).Msg("error before we start join party")
+				logger.Error().Err(err).Msg("error before we start join party")
 				return keygen.Response{
 					Status: common.Fail,
 					Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
@@ -65,13 +175,14 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 			}
 			blameNodes, err := blameMgr.NodeSyncBlame(req.Keys, onlinePeers)
 			if err != nil {
-				t.logger.Err(errJoinParty).Msg("fail to get peers to blame")
+				logger.Err(errJoinParty).Msg("fail to get peers to blame")
 			}
 			// make sure we blame the leader as well
-			t.logger.Error().Err(errJoinParty).Msgf("fail to form keygen party with online:%v", onlinePeers)
+			logger.Error().Err(errJoinParty).Msgf("fail to form keygen party with online:%v", onlinePeers)
 			return keygen.Response{
-				Status: common.Fail,
-				Blame:  blameNodes,
+				Status:    common.Fail,
+				Blame:     blameNodes,
+				JoinParty: t.partyCoordinator.JoinPartyReport(msgID),
 			}, nil
 
 		}
@@ -80,11 +191,11 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 		var blameNodes blame.Blame
 		blameNodes, err = blameMgr.NodeSyncBlame(req.Keys, onlinePeers)
 		if err != nil {
-			t.logger.Err(errJoinParty).Msg("fail to get peers to blame")
+			logger.Err(errJoinParty).Msg("fail to get peers to blame")
 		}
 		leaderPubKey, err := conversion.GetPubKeyFromPeerID(leader)
 		if err != nil {
-			t.logger.Error().Err(errJoinParty).Msgf("fail to convert the peerID to public key with leader %s", leader)
+			logger.Error().Err(errJoinParty).Msgf("fail to convert the peerID to public key with leader %s", leader)
 			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{})
 		} else {
 			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{{leaderPubKey, nil, nil}})
@@ -94,43 +205,91 @@ func (t *TssServer) Keygen(req keygen.Request) (keygen.Response, error) {
 		} else {
 			blameNodes = blameLeader
 		}
-		t.logger.Error().Err(errJoinParty).Msgf("fail to form keygen party with online:%v", onlinePeers)
+		logger.Error().Err(errJoinParty).Msgf("fail to form keygen party with online:%v", onlinePeers)
 
 		return keygen.Response{
-			Status: common.Fail,
-			Blame:  blameNodes,
+			Status:    common.Fail,
+			Blame:     blameNodes,
+			JoinParty: t.partyCoordinator.JoinPartyReport(msgID),
 		}, nil
 
 	}
 
 	t.tssMetrics.KeygenJoinParty(joinPartyTime, true)
-	t.logger.Debug().Msg("keygen party formed")
+	logger.Debug().Msg("keygen party formed")
 	// the statistic of keygen only care about Tss it self, even if the
 	// following http response aborts, it still counted as a successful keygen
 	// as the Tss model runs successfully.
+	t.ceremonyJournal.begin("keygen", msgID, "", onlinePeers)
+	defer t.ceremonyJournal.end(msgID)
+	tssCommon.SetProgressHook(func(round string, received int) {
+		t.ceremonyJournal.progress(msgID, round, received)
+	})
 	beforeKeygen := time.Now()
 	k, err := keygenInstance.GenerateNewKey(req)
 	keygenTime := time.Since(beforeKeygen)
 	if err != nil {
 		t.tssMetrics.UpdateKeyGen(keygenTime, false)
-		t.logger.Error().Err(err).Msg("err in keygen")
+		logger.Error().Err(err).Msg("err in keygen")
 		blameNodes := *blameMgr.GetBlame()
-		return keygen.NewResponse("", "", common.Fail, blameNodes), err
+		resp := keygen.NewResponse("", "", common.Fail, blameNodes)
+		resp.Stall = blameMgr.GetStall()
+		rounds, traceErr := blameMgr.TraceRounds(messages.KeygenRounds)
+		if traceErr != nil {
+			logger.Error().Err(traceErr).Msg("fail to trace the rounds of the keygen")
+		}
+		resp.Rounds = rounds
+		return resp, err
 	} else {
 		t.tssMetrics.UpdateKeyGen(keygenTime, true)
 	}
 
 	newPubKey, addr, err := conversion.GetTssPubKey(k)
 	if err != nil {
-		t.logger.Error().Err(err).Msg("fail to generate the new Tss key")
+		logger.Error().Err(err).Msg("fail to generate the new Tss key")
 		status = common.Fail
 	}
 
 	blameNodes := *blameMgr.GetBlame()
-	return keygen.NewResponse(
+	resp := keygen.NewResponse(
 		newPubKey,
 		addr.String(),
 		status,
 		blameNodes,
-	), nil
+	)
+	resp.Algo = req.Algo.OrDefault()
+	resp.Protocol = req.Protocol.OrDefault()
+	return resp, nil
+}
+
+// getSharePreParams return the pre-parameters of the given number of the other local shares of a weighted key, we
+// generate the ones the pool can not give
+func (t *TssServer) getSharePreParams(shares int) ([]*bkeygen.LocalPreParams, error) {
+	sharePreParams := make([]*bkeygen.LocalPreParams, shares)
+	for i := range sharePreParams {
+		if t.preParamsPool != nil {
+			sharePreParams[i] = t.preParamsPool.Take()
+		}
+		if sharePreParams[i] != nil {
+			continue
+		}
+		preParams, err := bkeygen.GeneratePreParams(t.conf.PreParamTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("fail to generate the pre-parameters of the local share %d: %w", i+1, err)
+		}
+		sharePreParams[i] = preParams
+	}
+	return sharePreParams, nil
+}
+
+// getPreParams return fresh pre-parameters from the pool, they are only used once, we fall back to the ones we
+// generated at start if the pool is disabled or empty
+func (t *TssServer) getPreParams() *bkeygen.LocalPreParams {
+	if t.preParamsPool != nil {
+		if preParams := t.preParamsPool.Take(); preParams != nil {
+			return preParams
+		}
+		t.logger.Warn().Msg("the pre-parameters pool is empty, use the default pre-parameters")
+	}
+	return t.preParams
 }
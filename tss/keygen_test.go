@@ -0,0 +1,37 @@
+package tss
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/messages"
+)
+
+type KeygenTestSuite struct{}
+
+var _ = Suite(&KeygenTestSuite{})
+
+func (s *KeygenTestSuite) TestUnsupportedKeygen(c *C) {
+	conversion.SetupBech32Prefix()
+	t := &TssServer{
+		logger:     log.Logger,
+		retireLock: &sync.Mutex{},
+	}
+	req := keygen.NewRequest([]string{conversion.GetRandomPubKey(), conversion.GetRandomPubKey()}, 10, messages.NEWJOINPARTYVERSION)
+	req.Vault = true
+	_, err := t.runKeygen(context.Background(), req)
+	c.Assert(err, Equals, ErrVaultKeygen)
+	c.Assert(errcode.Of(err), Equals, errcode.Unsupported)
+
+	req.Vault = false
+	req.Algo = conversion.AlgoEd25519
+	_, err = t.runKeygen(context.Background(), req)
+	c.Assert(err, Equals, ErrEdDSAKeygen)
+	c.Assert(errcode.Of(err), Equals, errcode.Unsupported)
+}
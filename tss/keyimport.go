@@ -0,0 +1,127 @@
+package tss
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/binance-chain/tss-lib/crypto"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keyimport"
+)
+
+// ImportKey split an existing private key into tss shares and distribute them to the committee, all the members
+// of the committee and the dealer should call it with the same keys and dealer, only the dealer sets the private
+// key. It is DANGEROUS, the dealer has seen the whole private key, so it should only be used to migrate the
+// legacy single key vaults into tss without moving the funds on chain. It is only for the library users with
+// AllowTrustedDealer set, the tss service does not expose it over http or grpc.
+func (t *TssServer) ImportKey(req keyimport.Request) (keyimport.Response, error) {
+	if !t.conf.AllowTrustedDealer {
+		return keyimport.Response{Status: common.Fail}, errors.New("trusted dealer key import is not allowed on this node")
+	}
+	if req.Confirmation != keyimport.ConfirmationPhrase {
+		return keyimport.Response{Status: common.Fail}, errors.New("the key import is not confirmed by the operator")
+	}
+	isDealer := req.DealerPubKey == t.localNodePubKey
+	if isDealer && len(req.PrivateKey) == 0 {
+		return keyimport.Response{Status: common.Fail}, errors.New("the dealer does not have the private key")
+	}
+	if !isDealer && len(req.PrivateKey) != 0 {
+		return keyimport.Response{Status: common.Fail}, errors.New("only the dealer should have the private key")
+	}
+	if req.Threshold > 0 {
+		if _, err := conversion.GetThresholdFromSigners(req.Threshold, len(req.Keys)); err != nil {
+			return keyimport.Response{Status: common.Fail}, errcode.Wrap(errcode.BadRequest, err)
+		}
+	}
+
+	t.tssKeyGenLocker.Lock()
+	defer t.tssKeyGenLocker.Unlock()
+	keys := make([]string, len(req.Keys))
+	copy(keys, req.Keys)
+	sort.Strings(keys)
+	msgSeed := "keyimport" + req.DealerPubKey + strings.Join(keys, "")
+	// the nodes asked for another threshold do not meet
+	if req.Threshold > 0 {
+		msgSeed += strconv.Itoa(req.Threshold)
+	}
+	msgID, err := common.MsgToHashString([]byte(msgSeed))
+	if err != nil {
+		return keyimport.Response{Status: common.Fail}, err
+	}
+	dealerPeer, err := conversion.GetPeerIDFromPubKey(req.DealerPubKey)
+	if err != nil {
+		return keyimport.Response{Status: common.Fail}, fmt.Errorf("fail to convert the dealer pub key to peer id: %w", err)
+	}
+	participants := keys
+	inCommittee := false
+	for _, el := range keys {
+		if el == req.DealerPubKey {
+			inCommittee = true
+			break
+		}
+	}
+	if !inCommittee {
+		participants = append([]string{req.DealerPubKey}, keys...)
+	}
+	if !isDealer {
+		if err := t.keyImporter.Expect(msgID, dealerPeer, keys, req.Threshold, t.localNodePubKey, t.getPreParams()); err != nil {
+			return keyimport.Response{Status: common.Fail}, fmt.Errorf("fail to prepare the key import: %w", err)
+		}
+		defer t.keyImporter.Cancel(msgID)
+	}
+
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(participants)
+	if err != nil {
+		return keyimport.Response{Status: common.Fail}, fmt.Errorf("fail to convert pub key to peer id: %w", err)
+	}
+	onlinePeers, errSync := t.partyCoordinator.SyncBarrier(msgID, peerIDs, t.conf.PartyTimeout)
+	if errSync != nil {
+		blameMgr := blame.NewBlameManager()
+		blameNodes, err := blameMgr.NodeSyncBlame(participants, onlinePeers)
+		if err != nil {
+			t.logger.Error().Err(err).Msg("fail to get peers to blame")
+		}
+		t.logger.Error().Err(errSync).Msgf("fail to form key import party with online:%v", onlinePeers)
+		return keyimport.NewResponse("", "", common.Fail, blameNodes), nil
+	}
+
+	var pubKey *crypto.ECPoint
+	if isDealer {
+		pubKey, err = t.dealKey(msgID, keys, req.Threshold, req.PrivateKey)
+	} else {
+		pubKey, err = t.keyImporter.Wait(msgID, t.conf.KeyGenTimeout)
+	}
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to import the key")
+		return keyimport.NewResponse("", "", common.Fail, blame.Blame{}), err
+	}
+	newPubKey, addr, err := conversion.GetTssPubKey(pubKey)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to generate the new Tss key")
+		return keyimport.NewResponse("", "", common.Fail, blame.Blame{}), err
+	}
+	t.logger.Warn().Msgf("imported the key %s through a trusted dealer", newPubKey)
+	return keyimport.NewResponse(newPubKey, addr.String(), common.Success, blame.Blame{}), nil
+}
+
+func (t *TssServer) dealKey(msgID string, keys []string, signers int, privateKey string) (*crypto.ECPoint, error) {
+	buf, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to decode the private key: %w", err)
+	}
+	defer func() {
+		for i := range buf {
+			buf[i] = 0
+		}
+	}()
+	return t.keyImporter.Deal(msgID, keys, signers, t.localNodePubKey, t.getPreParams(), new(big.Int).SetBytes(buf), t.conf.KeyGenTimeout)
+}
@@ -0,0 +1,32 @@
+package tss
+
+import (
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/keyimport"
+)
+
+type KeyImportTestSuite struct{}
+
+var _ = Suite(&KeyImportTestSuite{})
+
+func (s *KeyImportTestSuite) TestImportKeyThreshold(c *C) {
+	conversion.SetupBech32Prefix()
+	t := &TssServer{
+		logger: log.Logger,
+		conf:   common.TssConfig{AllowTrustedDealer: true},
+	}
+	keys := []string{conversion.GetRandomPubKey(), conversion.GetRandomPubKey(), conversion.GetRandomPubKey(), conversion.GetRandomPubKey()}
+	req := keyimport.NewRequest(keys, keys[0], "", keyimport.ConfirmationPhrase)
+	// the signers should be the majority of the parties, and no more than them
+	for _, el := range []int{2, 5} {
+		req.Threshold = el
+		_, err := t.ImportKey(req)
+		c.Assert(err, NotNil)
+		c.Assert(errcode.Of(err), Equals, errcode.BadRequest)
+	}
+}
@@ -0,0 +1,97 @@
+package tss
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// ErrShareNotFound is returned when the node is asked to use a pool key it holds no share of
+var ErrShareNotFound = errcode.New(errcode.ShareNotFound, "the node does not hold the share of the pool key")
+
+// KeyInfo is the metadata of a share the node holds, the share itself is left out
+type KeyInfo struct {
+	PoolPubKey string `json:"pool_pub_key"`
+	// CreatedAt is nil for the shares saved before we record it
+	CreatedAt       *time.Time              `json:"created_at,omitempty"`
+	ParticipantKeys []string                `json:"participant_keys,omitempty"`
+	LocalPartyKey   string                  `json:"local_party_key,omitempty"`
+	Weights         map[string]int          `json:"weights,omitempty"`
+	Threshold       int                     `json:"threshold,omitempty"`
+	Algo            conversion.Algo         `json:"algo,omitempty"`
+	Protocol        conversion.SignProtocol `json:"protocol,omitempty"`
+	RetiredAt       *time.Time              `json:"retired_at,omitempty"`
+	// Error is set when the share can not be read, the other fields are empty then
+	Error string `json:"error,omitempty"`
+}
+
+func newKeyInfo(state storage.KeygenLocalState) KeyInfo {
+	info := KeyInfo{
+		PoolPubKey:      state.PubKey,
+		CreatedAt:       state.CreatedAt,
+		ParticipantKeys: state.ParticipantKeys,
+		LocalPartyKey:   state.LocalPartyKey,
+		Weights:         state.Weights,
+		Algo:            state.Algo.OrDefault(),
+		Protocol:        state.Protocol.OrDefault(),
+		RetiredAt:       state.RetiredAt,
+	}
+	// the threshold is the number of the signers the keysign needs, the tss threshold is one less
+	if threshold, err := state.GetThreshold(); err == nil {
+		info.Threshold = threshold + 1
+	}
+	return info
+}
+
+// ListKeys return the metadata of all the shares the node holds, so the operators can audit them. The shares that
+// can not be read are listed with the error
+func (t *TssServer) ListKeys() ([]KeyInfo, error) {
+	lister, ok := t.stateManager.(storage.LocalStateLister)
+	if !ok {
+		return nil, errors.New("the state manager can not list the keys")
+	}
+	pubKeys, err := lister.ListLocalStates()
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	keys := make([]KeyInfo, 0, len(pubKeys))
+	for _, el := range pubKeys {
+		state, err := t.stateManager.GetLocalState(el)
+		if err != nil {
+			keys = append(keys, KeyInfo{PoolPubKey: el, Error: err.Error()})
+			continue
+		}
+		keys = append(keys, newKeyInfo(state))
+	}
+	return keys, nil
+}
+
+// getLocalState read the share of the pool key, a missing share is reported as ErrShareNotFound
+func (t *TssServer) getLocalState(poolPubKey string) (storage.KeygenLocalState, error) {
+	localState, err := t.stateManager.GetLocalState(poolPubKey)
+	if errors.Is(err, os.ErrNotExist) {
+		return storage.KeygenLocalState{}, fmt.Errorf("%w: %s", ErrShareNotFound, poolPubKey)
+	}
+	return localState, err
+}
+
+// migrateLocalStates save the keyshares of the outdated format versions in the current one, a keyshare that fails
+// the migration is caught by the verification
+func (t *TssServer) migrateLocalStates() {
+	lister, ok := t.stateManager.(storage.LocalStateLister)
+	if !ok {
+		return
+	}
+	migrated, err := storage.MigrateLocalStates(lister, t.stateManager)
+	for _, el := range migrated {
+		t.logger.Info().Str("pool pub key", el).Msg("the keyshare is migrated to the current format")
+	}
+	if err != nil {
+		t.logger.Warn().Err(err).Msg("fail to migrate the keyshares")
+	}
+}
@@ -0,0 +1,91 @@
+package tss
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type KeysTestSuite struct{}
+
+var _ = Suite(&KeysTestSuite{})
+
+func (s *KeysTestSuite) TestListKeys(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	state.CreatedAt = &createdAt
+	folder := c.MkDir()
+	stateManager, err := storage.NewFileStateMgr(folder)
+	c.Assert(err, IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+	}
+	keys, err := t.ListKeys()
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 0)
+
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	corrupted := "thorpub1addwnpepqf90u7n3nr2jwsw4t2gzhzqfdlply8dlzv3mdj4dr22uvhe04azq5gac3gq"
+	c.Assert(ioutil.WriteFile(filepath.Join(folder, "localstate-"+corrupted+".json"), []byte("{"), 0o600), IsNil)
+	keys, err = t.ListKeys()
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 2)
+	for _, el := range keys {
+		if el.PoolPubKey == corrupted {
+			c.Assert(el.Error, Not(Equals), "")
+			continue
+		}
+		c.Assert(el.PoolPubKey, Equals, state.PubKey)
+		c.Assert(el.Error, Equals, "")
+		c.Assert(el.CreatedAt.Equal(createdAt), Equals, true)
+		c.Assert(el.ParticipantKeys, DeepEquals, state.ParticipantKeys)
+		c.Assert(el.Algo, Equals, conversion.AlgoSecp256k1)
+		c.Assert(el.Protocol, Equals, conversion.SignProtocolGG20)
+		c.Assert(el.Threshold > 1, Equals, true)
+	}
+}
+
+func (s *KeysTestSuite) TestGetLocalState(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+	}
+	_, err = t.getLocalState(state.PubKey)
+	c.Assert(errors.Is(err, ErrShareNotFound), Equals, true)
+	c.Assert(errcode.Of(err), Equals, errcode.ShareNotFound)
+
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	localState, err := t.getLocalState(state.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(localState.PubKey, Equals, state.PubKey)
+}
+
+func (s *KeysTestSuite) TestErrorCode(c *C) {
+	c.Assert(errorCode(common.Success, blame.Blame{}, nil), Equals, errcode.Code(""))
+	c.Assert(errorCode(common.Fail, blame.Blame{}, ErrShuttingDown), Equals, errcode.ShuttingDown)
+	c.Assert(errorCode(common.Fail, blame.NewBlame(blame.TssTimeout, nil), nil), Equals, errcode.TssTimeout)
+	c.Assert(errorCode(common.Fail, blame.Blame{}, nil), Equals, errcode.Internal)
+}
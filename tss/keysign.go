@@ -1,6 +1,7 @@
 package tss
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -10,20 +11,31 @@ import (
 	"time"
 
 	tsslibcommon "github.com/binance-chain/tss-lib/common"
+	bcrypto "github.com/binance-chain/tss-lib/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/akildemir/go-tss/blame"
 	"github.com/akildemir/go-tss/common"
 	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/hd"
 	"github.com/akildemir/go-tss/keysign"
 	"github.com/akildemir/go-tss/messages"
 	"github.com/akildemir/go-tss/p2p"
 	"github.com/akildemir/go-tss/storage"
+	"github.com/akildemir/go-tss/webhook"
 )
 
-func (t *TssServer) waitForSignatures(msgID, poolPubKey string, msgsToSign [][]byte, sigChan chan string) (keysign.Response, error) {
+// ErrWeightedKey is returned when we are asked to sign with a weighted key in a way only the keys of a share per
+// participant support
+var ErrWeightedKey = errcode.New(errcode.BadRequest, "the pool key is weighted, it only signs ecdsa with the whole key")
+
+// ErrMuSig2Key is returned when we are asked to sign with a musig2 key in a way only the threshold keys support
+var ErrMuSig2Key = errcode.New(errcode.BadRequest, "the pool key is a musig2 key, it only signs schnorr with all its participants")
+
+func (t *TssServer) waitForSignatures(ctx context.Context, msgID, poolPubKey string, pubKey *bcrypto.ECPoint, encoding keysign.SignatureEncoding, msgsToSign [][]byte, sigChan chan string) (keysign.Response, error) {
 	// TSS keysign include both form party and keysign itself, thus we wait twice of the timeout
-	data, err := t.signatureNotifier.WaitForSignature(msgID, msgsToSign, poolPubKey, t.conf.KeySignTimeout, sigChan)
+	data, err := t.signatureNotifier.WaitForSignature(ctx, msgID, msgsToSign, poolPubKey, t.conf.KeySignTimeout, sigChan)
 	if err != nil {
 		return keysign.Response{}, err
 	}
@@ -32,13 +44,14 @@ func (t *TssServer) waitForSignatures(msgID, poolPubKey string, msgsToSign [][]b
 		return keysign.Response{}, errors.New("keysign failed")
 	}
 
-	return t.batchSignatures(data, msgsToSign), nil
+	return t.batchSignatures(data, msgsToSign, pubKey, encoding), nil
 }
 
-func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req keysign.Request, threshold int, allParticipants []string, localStateItem storage.KeygenLocalState, blameMgr *blame.Manager, keysignInstance *keysign.TssKeySign, sigChan chan string) (keysign.Response, error) {
+func (t *TssServer) generateSignature(ctx context.Context, msgID string, msgsToSign [][]byte, req keysign.Request, threshold int, allParticipants, excluded []string, localStateItem storage.KeygenLocalState, blameMgr *blame.Manager, keysignInstance *keysign.TssKeySign, sigChan chan string) (keysign.Response, error) {
+	logger := keysignInstance.GetTssCommonStruct().GetLogContext().Logger("tss")
 	allPeersID, err := conversion.GetPeerIDsFromPubKeys(allParticipants)
 	if err != nil {
-		t.logger.Error().Msg("invalid block height or public key")
+		logger.Error().Msg("invalid block height or public key")
 		return keysign.Response{
 			Status: common.Fail,
 			Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
@@ -50,14 +63,14 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 		return keysign.Response{
 			Status: common.Fail,
 			Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
-		}, errors.New("fail to parse the version")
+		}, errcode.New(errcode.BadRequest, "fail to parse the version")
 	}
 	// we use the old join party
 	if oldJoinParty {
 		allParticipants = req.SignerPubKeys
 		myPk, err := conversion.GetPubKeyFromPeerID(t.p2pCommunication.GetHost().ID().String())
 		if err != nil {
-			t.logger.Info().Msgf("fail to convert the p2p id(%s) to pubkey, turn to wait for signature", t.p2pCommunication.GetHost().ID().String())
+			logger.Info().Msgf("fail to convert the p2p id(%s) to pubkey, turn to wait for signature", t.p2pCommunication.GetHost().ID().String())
 			return keysign.Response{}, p2p.ErrNotActiveSigner
 		}
 		isSignMember := false
@@ -68,14 +81,14 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 			}
 		}
 		if !isSignMember {
-			t.logger.Info().Msgf("we(%s) are not the active signer", t.p2pCommunication.GetHost().ID().String())
+			logger.Info().Msgf("we(%s) are not the active signer", t.p2pCommunication.GetHost().ID().String())
 			return keysign.Response{}, p2p.ErrNotActiveSigner
 		}
 
 	}
 
 	joinPartyStartTime := time.Now()
-	onlinePeers, leader, errJoinParty := t.joinParty(msgID, req.Version, req.BlockHeight, allParticipants, threshold, sigChan)
+	onlinePeers, leader, errJoinParty := t.joinParty(ctx, msgID, req.Version, req.BlockHeight, allParticipants, excluded, threshold, sigChan)
 	joinPartyTime := time.Since(joinPartyStartTime)
 	if errJoinParty != nil {
 		// we received the signature from waiting for signature
@@ -86,7 +99,7 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 		// this indicate we are processing the leaderness join party
 		if leader == "NONE" {
 			if onlinePeers == nil {
-				t.logger.Error().Err(errJoinParty).Msg("error before we start join party")
+				logger.Error().Err(errJoinParty).Msg("error before we start join party")
 				t.broadcastKeysignFailure(msgID, allPeersID)
 				return keysign.Response{
 					Status: common.Fail,
@@ -96,21 +109,22 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 
 			blameNodes, err := blameMgr.NodeSyncBlame(req.SignerPubKeys, onlinePeers)
 			if err != nil {
-				t.logger.Err(err).Msg("fail to get peers to blame")
+				logger.Err(err).Msg("fail to get peers to blame")
 			}
 			t.broadcastKeysignFailure(msgID, allPeersID)
 			// make sure we blame the leader as well
-			t.logger.Error().Err(err).Msgf("fail to form keysign party with online:%v", onlinePeers)
+			logger.Error().Err(err).Msgf("fail to form keysign party with online:%v", onlinePeers)
 			return keysign.Response{
-				Status: common.Fail,
-				Blame:  blameNodes,
+				Status:    common.Fail,
+				Blame:     blameNodes,
+				JoinParty: t.partyCoordinator.JoinPartyReport(msgID),
 			}, nil
 		}
 
 		var blameLeader blame.Blame
 		leaderPubKey, err := conversion.GetPubKeyFromPeerID(leader)
 		if err != nil {
-			t.logger.Error().Err(errJoinParty).Msgf("fail to convert the peerID to public key %s", leader)
+			logger.Error().Err(errJoinParty).Msgf("fail to convert the peerID to public key %s", leader)
 			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{})
 		} else {
 			blameLeader = blame.NewBlame(blame.TssSyncFail, []blame.Node{{leaderPubKey, nil, nil}})
@@ -118,10 +132,11 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 
 		t.broadcastKeysignFailure(msgID, allPeersID)
 		// make sure we blame the leader as well
-		t.logger.Error().Err(errJoinParty).Msgf("messagesID(%s)fail to form keysign party with online:%v", msgID, onlinePeers)
+		logger.Error().Err(errJoinParty).Msgf("messagesID(%s)fail to form keysign party with online:%v", msgID, onlinePeers)
 		return keysign.Response{
-			Status: common.Fail,
-			Blame:  blameLeader,
+			Status:    common.Fail,
+			Blame:     blameLeader,
+			JoinParty: t.partyCoordinator.JoinPartyReport(msgID),
 		}, nil
 
 	}
@@ -134,7 +149,7 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 	}
 	if !isKeySignMember {
 		// we are not the keysign member so we quit keysign and waiting for signature
-		t.logger.Info().Msgf("we(%s) are not the active signer", t.p2pCommunication.GetHost().ID().String())
+		logger.Info().Msgf("we(%s) are not the active signer", t.p2pCommunication.GetHost().ID().String())
 		return keysign.Response{}, p2p.ErrNotActiveSigner
 	}
 	parsedPeers := make([]string, len(onlinePeers))
@@ -150,19 +165,41 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 			Blame:  blame.Blame{},
 		}, nil
 	}
+	t.ceremonyJournal.begin("keysign", msgID, req.PoolPubKey, onlinePeers)
+	defer t.ceremonyJournal.end(msgID)
+	keysignInstance.GetTssCommonStruct().SetProgressHook(func(round string, received int) {
+		t.ceremonyJournal.progress(msgID, round, received)
+	})
 	signatureData, err := keysignInstance.SignMessage(msgsToSign, localStateItem, signers)
 	// the statistic of keygen only care about Tss it self, even if the following http response aborts,
 	// it still counted as a successful keygen as the Tss model runs successfully.
 	if err != nil {
-		t.logger.Error().Err(err).Msg("err in keysign")
+		logger.Error().Err(err).Msg("err in keysign")
 		sigChan <- "signature generated"
 		t.broadcastKeysignFailure(msgID, allPeersID)
 		blameNodes := *blameMgr.GetBlame()
+		rounds, err := blameMgr.TraceRounds(messages.KeysignRounds)
+		if err != nil {
+			logger.Error().Err(err).Msg("fail to trace the rounds of the keysign")
+		}
 		return keysign.Response{
 			Status: common.Fail,
 			Blame:  blameNodes,
+			Stall:  blameMgr.GetStall(),
+			Rounds: rounds,
+			Abort:  blameMgr.GetAbort(),
 		}, nil
 	}
+	// we never hand out or broadcast a signature that does not verify
+	if err := keysign.VerifySignatures(localStateItem.LocalData.ECDSAPub.ToECDSAPubKey(), msgsToSign, signatureData); err != nil {
+		logger.Error().Err(err).Msg("the keysign produced an invalid signature")
+		sigChan <- "signature generated"
+		t.broadcastKeysignFailure(msgID, allPeersID)
+		return keysign.Response{
+			Status: common.Fail,
+			Blame:  blame.NewBlame(blame.InvalidSig, nil),
+		}, err
+	}
 
 	sigChan <- "signature generated"
 	// update signature notification
@@ -170,7 +207,10 @@ func (t *TssServer) generateSignature(msgID string, msgsToSign [][]byte, req key
 		return keysign.Response{}, fmt.Errorf("fail to broadcast signature:%w", err)
 	}
 
-	return t.batchSignatures(signatureData, msgsToSign), nil
+	resp := t.batchSignatures(signatureData, msgsToSign, localStateItem.LocalData.ECDSAPub, req.Encoding)
+	resp.Signers = signers
+	resp.Algo = localStateItem.Algo.OrDefault()
+	return resp, nil
 }
 
 func (t *TssServer) updateKeySignResult(result keysign.Response, timeSpent time.Duration) {
@@ -183,21 +223,294 @@ func (t *TssServer) updateKeySignResult(result keysign.Response, timeSpent time.
 }
 
 func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
+	return t.KeySignContext(context.Background(), req)
+}
+
+// KeySignContext is the KeySign that gives up once the context is cancelled, the join party, the tss-lib party loop
+// and the messages and the streams of the keysign are torn down
+func (t *TssServer) KeySignContext(ctx context.Context, req keysign.Request) (keysign.Response, error) {
+	// the failure response carries the last log lines of the keysign, of all its attempts
+	capture := common.NewCeremonyLog(t.conf.CeremonyLogLines)
+	resp, err := t.keySign(common.WithCeremonyLog(ctx, capture), req)
+	resp.ErrorCode = errorCode(resp.Status, resp.Blame, err)
+	if resp.Status == common.Fail {
+		resp.Logs = capture.Lines()
+	}
+	t.auditKeySign(ctx, req, resp, err)
+	return resp, err
+}
+
+func (t *TssServer) keySign(ctx context.Context, req keysign.Request) (resp keysign.Response, err error) {
+	if t.draining() {
+		return keysign.Response{}, ErrShuttingDown
+	}
+	req, err = t.keySignModeOfKey(req)
+	if err != nil {
+		return keysign.Response{}, err
+	}
+	// the policy and the ledger see the messages of the request, the parties sign their digests
+	digestReq, err := t.keySignDigests(req)
+	if err != nil {
+		return keysign.Response{}, err
+	}
+	if err := t.checkKeySignPolicy(req); err != nil {
+		return keysign.Response{}, err
+	}
+	if err := t.recordKeySign(req); err != nil {
+		return keysign.Response{}, err
+	}
+	req = digestReq
+	msgID, err := t.keySignMsgID(req, 0)
+	if err != nil {
+		return keysign.Response{}, err
+	}
+	defer func() {
+		t.notifyCeremony(webhook.KeySignCompleted, webhook.KeySignFailed, msgID, req.PoolPubKey, resp.Status, errorCode(resp.Status, resp.Blame, err))
+	}()
+	// the operator can cancel the keysign while it is queued as well
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer t.ceremonyCancels.track(msgID, cancel)()
+	if t.keySignQueue != nil {
+		stopChan, releaseStopChan := t.ceremonyStopChan(ctx)
+		release, err := t.keySignQueue.acquire(msgID, req.PoolPubKey, req.Priority, stopChan)
+		releaseStopChan()
+		if ctx.Err() != nil {
+			return keysign.Response{}, fmt.Errorf("the keysign is cancelled: %w", ctx.Err())
+		}
+		if err != nil {
+			t.logger.Warn().Err(err).Str("pool pub key", req.PoolPubKey).Msg("fail to queue the keysign")
+			return keysign.Response{}, err
+		}
+		defer release()
+	}
+	var attempts []keysign.Attempt
+	// the leader never picks the quarantined peers as the signers
+	excluded := t.quarantinedPubKeys()
+	retry := t.canRetryKeySign(req)
+	for attempt := 0; ; attempt++ {
+		attemptTime := time.Now()
+		untrack := func() {}
+		var views *blameViews
+		unsubscribe := func() {}
+		attemptMsgID, errID := t.keySignMsgID(req, attempt)
+		if errID == nil {
+			if attempt > 0 {
+				untrack = t.ceremonyCancels.track(attemptMsgID, cancel)
+			}
+			views, unsubscribe = t.subscribeBlameViews(attemptMsgID, len(req.SignerPubKeys))
+		}
+		resp, err := t.runKeySignAttempt(ctx, req, attempt, excluded)
+		untrack()
+		t.observeCeremony("keysign", attemptTime, resp.Status, ctx.Err() != nil)
+		if ctx.Err() != nil {
+			unsubscribe()
+			// we gave up on the keysign ourselves, so we blame no one
+			return keysign.Response{Status: common.Fail, Attempts: attempts}, fmt.Errorf("the keysign is cancelled: %w", ctx.Err())
+		}
+		t.recordReputation(resp.Status, resp.Blame, req.SignerPubKeys)
+		if resp.Status == common.Fail && errID == nil {
+			resp.Verdict = t.reportBlame("keysign", attemptMsgID, req.PoolPubKey, resp.Blame)
+			resp.BlameQuorum = t.confirmBlame(views, "keysign", req.PoolPubKey, resp.Verdict, req.SignerPubKeys)
+			t.quarantineBlamed(resp.Blame, resp.BlameQuorum)
+		}
+		unsubscribe()
+		if req.MaxAttempts <= 1 {
+			return resp, err
+		}
+		attempts = append(attempts, keysign.Attempt{
+			Signers:     resp.Signers,
+			Status:      resp.Status,
+			Blame:       resp.Blame,
+			Stall:       resp.Stall,
+			Rounds:      resp.Rounds,
+			Abort:       resp.Abort,
+			JoinParty:   resp.JoinParty,
+			Verdict:     resp.Verdict,
+			BlameQuorum: resp.BlameQuorum,
+		})
+		if err != nil || !retry || !retryableKeySign(resp) || attempt+1 >= req.MaxAttempts {
+			resp.Attempts = attempts
+			return resp, err
+		}
+		for _, el := range resp.Blame.BlameNodes {
+			excluded = append(excluded, el.Pubkey)
+		}
+		t.logger.Warn().Msgf("keysign attempt %d failed, try again without %v", attempt+1, excluded)
+	}
+}
+
+// checkKeySignPolicy ask the signing policy whether we take part in the keysign, we veto it before we join the party
+// so the others see us as offline. The canary and the health check keysigns sign the messages we make ourselves,
+// they are not asked
+func (t *TssServer) checkKeySignPolicy(req keysign.Request) error {
+	if t.keySignPolicy == nil {
+		return nil
+	}
+	policyReq, err := keysign.NewPolicyRequest(req)
+	if err != nil {
+		return err
+	}
+	if err := t.keySignPolicy.Check(policyReq); err != nil {
+		t.logger.Warn().Err(err).Str("pool pub key", req.PoolPubKey).Msg("the signing policy rejects the keysign")
+		return fmt.Errorf("%w: %s", keysign.ErrPolicyRejected, err)
+	}
+	return nil
+}
+
+// recordKeySign record the keysign in the sign ledger, it fails if the keysign conflicts with one we have taken part
+// in. The keysign is recorded before we join the party, a failed keysign may still have shown our partial signature
+func (t *TssServer) recordKeySign(req keysign.Request) error {
+	if t.signLedger == nil {
+		return nil
+	}
+	ledgerReq, err := keysign.NewPolicyRequest(req)
+	if err != nil {
+		return err
+	}
+	if err := t.signLedger.Record(ledgerReq); err != nil {
+		t.logger.Warn().Err(err).Str("pool pub key", req.PoolPubKey).Msg("the sign ledger refuses the keysign")
+		return err
+	}
+	return nil
+}
+
+// canRetryKeySign return true if the keysign can be tried again with another signer subset, only the leader of
+// the ecdsa join party picks the signers, the other keysigns sign with the signers of the request
+func (t *TssServer) canRetryKeySign(req keysign.Request) bool {
+	if req.MaxAttempts <= 1 || req.Mode.IsSchnorr() {
+		return false
+	}
+	oldJoinParty, err := conversion.VersionLTCheck(req.Version, messages.NEWJOINPARTYVERSION)
+	return err == nil && !oldJoinParty
+}
+
+// retryableKeySign return true if the keysign failed as a signer timed out. The parties outside of the signer subset
+// can not tell why it failed, so they try again as long as they get no signature, or they could not be picked
+func retryableKeySign(resp keysign.Response) bool {
+	switch resp.Status {
+	case common.NA:
+		return true
+	case common.Fail:
+		return resp.Blame.FailReason == blame.TssTimeout || resp.Blame.FailReason == blame.TssSyncFail
+	}
+	return false
+}
+
+// keySignMsgID return the message id of the attempt of the keysign, the later attempts are other ceremonies
+func (t *TssServer) keySignMsgID(req keysign.Request, attempt int) (string, error) {
+	msgID, err := t.requestToMsgId(req)
+	if err != nil || attempt == 0 {
+		return msgID, err
+	}
+	return fmt.Sprintf("%s-attempt-%d", msgID, attempt), nil
+}
+
+// runKeySign is the KeySign without the blame report and the reputation, the canary uses it as its failures
+// are not the evidences of the misbehaviour
+func (t *TssServer) runKeySign(req keysign.Request) (keysign.Response, error) {
+	req, err := t.keySignModeOfKey(req)
+	if err != nil {
+		return keysign.Response{}, err
+	}
+	req, err = t.keySignDigests(req)
+	if err != nil {
+		return keysign.Response{}, err
+	}
+	return t.runKeySignAttempt(context.Background(), req, 0, nil)
+}
+
+// keySignDigests return the request that signs the digests of its messages, they replace the messages so the
+// signatures of the response are over the digests. The algo of the pool key is the one of the request if we do
+// not hold a share of it
+func (t *TssServer) keySignDigests(req keysign.Request) (keysign.Request, error) {
+	if req.HashMode == "" && req.DigestLength == 0 && !req.ReduceDigest {
+		return req, nil
+	}
+	algo := req.Algo
+	if localState, err := t.stateManager.GetLocalState(req.PoolPubKey); err == nil {
+		algo = localState.Algo
+	}
+	digests, err := req.Digests(algo)
+	if err != nil {
+		return req, errcode.Errorf(errcode.BadRequest, "fail to get the digests of the messages: %w", err)
+	}
+	msgs := make([]string, len(digests))
+	for i, el := range digests {
+		msgs[i] = base64.StdEncoding.EncodeToString(el)
+	}
+	req.Messages = msgs
+	req.HashMode, req.DigestLength, req.ReduceDigest = "", 0, false
+	return req, nil
+}
+
+// keySignModeOfKey return the request with the sign mode the protocol of the pool key needs, the frost and the
+// musig2 keys sign schnorr if the request does not ask for a mode, and they have no ecdsa signatures. The mode is
+// part of the message id, so the parties that do not hold a share of the key can only take part if the request
+// sets it. All the participants of a musig2 key sign, they are the signers if the request has none
+func (t *TssServer) keySignModeOfKey(req keysign.Request) (keysign.Request, error) {
+	localState, err := t.stateManager.GetLocalState(req.PoolPubKey)
+	if err != nil {
+		// the keysign reports it once it loads the key
+		return req, nil
+	}
+	protocol := localState.Protocol.OrDefault()
+	if protocol != conversion.SignProtocolFROST && protocol != conversion.SignProtocolMuSig2 {
+		return req, nil
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = keysign.SignModeSchnorr
+	case keysign.SignModeSchnorr, keysign.SignModeTaproot:
+	default:
+		return req, errcode.Errorf(errcode.BadRequest, "the pool key signs with %s, it has no %s signatures", protocol, req.Mode)
+	}
+	if protocol == conversion.SignProtocolMuSig2 {
+		if req.UsePresignature {
+			return req, ErrMuSig2Key
+		}
+		if len(req.SignerPubKeys) == 0 {
+			req.SignerPubKeys = localState.ParticipantKeys
+		}
+	}
+	return req, nil
+}
+
+// runKeySignAttempt run an attempt of the keysign, the leader does not pick the excluded parties
+func (t *TssServer) runKeySignAttempt(ctx context.Context, req keysign.Request, attempt int, excluded []string) (keysign.Response, error) {
+	if err := t.acquireCeremony(); err != nil {
+		return keysign.Response{}, err
+	}
+	defer t.releaseCeremony()
 	t.logger.Info().Str("pool pub key", req.PoolPubKey).
 		Str("signer pub keys", strings.Join(req.SignerPubKeys, ",")).
 		Str("msg", strings.Join(req.Messages, ",")).
 		Msg("received keysign request")
 	emptyResp := keysign.Response{}
-	msgID, err := t.requestToMsgId(req)
+	msgID, err := t.keySignMsgID(req, attempt)
 	if err != nil {
 		return emptyResp, err
 	}
+	if err := req.Encoding.Validate(req.Mode); err != nil {
+		return emptyResp, errcode.Wrap(errcode.BadRequest, err)
+	}
+	if req.Mode.IsSchnorr() {
+		return t.runSchnorrKeySign(ctx, req, msgID)
+	}
+	if req.Mode != "" && req.Mode != keysign.SignModeECDSA {
+		return emptyResp, errcode.Errorf(errcode.BadRequest, "unknown sign mode %s", req.Mode)
+	}
+	if req.UsePresignature {
+		return emptyResp, errcode.New(errcode.BadRequest, "only the schnorr keysign can use the presignatures")
+	}
 
+	stopChan, releaseStopChan := t.ceremonyStopChan(ctx)
+	defer releaseStopChan()
 	keysignInstance := keysign.NewTssKeySign(
 		t.p2pCommunication.GetLocalPeerID(),
 		t.conf,
-		t.p2pCommunication.BroadcastMsgChan,
-		t.stopChan,
+		t.p2pCommunication.GetBroadcastChannel(),
+		stopChan,
 		msgID,
 		t.privateKey,
 		t.p2pCommunication,
@@ -205,33 +518,57 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 		len(req.Messages),
 	)
 
-	keySignChannels := keysignInstance.GetTssKeySignChannels()
-	t.p2pCommunication.SetSubscribe(messages.TSSKeySignMsg, msgID, keySignChannels)
-	t.p2pCommunication.SetSubscribe(messages.TSSKeySignVerMsg, msgID, keySignChannels)
-	t.p2pCommunication.SetSubscribe(messages.TSSControlMsg, msgID, keySignChannels)
-	t.p2pCommunication.SetSubscribe(messages.TSSTaskDone, msgID, keySignChannels)
+	tssCommon := keysignInstance.GetTssCommonStruct()
+	// the log lines of the keysign carry its msg id, peers and round, and they are kept for the failure response
+	logContext := tssCommon.GetLogContext()
+	logContext.SetCapture(common.CeremonyLogFromContext(ctx))
+	ctx = logContext.WithContext(ctx)
+	logger := logContext.Logger("tss")
+	t.p2pCommunication.SetSubscribe(messages.TSSKeySignMsg, msgID, tssCommon.GetMsgChannel(messages.TSSKeySignMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSKeySignVerMsg, msgID, tssCommon.GetMsgChannel(messages.TSSKeySignVerMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSControlMsg, msgID, tssCommon.GetMsgChannel(messages.TSSControlMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSTaskDone, msgID, tssCommon.GetMsgChannel(messages.TSSTaskDone))
+	t.p2pCommunication.SetSubscribe(messages.TSSCatchUpMsg, msgID, tssCommon.GetMsgChannel(messages.TSSCatchUpMsg))
+	t.p2pCommunication.SetSubscribe(messages.TSSAbortMsg, msgID, tssCommon.GetMsgChannel(messages.TSSAbortMsg))
 
 	defer func() {
 		t.p2pCommunication.CancelSubscribe(messages.TSSKeySignMsg, msgID)
 		t.p2pCommunication.CancelSubscribe(messages.TSSKeySignVerMsg, msgID)
 		t.p2pCommunication.CancelSubscribe(messages.TSSControlMsg, msgID)
 		t.p2pCommunication.CancelSubscribe(messages.TSSTaskDone, msgID)
+		t.p2pCommunication.CancelSubscribe(messages.TSSCatchUpMsg, msgID)
+		t.p2pCommunication.CancelSubscribe(messages.TSSAbortMsg, msgID)
 
+		if ctx.Err() != nil {
+			t.p2pCommunication.CancelCeremony(msgID)
+		}
 		t.p2pCommunication.ReleaseStream(msgID)
 		t.signatureNotifier.ReleaseStream(msgID)
 		t.partyCoordinator.ReleaseStream(msgID)
 	}()
 
-	localStateItem, err := t.stateManager.GetLocalState(req.PoolPubKey)
+	localStateItem, err := t.getSigningState(req)
 	if err != nil {
-		return emptyResp, fmt.Errorf("fail to get local keygen state: %w", err)
+		return emptyResp, err
+	}
+	defer storage.ZeroizeLocalState(&localStateItem)
+	if err := checkKeyAlgo(req, localStateItem); err != nil {
+		return emptyResp, err
+	}
+	// the signatures of a child key verify under the child pub key
+	signingPubKey := req.PoolPubKey
+	if len(req.DerivationPath) > 0 {
+		signingPubKey, _, err = conversion.GetTssPubKey(localStateItem.LocalData.ECDSAPub)
+		if err != nil {
+			return emptyResp, fmt.Errorf("fail to get the child pub key: %w", err)
+		}
 	}
 
 	var msgsToSign [][]byte
 	for _, val := range req.Messages {
 		msgToSign, err := base64.StdEncoding.DecodeString(val)
 		if err != nil {
-			return keysign.Response{}, fmt.Errorf("fail to decode message(%s): %w", strings.Join(req.Messages, ","), err)
+			return keysign.Response{}, errcode.Errorf(errcode.BadRequest, "fail to decode message(%s): %w", strings.Join(req.Messages, ","), err)
 		}
 		msgsToSign = append(msgsToSign, msgToSign)
 	}
@@ -239,11 +576,11 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	sort.SliceStable(msgsToSign, func(i, j int) bool {
 		ma, err := common.MsgToHashInt(msgsToSign[i])
 		if err != nil {
-			t.logger.Error().Err(err).Msgf("fail to convert the hash value")
+			logger.Error().Err(err).Msgf("fail to convert the hash value")
 		}
 		mb, err := common.MsgToHashInt(msgsToSign[j])
 		if err != nil {
-			t.logger.Error().Err(err).Msgf("fail to convert the hash value")
+			logger.Error().Err(err).Msgf("fail to convert the hash value")
 		}
 		if ma.Cmp(mb) == -1 {
 			return false
@@ -256,21 +593,28 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 		return keysign.Response{
 			Status: common.Fail,
 			Blame:  blame.NewBlame(blame.InternalError, []blame.Node{}),
-		}, errors.New("fail to parse the version")
+		}, errcode.New(errcode.BadRequest, "fail to parse the version")
 	}
 
 	if len(req.SignerPubKeys) == 0 && oldJoinParty {
-		return emptyResp, errors.New("empty signer pub keys")
+		return emptyResp, errcode.New(errcode.BadRequest, "empty signer pub keys")
 	}
 
-	threshold, err := conversion.GetThreshold(len(localStateItem.ParticipantKeys))
+	// the signers of a weighted key are picked by their shares, any threshold+1 of them hold enough shares to sign
+	threshold, err := localStateItem.GetPartyThreshold()
 	if err != nil {
-		t.logger.Error().Err(err).Msg("fail to get the threshold")
+		logger.Error().Err(err).Msg("fail to get the threshold")
 		return emptyResp, errors.New("fail to get threshold")
 	}
-	if len(req.SignerPubKeys) <= threshold && oldJoinParty {
-		t.logger.Error().Msgf("not enough signers, threshold=%d and signers=%d", threshold, len(req.SignerPubKeys))
-		return emptyResp, errors.New("not enough signers")
+	if len(req.SignerPubKeys) <= threshold && oldJoinParty && !localStateItem.Weighted() {
+		logger.Error().Msgf("not enough signers, threshold=%d and signers=%d", threshold, len(req.SignerPubKeys))
+		return emptyResp, errcode.New(errcode.BadRequest, "not enough signers")
+	}
+	// the given signers of a weighted key need enough shares rather than enough of them
+	if oldJoinParty {
+		if err := checkSignerShares(req.SignerPubKeys, localStateItem); err != nil {
+			return emptyResp, err
+		}
 	}
 
 	blameMgr := keysignInstance.GetTssCommonStruct().GetBlameMgr()
@@ -284,20 +628,20 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	// we wait for signatures
 	go func() {
 		defer wg.Done()
-		receivedSig, errWait = t.waitForSignatures(msgID, req.PoolPubKey, msgsToSign, sigChan)
+		receivedSig, errWait = t.waitForSignatures(ctx, msgID, signingPubKey, localStateItem.LocalData.ECDSAPub, req.Encoding, msgsToSign, sigChan)
 		// we received an valid signature indeed
 		if errWait == nil {
 			sigChan <- "signature received"
-			t.logger.Log().Msgf("for message %s we get the signature from the peer", msgID)
+			logger.Log().Msgf("for message %s we get the signature from the peer", msgID)
 			return
 		}
-		t.logger.Log().Msgf("we fail to get the valid signature with error %v", errWait)
+		logger.Log().Msgf("we fail to get the valid signature with error %v", errWait)
 	}()
 
 	// we generate the signature ourselves
 	go func() {
 		defer wg.Done()
-		generatedSig, errGen = t.generateSignature(msgID, msgsToSign, req, threshold, localStateItem.ParticipantKeys, localStateItem, blameMgr, keysignInstance, sigChan)
+		generatedSig, errGen = t.generateSignature(ctx, msgID, msgsToSign, req, threshold, localStateItem.ParticipantKeys, excluded, localStateItem, blameMgr, keysignInstance, sigChan)
 	}()
 	wg.Wait()
 	close(sigChan)
@@ -305,18 +649,74 @@ func (t *TssServer) KeySign(req keysign.Request) (keysign.Response, error) {
 	// we received the generated verified signature, so we return
 	if errWait == nil {
 		t.updateKeySignResult(receivedSig, keysignTime)
+		receivedSig.Signatures = keysign.OrderSignatures(req.Messages, receivedSig.Signatures)
 		return receivedSig, nil
 	}
 	// for this round, we are not the active signer
 	if errors.Is(errGen, p2p.ErrSignReceived) || errors.Is(errGen, p2p.ErrNotActiveSigner) {
 		t.updateKeySignResult(receivedSig, keysignTime)
+		receivedSig.Signatures = keysign.OrderSignatures(req.Messages, receivedSig.Signatures)
 		return receivedSig, nil
 	}
 	// we get the signature from our tss keysign
 	t.updateKeySignResult(generatedSig, keysignTime)
+	generatedSig.Signatures = keysign.OrderSignatures(req.Messages, generatedSig.Signatures)
 	return generatedSig, errGen
 }
 
+// checkKeyAlgo check the pool key is of the algo of the request
+func checkKeyAlgo(req keysign.Request, localState storage.KeygenLocalState) error {
+	if req.Algo == "" {
+		return nil
+	}
+	if req.Algo.OrDefault() != localState.Algo.OrDefault() {
+		return errcode.Errorf(errcode.BadRequest, "the pool key is a %s key, not a %s one", localState.Algo.OrDefault(), req.Algo)
+	}
+	return nil
+}
+
+// checkSignerShares check the signers hold more shares of a weighted key than its threshold
+func checkSignerShares(signers []string, localState storage.KeygenLocalState) error {
+	if !localState.Weighted() {
+		return nil
+	}
+	threshold, err := localState.GetThreshold()
+	if err != nil {
+		return fmt.Errorf("fail to get threshold: %w", err)
+	}
+	shares := conversion.TotalShares(signers, localState.Weights)
+	if shares <= threshold {
+		return errcode.Errorf(errcode.BadRequest, "the signers hold %d shares, the threshold is %d", shares, threshold)
+	}
+	return nil
+}
+
+// getSigningState return the local state the keysign signs with, it is the state of the child key of the pool if
+// the request has a derivation path
+func (t *TssServer) getSigningState(req keysign.Request) (storage.KeygenLocalState, error) {
+	localState, err := t.getLocalState(req.PoolPubKey)
+	if err != nil {
+		return storage.KeygenLocalState{}, fmt.Errorf("fail to get local keygen state: %w", err)
+	}
+	if localState.Retired() {
+		return storage.KeygenLocalState{}, ErrKeyRetired
+	}
+	if len(req.DerivationPath) == 0 {
+		return localState, nil
+	}
+	if localState.Weighted() {
+		return storage.KeygenLocalState{}, ErrWeightedKey
+	}
+	if localState.Protocol == conversion.SignProtocolMuSig2 {
+		return storage.KeygenLocalState{}, ErrMuSig2Key
+	}
+	derived, err := hd.DeriveLocalState(localState, req.DerivationPath)
+	if err != nil {
+		return storage.KeygenLocalState{}, fmt.Errorf("fail to derive the child key at %s: %w", req.DerivationPath, err)
+	}
+	return derived, nil
+}
+
 func (t *TssServer) broadcastKeysignFailure(messageID string, peers []peer.ID) {
 	if err := t.signatureNotifier.BroadcastFailed(messageID, peers); err != nil {
 		t.logger.Err(err).Msg("fail to broadcast keysign failure")
@@ -332,15 +732,37 @@ func (t *TssServer) isPartOfKeysignParty(parties []string) bool {
 	return false
 }
 
-func (t *TssServer) batchSignatures(sigs []*tsslibcommon.ECSignature, msgsToSign [][]byte) keysign.Response {
+// batchSignatures post-process the ecdsa signatures, the S is normalized to the low S form (BIP-62) whoever has
+// produced the signature, and the signatures are encoded in the requested encoding
+func (t *TssServer) batchSignatures(sigs []*tsslibcommon.ECSignature, msgsToSign [][]byte, pubKey *bcrypto.ECPoint, encoding keysign.SignatureEncoding) keysign.Response {
 	var signatures []keysign.Signature
 	for i, sig := range sigs {
 		msg := base64.StdEncoding.EncodeToString(msgsToSign[i])
+		sigS, flipped := keysign.NormalizeLowS(sig.S)
+		recoveryID := sig.SignatureRecovery
+		if flipped && len(recoveryID) == 1 {
+			recoveryID = []byte{recoveryID[0] ^ 1}
+		}
 		r := base64.StdEncoding.EncodeToString(sig.R)
-		s := base64.StdEncoding.EncodeToString(sig.S)
-		recovery := base64.StdEncoding.EncodeToString(sig.SignatureRecovery)
+		s := base64.StdEncoding.EncodeToString(sigS)
+		recovery := base64.StdEncoding.EncodeToString(recoveryID)
 
 		signature := keysign.NewSignature(msg, r, s, recovery)
+		if len(msgsToSign[i]) == 32 && pubKey != nil {
+			recoverable, err := keysign.RecoverableSignature(msgsToSign[i], sig.R, sigS, pubKey.ToECDSAPubKey())
+			if err != nil {
+				t.logger.Error().Err(err).Msgf("fail to get the recoverable signature of message %s", msg)
+			} else {
+				signature.RecoveryID = base64.StdEncoding.EncodeToString(recoverable[64:])
+				signature.Signature = base64.StdEncoding.EncodeToString(recoverable)
+			}
+		}
+		encoded, err := keysign.EncodeSignature(sig.R, sigS, encoding)
+		if err != nil {
+			t.logger.Error().Err(err).Msgf("fail to encode the signature of message %s", msg)
+		} else if encoded != nil {
+			signature.Encoded = base64.StdEncoding.EncodeToString(encoded)
+		}
 		signatures = append(signatures, signature)
 	}
 	return keysign.NewResponse(
@@ -0,0 +1,201 @@
+package tss
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akildemir/go-tss/keysign"
+)
+
+// keySignQueue admits the keysigns of the server, it runs up to maxRunning of them at the same time, the others
+// wait in the order of their priority and keep their arrival order within a priority. If serialize is set, the
+// keysigns of the same pool key run one after the other. The keysigns that do not fit in the queue are rejected
+// with a keysign.BusyError
+type keySignQueue struct {
+	lock       *sync.Mutex
+	maxRunning int
+	maxQueued  int
+	serialize  bool
+	running    int
+	runningFor map[string]int
+	waiting    []*keySignWaiter
+	seq        uint64
+	// avgDuration is the moving average of how long a keysign takes, it estimates the retry after
+	avgDuration time.Duration
+}
+
+type keySignWaiter struct {
+	msgID      string
+	poolPubKey string
+	priority   int
+	seq        uint64
+	queuedAt   time.Time
+	ready      chan struct{}
+}
+
+// newKeySignQueue create the keysign queue, the expected duration is used for the retry after until a keysign
+// finished
+func newKeySignQueue(maxRunning, maxQueued int, serialize bool, expectedDuration time.Duration) *keySignQueue {
+	return &keySignQueue{
+		lock:        &sync.Mutex{},
+		maxRunning:  maxRunning,
+		maxQueued:   maxQueued,
+		serialize:   serialize,
+		runningFor:  make(map[string]int),
+		avgDuration: expectedDuration,
+	}
+}
+
+// acquire wait until the keysign of the pool key can run, the returned func gives back its place once it finished
+func (q *keySignQueue) acquire(msgID, poolPubKey string, priority int, stopChan <-chan struct{}) (func(), error) {
+	q.lock.Lock()
+	q.seq++
+	waiter := &keySignWaiter{
+		msgID:      msgID,
+		poolPubKey: poolPubKey,
+		priority:   priority,
+		seq:        q.seq,
+		queuedAt:   time.Now().UTC(),
+		ready:      make(chan struct{}),
+	}
+	q.waiting = append(q.waiting, waiter)
+	sort.SliceStable(q.waiting, func(i, j int) bool {
+		if q.waiting[i].priority != q.waiting[j].priority {
+			return q.waiting[i].priority > q.waiting[j].priority
+		}
+		return q.waiting[i].seq < q.waiting[j].seq
+	})
+	q.dispatch()
+	select {
+	case <-waiter.ready:
+		q.lock.Unlock()
+		return q.releaseFunc(poolPubKey), nil
+	default:
+	}
+	if len(q.waiting) > q.maxQueued {
+		q.remove(waiter)
+		err := &keysign.BusyError{
+			RetryAfter: q.retryAfter(),
+			Queued:     len(q.waiting),
+		}
+		q.lock.Unlock()
+		return nil, err
+	}
+	q.lock.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return q.releaseFunc(poolPubKey), nil
+	case <-stopChan:
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		select {
+		case <-waiter.ready:
+			// we were started in the meantime, give the place to the next one
+			q.finish(poolPubKey)
+		default:
+			q.remove(waiter)
+		}
+		return nil, errors.New("received exit signal")
+	}
+}
+
+// queued return the number of the keysigns waiting in the queue
+func (q *keySignQueue) queued() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.waiting)
+}
+
+// stats return the number of the running and the waiting keysigns
+func (q *keySignQueue) stats() (int, int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.running, len(q.waiting)
+}
+
+// QueuedKeySign is a keysign waiting in the queue for its turn
+type QueuedKeySign struct {
+	MsgID      string    `json:"msg_id"`
+	PoolPubKey string    `json:"pool_pub_key"`
+	Priority   int       `json:"priority"`
+	QueuedAt   time.Time `json:"queued_at"`
+}
+
+// snapshot return the keysigns waiting in the queue in the order they are started
+func (q *keySignQueue) snapshot() []QueuedKeySign {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	ret := make([]QueuedKeySign, len(q.waiting))
+	for i, el := range q.waiting {
+		ret[i] = QueuedKeySign{
+			MsgID:      el.msgID,
+			PoolPubKey: el.poolPubKey,
+			Priority:   el.priority,
+			QueuedAt:   el.queuedAt,
+		}
+	}
+	return ret
+}
+
+func (q *keySignQueue) releaseFunc(poolPubKey string) func() {
+	started := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.lock.Lock()
+			defer q.lock.Unlock()
+			q.avgDuration = (q.avgDuration*3 + time.Since(started)) / 4
+			q.finish(poolPubKey)
+		})
+	}
+}
+
+func (q *keySignQueue) canRun(poolPubKey string) bool {
+	return q.running < q.maxRunning && (!q.serialize || q.runningFor[poolPubKey] == 0)
+}
+
+func (q *keySignQueue) start(poolPubKey string) {
+	q.running++
+	q.runningFor[poolPubKey]++
+}
+
+// finish take the keysign off the running ones and start the waiting keysigns that can run now
+func (q *keySignQueue) finish(poolPubKey string) {
+	q.running--
+	q.runningFor[poolPubKey]--
+	if q.runningFor[poolPubKey] <= 0 {
+		delete(q.runningFor, poolPubKey)
+	}
+	q.dispatch()
+}
+
+// dispatch start the waiting keysigns that can run in the order of the queue
+func (q *keySignQueue) dispatch() {
+	for i := 0; i < len(q.waiting) && q.running < q.maxRunning; {
+		waiter := q.waiting[i]
+		if !q.canRun(waiter.poolPubKey) {
+			i++
+			continue
+		}
+		q.start(waiter.poolPubKey)
+		q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+		close(waiter.ready)
+	}
+}
+
+func (q *keySignQueue) remove(waiter *keySignWaiter) {
+	for i, el := range q.waiting {
+		if el == waiter {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// retryAfter estimate how long it takes to run the keysigns in the queue
+func (q *keySignQueue) retryAfter() time.Duration {
+	return q.avgDuration * time.Duration(len(q.waiting)/q.maxRunning+1)
+}
@@ -0,0 +1,105 @@
+package tss
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/keysign"
+)
+
+type KeySignQueueTestSuite struct{}
+
+var _ = Suite(&KeySignQueueTestSuite{})
+
+// acquireAsync acquire the queue in the background and report the pool key once it is admitted
+func acquireAsync(q *keySignQueue, poolPubKey string, priority int, admitted chan<- string, stopChan chan struct{}) chan func() {
+	releases := make(chan func(), 1)
+	go func() {
+		release, err := q.acquire(poolPubKey, poolPubKey, priority, stopChan)
+		if err != nil {
+			close(releases)
+			return
+		}
+		admitted <- poolPubKey
+		releases <- release
+	}()
+	return releases
+}
+
+func waitQueued(c *C, q *keySignQueue, n int) {
+	for i := 0; i < 100 && q.queued() != n; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(q.queued(), Equals, n)
+}
+
+func (s *KeySignQueueTestSuite) TestPriority(c *C) {
+	stopChan := make(chan struct{})
+	q := newKeySignQueue(1, 3, false, time.Second)
+	release, err := q.acquire("", "pool", 0, stopChan)
+	c.Assert(err, IsNil)
+
+	admitted := make(chan string, 3)
+	low := acquireAsync(q, "low", 0, admitted, stopChan)
+	waitQueued(c, q, 1)
+	high := acquireAsync(q, "high", 10, admitted, stopChan)
+	waitQueued(c, q, 2)
+	later := acquireAsync(q, "later", 0, admitted, stopChan)
+	waitQueued(c, q, 3)
+
+	// the queue is full
+	_, err = q.acquire("", "pool", 0, stopChan)
+	var busy *keysign.BusyError
+	c.Assert(errors.As(err, &busy), Equals, true)
+	c.Assert(busy.Queued, Equals, 3)
+	c.Assert(busy.RetryAfter >= time.Second, Equals, true)
+
+	release()
+	c.Assert(<-admitted, Equals, "high")
+	(<-high)()
+	c.Assert(<-admitted, Equals, "low")
+	(<-low)()
+	c.Assert(<-admitted, Equals, "later")
+	(<-later)()
+	// releasing twice does not give back two places
+	release()
+	c.Assert(q.running, Equals, 0)
+}
+
+func (s *KeySignQueueTestSuite) TestSerialize(c *C) {
+	stopChan := make(chan struct{})
+	q := newKeySignQueue(2, 2, true, time.Second)
+	release, err := q.acquire("", "pool", 0, stopChan)
+	c.Assert(err, IsNil)
+
+	admitted := make(chan string, 2)
+	samePool := acquireAsync(q, "pool", 0, admitted, stopChan)
+	waitQueued(c, q, 1)
+	// the other pool does not wait for the keysign of the pool in the queue
+	otherPool := acquireAsync(q, "other", 0, admitted, stopChan)
+	c.Assert(<-admitted, Equals, "other")
+	(<-otherPool)()
+	waitQueued(c, q, 1)
+
+	release()
+	c.Assert(<-admitted, Equals, "pool")
+	(<-samePool)()
+}
+
+func (s *KeySignQueueTestSuite) TestStop(c *C) {
+	stopChan := make(chan struct{})
+	q := newKeySignQueue(1, 1, false, time.Second)
+	release, err := q.acquire("", "pool", 0, stopChan)
+	c.Assert(err, IsNil)
+	admitted := make(chan string, 1)
+	waiting := acquireAsync(q, "pool", 0, admitted, stopChan)
+	waitQueued(c, q, 1)
+	close(stopChan)
+	_, ok := <-waiting
+	c.Assert(ok, Equals, false)
+	c.Assert(q.queued(), Equals, 0)
+	release()
+	c.Assert(q.running, Equals, 0)
+}
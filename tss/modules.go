@@ -0,0 +1,198 @@
+package tss
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	tsslibcommon "github.com/binance-chain/tss-lib/common"
+	"github.com/binance-chain/tss-lib/crypto"
+	bkeygen "github.com/binance-chain/tss-lib/ecdsa/keygen"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/keyimport"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/monitor"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+	"github.com/akildemir/go-tss/webhook"
+)
+
+// Communication is the p2p layer the tss server runs the ceremonies over
+type Communication interface {
+	GetHost() host.Host
+	GetLocalPeerID() string
+	GetBroadcastChannel() chan *messages.BroadcastMsgChan
+	SetSubscribe(topic messages.THORChainTSSMessageType, msgID string, channel chan *p2p.Message)
+	CancelSubscribe(topic messages.THORChainTSSMessageType, msgID string)
+	ReleaseStream(msgID string)
+	CancelCeremony(msgID string)
+	ExportPeerAddress() map[peer.ID]p2p.AddrList
+	GetAttestationService() *p2p.AttestationService
+	GetChannelOccupancy() map[string]float64
+	GetCompressionStats() p2p.CompressionStats
+	GetDiscoveryState() p2p.DiscoveryEvent
+	GetConnectivity() p2p.Connectivity
+	Stats() p2p.BandwidthStats
+	GetPeerLatencies() map[peer.ID]p2p.PeerLatency
+	GetReputation() *p2p.Reputation
+	GetPeerStats() map[peer.ID]map[protocol.ID]p2p.ProtocolStats
+	GetPeerCapabilities() map[peer.ID]p2p.PeerCapability
+	GetCapabilities() p2p.Capabilities
+	SubscribePeerEvents(bufferSize int) (<-chan p2p.PeerEvent, func())
+	GetStreamHandlerStats() p2p.StreamHandlerStats
+	GetPeerStreams() map[peer.ID]int
+	GetDiagnostics() p2p.Diagnostics
+	DeliverMessage(peers []peer.ID, msg *messages.WrappedMessage) p2p.DeliveryReport
+	BanPeer(pID peer.ID, duration time.Duration) error
+	UnbanPeer(pID peer.ID) bool
+	QuarantineBlamed(pIDs []peer.ID) []peer.ID
+	Stop() error
+}
+
+// PartyCoordinator forms the parties of the ceremonies
+type PartyCoordinator interface {
+	JoinPartyWithRetry(ctx context.Context, msgID string, peers []string) ([]peer.ID, error)
+	JoinPartyWithLeader(msgID string, blockHeight int64, peers []string, threshold int, signChan chan string) ([]peer.ID, string, error)
+	JoinPartyWithLeaderExcept(ctx context.Context, msgID string, blockHeight int64, peers, excluded []string, threshold int, signChan chan string) ([]peer.ID, string, error)
+	SyncBarrier(token string, peers []peer.ID, timeout time.Duration) ([]peer.ID, error)
+	SetSignerSelector(selector p2p.SignerSelector)
+	JoinPartyReport(msgID string) *p2p.JoinPartyReport
+	ReleaseStream(msgID string)
+	Stop()
+}
+
+// SignatureNotifier shares the keysign signatures with the nodes that are not in the keysign party
+type SignatureNotifier interface {
+	WaitForSignature(ctx context.Context, messageID string, message [][]byte, poolPubKey string, timeout time.Duration, sigChan chan string) ([]*tsslibcommon.ECSignature, error)
+	BroadcastSignature(messageID string, sig []*tsslibcommon.ECSignature, peers []peer.ID) error
+	BroadcastFailed(messageID string, peers []peer.ID) error
+	ReleaseStream(msgID string)
+}
+
+// Metrics records the metrics of the ceremonies and the p2p layer
+type Metrics interface {
+	UpdateKeyGen(keygenTime time.Duration, success bool)
+	UpdateKeySign(keysignTime time.Duration, success bool)
+	KeygenJoinParty(joinpartyTime time.Duration, success bool)
+	KeysignJoinParty(joinpartyTime time.Duration, success bool)
+	UpdateChannelOccupancy(msgType string, occupancy float64)
+	UpdateP2PBytes(direction string, raw, wire int64)
+	UpdateCanary(canaryTime time.Duration, success bool)
+	UpdateCanaryParticipant(pubKey string, healthy bool)
+	UpdatePeerBandwidth(in, out map[string]int64)
+	UpdateProtocolBandwidth(in, out map[string]int64)
+	UpdatePeerProtocolStats(stats []monitor.PeerProtocolStat)
+	IncPeerEvent(eventType string)
+	UpdateStreamHandler(busy int64, queued int, rejected int64)
+	UpdateCeremony(ceremony, outcome string, duration time.Duration)
+	UpdateRoundLatency(ceremony, round string, latency time.Duration)
+	UpdatePeerStreams(streams map[string]int)
+	UpdateQueueDepth(queue string, running, queued int)
+	IncBlame(ceremony, reason, pubKey string)
+}
+
+// KeyImporter runs the trusted dealer key import
+type KeyImporter interface {
+	Expect(msgID string, dealer peer.ID, keys []string, signers int, localPubKey string, preParams *bkeygen.LocalPreParams) error
+	Wait(msgID string, timeout time.Duration) (*crypto.ECPoint, error)
+	Cancel(msgID string)
+	Deal(msgID string, keys []string, signers int, localPubKey string, preParams *bkeygen.LocalPreParams, privKey *big.Int, timeout time.Duration) (*crypto.ECPoint, error)
+	Stop()
+}
+
+// BlameNotifier delivers the blame events of the failed ceremonies to the slashing services
+type BlameNotifier interface {
+	Notify(ev blame.Event)
+	Stop()
+}
+
+// CeremonyNotifier delivers the outcome of the ceremonies to the monitoring and the incident tooling
+type CeremonyNotifier interface {
+	Notify(ev webhook.Event)
+	Stop()
+}
+
+var (
+	_ Communication     = &p2p.Communication{}
+	_ PartyCoordinator  = &p2p.PartyCoordinator{}
+	_ SignatureNotifier = &keysign.SignatureNotifier{}
+	_ Metrics           = &monitor.Metric{}
+	_ KeyImporter       = &keyimport.KeyImporter{}
+	_ BlameNotifier     = &blame.WebhookNotifier{}
+	_ CeremonyNotifier  = &webhook.Notifier{}
+)
+
+// Option replaces one of the modules the tss server is built from, the modules that are not replaced
+// are created with the defaults
+type Option func(*TssServer)
+
+// WithStateManager stores the local states with the given state manager instead of the files in the base folder
+func WithStateManager(stateManager storage.LocalStateManager) Option {
+	return func(t *TssServer) {
+		t.stateManager = stateManager
+	}
+}
+
+// WithPartyCoordinator forms the parties with the given party coordinator
+func WithPartyCoordinator(pc PartyCoordinator) Option {
+	return func(t *TssServer) {
+		t.partyCoordinator = pc
+	}
+}
+
+// WithSignatureNotifier shares the keysign signatures with the given signature notifier
+func WithSignatureNotifier(sn SignatureNotifier) Option {
+	return func(t *TssServer) {
+		t.signatureNotifier = sn
+	}
+}
+
+// WithMetrics records the metrics with the given metrics, EnableMonitor is ignored as the caller owns it
+func WithMetrics(metrics Metrics) Option {
+	return func(t *TssServer) {
+		t.tssMetrics = metrics
+	}
+}
+
+// WithKeyImporter runs the trusted dealer key import with the given key importer
+func WithKeyImporter(ki KeyImporter) Option {
+	return func(t *TssServer) {
+		t.keyImporter = ki
+	}
+}
+
+// WithKeySignPolicy asks the given policy before we take part in a keysign, the keysigns it vetoes fail with
+// keysign.ErrPolicyRejected
+func WithKeySignPolicy(policy keysign.Policy) Option {
+	return func(t *TssServer) {
+		t.keySignPolicy = policy
+	}
+}
+
+// WithBlameNotifier delivers the blame events with the given notifier instead of the webhook of BlameWebhookURL
+func WithBlameNotifier(bn BlameNotifier) Option {
+	return func(t *TssServer) {
+		t.blameNotifier = bn
+	}
+}
+
+// WithCeremonyNotifier delivers the outcome of the ceremonies with the given notifier instead of the webhooks of
+// Webhooks
+func WithCeremonyNotifier(cn CeremonyNotifier) Option {
+	return func(t *TssServer) {
+		t.ceremonyNotifier = cn
+	}
+}
+
+// WithArchivePassphrase encrypts the archives of the shares of the retired keys with the given passphrase, the
+// retired keys are only archived if it is set
+func WithArchivePassphrase(passphrase []byte) Option {
+	return func(t *TssServer) {
+		t.archivePassphrase = passphrase
+	}
+}
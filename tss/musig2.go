@@ -0,0 +1,84 @@
+package tss
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/schnorr"
+)
+
+// runMuSig2Keygen generate the n-of-n musig2 key of the parties, it has the join party of the keygen, and a single
+// round where each party broadcasts its pub key instead of the tss-lib rounds
+func (t *TssServer) runMuSig2Keygen(ctx context.Context, req keygen.Request, msgID string, stopChan chan struct{}) (keygen.Response, error) {
+	schnorrInstance := schnorr.NewTssSchnorr(
+		t.p2pCommunication.GetLocalPeerID(),
+		t.conf,
+		t.p2pCommunication.GetBroadcastChannel(),
+		stopChan,
+		msgID,
+	)
+	logContext := schnorrInstance.GetLogContext()
+	logContext.SetCapture(common.CeremonyLogFromContext(ctx))
+	ctx = logContext.WithContext(ctx)
+	logger := logContext.Logger("tss")
+	t.p2pCommunication.SetSubscribe(messages.TSSSchnorrMsg, msgID, schnorrInstance.GetMsgChannel())
+	defer func() {
+		t.p2pCommunication.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+		if ctx.Err() != nil {
+			t.p2pCommunication.CancelCeremony(msgID)
+		}
+		t.p2pCommunication.ReleaseStream(msgID)
+		t.partyCoordinator.ReleaseStream(msgID)
+	}()
+
+	sigChan := make(chan string)
+	joinPartyStartTime := time.Now()
+	onlinePeers, _, errJoinParty := t.joinParty(ctx, msgID, req.Version, req.BlockHeight, req.Keys, nil, len(req.Keys)-1, sigChan)
+	if errJoinParty != nil {
+		t.tssMetrics.KeygenJoinParty(time.Since(joinPartyStartTime), false)
+		t.tssMetrics.UpdateKeyGen(0, false)
+		blameMgr := blame.NewBlameManager()
+		blameNodes, err := blameMgr.NodeSyncBlame(req.Keys, onlinePeers)
+		if err != nil {
+			logger.Error().Err(err).Msg("fail to get peers to blame")
+		}
+		logger.Error().Err(errJoinParty).Msgf("fail to form musig2 keygen party with online:%v", onlinePeers)
+		return keygen.Response{
+			Status: common.Fail,
+			Blame:  blameNodes,
+		}, nil
+	}
+	t.tssMetrics.KeygenJoinParty(time.Since(joinPartyStartTime), true)
+
+	beforeKeygen := time.Now()
+	localState, err := schnorrInstance.GenerateMuSig2Key(req.Keys, t.localNodePubKey)
+	if err != nil {
+		t.tssMetrics.UpdateKeyGen(time.Since(beforeKeygen), false)
+		logger.Error().Err(err).Msg("err in musig2 keygen")
+		return keygen.NewResponse("", "", common.Fail, schnorrInstance.GetBlame()), err
+	}
+	t.tssMetrics.UpdateKeyGen(time.Since(beforeKeygen), true)
+	createdAt := time.Now().UTC()
+	localState.CreatedAt = &createdAt
+	if err := t.stateManager.SaveLocalState(localState); err != nil {
+		return keygen.Response{}, fmt.Errorf("fail to save keygen result to storage: %w", err)
+	}
+	if err := t.stateManager.SaveAddressBook(t.p2pCommunication.ExportPeerAddress()); err != nil {
+		logger.Error().Err(err).Msg("fail to save the peer addresses")
+	}
+
+	_, addr, err := conversion.GetTssPubKey(localState.LocalData.ECDSAPub)
+	if err != nil {
+		return keygen.Response{}, fmt.Errorf("fail to get the address of the musig2 key: %w", err)
+	}
+	resp := keygen.NewResponse(localState.PubKey, addr.String(), common.Success, blame.Blame{})
+	resp.Algo = localState.Algo
+	resp.Protocol = localState.Protocol
+	return resp, nil
+}
@@ -0,0 +1,68 @@
+package tss
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type MuSig2KeyTestSuite struct{}
+
+var _ = Suite(&MuSig2KeyTestSuite{})
+
+func (s *MuSig2KeyTestSuite) TestMuSig2Key(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	state.Protocol = conversion.SignProtocolMuSig2
+
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+	}
+
+	// the musig2 keys sign schnorr with all their participants
+	req := keysign.NewRequest(state.PubKey, []string{"aGVsbG8="}, 10, nil, messages.NEWJOINPARTYVERSION)
+	result, err := t.keySignModeOfKey(req)
+	c.Assert(err, IsNil)
+	c.Assert(result.Mode, Equals, keysign.SignModeSchnorr)
+	c.Assert(result.SignerPubKeys, DeepEquals, state.ParticipantKeys)
+	req.Mode = keysign.SignModeECDSA
+	_, err = t.keySignModeOfKey(req)
+	c.Assert(err, NotNil)
+	req.Mode = keysign.SignModeTaproot
+	req.UsePresignature = true
+	_, err = t.keySignModeOfKey(req)
+	c.Assert(err, Equals, ErrMuSig2Key)
+
+	req.UsePresignature = false
+	req.DerivationPath = "m/0/1"
+	_, err = t.getSigningState(req)
+	c.Assert(err, Equals, ErrMuSig2Key)
+
+	keygenReq := keygen.NewRequest(state.ParticipantKeys, 10, messages.NEWJOINPARTYVERSION)
+	keygenReq.Protocol = conversion.SignProtocolMuSig2
+	keygenReq.Threshold = len(state.ParticipantKeys) - 1
+	_, err = t.runKeygen(context.Background(), keygenReq)
+	c.Assert(err, ErrorMatches, "the musig2 keys need all the 4 parties to sign")
+	keygenReq.Threshold = 0
+	keygenReq.Weights = map[string]int{state.ParticipantKeys[0]: 2}
+	_, err = t.runKeygen(context.Background(), keygenReq)
+	c.Assert(err, NotNil)
+}
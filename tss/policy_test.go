@@ -0,0 +1,116 @@
+package tss
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type KeySignPolicyTestSuite struct{}
+
+var _ = Suite(&KeySignPolicyTestSuite{})
+
+func (s *KeySignPolicyTestSuite) TestKeySignPolicy(c *C) {
+	conversion.SetupBech32Prefix()
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	var checked []keysign.PolicyRequest
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+	}
+	WithKeySignPolicy(keysign.PolicyFunc(func(req keysign.PolicyRequest) error {
+		checked = append(checked, req)
+		if req.Metadata["tx"] != "verified" {
+			return errors.New("the transaction is not verified")
+		}
+		return nil
+	}))(t)
+
+	req := keysign.NewRequest(conversion.GetRandomPubKey(), []string{"aGVsbG8="}, 10, nil, messages.NEWJOINPARTYVERSION)
+	_, err = t.KeySignContext(context.Background(), req)
+	c.Assert(errors.Is(err, keysign.ErrPolicyRejected), Equals, true)
+	c.Assert(checked, HasLen, 1)
+	c.Assert(checked[0].PoolPubKey, Equals, req.PoolPubKey)
+	c.Assert(checked[0].Messages, DeepEquals, [][]byte{[]byte("hello")})
+
+	req.Metadata = map[string]string{"tx": "verified"}
+	c.Assert(t.checkKeySignPolicy(req), IsNil)
+	req.Messages = []string{"not base64"}
+	c.Assert(t.checkKeySignPolicy(req), NotNil)
+
+	// no policy, no veto
+	t.keySignPolicy = nil
+	c.Assert(t.checkKeySignPolicy(req), IsNil)
+}
+
+func (s *KeySignPolicyTestSuite) TestSignLedger(c *C) {
+	conversion.SetupBech32Prefix()
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+		signLedger:   keysign.NewLedger(stateManager, []string{"nonce"}, 0),
+	}
+	req := keysign.NewRequest(conversion.GetRandomPubKey(), []string{"aGVsbG8="}, 10, nil, messages.NEWJOINPARTYVERSION)
+	req.Metadata = map[string]string{"nonce": "1"}
+	c.Assert(t.recordKeySign(req), IsNil)
+	c.Assert(t.recordKeySign(req), IsNil)
+	records, err := stateManager.RetrieveSignRecords(req.PoolPubKey)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+
+	// the other message with the same nonce is refused before we join the party
+	req.Messages = []string{"d29ybGQ="}
+	_, err = t.KeySignContext(context.Background(), req)
+	c.Assert(errors.Is(err, keysign.ErrDoubleSign), Equals, true)
+}
+
+func (s *KeySignPolicyTestSuite) TestKeySignDigests(c *C) {
+	conversion.SetupBech32Prefix()
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	var checked []keysign.PolicyRequest
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+	}
+	WithKeySignPolicy(keysign.PolicyFunc(func(req keysign.PolicyRequest) error {
+		checked = append(checked, req)
+		return errors.New("no")
+	}))(t)
+
+	req := keysign.NewRequest(conversion.GetRandomPubKey(), []string{"aGVsbG8="}, 10, nil, messages.NEWJOINPARTYVERSION)
+	digestReq, err := t.keySignDigests(req)
+	c.Assert(err, IsNil)
+	c.Assert(digestReq, DeepEquals, req)
+
+	req.HashMode = keysign.HashModeSHA256
+	digestReq, err = t.keySignDigests(req)
+	c.Assert(err, IsNil)
+	c.Assert(digestReq.Messages, DeepEquals, []string{"LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="})
+	c.Assert(digestReq.HashMode, Equals, keysign.HashMode(""))
+
+	// the policy sees the messages of the request
+	_, err = t.KeySignContext(context.Background(), req)
+	c.Assert(errors.Is(err, keysign.ErrPolicyRejected), Equals, true)
+	c.Assert(checked[0].Messages, DeepEquals, [][]byte{[]byte("hello")})
+
+	// the invalid digests are refused before the policy
+	req.HashMode = keysign.HashModeSHA512
+	_, err = t.KeySignContext(context.Background(), req)
+	c.Assert(err, ErrorMatches, "fail to get the digests of the messages.*")
+	c.Assert(checked, HasLen, 1)
+}
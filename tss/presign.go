@@ -0,0 +1,106 @@
+package tss
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/schnorr"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// presignBatchSize is the number of the presignatures a presign ceremony generates, it is fixed so all the
+// participants agree on it whatever is left in their pool
+const presignBatchSize = 8
+
+// presignScheduler tops up the presignatures of PresignPoolPubKey at the start of every slot of PresignInterval
+func (t *TssServer) presignScheduler() {
+	interval := int64(t.conf.PresignInterval)
+	for {
+		slot := time.Now().UnixNano()/interval + 1
+		select {
+		case <-t.stopChan:
+			return
+		case <-time.After(time.Until(time.Unix(0, slot*interval))):
+		}
+		if err := t.runPresign(slot); err != nil {
+			t.logger.Error().Err(err).Msgf("fail to presign in slot %d", slot)
+		}
+	}
+}
+
+// runPresign runs a presign ceremony with all the participants of the pool if we are short of the
+// presignatures, it gives up the slot if we are busy with a real ceremony
+func (t *TssServer) runPresign(slot int64) error {
+	if atomic.LoadInt64(&t.activeCeremonies) > 0 {
+		t.logger.Info().Msgf("skip the presign of slot %d as we are busy with other ceremonies", slot)
+		return nil
+	}
+	poolPubKey := t.conf.PresignPoolPubKey
+	localState, err := t.getLocalState(poolPubKey)
+	if err != nil {
+		return fmt.Errorf("fail to get the local state of the presign key: %w", err)
+	}
+	defer storage.ZeroizeLocalState(&localState)
+	if localState.Weighted() {
+		return ErrWeightedKey
+	}
+	if localState.Protocol == conversion.SignProtocolMuSig2 {
+		return ErrMuSig2Key
+	}
+	count, err := t.presignPool.Count(poolPubKey, localState.ParticipantKeys)
+	if err != nil {
+		return err
+	}
+	if count >= t.conf.PresignPoolSize {
+		return nil
+	}
+	msgID, err := common.MsgToHashString([]byte(fmt.Sprintf("go-tss-presign-%s-%d", poolPubKey, slot)))
+	if err != nil {
+		return fmt.Errorf("fail to get the msg id of the presign: %w", err)
+	}
+	if err := t.acquireCeremony(); err != nil {
+		return err
+	}
+	defer t.releaseCeremony()
+
+	schnorrInstance := schnorr.NewTssSchnorr(
+		t.p2pCommunication.GetLocalPeerID(),
+		t.conf,
+		t.p2pCommunication.GetBroadcastChannel(),
+		t.stopChan,
+		msgID,
+	)
+	t.p2pCommunication.SetSubscribe(messages.TSSSchnorrMsg, msgID, schnorrInstance.GetMsgChannel())
+	defer func() {
+		t.p2pCommunication.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+		t.p2pCommunication.ReleaseStream(msgID)
+		t.partyCoordinator.ReleaseStream(msgID)
+	}()
+
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(localState.ParticipantKeys)
+	if err != nil {
+		return fmt.Errorf("fail to convert pub key to peer id: %w", err)
+	}
+	var peersIDStr []string
+	for _, el := range peerIDs {
+		peersIDStr = append(peersIDStr, el.String())
+	}
+	onlinePeers, err := t.partyCoordinator.JoinPartyWithRetry(context.Background(), msgID, peersIDStr)
+	if err != nil {
+		return fmt.Errorf("fail to form the presign party with online:%v: %w", onlinePeers, err)
+	}
+	presigs, err := schnorrInstance.Presign(localState, localState.ParticipantKeys, presignBatchSize)
+	if err != nil {
+		return fmt.Errorf("fail to presign: %w", err)
+	}
+	if err := t.presignPool.Add(poolPubKey, presigs); err != nil {
+		return fmt.Errorf("fail to add the presignatures to the pool: %w", err)
+	}
+	t.logger.Info().Msgf("presign of slot %d add %d presignatures", slot, len(presigs))
+	return nil
+}
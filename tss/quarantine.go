@@ -0,0 +1,59 @@
+package tss
+
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/conversion"
+)
+
+// quarantineBlamed count the failed ceremony against the nodes it blames, they are the nodes the quorum confirms if
+// the blame quorum is enabled, so a node that only we can not hear is not quarantined. The nodes blamed in too many
+// ceremonies are quarantined by the p2p layer
+func (t *TssServer) quarantineBlamed(b blame.Blame, q *blame.Quorum) {
+	if b.FailReason == blame.TssShutdown {
+		return
+	}
+	var blamed []string
+	if q != nil {
+		blamed = q.Confirmed
+	} else {
+		for _, el := range b.BlameNodes {
+			blamed = append(blamed, el.Pubkey)
+		}
+	}
+	pIDs := make([]peer.ID, 0, len(blamed))
+	for _, el := range blamed {
+		if el == t.localNodePubKey {
+			continue
+		}
+		pID, err := conversion.GetPeerIDFromPubKey(el)
+		if err != nil {
+			t.logger.Error().Err(err).Msgf("fail to convert the pub key(%s) to peer id", el)
+			continue
+		}
+		pIDs = append(pIDs, pID)
+	}
+	if len(pIDs) == 0 {
+		return
+	}
+	t.p2pCommunication.QuarantineBlamed(pIDs)
+}
+
+// quarantinedPubKeys return the pub keys of the quarantined peers, the leader of the keysign never picks them
+func (t *TssServer) quarantinedPubKeys() []string {
+	reputation := t.p2pCommunication.GetReputation()
+	if reputation == nil {
+		return nil
+	}
+	var pubKeys []string
+	for pID := range reputation.Quarantined() {
+		pk, err := conversion.GetPubKeyFromPeerID(pID.String())
+		if err != nil {
+			t.logger.Error().Err(err).Msgf("fail to get the pub key of peer %s", pID)
+			continue
+		}
+		pubKeys = append(pubKeys, pk)
+	}
+	return pubKeys
+}
@@ -0,0 +1,78 @@
+package tss
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+type QuarantineTestSuite struct{}
+
+var _ = Suite(&QuarantineTestSuite{})
+
+// quarantineComm is the Communication that keeps the peers it is told are blamed
+type quarantineComm struct {
+	Communication
+	reputation *p2p.Reputation
+	blamed     []peer.ID
+}
+
+func (q *quarantineComm) GetReputation() *p2p.Reputation {
+	return q.reputation
+}
+
+func (q *quarantineComm) QuarantineBlamed(pIDs []peer.ID) []peer.ID {
+	q.blamed = append(q.blamed, pIDs...)
+	return nil
+}
+
+func (s *QuarantineTestSuite) TestQuarantineBlamed(c *C) {
+	nodes := []blameViewNode{newBlameViewNode(c), newBlameViewNode(c), newBlameViewNode(c)}
+	comm := &quarantineComm{
+		reputation: p2p.NewReputation(p2p.ReputationConfig{
+			Quarantine: p2p.QuarantineConfig{Threshold: 1, Window: time.Hour, Cooldown: time.Hour},
+		}),
+	}
+	t := &TssServer{
+		logger:           log.Logger,
+		p2pCommunication: comm,
+		localNodePubKey:  nodes[0].pubKey,
+	}
+	b := blame.NewBlame(blame.TssTimeout, []blame.Node{
+		blame.NewNode(nodes[0].pubKey, nil, nil),
+		blame.NewNode(nodes[1].pubKey, nil, nil),
+	})
+	// we never quarantine ourselves
+	t.quarantineBlamed(b, nil)
+	c.Assert(comm.blamed, DeepEquals, []peer.ID{nodes[1].peerID})
+
+	// only the nodes the quorum confirms are counted
+	comm.blamed = nil
+	t.quarantineBlamed(b, &blame.Quorum{Confirmed: []string{nodes[2].pubKey}, Disputed: []string{nodes[1].pubKey}})
+	c.Assert(comm.blamed, DeepEquals, []peer.ID{nodes[2].peerID})
+	comm.blamed = nil
+	t.quarantineBlamed(b, &blame.Quorum{Disputed: []string{nodes[1].pubKey}})
+	c.Assert(comm.blamed, HasLen, 0)
+
+	// we blame no one if we shut down
+	t.quarantineBlamed(blame.NewBlame(blame.TssShutdown, b.BlameNodes), nil)
+	c.Assert(comm.blamed, HasLen, 0)
+
+	// the leader never picks the quarantined peers
+	c.Assert(t.quarantinedPubKeys(), HasLen, 0)
+	for _, el := range nodes[1:] {
+		_, ok := comm.reputation.RecordBlamed(el.peerID)
+		c.Assert(ok, Equals, true)
+	}
+	quarantined := t.quarantinedPubKeys()
+	sort.Strings(quarantined)
+	expected := []string{nodes[1].pubKey, nodes[2].pubKey}
+	sort.Strings(expected)
+	c.Assert(quarantined, DeepEquals, expected)
+}
@@ -0,0 +1,89 @@
+package tss
+
+import (
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// HealthReason is the machine-readable reason why the node can not take part in the ceremonies
+type HealthReason string
+
+const (
+	// HealthHostNotStarted the p2p host has not started yet
+	HealthHostNotStarted HealthReason = "host_not_started"
+	// HealthKeyshareStoreUnavailable we fail to list the keyshares in the store
+	HealthKeyshareStoreUnavailable HealthReason = "keyshare_store_unavailable"
+	// HealthDiscoveryPending we have neither joined the p2p network nor reached a signing quorum of the cached
+	// committee
+	HealthDiscoveryPending HealthReason = "discovery_pending"
+	// HealthIsolated we fail to stay connected to enough of the bootstrap peers and the committee members
+	HealthIsolated HealthReason = "isolated"
+	// HealthCommitteeUnreachable we are connected to less than a signing quorum of the cached committee
+	HealthCommitteeUnreachable HealthReason = "committee_unreachable"
+)
+
+// Health is the health and the readiness of the node, the node is healthy as long as the host runs and the keyshares
+// can be read, and it is ready once it can take part in the ceremonies
+type Health struct {
+	Healthy      bool                `json:"healthy"`
+	Ready        bool                `json:"ready"`
+	Reasons      []HealthReason      `json:"reasons,omitempty"`
+	Discovery    *p2p.DiscoveryEvent `json:"discovery,omitempty"`
+	Connectivity p2p.Connectivity    `json:"connectivity"`
+	// KeyshareStore is true if we can list the keyshares in the store, the stores that can not list the
+	// keyshares are taken as accessible
+	KeyshareStore bool   `json:"keyshare_store"`
+	KeyshareError string `json:"keyshare_error,omitempty"`
+	// PreParams is the number of the fresh keygen pre-parameters in the pool, out of PreParamsPoolSize
+	PreParams         int `json:"pre_params"`
+	PreParamsPoolSize int `json:"pre_params_pool_size,omitempty"`
+}
+
+// Evaluate return the health with the reasons why the node is not healthy or not ready, and the Healthy and the
+// Ready set accordingly
+func (h Health) Evaluate() Health {
+	h.Reasons = nil
+	if !h.Connectivity.HostStarted {
+		h.Reasons = append(h.Reasons, HealthHostNotStarted)
+	}
+	if !h.KeyshareStore {
+		h.Reasons = append(h.Reasons, HealthKeyshareStoreUnavailable)
+	}
+	h.Healthy = len(h.Reasons) == 0
+	switch {
+	case h.Discovery == nil:
+		h.Reasons = append(h.Reasons, HealthDiscoveryPending)
+	case h.Discovery.Isolated:
+		h.Reasons = append(h.Reasons, HealthIsolated)
+	case h.Discovery.State != p2p.DiscoveryReady && !h.Discovery.QuorumReachable:
+		h.Reasons = append(h.Reasons, HealthDiscoveryPending)
+	}
+	// we count ourselves in the quorum
+	if h.Connectivity.CommitteeQuorum > 0 && h.Connectivity.CommitteeConnected+1 < h.Connectivity.CommitteeQuorum {
+		h.Reasons = append(h.Reasons, HealthCommitteeUnreachable)
+	}
+	h.Ready = len(h.Reasons) == 0
+	return h
+}
+
+// GetHealth return the health and the readiness of the node, with the p2p connectivity, the state of the keyshare
+// store and the level of the pre-parameters pool
+func (t *TssServer) GetHealth() Health {
+	discovery := t.p2pCommunication.GetDiscoveryState()
+	health := Health{
+		Discovery:     &discovery,
+		Connectivity:  t.p2pCommunication.GetConnectivity(),
+		KeyshareStore: true,
+	}
+	if lister, ok := t.stateManager.(storage.LocalStateLister); ok {
+		if _, err := lister.ListLocalStates(); err != nil {
+			health.KeyshareStore = false
+			health.KeyshareError = err.Error()
+		}
+	}
+	if t.preParamsPool != nil {
+		health.PreParams = t.preParamsPool.Count()
+		health.PreParamsPoolSize = t.conf.PreParamsPoolSize
+	}
+	return health.Evaluate()
+}
@@ -0,0 +1,45 @@
+package tss
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/p2p"
+)
+
+type ReadinessTestSuite struct{}
+
+var _ = Suite(&ReadinessTestSuite{})
+
+func (s *ReadinessTestSuite) TestEvaluate(c *C) {
+	health := Health{}.Evaluate()
+	c.Assert(health.Healthy, Equals, false)
+	c.Assert(health.Ready, Equals, false)
+	c.Assert(health.Reasons, DeepEquals, []HealthReason{HealthHostNotStarted, HealthKeyshareStoreUnavailable, HealthDiscoveryPending})
+
+	health = Health{
+		Discovery:     &p2p.DiscoveryEvent{State: p2p.DiscoveryReady},
+		Connectivity:  p2p.Connectivity{HostStarted: true},
+		KeyshareStore: true,
+	}.Evaluate()
+	c.Assert(health.Healthy, Equals, true)
+	c.Assert(health.Ready, Equals, true)
+	c.Assert(health.Reasons, HasLen, 0)
+
+	// we can sign with the cached committee while the DHT bootstrap is still retrying
+	health.Discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryDegraded, QuorumReachable: true}
+	c.Assert(health.Evaluate().Ready, Equals, true)
+
+	health.Discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryReady, Isolated: true}
+	health = health.Evaluate()
+	c.Assert(health.Healthy, Equals, true)
+	c.Assert(health.Ready, Equals, false)
+	c.Assert(health.Reasons, DeepEquals, []HealthReason{HealthIsolated})
+
+	health.Discovery = &p2p.DiscoveryEvent{State: p2p.DiscoveryReady}
+	health.Connectivity = p2p.Connectivity{HostStarted: true, CommitteePeers: 3, CommitteeConnected: 1, CommitteeQuorum: 3}
+	health = health.Evaluate()
+	c.Assert(health.Ready, Equals, false)
+	c.Assert(health.Reasons, DeepEquals, []HealthReason{HealthCommitteeUnreachable})
+	health.Connectivity.CommitteeConnected = 2
+	c.Assert(health.Evaluate().Ready, Equals, true)
+}
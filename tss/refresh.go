@@ -0,0 +1,71 @@
+package tss
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// refreshScheduler refreshes the shares of RefreshPoolPubKey at the start of every slot of RefreshInterval
+func (t *TssServer) refreshScheduler() {
+	interval := int64(t.conf.RefreshInterval)
+	for {
+		slot := time.Now().UnixNano()/interval + 1
+		select {
+		case <-t.stopChan:
+			return
+		case <-time.After(time.Until(time.Unix(0, slot*interval))):
+		}
+		if err := t.runRefresh(slot); err != nil {
+			t.logger.Error().Err(err).Msgf("fail to refresh the shares in slot %d", slot)
+		}
+	}
+}
+
+// runRefresh reshares the key of the pool among all the participants of the pool, the pub key stays the same
+// while the shares are replaced, so the shares an attacker has got before the refresh are useless afterwards.
+// It gives up the slot if we are busy with a real ceremony
+func (t *TssServer) runRefresh(slot int64) error {
+	if atomic.LoadInt64(&t.activeCeremonies) > 0 {
+		t.logger.Info().Msgf("skip the refresh of slot %d as we are busy with other ceremonies", slot)
+		return nil
+	}
+	poolPubKey := t.conf.RefreshPoolPubKey
+	localState, err := t.getLocalState(poolPubKey)
+	if err != nil {
+		return fmt.Errorf("fail to get the local state of the refresh key: %w", err)
+	}
+	defer storage.ZeroizeLocalState(&localState)
+	msgID, err := common.MsgToHashString([]byte(fmt.Sprintf("go-tss-refresh-%s-%d", poolPubKey, slot)))
+	if err != nil {
+		return fmt.Errorf("fail to get the msg id of the refresh: %w", err)
+	}
+	if err := t.acquireCeremony(); err != nil {
+		return err
+	}
+	req := reshare.NewRefreshRequest(poolPubKey, localState.ParticipantKeys, slot, "")
+	// the refresh keeps the threshold of the key
+	req.Threshold = localState.Threshold
+	startTime := time.Now()
+	resp, err := t.reshare(context.Background(), req, localState.ParticipantKeys, msgID)
+	t.releaseCeremony()
+	t.observeCeremony("refresh", startTime, resp.Status, false)
+	t.recordReputation(resp.Status, resp.Blame, localState.ParticipantKeys)
+	if resp.Status == common.Fail {
+		t.reportBlame("refresh", msgID, poolPubKey, resp.Blame)
+		t.quarantineBlamed(resp.Blame, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to refresh the shares: %w", err)
+	}
+	if resp.Status != common.Success {
+		return fmt.Errorf("fail to refresh the shares, blame: %s", resp.Blame.String())
+	}
+	t.logger.Info().Msgf("refresh the shares of %s in slot %d", poolPubKey, slot)
+	return nil
+}
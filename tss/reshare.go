@@ -0,0 +1,130 @@
+package tss
+
+import (
+	"context"
+	"time"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/reshare"
+)
+
+// Reshare move the key of the pool to the new committee, the members of both committees should call it
+func (t *TssServer) Reshare(req reshare.Request) (reshare.Response, error) {
+	members := reshareMembers(req)
+	startTime := time.Now()
+	var views *blameViews
+	if msgID, err := t.requestToMsgId(req); err == nil {
+		var unsubscribe func()
+		views, unsubscribe = t.subscribeBlameViews(msgID, len(members))
+		defer unsubscribe()
+	}
+	// the failure response carries the last log lines of the resharing
+	capture := common.NewCeremonyLog(t.conf.CeremonyLogLines)
+	resp, err := t.runReshare(common.WithCeremonyLog(context.Background(), capture), req, members)
+	resp.ErrorCode = errorCode(resp.Status, resp.Blame, err)
+	if resp.Status == common.Fail {
+		resp.Logs = capture.Lines()
+	}
+	t.observeCeremony("reshare", startTime, resp.Status, false)
+	t.recordReputation(resp.Status, resp.Blame, members)
+	if resp.Status == common.Fail {
+		if msgID, errID := t.requestToMsgId(req); errID == nil {
+			resp.Verdict = t.reportBlame("reshare", msgID, req.PoolPubKey, resp.Blame)
+			resp.BlameQuorum = t.confirmBlame(views, "reshare", req.PoolPubKey, resp.Verdict, members)
+			t.quarantineBlamed(resp.Blame, resp.BlameQuorum)
+		}
+	}
+	return resp, err
+}
+
+// reshareMembers return the members of either committee, a member of both is listed once
+func reshareMembers(req reshare.Request) []string {
+	seen := make(map[string]bool)
+	var members []string
+	for _, el := range append(append([]string{}, req.OldPartyKeys...), req.NewPartyKeys...) {
+		if seen[el] {
+			continue
+		}
+		seen[el] = true
+		members = append(members, el)
+	}
+	return members
+}
+
+func (t *TssServer) runReshare(ctx context.Context, req reshare.Request, members []string) (reshare.Response, error) {
+	if err := t.acquireCeremony(); err != nil {
+		return reshare.Response{}, err
+	}
+	defer t.releaseCeremony()
+	msgID, err := t.requestToMsgId(req)
+	if err != nil {
+		return reshare.Response{}, err
+	}
+	return t.reshare(ctx, req, members, msgID)
+}
+
+// reshare form the party of the members of both committees and run the resharing, the caller holds the
+// ceremony slot
+func (t *TssServer) reshare(ctx context.Context, req reshare.Request, members []string, msgID string) (reshare.Response, error) {
+	reshareInstance := reshare.NewTssReshare(
+		t.localNodePubKey,
+		t.conf,
+		t.p2pCommunication.GetBroadcastChannel(),
+		t.stopChan,
+		t.getPreParams(),
+		msgID,
+		t.stateManager,
+	)
+	logContext := reshareInstance.GetLogContext()
+	logContext.SetCapture(common.CeremonyLogFromContext(ctx))
+	ctx = logContext.WithContext(ctx)
+	logger := logContext.Logger("tss")
+	t.p2pCommunication.SetSubscribe(messages.TSSReshareMsg, msgID, reshareInstance.GetMsgChannel())
+	defer func() {
+		t.p2pCommunication.CancelSubscribe(messages.TSSReshareMsg, msgID)
+		t.p2pCommunication.ReleaseStream(msgID)
+		t.partyCoordinator.ReleaseStream(msgID)
+	}()
+
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(members)
+	if err != nil {
+		return reshare.Response{}, err
+	}
+	var peersIDStr []string
+	for _, el := range peerIDs {
+		peersIDStr = append(peersIDStr, el.String())
+	}
+	joinPartyStartTime := time.Now()
+	onlinePeers, errJoinParty := t.partyCoordinator.JoinPartyWithRetry(ctx, msgID, peersIDStr)
+	if errJoinParty != nil {
+		t.tssMetrics.KeygenJoinParty(time.Since(joinPartyStartTime), false)
+		blameMgr := blame.NewBlameManager()
+		blameNodes, err := blameMgr.NodeSyncBlame(members, onlinePeers)
+		if err != nil {
+			logger.Error().Err(err).Msg("fail to get peers to blame")
+		}
+		logger.Error().Err(errJoinParty).Msgf("fail to form reshare party with online:%v", onlinePeers)
+		return reshare.Response{
+			Status:    common.Fail,
+			Blame:     blameNodes,
+			JoinParty: t.partyCoordinator.JoinPartyReport(msgID),
+		}, nil
+	}
+	t.tssMetrics.KeygenJoinParty(time.Since(joinPartyStartTime), true)
+	logContext.SetPeers(onlinePeers)
+
+	k, err := reshareInstance.Reshare(req)
+	if err != nil {
+		logger.Error().Err(err).Msg("err in reshare")
+		return reshare.NewResponse("", "", common.Fail, reshareInstance.GetBlame()), err
+	}
+	pubKey, addr, err := conversion.GetTssPubKey(k)
+	if err != nil {
+		logger.Error().Err(err).Msg("fail to get the pool pub key")
+		return reshare.NewResponse("", "", common.Fail, blame.Blame{}), nil
+	}
+	return reshare.NewResponse(pubKey, addr.String(), common.Success, blame.Blame{}), nil
+}
@@ -0,0 +1,82 @@
+package tss
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akildemir/go-tss/storage"
+)
+
+// retentionCheckInterval defines how often we look for the retired keys to archive
+const retentionCheckInterval = time.Hour
+
+// checkRetention verify the retired keys can be archived, the state manager has to list and delete the shares
+func (t *TssServer) checkRetention() error {
+	if len(t.conf.RetiredKeyArchiveFolder) == 0 {
+		return errors.New("the archive folder of the retired keys is not set")
+	}
+	if len(t.archivePassphrase) == 0 {
+		return errors.New("the archive passphrase of the retired keys is not set")
+	}
+	if _, ok := t.stateManager.(storage.LocalStateLister); !ok {
+		return errors.New("the state manager can not list the keys to archive")
+	}
+	if _, ok := t.stateManager.(storage.KeyDeleter); !ok {
+		return errors.New("the state manager can not delete the keys it archives")
+	}
+	return nil
+}
+
+func (t *TssServer) retentionScheduler() {
+	for {
+		if _, err := t.PruneRetiredKeys(); err != nil {
+			t.logger.Error().Err(err).Msg("fail to archive the retired keys")
+		}
+		select {
+		case <-t.stopChan:
+			return
+		case <-time.After(retentionCheckInterval):
+		}
+	}
+}
+
+// PruneRetiredKeys archive the shares of the keys retired for longer than the retention and remove them, it
+// return the pub keys it has archived. The share is only removed once its archive is written, the archive restores
+// it with storage.RestoreArchivedShare
+func (t *TssServer) PruneRetiredKeys() ([]string, error) {
+	if err := t.checkRetention(); err != nil {
+		return nil, err
+	}
+	lister := t.stateManager.(storage.LocalStateLister)
+	deleter := t.stateManager.(storage.KeyDeleter)
+	pubKeys, err := lister.ListLocalStates()
+	if err != nil {
+		return nil, fmt.Errorf("fail to list the local states: %w", err)
+	}
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	var archived []string
+	for _, el := range pubKeys {
+		localState, err := t.stateManager.GetLocalState(el)
+		if err != nil {
+			t.logger.Error().Err(err).Str("pool pub key", el).Msg("fail to get the local state of the key")
+			continue
+		}
+		retired := localState.Retired() && time.Since(*localState.RetiredAt) >= t.conf.RetiredKeyRetention
+		storage.ZeroizeLocalState(&localState)
+		if !retired {
+			continue
+		}
+		filePathName, err := storage.ArchiveShare(t.stateManager, t.conf.RetiredKeyArchiveFolder, el, t.archivePassphrase)
+		if err != nil {
+			return archived, fmt.Errorf("fail to archive the share of %s: %w", el, err)
+		}
+		if err := deleter.DeleteLocalState(el); err != nil {
+			return archived, fmt.Errorf("fail to delete the local state of %s: %w", el, err)
+		}
+		t.logger.Info().Str("pool pub key", el).Msgf("the share of the retired key is archived to %s", filePathName)
+		archived = append(archived, el)
+	}
+	return archived, nil
+}
@@ -0,0 +1,67 @@
+package tss
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type RetentionTestSuite struct{}
+
+var _ = Suite(&RetentionTestSuite{})
+
+func (s *RetentionTestSuite) TestPruneRetiredKeys(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	folder := filepath.Join(c.MkDir(), "archive")
+	t := &TssServer{
+		conf:         common.TssConfig{RetiredKeyRetention: time.Hour, RetiredKeyArchiveFolder: folder},
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+	}
+	_, err = t.PruneRetiredKeys()
+	c.Assert(err, NotNil)
+	t.archivePassphrase = []byte("passphrase")
+
+	// the key is not retired
+	archived, err := t.PruneRetiredKeys()
+	c.Assert(err, IsNil)
+	c.Assert(archived, HasLen, 0)
+	_, err = t.RetireKey(state.PubKey)
+	c.Assert(err, IsNil)
+	// the retention is not over yet
+	archived, err = t.PruneRetiredKeys()
+	c.Assert(err, IsNil)
+	c.Assert(archived, HasLen, 0)
+
+	t.conf.RetiredKeyRetention = time.Nanosecond
+	archived, err = t.PruneRetiredKeys()
+	c.Assert(err, IsNil)
+	c.Assert(archived, DeepEquals, []string{state.PubKey})
+	_, err = stateManager.GetLocalState(state.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	restored, err := storage.RestoreArchivedShare(stateManager, folder, state.PubKey, []byte("passphrase"))
+	c.Assert(err, IsNil)
+	c.Assert(restored.Retired(), Equals, false)
+	archived, err = t.PruneRetiredKeys()
+	c.Assert(err, IsNil)
+	c.Assert(archived, HasLen, 0)
+}
@@ -0,0 +1,99 @@
+package tss
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// ErrKeyRetired is returned when we are asked to sign with a retired key
+var ErrKeyRetired = errcode.New(errcode.KeyRetired, "the pool key is retired")
+
+// KeyRetirement is the outcome of the retirement of a key, the confirmation token is only returned once, the
+// deletion of the share needs it
+type KeyRetirement struct {
+	PoolPubKey        string    `json:"pool_pub_key"`
+	RetiredAt         time.Time `json:"retired_at"`
+	DeletableAt       time.Time `json:"deletable_at"`
+	ConfirmationToken string    `json:"confirmation_token"`
+}
+
+func hashDeletionToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// RetireKey mark the key as retired, it refuses any further keysign. Only the hash of the confirmation token is
+// saved, the caller has to keep the token to delete the share once the cooling-off period is over
+func (t *TssServer) RetireKey(poolPubKey string) (KeyRetirement, error) {
+	if len(poolPubKey) == 0 {
+		return KeyRetirement{}, errcode.New(errcode.BadRequest, "empty pool pub key")
+	}
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	localState, err := t.getLocalState(poolPubKey)
+	if err != nil {
+		return KeyRetirement{}, fmt.Errorf("fail to get the local state of the key: %w", err)
+	}
+	if localState.Retired() {
+		return KeyRetirement{}, fmt.Errorf("%w at %s", ErrKeyRetired, localState.RetiredAt)
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return KeyRetirement{}, fmt.Errorf("fail to generate the confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	retiredAt := time.Now().UTC()
+	localState.RetiredAt = &retiredAt
+	localState.DeletionTokenHash = hashDeletionToken(token)
+	if err := t.stateManager.SaveLocalState(localState); err != nil {
+		return KeyRetirement{}, fmt.Errorf("fail to save the local state of the key: %w", err)
+	}
+	t.logger.Info().Str("pool pub key", poolPubKey).Msg("the key is retired")
+	return KeyRetirement{
+		PoolPubKey:        poolPubKey,
+		RetiredAt:         retiredAt,
+		DeletableAt:       retiredAt.Add(t.conf.KeyDeletionCoolingOff),
+		ConfirmationToken: token,
+	}, nil
+}
+
+// DeleteKey delete the share of the retired key, it needs the confirmation token of the retirement and the
+// cooling-off period to be over. The share is overwritten before it is removed, it can not be recovered
+func (t *TssServer) DeleteKey(poolPubKey, confirmationToken string) error {
+	if len(poolPubKey) == 0 {
+		return errcode.New(errcode.BadRequest, "empty pool pub key")
+	}
+	deleter, ok := t.stateManager.(storage.KeyDeleter)
+	if !ok {
+		return errors.New("the state manager can not delete the keys")
+	}
+	t.retireLock.Lock()
+	defer t.retireLock.Unlock()
+	localState, err := t.getLocalState(poolPubKey)
+	if err != nil {
+		return fmt.Errorf("fail to get the local state of the key: %w", err)
+	}
+	if !localState.Retired() {
+		return errcode.New(errcode.BadRequest, "the pool key is not retired")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashDeletionToken(confirmationToken)), []byte(localState.DeletionTokenHash)) != 1 {
+		return errcode.New(errcode.BadRequest, "invalid confirmation token")
+	}
+	deletableAt := localState.RetiredAt.Add(t.conf.KeyDeletionCoolingOff)
+	if time.Now().Before(deletableAt) {
+		return errcode.Errorf(errcode.BadRequest, "the pool key can not be deleted before %s", deletableAt)
+	}
+	if err := deleter.DeleteLocalState(poolPubKey); err != nil {
+		return fmt.Errorf("fail to delete the local state of the key: %w", err)
+	}
+	t.logger.Info().Str("pool pub key", poolPubKey).Msg("the share of the retired key is deleted")
+	return nil
+}
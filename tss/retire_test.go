@@ -0,0 +1,63 @@
+package tss
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type RetireTestSuite struct{}
+
+var _ = Suite(&RetireTestSuite{})
+
+func (s *RetireTestSuite) TestRetireKey(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	t := &TssServer{
+		conf:         common.TssConfig{KeyDeletionCoolingOff: time.Hour},
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+	}
+	_, err = t.RetireKey("")
+	c.Assert(err, NotNil)
+	retirement, err := t.RetireKey(state.PubKey)
+	c.Assert(err, IsNil)
+	c.Assert(retirement.PoolPubKey, Equals, state.PubKey)
+	c.Assert(retirement.DeletableAt.Sub(retirement.RetiredAt), Equals, time.Hour)
+	c.Assert(retirement.ConfirmationToken, Not(Equals), "")
+	_, err = t.RetireKey(state.PubKey)
+	c.Assert(err, NotNil)
+
+	req := keysign.NewRequest(state.PubKey, []string{"aGVsbG8="}, 10, nil, messages.NEWJOINPARTYVERSION)
+	_, err = t.getSigningState(req)
+	c.Assert(err, Equals, ErrKeyRetired)
+
+	c.Assert(t.DeleteKey(state.PubKey, "whatever"), NotNil)
+	// the cooling-off period is not over yet
+	c.Assert(t.DeleteKey(state.PubKey, retirement.ConfirmationToken), NotNil)
+	t.conf.KeyDeletionCoolingOff = 0
+	c.Assert(t.DeleteKey(state.PubKey, retirement.ConfirmationToken), IsNil)
+	_, err = stateManager.GetLocalState(state.PubKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = t.RetireKey(state.PubKey)
+	c.Assert(errors.Is(err, ErrShareNotFound), Equals, true)
+}
@@ -0,0 +1,162 @@
+package tss
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/schnorr"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// runSchnorrKeySign sign the messages with the BIP-340 schnorr signatures, only the signers of the request take
+// part, so all of them should call it and they should all be online
+func (t *TssServer) runSchnorrKeySign(ctx context.Context, req keysign.Request, msgID string) (keysign.Response, error) {
+	emptyResp := keysign.Response{}
+	var merkleRoot []byte
+	if req.Mode == keysign.SignModeTaproot && len(req.TaprootMerkleRoot) > 0 {
+		var err error
+		merkleRoot, err = hex.DecodeString(req.TaprootMerkleRoot)
+		if err != nil {
+			return emptyResp, fmt.Errorf("fail to decode the taproot merkle root: %w", err)
+		}
+	}
+	localStateItem, err := t.getSigningState(req)
+	if err != nil {
+		return emptyResp, err
+	}
+	defer storage.ZeroizeLocalState(&localStateItem)
+	if err := checkKeyAlgo(req, localStateItem); err != nil {
+		return emptyResp, err
+	}
+	if localStateItem.Algo.OrDefault() != conversion.AlgoSecp256k1 {
+		return emptyResp, errors.New("the schnorr keysign needs a secp256k1 key")
+	}
+	if localStateItem.Weighted() {
+		return emptyResp, ErrWeightedKey
+	}
+	var msgsToSign [][]byte
+	for _, val := range req.Messages {
+		msgToSign, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return emptyResp, fmt.Errorf("fail to decode message(%s): %w", strings.Join(req.Messages, ","), err)
+		}
+		msgsToSign = append(msgsToSign, msgToSign)
+	}
+	if !t.isPartOfKeysignParty(req.SignerPubKeys) {
+		return emptyResp, errors.New("we are not a signer of the schnorr keysign")
+	}
+
+	stopChan, releaseStopChan := t.ceremonyStopChan(ctx)
+	defer releaseStopChan()
+	schnorrInstance := schnorr.NewTssSchnorr(
+		t.p2pCommunication.GetLocalPeerID(),
+		t.conf,
+		t.p2pCommunication.GetBroadcastChannel(),
+		stopChan,
+		msgID,
+	)
+	logContext := schnorrInstance.GetLogContext()
+	logContext.SetCapture(common.CeremonyLogFromContext(ctx))
+	ctx = logContext.WithContext(ctx)
+	logger := logContext.Logger("tss")
+	t.p2pCommunication.SetSubscribe(messages.TSSSchnorrMsg, msgID, schnorrInstance.GetMsgChannel())
+	defer func() {
+		t.p2pCommunication.CancelSubscribe(messages.TSSSchnorrMsg, msgID)
+		if ctx.Err() != nil {
+			t.p2pCommunication.CancelCeremony(msgID)
+		}
+		t.p2pCommunication.ReleaseStream(msgID)
+		t.partyCoordinator.ReleaseStream(msgID)
+	}()
+
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(req.SignerPubKeys)
+	if err != nil {
+		return emptyResp, fmt.Errorf("fail to convert pub key to peer id: %w", err)
+	}
+	var peersIDStr []string
+	for _, el := range peerIDs {
+		peersIDStr = append(peersIDStr, el.String())
+	}
+	joinPartyStartTime := time.Now()
+	onlinePeers, errJoinParty := t.partyCoordinator.JoinPartyWithRetry(ctx, msgID, peersIDStr)
+	if errJoinParty != nil {
+		t.tssMetrics.KeysignJoinParty(time.Since(joinPartyStartTime), false)
+		blameMgr := blame.NewBlameManager()
+		blameNodes, err := blameMgr.NodeSyncBlame(req.SignerPubKeys, onlinePeers)
+		if err != nil {
+			logger.Error().Err(err).Msg("fail to get peers to blame")
+		}
+		logger.Error().Err(errJoinParty).Msgf("fail to form schnorr keysign party with online:%v", onlinePeers)
+		return keysign.Response{
+			Status:    common.Fail,
+			Blame:     blameNodes,
+			JoinParty: t.partyCoordinator.JoinPartyReport(msgID),
+		}, nil
+	}
+	t.tssMetrics.KeysignJoinParty(time.Since(joinPartyStartTime), true)
+
+	keysignStartTime := time.Now()
+	var sigs [][]byte
+	var xOnlyPubKey []byte
+	if req.UsePresignature {
+		// the presignatures are taken out of the pool before we send any partial signature, so they are never
+		// used twice even if the keysign fails
+		presigs, errTake := t.presignPool.Take(req.PoolPubKey, req.SignerPubKeys, len(msgsToSign))
+		if errTake != nil {
+			return emptyResp, fmt.Errorf("fail to take the presignatures: %w", errTake)
+		}
+		sigs, xOnlyPubKey, err = schnorrInstance.SignMessagesWithPresignatures(msgsToSign, localStateItem, presigs, req.Mode == keysign.SignModeTaproot, merkleRoot)
+	} else {
+		sigs, xOnlyPubKey, err = schnorrInstance.SignMessages(msgsToSign, localStateItem, req.SignerPubKeys, req.Mode == keysign.SignModeTaproot, merkleRoot)
+	}
+	if err != nil {
+		t.tssMetrics.UpdateKeySign(time.Since(keysignStartTime), false)
+		logger.Error().Err(err).Msg("err in schnorr keysign")
+		return keysign.Response{
+			Status: common.Fail,
+			Blame:  schnorrInstance.GetBlame(),
+		}, nil
+	}
+	// we never hand out a signature that does not verify
+	for i, sig := range sigs {
+		if !schnorr.Verify(xOnlyPubKey, msgsToSign[i], sig) {
+			t.tssMetrics.UpdateKeySign(time.Since(keysignStartTime), false)
+			logger.Error().Msgf("the schnorr keysign produced an invalid signature of message %s", req.Messages[i])
+			return keysign.Response{
+				Status: common.Fail,
+				Blame:  blame.NewBlame(blame.InvalidSig, nil),
+			}, fmt.Errorf("%w: message %s", keysign.ErrInvalidSignature, req.Messages[i])
+		}
+	}
+	t.tssMetrics.UpdateKeySign(time.Since(keysignStartTime), true)
+
+	var signatures []keysign.Signature
+	for i, sig := range sigs {
+		signature := keysign.NewSignature(
+			req.Messages[i],
+			base64.StdEncoding.EncodeToString(sig[:32]),
+			base64.StdEncoding.EncodeToString(sig[32:]),
+			"",
+		)
+		// the BIP-340 signature is the compact encoding already
+		if req.Encoding == keysign.SignatureEncodingCompact {
+			signature.Encoded = base64.StdEncoding.EncodeToString(sig)
+		}
+		signatures = append(signatures, signature)
+	}
+	resp := keysign.NewResponse(signatures, common.Success, blame.Blame{})
+	resp.XOnlyPubKey = hex.EncodeToString(xOnlyPubKey)
+	resp.Signers = req.SignerPubKeys
+	resp.Algo = localStateItem.Algo.OrDefault()
+	return resp, nil
+}
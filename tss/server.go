@@ -1,8 +1,14 @@
 package tss
 
 import (
+	"context"
+	"time"
+
 	"github.com/akildemir/go-tss/keygen"
 	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/storage"
 )
 
 // Server define the necessary functionality should be provide by a TSS Server implementation
@@ -11,5 +17,25 @@ type Server interface {
 	Stop()
 	GetLocalPeerID() string
 	Keygen(req keygen.Request) (keygen.Response, error)
+	KeygenContext(ctx context.Context, req keygen.Request) (keygen.Response, error)
 	KeySign(req keysign.Request) (keysign.Response, error)
+	KeySignContext(ctx context.Context, req keysign.Request) (keysign.Response, error)
+	Reshare(req reshare.Request) (reshare.Response, error)
+	RetireKey(poolPubKey string) (KeyRetirement, error)
+	DeleteKey(poolPubKey, confirmationToken string) error
+	CheckKeyHealth(poolPubKey string, blockHeight int64) (KeyHealth, error)
+	ListKeys() ([]KeyInfo, error)
+	GetStatus() Status
+	GetHealth() Health
+	GetPeerStats() map[string]map[string]p2p.ProtocolStats
+	SubscribeCeremonyEvents(bufferSize int) (<-chan CeremonyEvent, func())
+	GetPeers() []PeerInfo
+	BanPeer(peerID string, duration time.Duration) error
+	UnbanPeer(peerID string) (bool, error)
+	GetActiveCeremonies() []storage.CeremonyRecord
+	GetQueuedKeySigns() []QueuedKeySign
+	CancelCeremony(msgID string) error
+	GetDiagnostics() Diagnostics
+	GetVersion() VersionInfo
+	ExportAuditLog(fromSeq uint64) ([]storage.AuditRecord, error)
 }
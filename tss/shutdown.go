@@ -0,0 +1,87 @@
+package tss
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+// ErrShuttingDown is returned for the ceremonies requested once we started to stop
+var ErrShuttingDown = errcode.New(errcode.ShuttingDown, "the tss server is shutting down")
+
+const (
+	// drainPollInterval is how often we check whether the in-flight ceremonies have finished
+	drainPollInterval = 100 * time.Millisecond
+	// ceremonyUnwindTimeout is how long we wait for the aborted ceremonies to tear down their parties
+	ceremonyUnwindTimeout = 5 * time.Second
+)
+
+// draining return true once we started to stop
+func (t *TssServer) draining() bool {
+	select {
+	case <-t.drainChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain refuse the new ceremonies and wait up to the drain timeout for the in-flight ones to finish, the ones still
+// running by then are aborted, the peers are told we shut down so they neither wait for the timeout nor slash us
+func (t *TssServer) drain() {
+	close(t.drainChan)
+	if t.waitCeremonies(t.conf.DrainTimeout) {
+		return
+	}
+	records := t.ceremonyJournal.active()
+	t.logger.Warn().Msgf("%d ceremonies are still running after the drain timeout, abort them", len(records))
+	t.abortCeremonies(records)
+	t.ceremonyCancels.cancelAll()
+	if !t.waitCeremonies(ceremonyUnwindTimeout) {
+		t.logger.Warn().Msg("the aborted ceremonies do not finish in time")
+	}
+}
+
+// waitCeremonies wait until no ceremony is running, it return false if they are still running after the timeout
+func (t *TssServer) waitCeremonies(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&t.activeCeremonies) > 0 {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		<-ticker.C
+	}
+	return true
+}
+
+// abortCeremonies tell the peers of the ceremonies we shut down, we wait until each of them takes the message as
+// the host is closed right after
+func (t *TssServer) abortCeremonies(records []storage.CeremonyRecord) {
+	if len(records) == 0 {
+		return
+	}
+	payload, err := json.Marshal(messages.TssAbort{Reason: "the node is shutting down", Shutdown: true})
+	if err != nil {
+		t.logger.Error().Err(err).Msg("fail to marshal the abort message")
+		return
+	}
+	for _, el := range records {
+		t.logger.Warn().Str("msg id", el.MsgID).Str("round", el.Round).Msgf("abort the %s as we shut down", el.Kind)
+		report := t.p2pCommunication.DeliverMessage(t.ceremonyPeers(el), &messages.WrappedMessage{
+			MessageType: messages.TSSAbortMsg,
+			MsgID:       el.MsgID,
+			Payload:     payload,
+		})
+		for _, p := range report.Peers {
+			if p.Err != nil {
+				t.logger.Error().Err(p.Err).Str("msg id", el.MsgID).Msgf("fail to tell peer %s we abort", p.PeerID)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tss
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/keysign"
+)
+
+type ShutdownTestSuite struct{}
+
+var _ = Suite(&ShutdownTestSuite{})
+
+func (s *ShutdownTestSuite) TestDrain(c *C) {
+	t := &TssServer{
+		logger:          log.Logger,
+		conf:            common.TssConfig{DrainTimeout: 5 * time.Second},
+		drainChan:       make(chan struct{}),
+		stopChan:        make(chan struct{}),
+		ceremonyCancels: newCeremonyCancels(),
+	}
+	c.Assert(t.draining(), Equals, false)
+	c.Assert(t.acquireCeremony(), IsNil)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		t.releaseCeremony()
+	}()
+	start := time.Now()
+	t.drain()
+	// the in-flight ceremony finished before the drain timeout
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+	c.Assert(atomic.LoadInt64(&t.activeCeremonies), Equals, int64(0))
+	c.Assert(t.draining(), Equals, true)
+	c.Assert(t.acquireCeremony(), Equals, ErrShuttingDown)
+	_, err := t.KeySignContext(context.Background(), keysign.Request{})
+	c.Assert(err, Equals, ErrShuttingDown)
+}
+
+func (s *ShutdownTestSuite) TestDrainTimeout(c *C) {
+	t := &TssServer{
+		logger:          log.Logger,
+		conf:            common.TssConfig{DrainTimeout: 100 * time.Millisecond},
+		drainChan:       make(chan struct{}),
+		stopChan:        make(chan struct{}),
+		ceremonyCancels: newCeremonyCancels(),
+	}
+	// the ceremony only finishes once it is cancelled
+	c.Assert(t.acquireCeremony(), IsNil)
+	ctx, cancel := context.WithCancel(context.Background())
+	untrack := t.ceremonyCancels.track("msg", cancel)
+	go func() {
+		<-ctx.Done()
+		untrack()
+		t.releaseCeremony()
+	}()
+	t.drain()
+	c.Assert(ctx.Err(), Equals, context.Canceled)
+	c.Assert(atomic.LoadInt64(&t.activeCeremonies), Equals, int64(0))
+}
+
+// recordingNotifier is the BlameNotifier that records the blame events
+type recordingNotifier struct {
+	events []blame.Event
+}
+
+func (n *recordingNotifier) Notify(ev blame.Event) {
+	n.events = append(n.events, ev)
+}
+
+func (n *recordingNotifier) Stop() {}
+
+func (s *ShutdownTestSuite) TestShutdownBlame(c *C) {
+	notifier := &recordingNotifier{}
+	t := &TssServer{blameNotifier: notifier, tssMetrics: newRecordingMetrics()}
+	// the node that shuts down is not reported to the slashing services
+	t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssShutdown, []blame.Node{blame.NewNode("pubkey", nil, nil)}))
+	c.Assert(notifier.events, HasLen, 0)
+	t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssTimeout, []blame.Node{blame.NewNode("pubkey", nil, nil)}))
+	c.Assert(notifier.events, HasLen, 1)
+}
@@ -0,0 +1,85 @@
+package tss
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+)
+
+// Tenants are the committees one process serves, each of them is a Server with its own p2p host, rendezvous,
+// keyshares and config, the requests name the tenant they are for, the ones that name none go to
+// common.DefaultTenant
+type Tenants struct {
+	servers map[string]Server
+}
+
+// NewTenants create the tenants of the node, the server of the flags is the default tenant
+func NewTenants(defaultServer Server) *Tenants {
+	return &Tenants{
+		servers: map[string]Server{common.DefaultTenant: defaultServer},
+	}
+}
+
+// Add add the server of the tenant, it should be done before the apis start
+func (t *Tenants) Add(id string, server Server) error {
+	if err := common.ValidateTenantID(id); err != nil {
+		return err
+	}
+	if _, ok := t.servers[id]; ok {
+		return fmt.Errorf("the tenant %s is duplicated", id)
+	}
+	t.servers[id] = server
+	return nil
+}
+
+// Get return the server of the tenant, the empty id is the default tenant
+func (t *Tenants) Get(id string) (Server, error) {
+	server, ok := t.servers[common.TenantOrDefault(id)]
+	if !ok {
+		return nil, errcode.Errorf(errcode.NotFound, "the tenant %s is not found", id)
+	}
+	return server, nil
+}
+
+// IDs return the ids of the tenants, sorted
+func (t *Tenants) IDs() []string {
+	ids := make([]string, 0, len(t.servers))
+	for id := range t.servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Start start the servers of all the tenants, it stops the ones it started if one fails
+func (t *Tenants) Start() error {
+	var started []Server
+	for _, id := range t.IDs() {
+		server := t.servers[id]
+		if err := server.Start(); err != nil {
+			for _, el := range started {
+				el.Stop()
+			}
+			return fmt.Errorf("fail to start the tenant %s: %w", id, err)
+		}
+		started = append(started, server)
+	}
+	return nil
+}
+
+// Stop stop the servers of all the tenants at the same time, so each of them drains its ceremonies within its own
+// drain timeout
+func (t *Tenants) Stop() {
+	wg := &sync.WaitGroup{}
+	for _, el := range t.servers {
+		wg.Add(1)
+		go func(server Server) {
+			defer wg.Done()
+			server.Stop()
+		}(el)
+	}
+	wg.Wait()
+}
@@ -1,53 +1,98 @@
 package tss
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	bkeygen "github.com/binance-chain/tss-lib/ecdsa/keygen"
 	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	tcrypto "github.com/tendermint/tendermint/crypto"
 
+	"github.com/akildemir/go-tss/blame"
 	"github.com/akildemir/go-tss/common"
 	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/errcode"
 	"github.com/akildemir/go-tss/keygen"
+	"github.com/akildemir/go-tss/keyimport"
 	"github.com/akildemir/go-tss/keysign"
 	"github.com/akildemir/go-tss/messages"
 	"github.com/akildemir/go-tss/monitor"
 	"github.com/akildemir/go-tss/p2p"
+	"github.com/akildemir/go-tss/reshare"
+	"github.com/akildemir/go-tss/schnorr"
 	"github.com/akildemir/go-tss/storage"
+	"github.com/akildemir/go-tss/webhook"
 )
 
+const p2pMonitorInterval = time.Second * 10
+
+// peerEventBufferSize is how many peer events we buffer before we drop them
+const peerEventBufferSize = 256
+
+// ceremonyEventBufferSize is how many ceremony events we buffer for the round latencies before we drop them
+const ceremonyEventBufferSize = 1024
+
 // TssServer is the structure that can provide all keysign and key gen features
 type TssServer struct {
 	conf              common.TssConfig
 	logger            zerolog.Logger
-	p2pCommunication  *p2p.Communication
+	p2pCommunication  Communication
 	localNodePubKey   string
 	preParams         *bkeygen.LocalPreParams
 	tssKeyGenLocker   *sync.Mutex
 	stopChan          chan struct{}
-	partyCoordinator  *p2p.PartyCoordinator
+	drainChan         chan struct{}
+	partyCoordinator  PartyCoordinator
 	stateManager      storage.LocalStateManager
-	signatureNotifier *keysign.SignatureNotifier
+	signatureNotifier SignatureNotifier
 	privateKey        tcrypto.PrivKey
-	tssMetrics        *monitor.Metric
+	tssMetrics        Metrics
+	keyImporter       KeyImporter
+	blameNotifier     BlameNotifier
+	ceremonyNotifier  CeremonyNotifier
+	activeCeremonies  int64
+	// waitingCeremonies are the ceremonies waiting for a slot
+	waitingCeremonies int64
+	ceremonySlots     chan struct{}
+	keySignQueue      *keySignQueue
+	canaryLock        *sync.RWMutex
+	canaryResult      *CanaryResult
+	healthLock        *sync.RWMutex
+	retireLock        *sync.Mutex
+	keyHealth         map[string]KeyHealth
+	presignPool       *schnorr.PresignPool
+	preParamsPool     *keygen.PreParamsPool
+	keySignPolicy     keysign.Policy
+	signLedger        *keysign.Ledger
+	auditLog          *keysign.AuditLog
+	ceremonyJournal   *ceremonyJournal
+	ceremonyCancels   *ceremonyCancels
+	archivePassphrase []byte
+	// interruptedCeremonies are the ceremonies the previous run of the node left unfinished, they are aborted once
+	// we start
+	interruptedCeremonies []storage.CeremonyRecord
 }
 
-// NewTss create a new instance of Tss
+// NewTss create a new instance of Tss, the modules it is built from can be replaced with the options,
+// the base folder is only used if the state manager is not replaced
 func NewTss(
-	comm *p2p.Communication,
+	comm Communication,
 	priKey tcrypto.PrivKey,
 	baseFolder string,
 	conf common.TssConfig,
 	preParams *bkeygen.LocalPreParams,
+	opts ...Option,
 ) (*TssServer, error) {
 	pk := coskey.PubKey{
 		Key: priKey.PubKey().Bytes()[:],
@@ -58,9 +103,70 @@ func NewTss(
 		return nil, fmt.Errorf("fail to genearte the key: %w", err)
 	}
 
-	stateManager, err := storage.NewFileStateMgr(baseFolder)
-	if err != nil {
-		return nil, fmt.Errorf("fail to create file state manager")
+	tssServer := TssServer{
+		conf:             conf,
+		logger:           log.With().Str("module", "tss").Logger(),
+		p2pCommunication: comm,
+		localNodePubKey:  pubKey,
+		tssKeyGenLocker:  &sync.Mutex{},
+		stopChan:         make(chan struct{}),
+		drainChan:        make(chan struct{}),
+		privateKey:       priKey,
+		canaryLock:       &sync.RWMutex{},
+		healthLock:       &sync.RWMutex{},
+		retireLock:       &sync.Mutex{},
+		ceremonyCancels:  newCeremonyCancels(),
+		keyHealth:        make(map[string]KeyHealth),
+	}
+	if conf.MaxConcurrentCeremonies > 0 {
+		tssServer.ceremonySlots = make(chan struct{}, conf.MaxConcurrentCeremonies)
+	}
+	if conf.MaxConcurrentKeySigns > 0 {
+		tssServer.keySignQueue = newKeySignQueue(conf.MaxConcurrentKeySigns, conf.MaxKeySignQueue, conf.SerializeKeySigns, conf.KeySignTimeout)
+	}
+	for _, opt := range opts {
+		opt(&tssServer)
+	}
+
+	if tssServer.stateManager == nil {
+		tssServer.stateManager, err = storage.NewFileStateMgr(baseFolder)
+		if err != nil {
+			return nil, fmt.Errorf("fail to create file state manager")
+		}
+	}
+	if conf.RetiredKeyRetention > 0 {
+		if err := tssServer.checkRetention(); err != nil {
+			return nil, err
+		}
+	}
+	tssServer.migrateLocalStates()
+	if err := tssServer.verifyLocalStates(); err != nil {
+		return nil, err
+	}
+	// the ceremonies are not journaled if the state manager can not persist them
+	journalStore, _ := tssServer.stateManager.(CeremonyJournalStore)
+	tssServer.ceremonyJournal, tssServer.interruptedCeremonies = newCeremonyJournal(journalStore, tssServer.logger)
+	// the presignatures are only kept in memory if the state manager can not persist them
+	presignStore, _ := tssServer.stateManager.(schnorr.PresignStore)
+	tssServer.presignPool = schnorr.NewPresignPool(presignStore)
+	if conf.SignLedger {
+		ledgerStore, ok := tssServer.stateManager.(keysign.LedgerStore)
+		if !ok {
+			tssServer.logger.Warn().Msg("the state manager can not persist the sign ledger, it is only kept in memory")
+		}
+		tssServer.signLedger = keysign.NewLedger(ledgerStore, conf.SignLedgerRules, conf.SignLedgerRetention)
+	}
+	if conf.AuditLog {
+		// an audit log that is lost on restart can not prove anything to the auditors, so we refuse to start
+		auditStore, ok := tssServer.stateManager.(keysign.AuditStore)
+		if !ok {
+			return nil, errors.New("the state manager can not persist the audit log")
+		}
+		tssServer.auditLog = keysign.NewAuditLog(auditStore)
+	}
+	if conf.PreParamsPoolSize > 0 {
+		preParamsStore, _ := tssServer.stateManager.(keygen.PreParamsStore)
+		tssServer.preParamsPool = keygen.NewPreParamsPool(preParamsStore, conf.PreParamsPoolSize, conf.PreParamsTTL, conf.PreParamTimeout)
 	}
 
 	// When using the keygen party it is recommended that you pre-compute the
@@ -78,25 +184,38 @@ func NewTss(
 		return nil, errors.New("invalid preparams")
 	}
 
-	pc := p2p.NewPartyCoordinator(comm.GetHost(), conf.PartyTimeout)
-	sn := keysign.NewSignatureNotifier(comm.GetHost())
-	metrics := monitor.NewMetric()
-	if conf.EnableMonitor {
-		metrics.Enable()
+	tssServer.preParams = preParams
+
+	if tssServer.partyCoordinator == nil {
+		tssServer.partyCoordinator = p2p.NewPartyCoordinator(comm.GetHost(), conf.PartyTimeout)
 	}
-	tssServer := TssServer{
-		conf:              conf,
-		logger:            log.With().Str("module", "tss").Logger(),
-		p2pCommunication:  comm,
-		localNodePubKey:   pubKey,
-		preParams:         preParams,
-		tssKeyGenLocker:   &sync.Mutex{},
-		stopChan:          make(chan struct{}),
-		partyCoordinator:  pc,
-		stateManager:      stateManager,
-		signatureNotifier: sn,
-		privateKey:        priKey,
-		tssMetrics:        metrics,
+	selector, err := p2p.NewSignerSelector(conf.SignerSelection, comm.GetPeerLatencies, comm.GetReputation())
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the signer selector: %w", err)
+	}
+	tssServer.partyCoordinator.SetSignerSelector(selector)
+	if tssServer.signatureNotifier == nil {
+		tssServer.signatureNotifier = keysign.NewSignatureNotifier(comm.GetHost())
+	}
+	if tssServer.tssMetrics == nil {
+		metrics := monitor.NewMetric()
+		if conf.EnableMonitor {
+			metrics.Enable()
+		}
+		tssServer.tssMetrics = metrics
+	}
+	if tssServer.keyImporter == nil {
+		tssServer.keyImporter = keyimport.NewKeyImporter(comm.GetHost(), tssServer.stateManager)
+	}
+	if tssServer.blameNotifier == nil && len(conf.BlameWebhookURL) > 0 {
+		tssServer.blameNotifier = blame.NewWebhookNotifier(conf.BlameWebhookURL)
+	}
+	if tssServer.ceremonyNotifier == nil && len(conf.Webhooks) > 0 {
+		targets, err := webhookTargets(conf)
+		if err != nil {
+			return nil, err
+		}
+		tssServer.ceremonyNotifier = webhook.NewNotifier(targets)
 	}
 
 	return &tssServer, nil
@@ -105,11 +224,189 @@ func NewTss(
 // Start Tss server
 func (t *TssServer) Start() error {
 	log.Info().Msg("Starting the TSS servers")
+	go t.monitorP2P()
+	events, cancel := t.p2pCommunication.SubscribePeerEvents(peerEventBufferSize)
+	go t.watchPeerEvents(events, cancel)
+	ceremonyEvents, cancelCeremonyEvents := t.SubscribeCeremonyEvents(ceremonyEventBufferSize)
+	go t.watchCeremonyEvents(ceremonyEvents, cancelCeremonyEvents)
+	if t.conf.CanaryInterval > 0 && len(t.conf.CanaryPoolPubKey) > 0 {
+		go t.canaryScheduler()
+	}
+	if t.conf.HealthCheckInterval > 0 && len(t.conf.HealthCheckPoolPubKeys) > 0 {
+		go t.healthCheckScheduler()
+	}
+	if t.conf.PresignInterval > 0 && len(t.conf.PresignPoolPubKey) > 0 && t.conf.PresignPoolSize > 0 {
+		go t.presignScheduler()
+	}
+	if t.conf.RefreshInterval > 0 && len(t.conf.RefreshPoolPubKey) > 0 {
+		go t.refreshScheduler()
+	}
+	if t.preParamsPool != nil {
+		go t.preParamsPool.Run(t.stopChan)
+	}
+	if t.conf.RetiredKeyRetention > 0 {
+		go t.retentionScheduler()
+	}
+	if len(t.interruptedCeremonies) > 0 {
+		go t.abortInterruptedCeremonies(t.interruptedCeremonies)
+	}
 	return nil
 }
 
+// monitorP2P export the occupancy of the subscriber channels and the p2p traffic to the metrics
+func (t *TssServer) monitorP2P() {
+	ticker := time.NewTicker(p2pMonitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		case <-ticker.C:
+			for msgType, occupancy := range t.p2pCommunication.GetChannelOccupancy() {
+				t.tssMetrics.UpdateChannelOccupancy(msgType, occupancy)
+			}
+			stats := t.p2pCommunication.GetCompressionStats()
+			t.tssMetrics.UpdateP2PBytes("sent", stats.SentRaw, stats.SentWire)
+			t.tssMetrics.UpdateP2PBytes("received", stats.ReceivedRaw, stats.ReceivedWire)
+			t.exportBandwidth(t.p2pCommunication.Stats())
+			t.exportPeerStats(t.p2pCommunication.GetPeerStats())
+			streams := t.p2pCommunication.GetStreamHandlerStats()
+			t.tssMetrics.UpdateStreamHandler(streams.Busy, streams.Queued, streams.Rejected)
+			t.exportPeerStreams(t.p2pCommunication.GetPeerStreams())
+			t.exportQueues()
+		}
+	}
+}
+
+// watchPeerEvents count the peer events in the metrics until we stop
+func (t *TssServer) watchPeerEvents(events <-chan p2p.PeerEvent, cancel func()) {
+	defer cancel()
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			t.tssMetrics.IncPeerEvent(string(ev.Type))
+			if ev.Type == p2p.PeerBanned {
+				t.logger.Warn().Msgf("peer %s is banned: %s", ev.Peer, ev.Reason)
+			}
+		}
+	}
+}
+
+// watchCeremonyEvents record how long the local party of each ceremony spends in each round until we stop, a
+// round ends once the party moves on to the next round or the ceremony finishes
+func (t *TssServer) watchCeremonyEvents(events <-chan CeremonyEvent, cancel func()) {
+	defer cancel()
+	rounds := make(map[string]CeremonyEvent)
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if last, ok := rounds[ev.MsgID]; ok && len(last.Round) != 0 {
+				t.tssMetrics.UpdateRoundLatency(last.Kind, last.Round, ev.Time.Sub(last.Time))
+			}
+			if ev.Type == CeremonyFinished {
+				delete(rounds, ev.MsgID)
+				continue
+			}
+			rounds[ev.MsgID] = ev
+		}
+	}
+}
+
+// exportPeerStreams export the number of the streams we have open with each peer to the metrics
+func (t *TssServer) exportPeerStreams(streams map[peer.ID]int) {
+	ret := make(map[string]int, len(streams))
+	for k, v := range streams {
+		ret[k.String()] = v
+	}
+	t.tssMetrics.UpdatePeerStreams(ret)
+}
+
+// exportQueues export the running and the waiting ceremonies and keysigns to the metrics
+func (t *TssServer) exportQueues() {
+	t.tssMetrics.UpdateQueueDepth("ceremony", int(atomic.LoadInt64(&t.activeCeremonies)), int(atomic.LoadInt64(&t.waitingCeremonies)))
+	if t.keySignQueue != nil {
+		running, queued := t.keySignQueue.stats()
+		t.tssMetrics.UpdateQueueDepth("keysign", running, queued)
+	}
+}
+
+// observeCeremony count the ceremony of the given type in the metrics by its outcome, the ceremonies we gave
+// up on ourselves are cancelled
+func (t *TssServer) observeCeremony(ceremony string, startTime time.Time, status common.Status, cancelled bool) {
+	outcome := "failure"
+	switch {
+	case cancelled:
+		outcome = "cancelled"
+	case status == common.Success:
+		outcome = "success"
+	}
+	t.tssMetrics.UpdateCeremony(ceremony, outcome, time.Since(startTime))
+}
+
+// exportBandwidth export the bytes we exchanged with each peer and over each protocol to the metrics
+func (t *TssServer) exportBandwidth(stats p2p.BandwidthStats) {
+	peerIn := make(map[string]int64, len(stats.Peers))
+	peerOut := make(map[string]int64, len(stats.Peers))
+	for k, v := range stats.Peers {
+		peerIn[k] = v.TotalIn
+		peerOut[k] = v.TotalOut
+	}
+	t.tssMetrics.UpdatePeerBandwidth(peerIn, peerOut)
+	protoIn := make(map[string]int64, len(stats.Protocols))
+	protoOut := make(map[string]int64, len(stats.Protocols))
+	for k, v := range stats.Protocols {
+		protoIn[k] = v.TotalIn
+		protoOut[k] = v.TotalOut
+	}
+	t.tssMetrics.UpdateProtocolBandwidth(protoIn, protoOut)
+}
+
+// exportPeerStats export the tss message statistics of each peer over each protocol to the metrics
+func (t *TssServer) exportPeerStats(stats map[peer.ID]map[protocol.ID]p2p.ProtocolStats) {
+	var ret []monitor.PeerProtocolStat
+	for pID, protocols := range stats {
+		for protocolID, el := range protocols {
+			ret = append(ret, monitor.PeerProtocolStat{
+				Peer:        pID.String(),
+				Protocol:    string(protocolID),
+				MessagesIn:  el.MessagesIn,
+				MessagesOut: el.MessagesOut,
+				Failures:    el.Failures,
+				AvgLatency:  el.AvgLatency,
+			})
+		}
+	}
+	t.tssMetrics.UpdatePeerProtocolStats(ret)
+}
+
+// GetPeerStats return the tss message statistics of each peer over each protocol, so the operators can find
+// the peers that slow the ceremonies down
+func (t *TssServer) GetPeerStats() map[string]map[string]p2p.ProtocolStats {
+	stats := t.p2pCommunication.GetPeerStats()
+	ret := make(map[string]map[string]p2p.ProtocolStats, len(stats))
+	for pID, protocols := range stats {
+		m := make(map[string]p2p.ProtocolStats, len(protocols))
+		for protocolID, el := range protocols {
+			m[string(protocolID)] = el
+		}
+		ret[pID.String()] = m
+	}
+	return ret
+}
+
 // Stop Tss server
 func (t *TssServer) Stop() {
+	t.drain()
 	close(t.stopChan)
 	// stop the p2p and finish the p2p wait group
 	err := t.p2pCommunication.Stop()
@@ -117,19 +414,154 @@ func (t *TssServer) Stop() {
 		t.logger.Error().Msgf("error in shutdown the p2p server")
 	}
 	t.partyCoordinator.Stop()
+	t.keyImporter.Stop()
+	if t.blameNotifier != nil {
+		t.blameNotifier.Stop()
+	}
+	if t.ceremonyNotifier != nil {
+		t.ceremonyNotifier.Stop()
+	}
+	storage.ZeroizePreParams(t.preParams)
+	if t.ceremonyJournal != nil {
+		t.ceremonyJournal.events.close()
+	}
 	log.Info().Msg("The Tss and p2p server has been stopped successfully")
 }
 
+// reportBlame count the blame of the failed ceremony in the metrics and send it to the blame notifier and the
+// ceremony notifier if we have them, it return the blame event signed with the node key, the verdict of the
+// response, nil if no one is to be slashed
+func (t *TssServer) reportBlame(ceremony, msgID, poolPubKey string, b blame.Blame) *blame.Event {
+	for _, el := range b.BlameNodes {
+		t.tssMetrics.IncBlame(ceremony, b.FailReason, el.Pubkey)
+	}
+	// the nodes that shut down gracefully are blamed so the retries go without them, but they are not slashed
+	if len(b.BlameNodes) == 0 || b.FailReason == blame.TssShutdown {
+		return nil
+	}
+	ev := blame.NewEvent(ceremony, msgID, poolPubKey, t.localNodePubKey, b)
+	if t.privateKey != nil {
+		// the unsigned event is still reported, the consumers that need the signature refuse it
+		if err := ev.Sign(t.privateKey); err != nil {
+			t.logger.Error().Err(err).Str("msg id", msgID).Msg("fail to sign the blame event")
+		}
+	}
+	if t.blameNotifier != nil {
+		t.blameNotifier.Notify(ev)
+	}
+	if t.ceremonyNotifier != nil {
+		t.ceremonyNotifier.Notify(webhook.NewBlameEvent(ev))
+	}
+	return &ev
+}
+
+// errorCode return the code the caller is told of the outcome of the ceremony, the error has it if the ceremony
+// did not run, otherwise the blame of the failed ceremony tells it
+func errorCode(status common.Status, b blame.Blame, err error) errcode.Code {
+	if err != nil {
+		return errcode.Of(err)
+	}
+	if status != common.Fail {
+		return ""
+	}
+	if code := b.ErrorCode(); code != "" {
+		return code
+	}
+	return errcode.Internal
+}
+
+// recordReputation score the participants with the outcome of the ceremony, the blamed nodes are penalised if
+// it fails, otherwise the participants are rewarded
+func (t *TssServer) recordReputation(status common.Status, b blame.Blame, participants []string) {
+	reputation := t.p2pCommunication.GetReputation()
+	if reputation == nil {
+		return
+	}
+	switch status {
+	case common.Success:
+		for _, el := range participants {
+			if el == t.localNodePubKey {
+				continue
+			}
+			pID, err := conversion.GetPeerIDFromPubKey(el)
+			if err != nil {
+				t.logger.Error().Err(err).Msgf("fail to convert the pub key(%s) to peer id", el)
+				continue
+			}
+			reputation.Record(pID, p2p.EventCeremonySuccess)
+		}
+	case common.Fail:
+		if b.FailReason == blame.TssShutdown {
+			return
+		}
+		event := p2p.EventBlame
+		if b.FailReason == blame.TssTimeout || b.FailReason == blame.TssSyncFail {
+			event = p2p.EventTimeout
+		}
+		for _, el := range b.BlameNodes {
+			pID, err := conversion.GetPeerIDFromPubKey(el.Pubkey)
+			if err != nil {
+				t.logger.Error().Err(err).Msgf("fail to convert the pub key(%s) to peer id", el.Pubkey)
+				continue
+			}
+			reputation.Record(pID, event)
+		}
+	}
+}
+
 func (t *TssServer) requestToMsgId(request interface{}) (string, error) {
 	var dat []byte
 	var keys []string
 	switch value := request.(type) {
 	case keygen.Request:
+		// the keys of another threshold are another ceremony
+		if value.Threshold > 0 {
+			dat = []byte(fmt.Sprintf("threshold-%d", value.Threshold))
+		}
+		// the keys of another algo are another ceremony
+		if algo := value.Algo.OrDefault(); algo != conversion.AlgoSecp256k1 {
+			dat = append([]byte(algo), dat...)
+		}
+		if protocol := value.Protocol.OrDefault(); protocol != conversion.SignProtocolGG20 {
+			dat = append([]byte(protocol), dat...)
+		}
+		// the keys of other weights are another ceremony
+		if len(value.Weights) > 0 {
+			weighted := append([]string{}, value.Keys...)
+			sort.Strings(weighted)
+			for i, el := range weighted {
+				weighted[i] = fmt.Sprintf("%s:%d", el, conversion.GetWeight(value.Weights, el))
+			}
+			dat = append([]byte(strings.Join(weighted, ",")), dat...)
+		}
 		keys = value.Keys
 	case keysign.Request:
 		sort.Strings(value.Messages)
 		dat = []byte(strings.Join(value.Messages, ","))
+		// the schnorr keysign of the same messages is another ceremony
+		if value.Mode.IsSchnorr() {
+			dat = append([]byte(string(value.Mode)+value.TaprootMerkleRoot), dat...)
+			if value.UsePresignature {
+				dat = append([]byte("presign"), dat...)
+			}
+		}
+		// the children of the pool sign the same messages in other ceremonies
+		if len(value.DerivationPath) > 0 {
+			dat = append([]byte(value.DerivationPath), dat...)
+		}
 		keys = value.SignerPubKeys
+	case reshare.Request:
+		dat = []byte("reshare" + value.PoolPubKey)
+		if value.Threshold > 0 {
+			dat = append(dat, []byte(fmt.Sprintf("-threshold-%d", value.Threshold))...)
+		}
+		// a member may move between the committees, so the keys are marked with their committee
+		for _, el := range value.OldPartyKeys {
+			keys = append(keys, "old"+el)
+		}
+		for _, el := range value.NewPartyKeys {
+			keys = append(keys, "new"+el)
+		}
 	default:
 		t.logger.Error().Msg("unknown request type")
 		return "", errors.New("unknown request type")
@@ -143,13 +575,24 @@ func (t *TssServer) requestToMsgId(request interface{}) (string, error) {
 	return common.MsgToHashString(dat)
 }
 
-func (t *TssServer) joinParty(msgID, version string, blockHeight int64, participants []string, threshold int, sigChan chan string) ([]peer.ID, string, error) {
+// ceremonyLogger return the logger the context of the ceremony carries, the one of the server if it carries none
+func (t *TssServer) ceremonyLogger(ctx context.Context) zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger.With().Str("module", "tss").Logger()
+	}
+	return t.logger
+}
+
+// joinParty form the party of the ceremony, the leader never picks the excluded participants, they are only left out
+// by the join party with the leader
+func (t *TssServer) joinParty(ctx context.Context, msgID, version string, blockHeight int64, participants, excluded []string, threshold int, sigChan chan string) ([]peer.ID, string, error) {
+	logger := t.ceremonyLogger(ctx)
 	oldJoinParty, err := conversion.VersionLTCheck(version, messages.NEWJOINPARTYVERSION)
 	if err != nil {
 		return nil, "", fmt.Errorf("fail to parse the version with error:%w", err)
 	}
 	if oldJoinParty {
-		t.logger.Info().Msg("we apply the leadless join party")
+		logger.Info().Msg("we apply the leadless join party")
 		peerIDs, err := conversion.GetPeerIDsFromPubKeys(participants)
 		if err != nil {
 			return nil, "NONE", fmt.Errorf("fail to convert pub key to peer id: %w", err)
@@ -158,13 +601,13 @@ func (t *TssServer) joinParty(msgID, version string, blockHeight int64, particip
 		for _, el := range peerIDs {
 			peersIDStr = append(peersIDStr, el.String())
 		}
-		onlines, err := t.partyCoordinator.JoinPartyWithRetry(msgID, peersIDStr)
+		onlines, err := t.partyCoordinator.JoinPartyWithRetry(ctx, msgID, peersIDStr)
 		return onlines, "NONE", err
 	} else {
-		t.logger.Info().Msg("we apply the join party with a leader")
+		logger.Info().Msg("we apply the join party with a leader")
 
 		if len(participants) == 0 {
-			t.logger.Error().Msg("we fail to have any participants or passed by request")
+			logger.Error().Msg("we fail to have any participants or passed by request")
 			return nil, "", errors.New("no participants can be found")
 		}
 		peersID, err := conversion.GetPeerIDsFromPubKeys(participants)
@@ -176,11 +619,102 @@ func (t *TssServer) joinParty(msgID, version string, blockHeight int64, particip
 			peersIDStr = append(peersIDStr, el.String())
 		}
 
-		return t.partyCoordinator.JoinPartyWithLeader(msgID, blockHeight, peersIDStr, threshold, sigChan)
+		excludedIDs, err := conversion.GetPeerIDsFromPubKeys(excluded)
+		if err != nil {
+			return nil, "", errors.New("fail to convert the excluded public key to peer ID")
+		}
+		var excludedIDStr []string
+		for _, el := range excludedIDs {
+			excludedIDStr = append(excludedIDStr, el.String())
+		}
+		return t.partyCoordinator.JoinPartyWithLeaderExcept(ctx, msgID, blockHeight, peersIDStr, excludedIDStr, threshold, sigChan)
+	}
+}
+
+// Status is the status of the tss server
+type Status struct {
+	LocalAttestation *p2p.BuildAttestation          `json:"local_attestation,omitempty"`
+	PeerAttestations map[string]p2p.PeerAttestation `json:"peer_attestations,omitempty"`
+	Discovery        *p2p.DiscoveryEvent            `json:"discovery,omitempty"`
+	Canary           *CanaryResult                  `json:"canary,omitempty"`
+	KeyHealth        map[string]KeyHealth           `json:"key_health,omitempty"`
+	PeerLatencies    map[string]p2p.PeerLatency     `json:"peer_latencies,omitempty"`
+	PeerReputations  map[string]p2p.PeerReputation  `json:"peer_reputations,omitempty"`
+	PeerCapabilities map[string]p2p.PeerCapability  `json:"peer_capabilities,omitempty"`
+	// Algos are the algos this node can hold the keys of
+	Algos []conversion.Algo `json:"algos,omitempty"`
+}
+
+// Ready return true once we have joined the p2p network, or we can reach a signing quorum of the cached committee,
+// and we are not isolated from the bootstrap peers and the committee
+func (s Status) Ready() bool {
+	if s.Discovery == nil || s.Discovery.Isolated {
+		return false
 	}
+	return s.Discovery.State == p2p.DiscoveryReady || s.Discovery.QuorumReachable
+}
+
+// GetStatus return the status of the tss server, including the build attestations of the peers and
+// the p2p discovery state, the latest canary keysign, the latest health check of the vaults and the latency, the reputation and the capabilities of the peers
+func (t *TssServer) GetStatus() Status {
+	discovery := t.p2pCommunication.GetDiscoveryState()
+	status := Status{
+		Discovery: &discovery,
+		Canary:    t.GetCanaryResult(),
+		KeyHealth: t.GetKeyHealth(),
+		Algos:     common.SupportedAlgos(),
+	}
+	if latencies := t.p2pCommunication.GetPeerLatencies(); len(latencies) > 0 {
+		status.PeerLatencies = make(map[string]p2p.PeerLatency, len(latencies))
+		for k, v := range latencies {
+			status.PeerLatencies[k.String()] = v
+		}
+	}
+	if reputation := t.p2pCommunication.GetReputation(); reputation != nil {
+		scores := reputation.Scores()
+		if len(scores) > 0 {
+			status.PeerReputations = make(map[string]p2p.PeerReputation, len(scores))
+			for k, v := range scores {
+				status.PeerReputations[k.String()] = v
+			}
+		}
+	}
+	if capabilities := t.p2pCommunication.GetPeerCapabilities(); len(capabilities) > 0 {
+		status.PeerCapabilities = make(map[string]p2p.PeerCapability, len(capabilities))
+		for k, v := range capabilities {
+			status.PeerCapabilities[k.String()] = v
+		}
+	}
+	if as := t.p2pCommunication.GetAttestationService(); as != nil {
+		local := as.Local()
+		status.LocalAttestation = &local
+		status.PeerAttestations = as.PeerAttestations()
+	}
+	return status
 }
 
 // GetLocalPeerID return the local peer
 func (t *TssServer) GetLocalPeerID() string {
 	return t.p2pCommunication.GetLocalPeerID()
 }
+
+// SyncPeers blocks until all the nodes of the given pub keys are reachable and have acknowledged the
+// given token, it is the same barrier we use to sync the nodes before keygen/keysign, so embedders can
+// use it before they trigger any coordinated actions. All the nodes should call it with the same token.
+// It returns the pub keys of the nodes that acknowledged the token.
+func (t *TssServer) SyncPeers(token string, pubKeys []string, timeout time.Duration) ([]string, error) {
+	peerIDs, err := conversion.GetPeerIDsFromPubKeys(pubKeys)
+	if err != nil {
+		return nil, fmt.Errorf("fail to convert pub key to peer id: %w", err)
+	}
+	onlines, errSync := t.partyCoordinator.SyncBarrier(token, peerIDs, timeout)
+	onlinePeers := make([]string, len(onlines))
+	for i, el := range onlines {
+		onlinePeers[i] = el.String()
+	}
+	onlinePubKeys, err := conversion.GetPubKeysFromPeerIDs(onlinePeers)
+	if err != nil {
+		return nil, fmt.Errorf("fail to convert peer id to pub key: %w", err)
+	}
+	return onlinePubKeys, errSync
+}
@@ -0,0 +1,66 @@
+package tss
+
+import (
+	"runtime"
+	"sort"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+)
+
+const (
+	// FeatureTrustedDealer the node takes part in the trusted dealer key import
+	FeatureTrustedDealer = "trusted-dealer"
+	// FeaturePresign the node keeps the presignatures of the schnorr keysign ready
+	FeaturePresign = "presign"
+	// FeatureRefresh the node refreshes the shares of the refresh pool key
+	FeatureRefresh = "refresh"
+)
+
+// VersionInfo is the build of the node and what it can run, the coordinators compare the ones of the committee
+// before they trigger a keygen, the nodes that differ may not finish it
+type VersionInfo struct {
+	// Version and Commit are the ones of the build attestation, empty if the attestation is not enabled
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+	// MinProtocolVersion and MaxProtocolVersion are the versions of the wire format of the tss messages we talk
+	MinProtocolVersion int                       `json:"min_protocol_version"`
+	MaxProtocolVersion int                       `json:"max_protocol_version"`
+	Algos              []conversion.Algo         `json:"algos"`
+	SignProtocols      []conversion.SignProtocol `json:"sign_protocols"`
+	// Features are the p2p features we advertise to the peers and the ones of the config, sorted
+	Features []string `json:"features"`
+}
+
+// GetVersion return the build, the protocol versions, the algos and the features of the node
+func (t *TssServer) GetVersion() VersionInfo {
+	capabilities := t.p2pCommunication.GetCapabilities()
+	info := VersionInfo{
+		GoVersion:          runtime.Version(),
+		MinProtocolVersion: capabilities.MinVersion,
+		MaxProtocolVersion: capabilities.MaxVersion,
+		Algos:              common.SupportedAlgos(),
+		SignProtocols:      []conversion.SignProtocol{conversion.SignProtocolGG20, conversion.SignProtocolFROST, conversion.SignProtocolMuSig2},
+		Features:           append([]string{}, capabilities.Features...),
+	}
+	if as := t.p2pCommunication.GetAttestationService(); as != nil {
+		local := as.Local()
+		info.Version = local.Version
+		info.Commit = local.Commit
+	}
+	if t.conf.AllowTrustedDealer {
+		info.Features = append(info.Features, FeatureTrustedDealer)
+	}
+	if t.conf.PresignPoolSize > 0 {
+		info.Features = append(info.Features, FeaturePresign)
+	}
+	if t.conf.RefreshInterval > 0 {
+		info.Features = append(info.Features, FeatureRefresh)
+	}
+	if t.conf.BlameQuorumTimeout > 0 {
+		info.Features = append(info.Features, FeatureBlameQuorum)
+	}
+	sort.Strings(info.Features)
+	return info
+}
@@ -0,0 +1,37 @@
+package tss
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/p2p"
+)
+
+type VersionTestSuite struct{}
+
+var _ = Suite(&VersionTestSuite{})
+
+func (s *VersionTestSuite) TestGetVersion(c *C) {
+	comm, err := p2p.NewCommunication("version", nil, 2393, "")
+	c.Assert(err, IsNil)
+	t := &TssServer{
+		conf:             common.TssConfig{AllowTrustedDealer: true, PresignPoolSize: 2},
+		p2pCommunication: comm,
+	}
+	info := t.GetVersion()
+	c.Assert(info.Version, Equals, "")
+	c.Assert(info.GoVersion, Not(Equals), "")
+	c.Assert(info.MinProtocolVersion, Equals, p2p.MinProtocolVersion)
+	c.Assert(info.MaxProtocolVersion, Equals, p2p.MaxProtocolVersion)
+	c.Assert(info.Algos, DeepEquals, []conversion.Algo{conversion.AlgoSecp256k1})
+	c.Assert(info.SignProtocols, HasLen, 3)
+	features := make(map[string]bool)
+	for _, el := range info.Features {
+		features[el] = true
+	}
+	for _, el := range []string{p2p.FeatureProtobuf, p2p.FeatureFROST, FeatureTrustedDealer, FeaturePresign} {
+		c.Assert(features[el], Equals, true, Commentf(el))
+	}
+	c.Assert(features[FeatureRefresh], Equals, false)
+}
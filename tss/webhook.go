@@ -0,0 +1,44 @@
+package tss
+
+import (
+	"fmt"
+
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/webhook"
+)
+
+// webhookTargets return the webhooks of the config, they share the secret and the kinds of the events
+func webhookTargets(conf common.TssConfig) ([]webhook.Target, error) {
+	var events []webhook.EventType
+	for _, el := range conf.WebhookEvents {
+		eventType, err := webhook.ParseEventType(el)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse the webhook events: %w", err)
+		}
+		events = append(events, eventType)
+	}
+	targets := make([]webhook.Target, 0, len(conf.Webhooks))
+	for _, el := range conf.Webhooks {
+		targets = append(targets, webhook.Target{
+			URL:    el,
+			Secret: []byte(conf.WebhookSecret),
+			Events: events,
+		})
+	}
+	return targets, nil
+}
+
+// notifyCeremony send the outcome of the keygen or the keysign to the ceremony notifier if we have one, the
+// ceremonies that did not run are not reported
+func (t *TssServer) notifyCeremony(completed, failed webhook.EventType, msgID, poolPubKey string, status common.Status, code errcode.Code) {
+	if t.ceremonyNotifier == nil {
+		return
+	}
+	switch status {
+	case common.Success:
+		t.ceremonyNotifier.Notify(webhook.NewEvent(completed, msgID, poolPubKey, t.localNodePubKey, ""))
+	case common.Fail:
+		t.ceremonyNotifier.Notify(webhook.NewEvent(failed, msgID, poolPubKey, t.localNodePubKey, string(code)))
+	}
+}
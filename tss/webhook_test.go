@@ -0,0 +1,83 @@
+package tss
+
+import (
+	coskey "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types/bech32/legacybech32"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+	"github.com/akildemir/go-tss/common"
+	"github.com/akildemir/go-tss/errcode"
+	"github.com/akildemir/go-tss/webhook"
+)
+
+type WebhookTestSuite struct{}
+
+var _ = Suite(&WebhookTestSuite{})
+
+// recordingCeremonyNotifier is the CeremonyNotifier that records the events
+type recordingCeremonyNotifier struct {
+	events []webhook.Event
+}
+
+func (n *recordingCeremonyNotifier) Notify(ev webhook.Event) {
+	n.events = append(n.events, ev)
+}
+
+func (n *recordingCeremonyNotifier) Stop() {}
+
+func (s *WebhookTestSuite) TestWebhookTargets(c *C) {
+	targets, err := webhookTargets(common.TssConfig{
+		Webhooks:      common.StringList{"http://a", "http://b"},
+		WebhookSecret: "secret",
+		WebhookEvents: common.StringList{"keysign.failed"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(targets, HasLen, 2)
+	c.Assert(targets[1].URL, Equals, "http://b")
+	c.Assert(targets[1].Secret, DeepEquals, []byte("secret"))
+	c.Assert(targets[1].Events, DeepEquals, []webhook.EventType{webhook.KeySignFailed})
+
+	_, err = webhookTargets(common.TssConfig{
+		Webhooks:      common.StringList{"http://a"},
+		WebhookEvents: common.StringList{"keysign.done"},
+	})
+	c.Assert(err, NotNil)
+}
+
+func (s *WebhookTestSuite) TestNotifyCeremony(c *C) {
+	notifier := &recordingCeremonyNotifier{}
+	t := &TssServer{ceremonyNotifier: notifier, tssMetrics: newRecordingMetrics(), localNodePubKey: "local"}
+	// the ceremony that did not run is not reported
+	t.notifyCeremony(webhook.KeySignCompleted, webhook.KeySignFailed, "msg", "pool", common.NA, errcode.BadRequest)
+	c.Assert(notifier.events, HasLen, 0)
+	t.notifyCeremony(webhook.KeySignCompleted, webhook.KeySignFailed, "msg", "pool", common.Success, "")
+	t.notifyCeremony(webhook.KeygenCompleted, webhook.KeygenFailed, "msg", "", common.Fail, errcode.TssTimeout)
+	c.Assert(notifier.events, HasLen, 2)
+	c.Assert(notifier.events[0].Type, Equals, webhook.KeySignCompleted)
+	c.Assert(notifier.events[0].PoolPubKey, Equals, "pool")
+	c.Assert(notifier.events[0].Reporter, Equals, "local")
+	c.Assert(notifier.events[1].Type, Equals, webhook.KeygenFailed)
+	c.Assert(notifier.events[1].ErrorCode, Equals, string(errcode.TssTimeout))
+
+	// the blame goes to the webhooks as well
+	verdict := t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssTimeout, []blame.Node{blame.NewNode("pubkey", nil, nil)}))
+	c.Assert(notifier.events, HasLen, 3)
+	c.Assert(notifier.events[2].Type, Equals, webhook.BlameReported)
+	c.Assert(notifier.events[2].Blame, NotNil)
+	c.Assert(notifier.events[2].Blame.Offenders, HasLen, 1)
+	c.Assert(verdict, NotNil)
+	c.Assert(verdict.Signature, HasLen, 0)
+	c.Assert(t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssShutdown, []blame.Node{blame.NewNode("pubkey", nil, nil)})), IsNil)
+
+	// the verdict is signed with the node key
+	priKey := secp256k1.GenPrivKey()
+	t.privateKey = priKey
+	pk, err := sdk.MarshalPubKey(sdk.AccPK, &coskey.PubKey{Key: priKey.PubKey().Bytes()})
+	c.Assert(err, IsNil)
+	t.localNodePubKey = pk
+	verdict = t.reportBlame("keysign", "msg", "pool", blame.NewBlame(blame.TssTimeout, []blame.Node{blame.NewNode("pubkey", nil, nil)}))
+	c.Assert(verdict.VerifySignature(), Equals, true)
+	c.Assert(notifier.events[3].Blame.VerifySignature(), Equals, true)
+}
@@ -0,0 +1,48 @@
+package tss
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/conversion"
+	"github.com/akildemir/go-tss/keysign"
+	"github.com/akildemir/go-tss/messages"
+	"github.com/akildemir/go-tss/storage"
+)
+
+type WeightedKeyTestSuite struct{}
+
+var _ = Suite(&WeightedKeyTestSuite{})
+
+func (s *WeightedKeyTestSuite) TestWeightedKey(c *C) {
+	conversion.SetupBech32Prefix()
+	data, err := ioutil.ReadFile("../test_data/keysign_data/0.json")
+	c.Assert(err, IsNil)
+	var state storage.KeygenLocalState
+	c.Assert(json.Unmarshal(data, &state), IsNil)
+	keys := append([]string{}, state.ParticipantKeys...)
+	c.Assert(checkSignerShares(keys[1:3], state), IsNil)
+
+	state.Weights = map[string]int{keys[0]: 2}
+	c.Assert(checkSignerShares(keys[1:3], state), NotNil)
+	c.Assert(checkSignerShares(keys[:3], state), IsNil)
+
+	stateManager, err := storage.NewFileStateMgr(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(stateManager.SaveLocalState(state), IsNil)
+	t := &TssServer{
+		logger:       log.Logger,
+		stateManager: stateManager,
+		retireLock:   &sync.Mutex{},
+	}
+	req := keysign.NewRequest(state.PubKey, []string{"aGVsbG8="}, 10, nil, messages.NEWJOINPARTYVERSION)
+	_, err = t.getSigningState(req)
+	c.Assert(err, IsNil)
+	req.DerivationPath = "m/0/1"
+	_, err = t.getSigningState(req)
+	c.Assert(err, Equals, ErrWeightedKey)
+}
@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akildemir/go-tss/blame"
+)
+
+// EventSchemaVersion is the version of the Event schema, it is bumped on every change that is not backward compatible
+const EventSchemaVersion = 1
+
+const (
+	// SignatureHeader is the http header that carries the hmac of the payload, sha256=<hex>
+	SignatureHeader = "X-Tss-Signature"
+	// TimestampHeader is the http header that carries the unix time the payload is signed at
+	TimestampHeader = "X-Tss-Timestamp"
+	// EventTypeHeader is the http header that carries the Event.Type, so the consumers can route the events
+	// without decoding the body
+	EventTypeHeader = "X-Tss-Event"
+	// EventIDHeader is the http header that carries the Event.ID, the retries of an event carry the same one
+	EventIDHeader   = "X-Tss-Event-Id"
+	signaturePrefix = "sha256="
+)
+
+// EventType is the kind of the Event
+type EventType string
+
+const (
+	// KeygenCompleted the keygen has generated the key
+	KeygenCompleted EventType = "keygen.completed"
+	// KeygenFailed the keygen has failed
+	KeygenFailed EventType = "keygen.failed"
+	// KeySignCompleted the keysign has signed the messages
+	KeySignCompleted EventType = "keysign.completed"
+	// KeySignFailed the keysign has failed
+	KeySignFailed EventType = "keysign.failed"
+	// BlameReported a failed ceremony blames the nodes, the event carries the blame.Event
+	BlameReported EventType = "blame.reported"
+)
+
+// EventTypes are all the kinds of the Event
+var EventTypes = []EventType{KeygenCompleted, KeygenFailed, KeySignCompleted, KeySignFailed, BlameReported}
+
+// ParseEventType return the EventType of the given name
+func ParseEventType(name string) (EventType, error) {
+	for _, el := range EventTypes {
+		if string(el) == name {
+			return el, nil
+		}
+	}
+	return "", fmt.Errorf("unknown webhook event %q", name)
+}
+
+// Event is the outcome of a ceremony, it is the payload we post to the webhooks as json.
+//
+//	{
+//	  "schema_version": 1,
+//	  "id": "random hex id of the event",
+//	  "type": "keygen.completed|keygen.failed|keysign.completed|keysign.failed|blame.reported",
+//	  "msg_id": "the id of the ceremony",
+//	  "pool_pub_key": "the new key of the keygen or the pool we sign with",
+//	  "reporter": "the pub key of the node that reports the event",
+//	  "error_code": "the errcode of the failed ceremony",
+//	  "blame": {"the blame.Event of blame.reported"},
+//	  "time": "RFC3339 time"
+//	}
+type Event struct {
+	SchemaVersion int          `json:"schema_version"`
+	ID            string       `json:"id"`
+	Type          EventType    `json:"type"`
+	MsgID         string       `json:"msg_id"`
+	PoolPubKey    string       `json:"pool_pub_key,omitempty"`
+	Reporter      string       `json:"reporter"`
+	ErrorCode     string       `json:"error_code,omitempty"`
+	Blame         *blame.Event `json:"blame,omitempty"`
+	Time          time.Time    `json:"time"`
+}
+
+// NewEvent create the Event of the ceremony
+func NewEvent(eventType EventType, msgID, poolPubKey, reporter, errorCode string) Event {
+	return Event{
+		SchemaVersion: EventSchemaVersion,
+		ID:            newEventID(),
+		Type:          eventType,
+		MsgID:         msgID,
+		PoolPubKey:    poolPubKey,
+		Reporter:      reporter,
+		ErrorCode:     errorCode,
+		Time:          time.Now().UTC(),
+	}
+}
+
+// NewBlameEvent create the Event that carries the blame event of the failed ceremony
+func NewBlameEvent(ev blame.Event) Event {
+	webhookEvent := NewEvent(BlameReported, ev.MsgID, ev.PoolPubKey, ev.Reporter, "")
+	webhookEvent.Blame = &ev
+	return webhookEvent
+}
+
+func newEventID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Sign return the signature of the payload signed at the given unix time, it is the hmac-sha256 of
+// "<timestamp>.<body>" with the secret of the webhook
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify check the signature and the timestamp headers of the payload, the payloads signed longer than maxAge ago
+// are refused so a payload can not be replayed later, 0 does not check the age
+func Verify(secret []byte, timestampHeader, signatureHeader string, body []byte, maxAge time.Duration) error {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q: %w", timestampHeader, err)
+	}
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return errors.New("the signature is not a sha256 one")
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signatureHeader)) {
+		return errors.New("the signature does not match")
+	}
+	if maxAge > 0 && time.Since(time.Unix(timestamp, 0)) > maxAge {
+		return fmt.Errorf("the payload is signed more than %s ago", maxAge)
+	}
+	return nil
+}
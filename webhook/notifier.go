@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	queueSize     = 64
+	postTimeout   = time.Second * 10
+	maxAttempts   = 5
+	maxRetryDelay = time.Minute
+)
+
+// Target is a webhook we post the events to
+type Target struct {
+	URL string
+	// Secret is the key of the hmac of the payloads, empty leaves them unsigned
+	Secret []byte
+	// Events are the kinds of the events the webhook gets, empty gets them all
+	Events []EventType
+}
+
+// wants return true if the webhook gets the events of the kind
+func (t Target) wants(eventType EventType) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, el := range t.Events {
+		if el == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifier posts the events to the webhooks in the background, each webhook has its own queue so a slow one
+// never holds up the others or the ceremonies
+type Notifier struct {
+	logger     zerolog.Logger
+	client     *http.Client
+	retryDelay time.Duration
+	targets    []*target
+	stopChan   chan struct{}
+	wg         *sync.WaitGroup
+}
+
+type target struct {
+	Target
+	events chan Event
+}
+
+// NewNotifier create a new instance of Notifier that posts the events to the given webhooks
+func NewNotifier(targets []Target) *Notifier {
+	n := &Notifier{
+		logger:     log.With().Str("module", "webhook").Logger(),
+		client:     &http.Client{Timeout: postTimeout},
+		retryDelay: time.Second,
+		stopChan:   make(chan struct{}),
+		wg:         &sync.WaitGroup{},
+	}
+	for _, el := range targets {
+		t := &target{
+			Target: el,
+			events: make(chan Event, queueSize),
+		}
+		n.targets = append(n.targets, t)
+		n.wg.Add(1)
+		go n.process(t)
+	}
+	return n
+}
+
+// Notify queue the event to be posted to the webhooks that want it, the event is dropped for the webhooks whose
+// queue is full
+func (n *Notifier) Notify(ev Event) {
+	for _, el := range n.targets {
+		if !el.wants(ev.Type) {
+			continue
+		}
+		select {
+		case el.events <- ev:
+		default:
+			n.logger.Error().Str("url", el.URL).Str("id", ev.ID).Msg("the webhook queue is full, drop the event")
+		}
+	}
+}
+
+// Stop the notifier, the events still in the queues are dropped
+func (n *Notifier) Stop() {
+	close(n.stopChan)
+	n.wg.Wait()
+}
+
+func (n *Notifier) process(t *target) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.stopChan:
+			return
+		case ev := <-t.events:
+			if err := n.post(t, ev); err != nil {
+				n.logger.Error().Err(err).Str("url", t.URL).Str("id", ev.ID).Msgf("fail to post the %s event", ev.Type)
+			}
+		}
+	}
+}
+
+// post send the event to the webhook, it retries on the network errors and the server errors with an exponential
+// backoff
+func (n *Notifier) post(t *target, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("fail to marshal the event: %w", err)
+	}
+	delay := n.retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.send(t, ev, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == maxAttempts {
+			return err
+		}
+		n.logger.Warn().Err(err).Str("url", t.URL).Msgf("fail to post the %s event, retry in %s", ev.Type, delay)
+		select {
+		case <-n.stopChan:
+			return err
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// send post the body once, each attempt is signed with its own timestamp, it returns whether it is worth retrying
+// when it fails
+func (n *Notifier) send(t *target, ev Event, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("fail to create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, string(ev.Type))
+	req.Header.Set(EventIDHeader, ev.ID)
+	if len(t.Secret) > 0 {
+		timestamp := time.Now().Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, Sign(t.Secret, timestamp, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("fail to post the request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			n.logger.Error().Err(err).Msg("fail to close the response body")
+		}
+	}()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	// the request is rejected, sending it again will not help
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return false, err
+	}
+	return true, err
+}
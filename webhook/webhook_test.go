@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/akildemir/go-tss/blame"
+)
+
+func TestPackage(t *testing.T) { TestingT(t) }
+
+type WebhookTestSuite struct{}
+
+var _ = Suite(&WebhookTestSuite{})
+
+func (WebhookTestSuite) TestSignVerify(c *C) {
+	secret := []byte("secret")
+	body := []byte(`{"type":"keysign.failed"}`)
+	now := time.Now().Unix()
+	signature := Sign(secret, now, body)
+	timestamp := strconv.FormatInt(now, 10)
+	c.Assert(Verify(secret, timestamp, signature, body, time.Minute), IsNil)
+	c.Assert(Verify([]byte("other"), timestamp, signature, body, time.Minute), NotNil)
+	c.Assert(Verify(secret, timestamp, signature, []byte(`{}`), time.Minute), NotNil)
+	c.Assert(Verify(secret, strconv.FormatInt(now+1, 10), signature, body, time.Minute), NotNil)
+	c.Assert(Verify(secret, "abc", signature, body, time.Minute), NotNil)
+	c.Assert(Verify(secret, timestamp, signature[len(signaturePrefix):], body, time.Minute), NotNil)
+	// the old payloads are refused
+	old := now - 3600
+	c.Assert(Verify(secret, strconv.FormatInt(old, 10), Sign(secret, old, body), body, time.Minute), NotNil)
+	c.Assert(Verify(secret, strconv.FormatInt(old, 10), Sign(secret, old, body), body, 0), IsNil)
+}
+
+func (WebhookTestSuite) TestParseEventType(c *C) {
+	for _, el := range EventTypes {
+		eventType, err := ParseEventType(string(el))
+		c.Assert(err, IsNil)
+		c.Assert(eventType, Equals, el)
+	}
+	_, err := ParseEventType("keysign.done")
+	c.Assert(err, NotNil)
+}
+
+func (WebhookTestSuite) TestNotify(c *C) {
+	secret := []byte("secret")
+	var calls int32
+	received := make(chan Event, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first attempt fails, the notifier should retry
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		c.Check(err, IsNil)
+		c.Check(Verify(secret, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body, time.Minute), IsNil)
+		var ev Event
+		c.Check(json.Unmarshal(body, &ev), IsNil)
+		c.Check(r.Header.Get(EventTypeHeader), Equals, string(ev.Type))
+		c.Check(r.Header.Get(EventIDHeader), Equals, ev.ID)
+		received <- ev
+	}))
+	defer server.Close()
+	var otherCalls int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&otherCalls, 1)
+		c.Check(r.Header.Get(SignatureHeader), Equals, "")
+	}))
+	defer other.Close()
+
+	n := NewNotifier([]Target{
+		{URL: server.URL, Secret: secret},
+		// the other webhook only wants the blame
+		{URL: other.URL, Events: []EventType{BlameReported}},
+	})
+	n.retryDelay = time.Millisecond * 10
+	defer n.Stop()
+	ev := NewEvent(KeySignFailed, "msg-id", "pool", "reporter", "tss_timeout")
+	n.Notify(ev)
+	select {
+	case got := <-received:
+		c.Assert(got.ID, Equals, ev.ID)
+		c.Assert(got.Type, Equals, KeySignFailed)
+		c.Assert(got.ErrorCode, Equals, "tss_timeout")
+		c.Assert(got.SchemaVersion, Equals, EventSchemaVersion)
+	case <-time.After(time.Second * 5):
+		c.Fatal("fail to receive the event")
+	}
+	c.Assert(atomic.LoadInt32(&calls), Equals, int32(2))
+
+	blameEvent := NewBlameEvent(blame.NewEvent("keysign", "msg-id", "pool", "reporter", blame.NewBlame(blame.TssTimeout, nil)))
+	c.Assert(blameEvent.MsgID, Equals, "msg-id")
+	n.Notify(blameEvent)
+	select {
+	case got := <-received:
+		c.Assert(got.Type, Equals, BlameReported)
+		c.Assert(got.Blame, NotNil)
+		c.Assert(got.Blame.FailReason, Equals, blame.TssTimeout)
+	case <-time.After(time.Second * 5):
+		c.Fatal("fail to receive the blame event")
+	}
+	for i := 0; i < 100 && atomic.LoadInt32(&otherCalls) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	c.Assert(atomic.LoadInt32(&otherCalls), Equals, int32(1))
+}
+
+func (WebhookTestSuite) TestRejected(c *C) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	n := NewNotifier(nil)
+	n.retryDelay = time.Millisecond * 10
+	defer n.Stop()
+	t := &target{Target: Target{URL: server.URL}}
+	ev := NewEvent(KeygenCompleted, "msg-id", "pool", "reporter", "")
+	// the rejected event is not retried
+	retry, err := n.send(t, ev, []byte("{}"))
+	c.Assert(err, NotNil)
+	c.Assert(retry, Equals, false)
+	c.Assert(n.post(t, ev), NotNil)
+	c.Assert(atomic.LoadInt32(&calls), Equals, int32(2))
+}